@@ -0,0 +1,157 @@
+// Package auth implements the authorization part of the MCP specification,
+// which protects HTTP transports with OAuth 2.1 bearer tokens.
+//
+// On the server side, an MCP server is an OAuth resource server. It
+// publishes protected resource metadata (RFC 9728) naming the authorization
+// servers that issue its tokens, and Middleware rejects requests without a
+// valid token with a 401 whose WWW-Authenticate header points clients at
+// that metadata. JWKSValidator validates JWT access tokens against the keys
+// the authorization server publishes, fetching and caching them.
+//
+// On the client side, Transport is an http.RoundTripper that adds the
+// access token to requests. When the server answers 401, it discovers the
+// authorization server from the challenge, runs the authorization code flow
+// with PKCE and retries the request; expired tokens are refreshed.
+//
+// # Server Usage
+//
+//	validator := auth.NewJWKSValidator(auth.JWKSConfig{
+//	    URL:      "https://auth.example.com/.well-known/jwks.json",
+//	    Issuer:   "https://auth.example.com",
+//	    Audience: "https://mcp.example.com",
+//	})
+//	srv := server.NewServer("my-service",
+//	    server.WithOAuth(server.OAuthConfig{
+//	        Metadata: auth.ProtectedResourceMetadata{
+//	            Resource:             "https://mcp.example.com",
+//	            AuthorizationServers: []string{"https://auth.example.com"},
+//	        },
+//	        Validator:  validator,
+//	        ToolScopes: map[string][]string{"delete_file": {"files:write"}},
+//	    }),
+//	).AsStreamableHTTP(":8080")
+//
+// # Client Usage
+//
+//	oauth := auth.NewTransport(auth.ClientConfig{
+//	    ClientID:    "my-client",
+//	    RedirectURL: "http://127.0.0.1:8765/callback",
+//	    Authorize:   openBrowserAndWaitForRedirect,
+//	})
+//	c, err := client.NewClient("my-client",
+//	    client.WithStreamableHTTP("https://mcp.example.com/mcp",
+//	        streamablehttp.WithHTTPClient(oauth.Client())),
+//	)
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned by validators for tokens that are malformed,
+// badly signed, expired or issued for another audience.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Token is a validated access token.
+type Token struct {
+	// Subject identifies the user or service the token was issued to.
+	Subject string
+
+	// ClientID identifies the OAuth client the token was issued to.
+	ClientID string
+
+	Issuer    string
+	Audience  []string
+	Scopes    []string
+	ExpiresAt time.Time
+
+	// Claims holds all claims of the token.
+	Claims map[string]interface{}
+}
+
+// HasScopes reports whether the token grants all of scopes.
+func (t *Token) HasScopes(scopes ...string) bool {
+	return hasScopes(t.Scopes, scopes)
+}
+
+// Principal returns the caller the token identifies.
+func (t *Token) Principal() *Principal {
+	return &Principal{Subject: t.Subject, ClientID: t.ClientID, Scopes: t.Scopes}
+}
+
+// Principal is the caller a request is made on behalf of, which servers
+// check the required scopes of tools and resources against.
+type Principal struct {
+	// Subject identifies the user or service making the request.
+	Subject string
+
+	// ClientID identifies the application making the request.
+	ClientID string
+
+	// Scopes are the permissions granted to the caller.
+	Scopes []string
+}
+
+// HasScopes reports whether the principal was granted all of scopes. A nil
+// principal has no scopes.
+func (p *Principal) HasScopes(scopes ...string) bool {
+	if p == nil {
+		return len(scopes) == 0
+	}
+	return hasScopes(p.Scopes, scopes)
+}
+
+// TokenValidator validates bearer tokens.
+type TokenValidator interface {
+	// Validate returns the token if it is valid, or an error wrapping
+	// ErrInvalidToken if it isn't.
+	Validate(ctx context.Context, token string) (*Token, error)
+}
+
+// TokenValidatorFunc adapts a function to a TokenValidator, such as one
+// looking up opaque tokens with the authorization server.
+type TokenValidatorFunc func(ctx context.Context, token string) (*Token, error)
+
+// Validate implements TokenValidator.
+func (f TokenValidatorFunc) Validate(ctx context.Context, token string) (*Token, error) {
+	return f(ctx, token)
+}
+
+// tokenKey is the context key of the validated token.
+type tokenKey struct{}
+
+// ContextWithToken returns a context carrying a validated token.
+func ContextWithToken(ctx context.Context, token *Token) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// TokenFromContext returns the token Middleware validated for a request.
+func TokenFromContext(ctx context.Context) (*Token, bool) {
+	token, ok := ctx.Value(tokenKey{}).(*Token)
+	return token, ok
+}
+
+// hasScopes reports whether granted includes all of required.
+func hasScopes(granted, required []string) bool {
+	for _, scope := range required {
+		found := false
+		for _, g := range granted {
+			if g == scope {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// scopeList splits a space-separated scope string.
+func scopeList(scope string) []string {
+	return strings.Fields(scope)
+}
@@ -0,0 +1,372 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// issuer signs tokens with an RSA key published as a JWKS.
+type issuer struct {
+	key     *rsa.PrivateKey
+	fetches int32
+	server  *httptest.Server
+}
+
+func newIssuer(t *testing.T) *issuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := &issuer{key: key}
+	iss.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&iss.fetches, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "k1",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	t.Cleanup(iss.server.Close)
+	return iss
+}
+
+// sign returns a JWT with the given claims, signed with key ID kid.
+func (iss *issuer) sign(t *testing.T, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "at+jwt", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, iss.key, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (iss *issuer) claims(scope string, ttl time.Duration) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":       "https://auth.example.com",
+		"aud":       "https://mcp.example.com",
+		"sub":       "alice",
+		"client_id": "test-client",
+		"scope":     scope,
+		"exp":       time.Now().Add(ttl).Unix(),
+	}
+}
+
+func (iss *issuer) validator() *JWKSValidator {
+	return NewJWKSValidator(JWKSConfig{
+		URL:      iss.server.URL,
+		Issuer:   "https://auth.example.com",
+		Audience: "https://mcp.example.com",
+	})
+}
+
+func TestJWKSValidator(t *testing.T) {
+	iss := newIssuer(t)
+	validator := iss.validator()
+	ctx := context.Background()
+
+	token, err := validator.Validate(ctx, iss.sign(t, "k1", iss.claims("files:read files:write", time.Hour)))
+	if err != nil {
+		t.Fatalf("Expected a valid token, got %v", err)
+	}
+	if token.Subject != "alice" || token.ClientID != "test-client" || !token.HasScopes("files:read", "files:write") || token.HasScopes("admin") {
+		t.Errorf("Unexpected token %+v", token)
+	}
+
+	expired := iss.claims("", -time.Minute)
+	otherAudience := iss.claims("", time.Hour)
+	otherAudience["aud"] = []string{"https://other.example.com"}
+	noAudience := iss.claims("", time.Hour)
+	delete(noAudience, "aud")
+	tampered := iss.sign(t, "k1", iss.claims("", time.Hour))
+	tampered = tampered[:strings.LastIndex(tampered, ".")] + ".AAAA"
+	for name, raw := range map[string]string{
+		"expired":        iss.sign(t, "k1", expired),
+		"other audience": iss.sign(t, "k1", otherAudience),
+		"no audience":    iss.sign(t, "k1", noAudience),
+		"tampered":       tampered,
+		"unknown key":    iss.sign(t, "k2", iss.claims("", time.Hour)),
+		"not a JWT":      "opaque",
+	} {
+		if _, err := validator.Validate(ctx, raw); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+
+	// The unknown key caused at most one refetch, and the rest used the cache
+	if fetches := atomic.LoadInt32(&iss.fetches); fetches > 2 {
+		t.Errorf("Expected the key set to be cached, fetched %d times", fetches)
+	}
+}
+
+func TestJWKSValidatorRequiresAudience(t *testing.T) {
+	iss := newIssuer(t)
+	validator := NewJWKSValidator(JWKSConfig{URL: iss.server.URL, Issuer: "https://auth.example.com"})
+	token := iss.sign(t, "k1", iss.claims("", time.Hour))
+
+	if _, err := validator.Validate(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected tokens to be refused without an audience, got %v", err)
+	}
+	validator.DefaultAudience("https://mcp.example.com")
+	if _, err := validator.Validate(context.Background(), token); err != nil {
+		t.Errorf("Expected the token to be accepted for its audience, got %v", err)
+	}
+	validator.DefaultAudience("https://other.example.com")
+	if _, err := validator.Validate(context.Background(), token); err != nil {
+		t.Errorf("Expected the first audience to be kept, got %v", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	iss := newIssuer(t)
+	var seen *Token
+	handler := Middleware(MiddlewareConfig{
+		Validator: iss.validator(),
+		Scopes: func(method string, params json.RawMessage) []string {
+			if method == "tools/call" && strings.Contains(string(params), `"delete"`) {
+				return []string{"files:write"}
+			}
+			return nil
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = TokenFromContext(r.Context())
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+
+	call := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"delete"}}`
+	serve := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "http://mcp.example.com/mcp", strings.NewReader(call))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("")
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 without a token, got %d", rec.Code)
+	}
+	challenge, ok := ParseChallenge(rec.Header().Get("WWW-Authenticate"))
+	if !ok || challenge["resource_metadata"] != "http://mcp.example.com"+MetadataPath {
+		t.Errorf("Unexpected challenge %q", rec.Header().Get("WWW-Authenticate"))
+	}
+
+	rec = serve(iss.sign(t, "k1", iss.claims("files:read", time.Hour)))
+	challenge, _ = ParseChallenge(rec.Header().Get("WWW-Authenticate"))
+	if rec.Code != http.StatusForbidden || challenge["error"] != "insufficient_scope" || challenge["scope"] != "files:write" {
+		t.Errorf("Expected an insufficient_scope challenge, got %d %v", rec.Code, challenge)
+	}
+
+	rec = serve(iss.sign(t, "k1", iss.claims("files:write", time.Hour)))
+	if rec.Code != http.StatusOK || rec.Body.String() != call {
+		t.Errorf("Expected the request to pass with its body, got %d %q", rec.Code, rec.Body.String())
+	}
+	if seen == nil || seen.Subject != "alice" {
+		t.Errorf("Expected the token in the request context, got %+v", seen)
+	}
+}
+
+func TestTransportAuthorizationFlow(t *testing.T) {
+	iss := newIssuer(t)
+	var grants []string
+
+	// The authorization server checks PKCE and issues short-lived tokens
+	var challenge, authURL, mcpURL string
+	authMux := http.NewServeMux()
+	authMux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+			Issuer:                authURL,
+			AuthorizationEndpoint: authURL + "/authorize",
+			TokenEndpoint:         authURL + "/token",
+		})
+	})
+	authMux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		grant := r.PostForm.Get("grant_type")
+		grants = append(grants, grant)
+		switch grant {
+		case "authorization_code":
+			sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+			if r.PostForm.Get("code") != "code-1" || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+				return
+			}
+		case "refresh_token":
+			if r.PostForm.Get("refresh_token") != "refresh-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+				return
+			}
+		}
+		if r.PostForm.Get("resource") != mcpURL {
+			t.Errorf("Expected the token to be requested for the resource, got %q", r.PostForm.Get("resource"))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  iss.sign(t, "k1", iss.claims("files:read", time.Hour)),
+			"token_type":    "Bearer",
+			"expires_in":    3600,
+			"refresh_token": "refresh-1",
+			"scope":         "files:read",
+		})
+	})
+	authServer := httptest.NewServer(authMux)
+	defer authServer.Close()
+	authURL = authServer.URL
+
+	mcpMux := http.NewServeMux()
+	mcpMux.HandleFunc(MetadataPath, func(w http.ResponseWriter, r *http.Request) {
+		MetadataHandler(ProtectedResourceMetadata{
+			Resource:             mcpURL,
+			AuthorizationServers: []string{authServer.URL},
+		}).ServeHTTP(w, r)
+	})
+	mcpMux.Handle("/mcp", Middleware(MiddlewareConfig{Validator: iss.validator()})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, _ := TokenFromContext(r.Context())
+			io.WriteString(w, token.Subject)
+		})))
+	mcpServer := httptest.NewServer(mcpMux)
+	defer mcpServer.Close()
+	mcpURL = mcpServer.URL
+
+	authorizations := 0
+	oauth := NewTransport(ClientConfig{
+		ClientID:    "test-client",
+		RedirectURL: "http://127.0.0.1/callback",
+		Authorize: func(ctx context.Context, authorizationURL string) (url.Values, error) {
+			authorizations++
+			u, _ := url.Parse(authorizationURL)
+			query := u.Query()
+			if query.Get("code_challenge_method") != "S256" || query.Get("resource") != mcpURL {
+				t.Errorf("Unexpected authorization URL %s", authorizationURL)
+			}
+			challenge = query.Get("code_challenge")
+			return url.Values{"code": {"code-1"}, "state": {query.Get("state")}}, nil
+		},
+	})
+	client := oauth.Client()
+
+	post := func() string {
+		t.Helper()
+		resp, err := client.Post(mcpServer.URL+"/mcp", "application/json", strings.NewReader(`{}`))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected 200, got %d %s", resp.StatusCode, body)
+		}
+		return string(body)
+	}
+
+	if subject := post(); subject != "alice" {
+		t.Errorf("Expected the request to be authorized, got %q", subject)
+	}
+	post()
+	if authorizations != 1 {
+		t.Errorf("Expected one authorization, got %d", authorizations)
+	}
+
+	// An expired token is refreshed without asking the user again
+	oauth.Token().Expiry = time.Now().Add(-time.Minute)
+	post()
+	if authorizations != 1 || len(grants) != 2 || grants[1] != "refresh_token" {
+		t.Errorf("Expected the token to be refreshed, got grants %v", grants)
+	}
+}
+
+func TestTransportRejectsForeignMetadata(t *testing.T) {
+	var authURL, issuer string
+	authMux := http.NewServeMux()
+	authMux.HandleFunc("/.well-known/oauth-authorization-server", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(AuthorizationServerMetadata{
+			Issuer:                issuer,
+			AuthorizationEndpoint: authURL + "/authorize",
+			TokenEndpoint:         authURL + "/token",
+		})
+	})
+	authServer := httptest.NewServer(authMux)
+	defer authServer.Close()
+	authURL = authServer.URL
+
+	var resource string
+	mcpMux := http.NewServeMux()
+	mcpMux.HandleFunc(MetadataPath, func(w http.ResponseWriter, r *http.Request) {
+		MetadataHandler(ProtectedResourceMetadata{
+			Resource:             resource,
+			AuthorizationServers: []string{authServer.URL},
+		}).ServeHTTP(w, r)
+	})
+	mcpMux.HandleFunc("/mcp", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer resource_metadata="http://`+r.Host+MetadataPath+`"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	mcpServer := httptest.NewServer(mcpMux)
+	defer mcpServer.Close()
+
+	for name, metadata := range map[string][2]string{
+		"another server's resource":    {"https://bank.example.com", authURL},
+		"another path on the server":   {mcpServer.URL + "/other", authURL},
+		"another authorization server": {mcpServer.URL, "https://evil.example.com"},
+	} {
+		resource, issuer = metadata[0], metadata[1]
+		authorized := false
+		client := NewTransport(ClientConfig{
+			ClientID:    "test-client",
+			RedirectURL: "http://127.0.0.1/callback",
+			Authorize: func(ctx context.Context, authorizationURL string) (url.Values, error) {
+				authorized = true
+				return nil, errors.New("unexpected authorization")
+			},
+		}).Client()
+		resp, err := client.Post(mcpServer.URL+"/mcp", "application/json", strings.NewReader(`{}`))
+		if err == nil {
+			resp.Body.Close()
+		}
+		if !errors.Is(err, ErrAuthorizationFailed) || authorized {
+			t.Errorf("%s: expected the metadata to be rejected before authorizing, got %v", name, err)
+		}
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	params, ok := ParseChallenge(`Bearer resource_metadata="https://mcp.example.com/.well-known/oauth-protected-resource", error="insufficient_scope", scope="a b", realm=mcp`)
+	if !ok {
+		t.Fatal("Expected a Bearer challenge")
+	}
+	if params["error"] != "insufficient_scope" || params["scope"] != "a b" || params["realm"] != "mcp" ||
+		params["resource_metadata"] != "https://mcp.example.com/.well-known/oauth-protected-resource" {
+		t.Errorf("Unexpected params %v", params)
+	}
+	if _, ok := ParseChallenge(`Basic realm="x"`); ok {
+		t.Error("Expected other schemes to be rejected")
+	}
+}
@@ -0,0 +1,506 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrAuthorizationFailed is returned by Transport when it can't get a token
+// the server accepts.
+var ErrAuthorizationFailed = errors.New("authorization failed")
+
+// AuthorizeFunc sends the user to an authorization URL, usually by opening
+// a browser, and returns the query parameters of the redirect back to the
+// client's redirect URL, which carry the authorization code and state.
+type AuthorizeFunc func(ctx context.Context, authorizationURL string) (url.Values, error)
+
+// ClientConfig configures a Transport.
+type ClientConfig struct {
+	// ClientID is the client's ID with the authorization server.
+	ClientID string
+
+	// ClientSecret authenticates confidential clients. Public clients,
+	// such as desktop applications, leave it empty and rely on PKCE.
+	ClientSecret string
+
+	// RedirectURL is where the authorization server sends the user back.
+	RedirectURL string
+
+	// Scopes are requested in addition to those a server's challenge names.
+	Scopes []string
+
+	// Authorize gets the user's consent. Without it, the transport can
+	// only use and refresh Token.
+	Authorize AuthorizeFunc
+
+	// Token is a previously obtained token to start with.
+	Token *OAuthToken
+
+	// OnToken is called with each new token, so it can be saved.
+	OnToken func(*OAuthToken)
+
+	// HTTPClient fetches metadata and tokens. It defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// OAuthToken is a token obtained from an authorization server.
+type OAuthToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+	Scope        string    `json:"scope,omitempty"`
+
+	// TokenURL and Resource are where the token was issued and for what
+	// server, used to refresh it.
+	TokenURL string `json:"token_url,omitempty"`
+	Resource string `json:"resource,omitempty"`
+}
+
+// expired reports whether the token has expired or is about to.
+func (t *OAuthToken) expired() bool {
+	return !t.Expiry.IsZero() && time.Now().Add(10*time.Second).After(t.Expiry)
+}
+
+// AuthorizationServerMetadata is the metadata of an authorization server
+// (RFC 8414).
+type AuthorizationServerMetadata struct {
+	Issuer                        string   `json:"issuer"`
+	AuthorizationEndpoint         string   `json:"authorization_endpoint"`
+	TokenEndpoint                 string   `json:"token_endpoint"`
+	RegistrationEndpoint          string   `json:"registration_endpoint,omitempty"`
+	ScopesSupported               []string `json:"scopes_supported,omitempty"`
+	CodeChallengeMethodsSupported []string `json:"code_challenge_methods_supported,omitempty"`
+}
+
+// Transport is an http.RoundTripper that authorizes requests to an MCP
+// server with OAuth 2.1. It sends its access token with each request,
+// refreshes the token before it expires, and when the server answers 401 or
+// 403 with a Bearer challenge, it discovers the authorization server from
+// the server's protected resource metadata, gets a new token with the
+// authorization code flow and PKCE, and retries the request once.
+type Transport struct {
+	// Base sends the requests. It defaults to http.DefaultTransport.
+	Base http.RoundTripper
+
+	config ClientConfig
+
+	mu    sync.Mutex
+	token *OAuthToken
+}
+
+// NewTransport creates a transport authorizing requests as set by config.
+func NewTransport(config ClientConfig) *Transport {
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return &Transport{config: config, token: config.Token}
+}
+
+// Client returns an HTTP client sending requests through the transport.
+func (t *Transport) Client() *http.Client {
+	return &http.Client{Transport: t}
+}
+
+// Token returns the current token, or nil if there is none yet.
+func (t *Transport) Token() *OAuthToken {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.token
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The body may have to be sent twice
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := t.currentToken(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.send(req, body, token)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden {
+		return resp, nil
+	}
+	challenge, ok := ParseChallenge(resp.Header.Get("WWW-Authenticate"))
+	if !ok || (resp.StatusCode == http.StatusForbidden && challenge["error"] != "insufficient_scope") {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	token, err = t.authorize(req.Context(), req.URL, challenge)
+	if err != nil {
+		return nil, err
+	}
+	return t.send(req, body, token)
+}
+
+// send sends a copy of req with the token.
+func (t *Transport) send(req *http.Request, body []byte, token *OAuthToken) (*http.Response, error) {
+	clone := req.Clone(req.Context())
+	if body != nil {
+		clone.Body = io.NopCloser(bytes.NewReader(body))
+		clone.ContentLength = int64(len(body))
+	}
+	if token != nil {
+		clone.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(clone)
+}
+
+// currentToken returns the token to send, refreshing it if it expired.
+func (t *Transport) currentToken(ctx context.Context) (*OAuthToken, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.token == nil || !t.token.expired() {
+		return t.token, nil
+	}
+	if t.token.RefreshToken == "" || t.token.TokenURL == "" {
+		// Let the server's challenge start a new authorization
+		return nil, nil
+	}
+	refreshed, err := t.refresh(ctx, t.token)
+	if err != nil {
+		return nil, nil
+	}
+	return refreshed, nil
+}
+
+// authorize gets a token after the server rejected the current one: by
+// refreshing it if the server only found it invalid, or by sending the user
+// through the authorization code flow.
+func (t *Transport) authorize(ctx context.Context, serverURL *url.URL, challenge map[string]string) (*OAuthToken, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != nil && t.token.RefreshToken != "" && t.token.TokenURL != "" && challenge["error"] != "insufficient_scope" {
+		if token, err := t.refresh(ctx, t.token); err == nil {
+			return token, nil
+		}
+	}
+	if t.config.Authorize == nil {
+		return nil, fmt.Errorf("%w: the server requires authorization and no Authorize function is set", ErrAuthorizationFailed)
+	}
+
+	resource, server, err := t.discover(ctx, serverURL, challenge)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAuthorizationFailed, err)
+	}
+
+	scopes := append([]string(nil), t.config.Scopes...)
+	scopes = append(scopes, scopeList(challenge["scope"])...)
+	if t.token != nil && challenge["error"] == "insufficient_scope" {
+		// Keep the scopes already granted
+		scopes = append(scopes, scopeList(t.token.Scope)...)
+	}
+
+	verifier, challengeValue := newPKCE()
+	state := randomString(16)
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {t.config.ClientID},
+		"redirect_uri":          {t.config.RedirectURL},
+		"code_challenge":        {challengeValue},
+		"code_challenge_method": {"S256"},
+		"state":                 {state},
+		"resource":              {resource},
+	}
+	if len(scopes) > 0 {
+		query.Set("scope", strings.Join(uniqueStrings(scopes), " "))
+	}
+	authorizationURL := server.AuthorizationEndpoint
+	if strings.Contains(authorizationURL, "?") {
+		authorizationURL += "&" + query.Encode()
+	} else {
+		authorizationURL += "?" + query.Encode()
+	}
+
+	callback, err := t.config.Authorize(ctx, authorizationURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAuthorizationFailed, err)
+	}
+	if callback.Get("state") != state {
+		return nil, fmt.Errorf("%w: state mismatch in the redirect", ErrAuthorizationFailed)
+	}
+	if e := callback.Get("error"); e != "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrAuthorizationFailed, e, callback.Get("error_description"))
+	}
+
+	return t.requestToken(ctx, server.TokenEndpoint, resource, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {callback.Get("code")},
+		"redirect_uri":  {t.config.RedirectURL},
+		"code_verifier": {verifier},
+	})
+}
+
+// refresh exchanges a refresh token for a new token. t.mu must be held.
+func (t *Transport) refresh(ctx context.Context, token *OAuthToken) (*OAuthToken, error) {
+	refreshed, err := t.requestToken(ctx, token.TokenURL, token.Resource, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {token.RefreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if refreshed.RefreshToken == "" {
+		// Servers that don't rotate refresh tokens keep the old one valid
+		refreshed.RefreshToken = token.RefreshToken
+	}
+	return refreshed, nil
+}
+
+// requestToken requests a token from the token endpoint and makes it
+// current. t.mu must be held.
+func (t *Transport) requestToken(ctx context.Context, tokenURL, resource string, form url.Values) (*OAuthToken, error) {
+	form.Set("resource", resource)
+	if t.config.ClientSecret == "" {
+		form.Set("client_id", t.config.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if t.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(t.config.ClientID), url.QueryEscape(t.config.ClientSecret))
+	}
+
+	resp, err := t.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: token request failed: %v", ErrAuthorizationFailed, err)
+	}
+	defer resp.Body.Close()
+	var response struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		RefreshToken     string `json:"refresh_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Scope            string `json:"scope"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&response); err != nil {
+		return nil, fmt.Errorf("%w: invalid token response: %v", ErrAuthorizationFailed, err)
+	}
+	if resp.StatusCode != http.StatusOK || response.AccessToken == "" {
+		return nil, fmt.Errorf("%w: token request rejected: %s %s", ErrAuthorizationFailed, response.Error, response.ErrorDescription)
+	}
+	if !strings.EqualFold(response.TokenType, "Bearer") {
+		return nil, fmt.Errorf("%w: unsupported token type %q", ErrAuthorizationFailed, response.TokenType)
+	}
+
+	token := &OAuthToken{
+		AccessToken:  response.AccessToken,
+		RefreshToken: response.RefreshToken,
+		Scope:        response.Scope,
+		TokenURL:     tokenURL,
+		Resource:     resource,
+	}
+	if response.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(response.ExpiresIn) * time.Second)
+	}
+	t.token = token
+	if t.config.OnToken != nil {
+		t.config.OnToken(token)
+	}
+	return token, nil
+}
+
+// discover finds the resource URI and authorization server of an MCP
+// server from its protected resource metadata.
+func (t *Transport) discover(ctx context.Context, serverURL *url.URL, challenge map[string]string) (string, *AuthorizationServerMetadata, error) {
+	metadataURL := challenge["resource_metadata"]
+	if metadataURL == "" {
+		metadataURL = (&url.URL{Scheme: serverURL.Scheme, Host: serverURL.Host, Path: MetadataPath}).String()
+	}
+	var resource ProtectedResourceMetadata
+	if err := getJSON(ctx, t.config.HTTPClient, metadataURL, &resource); err != nil {
+		return "", nil, fmt.Errorf("failed to fetch resource metadata: %w", err)
+	}
+	if len(resource.AuthorizationServers) == 0 {
+		return "", nil, errors.New("resource metadata names no authorization server")
+	}
+	if resource.Resource == "" {
+		resource.Resource = (&url.URL{Scheme: serverURL.Scheme, Host: serverURL.Host}).String()
+	}
+	// A server naming another server's resource would be sent tokens for
+	// it (RFC 9728, section 3.3)
+	if !servesResource(serverURL, resource.Resource) {
+		return "", nil, fmt.Errorf("%w: resource metadata names resource %q, not the server at %s",
+			ErrAuthorizationFailed, resource.Resource, serverURL.Redacted())
+	}
+	server, err := FetchAuthorizationServerMetadata(ctx, t.config.HTTPClient, resource.AuthorizationServers[0])
+	if err != nil {
+		return "", nil, err
+	}
+	return resource.Resource, server, nil
+}
+
+// servesResource reports whether the resource URI identifies the server
+// at serverURL: it has the server's origin, and its path is the server's or
+// a parent of it.
+func servesResource(serverURL *url.URL, resource string) bool {
+	u, err := url.Parse(resource)
+	if err != nil || u.RawQuery != "" || u.Fragment != "" {
+		return false
+	}
+	if !strings.EqualFold(u.Scheme, serverURL.Scheme) || !strings.EqualFold(u.Host, serverURL.Host) {
+		return false
+	}
+	prefix := strings.TrimSuffix(u.Path, "/")
+	path := strings.TrimSuffix(serverURL.Path, "/")
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// FetchAuthorizationServerMetadata fetches the metadata of the
+// authorization server with the given issuer URL, from its OAuth metadata
+// (RFC 8414) or, failing that, its OpenID Connect discovery document.
+// Metadata whose issuer isn't the given one is rejected.
+func FetchAuthorizationServerMetadata(ctx context.Context, client *http.Client, issuer string) (*AuthorizationServerMetadata, error) {
+	u, err := url.Parse(issuer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid issuer %q: %w", issuer, err)
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	candidates := []string{
+		// RFC 8414 inserts the well-known path before the issuer's path
+		(&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/.well-known/oauth-authorization-server" + path}).String(),
+		(&url.URL{Scheme: u.Scheme, Host: u.Host, Path: path + "/.well-known/openid-configuration"}).String(),
+	}
+
+	var lastErr error
+	for _, candidate := range candidates {
+		var metadata AuthorizationServerMetadata
+		if err := getJSON(ctx, client, candidate, &metadata); err != nil {
+			lastErr = err
+			continue
+		}
+		if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" {
+			lastErr = fmt.Errorf("metadata at %s lacks endpoints", candidate)
+			continue
+		}
+		// Metadata for another issuer could send the user's credentials
+		// elsewhere (RFC 8414, section 3.3)
+		if metadata.Issuer != issuer {
+			return nil, fmt.Errorf("%w: metadata at %s is for issuer %q, not %q",
+				ErrAuthorizationFailed, candidate, metadata.Issuer, issuer)
+		}
+		return &metadata, nil
+	}
+	return nil, fmt.Errorf("failed to fetch authorization server metadata: %w", lastErr)
+}
+
+// ParseChallenge parses a Bearer challenge of a WWW-Authenticate header
+// into its parameters. It reports false for other schemes.
+func ParseChallenge(header string) (map[string]string, bool) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for rest = strings.TrimSpace(rest); rest != ""; {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			// Quoted string with backslash escapes
+			var b strings.Builder
+			i := 1
+			for ; i < len(value) && value[i] != '"'; i++ {
+				if value[i] == '\\' && i+1 < len(value) {
+					i++
+				}
+				b.WriteByte(value[i])
+			}
+			params[key] = b.String()
+			rest = value[min(i+1, len(value)):]
+		} else {
+			token, remainder, _ := strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(token)
+			rest = "," + remainder
+		}
+		rest = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(rest), ","))
+	}
+	return params, true
+}
+
+// getJSON fetches a JSON document.
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// newPKCE returns a PKCE code verifier and its S256 challenge (RFC 7636).
+func newPKCE() (verifier, challenge string) {
+	verifier = randomString(32)
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// randomString returns n random bytes encoded as base64url.
+func randomString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand does not fail on supported platforms
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// uniqueStrings removes repeated values, keeping the first of each.
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := values[:0]
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
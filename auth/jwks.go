@@ -0,0 +1,400 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultJWKSCacheTTL is how long JWKSValidator keeps fetched keys unless
+// JWKSConfig.CacheTTL is set.
+const DefaultJWKSCacheTTL = time.Hour
+
+// jwksRefreshInterval is the minimum time between fetches caused by tokens
+// signed with unknown keys, so forged key IDs can't flood the JWKS endpoint.
+const jwksRefreshInterval = 30 * time.Second
+
+// JWKSConfig configures a JWKSValidator.
+type JWKSConfig struct {
+	// URL is the authorization server's JSON Web Key Set endpoint.
+	URL string
+
+	// Issuer is the required iss claim. Empty accepts any issuer.
+	Issuer string
+
+	// Audience is required in the aud claim, usually the server's resource
+	// URI, so that tokens issued for other resources are refused. Every
+	// token is refused until it is set; server.WithOAuth sets it to the
+	// resource of its metadata when it is empty.
+	Audience string
+
+	// CacheTTL is how long fetched keys are used before fetching them
+	// again. Keys are also fetched when a token names an unknown key.
+	CacheTTL time.Duration
+
+	// Leeway is the clock skew allowed when checking exp and nbf.
+	Leeway time.Duration
+
+	// HTTPClient fetches the key set. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// JWKSValidator validates JWT access tokens signed with the keys of a JSON
+// Web Key Set. It supports the RS256, RS384, RS512, PS256, PS384, PS512,
+// ES256, ES384, ES512 and EdDSA algorithms.
+type JWKSValidator struct {
+	config JWKSConfig
+	now    func() time.Time
+
+	mu      sync.Mutex
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+}
+
+// NewJWKSValidator creates a validator for tokens signed with the keys at
+// config.URL.
+func NewJWKSValidator(config JWKSConfig) *JWKSValidator {
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = DefaultJWKSCacheTTL
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return &JWKSValidator{config: config, now: time.Now}
+}
+
+// DefaultAudience sets the audience tokens must be issued for, unless
+// JWKSConfig.Audience set one.
+func (v *JWKSValidator) DefaultAudience(audience string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.config.Audience == "" {
+		v.config.Audience = audience
+	}
+}
+
+// Validate implements TokenValidator.
+func (v *JWKSValidator) Validate(ctx context.Context, raw string) (*Token, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: not a JWT", ErrInvalidToken)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: bad header: %v", ErrInvalidToken, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad signature encoding", ErrInvalidToken)
+	}
+
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: bad claims: %v", ErrInvalidToken, err)
+	}
+	token := tokenFromClaims(claims)
+	if err := v.checkClaims(token, claims); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// checkClaims checks the registered claims of a token.
+func (v *JWKSValidator) checkClaims(token *Token, claims map[string]interface{}) error {
+	now := v.now()
+	if token.ExpiresAt.IsZero() {
+		return fmt.Errorf("%w: no expiry", ErrInvalidToken)
+	}
+	if now.After(token.ExpiresAt.Add(v.config.Leeway)) {
+		return fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.config.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+	if v.config.Issuer != "" && token.Issuer != v.config.Issuer {
+		return fmt.Errorf("%w: issued by %q", ErrInvalidToken, token.Issuer)
+	}
+	v.mu.Lock()
+	required := v.config.Audience
+	v.mu.Unlock()
+	if required == "" {
+		return fmt.Errorf("%w: no audience configured", ErrInvalidToken)
+	}
+	for _, audience := range token.Audience {
+		if audience == required {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: not issued for %s", ErrInvalidToken, required)
+}
+
+// tokenFromClaims reads the claims of a JWT access token (RFC 9068).
+func tokenFromClaims(claims map[string]interface{}) *Token {
+	token := &Token{Claims: claims}
+	token.Subject, _ = claims["sub"].(string)
+	token.Issuer, _ = claims["iss"].(string)
+	token.ClientID, _ = claims["client_id"].(string)
+	if token.ClientID == "" {
+		token.ClientID, _ = claims["azp"].(string)
+	}
+	switch aud := claims["aud"].(type) {
+	case string:
+		token.Audience = []string{aud}
+	case []interface{}:
+		for _, value := range aud {
+			if s, ok := value.(string); ok {
+				token.Audience = append(token.Audience, s)
+			}
+		}
+	}
+	if scope, ok := claims["scope"].(string); ok {
+		token.Scopes = scopeList(scope)
+	} else if scopes, ok := claims["scp"].([]interface{}); ok {
+		for _, value := range scopes {
+			if s, ok := value.(string); ok {
+				token.Scopes = append(token.Scopes, s)
+			}
+		}
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		token.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	return token
+}
+
+// key returns the key with the given ID, fetching the key set when it is
+// stale or doesn't have the key.
+func (v *JWKSValidator) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	age := v.now().Sub(v.fetched)
+	key, found := v.lookup(kid)
+	if v.keys == nil || age > v.config.CacheTTL || (!found && age > jwksRefreshInterval) {
+		keys, err := v.fetch(ctx)
+		if err != nil {
+			if found {
+				// Keep using the cached key while the endpoint is down
+				return key, nil
+			}
+			return nil, err
+		}
+		v.keys, v.fetched = keys, v.now()
+		key, found = v.lookup(kid)
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+	}
+	return key, nil
+}
+
+// lookup finds a cached key. Tokens without a key ID match a set with a
+// single key.
+func (v *JWKSValidator) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+// fetch downloads and parses the key set.
+func (v *JWKSValidator) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.config.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, raw := range set.Keys {
+		kid, key, err := parseJWK(raw)
+		if err != nil {
+			// Skip keys of types we don't support
+			continue
+		}
+		keys[kid] = key
+	}
+	return keys, nil
+}
+
+// parseJWK parses a public JSON Web Key.
+func parseJWK(raw json.RawMessage) (string, crypto.PublicKey, error) {
+	var jwk struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		Crv string `json:"crv"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+	if err := json.Unmarshal(raw, &jwk); err != nil {
+		return "", nil, err
+	}
+	if jwk.Use != "" && jwk.Use != "sig" {
+		return "", nil, fmt.Errorf("key %q is not for signatures", jwk.Kid)
+	}
+
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return "", nil, err
+		}
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return "", nil, err
+		}
+		return jwk.Kid, &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return "", nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return "", nil, err
+		}
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return "", nil, err
+		}
+		return jwk.Kid, &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if jwk.Crv != "Ed25519" {
+			return "", nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return "", nil, fmt.Errorf("invalid Ed25519 key")
+		}
+		return jwk.Kid, ed25519.PublicKey(x), nil
+	}
+	return "", nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+}
+
+// verifySignature checks a JWS signature made with alg.
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	var hash crypto.Hash
+	switch {
+	case strings.HasSuffix(alg, "256"):
+		hash = crypto.SHA256
+	case strings.HasSuffix(alg, "384"):
+		hash = crypto.SHA384
+	case strings.HasSuffix(alg, "512"):
+		hash = crypto.SHA512
+	}
+
+	switch {
+	case strings.HasPrefix(alg, "RS") && hash != 0:
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key does not match algorithm %s", alg)
+		}
+		return rsa.VerifyPKCS1v15(rsaKey, hash, digest(hash, signed), signature)
+	case strings.HasPrefix(alg, "PS") && hash != 0:
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key does not match algorithm %s", alg)
+		}
+		return rsa.VerifyPSS(rsaKey, hash, digest(hash, signed), signature, nil)
+	case strings.HasPrefix(alg, "ES") && hash != 0:
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("key does not match algorithm %s", alg)
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("bad signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest(hash, signed), r, s) {
+			return fmt.Errorf("bad signature")
+		}
+		return nil
+	case alg == "EdDSA":
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("key does not match algorithm %s", alg)
+		}
+		if !ed25519.Verify(edKey, signed, signature) {
+			return fmt.Errorf("bad signature")
+		}
+		return nil
+	}
+	// Includes "none"
+	return fmt.Errorf("unsupported algorithm %q", alg)
+}
+
+// digest hashes data with hash.
+func digest(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// decodeSegment decodes a base64url-encoded JSON segment of a JWT.
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// decodeBigInt decodes a base64url-encoded big-endian integer.
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/localrivet/gomcp/transport"
+)
+
+// MetadataPath is where servers publish their protected resource metadata.
+const MetadataPath = "/.well-known/oauth-protected-resource"
+
+// The _meta keys Middleware sets on the requests of an authorized HTTP
+// request, which server handlers read from the request's metadata.
+const (
+	// MetaSubject carries the token's subject.
+	MetaSubject = "authSubject"
+
+	// MetaClientID carries the OAuth client the token was issued to.
+	MetaClientID = "authClientId"
+
+	// MetaScopes carries the token's scopes, separated by spaces.
+	MetaScopes = "authScopes"
+)
+
+// ProtectedResourceMetadata describes an MCP server as an OAuth protected
+// resource (RFC 9728), telling clients where to get tokens for it.
+type ProtectedResourceMetadata struct {
+	// Resource is the server's resource URI, which tokens must be issued
+	// for, such as "https://mcp.example.com".
+	Resource string `json:"resource"`
+
+	// AuthorizationServers lists the issuers of tokens for the server.
+	AuthorizationServers []string `json:"authorization_servers"`
+
+	ScopesSupported        []string `json:"scopes_supported,omitempty"`
+	BearerMethodsSupported []string `json:"bearer_methods_supported,omitempty"`
+	ResourceName           string   `json:"resource_name,omitempty"`
+	ResourceDocumentation  string   `json:"resource_documentation,omitempty"`
+}
+
+// MetadataHandler serves metadata as JSON, for mounting at MetadataPath.
+func MetadataHandler(metadata ProtectedResourceMetadata) http.Handler {
+	if len(metadata.BearerMethodsSupported) == 0 {
+		metadata.BearerMethodsSupported = []string{"header"}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		// Browser-based clients discover the metadata across origins
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		json.NewEncoder(w).Encode(metadata)
+	})
+}
+
+// MiddlewareConfig configures Middleware.
+type MiddlewareConfig struct {
+	// Validator validates the bearer tokens of requests.
+	Validator TokenValidator
+
+	// MetadataURL is the URL of the protected resource metadata named in
+	// challenges. It defaults to MetadataPath on the request's host.
+	MetadataURL string
+
+	// Scopes returns the scopes a JSON-RPC request needs, from its method
+	// and params. Requests whose token lacks them are answered 403 with an
+	// insufficient_scope challenge naming them, so clients can ask for
+	// them. Nil requires no scopes beyond a valid token.
+	Scopes func(method string, params json.RawMessage) []string
+}
+
+// Middleware protects an MCP endpoint with bearer tokens. Requests without
+// a valid token are answered 401 with a WWW-Authenticate challenge that
+// points clients at the protected resource metadata. Authorized requests
+// carry the token in their context, for TokenFromContext, and its subject,
+// client and scopes in the _meta of their JSON-RPC messages, for server
+// handlers.
+func Middleware(config MiddlewareConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Let browsers' CORS preflights through
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			metadataURL := config.MetadataURL
+			if metadataURL == "" {
+				metadataURL = requestMetadataURL(r)
+			}
+
+			raw, ok := bearerToken(r)
+			if !ok {
+				writeChallenge(w, http.StatusUnauthorized, metadataURL, nil)
+				return
+			}
+			token, err := config.Validator.Validate(r.Context(), raw)
+			if err != nil {
+				if !errors.Is(err, ErrInvalidToken) {
+					http.Error(w, "Token validation failed", http.StatusServiceUnavailable)
+					return
+				}
+				writeChallenge(w, http.StatusUnauthorized, metadataURL, map[string]string{
+					"error":             "invalid_token",
+					"error_description": err.Error(),
+				})
+				return
+			}
+
+			if config.Scopes != nil && r.Method == http.MethodPost {
+				missing, err := missingScopes(r, token, config.Scopes)
+				if err != nil {
+					http.Error(w, "Failed to read request body", http.StatusBadRequest)
+					return
+				}
+				if len(missing) > 0 {
+					writeChallenge(w, http.StatusForbidden, metadataURL, map[string]string{
+						"error":             "insufficient_scope",
+						"scope":             strings.Join(missing, " "),
+						"error_description": "The request requires more scopes than the token grants",
+					})
+					return
+				}
+			}
+
+			ctx := ContextWithToken(r.Context(), token)
+			ctx = transport.ContextWithMeta(ctx, map[string]string{
+				MetaSubject:  token.Subject,
+				MetaClientID: token.ClientID,
+				MetaScopes:   strings.Join(token.Scopes, " "),
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// bearerToken returns the token of an Authorization header. Tokens in
+// query strings aren't accepted, as OAuth 2.1 requires.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// missingScopes returns the scopes the JSON-RPC messages in a request body
+// need that the token doesn't grant. The body is left readable.
+func missingScopes(r *http.Request, token *Token, scopes func(string, json.RawMessage) []string) ([]string, error) {
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	type message struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	var messages []message
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		json.Unmarshal(trimmed, &messages)
+	} else {
+		var single message
+		if json.Unmarshal(trimmed, &single) == nil {
+			messages = append(messages, single)
+		}
+	}
+
+	var missing []string
+	seen := make(map[string]bool)
+	for _, msg := range messages {
+		if msg.Method == "" {
+			continue
+		}
+		for _, scope := range scopes(msg.Method, msg.Params) {
+			if !seen[scope] && !token.HasScopes(scope) {
+				seen[scope] = true
+				missing = append(missing, scope)
+			}
+		}
+	}
+	return missing, nil
+}
+
+// writeChallenge answers a request with a Bearer challenge (RFC 6750).
+func writeChallenge(w http.ResponseWriter, status int, metadataURL string, params map[string]string) {
+	challenge := fmt.Sprintf("Bearer resource_metadata=%q", metadataURL)
+	for _, key := range []string{"error", "scope", "error_description"} {
+		if value, ok := params[key]; ok {
+			challenge += fmt.Sprintf(", %s=%q", key, value)
+		}
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, http.StatusText(status), status)
+}
+
+// requestMetadataURL returns the metadata URL on the host a request was
+// sent to.
+func requestMetadataURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	} else if proto := r.Header.Get("X-Forwarded-Proto"); proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host + MetadataPath
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Request is one message of a batch sent with CallBatch.
+type Request struct {
+	// Method is the JSON-RPC method, such as "tools/call".
+	Method string
+
+	// Params are the method's parameters, or nil for none.
+	Params interface{}
+
+	// Notification sends the message without an ID. The server doesn't
+	// answer it, and its BatchResult is always empty.
+	Notification bool
+}
+
+// BatchResult is the outcome of one Request of a batch.
+type BatchResult struct {
+	// Result is the result the server returned.
+	Result interface{}
+
+	// Err is the error the server returned for the request, or an error
+	// if it returned none.
+	Err error
+}
+
+// CallBatch sends requests to the server as a single JSON-RPC batch and
+// returns their results in the same order. The server may handle the
+// requests concurrently. The returned error reports a failure of the batch
+// as a whole; errors of individual requests are in their BatchResult.
+//
+// Example:
+//
+//	results, err := c.CallBatch([]client.Request{
+//	    {Method: "tools/call", Params: map[string]interface{}{"name": "weather", "arguments": oslo}},
+//	    {Method: "tools/call", Params: map[string]interface{}{"name": "weather", "arguments": lima}},
+//	})
+func (c *clientImpl) CallBatch(requests []Request) ([]BatchResult, error) {
+	if len(requests) == 0 {
+		return nil, errors.New("empty batch")
+	}
+
+	c.mu.RLock()
+	connected := c.connected
+	c.mu.RUnlock()
+	if !connected {
+		if err := c.Connect(); err != nil {
+			return nil, err
+		}
+	}
+
+	batch := make([]map[string]interface{}, len(requests))
+	positions := make(map[int64]int, len(requests))
+	for i, request := range requests {
+		message := map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  request.Method,
+		}
+		if request.Params != nil {
+			message["params"] = request.Params
+		}
+		if !request.Notification {
+			id := c.generateRequestID()
+			message["id"] = id
+			positions[id] = i
+		}
+		batch[i] = message
+	}
+
+	batchJSON, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, c.requestTimeout)
+	defer cancel()
+	responseJSON, err := c.transport.SendWithContext(ctx, batchJSON)
+	if err != nil {
+		return nil, c.handleConnectionLoss(&sendError{err: err})
+	}
+
+	results := make([]BatchResult, len(requests))
+	if len(positions) == 0 {
+		return results, nil
+	}
+
+	type response struct {
+		ID     *int64      `json:"id"`
+		Result interface{} `json:"result,omitempty"`
+		Error  *struct {
+			Code    int         `json:"code"`
+			Message string      `json:"message"`
+			Data    interface{} `json:"data,omitempty"`
+		} `json:"error,omitempty"`
+	}
+
+	var responses []response
+	if err := json.Unmarshal(responseJSON, &responses); err != nil {
+		// Servers that reject the batch whole answer with a single error
+		var single response
+		if json.Unmarshal(responseJSON, &single) == nil && single.Error != nil {
+			return nil, serverError(single.Error.Code, single.Error.Message, single.Error.Data)
+		}
+		return nil, fmt.Errorf("failed to parse batch response: %w", err)
+	}
+
+	answered := make(map[int]bool, len(responses))
+	for _, r := range responses {
+		if r.ID == nil {
+			continue
+		}
+		i, ok := positions[*r.ID]
+		if !ok {
+			continue
+		}
+		answered[i] = true
+		if r.Error != nil {
+			results[i].Err = serverError(r.Error.Code, r.Error.Message, r.Error.Data)
+			continue
+		}
+		results[i].Result = r.Result
+		c.upgradeLegacyResult(requests[i].Method, r.Result)
+	}
+	for id, i := range positions {
+		if !answered[i] {
+			results[i].Err = fmt.Errorf("no response to batch request %d", id)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,122 @@
+// Package client provides the client-side implementation of the MCP protocol.
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/localrivet/gomcp/protocol"
+)
+
+// NegotiatedCapabilities describes what both sides agreed on during initialization.
+type NegotiatedCapabilities struct {
+	// ProtocolVersion is the negotiated protocol version.
+	ProtocolVersion string
+
+	// Server holds the capabilities the server advertised.
+	Server map[string]interface{}
+
+	// Client holds the capabilities this client declared.
+	Client ClientCapabilities
+}
+
+// ServerSupports reports whether the server advertised a capability, given as
+// a dotted path such as "tools" or "resources.subscribe".
+func (n NegotiatedCapabilities) ServerSupports(capability string) bool {
+	var current interface{} = n.Server
+	for _, key := range strings.Split(capability, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if current, ok = object[key]; !ok {
+			return false
+		}
+	}
+	enabled, isBool := current.(bool)
+	return !isBool || enabled
+}
+
+// CapabilityError is returned when the server rejects a method because it did
+// not advertise the capability the method belongs to.
+type CapabilityError struct {
+	// Method is the method that was called.
+	Method string
+
+	// Capability is the dotted path of the capability the server is missing.
+	Capability string
+
+	// Detail is the server's own explanation, if it sent one.
+	Detail string
+}
+
+// Error implements the error interface.
+func (e *CapabilityError) Error() string {
+	msg := fmt.Sprintf("method %s is not available: the server did not advertise the %q capability", e.Method, e.Capability)
+	if e.Detail != "" {
+		msg += " (" + e.Detail + ")"
+	}
+	return msg
+}
+
+// methodCapabilities maps client-initiated methods to the server capability
+// they require, including the legacy method names this client still sends.
+var methodCapabilities = map[string]string{
+	"tools/list":               "tools",
+	"tools/call":               "tools",
+	"resources/list":           "resources",
+	"resources/read":           "resources",
+	"resource/get":             "resources",
+	"resources/templates/list": "resources",
+	"resources/subscribe":      "resources.subscribe",
+	"resources/unsubscribe":    "resources.subscribe",
+	"prompts/list":             "prompts",
+	"prompts/get":              "prompts",
+	"prompt/get":               "prompts",
+	"completion/complete":      "completions",
+	"logging/setLevel":         "logging",
+	"uploads/create":           "experimental.uploads",
+	"uploads/append":           "experimental.uploads",
+	"uploads/complete":         "experimental.uploads",
+}
+
+// NegotiatedCapabilities returns the capabilities agreed during initialization.
+//
+// Example:
+//
+//	caps := client.NegotiatedCapabilities()
+//	if !caps.ServerSupports("resources.subscribe") {
+//	    log.Println("server cannot push resource updates")
+//	}
+func (c *clientImpl) NegotiatedCapabilities() NegotiatedCapabilities {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return NegotiatedCapabilities{
+		ProtocolVersion: c.negotiatedVersion,
+		Server:          c.serverCapabilities,
+		Client:          c.capabilities,
+	}
+}
+
+// explainMissingCapability turns a method-not-found error into a
+// CapabilityError when the server never advertised the capability the method
+// needs, so callers learn why instead of seeing a bare error code.
+func (c *clientImpl) explainMissingCapability(method string, err error) error {
+	var rpcErr *protocol.Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != protocol.MethodNotFound {
+		return err
+	}
+
+	capability, known := methodCapabilities[method]
+	if !known {
+		return err
+	}
+
+	if c.NegotiatedCapabilities().ServerSupports(capability) {
+		return err
+	}
+	detail, _ := rpcErr.Data.(string)
+	return &CapabilityError{Method: method, Capability: capability, Detail: detail}
+}
@@ -33,6 +33,7 @@
 //   - WithTransport: Specify a custom transport implementation
 //   - WithRequestTimeout: Set request timeout duration
 //   - WithConnectionTimeout: Set connection timeout duration
+//   - WithRetryPolicy: Retry idempotent requests after transient transport errors
 //   - WithSamplingOptimizations: Configure sampling performance optimizations
 //
 // # Thread Safety
@@ -43,6 +44,8 @@ package client
 import (
 	"context"
 	"fmt"
+	"io"
+	"iter"
 	"log/slog"
 	"os"
 	"sync"
@@ -50,6 +53,7 @@ import (
 	"time"
 
 	"github.com/localrivet/gomcp/mcp"
+	"github.com/localrivet/gomcp/telemetry"
 )
 
 // Client represents an MCP client for communicating with MCP servers.
@@ -61,13 +65,14 @@ type Client interface {
 	// The name parameter specifies the tool to call. The args parameter contains
 	// the arguments to pass to the tool as key-value pairs. The returned interface{}
 	// contains the tool's output, which can be any JSON-serializable value.
+	// Options such as WithCallMeta customize the individual call.
 	//
 	// Example:
 	//  result, err := client.CallTool("translate", map[string]interface{}{
 	//      "text": "Hello world",
 	//      "target_language": "Spanish",
-	//  })
-	CallTool(name string, args map[string]interface{}) (interface{}, error)
+	//  }, client.WithCallMeta(map[string]interface{}{"progressToken": "t1"}))
+	CallTool(name string, args map[string]interface{}, opts ...CallOption) (interface{}, error)
 
 	// GetResource retrieves a resource from the server by its path.
 	//
@@ -78,6 +83,13 @@ type Client interface {
 	//  resource, err := client.GetResource("/users/123")
 	GetResource(path string) (interface{}, error)
 
+	// ReadResource reads a resource and returns its content items, verifying
+	// the digests the server sent with them.
+	//
+	// Example:
+	//  contents, err := client.ReadResource("file:///images/logo.png")
+	ReadResource(uri string) ([]ResourceContent, error)
+
 	// GetPrompt retrieves and renders a prompt from the server.
 	//
 	// The name parameter specifies the prompt to render. The variables parameter
@@ -90,6 +102,37 @@ type Client interface {
 	//  })
 	GetPrompt(name string, variables map[string]interface{}) (interface{}, error)
 
+	// Complete asks the server to suggest values for an argument of a
+	// prompt or resource template, given the value typed so far.
+	//
+	// Example:
+	//  completion, err := client.Complete(client.PromptRef("code_review"), "language", "py")
+	Complete(ref CompletionRef, arg, value string) (*Completion, error)
+
+	// Upload sends a file to the server in chunks and returns the upload://
+	// URI to pass to its tools. The server must enable uploads.
+	//
+	// Example:
+	//  upload, err := client.Upload(ctx, "notes.txt", strings.NewReader(notes))
+	Upload(ctx context.Context, name string, r io.Reader) (*UploadResult, error)
+
+	// UploadFile uploads a local file like Upload, keeping its base name.
+	UploadFile(ctx context.Context, path string) (*UploadResult, error)
+
+	// DownloadResource reads a resource into w in ranges, with progress
+	// reporting, resumption and checksum verification set by options.
+	//
+	// Example:
+	//  result, err := client.DownloadResource(ctx, "file:///artifacts/build.tar.gz", f)
+	DownloadResource(ctx context.Context, uri string, w io.Writer, opts ...DownloadOption) (*DownloadResult, error)
+
+	// ReadResourceRange reads length bytes of a resource at offset, for
+	// resources too large to read at once.
+	//
+	// Example:
+	//  r, err := client.ReadResourceRange("file:///logs/app.log", 0, 1<<20)
+	ReadResourceRange(uri string, offset, length int64) (*ResourceRange, error)
+
 	// GetRoot retrieves the root resource from the server.
 	//
 	// This is a convenience method equivalent to calling GetResource("/").
@@ -111,26 +154,35 @@ type Client interface {
 	//  defer client.Close()
 	Close() error
 
-	// AddRoot registers a new root endpoint with the server.
+	// AddRoot adds a root, such as a project directory, to those the client
+	// exposes to the server, which lists them with roots/list. An initialized
+	// server is sent notifications/roots/list_changed.
 	//
-	// The uri parameter specifies the path of the root. The name parameter
-	// provides a human-readable name for the root.
+	// The uri parameter locates the root. The name parameter provides a
+	// human-readable name for the root.
 	//
 	// Example:
-	//  err := client.AddRoot("/api/v2", "API Version 2")
+	//  err := client.AddRoot("file:///home/user/project", "Project")
 	AddRoot(uri string, name string) error
 
-	// RemoveRoot unregisters a root endpoint from the server.
-	//
-	// The uri parameter specifies the path of the root to remove.
+	// RemoveRoot removes a root from those the client exposes to the server,
+	// notifying an initialized server of the change.
 	//
 	// Example:
-	//  err := client.RemoveRoot("/api/v1")
+	//  err := client.RemoveRoot("file:///home/user/project")
 	RemoveRoot(uri string) error
 
-	// GetRoots retrieves the list of root endpoints from the server.
+	// SetRoots replaces the roots the client exposes to the server, notifying
+	// an initialized server of the change.
 	//
-	// The returned slice contains all registered roots with their URIs and names.
+	// Example:
+	//  err := client.SetRoots([]client.Root{
+	//      {URI: "file:///home/user/api", Name: "API"},
+	//      {URI: "file:///home/user/web", Name: "Web"},
+	//  })
+	SetRoots(roots []Root) error
+
+	// GetRoots returns the roots the client exposes to the server.
 	//
 	// Example:
 	//  roots, err := client.GetRoots()
@@ -149,6 +201,15 @@ type Client interface {
 	//  fmt.Printf("Connected using MCP protocol version %s\n", version)
 	Version() string
 
+	// NegotiatedCapabilities returns the protocol version and the capabilities
+	// both sides declared during initialization.
+	//
+	// Example:
+	//  if client.NegotiatedCapabilities().ServerSupports("prompts") {
+	//      prompt, _ := client.GetPrompt("greeting", nil)
+	//  }
+	NegotiatedCapabilities() NegotiatedCapabilities
+
 	// IsInitialized returns whether the client has been initialized.
 	//
 	// Initialization occurs during the first operation that requires
@@ -191,6 +252,89 @@ type Client interface {
 	// The streaming API is available only in protocol version 2025-03-26 and later.
 	// The handler is called for each chunk of the streaming response.
 	RequestStreamingSampling(req *StreamingSamplingRequest, handler StreamingResponseHandler) (*StreamingSamplingSession, error)
+
+	// SubscribeResource subscribes to change notifications for a resource.
+	//
+	// The handler is called with the resource URI whenever the server sends a
+	// notifications/resources/updated message for it. Subscriptions are restored
+	// automatically when the client reconnects. Returns an error if the server
+	// does not advertise the resources.subscribe capability.
+	//
+	// Example:
+	//  err := client.SubscribeResource("file:///logs/app.log", func(uri string) {
+	//      content, _ := client.GetResource(uri)
+	//      fmt.Println(content)
+	//  })
+	SubscribeResource(uri string, handler ResourceUpdateHandler) error
+
+	// UnsubscribeResource cancels a subscription created with SubscribeResource.
+	//
+	// Example:
+	//  err := client.UnsubscribeResource("file:///logs/app.log")
+	UnsubscribeResource(uri string) error
+
+	// SetLogLevel asks the server to send log messages at level and above,
+	// one of "debug", "info", "notice", "warning", "error", "critical",
+	// "alert" or "emergency". Messages reach the OnLogMessage handler.
+	// Returns an error if the server does not advertise the logging
+	// capability.
+	//
+	// Example:
+	//  err := client.SetLogLevel("debug")
+	SetLogLevel(level string) error
+
+	// Tools iterates over the server's tools, following pagination cursors
+	// as the loop advances.
+	//
+	// Example:
+	//  for tool, err := range client.Tools(ctx) {
+	//      if err != nil {
+	//          return err
+	//      }
+	//      fmt.Println(tool.Name)
+	//  }
+	Tools(ctx context.Context) iter.Seq2[Tool, error]
+
+	// Resources iterates over the server's resources, following pagination cursors.
+	Resources(ctx context.Context) iter.Seq2[Resource, error]
+
+	// Prompts iterates over the server's prompts, following pagination cursors.
+	Prompts(ctx context.Context) iter.Seq2[Prompt, error]
+
+	// ListAllTools returns every tool the server has, requesting each page
+	// of tools/list in turn.
+	//
+	// Example:
+	//  tools, err := client.ListAllTools(ctx)
+	ListAllTools(ctx context.Context) ([]Tool, error)
+
+	// ListAllResources returns every resource, across resources/list pages.
+	ListAllResources(ctx context.Context) ([]Resource, error)
+
+	// ListAllPrompts returns every prompt, across prompts/list pages.
+	ListAllPrompts(ctx context.Context) ([]Prompt, error)
+
+	// CallToolStream calls a tool and iterates over the content it streams
+	// before its final result, which is yielded last.
+	//
+	// Example:
+	//  for chunk, err := range client.CallToolStream(ctx, "summarize", args) {
+	//      if err != nil {
+	//          return err
+	//      }
+	//      fmt.Print(chunk.Text())
+	//  }
+	CallToolStream(ctx context.Context, name string, args map[string]interface{}, opts ...CallOption) iter.Seq2[StreamChunk, error]
+
+	// CallBatch sends requests as a single JSON-RPC batch and returns
+	// their results in the same order.
+	//
+	// Example:
+	//  results, err := client.CallBatch([]client.Request{
+	//      {Method: "tools/call", Params: map[string]interface{}{"name": "weather", "arguments": oslo}},
+	//      {Method: "resources/read", Params: map[string]interface{}{"uri": "docs://readme"}},
+	//  })
+	CallBatch(requests []Request) ([]BatchResult, error)
 }
 
 // clientImpl is the concrete implementation of the Client interface.
@@ -213,10 +357,53 @@ type clientImpl struct {
 	capabilities      ClientCapabilities
 	samplingHandler   SamplingHandler
 
+	// serverCapabilities holds the capabilities advertised by the server during initialization
+	serverCapabilities map[string]interface{}
+
+	// toolSchemas caches the input schemas used by CallToolTyped, keyed by
+	// tool name, until the server's tool list changes
+	toolSchemas map[string]map[string]interface{}
+
+	// Resource subscriptions, kept across reconnects so they can be restored
+	subscriptions   map[string]ResourceUpdateHandler
+	subscribing     map[string]*pendingSubscription
+	subscriptionsMu sync.RWMutex
+
+	// Trust-on-first-use fingerprinting of the server
+	trustStore         TrustStore
+	trustPolicy        TrustPolicy
+	serverIdentityHash string // identity of the connected server, recorded on first use
+
+	// Locale and time zone hints sent to the server in the initialize _meta
+	locale   string
+	timezone string
+
 	// Server management
 	serverRegistry *ServerRegistry
 	serverName     string
 
+	// reconnect is set by WithAutoReconnect
+	reconnect *reconnector
+
+	// retry is set by WithRetryPolicy
+	retry *RetryPolicy
+
+	// shutdownHandler is set by OnServerShutdown, and shutdownNotice holds
+	// the server's last shutdown announcement until the client reconnects
+	shutdownHandler func(ShutdownEvent)
+	shutdownNotice  *ShutdownEvent
+
+	// logHandler is set by OnLogMessage
+	logHandler func(LogMessage)
+
+	// tracer records a span per request when WithTracerProvider is set
+	tracer *telemetry.Tracer
+
+	// Calls in progress with CallToolStream, keyed by stream token
+	streams       map[string]*toolStream
+	streamsMu     sync.Mutex
+	streamCounter atomic.Int64
+
 	// Performance optimization fields
 	samplingCache   *SamplingCache
 	sizeAnalyzer    *ContentSizeAnalyzer
@@ -265,6 +452,8 @@ func NewClient(url string, options ...Option) (Client, error) {
 		ctx:               ctx,
 		cancel:            cancel,
 		roots:             []Root{},
+		subscriptions:     make(map[string]ResourceUpdateHandler),
+		subscribing:       make(map[string]*pendingSubscription),
 		capabilities: ClientCapabilities{
 			Roots: RootsCapability{
 				ListChanged: true,
@@ -285,6 +474,7 @@ func NewClient(url string, options ...Option) (Client, error) {
 		cancel() // Clean up resources
 		return nil, fmt.Errorf("failed to connect to MCP server: %w", err)
 	}
+	c.startHealthCheck()
 
 	return c, nil
 }
@@ -0,0 +1,426 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrProcessNotRunning is returned by a CommandTransport asked to send while
+// its server process is not running.
+var ErrProcessNotRunning = errors.New("server process not running")
+
+// commandStopGracePeriod is how long Disconnect lets the server exit after
+// its stdin closes before killing it.
+const commandStopGracePeriod = 2 * time.Second
+
+// CommandTransport runs an MCP server as a child process and speaks
+// newline-delimited JSON-RPC with it over the process's stdin and stdout,
+// the way desktop hosts launch servers. Lines the server writes to stderr
+// are logged. Connect starts the process and Disconnect stops it, killing
+// the whole process tree if it doesn't exit once its stdin closes.
+type CommandTransport struct {
+	// Env holds environment variables, as "KEY=value", added to the
+	// client's own environment for the server.
+	Env []string
+
+	// Dir is the server's working directory. If empty, the server runs in
+	// the client's.
+	Dir string
+
+	name string
+	args []string
+
+	// logger returns the logger for the server's stderr, and onExit is
+	// called when the server exits without being stopped.
+	logger func() *slog.Logger
+	onExit func(err error)
+
+	requestTimeout      time.Duration
+	connectionTimeout   time.Duration
+	notificationHandler func(method string, params []byte)
+
+	mu      sync.Mutex
+	process *commandProcess
+	pending map[string]chan []byte
+}
+
+// commandProcess is one run of the server.
+type commandProcess struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	writeMu  sync.Mutex
+	stopping atomic.Bool
+
+	// exited is closed once the process has exited, with err set to how.
+	exited chan struct{}
+	err    error
+}
+
+// NewCommandTransport returns a transport that runs the server with
+// command and args.
+func NewCommandTransport(command string, args ...string) *CommandTransport {
+	return &CommandTransport{
+		name:              command,
+		args:              args,
+		requestTimeout:    30 * time.Second,
+		connectionTimeout: 10 * time.Second,
+		pending:           make(map[string]chan []byte),
+	}
+}
+
+// WithCommand configures the client to launch the server as a child
+// process running command with args, and to talk to it over stdio. Closing
+// the client stops the server.
+//
+// Combine it with WithAutoReconnect to restart the server when it exits
+// unexpectedly: the process is started again, and initialize replayed, with
+// the policy's backoff and attempt limit.
+//
+// Example:
+//
+//	c, err := client.NewClient("filesystem",
+//	    client.WithCommand("npx", "-y", "@modelcontextprotocol/server-filesystem", "/tmp"),
+//	    client.WithAutoReconnect(client.ReconnectPolicy{MaxAttempts: 5}),
+//	)
+func WithCommand(command string, args ...string) Option {
+	return WithCommandTransport(NewCommandTransport(command, args...))
+}
+
+// WithCommandTransport configures the client to use a CommandTransport,
+// for servers that need environment variables or a working directory.
+//
+// Example:
+//
+//	transport := client.NewCommandTransport("./weather-server", "--units", "metric")
+//	transport.Env = []string{"WEATHER_API_KEY=" + key}
+//	c, err := client.NewClient("weather", client.WithCommandTransport(transport))
+func WithCommandTransport(transport *CommandTransport) Option {
+	return func(c *clientImpl) {
+		transport.SetRequestTimeout(c.requestTimeout)
+		transport.SetConnectionTimeout(c.connectionTimeout)
+		transport.logger = func() *slog.Logger { return c.logger }
+		transport.onExit = func(err error) {
+			if c.reconnect != nil {
+				c.startReconnect(err)
+			}
+		}
+		c.transport = transport
+	}
+}
+
+// Connect implements Transport. It starts the server process, unless it is
+// already running.
+func (t *CommandTransport) Connect() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.process != nil {
+		return nil
+	}
+
+	cmd := exec.Command(t.name, t.args...)
+	cmd.Env = append(os.Environ(), t.Env...)
+	cmd.Dir = t.Dir
+	configureProcessGroup(cmd)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", t.name, err)
+	}
+
+	process := &commandProcess{cmd: cmd, stdin: stdin, exited: make(chan struct{})}
+	t.process = process
+	t.log().Debug("started server process", "command", t.name, "pid", cmd.Process.Pid)
+
+	go t.run(process, stdout, stderr)
+	return nil
+}
+
+// ConnectWithContext implements Transport.
+func (t *CommandTransport) ConnectWithContext(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		return t.Connect()
+	}
+}
+
+// run reads the server's output until it exits, then reaps it and fails
+// the requests still waiting for a response.
+func (t *CommandTransport) run(process *commandProcess, stdout, stderr io.Reader) {
+	logged := make(chan struct{})
+	go func() {
+		defer close(logged)
+		t.logStderr(stderr)
+	}()
+
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			t.deliver(line)
+		}
+		if err != nil {
+			break
+		}
+	}
+	<-logged
+
+	// Wait may only be called once both pipes are drained
+	process.err = process.cmd.Wait()
+	close(process.exited)
+
+	t.mu.Lock()
+	if t.process == process {
+		t.process = nil
+	}
+	t.mu.Unlock()
+
+	if process.stopping.Load() {
+		return
+	}
+	err := fmt.Errorf("server process exited: %w", exitError(process.err))
+	t.log().Error("server process exited unexpectedly", "command", t.name, "error", process.err)
+	if t.onExit != nil {
+		t.onExit(err)
+	}
+}
+
+// logStderr logs each line the server writes to stderr.
+func (t *CommandTransport) logStderr(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			t.log().Info("server stderr", "command", t.name, "line", string(line))
+		}
+	}
+}
+
+// deliver hands a line from the server to the request waiting for it, or
+// to the notification handler if it is a notification or a request.
+func (t *CommandTransport) deliver(line []byte) {
+	if id, isResponse := messageID(line); isResponse {
+		t.mu.Lock()
+		waiting, found := t.pending[id]
+		delete(t.pending, id)
+		t.mu.Unlock()
+
+		if found {
+			waiting <- line
+		} else {
+			t.log().Debug("discarding response to unknown request", "id", id)
+		}
+		return
+	}
+
+	t.mu.Lock()
+	handler := t.notificationHandler
+	t.mu.Unlock()
+	if handler != nil {
+		go handler("", line)
+	}
+}
+
+// Disconnect implements Transport. It closes the server's stdin and kills
+// its process tree if it has not exited shortly afterwards.
+func (t *CommandTransport) Disconnect() error {
+	t.mu.Lock()
+	process := t.process
+	t.process = nil
+	t.mu.Unlock()
+
+	if process == nil {
+		return nil
+	}
+	process.stopping.Store(true)
+	process.stdin.Close()
+
+	select {
+	case <-process.exited:
+	case <-time.After(commandStopGracePeriod):
+		killProcessTree(process.cmd)
+		<-process.exited
+	}
+	return nil
+}
+
+// Send implements Transport.
+func (t *CommandTransport) Send(message []byte) ([]byte, error) {
+	t.mu.Lock()
+	timeout := t.requestTimeout
+	t.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return t.SendWithContext(ctx, message)
+}
+
+// SendWithContext implements Transport. Requests wait for the server's
+// response; notifications and responses return a nil response once
+// written.
+func (t *CommandTransport) SendWithContext(ctx context.Context, message []byte) ([]byte, error) {
+	id, isRequest := requestID(message)
+
+	t.mu.Lock()
+	process := t.process
+	var response chan []byte
+	if process != nil && isRequest {
+		response = make(chan []byte, 1)
+		t.pending[id] = response
+	}
+	t.mu.Unlock()
+
+	if process == nil {
+		return nil, ErrProcessNotRunning
+	}
+	if err := process.write(message); err != nil {
+		t.forget(id, isRequest)
+		return nil, err
+	}
+	if !isRequest {
+		return nil, nil
+	}
+
+	select {
+	case line := <-response:
+		return line, nil
+	case <-process.exited:
+		t.forget(id, isRequest)
+		select {
+		case line := <-response:
+			return line, nil
+		default:
+		}
+		return nil, fmt.Errorf("server process exited: %w", exitError(process.err))
+	case <-ctx.Done():
+		t.forget(id, isRequest)
+		return nil, ctx.Err()
+	}
+}
+
+// forget stops waiting for the response to a request.
+func (t *CommandTransport) forget(id string, isRequest bool) {
+	if !isRequest {
+		return
+	}
+	t.mu.Lock()
+	delete(t.pending, id)
+	t.mu.Unlock()
+}
+
+// SetRequestTimeout implements Transport.
+func (t *CommandTransport) SetRequestTimeout(timeout time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requestTimeout = timeout
+}
+
+// SetConnectionTimeout implements Transport.
+func (t *CommandTransport) SetConnectionTimeout(timeout time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.connectionTimeout = timeout
+}
+
+// RegisterNotificationHandler implements Transport. The handler receives
+// each server-initiated message whole, with an empty method.
+func (t *CommandTransport) RegisterNotificationHandler(handler func(method string, params []byte)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.notificationHandler = handler
+}
+
+func (t *CommandTransport) log() *slog.Logger {
+	if t.logger != nil {
+		return t.logger()
+	}
+	return slog.Default()
+}
+
+func (p *commandProcess) write(message []byte) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	if _, err := p.stdin.Write(append(message, '\n')); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
+
+// exitError describes how a process exited, for processes that exited
+// cleanly as well.
+func exitError(err error) error {
+	if err == nil {
+		return errors.New("exit status 0")
+	}
+	return err
+}
+
+// requestID returns the ID to match the response to a message by, and
+// whether a response is expected: the ID of a request, or of the first
+// request in a batch, whose response comes first in the reply.
+func requestID(message []byte) (string, bool) {
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if trimmed := bytes.TrimSpace(message); len(trimmed) > 0 && trimmed[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(trimmed, &batch); err != nil {
+			return "", false
+		}
+		for _, element := range batch {
+			if id, ok := requestID(element); ok {
+				return id, true
+			}
+		}
+		return "", false
+	}
+	if err := json.Unmarshal(message, &msg); err != nil || msg.Method == "" || len(msg.ID) == 0 || string(msg.ID) == "null" {
+		return "", false
+	}
+	return string(msg.ID), true
+}
+
+// messageID returns the ID of a response, or of the first response in a
+// batch reply, and whether the message is a response at all.
+func messageID(message []byte) (string, bool) {
+	if message[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(message, &batch); err != nil || len(batch) == 0 {
+			return "", false
+		}
+		return messageID(batch[0])
+	}
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.Unmarshal(message, &msg); err != nil || msg.Method != "" || len(msg.ID) == 0 {
+		return "", false
+	}
+	return string(msg.ID), true
+}
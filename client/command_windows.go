@@ -0,0 +1,19 @@
+//go:build windows
+
+package client
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// configureProcessGroup is a no-op on Windows, where killProcessTree finds
+// the server's children by their parent process ID.
+func configureProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessTree kills the server and the processes it spawned.
+func killProcessTree(cmd *exec.Cmd) {
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run(); err != nil {
+		cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,17 @@
+// Package client provides the client-side implementation of the MCP protocol.
+package client
+
+import "github.com/localrivet/gomcp/mcp/compat"
+
+// upgradeLegacyResult rewrites list results from servers built against older
+// protocol revisions into the current shape, logging a deprecation warning
+// for each legacy field.
+func (c *clientImpl) upgradeLegacyResult(method string, result interface{}) {
+	object, ok := result.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for _, d := range compat.UpgradeResult(method, object) {
+		c.logger.Warn("deprecated response field", "method", method, "warning", d.String())
+	}
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CompletionRef identifies what a completion request completes an argument
+// of: a prompt or a resource template.
+type CompletionRef struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+	URI  string `json:"uri,omitempty"`
+}
+
+// PromptRef refers to the prompt with the given name.
+func PromptRef(name string) CompletionRef {
+	return CompletionRef{Type: "ref/prompt", Name: name}
+}
+
+// ResourceRef refers to the resource template with the given URI template.
+func ResourceRef(uriTemplate string) CompletionRef {
+	return CompletionRef{Type: "ref/resource", URI: uriTemplate}
+}
+
+// Completion holds the values a server suggests for an argument.
+type Completion struct {
+	// Values are the suggestions, best first, at most 100 of them.
+	Values []string `json:"values"`
+
+	// Total is the number of suggestions the server has, which may exceed
+	// len(Values), or zero if the server didn't say.
+	Total int `json:"total,omitempty"`
+
+	// HasMore reports whether the server has more suggestions than Values.
+	HasMore bool `json:"hasMore,omitempty"`
+}
+
+// Complete asks the server to suggest values for the argument arg of ref,
+// given what the user has typed of it so far.
+func (c *clientImpl) Complete(ref CompletionRef, arg, value string) (*Completion, error) {
+	result, err := c.sendRequest("completion/complete", map[string]interface{}{
+		"ref": ref,
+		"argument": map[string]interface{}{
+			"name":  arg,
+			"value": value,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("invalid completion result: %w", err)
+	}
+	var response struct {
+		Completion Completion `json:"completion"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("invalid completion result: %w", err)
+	}
+	return &response.Completion, nil
+}
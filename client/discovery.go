@@ -0,0 +1,219 @@
+// Package client provides the client-side implementation of the MCP protocol.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/localrivet/gomcp/mcp"
+	"github.com/localrivet/gomcp/util/mdns"
+)
+
+// Discovery is the result of reading a server's /.well-known/mcp.json document.
+type Discovery struct {
+	// Document is the discovery document published by the server.
+	Document mcp.DiscoveryDocument
+
+	// Transport is the transport type selected from the document.
+	Transport string
+
+	// URL is the absolute endpoint URL for the selected transport.
+	URL string
+}
+
+// Option returns a client option that configures the discovered transport.
+func (d *Discovery) Option() Option {
+	switch d.Transport {
+	case mcp.TransportWebsocket:
+		return WithWebsocket(d.URL)
+	case mcp.TransportSSE:
+		return WithSSE(d.URL)
+	case mcp.TransportStreamableHTTP:
+		return WithStreamableHTTP(d.URL)
+	default:
+		return WithHTTP(d.URL)
+	}
+}
+
+// Discover reads the discovery document published by the server at baseURL
+// and selects the transport to use, so callers don't have to know whether a
+// server listens on /sse, /mcp or /api.
+//
+// Example:
+//
+//	d, err := client.Discover("http://localhost:8080")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	c, err := client.NewClient(d.URL, d.Option())
+func Discover(baseURL string) (*Discovery, error) {
+	return DiscoverWithContext(context.Background(), baseURL)
+}
+
+// DiscoverWithContext is like Discover but honours the given context.
+func DiscoverWithContext(ctx context.Context, baseURL string) (*Discovery, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return nil, fmt.Errorf("discovery requires an http or https URL, got %q", baseURL)
+	}
+
+	wellKnown := base.ResolveReference(&url.URL{Path: mcp.WellKnownPath})
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery document request failed with status %d", resp.StatusCode)
+	}
+
+	var doc mcp.DiscoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse discovery document: %w", err)
+	}
+
+	for _, endpoint := range doc.Transports {
+		endpointURL, ok := resolveEndpoint(base, endpoint)
+		if !ok {
+			continue
+		}
+		return &Discovery{
+			Document:  doc,
+			Transport: endpoint.Type,
+			URL:       endpointURL,
+		}, nil
+	}
+
+	return nil, errors.New("discovery document does not advertise a supported transport")
+}
+
+// resolveEndpoint builds the absolute URL for an advertised transport endpoint.
+func resolveEndpoint(base *url.URL, endpoint mcp.TransportEndpoint) (string, bool) {
+	ref, err := url.Parse(endpoint.Endpoint)
+	if err != nil {
+		return "", false
+	}
+	resolved := base.ResolveReference(ref)
+
+	switch endpoint.Type {
+	case mcp.TransportHTTP, mcp.TransportSSE, mcp.TransportStreamableHTTP:
+		return resolved.String(), true
+	case mcp.TransportWebsocket:
+		resolved.Scheme = strings.Replace(resolved.Scheme, "http", "ws", 1)
+		return resolved.String(), true
+	default:
+		return "", false
+	}
+}
+
+// LocalServer is an MCP server found on the local network via mDNS.
+type LocalServer struct {
+	// Name is the advertised service instance name.
+	Name string
+
+	// Host and Port identify where the server listens.
+	Host string
+	Port int
+
+	// Transport is the advertised transport type, e.g. "sse" or "websocket".
+	Transport string
+
+	// URL is the endpoint URL for the advertised transport.
+	URL string
+
+	// Text holds the raw TXT record values published by the server.
+	Text map[string]string
+}
+
+// Option returns a client option that configures the server's transport.
+func (s LocalServer) Option() Option {
+	d := Discovery{Transport: s.Transport, URL: s.URL}
+	return d.Option()
+}
+
+// DiscoverLocal browses the local network for MCP servers advertised via
+// mDNS ("_mcp._tcp") and returns those that answered before ctx is done.
+// If ctx has no deadline, browsing stops after two seconds.
+//
+// Example:
+//
+//	servers, err := client.DiscoverLocal(context.Background())
+//	for _, s := range servers {
+//	    fmt.Printf("%s at %s\n", s.Name, s.URL)
+//	}
+func DiscoverLocal(ctx context.Context) ([]LocalServer, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+	}
+
+	entries, err := mdns.Browse(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make([]LocalServer, 0, len(entries))
+	for _, entry := range entries {
+		if server, ok := localServerFromEntry(entry); ok {
+			servers = append(servers, server)
+		}
+	}
+	return servers, nil
+}
+
+// localServerFromEntry converts an mDNS entry into a LocalServer.
+func localServerFromEntry(entry mdns.Entry) (LocalServer, bool) {
+	host := entry.Host
+	if len(entry.IPs) > 0 {
+		host = entry.IPs[0].String()
+	}
+	if host == "" || entry.Port == 0 {
+		return LocalServer{}, false
+	}
+
+	transportType := entry.Text["transport"]
+	if transportType == "" {
+		transportType = mcp.TransportHTTP
+	}
+
+	base := &url.URL{Scheme: "http", Host: net.JoinHostPort(host, strconv.Itoa(entry.Port))}
+	endpointURL, ok := resolveEndpoint(base, mcp.TransportEndpoint{
+		Type:     transportType,
+		Endpoint: entry.Text["path"],
+	})
+	if !ok {
+		return LocalServer{}, false
+	}
+
+	return LocalServer{
+		Name:      entry.Instance,
+		Host:      entry.Host,
+		Port:      entry.Port,
+		Transport: transportType,
+		URL:       endpointURL,
+		Text:      entry.Text,
+	}, true
+}
@@ -0,0 +1,303 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+// DefaultDownloadChunkSize is the size of the ranges DownloadResource reads
+// unless WithDownloadChunkSize is used.
+const DefaultDownloadChunkSize = 4 << 20
+
+// ErrChecksumMismatch is returned when content doesn't match its checksum:
+// by DownloadResource for the checksum given with WithDownloadChecksum, and
+// by DownloadResource and ReadResource for digests sent by the server.
+var ErrChecksumMismatch = errors.New("content does not match the expected checksum")
+
+// DownloadOption configures DownloadResource.
+type DownloadOption func(*downloadOptions)
+
+// downloadOptions holds the settings of a download.
+type downloadOptions struct {
+	chunkSize int64
+	progress  func(written, total int64)
+	resume    io.Reader
+	checksum  string
+}
+
+// WithDownloadChunkSize sets how many bytes each resources/read of the
+// download asks for. Servers may return less.
+func WithDownloadChunkSize(n int64) DownloadOption {
+	return func(o *downloadOptions) {
+		if n > 0 {
+			o.chunkSize = n
+		}
+	}
+}
+
+// WithDownloadProgress calls fn after each chunk is written, with the bytes
+// written so far, including resumed ones, and the size of the resource, or
+// -1 if the server didn't give it.
+func WithDownloadProgress(fn func(written, total int64)) DownloadOption {
+	return func(o *downloadOptions) {
+		o.progress = fn
+	}
+}
+
+// WithDownloadResume resumes an interrupted download. The content already
+// downloaded is read from r to its end to find where to continue and to
+// include it in checksum verification; the rest is written to the writer
+// passed to DownloadResource. A file opened for reading and writing can be
+// both, as reading it to its end leaves writes appending:
+//
+//	f, err := os.OpenFile("build.tar.gz", os.O_RDWR|os.O_CREATE, 0o644)
+//	...
+//	_, err = c.DownloadResource(ctx, uri, f, client.WithDownloadResume(f))
+func WithDownloadResume(r io.Reader) DownloadOption {
+	return func(o *downloadOptions) {
+		o.resume = r
+	}
+}
+
+// WithDownloadChecksum verifies the downloaded content against a
+// hex-encoded SHA-256 digest, failing with ErrChecksumMismatch if it
+// differs.
+func WithDownloadChecksum(sha256Hex string) DownloadOption {
+	return func(o *downloadOptions) {
+		o.checksum = strings.ToLower(sha256Hex)
+	}
+}
+
+// DownloadResult describes a finished download.
+type DownloadResult struct {
+	// Size is the size of the resource, including any resumed part.
+	Size int64
+
+	// Written is the number of bytes written to the writer by this call.
+	Written int64
+
+	// MimeType is the media type the server gave the resource.
+	MimeType string
+
+	// SHA256 is the hex-encoded SHA-256 digest of the whole resource.
+	SHA256 string
+}
+
+// DownloadResource reads a resource into w in ranges, so large resources,
+// such as artifacts produced by tools, are never held in memory whole.
+// Resources served without ranges, such as those of handlers returning
+// their content at once, are written as returned.
+//
+// Example:
+//
+//	f, err := os.Create("build.tar.gz")
+//	if err != nil {
+//	    return err
+//	}
+//	defer f.Close()
+//	result, err := c.DownloadResource(ctx, "file:///artifacts/build.tar.gz", f,
+//	    client.WithDownloadProgress(func(written, total int64) {
+//	        fmt.Printf("\r%d of %d bytes", written, total)
+//	    }))
+func (c *clientImpl) DownloadResource(ctx context.Context, uri string, w io.Writer, opts ...DownloadOption) (*DownloadResult, error) {
+	options := downloadOptions{chunkSize: DefaultDownloadChunkSize}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	digest := sha256.New()
+	var offset int64
+	if options.resume != nil {
+		n, err := io.Copy(digest, options.resume)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the downloaded part: %w", err)
+		}
+		offset = n
+	}
+
+	result := &DownloadResult{Size: -1}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		chunk, err := c.readResourceChunk(uri, offset, options.chunkSize)
+		if err != nil {
+			return nil, err
+		}
+		if !chunk.ranged && offset > 0 {
+			return nil, fmt.Errorf("resource %s can't be read in ranges, so the download can't be resumed", uri)
+		}
+		if chunk.mimeType != "" {
+			result.MimeType = chunk.mimeType
+		}
+		if err := writeChunk(w, digest, chunk.data); err != nil {
+			return nil, err
+		}
+		offset += int64(len(chunk.data))
+		result.Written += int64(len(chunk.data))
+		if chunk.ranged {
+			result.Size = chunk.size
+		}
+		if options.progress != nil {
+			options.progress(offset, result.Size)
+		}
+
+		// A server that doesn't read in ranges returned everything; one that
+		// does returns an empty range at the end
+		if !chunk.ranged || offset >= chunk.size || len(chunk.data) == 0 {
+			break
+		}
+	}
+	result.Size = offset
+	result.SHA256 = hex.EncodeToString(digest.Sum(nil))
+
+	if options.checksum != "" && options.checksum != result.SHA256 {
+		return result, fmt.Errorf("%w: got %s, want %s", ErrChecksumMismatch, result.SHA256, options.checksum)
+	}
+	return result, nil
+}
+
+// ResourceRange is a range of a resource read by ReadResourceRange.
+type ResourceRange struct {
+	// Data is the content of the range, with text as its UTF-8 bytes.
+	Data []byte
+
+	// MimeType is the media type the server gave the resource.
+	MimeType string
+
+	// Offset is where in the resource the range starts.
+	Offset int64
+
+	// Size is the size of the whole resource.
+	Size int64
+}
+
+// ReadResourceRange reads length bytes of a resource at offset with
+// resources/read, for consuming resources too large for a single message,
+// such as files served by the server's WithFileContent, a range at a time.
+// Servers may return less than length, ending text ranges on a character
+// boundary, so the next range starts at Offset+len(Data); a range at Size
+// is empty. Resources the server doesn't read in ranges are returned whole
+// for a range at offset zero, and fail for any other.
+//
+// Example:
+//
+//	for offset := int64(0); ; {
+//	    r, err := c.ReadResourceRange(uri, offset, 1<<20)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    process(r.Data)
+//	    offset += int64(len(r.Data))
+//	    if offset >= r.Size || len(r.Data) == 0 {
+//	        break
+//	    }
+//	}
+func (c *clientImpl) ReadResourceRange(uri string, offset, length int64) (*ResourceRange, error) {
+	if offset < 0 || length < 0 {
+		return nil, fmt.Errorf("invalid range of resource %s: offset %d, length %d", uri, offset, length)
+	}
+	chunk, err := c.readResourceChunk(uri, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	if !chunk.ranged {
+		if offset > 0 {
+			return nil, fmt.Errorf("resource %s can't be read in ranges", uri)
+		}
+		chunk.size = int64(len(chunk.data))
+	}
+	return &ResourceRange{Data: chunk.data, MimeType: chunk.mimeType, Offset: offset, Size: chunk.size}, nil
+}
+
+// resourceChunk is a range of a resource returned by resources/read.
+type resourceChunk struct {
+	data     []byte
+	mimeType string
+	ranged   bool
+	size     int64
+}
+
+// readResourceChunk reads length bytes of a resource at offset.
+func (c *clientImpl) readResourceChunk(uri string, offset, length int64) (*resourceChunk, error) {
+	result, err := c.sendRequest("resources/read", map[string]interface{}{
+		"uri":    uri,
+		"offset": offset,
+		"length": length,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resources/read result: %w", err)
+	}
+	var response struct {
+		Contents []struct {
+			MimeType string  `json:"mimeType"`
+			Text     *string `json:"text"`
+			Blob     *string `json:"blob"`
+			Meta     *struct {
+				SHA256 string `json:"sha256"`
+			} `json:"_meta"`
+		} `json:"contents"`
+		Meta *struct {
+			Size   *int64 `json:"size"`
+			Offset int64  `json:"offset"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("invalid resources/read result: %w", err)
+	}
+
+	chunk := &resourceChunk{}
+	if response.Meta != nil && response.Meta.Size != nil {
+		if response.Meta.Offset != offset {
+			return nil, fmt.Errorf("server returned offset %d for a read at %d", response.Meta.Offset, offset)
+		}
+		chunk.ranged, chunk.size = true, *response.Meta.Size
+	}
+
+	for _, content := range response.Contents {
+		if chunk.mimeType == "" {
+			chunk.mimeType = content.MimeType
+		}
+		var data []byte
+		switch {
+		case content.Blob != nil:
+			decoded, err := base64.StdEncoding.DecodeString(*content.Blob)
+			if err != nil {
+				return nil, fmt.Errorf("invalid resource blob: %w", err)
+			}
+			data = decoded
+		case content.Text != nil:
+			data = []byte(*content.Text)
+		}
+		// Catch a corrupted range now rather than after the whole download
+		if content.Meta != nil && content.Meta.SHA256 != "" {
+			if err := verifyDigest(data, content.Meta.SHA256); err != nil {
+				return nil, fmt.Errorf("range at %d: %w", offset, err)
+			}
+		}
+		chunk.data = append(chunk.data, data...)
+	}
+	return chunk, nil
+}
+
+// writeChunk writes a chunk to w and to the running digest.
+func writeChunk(w io.Writer, digest hash.Hash, data []byte) error {
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write download: %w", err)
+	}
+	digest.Write(data)
+	return nil
+}
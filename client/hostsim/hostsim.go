@@ -0,0 +1,322 @@
+// Package hostsim simulates MCP host applications so server authors can check
+// compatibility locally, without installing each host.
+//
+// A Session drives a server, in-process or over a Conn, the way a given host would: it sends
+// the host's clientInfo, protocol version and capabilities at initialize,
+// lists tools the way the host does, and reproduces the host's known quirks,
+// such as strict input schema validation or repeating the initialize
+// handshake on an open connection.
+//
+// # Basic Usage
+//
+//	for _, profile := range hostsim.Profiles() {
+//		session, err := hostsim.Connect(srv, profile)
+//		if err != nil {
+//			t.Fatalf("%s: %v", profile.Name, err)
+//		}
+//		if _, err := session.CallTool("echo", map[string]interface{}{"message": "hi"}); err != nil {
+//			t.Errorf("%s: %v", profile.Name, err)
+//		}
+//	}
+package hostsim
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/util/schema"
+)
+
+// Tool is a tool as the simulated host sees it after tools/list.
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// SchemaError reports the tools a host with strict schema validation
+// refused to load.
+type SchemaError struct {
+	Host     string
+	Problems []string
+}
+
+// Error implements the error interface.
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s rejected the tool list: %s", e.Host, strings.Join(e.Problems, "; "))
+}
+
+// ResponseError is a JSON-RPC error returned by the server.
+type ResponseError struct {
+	Method  string
+	Code    int
+	Message string
+	Data    interface{}
+}
+
+// Error implements the error interface.
+func (e *ResponseError) Error() string {
+	if e.Data != nil {
+		return fmt.Sprintf("%s: server returned error: %s (code %d): %v", e.Method, e.Message, e.Code, e.Data)
+	}
+	return fmt.Sprintf("%s: server returned error: %s (code %d)", e.Method, e.Message, e.Code)
+}
+
+// Conn carries JSON-RPC messages between a simulated host and a server.
+type Conn interface {
+	// Call sends a request and returns the server's response to it.
+	Call(request []byte) ([]byte, error)
+
+	// Notify sends a notification, which has no response.
+	Notify(notification []byte) error
+}
+
+// ServerConn connects to a server in-process, through its message handler.
+func ServerConn(srv server.Server) Conn {
+	return serverConn{srv: srv}
+}
+
+type serverConn struct {
+	srv server.Server
+}
+
+// Call implements Conn.
+func (c serverConn) Call(request []byte) ([]byte, error) {
+	return server.HandleMessage(c.srv.GetServer(), request)
+}
+
+// Notify implements Conn.
+func (c serverConn) Notify(notification []byte) error {
+	_, err := server.HandleMessage(c.srv.GetServer(), notification)
+	return err
+}
+
+// Session is a simulated host connected to a server.
+type Session struct {
+	profile Profile
+	conn    Conn
+
+	mu        sync.Mutex
+	nextID    int64
+	calls     int
+	handshake int
+	version   string
+	tools     []Tool
+}
+
+// Connect runs the profile's initialize handshake against srv in-process.
+// See Dial.
+func Connect(srv server.Server, profile Profile) (*Session, error) {
+	return Dial(ServerConn(srv), profile)
+}
+
+// Dial runs the profile's initialize handshake over conn and, if the profile
+// does so, loads the tool list. A host with strict schema validation returns
+// a *SchemaError when any tool's input schema would be rejected.
+func Dial(conn Conn, profile Profile) (*Session, error) {
+	s := &Session{profile: profile, conn: conn}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Profile returns the profile the session simulates.
+func (s *Session) Profile() Profile {
+	return s.profile
+}
+
+// ProtocolVersion returns the protocol revision the server negotiated in the
+// most recent handshake.
+func (s *Session) ProtocolVersion() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.version
+}
+
+// Tools returns the tools loaded by the most recent handshake.
+func (s *Session) Tools() []Tool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Tool(nil), s.tools...)
+}
+
+// Handshakes returns the number of initialize handshakes performed so far.
+func (s *Session) Handshakes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.handshake
+}
+
+// Reconnect repeats the initialize handshake on the open connection, as
+// hosts do when they reload their configuration.
+func (s *Session) Reconnect() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connect()
+}
+
+// CallTool calls a tool the way the host would. Profiles with ReconnectEvery
+// set repeat the initialize handshake before the call when it is due.
+func (s *Session) CallTool(name string, args map[string]interface{}) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.profile.ReconnectEvery > 0 && s.calls > 0 && s.calls%s.profile.ReconnectEvery == 0 {
+		if err := s.connect(); err != nil {
+			return nil, fmt.Errorf("reconnect after %d calls: %w", s.calls, err)
+		}
+	}
+	s.calls++
+
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	var result map[string]interface{}
+	if err := s.request("tools/call", map[string]interface{}{"name": name, "arguments": args}, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// connect performs the handshake. The caller must hold s.mu.
+func (s *Session) connect() error {
+	initParams := map[string]interface{}{
+		"protocolVersion": s.profile.ProtocolVersion,
+		"clientInfo": map[string]interface{}{
+			"name":    s.profile.ClientName,
+			"version": s.profile.ClientVersion,
+		},
+		"capabilities": s.profile.Capabilities,
+	}
+	if initParams["capabilities"] == nil {
+		initParams["capabilities"] = map[string]interface{}{}
+	}
+	var initResult struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if err := s.request("initialize", initParams, &initResult); err != nil {
+		return err
+	}
+	s.handshake++
+	s.version = initResult.ProtocolVersion
+
+	if err := s.notify("notifications/initialized"); err != nil {
+		return err
+	}
+
+	if !s.profile.ListToolsOnConnect {
+		return nil
+	}
+	tools, err := s.listTools()
+	if err != nil {
+		return err
+	}
+	if s.profile.StrictSchemas {
+		var problems []string
+		for _, tool := range tools {
+			for _, issue := range schema.Lint(schema.Tool{Name: tool.Name, InputSchema: tool.InputSchema}) {
+				if issue.Severity == schema.SeverityError {
+					problems = append(problems, issue.String())
+				}
+			}
+		}
+		if len(problems) > 0 {
+			return &SchemaError{Host: s.profile.Name, Problems: problems}
+		}
+	}
+	s.tools = tools
+	return nil
+}
+
+// listTools follows tools/list pagination until the last page.
+func (s *Session) listTools() ([]Tool, error) {
+	var tools []Tool
+	seen := make(map[string]bool)
+	cursor := ""
+	for {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var page struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := s.request("tools/list", params, &page); err != nil {
+			return nil, err
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" || seen[page.NextCursor] {
+			break
+		}
+		seen[page.NextCursor] = true
+		cursor = page.NextCursor
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools, nil
+}
+
+// request sends a request over the connection and decodes the result into
+// out, if out is not nil.
+func (s *Session) request(method string, params interface{}, out interface{}) error {
+	s.nextID++
+	message, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      s.nextID,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+
+	responseBytes, err := s.conn.Call(message)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if len(responseBytes) == 0 {
+		return fmt.Errorf("%s: server sent no response", method)
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int         `json:"code"`
+			Message string      `json:"message"`
+			Data    interface{} `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(responseBytes, &response); err != nil {
+		return fmt.Errorf("%s: failed to parse response: %w", method, err)
+	}
+	if response.Error != nil {
+		return &ResponseError{Method: method, Code: response.Error.Code, Message: response.Error.Message, Data: response.Error.Data}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, out); err != nil {
+		return fmt.Errorf("%s: failed to parse result: %w", method, err)
+	}
+	return nil
+}
+
+// notify sends a notification, which has no response.
+func (s *Session) notify(method string) error {
+	message, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+	})
+	if err != nil {
+		return err
+	}
+	return s.conn.Notify(message)
+}
@@ -0,0 +1,96 @@
+package hostsim
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+type echoArgs struct {
+	Message string `json:"message"`
+}
+
+type tagArgs struct {
+	Tags []string `json:"tags"`
+}
+
+// untypedTagsSchema declares tags as an array without items, which strict
+// hosts reject.
+var untypedTagsSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"tags": map[string]interface{}{"type": "array"},
+	},
+}
+
+func newEchoServer(options ...server.Option) server.Server {
+	return server.NewServer("hostsim-test", options...).
+		Tool("echo", "Echo a message", func(ctx *server.Context, args echoArgs) (interface{}, error) {
+			return args.Message, nil
+		})
+}
+
+func TestProfilesConnectAndCall(t *testing.T) {
+	for _, profile := range Profiles() {
+		t.Run(profile.Name, func(t *testing.T) {
+			session, err := Connect(newEchoServer(), profile)
+			if err != nil {
+				t.Fatalf("Connect failed: %v", err)
+			}
+			if tools := session.Tools(); len(tools) != 1 || tools[0].Name != "echo" {
+				t.Fatalf("Expected the echo tool, got %v", tools)
+			}
+			for i := 0; i < 5; i++ {
+				if _, err := session.CallTool("echo", map[string]interface{}{"message": "hi"}); err != nil {
+					t.Fatalf("Call %d failed: %v", i+1, err)
+				}
+			}
+		})
+	}
+}
+
+func TestStrictSchemasRejectUnusableTools(t *testing.T) {
+	srv := newEchoServer().
+		Tool("tag", "Tag things", func(ctx *server.Context, args tagArgs) (interface{}, error) {
+			return "ok", nil
+		}).
+		Tool("bad.name", "Dotted name", func(ctx *server.Context, args echoArgs) (interface{}, error) {
+			return "ok", nil
+		}).
+		WithSchema("tag", untypedTagsSchema)
+
+	_, err := Connect(srv, ClaudeDesktop)
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("Expected a SchemaError, got %v", err)
+	}
+	problems := strings.Join(schemaErr.Problems, "\n")
+	for _, want := range []string{`tool "tag": inputSchema.properties.tags.items: array schemas must declare items`, `tool "bad.name": name: must match`} {
+		if !strings.Contains(problems, want) {
+			t.Errorf("Expected problem %q, got:\n%s", want, problems)
+		}
+	}
+
+	if _, err := Connect(srv, VSCode); err != nil {
+		t.Errorf("Expected a lenient host to load the tools, got %v", err)
+	}
+}
+
+func TestCursorReconnectAgainstStrictLifecycle(t *testing.T) {
+	session, err := Connect(newEchoServer(server.WithLifecyclePolicy(server.LifecycleReject)), Cursor)
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+
+	var callErr error
+	for i := 0; i < Cursor.ReconnectEvery+1 && callErr == nil; i++ {
+		_, callErr = session.CallTool("echo", map[string]interface{}{"message": "hi"})
+	}
+
+	var respErr *ResponseError
+	if !errors.As(callErr, &respErr) || respErr.Method != "initialize" {
+		t.Fatalf("Expected the repeated initialize to be rejected, got %v", callErr)
+	}
+}
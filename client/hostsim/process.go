@@ -0,0 +1,224 @@
+package hostsim
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// DefaultCallTimeout bounds how long a Process waits for a response.
+const DefaultCallTimeout = 10 * time.Second
+
+// closeGracePeriod is how long Close lets the server exit on its own. Many
+// servers keep running after stdin closes, so it is kept short.
+const closeGracePeriod = 500 * time.Millisecond
+
+// Process is a Conn to a server running as a child process and speaking
+// newline-delimited JSON-RPC over stdio, the way desktop hosts launch servers.
+type Process struct {
+	// Timeout bounds how long Call waits for a response. Zero means
+	// DefaultCallTimeout.
+	Timeout time.Duration
+
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	stderr  lockedBuffer
+	lines   chan []byte
+	done    chan struct{}
+	closing chan struct{}
+
+	writeMu sync.Mutex
+	readErr error
+}
+
+// StartProcess launches command with args and connects to it over stdio.
+// Cancelling ctx kills the process.
+func StartProcess(ctx context.Context, command string, args ...string) (*Process, error) {
+	cmd := exec.CommandContext(ctx, command, args...)
+	p := &Process{
+		cmd:     cmd,
+		lines:   make(chan []byte, 16),
+		done:    make(chan struct{}),
+		closing: make(chan struct{}),
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	cmd.Stderr = &p.stderr
+	p.stdin = stdin
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", command, err)
+	}
+
+	go p.readLoop(stdout)
+	return p, nil
+}
+
+// readLoop forwards each line the server writes until stdout closes.
+func (p *Process) readLoop(stdout io.Reader) {
+	defer close(p.done)
+
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			select {
+			case p.lines <- line:
+			case <-p.closing:
+				return
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				p.readErr = err
+			}
+			return
+		}
+	}
+}
+
+// Call implements Conn. Messages the server sends that are not the response
+// to request, such as notifications, are skipped.
+func (p *Process) Call(request []byte) ([]byte, error) {
+	var req struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(request, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse request: %w", err)
+	}
+	if err := p.write(request); err != nil {
+		return nil, err
+	}
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCallTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case line := <-p.lines:
+			if isResponseTo(line, req.ID) {
+				return line, nil
+			}
+		case <-p.done:
+			// Lines read before stdout closed may still be buffered
+			for len(p.lines) > 0 {
+				if line := <-p.lines; isResponseTo(line, req.ID) {
+					return line, nil
+				}
+			}
+			if p.readErr != nil {
+				return nil, fmt.Errorf("server output failed: %w", p.readErr)
+			}
+			return nil, fmt.Errorf("server exited before responding%s", p.stderrSuffix())
+		case <-timer.C:
+			return nil, fmt.Errorf("no response within %s%s", timeout, p.stderrSuffix())
+		}
+	}
+}
+
+// isResponseTo reports whether line is the response to the request with id.
+func isResponseTo(line []byte, id json.RawMessage) bool {
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.Unmarshal(line, &msg); err != nil || msg.Method != "" {
+		return false
+	}
+	return bytes.Equal(msg.ID, id)
+}
+
+// Notify implements Conn.
+func (p *Process) Notify(notification []byte) error {
+	return p.write(notification)
+}
+
+// Stderr returns what the server has written to stderr so far.
+func (p *Process) Stderr() string {
+	return p.stderr.String()
+}
+
+// Close closes the server's stdin and kills the server if it has not exited
+// shortly afterwards.
+func (p *Process) Close() error {
+	close(p.closing)
+	p.stdin.Close()
+
+	exited := make(chan error, 1)
+	go func() { exited <- p.cmd.Wait() }()
+
+	var err error
+	select {
+	case err = <-exited:
+	case <-time.After(closeGracePeriod):
+		p.cmd.Process.Kill()
+		err = <-exited
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// A server that exits with an error status once stdin closes, or
+		// that had to be killed, has still shut down; there is nothing
+		// useful to report.
+		return nil
+	}
+	return err
+}
+
+func (p *Process) write(message []byte) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	if _, err := p.stdin.Write(append(message, '\n')); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
+
+// stderrSuffix returns the tail of the server's stderr for error messages.
+func (p *Process) stderrSuffix() string {
+	const max = 500
+	text := bytes.TrimSpace([]byte(p.Stderr()))
+	if len(text) == 0 {
+		return ""
+	}
+	if len(text) > max {
+		text = append([]byte("..."), text[len(text)-max:]...)
+	}
+	return fmt.Sprintf("; stderr: %s", text)
+}
+
+// lockedBuffer is a buffer the process writes to while Stderr reads it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
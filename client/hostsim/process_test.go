@@ -0,0 +1,48 @@
+package hostsim
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+// TestMain lets the test binary act as a stdio server for the process tests.
+func TestMain(m *testing.M) {
+	if os.Getenv("HOSTSIM_TEST_SERVER") == "1" {
+		if err := newEchoServer().AsStdio().Run(); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func TestProcessConn(t *testing.T) {
+	t.Setenv("HOSTSIM_TEST_SERVER", "1")
+
+	process, err := StartProcess(context.Background(), os.Args[0])
+	if err != nil {
+		t.Fatalf("StartProcess failed: %v", err)
+	}
+	defer process.Close()
+
+	session, err := Dial(process, VSCode)
+	if err != nil {
+		t.Fatalf("Dial failed: %v\nstderr: %s", err, process.Stderr())
+	}
+	if session.ProtocolVersion() != VSCode.ProtocolVersion {
+		t.Errorf("Expected protocol %s, got %s", VSCode.ProtocolVersion, session.ProtocolVersion())
+	}
+
+	result, err := session.CallTool("echo", map[string]interface{}{"message": "over stdio"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	content, _ := result["content"].([]interface{})
+	if len(content) != 1 {
+		t.Fatalf("Expected one content item, got %v", result)
+	}
+	if text, _ := content[0].(map[string]interface{})["text"].(string); text != "over stdio" {
+		t.Errorf("Expected echoed text, got %v", result)
+	}
+}
@@ -0,0 +1,73 @@
+package hostsim
+
+// Profile describes how a host application behaves as an MCP client. The
+// built-in profiles approximate the behavior of popular hosts closely enough
+// to surface the compatibility problems they are known for; they are not
+// exact reimplementations.
+type Profile struct {
+	// Name identifies the profile in reports.
+	Name string
+
+	// ClientName and ClientVersion are sent as clientInfo at initialize.
+	ClientName    string
+	ClientVersion string
+
+	// ProtocolVersion is the revision the host asks for.
+	ProtocolVersion string
+
+	// Capabilities are the client capabilities declared at initialize.
+	Capabilities map[string]interface{}
+
+	// StrictSchemas makes the host refuse to load tools whose input schema
+	// it cannot use, instead of ignoring the problem.
+	StrictSchemas bool
+
+	// ReconnectEvery repeats the initialize handshake on the same
+	// connection after this many tool calls. Zero never reconnects.
+	ReconnectEvery int
+
+	// ListToolsOnConnect makes the host call tools/list after every handshake.
+	ListToolsOnConnect bool
+}
+
+// ClaudeDesktop validates tool input schemas strictly and refuses to load a
+// server whose tools have unusable schemas.
+var ClaudeDesktop = Profile{
+	Name:               "claude-desktop",
+	ClientName:         "claude-ai",
+	ClientVersion:      "0.1.0",
+	ProtocolVersion:    "2024-11-05",
+	Capabilities:       map[string]interface{}{},
+	StrictSchemas:      true,
+	ListToolsOnConnect: true,
+}
+
+// Cursor re-runs the initialize handshake on an existing connection, which
+// servers that enforce a single initialize per session reject.
+var Cursor = Profile{
+	Name:               "cursor",
+	ClientName:         "cursor-vscode",
+	ClientVersion:      "1.0.0",
+	ProtocolVersion:    "2025-03-26",
+	Capabilities:       map[string]interface{}{"roots": map[string]interface{}{"listChanged": false}},
+	ReconnectEvery:     3,
+	ListToolsOnConnect: true,
+}
+
+// VSCode declares roots and sampling support and uses the 2025-03-26 revision.
+var VSCode = Profile{
+	Name:            "vscode",
+	ClientName:      "Visual Studio Code",
+	ClientVersion:   "1.99.0",
+	ProtocolVersion: "2025-03-26",
+	Capabilities: map[string]interface{}{
+		"roots":    map[string]interface{}{"listChanged": true},
+		"sampling": map[string]interface{}{},
+	},
+	ListToolsOnConnect: true,
+}
+
+// Profiles returns the built-in host profiles.
+func Profiles() []Profile {
+	return []Profile{ClaudeDesktop, Cursor, VSCode}
+}
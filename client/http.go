@@ -3,9 +3,11 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -133,6 +135,10 @@ type httpTransport struct {
 	connectionTimeout   time.Duration
 	notificationHandler func(method string, params []byte)
 	headers             map[string]string
+
+	// peerCert is the leaf certificate presented by the server on the last TLS response
+	peerCert   *x509.Certificate
+	peerCertMu sync.RWMutex
 }
 
 // Connect implements the Transport interface.
@@ -181,6 +187,13 @@ func (t *httpTransport) SendWithContext(ctx context.Context, message []byte) ([]
 	}
 	defer resp.Body.Close()
 
+	// Remember the server certificate for identity pinning
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		t.peerCertMu.Lock()
+		t.peerCert = resp.TLS.PeerCertificates[0]
+		t.peerCertMu.Unlock()
+	}
+
 	// Check response status
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("HTTP request failed with status: %d", resp.StatusCode)
@@ -195,6 +208,14 @@ func (t *httpTransport) SendWithContext(ctx context.Context, message []byte) ([]
 	return body, nil
 }
 
+// PeerCertificate returns the leaf TLS certificate presented by the server,
+// or nil if the connection does not use TLS.
+func (t *httpTransport) PeerCertificate() *x509.Certificate {
+	t.peerCertMu.RLock()
+	defer t.peerCertMu.RUnlock()
+	return t.peerCert
+}
+
 // SetRequestTimeout implements the Transport interface.
 func (t *httpTransport) SetRequestTimeout(timeout time.Duration) {
 	t.requestTimeout = timeout
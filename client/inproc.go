@@ -0,0 +1,29 @@
+package client
+
+import (
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+// WithInProcess configures the client to talk to a server in the same
+// process through the client end of an inproc.Pair.
+//
+// Example:
+//
+//	c, s := inproc.Pair()
+//	srv := server.NewServer("test", server.WithTransport(s))
+//	go srv.Run()
+//
+//	client.NewClient("test", client.WithInProcess(c))
+func WithInProcess(transport *inproc.ClientTransport) Option {
+	return func(c *clientImpl) {
+		transport.SetRequestTimeout(c.requestTimeout)
+		transport.SetConnectionTimeout(c.connectionTimeout)
+		c.transport = transport
+
+		// The server is built from this module, so it supports the newest
+		// stable revision
+		if c.negotiatedVersion == "" {
+			c.negotiatedVersion = "2025-03-26"
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Package client provides the client-side implementation of the MCP protocol.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+)
+
+// Tools iterates over the server's tools, requesting further pages from
+// tools/list as the loop advances. Iteration stops at the first error, which
+// is yielded with a zero Tool.
+//
+// Example:
+//
+//	for tool, err := range client.Tools(ctx) {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    fmt.Println(tool.Name)
+//	}
+func (c *clientImpl) Tools(ctx context.Context) iter.Seq2[Tool, error] {
+	return paginate[Tool](ctx, c, "tools/list", "tools")
+}
+
+// Resources iterates over the server's resources across resources/list pages.
+func (c *clientImpl) Resources(ctx context.Context) iter.Seq2[Resource, error] {
+	return paginate[Resource](ctx, c, "resources/list", "resources")
+}
+
+// Prompts iterates over the server's prompts across prompts/list pages.
+func (c *clientImpl) Prompts(ctx context.Context) iter.Seq2[Prompt, error] {
+	return paginate[Prompt](ctx, c, "prompts/list", "prompts")
+}
+
+// ListAllTools returns every tool the server has, requesting each page of
+// tools/list in turn.
+func (c *clientImpl) ListAllTools(ctx context.Context) ([]Tool, error) {
+	return collect(c.Tools(ctx))
+}
+
+// ListAllResources returns every resource across resources/list pages.
+func (c *clientImpl) ListAllResources(ctx context.Context) ([]Resource, error) {
+	return collect(c.Resources(ctx))
+}
+
+// ListAllPrompts returns every prompt across prompts/list pages.
+func (c *clientImpl) ListAllPrompts(ctx context.Context) ([]Prompt, error) {
+	return collect(c.Prompts(ctx))
+}
+
+// IterateTool iterates over the items of a paginated tool's results, such
+// as those of tools wrapped by server.Paginated. It calls the tool with args,
+// and again with the result's nextCursor as the cursor argument as the loop
+// advances, decoding the items of each page into Item. Iteration stops at
+// the first error, which is yielded with a zero Item.
+//
+// Example:
+//
+//	for issue, err := range client.IterateTool[Issue](ctx, c, "search", map[string]interface{}{"query": "crash"}) {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    fmt.Println(issue.Title)
+//	}
+func IterateTool[Item any](ctx context.Context, c Client, name string, args map[string]interface{}, opts ...CallOption) iter.Seq2[Item, error] {
+	return func(yield func(Item, error) bool) {
+		var zero Item
+		cursor := ""
+		seen := make(map[string]bool)
+
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			callArgs := make(map[string]interface{}, len(args)+1)
+			for key, value := range args {
+				callArgs[key] = value
+			}
+			if cursor != "" {
+				callArgs["cursor"] = cursor
+			}
+
+			result, err := c.CallTool(name, callArgs, opts...)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			var page struct {
+				Items      []Item `json:"items"`
+				NextCursor string `json:"nextCursor"`
+			}
+			if err := decodeToolResult(name, result, &page); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range page.Items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			// Stop on the last page, and guard against tools that repeat a cursor
+			if page.NextCursor == "" || seen[page.NextCursor] {
+				return
+			}
+			seen[page.NextCursor] = true
+			cursor = page.NextCursor
+		}
+	}
+}
+
+// CallToolAll returns every item of a paginated tool's results, calling it
+// for each page in turn.
+func CallToolAll[Item any](ctx context.Context, c Client, name string, args map[string]interface{}, opts ...CallOption) ([]Item, error) {
+	return collect(IterateTool[Item](ctx, c, name, args, opts...))
+}
+
+// collect gathers the entries of a paginated list, stopping at the first
+// error.
+func collect[T any](entries iter.Seq2[T, error]) ([]T, error) {
+	var all []T
+	for entry, err := range entries {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, entry)
+	}
+	return all, nil
+}
+
+// paginate walks the cursors of a list method, yielding each entry under key.
+func paginate[T any](ctx context.Context, c *clientImpl, method, key string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		cursor := ""
+		seen := make(map[string]bool)
+
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			var params interface{}
+			if cursor != "" {
+				params = map[string]interface{}{"cursor": cursor}
+			}
+
+			result, err := c.sendRequest(method, params)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			page, err := decodePage[T](result, key)
+			if err != nil {
+				yield(zero, fmt.Errorf("failed to decode %s result: %w", method, err))
+				return
+			}
+
+			for _, entry := range page.entries {
+				if !yield(entry, nil) {
+					return
+				}
+			}
+
+			// Stop on the last page, and guard against servers that repeat a cursor
+			if page.nextCursor == "" || seen[page.nextCursor] {
+				return
+			}
+			seen[page.nextCursor] = true
+			cursor = page.nextCursor
+		}
+	}
+}
+
+// listPage is one decoded page of a list result.
+type listPage[T any] struct {
+	entries    []T
+	nextCursor string
+}
+
+// decodePage decodes the entries under key and the nextCursor of a list result.
+func decodePage[T any](result interface{}, key string) (listPage[T], error) {
+	var page listPage[T]
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return page, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return page, err
+	}
+	if entries, ok := raw[key]; ok {
+		if err := json.Unmarshal(entries, &page.entries); err != nil {
+			return page, err
+		}
+	}
+	if cursor, ok := raw["nextCursor"]; ok {
+		if err := json.Unmarshal(cursor, &page.nextCursor); err != nil {
+			return page, err
+		}
+	}
+	return page, nil
+}
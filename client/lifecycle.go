@@ -91,6 +91,11 @@ func (c *clientImpl) initialize() error {
 		},
 	}
 
+	// Declare locale and time zone hints, if any
+	if meta := c.initializeMeta(); meta != nil {
+		initRequest["params"].(map[string]interface{})["_meta"] = meta
+	}
+
 	// Convert the request to JSON
 	requestJSON, err := json.Marshal(initRequest)
 	if err != nil {
@@ -124,7 +129,7 @@ func (c *clientImpl) initialize() error {
 
 	// Check for error response
 	if response.Error != nil {
-		return fmt.Errorf("server returned error: %s (code %d)", response.Error.Message, response.Error.Code)
+		return serverError(response.Error.Code, response.Error.Message, response.Error.Data)
 	}
 
 	// Extract the negotiated protocol version
@@ -139,6 +144,13 @@ func (c *clientImpl) initialize() error {
 	}
 
 	c.negotiatedVersion = protocolVersion.(string)
+	c.serverCapabilities, _ = response.Result["capabilities"].(map[string]interface{})
+
+	// Compare the server's identity against the one recorded on first use
+	// before acknowledging the handshake
+	if err := c.verifyServerIdentity(response.Result); err != nil {
+		return err
+	}
 	c.initialized = true
 
 	c.logger.Info("initialized client connection",
@@ -154,6 +166,17 @@ func (c *clientImpl) initialize() error {
 	// Setup notification handler
 	c.registerNotificationHandler()
 
+	// Compare the tools against the fingerprint recorded on first use. Servers
+	// may hold or reject tools/list until notifications/initialized, so this
+	// can only happen once the handshake is complete.
+	if err := c.verifyToolSchemas(); err != nil {
+		c.initialized = false
+		return err
+	}
+
+	// Restore resource subscriptions from a previous connection
+	c.resubscribeResources()
+
 	return nil
 }
 
@@ -179,12 +202,31 @@ func (c *clientImpl) sendInitializedNotification() error {
 	return nil
 }
 
+// handlePing answers a ping from the server, which checks the client is
+// still there.
+func (c *clientImpl) handlePing(requestID int64) error {
+	responseJSON, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      requestID,
+		"result":  map[string]interface{}{},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal ping response: %w", err)
+	}
+	if _, err := c.transport.Send(responseJSON); err != nil {
+		return fmt.Errorf("failed to send ping response: %w", err)
+	}
+	return nil
+}
+
 // Close closes the client connection.
 func (c *clientImpl) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	if !c.connected {
+		// Stop any reconnect in progress
+		c.cancel()
 		return nil
 	}
 
@@ -245,14 +287,24 @@ func (c *clientImpl) registerNotificationHandler() {
 			Params  json.RawMessage `json:"params,omitempty"`
 		}
 
-		if err := json.Unmarshal(params, &request); err != nil {
-			c.logger.Error("failed to parse server message", "error", err)
-			return
+		// Some transports deliver the full message, others the method and its params
+		if method == "" {
+			if err := json.Unmarshal(params, &request); err != nil {
+				c.logger.Error("failed to parse server message", "error", err)
+				return
+			}
+		} else {
+			request.Method = method
+			request.Params = params
 		}
 
 		// Handle request methods
 		if request.ID != 0 {
 			switch request.Method {
+			case "ping":
+				if err := c.handlePing(request.ID); err != nil {
+					c.logger.Error("failed to handle ping request", "error", err)
+				}
 			case "roots/list":
 				if err := c.handleRootsList(request.ID); err != nil {
 					c.logger.Error("failed to handle roots/list request", "error", err)
@@ -283,9 +335,38 @@ func (c *clientImpl) registerNotificationHandler() {
 
 		// Handle notification methods
 		switch request.Method {
-		// Handle server notifications here
+		case "notifications/resources/updated":
+			c.handleResourceUpdated(request.Params)
+		case "notifications/tools/content":
+			c.handleToolContent(request.Params)
+		case "notifications/tools/list_changed":
+			c.mu.Lock()
+			c.toolSchemas = nil
+			c.mu.Unlock()
+			if c.trustStore != nil {
+				// Listing tools waits on the transport that delivers this notification
+				go c.recheckToolSchemas()
+			}
+		case "notifications/message":
+			c.handleLogMessage(request.Params)
 		default:
 			c.logger.Debug("received notification", "method", request.Method)
 		}
 	})
 }
+
+// initializeMeta returns the _meta sent with the initialize request, or nil if
+// the client has nothing to declare.
+func (c *clientImpl) initializeMeta() map[string]interface{} {
+	meta := make(map[string]interface{})
+	if c.locale != "" {
+		meta["locale"] = c.locale
+	}
+	if c.timezone != "" {
+		meta["timezone"] = c.timezone
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta
+}
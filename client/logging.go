@@ -0,0 +1,90 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// LogMessage is a log message the server sent in a notifications/message.
+type LogMessage struct {
+	// Level is the message's severity, one of the eight levels MCP defines,
+	// from "debug" to "emergency".
+	Level string
+
+	// Logger names the source of the message, or is empty.
+	Logger string
+
+	// Data is the message's content as decoded from JSON, typically a
+	// string or a map of details.
+	Data interface{}
+}
+
+// ErrLoggingNotSupported is returned when the server does not advertise
+// the logging capability.
+var ErrLoggingNotSupported = errors.New("server does not support logging")
+
+// logLevels are the levels SetLogLevel accepts.
+var logLevels = map[string]bool{
+	"debug": true, "info": true, "notice": true, "warning": true,
+	"error": true, "critical": true, "alert": true, "emergency": true,
+}
+
+// OnLogMessage calls handler with every log message the server sends, so
+// hosts can show or record server logs. The server sends messages at the
+// level set with SetLogLevel and above.
+//
+// Example:
+//
+//	c, err := client.NewClient("ws://localhost:8080/mcp",
+//	    client.OnLogMessage(func(m client.LogMessage) {
+//	        log.Printf("[%s] %s: %v", m.Level, m.Logger, m.Data)
+//	    }),
+//	)
+func OnLogMessage(handler func(LogMessage)) Option {
+	return func(c *clientImpl) {
+		c.logHandler = handler
+	}
+}
+
+// SetLogLevel asks the server to send log messages at level and above.
+func (c *clientImpl) SetLogLevel(level string) error {
+	if !logLevels[level] {
+		return fmt.Errorf("unknown log level %q", level)
+	}
+	if !c.supportsLogging() {
+		return ErrLoggingNotSupported
+	}
+	if _, err := c.sendRequest("logging/setLevel", map[string]interface{}{"level": level}); err != nil {
+		return fmt.Errorf("failed to set log level: %w", err)
+	}
+	return nil
+}
+
+// supportsLogging reports whether the server advertised the logging
+// capability.
+func (c *clientImpl) supportsLogging() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, ok := c.serverCapabilities["logging"]
+	return ok
+}
+
+// dispatchLogMessage passes a notifications/message to the OnLogMessage
+// handler.
+func (c *clientImpl) dispatchLogMessage(params []byte) {
+	if c.logHandler == nil {
+		return
+	}
+	var message struct {
+		Level  string      `json:"level"`
+		Logger string      `json:"logger"`
+		Data   interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(params, &message); err != nil {
+		c.logger.Debug("invalid log message", "error", err)
+		return
+	}
+	c.logHandler(LogMessage{Level: message.Level, Logger: message.Logger, Data: message.Data})
+}
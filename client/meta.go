@@ -0,0 +1,83 @@
+// Package client provides the client-side implementation of the MCP protocol.
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// CallOption customizes a single request.
+type CallOption func(*callOptions)
+
+// callOptions holds the settings collected from CallOptions.
+type callOptions struct {
+	meta    map[string]interface{}
+	timeout time.Duration
+	ctx     context.Context
+}
+
+// WithCallMeta attaches fields to the _meta object of a request, such as a
+// progress token, trace context or idempotency key. Repeated options are
+// merged, with later keys taking precedence.
+//
+// Example:
+//
+//	result, err := client.CallTool("charge", args, client.WithCallMeta(map[string]any{
+//	    "idempotencyKey": orderID,
+//	    "traceparent":    traceparent,
+//	}))
+func WithCallMeta(meta map[string]interface{}) CallOption {
+	return func(o *callOptions) {
+		if o.meta == nil {
+			o.meta = make(map[string]interface{}, len(meta))
+		}
+		for key, value := range meta {
+			o.meta[key] = value
+		}
+	}
+}
+
+// WithCallTimeout sets how long to wait for the response to this request,
+// in place of the client's request timeout. Transports that enforce their
+// own request timeout still stop waiting when it runs out, so raise
+// WithRequestTimeout to allow calls longer than it.
+//
+// Example:
+//
+//	result, err := client.CallTool("quick-lookup", args, client.WithCallTimeout(2*time.Second))
+func WithCallTimeout(timeout time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithContext ties a request to ctx: when ctx is canceled before the
+// response arrives, the client stops waiting, returns ctx's error and sends
+// the server a notifications/cancelled for the request, which cancels the
+// context of its handler.
+//
+// Example:
+//
+//	ctx, cancel := context.WithCancel(context.Background())
+//	defer cancel()
+//	result, err := client.CallTool("crawl", args, client.WithContext(ctx))
+func WithContext(ctx context.Context) CallOption {
+	return func(o *callOptions) {
+		if ctx != nil {
+			o.ctx = ctx
+		}
+	}
+}
+
+// applyCallOptions adds the _meta collected from opts to request params,
+// and returns the collected options.
+func applyCallOptions(params map[string]interface{}, opts []CallOption) callOptions {
+	o := callOptions{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if len(o.meta) > 0 {
+		params["_meta"] = o.meta
+	}
+	return o
+}
@@ -86,6 +86,36 @@ func WithSamplingCapability(enabled bool, config map[string]interface{}) Option
 	}
 }
 
+// OnSamplingRequest serves the server's sampling/createMessage requests
+// with handler, which typically forwards them to the host's language model,
+// and declares the sampling capability during initialization so servers
+// know they may send them.
+//
+// Example:
+//
+//	c, err := client.NewClient("stdio:///weather-server",
+//	    client.OnSamplingRequest(func(params client.SamplingCreateMessageParams) (client.SamplingResponse, error) {
+//	        text, err := llm.Complete(params.SystemPrompt, params.Messages, params.MaxTokens)
+//	        if err != nil {
+//	            return client.SamplingResponse{}, err
+//	        }
+//	        return client.SamplingResponse{
+//	            Role:       "assistant",
+//	            Content:    client.SamplingMessageContent{Type: "text", Text: text},
+//	            Model:      llm.Name,
+//	            StopReason: "endTurn",
+//	        }, nil
+//	    }),
+//	)
+func OnSamplingRequest(handler SamplingHandler) Option {
+	return func(c *clientImpl) {
+		c.samplingHandler = handler
+		if c.capabilities.Sampling == nil {
+			c.capabilities.Sampling = map[string]interface{}{}
+		}
+	}
+}
+
 // WithExperimentalCapability adds an experimental capability.
 func WithExperimentalCapability(name string, config interface{}) Option {
 	return func(c *clientImpl) {
@@ -96,6 +126,49 @@ func WithExperimentalCapability(name string, config interface{}) Option {
 	}
 }
 
+// ContextBudget is the share of its model's context window a host can give
+// the server's results, in tokens.
+type ContextBudget struct {
+	// ContextWindow is the size of the model's context window.
+	ContextWindow int `json:"contextWindow,omitempty"`
+
+	// MaxResultTokens is how many tokens a single tool result or resource
+	// should take at most.
+	MaxResultTokens int `json:"maxResultTokens,omitempty"`
+}
+
+// WithContextBudget declares the host's context budget at initialize, as
+// the experimental "contextBudget" capability, so servers can tune how
+// verbose their results are, where they truncate and how much a page holds.
+//
+// Example:
+//
+//	c, err := client.NewClient("desktop", client.WithContextBudget(client.ContextBudget{
+//	    ContextWindow:   200000,
+//	    MaxResultTokens: 8000,
+//	}))
+func WithContextBudget(budget ContextBudget) Option {
+	return WithExperimentalCapability("contextBudget", budget)
+}
+
+// WithLocale declares the user's preferred language, as a BCP 47 tag such as
+// "fr-CA", so servers can localize tool output. It is sent in the _meta of the
+// initialize request.
+func WithLocale(locale string) Option {
+	return func(c *clientImpl) {
+		c.locale = locale
+	}
+}
+
+// WithTimezone declares the user's time zone as an IANA name such as
+// "Europe/Paris", so servers can format dates and times for the user. It is
+// sent in the _meta of the initialize request.
+func WithTimezone(name string) Option {
+	return func(c *clientImpl) {
+		c.timezone = name
+	}
+}
+
 // WithProtocolVersion sets a specific protocol version for the client to use.
 // This bypasses the normal negotiation process and forces the client to use this version.
 // This is useful for testing or when you know exactly which version the server expects.
@@ -5,10 +5,27 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
+
+	"github.com/localrivet/gomcp/protocol"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // sendRequest sends a JSON-RPC request to the server and parses the response.
 func (c *clientImpl) sendRequest(method string, params interface{}) (interface{}, error) {
+	return c.sendRequestWithTimeout(method, params, 0)
+}
+
+// sendRequestWithTimeout sends a request like sendRequest, waiting up to
+// timeout for the response. A zero timeout uses the client's request
+// timeout.
+func (c *clientImpl) sendRequestWithTimeout(method string, params interface{}, timeout time.Duration) (interface{}, error) {
+	return c.sendRequestContext(context.Background(), method, params, timeout)
+}
+
+// sendRequestContext sends a request like sendRequestWithTimeout, giving up
+// on it when ctx is canceled.
+func (c *clientImpl) sendRequestContext(ctx context.Context, method string, params interface{}, timeout time.Duration) (interface{}, error) {
 	c.mu.RLock()
 	connected := c.connected
 	c.mu.RUnlock()
@@ -19,10 +36,48 @@ func (c *clientImpl) sendRequest(method string, params interface{}) (interface{}
 		}
 	}
 
+	result, err := c.doRequestContext(ctx, method, params, timeout)
+	if err != nil && c.retry != nil && idempotent(method, params) {
+		result, err = c.retryRequest(ctx, method, params, timeout, err)
+	}
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, c.explainMissingCapability(method, c.handleConnectionLoss(err))
+	}
+	c.upgradeLegacyResult(method, result)
+	return result, nil
+}
+
+// doRequest sends a JSON-RPC request over the current transport without
+// checking the connection state. It is safe to call while c.mu is held. A
+// zero timeout uses the client's request timeout.
+func (c *clientImpl) doRequest(method string, params interface{}, timeout time.Duration) (interface{}, error) {
+	return c.doRequestContext(context.Background(), method, params, timeout)
+}
+
+// doRequestContext sends a request like doRequest, giving up on it when
+// call is canceled. Requests given up on, whether by call or by running out
+// of time, are cancelled on the server with notifications/cancelled.
+func (c *clientImpl) doRequestContext(call context.Context, method string, params interface{}, timeout time.Duration) (result interface{}, err error) {
+	if err := call.Err(); err != nil {
+		return nil, err
+	}
+	id := c.generateRequestID()
+
+	// Trace the request, passing its trace context on in _meta
+	parent := c.ctx
+	if c.tracer != nil {
+		var span trace.Span
+		params, parent, span = c.startSpan(method, id, params)
+		defer func() { endSpan(span, err) }()
+	}
+
 	// Create the request
 	request := map[string]interface{}{
 		"jsonrpc": "2.0",
-		"id":      c.generateRequestID(),
+		"id":      id,
 		"method":  method,
 	}
 
@@ -37,13 +92,24 @@ func (c *clientImpl) sendRequest(method string, params interface{}) (interface{}
 	}
 
 	// Create a context with the request timeout
-	ctx, cancel := context.WithTimeout(c.ctx, c.requestTimeout)
+	if timeout <= 0 {
+		timeout = c.requestTimeout
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
+	stop := context.AfterFunc(call, cancel)
+	defer stop()
 
 	// Send the request
 	responseJSON, err := c.transport.SendWithContext(ctx, requestJSON)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		if ctx.Err() != nil && method != "initialize" {
+			c.cancelRequest(id, ctx.Err())
+		}
+		if call.Err() != nil {
+			return nil, call.Err()
+		}
+		return nil, &sendError{err: err}
 	}
 
 	// Parse the response
@@ -64,14 +130,21 @@ func (c *clientImpl) sendRequest(method string, params interface{}) (interface{}
 
 	// Check for error response
 	if response.Error != nil {
-		return nil, fmt.Errorf("server returned error: %s (code %d)", response.Error.Message, response.Error.Code)
+		return nil, serverError(response.Error.Code, response.Error.Message, response.Error.Data)
 	}
 
 	return response.Result, nil
 }
 
+// serverError returns the error a server answered a request with. It wraps
+// a *protocol.Error, so callers can branch on its code with
+// protocol.IsErrorCode.
+func serverError(code int, message string, data interface{}) error {
+	return fmt.Errorf("server returned error: %w (code %d)", &protocol.Error{Code: code, Message: message, Data: data}, code)
+}
+
 // CallTool calls a tool on the server.
-func (c *clientImpl) CallTool(name string, args map[string]interface{}) (interface{}, error) {
+func (c *clientImpl) CallTool(name string, args map[string]interface{}, opts ...CallOption) (interface{}, error) {
 	params := map[string]interface{}{
 		"name": name,
 	}
@@ -79,8 +152,28 @@ func (c *clientImpl) CallTool(name string, args map[string]interface{}) (interfa
 	if args != nil {
 		params["arguments"] = args
 	}
+	o := applyCallOptions(params, opts)
 
-	return c.sendRequest("tools/call", params)
+	return c.sendRequestContext(o.ctx, "tools/call", params, o.timeout)
+}
+
+// cancelRequest tells the server that the client gave up on a request, so
+// that it stops working on it.
+func (c *clientImpl) cancelRequest(id int64, reason error) {
+	notification, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/cancelled",
+		"params": map[string]interface{}{
+			"requestId": id,
+			"reason":    reason.Error(),
+		},
+	})
+	if err != nil {
+		return
+	}
+	if _, err := c.transport.Send(notification); err != nil {
+		c.logger.Debug("failed to send cancellation", "requestId", id, "error", err)
+	}
 }
 
 // GetResource retrieves a resource from the server.
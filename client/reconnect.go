@@ -0,0 +1,288 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/localrivet/gomcp/protocol"
+)
+
+// ErrConnectionLost is returned, wrapped around the transport's error, by
+// requests that fail because the connection to the server was lost. With
+// WithAutoReconnect, the client is reconnecting when it is returned.
+var ErrConnectionLost = errors.New("connection to server lost")
+
+// ConnectionState is the state of the client's connection to the server.
+type ConnectionState string
+
+const (
+	// ConnectionConnected means the connection is up and initialized.
+	ConnectionConnected ConnectionState = "connected"
+
+	// ConnectionDisconnected means the connection failed and no reconnect attempt
+	// has started yet.
+	ConnectionDisconnected ConnectionState = "disconnected"
+
+	// ConnectionReconnecting means a reconnect attempt is in progress.
+	ConnectionReconnecting ConnectionState = "reconnecting"
+
+	// ConnectionFailed means the policy's MaxAttempts were used up. The next
+	// request will try to connect again.
+	ConnectionFailed ConnectionState = "failed"
+)
+
+// ConnectionEvent reports a change of connection state.
+type ConnectionEvent struct {
+	State ConnectionState
+
+	// Attempt is the number of the reconnect attempt, starting at 1, for
+	// the reconnecting state and the failed attempt's error.
+	Attempt int
+
+	// Err is the error that caused the change, if any.
+	Err error
+
+	// Shutdown is the shutdown the server announced before the connection
+	// was lost, if any, for the disconnected state.
+	Shutdown *ShutdownEvent
+}
+
+// ReconnectPolicy configures WithAutoReconnect. Zero values select the defaults.
+type ReconnectPolicy struct {
+	// InitialDelay is the wait before the first reconnect attempt. The
+	// default is 500ms.
+	InitialDelay time.Duration
+
+	// MaxDelay caps the wait between attempts. The default is 30s.
+	MaxDelay time.Duration
+
+	// Multiplier is applied to the wait after each failed attempt. The
+	// default is 2.
+	Multiplier float64
+
+	// Jitter randomizes each wait by up to this fraction of it, so that many
+	// clients don't reconnect in step. The default is 0.2; use a negative
+	// value to disable it.
+	Jitter float64
+
+	// MaxAttempts is how many attempts are made before giving up. Zero means
+	// no limit.
+	MaxAttempts int
+
+	// HealthCheckInterval is how often the server is pinged to detect
+	// connections that drop silently. Zero disables health checks, and loss
+	// is then detected when a request fails.
+	HealthCheckInterval time.Duration
+
+	// OnStateChange is called with every change of connection state.
+	OnStateChange func(ConnectionEvent)
+}
+
+// WithAutoReconnect makes the client reconnect when the connection to the
+// server is lost. Loss is detected from requests that fail at the transport
+// and, if the policy enables them, from failed health-check pings.
+//
+// Reconnecting connects the transport again, replays initialize and
+// restores resource subscriptions. The request that detected the loss fails
+// with ErrConnectionLost and is not retried, since the server may have
+// processed it.
+//
+// Example:
+//
+//	c, err := client.NewClient("sse://localhost:8080/mcp",
+//	    client.WithAutoReconnect(client.ReconnectPolicy{
+//	        MaxDelay:            time.Minute,
+//	        HealthCheckInterval: 15 * time.Second,
+//	        OnStateChange: func(e client.ConnectionEvent) {
+//	            log.Printf("connection %s (attempt %d): %v", e.State, e.Attempt, e.Err)
+//	        },
+//	    }),
+//	)
+func WithAutoReconnect(policy ReconnectPolicy) Option {
+	return func(c *clientImpl) {
+		if policy.InitialDelay <= 0 {
+			policy.InitialDelay = 500 * time.Millisecond
+		}
+		if policy.MaxDelay <= 0 {
+			policy.MaxDelay = 30 * time.Second
+		}
+		if policy.Multiplier < 1 {
+			policy.Multiplier = 2
+		}
+		if policy.Jitter == 0 {
+			policy.Jitter = 0.2
+		}
+		c.reconnect = &reconnector{policy: policy}
+	}
+}
+
+// reconnector holds the reconnect state of a client.
+type reconnector struct {
+	policy ReconnectPolicy
+
+	mu            sync.Mutex
+	running       bool
+	healthStarted bool
+}
+
+// sendError is a request failure at the transport.
+type sendError struct {
+	err error
+}
+
+func (e *sendError) Error() string {
+	return "failed to send request: " + e.err.Error()
+}
+
+func (e *sendError) Unwrap() error {
+	return e.err
+}
+
+// connectionLost reports whether a request error means the connection is
+// gone, rather than the request timing out or the server rejecting it.
+func connectionLost(err error) bool {
+	var send *sendError
+	if !errors.As(err, &send) {
+		return false
+	}
+	return !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled)
+}
+
+// handleConnectionLoss starts reconnecting after a request failed at the
+// transport, and returns the error to report for the request.
+func (c *clientImpl) handleConnectionLoss(err error) error {
+	if c.reconnect == nil || !connectionLost(err) {
+		return err
+	}
+	c.startReconnect(err)
+	return fmt.Errorf("%w: %w", ErrConnectionLost, err)
+}
+
+// startReconnect marks the connection as lost and reconnects in the
+// background, unless a reconnect is already running.
+func (c *clientImpl) startReconnect(cause error) {
+	r := c.reconnect
+	r.mu.Lock()
+	if r.running || c.ctx.Err() != nil {
+		r.mu.Unlock()
+		return
+	}
+	r.running = true
+	r.mu.Unlock()
+
+	c.mu.Lock()
+	c.connected = false
+	c.initialized = false
+	c.transport.Disconnect()
+	notice := c.shutdownNotice
+	c.shutdownNotice = nil
+	c.mu.Unlock()
+
+	c.logger.Warn("connection to server lost", "url", c.url, "error", cause)
+	c.emitConnectionEvent(ConnectionEvent{State: ConnectionDisconnected, Err: cause, Shutdown: notice})
+
+	firstDelay := r.policy.InitialDelay
+	if notice != nil && notice.ReconnectAfter > firstDelay {
+		firstDelay = notice.ReconnectAfter
+	}
+	go c.reconnectLoop(firstDelay)
+}
+
+// reconnectLoop connects again with exponential backoff, starting from
+// delay, until it succeeds, the attempts run out or the client is closed.
+func (c *clientImpl) reconnectLoop(delay time.Duration) {
+	r := c.reconnect
+	defer func() {
+		r.mu.Lock()
+		r.running = false
+		r.mu.Unlock()
+	}()
+
+	for attempt := 1; r.policy.MaxAttempts == 0 || attempt <= r.policy.MaxAttempts; attempt++ {
+		select {
+		case <-time.After(jittered(delay, r.policy.Jitter)):
+		case <-c.ctx.Done():
+			return
+		}
+
+		c.emitConnectionEvent(ConnectionEvent{State: ConnectionReconnecting, Attempt: attempt})
+		err := c.Connect()
+		if err == nil {
+			c.logger.Info("reconnected to server", "url", c.url, "attempts", attempt)
+			c.emitConnectionEvent(ConnectionEvent{State: ConnectionConnected, Attempt: attempt})
+			return
+		}
+		if c.ctx.Err() != nil {
+			return
+		}
+		c.logger.Warn("reconnect attempt failed", "attempt", attempt, "error", err)
+
+		delay = time.Duration(float64(delay) * r.policy.Multiplier)
+		if delay > r.policy.MaxDelay {
+			delay = r.policy.MaxDelay
+		}
+	}
+
+	c.logger.Error("giving up reconnecting to server", "url", c.url, "attempts", r.policy.MaxAttempts)
+	c.emitConnectionEvent(ConnectionEvent{State: ConnectionFailed, Attempt: r.policy.MaxAttempts})
+}
+
+// jittered randomizes a delay by up to the given fraction of it.
+func jittered(delay time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return delay
+	}
+	spread := float64(delay) * jitter
+	return delay + time.Duration(spread*(2*rand.Float64()-1))
+}
+
+// startHealthCheck pings the server at the policy's interval for the life
+// of the client. It is started once, after the first connection.
+func (c *clientImpl) startHealthCheck() {
+	r := c.reconnect
+	if r == nil || r.policy.HealthCheckInterval <= 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.healthStarted {
+		return
+	}
+	r.healthStarted = true
+
+	go func() {
+		ticker := time.NewTicker(r.policy.HealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-c.ctx.Done():
+				return
+			}
+
+			r.mu.Lock()
+			running := r.running
+			r.mu.Unlock()
+			if running || !c.IsConnected() {
+				continue
+			}
+			if _, err := c.doRequest("ping", nil, 0); err != nil && c.ctx.Err() == nil {
+				var rpcErr *protocol.Error
+				if !errors.As(err, &rpcErr) {
+					c.startReconnect(err)
+				}
+			}
+		}
+	}()
+}
+
+// emitConnectionEvent calls the policy's state handler, if any.
+func (c *clientImpl) emitConnectionEvent(event ConnectionEvent) {
+	if c.reconnect != nil && c.reconnect.policy.OnStateChange != nil {
+		c.reconnect.policy.OnStateChange(event)
+	}
+}
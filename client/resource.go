@@ -0,0 +1,137 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ResourceContent is an item of the content of a resource.
+type ResourceContent struct {
+	// URI identifies the item, if the server gave it.
+	URI string
+
+	// MimeType is the media type of the item, if the server gave it.
+	MimeType string
+
+	// Text holds the content of text items.
+	Text string
+
+	// Blob holds the decoded content of binary items; it is nil for text.
+	Blob []byte
+
+	// SHA256 is the hex-encoded digest the server sent with the item, which
+	// has been verified, or empty if none was sent.
+	SHA256 string
+}
+
+// resourceItem is a content item of a resources/read result, in any of the
+// shapes the protocol versions use.
+type resourceItem struct {
+	URI      string         `json:"uri"`
+	MimeType string         `json:"mimeType"`
+	Text     *string        `json:"text"`
+	Blob     *string        `json:"blob"`
+	Data     *string        `json:"data"`
+	Content  []resourceItem `json:"content"`
+	Meta     *struct {
+		SHA256 string `json:"sha256"`
+	} `json:"_meta"`
+}
+
+// ReadResource reads a resource with resources/read and returns its content
+// items, with binary items decoded. Items the server sent a digest for are
+// verified against it, so content truncated or corrupted on the way fails
+// with an error wrapping ErrChecksumMismatch rather than being returned.
+//
+// Example:
+//
+//	contents, err := c.ReadResource("file:///images/logo.png")
+//	if err != nil {
+//	    return err
+//	}
+//	os.WriteFile("logo.png", contents[0].Blob, 0o644)
+func (c *clientImpl) ReadResource(uri string) ([]ResourceContent, error) {
+	result, err := c.sendRequest("resources/read", map[string]interface{}{"uri": uri})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("invalid resources/read result: %w", err)
+	}
+	var response struct {
+		Contents []resourceItem `json:"contents"`
+		Content  []resourceItem `json:"content"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("invalid resources/read result: %w", err)
+	}
+
+	var contents []ResourceContent
+	var collect func(items []resourceItem) error
+	collect = func(items []resourceItem) error {
+		for _, item := range items {
+			if item.Text == nil && item.Blob == nil && item.Data == nil {
+				if err := collect(item.Content); err != nil {
+					return err
+				}
+				continue
+			}
+			content, err := item.decode()
+			if err != nil {
+				return fmt.Errorf("resource %s: %w", uri, err)
+			}
+			contents = append(contents, content)
+		}
+		return nil
+	}
+	if err := collect(response.Contents); err != nil {
+		return nil, err
+	}
+	if err := collect(response.Content); err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+// decode decodes a content item and verifies its digest, if it has one.
+func (item resourceItem) decode() (ResourceContent, error) {
+	content := ResourceContent{URI: item.URI, MimeType: item.MimeType}
+	var payload []byte
+	switch {
+	case item.Blob != nil || item.Data != nil:
+		encoded := item.Blob
+		if encoded == nil {
+			encoded = item.Data
+		}
+		decoded, err := base64.StdEncoding.DecodeString(*encoded)
+		if err != nil {
+			return content, fmt.Errorf("invalid base64 content: %w", err)
+		}
+		content.Blob, payload = decoded, decoded
+	default:
+		content.Text, payload = *item.Text, []byte(*item.Text)
+	}
+
+	if item.Meta != nil && item.Meta.SHA256 != "" {
+		if err := verifyDigest(payload, item.Meta.SHA256); err != nil {
+			return content, err
+		}
+		content.SHA256 = strings.ToLower(item.Meta.SHA256)
+	}
+	return content, nil
+}
+
+// verifyDigest checks data against a hex-encoded SHA-256 digest.
+func verifyDigest(data []byte, sha256Hex string) error {
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != strings.ToLower(sha256Hex) {
+		return fmt.Errorf("%w: got %s for %d bytes, want %s", ErrChecksumMismatch, got, len(data), sha256Hex)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy configures WithRetryPolicy. Zero values select the defaults.
+type RetryPolicy struct {
+	// MaxAttempts is how many times a request is sent, counting the first
+	// attempt. The default is 3.
+	MaxAttempts int
+
+	// InitialDelay is the wait before the first retry. The default is
+	// 100ms.
+	InitialDelay time.Duration
+
+	// MaxDelay caps the wait between attempts. The default is 2s.
+	MaxDelay time.Duration
+
+	// Multiplier is applied to the wait after each failed attempt. The
+	// default is 2.
+	Multiplier float64
+
+	// Jitter randomizes each wait by up to this fraction of it, so that many
+	// clients don't retry in step. The default is 0.2; use a negative value
+	// to disable it.
+	Jitter float64
+}
+
+// idempotentMethods are the requests that can be sent again without
+// changing the result.
+var idempotentMethods = map[string]bool{
+	"ping":                     true,
+	"tools/list":               true,
+	"resources/list":           true,
+	"resources/templates/list": true,
+	"resources/read":           true,
+	"prompts/list":             true,
+	"prompts/get":              true,
+}
+
+// WithRetryPolicy retries idempotent requests that fail at the transport,
+// such as a dropped connection or a failed HTTP POST, waiting with jittered
+// exponential backoff between attempts. Requests that the server answered
+// with an error, or that timed out, are not retried.
+//
+// The idempotent requests are ping, list requests, resources/read and
+// prompts/get, and tool calls sent with an idempotency key in _meta, whose
+// results the server replays rather than calling the tool again.
+//
+// Example:
+//
+//	c, err := client.NewClient("http://localhost:8080/mcp",
+//	    client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 5}),
+//	)
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *clientImpl) {
+		if policy.MaxAttempts <= 0 {
+			policy.MaxAttempts = 3
+		}
+		if policy.InitialDelay <= 0 {
+			policy.InitialDelay = 100 * time.Millisecond
+		}
+		if policy.MaxDelay <= 0 {
+			policy.MaxDelay = 2 * time.Second
+		}
+		if policy.Multiplier < 1 {
+			policy.Multiplier = 2
+		}
+		if policy.Jitter == 0 {
+			policy.Jitter = 0.2
+		}
+		c.retry = &policy
+	}
+}
+
+// idempotent reports whether a request can be retried.
+func idempotent(method string, params interface{}) bool {
+	if idempotentMethods[method] {
+		return true
+	}
+	if method != "tools/call" {
+		return false
+	}
+	p, _ := params.(map[string]interface{})
+	meta, _ := p["_meta"].(map[string]interface{})
+	key, _ := meta["idempotencyKey"].(string)
+	return key != ""
+}
+
+// retryRequest sends a request that failed with err again, as the retry
+// policy allows, and returns the outcome of the last attempt.
+func (c *clientImpl) retryRequest(call context.Context, method string, params interface{}, timeout time.Duration, err error) (interface{}, error) {
+	policy := c.retry
+	delay := policy.InitialDelay
+	for attempt := 2; attempt <= policy.MaxAttempts && connectionLost(err); attempt++ {
+		c.logger.Debug("retrying request", "method", method, "attempt", attempt, "error", err)
+		select {
+		case <-time.After(jittered(delay, policy.Jitter)):
+		case <-c.ctx.Done():
+			return nil, err
+		case <-call.Done():
+			return nil, err
+		}
+
+		var result interface{}
+		result, err = c.doRequestContext(call, method, params, timeout)
+		if err == nil {
+			return result, nil
+		}
+
+		delay = time.Duration(float64(delay) * policy.Multiplier)
+		if delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+	}
+	return nil, err
+}
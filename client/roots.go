@@ -6,153 +6,66 @@ import (
 	"fmt"
 )
 
-// AddRoot adds a filesystem root to be exposed to the server.
+// AddRoot adds a root to those the client exposes to the server, and
+// notifies the server that the list changed.
 func (c *clientImpl) AddRoot(uri string, name string) error {
 	c.rootsMu.Lock()
-	defer c.rootsMu.Unlock()
-
-	// Check if the root already exists
 	for _, root := range c.roots {
 		if root.URI == uri {
+			c.rootsMu.Unlock()
 			return fmt.Errorf("root with URI %s already exists", uri)
 		}
 	}
-
-	// Add the root to our local cache
 	c.roots = append(c.roots, Root{
 		URI:  uri,
 		Name: name,
 	})
+	c.rootsMu.Unlock()
 
-	// Only send the actual request if we're initialized
-	if c.IsInitialized() {
-		// Use the sendRequest method to handle the JSON-RPC request
-		params := map[string]interface{}{
-			"uri":  uri,
-			"name": name,
-		}
-
-		_, err := c.sendRequest("roots/add", params)
-		if err != nil {
-			// Rollback the local addition on error
-			for i, root := range c.roots {
-				if root.URI == uri {
-					c.roots = append(c.roots[:i], c.roots[i+1:]...)
-					break
-				}
-			}
-			return fmt.Errorf("failed to add root: %w", err)
-		}
-	}
-
-	// Enable roots capability if not already enabled
-	if !c.capabilities.Roots.ListChanged {
-		c.capabilities.Roots.ListChanged = true
-	}
-
-	return nil
+	return c.rootsChanged()
 }
 
-// RemoveRoot removes a filesystem root.
+// RemoveRoot removes a root from those the client exposes to the server,
+// and notifies the server that the list changed.
 func (c *clientImpl) RemoveRoot(uri string) error {
 	c.rootsMu.Lock()
-	defer c.rootsMu.Unlock()
-
-	// Find the root in our local cache
-	var foundIndex = -1
+	foundIndex := -1
 	for i, root := range c.roots {
 		if root.URI == uri {
 			foundIndex = i
 			break
 		}
 	}
-
 	if foundIndex == -1 {
+		c.rootsMu.Unlock()
 		return fmt.Errorf("root with URI %s not found", uri)
 	}
+	c.roots = append(c.roots[:foundIndex], c.roots[foundIndex+1:]...)
+	c.rootsMu.Unlock()
 
-	// Only send the actual request if we're initialized
-	if c.IsInitialized() {
-		params := map[string]interface{}{
-			"uri": uri,
-		}
+	return c.rootsChanged()
+}
 
-		_, err := c.sendRequest("roots/remove", params)
-		if err != nil {
-			return fmt.Errorf("failed to remove root: %w", err)
+// SetRoots replaces the roots the client exposes to the server, and
+// notifies the server that the list changed.
+func (c *clientImpl) SetRoots(roots []Root) error {
+	seen := make(map[string]bool, len(roots))
+	for _, root := range roots {
+		if seen[root.URI] {
+			return fmt.Errorf("root with URI %s listed twice", root.URI)
 		}
+		seen[root.URI] = true
 	}
 
-	// Remove the root from our local cache
-	c.roots = append(c.roots[:foundIndex], c.roots[foundIndex+1:]...)
+	c.rootsMu.Lock()
+	c.roots = append([]Root(nil), roots...)
+	c.rootsMu.Unlock()
 
-	return nil
+	return c.rootsChanged()
 }
 
-// GetRoots returns the current list of roots.
+// GetRoots returns the roots the client exposes to the server.
 func (c *clientImpl) GetRoots() ([]Root, error) {
-	// If we're initialized, get the roots from the server
-	if c.IsInitialized() {
-		result, err := c.sendRequest("roots/list", nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get roots: %w", err)
-		}
-
-		// Parse the result based on the format
-		resultMap, ok := result.(map[string]interface{})
-		if !ok {
-			return nil, fmt.Errorf("unexpected result format")
-		}
-
-		rootsData, ok := resultMap["roots"]
-		if !ok {
-			return nil, fmt.Errorf("roots field not found in response")
-		}
-
-		rootsList, ok := rootsData.([]interface{})
-		if !ok {
-			return nil, fmt.Errorf("roots field is not an array")
-		}
-
-		roots := make([]Root, 0, len(rootsList))
-		for _, item := range rootsList {
-			rootMap, ok := item.(map[string]interface{})
-			if !ok {
-				continue
-			}
-
-			uri, ok := rootMap["uri"].(string)
-			if !ok {
-				continue
-			}
-
-			name, ok := rootMap["name"].(string)
-			if !ok {
-				name = uri // Use URI as fallback name
-			}
-
-			root := Root{
-				URI:  uri,
-				Name: name,
-			}
-
-			// Add metadata if available (for v2025-03-26)
-			if metadata, ok := rootMap["metadata"].(map[string]interface{}); ok {
-				root.Metadata = metadata
-			}
-
-			roots = append(roots, root)
-		}
-
-		// Update our local cache
-		c.rootsMu.Lock()
-		c.roots = roots
-		c.rootsMu.Unlock()
-
-		return roots, nil
-	}
-
-	// If not initialized, return the cached roots
 	c.rootsMu.RLock()
 	defer c.rootsMu.RUnlock()
 
@@ -163,12 +76,18 @@ func (c *clientImpl) GetRoots() ([]Root, error) {
 	return roots, nil
 }
 
+// rootsChanged tells an initialized server that the roots changed. Before
+// initialization there is nothing to do, as the server will ask for them.
+func (c *clientImpl) rootsChanged() error {
+	if !c.IsInitialized() {
+		return nil
+	}
+	return c.sendRootsListChangedNotification()
+}
+
 // handleRootsList handles a roots/list request from the server.
 func (c *clientImpl) handleRootsList(requestID int64) error {
-	c.rootsMu.RLock()
-	roots := make([]Root, len(c.roots))
-	copy(roots, c.roots)
-	c.rootsMu.RUnlock()
+	roots, _ := c.GetRoots()
 
 	response := map[string]interface{}{
 		"jsonrpc": "2.0",
@@ -54,6 +54,10 @@ type SamplingCreateMessageParams struct {
 	ModelPreferences SamplingModelPreferences `json:"modelPreferences"`
 	SystemPrompt     string                   `json:"systemPrompt,omitempty"`
 	MaxTokens        int                      `json:"maxTokens,omitempty"`
+	IncludeContext   string                   `json:"includeContext,omitempty"`
+	Temperature      *float64                 `json:"temperature,omitempty"`
+	StopSequences    []string                 `json:"stopSequences,omitempty"`
+	Metadata         map[string]interface{}   `json:"metadata,omitempty"`
 	ProtocolVersion  string                   `json:"-"` // Internal field for version tracking
 }
 
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ShutdownEvent reports that the server is shutting down, as announced in
+// the notifications/message a gomcp server sends before it stops.
+type ShutdownEvent struct {
+	// Reason is the server's machine-readable reason, such as "upgrade".
+	Reason string
+
+	// Message describes the shutdown for users.
+	Message string
+
+	// ReconnectAfter is how long the server asked clients to wait before
+	// reconnecting, or zero if it gave no hint.
+	ReconnectAfter time.Duration
+}
+
+// OnServerShutdown calls handler when the server announces it is shutting
+// down, before the connection closes, so host UIs can show the reason
+// instead of a generic disconnect.
+//
+// With WithAutoReconnect, the first reconnect attempt also waits at least the
+// server's ReconnectAfter hint, and the disconnected ConnectionEvent carries
+// the announcement.
+//
+// Example:
+//
+//	c, err := client.NewClient("ws://localhost:8080/mcp",
+//	    client.OnServerShutdown(func(e client.ShutdownEvent) {
+//	        ui.Status("%s, reconnecting in %v", e.Message, e.ReconnectAfter)
+//	    }),
+//	)
+func OnServerShutdown(handler func(ShutdownEvent)) Option {
+	return func(c *clientImpl) {
+		c.shutdownHandler = handler
+	}
+}
+
+// handleLogMessage handles a notifications/message from the server, which
+// may announce a shutdown.
+func (c *clientImpl) handleLogMessage(params []byte) {
+	c.dispatchLogMessage(params)
+
+	var message struct {
+		Level string `json:"level"`
+		Data  struct {
+			Event          string `json:"event"`
+			Reason         string `json:"reason"`
+			Message        string `json:"message"`
+			ReconnectAfter int    `json:"reconnectAfter"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(params, &message); err != nil || message.Data.Event != "shutdown" {
+		c.logger.Debug("received notification", "method", "notifications/message")
+		return
+	}
+
+	event := ShutdownEvent{
+		Reason:         message.Data.Reason,
+		Message:        message.Data.Message,
+		ReconnectAfter: time.Duration(message.Data.ReconnectAfter) * time.Second,
+	}
+	c.logger.Info("server shutting down", "url", c.url, "reason", event.Reason, "reconnectAfter", event.ReconnectAfter)
+
+	c.mu.Lock()
+	c.shutdownNotice = &event
+	c.mu.Unlock()
+
+	if c.shutdownHandler != nil {
+		c.shutdownHandler(event)
+	}
+}
@@ -0,0 +1,242 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StreamChunk is a piece of a tool result delivered by CallToolStream.
+type StreamChunk struct {
+	// Content holds the chunk's content items as sent by the server, such
+	// as {"type": "text", "text": "..."}.
+	Content []map[string]interface{}
+
+	// Final is set on the last chunk, which holds the content of the tool's
+	// final result.
+	Final bool
+}
+
+// Text returns the text of the chunk's text items, joined with newlines.
+func (c StreamChunk) Text() string {
+	var texts []string
+	for _, item := range c.Content {
+		if text, ok := item["text"].(string); ok && item["type"] == "text" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// toolStream collects the chunks of one streamed call, putting them back in
+// sequence order.
+type toolStream struct {
+	mu      sync.Mutex
+	next    int
+	pending map[int][]map[string]interface{}
+	queue   []StreamChunk
+	ready   chan struct{}
+}
+
+func newToolStream() *toolStream {
+	return &toolStream{
+		pending: make(map[int][]map[string]interface{}),
+		ready:   make(chan struct{}, 1),
+	}
+}
+
+// add records a chunk, queuing it and any it unblocks once every earlier
+// chunk has arrived.
+func (s *toolStream) add(sequence int, content []map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sequence < s.next {
+		return
+	}
+	s.pending[sequence] = content
+	for {
+		content, ok := s.pending[s.next]
+		if !ok {
+			break
+		}
+		delete(s.pending, s.next)
+		s.queue = append(s.queue, StreamChunk{Content: content})
+		s.next++
+	}
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the queued chunks and how many have been queued in all.
+func (s *toolStream) take() ([]StreamChunk, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	chunks := s.queue
+	s.queue = nil
+	return chunks, s.next
+}
+
+// CallToolStream calls a tool and iterates over the content it streams as
+// it is produced, in order, followed by a chunk with Final set holding the
+// content of its final result. A final result with isError set is yielded
+// together with a *ToolError.
+//
+// Servers that don't stream deliver their whole result as the final chunk.
+// Breaking out of the loop stops the iteration but not the call.
+//
+// Example:
+//
+//	for chunk, err := range c.CallToolStream(ctx, "summarize", args) {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    fmt.Print(chunk.Text())
+//	}
+func (c *clientImpl) CallToolStream(ctx context.Context, name string, args map[string]interface{}, opts ...CallOption) iter.Seq2[StreamChunk, error] {
+	return func(yield func(StreamChunk, error) bool) {
+		token := fmt.Sprintf("stream-%d", c.streamCounter.Add(1))
+		stream := newToolStream()
+		c.streamsMu.Lock()
+		if c.streams == nil {
+			c.streams = make(map[string]*toolStream)
+		}
+		c.streams[token] = stream
+		c.streamsMu.Unlock()
+		defer func() {
+			c.streamsMu.Lock()
+			delete(c.streams, token)
+			c.streamsMu.Unlock()
+		}()
+
+		params := map[string]interface{}{"name": name}
+		if args != nil {
+			params["arguments"] = args
+		}
+		opts = append(opts, WithCallMeta(map[string]interface{}{"streamToken": token}))
+		o := applyCallOptions(params, opts)
+
+		// Leaving the loop early cancels the call on the server
+		callCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		type callResult struct {
+			result interface{}
+			err    error
+		}
+		done := make(chan callResult, 1)
+		go func() {
+			result, err := c.sendRequestContext(callCtx, "tools/call", params, o.timeout)
+			done <- callResult{result, err}
+		}()
+
+		flush := func() bool {
+			chunks, _ := stream.take()
+			for _, chunk := range chunks {
+				if !yield(chunk, nil) {
+					return false
+				}
+			}
+			return true
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				yield(StreamChunk{}, ctx.Err())
+				return
+			case <-stream.ready:
+				if !flush() {
+					return
+				}
+			case res := <-done:
+				if res.err != nil {
+					yield(StreamChunk{}, res.err)
+					return
+				}
+				object, _ := res.result.(map[string]interface{})
+				if !c.drainStream(ctx, stream, streamedChunks(object), flush) {
+					return
+				}
+				if err := ctx.Err(); err != nil {
+					yield(StreamChunk{}, err)
+					return
+				}
+
+				final := StreamChunk{Final: true}
+				content, _ := object["content"].([]interface{})
+				for _, item := range content {
+					if entry, ok := item.(map[string]interface{}); ok {
+						final.Content = append(final.Content, entry)
+					}
+				}
+				if isError, _ := object["isError"].(bool); isError {
+					yield(final, &ToolError{Tool: name, Message: final.Text(), Content: content})
+					return
+				}
+				yield(final, nil)
+				return
+			}
+		}
+	}
+}
+
+// drainStream yields the chunks of a finished call until the number the
+// server reported sending have arrived. Chunks lost in transit are waited
+// for up to the request timeout. It reports false if the loop was broken.
+func (c *clientImpl) drainStream(ctx context.Context, stream *toolStream, expected int, flush func() bool) bool {
+	timeout := time.NewTimer(c.requestTimeout)
+	defer timeout.Stop()
+	for {
+		if !flush() {
+			return false
+		}
+		if _, received := stream.take(); received >= expected {
+			return true
+		}
+		select {
+		case <-stream.ready:
+		case <-ctx.Done():
+			return true
+		case <-timeout.C:
+			c.logger.Warn("streamed tool content missing", "expected", expected)
+			return true
+		}
+	}
+}
+
+// streamedChunks returns the number of chunks the server reported sending
+// before a final result.
+func streamedChunks(result map[string]interface{}) int {
+	meta, _ := result["_meta"].(map[string]interface{})
+	count, _ := meta["streamedChunks"].(float64)
+	return int(count)
+}
+
+// handleToolContent delivers a notifications/tools/content message to the
+// CallToolStream it belongs to.
+func (c *clientImpl) handleToolContent(params []byte) {
+	var notification struct {
+		StreamToken string                   `json:"streamToken"`
+		Sequence    int                      `json:"sequence"`
+		Content     []map[string]interface{} `json:"content"`
+	}
+	if err := json.Unmarshal(params, &notification); err != nil {
+		c.logger.Error("failed to parse tool content notification", "error", err)
+		return
+	}
+
+	c.streamsMu.Lock()
+	stream, ok := c.streams[notification.StreamToken]
+	c.streamsMu.Unlock()
+	if !ok {
+		c.logger.Debug("ignoring content for finished stream", "streamToken", notification.StreamToken)
+		return
+	}
+	stream.add(notification.Sequence, notification.Content)
+}
@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/localrivet/gomcp/transport/streamablehttp"
+)
+
+// StreamableHTTPTransport adapts the streamablehttp.Transport to implement the
+// client.Transport interface.
+type StreamableHTTPTransport struct {
+	transport           *streamablehttp.Transport
+	requestTimeout      time.Duration
+	connectionTimeout   time.Duration
+	notificationHandler func(method string, params []byte)
+	mu                  sync.RWMutex
+}
+
+// NewStreamableHTTPTransport creates a Streamable HTTP transport adapter for
+// the MCP endpoint at url.
+func NewStreamableHTTPTransport(url string, options ...streamablehttp.Option) *StreamableHTTPTransport {
+	t := &StreamableHTTPTransport{
+		transport:         streamablehttp.NewTransport(url, options...),
+		requestTimeout:    30 * time.Second,
+		connectionTimeout: 10 * time.Second,
+	}
+
+	// Requests and notifications from the server arrive on response streams
+	// and on the server-message stream
+	t.transport.SetMessageHandler(func(message []byte) ([]byte, error) {
+		t.mu.RLock()
+		handler := t.notificationHandler
+		t.mu.RUnlock()
+		if handler != nil {
+			handler("", message)
+		}
+		return nil, nil
+	})
+
+	return t
+}
+
+// Connect implements the Transport interface. No request is made until the
+// first message is sent.
+func (t *StreamableHTTPTransport) Connect() error {
+	if err := t.transport.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize Streamable HTTP transport: %w", err)
+	}
+	return t.transport.Start()
+}
+
+// ConnectWithContext implements the Transport interface.
+func (t *StreamableHTTPTransport) ConnectWithContext(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		return t.Connect()
+	}
+}
+
+// Disconnect implements the Transport interface. It ends the session on the
+// server.
+func (t *StreamableHTTPTransport) Disconnect() error {
+	return t.transport.Stop()
+}
+
+// Send implements the Transport interface.
+func (t *StreamableHTTPTransport) Send(message []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.requestTimeout)
+	defer cancel()
+	return t.SendWithContext(ctx, message)
+}
+
+// SendWithContext implements the Transport interface. Notifications and
+// responses return a nil response.
+func (t *StreamableHTTPTransport) SendWithContext(ctx context.Context, message []byte) ([]byte, error) {
+	return t.transport.Request(ctx, message)
+}
+
+// SessionID returns the session ID assigned by the server, or "" before the
+// client has initialized.
+func (t *StreamableHTTPTransport) SessionID() string {
+	return t.transport.SessionID()
+}
+
+// SetRequestTimeout implements the Transport interface.
+func (t *StreamableHTTPTransport) SetRequestTimeout(timeout time.Duration) {
+	t.requestTimeout = timeout
+}
+
+// SetConnectionTimeout implements the Transport interface.
+func (t *StreamableHTTPTransport) SetConnectionTimeout(timeout time.Duration) {
+	t.connectionTimeout = timeout
+}
+
+// RegisterNotificationHandler implements the Transport interface.
+func (t *StreamableHTTPTransport) RegisterNotificationHandler(handler func(method string, params []byte)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.notificationHandler = handler
+}
+
+// WithStreamableHTTP configures the client to use the Streamable HTTP
+// transport, which servers built on the 2025-03-26 or later specification
+// revisions expose as a single endpoint.
+//
+// Parameters:
+// - url: The MCP endpoint URL (e.g., "http://localhost:8080/mcp")
+// - options: Optional transport settings
+//
+// Example:
+//
+//	client.NewClient("my-client",
+//	    client.WithStreamableHTTP("http://localhost:8080/mcp",
+//	        streamablehttp.WithHeader("Authorization", "Bearer token")),
+//	)
+func WithStreamableHTTP(url string, options ...streamablehttp.Option) Option {
+	return func(c *clientImpl) {
+		transport := NewStreamableHTTPTransport(url, options...)
+		transport.SetRequestTimeout(c.requestTimeout)
+		transport.SetConnectionTimeout(c.connectionTimeout)
+		c.transport = transport
+
+		// Streamable HTTP was introduced in 2025-03-26, so servers that speak
+		// it support that revision
+		if c.negotiatedVersion == "" {
+			c.negotiatedVersion = "2025-03-26"
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Package client provides the client-side implementation of the MCP protocol.
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ResourceUpdateHandler is called when a subscribed resource changes on the server.
+type ResourceUpdateHandler func(uri string)
+
+// ErrSubscriptionsNotSupported is returned when the server does not advertise
+// the resources.subscribe capability.
+var ErrSubscriptionsNotSupported = errors.New("server does not support resource subscriptions")
+
+// SubscribeResource subscribes to change notifications for a resource.
+func (c *clientImpl) SubscribeResource(uri string, handler ResourceUpdateHandler) error {
+	if uri == "" {
+		return errors.New("resource uri cannot be empty")
+	}
+	if handler == nil {
+		return errors.New("resource update handler cannot be nil")
+	}
+
+	if !c.supportsResourceSubscriptions() {
+		return ErrSubscriptionsNotSupported
+	}
+
+	// Only ask the server once per URI; later calls just replace the
+	// handler, and calls made while the request is in flight wait for it
+	for {
+		c.subscriptionsMu.Lock()
+		if _, subscribed := c.subscriptions[uri]; subscribed {
+			c.subscriptions[uri] = handler
+			c.subscriptionsMu.Unlock()
+			return nil
+		}
+		pending, inFlight := c.subscribing[uri]
+		if !inFlight {
+			break
+		}
+		c.subscriptionsMu.Unlock()
+		<-pending.done
+		if pending.err != nil {
+			return fmt.Errorf("failed to subscribe to resource %s: %w", uri, pending.err)
+		}
+	}
+	pending := &pendingSubscription{done: make(chan struct{})}
+	c.subscribing[uri] = pending
+	c.subscriptionsMu.Unlock()
+
+	_, err := c.sendRequest("resources/subscribe", map[string]interface{}{"uri": uri})
+
+	c.subscriptionsMu.Lock()
+	delete(c.subscribing, uri)
+	if err == nil {
+		c.subscriptions[uri] = handler
+	}
+	pending.err = err
+	close(pending.done)
+	c.subscriptionsMu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to resource %s: %w", uri, err)
+	}
+	return nil
+}
+
+// pendingSubscription is a resources/subscribe request in flight, which
+// other subscribers to its URI wait for.
+type pendingSubscription struct {
+	done chan struct{}
+	err  error // set before done is closed
+}
+
+// UnsubscribeResource cancels a subscription created with SubscribeResource.
+// A subscription still being made is waited for first.
+func (c *clientImpl) UnsubscribeResource(uri string) error {
+	c.subscriptionsMu.RLock()
+	for c.subscribing[uri] != nil {
+		pending := c.subscribing[uri]
+		c.subscriptionsMu.RUnlock()
+		<-pending.done
+		c.subscriptionsMu.RLock()
+	}
+	_, subscribed := c.subscriptions[uri]
+	c.subscriptionsMu.RUnlock()
+
+	if !subscribed {
+		return fmt.Errorf("not subscribed to resource %s", uri)
+	}
+
+	if _, err := c.sendRequest("resources/unsubscribe", map[string]interface{}{"uri": uri}); err != nil {
+		return fmt.Errorf("failed to unsubscribe from resource %s: %w", uri, err)
+	}
+
+	c.subscriptionsMu.Lock()
+	delete(c.subscriptions, uri)
+	c.subscriptionsMu.Unlock()
+
+	return nil
+}
+
+// supportsResourceSubscriptions reports whether the server advertised the
+// resources.subscribe capability during initialization.
+func (c *clientImpl) supportsResourceSubscriptions() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	resources, ok := c.serverCapabilities["resources"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	subscribe, _ := resources["subscribe"].(bool)
+	return subscribe
+}
+
+// resubscribeResources restores all active subscriptions after the client
+// (re)initializes its connection. It must be called with c.mu held.
+func (c *clientImpl) resubscribeResources() {
+	c.subscriptionsMu.RLock()
+	uris := make([]string, 0, len(c.subscriptions))
+	for uri := range c.subscriptions {
+		uris = append(uris, uri)
+	}
+	c.subscriptionsMu.RUnlock()
+
+	for _, uri := range uris {
+		if _, err := c.doRequest("resources/subscribe", map[string]interface{}{"uri": uri}, 0); err != nil {
+			c.logger.Warn("failed to restore resource subscription", "uri", uri, "error", err)
+		}
+	}
+}
+
+// handleResourceUpdated dispatches a notifications/resources/updated message
+// to the handler registered for its URI.
+func (c *clientImpl) handleResourceUpdated(params json.RawMessage) {
+	var notification struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(params, &notification); err != nil {
+		c.logger.Error("failed to parse resource updated notification", "error", err)
+		return
+	}
+
+	c.subscriptionsMu.RLock()
+	handler, ok := c.subscriptions[notification.URI]
+	c.subscriptionsMu.RUnlock()
+
+	if !ok {
+		c.logger.Debug("ignoring update for unsubscribed resource", "uri", notification.URI)
+		return
+	}
+	handler(notification.URI)
+}
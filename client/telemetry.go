@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/localrivet/gomcp/protocol"
+	"github.com/localrivet/gomcp/telemetry"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracerProvider records an OpenTelemetry span for every request the
+// client sends, and propagates its trace context to the server in the
+// request's _meta.
+//
+// Example:
+//
+//	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+//	c, err := client.NewClient(url, client.WithTracerProvider(tp))
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *clientImpl) {
+		c.tracer = telemetry.NewTracer(provider)
+	}
+}
+
+// WithTraceContext makes the span in ctx the parent of the request, for
+// example the span of the request a server is handling when it calls
+// another server on the caller's behalf:
+//
+//	result, err := upstream.CallTool("search", args, client.WithTraceContext(ctx.Context()))
+func WithTraceContext(ctx context.Context) CallOption {
+	return func(o *callOptions) {
+		if o.meta == nil {
+			o.meta = make(map[string]interface{})
+		}
+		telemetry.Inject(ctx, o.meta)
+	}
+}
+
+// startSpan starts the span of a request, returning the params to send with
+// its trace context added to their _meta, and the context to send them with.
+func (c *clientImpl) startSpan(method string, id int64, params interface{}) (interface{}, context.Context, trace.Span) {
+	traced := tracedParams(params)
+	meta, _ := traced["_meta"].(map[string]interface{})
+	meta = copyMeta(meta)
+	traced["_meta"] = meta
+
+	request := telemetry.Request{Method: method, ID: id}
+	if method == "tools/call" {
+		request.Tool, _ = traced["name"].(string)
+	}
+	ctx, span := c.tracer.StartClient(c.ctx, meta, request)
+	return traced, ctx, span
+}
+
+// endSpan ends the span of a request with its outcome.
+func endSpan(span trace.Span, err error) {
+	var rpcErr *protocol.Error
+	if errors.As(err, &rpcErr) {
+		telemetry.End(span, rpcErr.Code, err)
+		return
+	}
+	telemetry.End(span, 0, err)
+}
+
+// tracedParams returns a copy of params as a map that trace context can be
+// added to without changing the caller's value.
+func tracedParams(params interface{}) map[string]interface{} {
+	switch p := params.(type) {
+	case nil:
+		return make(map[string]interface{})
+	case map[string]interface{}:
+		traced := make(map[string]interface{}, len(p)+1)
+		for key, value := range p {
+			traced[key] = value
+		}
+		return traced
+	}
+	traced := make(map[string]interface{})
+	if data, err := json.Marshal(params); err == nil {
+		_ = json.Unmarshal(data, &traced)
+	}
+	return traced
+}
+
+// copyMeta returns a copy of meta, which may be nil.
+func copyMeta(meta map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(meta)+2)
+	for key, value := range meta {
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+// TestCallBatch sends a batch through an in-process pair and checks that
+// results come back in request order.
+func TestCallBatch(t *testing.T) {
+	c, s := inproc.Pair()
+	srv := server.NewServer("batch-test", server.WithTransport(s)).
+		Tool("echo", "Echo a message", func(ctx *server.Context, args struct {
+			Message string `json:"message"`
+		}) (interface{}, error) {
+			return args.Message, nil
+		})
+	go srv.Run()
+
+	cl, err := client.NewClient("batch-client", client.WithInProcess(c))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	call := func(message string) client.Request {
+		return client.Request{Method: "tools/call", Params: map[string]interface{}{
+			"name": "echo", "arguments": map[string]interface{}{"message": message},
+		}}
+	}
+	results, err := cl.CallBatch([]client.Request{
+		call("first"),
+		{Method: "no/such/method"},
+		{Method: "notifications/roots/list_changed", Notification: true},
+		call("second"),
+	})
+	if err != nil {
+		t.Fatalf("CallBatch failed: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+	if !strings.Contains(fmt.Sprint(results[0].Result), "first") || !strings.Contains(fmt.Sprint(results[3].Result), "second") {
+		t.Errorf("Expected results in request order, got %v and %v", results[0].Result, results[3].Result)
+	}
+	if results[1].Err == nil {
+		t.Error("Expected an error for the unknown method")
+	}
+	if results[2].Err != nil || results[2].Result != nil {
+		t.Errorf("Expected an empty result for the notification, got %+v", results[2])
+	}
+}
@@ -0,0 +1,35 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+func TestWithContextBudget(t *testing.T) {
+	c, s := inproc.Pair()
+	budgets := make(chan server.ClientBudget, 1)
+	srv := server.NewServer("budget-test", server.WithTransport(s)).
+		Tool("budget", "Report the client's budget", func(ctx *server.Context, args interface{}) (string, error) {
+			budget, _ := ctx.ClientBudget()
+			budgets <- budget
+			return "ok", nil
+		})
+	go srv.Run()
+
+	cl, err := client.NewClient("budget-client", client.WithInProcess(c),
+		client.WithContextBudget(client.ContextBudget{ContextWindow: 128000, MaxResultTokens: 4000}))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	if _, err := cl.CallTool("budget", nil); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if budget := <-budgets; budget.ContextWindow != 128000 || budget.MaxResultTokens != 4000 {
+		t.Errorf("Expected the declared budget, got %+v", budget)
+	}
+}
@@ -0,0 +1,70 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+// TestCallToolCancellation checks that cancelling the context of a tool
+// call cancels the context of its handler on the server.
+func TestCallToolCancellation(t *testing.T) {
+	c, s := inproc.Pair()
+	srv := server.NewServer("cancel-test", server.WithTransport(s))
+	started := make(chan struct{})
+	causes := make(chan error, 1)
+	srv.Tool("wait", "Waits until cancelled", func(ctx *server.Context, args struct{}) (string, error) {
+		close(started)
+		select {
+		case <-ctx.Done():
+			causes <- context.Cause(ctx.Context())
+			return "", ctx.Err()
+		case <-time.After(5 * time.Second):
+			causes <- nil
+			return "finished", nil
+		}
+	})
+	go srv.Run()
+
+	cl, err := client.NewClient("cancel-client",
+		client.WithInProcess(c),
+		client.WithProtocolVersion("2025-03-26"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	begun := time.Now()
+	if _, err := cl.CallTool("wait", nil, client.WithContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the call to end with context.Canceled, got %v", err)
+	}
+	if elapsed := time.Since(begun); elapsed > 2*time.Second {
+		t.Errorf("Expected the call to return once cancelled, took %v", elapsed)
+	}
+
+	select {
+	case cause := <-causes:
+		if !errors.Is(cause, server.ErrRequestCancelled) {
+			t.Errorf("Expected the handler to be cancelled by the client, got %v", cause)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the handler's context to be cancelled")
+	}
+
+	// A cancelled context never sends the request
+	if _, err := cl.CallTool("wait", nil, client.WithContext(ctx)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled context to fail the call, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/mcp"
+)
+
+func newCapabilitiesClient(t *testing.T, mockTransport *MockTransport, capabilities map[string]interface{}) client.Client {
+	t.Helper()
+
+	EnsureConnected(mockTransport)
+	mockTransport.QueueConditionalResponse(CreateInitializeResponse("2025-03-26", capabilities), nil, IsRequestMethod("initialize"))
+
+	c, err := client.NewClient("test://server",
+		client.WithTransport(mockTransport),
+		client.WithVersionDetector(mcp.NewVersionDetector()),
+		client.WithSamplingCapability(true, nil),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	return c
+}
+
+func TestNegotiatedCapabilities(t *testing.T) {
+	c := newCapabilitiesClient(t, NewMockTransport(), map[string]interface{}{
+		"tools":     map[string]interface{}{"listChanged": true},
+		"resources": map[string]interface{}{"subscribe": false},
+	})
+
+	caps := c.NegotiatedCapabilities()
+	if caps.ProtocolVersion != "2025-03-26" {
+		t.Errorf("Expected protocol version 2025-03-26, got %q", caps.ProtocolVersion)
+	}
+	if !caps.ServerSupports("tools") || !caps.ServerSupports("tools.listChanged") {
+		t.Error("Expected server to support tools")
+	}
+	if !caps.ServerSupports("resources") || caps.ServerSupports("resources.subscribe") {
+		t.Error("Expected resources without subscriptions")
+	}
+	if caps.ServerSupports("prompts") {
+		t.Error("Expected server not to support prompts")
+	}
+	if caps.Client.Sampling == nil {
+		t.Error("Expected client sampling capability to be reported")
+	}
+}
+
+func TestMethodNotFoundExplainsMissingCapability(t *testing.T) {
+	mockTransport := NewMockTransport()
+	c := newCapabilitiesClient(t, mockTransport, map[string]interface{}{
+		"tools": map[string]interface{}{},
+	})
+
+	mockTransport.QueueResponse(CreateToolErrorResponse(2, -32601, "Method not found", "method not found: prompt/get"), nil)
+	_, err := c.GetPrompt("greeting", nil)
+
+	var capErr *client.CapabilityError
+	if !errors.As(err, &capErr) {
+		t.Fatalf("Expected a CapabilityError, got %v", err)
+	}
+	if capErr.Method != "prompt/get" || capErr.Capability != "prompts" {
+		t.Errorf("Unexpected capability error: %+v", capErr)
+	}
+
+	// An advertised capability keeps the server's original error
+	mockTransport.QueueResponse(CreateToolErrorResponse(3, -32601, "Method not found", nil), nil)
+	_, err = c.CallTool("missing", nil)
+	if errors.As(err, &capErr) {
+		t.Errorf("Expected a plain error for an advertised capability, got %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "code -32601") {
+		t.Errorf("Expected the server error to be returned, got %v", err)
+	}
+}
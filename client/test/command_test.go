@@ -0,0 +1,127 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+)
+
+// TestMain lets the test binary act as a stdio server for the command
+// transport tests.
+func TestMain(m *testing.M) {
+	if os.Getenv("GOMCP_COMMAND_TEST_SERVER") == "1" {
+		runCommandTestServer()
+	}
+	os.Exit(m.Run())
+}
+
+func runCommandTestServer() {
+	fmt.Fprintln(os.Stderr, "command test server ready")
+	srv := server.NewServer("command-test").
+		Tool("echo", "Echo a message", func(ctx *server.Context, args struct {
+			Message string `json:"message"`
+		}) (interface{}, error) {
+			return args.Message, nil
+		}).
+		Tool("crash", "Exit the process", func(ctx *server.Context, args struct{}) (interface{}, error) {
+			os.Exit(3)
+			return nil, nil
+		})
+	if err := srv.AsStdio().Run(); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// syncBuffer collects log output written from the transport's goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestCommandTransport(t *testing.T) {
+	t.Setenv("GOMCP_COMMAND_TEST_SERVER", "1")
+	logs := &syncBuffer{}
+
+	c, err := client.NewClient("command-test",
+		client.WithLogger(slog.New(slog.NewTextHandler(logs, nil))),
+		client.WithCommand(os.Args[0]),
+		client.WithProtocolVersion("2025-03-26"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start the server: %v", err)
+	}
+	defer c.Close()
+
+	result, err := c.CallTool("echo", map[string]interface{}{"message": "over stdio"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !strings.Contains(fmt.Sprint(result), "over stdio") {
+		t.Errorf("Expected the echoed message, got %v", result)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(logs.String(), "command test server ready") {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the server's stderr to be logged, got %q", logs.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCommandTransportRestartsOnCrash(t *testing.T) {
+	t.Setenv("GOMCP_COMMAND_TEST_SERVER", "1")
+	reconnected := make(chan struct{}, 1)
+
+	c, err := client.NewClient("command-test",
+		client.WithCommand(os.Args[0]),
+		client.WithProtocolVersion("2025-03-26"),
+		client.WithAutoReconnect(client.ReconnectPolicy{
+			InitialDelay: 10 * time.Millisecond,
+			MaxAttempts:  3,
+			OnStateChange: func(e client.ConnectionEvent) {
+				if e.State == client.ConnectionConnected {
+					reconnected <- struct{}{}
+				}
+			},
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to start the server: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.CallTool("crash", map[string]interface{}{}); err == nil {
+		t.Fatal("Expected the call that crashed the server to fail")
+	}
+	select {
+	case <-reconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the server to be restarted")
+	}
+
+	if _, err := c.CallTool("echo", map[string]interface{}{"message": "again"}); err != nil {
+		t.Errorf("Expected calls to succeed after the restart, got %v", err)
+	}
+}
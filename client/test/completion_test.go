@@ -0,0 +1,95 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+func TestCompleteUsesRegisteredProviders(t *testing.T) {
+	languages := []string{"go", "javascript", "python", "pyret"}
+	c, s := inproc.Pair()
+	srv := server.NewServer("completion-test",
+		server.WithTransport(s),
+		server.WithCompletions(func(arg, prefix string) []string {
+			var ids []string
+			for i := 0; i < 150; i++ {
+				ids = append(ids, fmt.Sprintf("%s%03d", prefix, i))
+			}
+			return ids
+		}),
+	).Completion("code_review", func(arg, prefix string) []string {
+		if arg != "language" {
+			return nil
+		}
+		var matches []string
+		for _, language := range languages {
+			if strings.HasPrefix(language, prefix) {
+				matches = append(matches, language)
+			}
+		}
+		return matches
+	})
+	go srv.Run()
+
+	cl, err := client.NewClient("completion-client", client.WithInProcess(c), client.WithProtocolVersion("2025-03-26"))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	if !cl.NegotiatedCapabilities().ServerSupports("completions") {
+		t.Error("Expected the server to advertise completions")
+	}
+
+	completion, err := cl.Complete(client.PromptRef("code_review"), "language", "py")
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if strings.Join(completion.Values, ",") != "python,pyret" || completion.Total != 2 || completion.HasMore {
+		t.Errorf("Unexpected completion: %+v", completion)
+	}
+
+	completion, err = cl.Complete(client.PromptRef("code_review"), "style", "")
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if len(completion.Values) != 0 {
+		t.Errorf("Expected no values for an argument without suggestions, got %v", completion.Values)
+	}
+
+	completion, err = cl.Complete(client.ResourceRef("tickets://{id}"), "id", "T-")
+	if err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if len(completion.Values) != 100 || completion.Total != 150 || !completion.HasMore {
+		t.Errorf("Expected the fallback's values capped at 100 of 150, got %d of %d (hasMore %v)",
+			len(completion.Values), completion.Total, completion.HasMore)
+	}
+	if completion.Values[0] != "T-000" {
+		t.Errorf("Expected the typed prefix to reach the provider, got %q", completion.Values[0])
+	}
+}
+
+func TestCompleteWithoutProviders(t *testing.T) {
+	c, s := inproc.Pair()
+	srv := server.NewServer("completion-test", server.WithTransport(s))
+	go srv.Run()
+
+	cl, err := client.NewClient("completion-client", client.WithInProcess(c), client.WithProtocolVersion("2025-03-26"))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	_, err = cl.Complete(client.PromptRef("code_review"), "language", "py")
+	var capErr *client.CapabilityError
+	if !errors.As(err, &capErr) || capErr.Capability != "completions" {
+		t.Errorf("Expected a missing completions capability error, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+func newDigestClient(t *testing.T, srv server.Server, c *inproc.ClientTransport) client.Client {
+	t.Helper()
+	go srv.Run()
+	cl, err := client.NewClient("digest-client", client.WithInProcess(c), client.WithProtocolVersion("2025-03-26"))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { cl.Close() })
+	return cl
+}
+
+func TestReadResourceVerifiesDigests(t *testing.T) {
+	image := make([]byte, 20<<10)
+	rand.New(rand.NewSource(3)).Read(image)
+	path := filepath.Join(t.TempDir(), "logo.png")
+	if err := os.WriteFile(path, image, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(image)
+
+	c, s := inproc.Pair()
+	srv := server.NewServer("digest-test", server.WithTransport(s), server.WithContentDigests()).
+		Resource("file:///images/logo.png", "Logo", server.WithFileContent(path, server.WithFileMimeType("image/png"))).
+		Resource("docs://motd", "Message of the day", func(ctx *server.Context, args interface{}) (string, error) {
+			return "Hello", nil
+		})
+	cl := newDigestClient(t, srv, c)
+
+	contents, err := cl.ReadResource("file:///images/logo.png")
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	if len(contents) != 1 || string(contents[0].Blob) != string(image) || contents[0].SHA256 != hex.EncodeToString(sum[:]) {
+		t.Fatalf("Unexpected contents: %d items", len(contents))
+	}
+
+	contents, err = cl.ReadResource("docs://motd")
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	if len(contents) != 1 || contents[0].Text != "Hello" || contents[0].SHA256 == "" {
+		t.Errorf("Expected digested text, got %+v", contents)
+	}
+
+	// Ranged downloads verify each range as it arrives
+	if _, err := cl.DownloadResource(context.Background(), "file:///images/logo.png", io.Discard,
+		client.WithDownloadChunkSize(8<<10)); err != nil {
+		t.Errorf("DownloadResource failed: %v", err)
+	}
+}
+
+func TestReadResourceDetectsTruncation(t *testing.T) {
+	image := make([]byte, 4<<10)
+	rand.New(rand.NewSource(4)).Read(image)
+	sum := sha256.Sum256(image)
+
+	c, s := inproc.Pair()
+	srv := server.NewServer("digest-test", server.WithTransport(s)).
+		Resource("file:///images/logo.png", "Logo", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			// The digest of the whole image sent with half of it
+			return map[string]interface{}{
+				"contents": []interface{}{map[string]interface{}{
+					"uri":      "file:///images/logo.png",
+					"mimeType": "image/png",
+					"blob":     base64.StdEncoding.EncodeToString(image[:len(image)/2]),
+					"_meta":    map[string]interface{}{"sha256": hex.EncodeToString(sum[:])},
+				}},
+			}, nil
+		})
+	cl := newDigestClient(t, srv, c)
+
+	if _, err := cl.ReadResource("file:///images/logo.png"); !errors.Is(err, client.ErrChecksumMismatch) {
+		t.Errorf("Expected a checksum mismatch, got %v", err)
+	}
+	if _, err := cl.DownloadResource(context.Background(), "file:///images/logo.png", io.Discard); !errors.Is(err, client.ErrChecksumMismatch) {
+		t.Errorf("Expected a checksum mismatch, got %v", err)
+	}
+}
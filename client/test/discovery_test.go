@@ -0,0 +1,108 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/mcp"
+)
+
+func serveDiscoveryDocument(t *testing.T, doc mcp.DiscoveryDocument) *httptest.Server {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != mcp.WellKnownPath {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(doc)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestDiscover(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints []mcp.TransportEndpoint
+		transport string
+		path      string
+		scheme    string
+	}{
+		{
+			name:      "sse",
+			endpoints: []mcp.TransportEndpoint{{Type: mcp.TransportSSE, Endpoint: "/mcp/sse", MessageEndpoint: "/mcp/message"}},
+			transport: mcp.TransportSSE,
+			path:      "/mcp/sse",
+			scheme:    "http://",
+		},
+		{
+			name:      "websocket",
+			endpoints: []mcp.TransportEndpoint{{Type: mcp.TransportWebsocket, Endpoint: "/ws"}},
+			transport: mcp.TransportWebsocket,
+			path:      "/ws",
+			scheme:    "ws://",
+		},
+		{
+			name: "skips unknown transports",
+			endpoints: []mcp.TransportEndpoint{
+				{Type: "carrier-pigeon", Endpoint: "/coop"},
+				{Type: mcp.TransportHTTP, Endpoint: "/api"},
+			},
+			transport: mcp.TransportHTTP,
+			path:      "/api",
+			scheme:    "http://",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := serveDiscoveryDocument(t, mcp.DiscoveryDocument{
+				Name:             "test-server",
+				Version:          "1.0.0",
+				ProtocolVersions: []string{"2025-03-26"},
+				Transports:       tc.endpoints,
+			})
+
+			d, err := client.Discover(ts.URL)
+			if err != nil {
+				t.Fatalf("Discover failed: %v", err)
+			}
+
+			if d.Document.Name != "test-server" {
+				t.Errorf("Expected server name 'test-server', got %q", d.Document.Name)
+			}
+			if d.Transport != tc.transport {
+				t.Errorf("Expected transport %q, got %q", tc.transport, d.Transport)
+			}
+			if !strings.HasPrefix(d.URL, tc.scheme) || !strings.HasSuffix(d.URL, tc.path) {
+				t.Errorf("Expected URL %s...%s, got %s", tc.scheme, tc.path, d.URL)
+			}
+			if d.Option() == nil {
+				t.Error("Expected a transport option")
+			}
+		})
+	}
+}
+
+func TestDiscoverErrors(t *testing.T) {
+	ts := serveDiscoveryDocument(t, mcp.DiscoveryDocument{Name: "stdio-only"})
+	if _, err := client.Discover(ts.URL); err == nil {
+		t.Error("Expected error when no supported transport is advertised")
+	}
+
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	if _, err := client.Discover(missing.URL); err == nil {
+		t.Error("Expected error when the discovery document is missing")
+	}
+
+	if _, err := client.Discover("ws://localhost:1234"); err == nil {
+		t.Error("Expected error for a non-HTTP base URL")
+	}
+}
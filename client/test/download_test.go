@@ -0,0 +1,140 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+func newDownloadClient(t *testing.T, content []byte) client.Client {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "build.bin")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, s := inproc.Pair()
+	srv := server.NewServer("download-test", server.WithTransport(s)).
+		Resource("file:///artifacts/build.bin", "Latest build",
+			server.WithFileContent(path, server.WithFileMimeType("application/octet-stream"), server.WithMaxReadSize(64<<10)))
+	go srv.Run()
+
+	cl, err := client.NewClient("download-client", client.WithInProcess(c), client.WithProtocolVersion("2025-03-26"))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { cl.Close() })
+	return cl
+}
+
+func TestDownloadResourceInRanges(t *testing.T) {
+	content := make([]byte, 300<<10)
+	rand.New(rand.NewSource(1)).Read(content)
+	sum := sha256.Sum256(content)
+	cl := newDownloadClient(t, content)
+
+	var buf bytes.Buffer
+	var reports []int64
+	result, err := cl.DownloadResource(context.Background(), "file:///artifacts/build.bin", &buf,
+		client.WithDownloadChunkSize(100<<10),
+		client.WithDownloadChecksum(hex.EncodeToString(sum[:])),
+		client.WithDownloadProgress(func(written, total int64) {
+			if total != int64(len(content)) {
+				t.Errorf("Expected a total of %d, got %d", len(content), total)
+			}
+			reports = append(reports, written)
+		}))
+	if err != nil {
+		t.Fatalf("DownloadResource failed: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), content) {
+		t.Fatalf("Downloaded %d bytes that differ from the %d served", buf.Len(), len(content))
+	}
+	if result.Size != int64(len(content)) || result.Written != int64(len(content)) || result.MimeType != "application/octet-stream" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	// The server caps reads at 64 KiB, so 300 KiB takes five of them
+	if len(reports) != 5 || reports[len(reports)-1] != int64(len(content)) {
+		t.Errorf("Expected progress after each of 5 ranges, got %v", reports)
+	}
+
+	_, err = cl.DownloadResource(context.Background(), "file:///artifacts/build.bin", &bytes.Buffer{},
+		client.WithDownloadChecksum(hex.EncodeToString(make([]byte, sha256.Size))))
+	if !errors.Is(err, client.ErrChecksumMismatch) {
+		t.Errorf("Expected a checksum mismatch, got %v", err)
+	}
+}
+
+func TestDownloadResourceResumes(t *testing.T) {
+	content := make([]byte, 200<<10)
+	rand.New(rand.NewSource(2)).Read(content)
+	sum := sha256.Sum256(content)
+	cl := newDownloadClient(t, content)
+
+	// A download interrupted partway through
+	path := filepath.Join(t.TempDir(), "partial.bin")
+	if err := os.WriteFile(path, content[:70<<10], 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	result, err := cl.DownloadResource(context.Background(), "file:///artifacts/build.bin", f,
+		client.WithDownloadResume(f),
+		client.WithDownloadChecksum(hex.EncodeToString(sum[:])))
+	if err != nil {
+		t.Fatalf("DownloadResource failed: %v", err)
+	}
+	if result.Written != int64(len(content)-70<<10) || result.Size != int64(len(content)) {
+		t.Errorf("Expected only the rest to be downloaded, got %+v", result)
+	}
+	downloaded, _ := os.ReadFile(path)
+	if !bytes.Equal(downloaded, content) {
+		t.Errorf("Resumed file differs from the resource")
+	}
+}
+
+func TestReadResourceRange(t *testing.T) {
+	content := make([]byte, 150<<10)
+	rand.New(rand.NewSource(2)).Read(content)
+	cl := newDownloadClient(t, content)
+
+	var assembled []byte
+	for offset := int64(0); ; {
+		r, err := cl.ReadResourceRange("file:///artifacts/build.bin", offset, 100<<10)
+		if err != nil {
+			t.Fatalf("ReadResourceRange failed: %v", err)
+		}
+		if r.Offset != offset || r.Size != int64(len(content)) || r.MimeType != "application/octet-stream" {
+			t.Fatalf("Unexpected range %d of %d (%s)", r.Offset, r.Size, r.MimeType)
+		}
+		if len(r.Data) > 64<<10 {
+			t.Fatalf("Expected at most the server's read size, got %d bytes", len(r.Data))
+		}
+		assembled = append(assembled, r.Data...)
+		offset += int64(len(r.Data))
+		if offset >= r.Size {
+			break
+		}
+	}
+	if !bytes.Equal(assembled, content) {
+		t.Error("Expected the ranges to reassemble the resource")
+	}
+
+	if _, err := cl.ReadResourceRange("file:///artifacts/build.bin", -1, 10); err == nil {
+		t.Error("Expected an error for a negative offset")
+	}
+}
@@ -405,54 +405,22 @@ func TestRoots_Draft(t *testing.T) {
 	c, mockTransport := setupTest(t)
 
 	// Test add root
-	addRootResponse := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      1,
-		"result":  map[string]interface{}{},
-	}
-	addRootJSON, _ := json.Marshal(addRootResponse)
-	mockTransport.QueueResponse(addRootJSON, nil)
-
 	err := c.AddRoot("/test/draft/root", "Draft Test Root")
 	if err != nil {
 		t.Fatalf("AddRoot failed: %v", err)
 	}
 
-	// Verify the add request format
-	var addRequest map[string]interface{}
-	if err := json.Unmarshal(mockTransport.LastSentMessage, &addRequest); err != nil {
-		t.Fatalf("Failed to parse add request: %v", err)
-	}
-
-	if addRequest["method"] != "roots/add" {
-		t.Errorf("Expected method roots/add, got %v", addRequest["method"])
-	}
-
-	addParams, ok := addRequest["params"].(map[string]interface{})
-	if !ok {
-		t.Fatalf("Expected params in add request, got %v", addRequest)
+	// Roots are kept by the client, which tells the server they changed
+	var addNotification map[string]interface{}
+	if err := json.Unmarshal(mockTransport.LastSentMessage, &addNotification); err != nil {
+		t.Fatalf("Failed to parse add notification: %v", err)
 	}
 
-	if addParams["uri"] != "/test/draft/root" || addParams["name"] != "Draft Test Root" {
-		t.Errorf("Add root params not as expected: %v", addParams)
+	if addNotification["method"] != "notifications/roots/list_changed" {
+		t.Errorf("Expected method notifications/roots/list_changed, got %v", addNotification["method"])
 	}
 
 	// Test get roots
-	getRootsResponse := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      2,
-		"result": map[string]interface{}{
-			"roots": []interface{}{
-				map[string]interface{}{
-					"uri":  "/test/draft/root",
-					"name": "Draft Test Root",
-				},
-			},
-		},
-	}
-	getRootsJSON, _ := json.Marshal(getRootsResponse)
-	mockTransport.QueueResponse(getRootsJSON, nil)
-
 	roots, err := c.GetRoots()
 	if err != nil {
 		t.Fatalf("GetRoots failed: %v", err)
@@ -466,46 +434,23 @@ func TestRoots_Draft(t *testing.T) {
 		t.Errorf("Root doesn't match expected: %+v", roots[0])
 	}
 
-	// Verify the get roots request format
-	var getRootsRequest map[string]interface{}
-	if err := json.Unmarshal(mockTransport.LastSentMessage, &getRootsRequest); err != nil {
-		t.Fatalf("Failed to parse get roots request: %v", err)
-	}
-
-	if getRootsRequest["method"] != "roots/list" {
-		t.Errorf("Expected method roots/list, got %v", getRootsRequest["method"])
-	}
-
 	// Test remove root
-	removeRootResponse := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      3,
-		"result":  map[string]interface{}{},
-	}
-	removeRootJSON, _ := json.Marshal(removeRootResponse)
-	mockTransport.QueueResponse(removeRootJSON, nil)
-
+	mockTransport.LastSentMessage = nil
 	err = c.RemoveRoot("/test/draft/root")
 	if err != nil {
 		t.Fatalf("RemoveRoot failed: %v", err)
 	}
 
-	// Verify the remove request format
-	var removeRequest map[string]interface{}
-	if err := json.Unmarshal(mockTransport.LastSentMessage, &removeRequest); err != nil {
-		t.Fatalf("Failed to parse remove request: %v", err)
+	var removeNotification map[string]interface{}
+	if err := json.Unmarshal(mockTransport.LastSentMessage, &removeNotification); err != nil {
+		t.Fatalf("Failed to parse remove notification: %v", err)
 	}
 
-	if removeRequest["method"] != "roots/remove" {
-		t.Errorf("Expected method roots/remove, got %v", removeRequest["method"])
-	}
-
-	removeParams, ok := removeRequest["params"].(map[string]interface{})
-	if !ok {
-		t.Fatalf("Expected params in remove request, got %v", removeRequest)
+	if removeNotification["method"] != "notifications/roots/list_changed" {
+		t.Errorf("Expected method notifications/roots/list_changed, got %v", removeNotification["method"])
 	}
 
-	if removeParams["uri"] != "/test/draft/root" {
-		t.Errorf("Remove root params not as expected: %v", removeParams)
+	if roots, _ := c.GetRoots(); len(roots) != 0 {
+		t.Errorf("Expected no roots after removal, got %+v", roots)
 	}
 }
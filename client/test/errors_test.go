@@ -0,0 +1,56 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/protocol"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+func TestClientErrorsCarryProtocolCodes(t *testing.T) {
+	c, s := inproc.Pair()
+	srv := server.NewServer("errors-test", server.WithTransport(s)).
+		Tool("echo", "Echo a message", func(ctx *server.Context, args struct {
+			Message string `json:"message"`
+		}) (interface{}, error) {
+			return args.Message, nil
+		})
+	srv.Use(func(next server.RequestHandler) server.RequestHandler {
+		return func(ctx *server.Context) (interface{}, error) {
+			if ctx.Request.ToolName == "echo" && ctx.Meta().APIKey() == "" {
+				return nil, &server.RPCError{Code: protocol.Unauthorized, Message: "Unauthorized"}
+			}
+			return next(ctx)
+		}
+	})
+	go srv.Run()
+
+	cl, err := client.NewClient("errors-client", client.WithInProcess(c), client.WithProtocolVersion("2025-03-26"))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	_, err = cl.CallTool("missing", nil)
+	if !protocol.IsUnknownTool(err) || !protocol.IsErrorCode(err, protocol.InvalidParams) {
+		t.Errorf("Expected an unknown tool error, got %v", err)
+	}
+
+	_, err = cl.CallTool("echo", map[string]interface{}{"message": "hi"})
+	if !protocol.IsErrorCode(err, protocol.Unauthorized) {
+		t.Errorf("Expected an unauthorized error, got %v", err)
+	}
+	var protocolErr *protocol.Error
+	if !errors.As(err, &protocolErr) || protocolErr.Message != "Unauthorized" {
+		t.Errorf("Expected the server's message, got %v", err)
+	}
+	if got := err.Error(); got != "server returned error: Unauthorized (code -32001)" {
+		t.Errorf("Unexpected message %q", got)
+	}
+	if protocol.IsUnknownTool(err) {
+		t.Error("Expected only unknown tool errors to report IsUnknownTool")
+	}
+}
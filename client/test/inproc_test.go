@@ -0,0 +1,39 @@
+package test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+// TestInProcessRoundTrip connects a client to a server through an in-process
+// pair and calls a tool.
+func TestInProcessRoundTrip(t *testing.T) {
+	c, s := inproc.Pair()
+
+	srv := server.NewServer("inproc-test", server.WithTransport(s)).
+		Tool("echo", "Echo a message", func(ctx *server.Context, args struct {
+			Message string `json:"message"`
+		}) (interface{}, error) {
+			return args.Message, nil
+		})
+	go srv.Run()
+
+	cl, err := client.NewClient("inproc-client", client.WithInProcess(c))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	result, err := cl.CallTool("echo", map[string]interface{}{"message": "no sockets"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !strings.Contains(fmt.Sprint(result), "no sockets") {
+		t.Errorf("Expected the echoed message, got %v", result)
+	}
+}
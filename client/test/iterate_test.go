@@ -0,0 +1,115 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+func listResponse(key string, entries []map[string]interface{}, nextCursor string) []byte {
+	result := map[string]interface{}{key: entries}
+	if nextCursor != "" {
+		result["nextCursor"] = nextCursor
+	}
+	response, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 2, "result": result})
+	return response
+}
+
+func TestToolsIteratesAcrossPages(t *testing.T) {
+	mockTransport := NewMockTransport()
+	c := newCapabilitiesClient(t, mockTransport, map[string]interface{}{"tools": map[string]interface{}{}})
+
+	mockTransport.QueueResponse(listResponse("tools", []map[string]interface{}{{"name": "a"}, {"name": "b"}}, "page-2"), nil)
+	mockTransport.QueueResponse(listResponse("tools", []map[string]interface{}{{"name": "c", "description": "third"}}, ""), nil)
+
+	var names []string
+	for tool, err := range c.Tools(context.Background()) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		names = append(names, tool.Name)
+	}
+	if len(names) != 3 || names[0] != "a" || names[2] != "c" {
+		t.Errorf("Unexpected tools: %v", names)
+	}
+
+	requests := mockTransport.GetRequestsByMethod("tools/list")
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 tools/list requests, got %d", len(requests))
+	}
+	var second struct {
+		Params struct {
+			Cursor string `json:"cursor"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(requests[1].Message, &second); err != nil || second.Params.Cursor != "page-2" {
+		t.Errorf("Expected second request to carry the cursor, got %s", requests[1].Message)
+	}
+}
+
+func TestPromptsStopsEarly(t *testing.T) {
+	mockTransport := NewMockTransport()
+	c := newCapabilitiesClient(t, mockTransport, map[string]interface{}{"prompts": map[string]interface{}{}})
+
+	mockTransport.QueueResponse(listResponse("prompts", []map[string]interface{}{{"name": "first"}, {"name": "second"}}, "more"), nil)
+
+	for prompt, err := range c.Prompts(context.Background()) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if prompt.Name != "first" {
+			t.Errorf("Expected first prompt, got %q", prompt.Name)
+		}
+		break
+	}
+	if got := len(mockTransport.GetRequestsByMethod("prompts/list")); got != 1 {
+		t.Errorf("Expected breaking out of the loop to stop paging, got %d requests", got)
+	}
+}
+
+func TestResourcesYieldsErrors(t *testing.T) {
+	mockTransport := NewMockTransport()
+	c := newCapabilitiesClient(t, mockTransport, nil)
+
+	mockTransport.QueueResponse(CreateToolErrorResponse(2, -32601, "Method not found", nil), nil)
+
+	var errs int
+	for _, err := range c.Resources(context.Background()) {
+		if err != nil {
+			errs++
+		}
+	}
+	if errs != 1 {
+		t.Errorf("Expected exactly one error, got %d", errs)
+	}
+}
+
+func TestListAllToolsWalksServerPages(t *testing.T) {
+	c, s := inproc.Pair()
+	srv := server.NewServer("paginate-test", server.WithTransport(s), server.WithPageSize(2))
+	for i := 0; i < 5; i++ {
+		srv.Tool(fmt.Sprintf("tool-%d", i), "A tool", func(ctx *server.Context, args struct{}) (string, error) {
+			return "", nil
+		})
+	}
+	go srv.Run()
+
+	cl, err := client.NewClient("paginate-client", client.WithInProcess(c), client.WithProtocolVersion("2025-03-26"))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	tools, err := cl.ListAllTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListAllTools failed: %v", err)
+	}
+	if len(tools) != 5 || tools[0].Name != "tool-0" || tools[4].Name != "tool-4" {
+		t.Errorf("Expected all 5 tools in order, got %+v", tools)
+	}
+}
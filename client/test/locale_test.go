@@ -0,0 +1,59 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/mcp"
+)
+
+// initializeMeta connects a client with the given options and returns the
+// _meta of its initialize request
+func initializeMeta(t *testing.T, options ...client.Option) map[string]interface{} {
+	t.Helper()
+
+	mockTransport := NewMockTransport()
+	EnsureConnected(mockTransport)
+	mockTransport.QueueConditionalResponse(CreateInitializeResponse("2025-03-26", nil), nil, IsRequestMethod("initialize"))
+
+	options = append([]client.Option{
+		client.WithTransport(mockTransport),
+		client.WithVersionDetector(mcp.NewVersionDetector()),
+	}, options...)
+	if _, err := client.NewClient("test://server", options...); err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	requests := mockTransport.GetRequestsByMethod("initialize")
+	if len(requests) == 0 {
+		t.Fatal("Expected an initialize request")
+	}
+
+	var request struct {
+		Params struct {
+			Meta map[string]interface{} `json:"_meta"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(requests[0].Message, &request); err != nil {
+		t.Fatalf("Failed to parse initialize request: %v", err)
+	}
+	return request.Params.Meta
+}
+
+func TestLocaleAndTimezoneSentOnInitialize(t *testing.T) {
+	meta := initializeMeta(t, client.WithLocale("fr-CA"), client.WithTimezone("America/Montreal"))
+
+	if meta["locale"] != "fr-CA" {
+		t.Errorf("Expected locale fr-CA, got %v", meta["locale"])
+	}
+	if meta["timezone"] != "America/Montreal" {
+		t.Errorf("Expected timezone America/Montreal, got %v", meta["timezone"])
+	}
+}
+
+func TestInitializeOmitsMetaWithoutHints(t *testing.T) {
+	if meta := initializeMeta(t); meta != nil {
+		t.Errorf("Expected no _meta without locale or timezone, got %v", meta)
+	}
+}
@@ -0,0 +1,59 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+// TestLogMessages checks that server log messages at the level the client
+// set reach its OnLogMessage handler.
+func TestLogMessages(t *testing.T) {
+	c, s := inproc.Pair()
+	srv := server.NewServer("logging-test", server.WithTransport(s))
+	srv.Tool("work", "Logs a debug and an error message", func(ctx *server.Context, args struct{}) (string, error) {
+		ctx.Log(server.LogDebug, "worker", "starting")
+		ctx.Log(server.LogError, "worker", map[string]interface{}{"message": "disk full"})
+		return "done", nil
+	})
+	go srv.Run()
+
+	messages := make(chan client.LogMessage, 4)
+	cl, err := client.NewClient("logging-client",
+		client.WithInProcess(c),
+		client.WithProtocolVersion("2025-03-26"),
+		client.OnLogMessage(func(m client.LogMessage) { messages <- m }),
+	)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	if err := cl.SetLogLevel("loud"); err == nil {
+		t.Error("Expected an unknown level to be rejected")
+	}
+	if err := cl.SetLogLevel("error"); err != nil {
+		t.Fatalf("Failed to set the log level: %v", err)
+	}
+	if _, err := cl.CallTool("work", map[string]interface{}{}); err != nil {
+		t.Fatalf("Tool call failed: %v", err)
+	}
+
+	select {
+	case m := <-messages:
+		data, _ := m.Data.(map[string]interface{})
+		if m.Level != "error" || m.Logger != "worker" || data["message"] != "disk full" {
+			t.Errorf("Expected the error message, got %+v", m)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a log message")
+	}
+	select {
+	case m := <-messages:
+		t.Errorf("Expected messages below the level to be dropped, got %+v", m)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
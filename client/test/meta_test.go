@@ -0,0 +1,59 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+)
+
+func TestCallToolWithCallMeta(t *testing.T) {
+	mockTransport := NewMockTransport()
+	c := newCapabilitiesClient(t, mockTransport, map[string]interface{}{"tools": map[string]interface{}{}})
+
+	mockTransport.QueueResponse(CreateToolResponse("ok"), nil)
+	_, err := c.CallTool("charge", map[string]interface{}{"amount": 5},
+		client.WithCallMeta(map[string]interface{}{"idempotencyKey": "first", "tenant": "acme"}),
+		client.WithCallMeta(map[string]interface{}{"idempotencyKey": "order-42"}),
+	)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+
+	requests := mockTransport.GetRequestsByMethod("tools/call")
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 tools/call request, got %d", len(requests))
+	}
+	var request struct {
+		Params struct {
+			Meta map[string]interface{} `json:"_meta"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(requests[0].Message, &request); err != nil {
+		t.Fatalf("Failed to parse request: %v", err)
+	}
+	if request.Params.Meta["idempotencyKey"] != "order-42" || request.Params.Meta["tenant"] != "acme" {
+		t.Errorf("Unexpected _meta: %v", request.Params.Meta)
+	}
+}
+
+func TestCallToolWithoutMeta(t *testing.T) {
+	mockTransport := NewMockTransport()
+	c := newCapabilitiesClient(t, mockTransport, map[string]interface{}{"tools": map[string]interface{}{}})
+
+	mockTransport.QueueResponse(CreateToolResponse("ok"), nil)
+	if _, err := c.CallTool("echo", nil); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+
+	requests := mockTransport.GetRequestsByMethod("tools/call")
+	var request struct {
+		Params map[string]interface{} `json:"params"`
+	}
+	if err := json.Unmarshal(requests[0].Message, &request); err != nil {
+		t.Fatalf("Failed to parse request: %v", err)
+	}
+	if _, ok := request.Params["_meta"]; ok {
+		t.Errorf("Expected no _meta without options, got %v", request.Params)
+	}
+}
@@ -0,0 +1,55 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+type pagedIssue struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"`
+}
+
+func TestIterateTool(t *testing.T) {
+	c, s := inproc.Pair()
+	srv := server.NewServer("paginated-test", server.WithTransport(s), server.WithPageSize(3)).
+		Tool("search", "Search the issues", server.Paginated(func(ctx *server.Context, args struct {
+			server.PageRequest
+			Label string `json:"label"`
+		}) ([]pagedIssue, error) {
+			var issues []pagedIssue
+			for id := 1; id <= 7; id++ {
+				issues = append(issues, pagedIssue{ID: id, Label: args.Label})
+			}
+			return issues, nil
+		}))
+	go srv.Run()
+
+	cl, err := client.NewClient("paginated-client", client.WithInProcess(c))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	issues, err := client.CallToolAll[pagedIssue](context.Background(), cl, "search", map[string]interface{}{"label": "bug"})
+	if err != nil {
+		t.Fatalf("CallToolAll failed: %v", err)
+	}
+	if len(issues) != 7 || issues[0].ID != 1 || issues[6].ID != 7 || issues[6].Label != "bug" {
+		t.Errorf("Expected all 7 issues, got %+v", issues)
+	}
+
+	count := 0
+	for _, err := range client.IterateTool[pagedIssue](context.Background(), cl, "search", map[string]interface{}{"label": "docs"}) {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if count++; count == 2 {
+			break
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport"
+)
+
+// droppableTransport passes messages to a server until it is taken down,
+// after which sends and connects fail until it is brought back up.
+type droppableTransport struct {
+	srv      server.Server
+	down     atomic.Bool
+	connects atomic.Int32
+	mu       sync.Mutex
+	methods  []string
+}
+
+var errLinkDown = errors.New("link down")
+
+func (t *droppableTransport) Connect() error {
+	t.connects.Add(1)
+	if t.down.Load() {
+		return errLinkDown
+	}
+	return nil
+}
+
+func (t *droppableTransport) ConnectWithContext(ctx context.Context) error { return t.Connect() }
+func (t *droppableTransport) Disconnect() error                            { return nil }
+func (t *droppableTransport) SetRequestTimeout(time.Duration)              {}
+func (t *droppableTransport) SetConnectionTimeout(time.Duration)           {}
+func (t *droppableTransport) RegisterNotificationHandler(func(string, []byte)) {
+}
+
+func (t *droppableTransport) Send(message []byte) ([]byte, error) {
+	return t.SendWithContext(context.Background(), message)
+}
+
+func (t *droppableTransport) SendWithContext(ctx context.Context, message []byte) ([]byte, error) {
+	if t.down.Load() {
+		return nil, errLinkDown
+	}
+	var request struct {
+		Method string `json:"method"`
+	}
+	json.Unmarshal(message, &request)
+	t.mu.Lock()
+	t.methods = append(t.methods, request.Method)
+	t.mu.Unlock()
+	return server.HandleMessage(t.srv.GetServer(), message)
+}
+
+func (t *droppableTransport) count(method string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for _, m := range t.methods {
+		if m == method {
+			n++
+		}
+	}
+	return n
+}
+
+// discardTransport drops the server's notifications, which the
+// droppable transport has no way to deliver.
+type discardTransport struct {
+	transport.BaseTransport
+}
+
+func (discardTransport) Initialize() error        { return nil }
+func (discardTransport) Start() error             { return nil }
+func (discardTransport) Stop() error              { return nil }
+func (discardTransport) Send([]byte) error        { return nil }
+func (discardTransport) Receive() ([]byte, error) { return nil, errors.New("not supported") }
+
+func newReconnectServer() server.Server {
+	return server.NewServer("reconnect-test", server.WithTransport(&discardTransport{})).
+		Tool("echo", "Echo a message", func(ctx *server.Context, args struct {
+			Message string `json:"message"`
+		}) (interface{}, error) {
+			return args.Message, nil
+		}).
+		Resource("file:///status", "Status", func(ctx *server.Context) (interface{}, error) {
+			return "ok", nil
+		})
+}
+
+// stateRecorder collects connection events.
+type stateRecorder struct {
+	mu     sync.Mutex
+	events []client.ConnectionEvent
+	ch     chan client.ConnectionState
+}
+
+func newStateRecorder() *stateRecorder {
+	return &stateRecorder{ch: make(chan client.ConnectionState, 32)}
+}
+
+func (r *stateRecorder) record(e client.ConnectionEvent) {
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+	r.ch <- e.State
+}
+
+func (r *stateRecorder) waitFor(t *testing.T, state client.ConnectionState) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case got := <-r.ch:
+			if got == state {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("Timed out waiting for connection state %s", state)
+		}
+	}
+}
+
+func TestReconnectAfterTransportLoss(t *testing.T) {
+	transport := &droppableTransport{srv: newReconnectServer()}
+	states := newStateRecorder()
+
+	c, err := client.NewClient("test://server",
+		client.WithTransport(transport),
+		client.WithProtocolVersion("2025-03-26"),
+		client.WithAutoReconnect(client.ReconnectPolicy{
+			InitialDelay:  10 * time.Millisecond,
+			Jitter:        -1,
+			OnStateChange: states.record,
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.SubscribeResource("file:///status", func(string) {}); err != nil {
+		t.Fatalf("SubscribeResource failed: %v", err)
+	}
+
+	// Fail a few reconnect attempts before the link comes back
+	transport.down.Store(true)
+	if _, err := c.CallTool("echo", map[string]interface{}{"message": "lost"}); !errors.Is(err, client.ErrConnectionLost) {
+		t.Fatalf("Expected ErrConnectionLost, got %v", err)
+	}
+	states.waitFor(t, client.ConnectionReconnecting)
+	states.waitFor(t, client.ConnectionReconnecting)
+	transport.down.Store(false)
+	states.waitFor(t, client.ConnectionConnected)
+
+	if _, err := c.CallTool("echo", map[string]interface{}{"message": "back"}); err != nil {
+		t.Fatalf("Expected calls to work after reconnecting, got %v", err)
+	}
+	if n := transport.count("initialize"); n != 2 {
+		t.Errorf("Expected initialize to be replayed once, got %d initialize requests", n)
+	}
+	if n := transport.count("resources/subscribe"); n != 2 {
+		t.Errorf("Expected the subscription to be restored, got %d subscribe requests", n)
+	}
+
+	states.mu.Lock()
+	defer states.mu.Unlock()
+	if states.events[0].State != client.ConnectionDisconnected || !errors.Is(states.events[0].Err, errLinkDown) {
+		t.Errorf("Expected the first event to report the loss, got %+v", states.events[0])
+	}
+}
+
+func TestReconnectGivesUp(t *testing.T) {
+	transport := &droppableTransport{srv: newReconnectServer()}
+	states := newStateRecorder()
+
+	c, err := client.NewClient("test://server",
+		client.WithTransport(transport),
+		client.WithProtocolVersion("2025-03-26"),
+		client.WithAutoReconnect(client.ReconnectPolicy{
+			InitialDelay:  time.Millisecond,
+			MaxAttempts:   3,
+			OnStateChange: states.record,
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	transport.down.Store(true)
+	c.CallTool("echo", map[string]interface{}{"message": "lost"})
+	states.waitFor(t, client.ConnectionFailed)
+
+	// One initial connect and three reconnect attempts
+	if n := transport.connects.Load(); n != 4 {
+		t.Errorf("Expected 4 connection attempts, got %d", n)
+	}
+}
+
+func TestHealthCheckDetectsSilentDrop(t *testing.T) {
+	transport := &droppableTransport{srv: newReconnectServer()}
+	states := newStateRecorder()
+
+	c, err := client.NewClient("test://server",
+		client.WithTransport(transport),
+		client.WithProtocolVersion("2025-03-26"),
+		client.WithAutoReconnect(client.ReconnectPolicy{
+			InitialDelay:        time.Millisecond,
+			HealthCheckInterval: 10 * time.Millisecond,
+			OnStateChange:       states.record,
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	transport.down.Store(true)
+	states.waitFor(t, client.ConnectionDisconnected)
+	transport.down.Store(false)
+	states.waitFor(t, client.ConnectionConnected)
+
+	if !c.IsConnected() {
+		t.Error("Expected the client to be connected again")
+	}
+}
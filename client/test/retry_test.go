@@ -0,0 +1,146 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/client"
+)
+
+// flakyTransport fails a number of sends before passing messages to the
+// server.
+type flakyTransport struct {
+	droppableTransport
+	failures atomic.Int32
+	attempts atomic.Int32
+}
+
+func (t *flakyTransport) Send(message []byte) ([]byte, error) {
+	return t.SendWithContext(context.Background(), message)
+}
+
+func (t *flakyTransport) SendWithContext(ctx context.Context, message []byte) ([]byte, error) {
+	t.attempts.Add(1)
+	if t.failures.Add(-1) >= 0 {
+		return nil, errLinkDown
+	}
+	return t.droppableTransport.SendWithContext(ctx, message)
+}
+
+func newRetryClient(t *testing.T, transport *flakyTransport) client.Client {
+	t.Helper()
+	c, err := client.NewClient("test://server",
+		client.WithTransport(transport),
+		client.WithProtocolVersion("2025-03-26"),
+		client.WithRetryPolicy(client.RetryPolicy{
+			MaxAttempts:  3,
+			InitialDelay: time.Millisecond,
+			Jitter:       -1,
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestRetryPolicyRetriesIdempotentRequests(t *testing.T) {
+	transport := &flakyTransport{droppableTransport: droppableTransport{srv: newReconnectServer()}}
+	c := newRetryClient(t, transport)
+
+	transport.failures.Store(2)
+	transport.attempts.Store(0)
+	tools, err := c.ListAllTools(context.Background())
+	if err != nil {
+		t.Fatalf("Expected the list to succeed on the third attempt, got %v", err)
+	}
+	if len(tools) != 1 {
+		t.Errorf("Expected 1 tool, got %d", len(tools))
+	}
+	if n := transport.attempts.Load(); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+
+	// Attempts run out
+	transport.failures.Store(3)
+	transport.attempts.Store(0)
+	if _, err := c.ListAllTools(context.Background()); !errors.Is(err, errLinkDown) {
+		t.Errorf("Expected the transport error after the last attempt, got %v", err)
+	}
+	if n := transport.attempts.Load(); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+}
+
+func TestRetryPolicyToolCalls(t *testing.T) {
+	transport := &flakyTransport{droppableTransport: droppableTransport{srv: newReconnectServer()}}
+	c := newRetryClient(t, transport)
+	args := map[string]interface{}{"message": "hi"}
+
+	// Without an idempotency key the call may have run, so it isn't retried
+	transport.failures.Store(1)
+	transport.attempts.Store(0)
+	if _, err := c.CallTool("echo", args); err == nil {
+		t.Error("Expected the tool call to fail without retrying")
+	}
+	if n := transport.attempts.Load(); n != 1 {
+		t.Errorf("Expected 1 attempt, got %d", n)
+	}
+
+	transport.failures.Store(1)
+	transport.attempts.Store(0)
+	_, err := c.CallTool("echo", args, client.WithCallMeta(map[string]interface{}{"idempotencyKey": "order-1"}))
+	if err != nil {
+		t.Fatalf("Expected the idempotent call to be retried, got %v", err)
+	}
+	if n := transport.attempts.Load(); n != 2 {
+		t.Errorf("Expected 2 attempts, got %d", n)
+	}
+}
+
+// deadlineTransport records how long each request was given.
+type deadlineTransport struct {
+	droppableTransport
+	deadline atomic.Int64
+}
+
+func (t *deadlineTransport) SendWithContext(ctx context.Context, message []byte) ([]byte, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		t.deadline.Store(int64(time.Until(deadline)))
+	}
+	return t.droppableTransport.SendWithContext(ctx, message)
+}
+
+func (t *deadlineTransport) Send(message []byte) ([]byte, error) {
+	return t.SendWithContext(context.Background(), message)
+}
+
+func TestWithCallTimeout(t *testing.T) {
+	transport := &deadlineTransport{droppableTransport: droppableTransport{srv: newReconnectServer()}}
+	c, err := client.NewClient("test://server",
+		client.WithTransport(transport),
+		client.WithProtocolVersion("2025-03-26"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.CallTool("echo", map[string]interface{}{"message": "hi"}, client.WithCallTimeout(2*time.Second)); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if d := time.Duration(transport.deadline.Load()); d <= 0 || d > 2*time.Second {
+		t.Errorf("Expected the request to be sent with a 2s deadline, got %v", d)
+	}
+
+	if _, err := c.CallTool("echo", map[string]interface{}{"message": "hi"}); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if d := time.Duration(transport.deadline.Load()); d <= 2*time.Second {
+		t.Errorf("Expected the client's request timeout without the option, got %v", d)
+	}
+}
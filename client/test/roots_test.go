@@ -0,0 +1,76 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+// TestServerListsClientRoots checks that a tool sees the client's roots,
+// and sees them change once the client notifies the server.
+func TestServerListsClientRoots(t *testing.T) {
+	c, s := inproc.Pair()
+	srv := server.NewServer("roots-test", server.WithTransport(s)).
+		Tool("roots", "List the client's roots", func(ctx *server.Context, args struct{}) (interface{}, error) {
+			roots, err := ctx.ListRoots()
+			if err != nil {
+				return nil, err
+			}
+			uris := make([]string, 0, len(roots))
+			for _, root := range roots {
+				uris = append(uris, root.URI)
+			}
+			return strings.Join(uris, ","), nil
+		})
+	go srv.Run()
+
+	cl, err := client.NewClient("roots-client", client.WithInProcess(c), client.WithProtocolVersion("2025-03-26"))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	if err := cl.SetRoots([]client.Root{{URI: "file:///workspace/api", Name: "API"}}); err != nil {
+		t.Fatalf("SetRoots failed: %v", err)
+	}
+
+	listed := func() string {
+		result, err := cl.CallTool("roots", nil)
+		if err != nil {
+			t.Fatalf("CallTool failed: %v", err)
+		}
+		content := result.(map[string]interface{})["content"].([]interface{})
+		return content[0].(map[string]interface{})["text"].(string)
+	}
+
+	// The notification is handled asynchronously, so wait for the server to
+	// see each change
+	waitFor := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			got := listed()
+			if got == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected the server to list %q, got %q", want, got)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	waitFor("file:///workspace/api")
+	if err := cl.AddRoot("file:///workspace/web", "Web"); err != nil {
+		t.Fatalf("AddRoot failed: %v", err)
+	}
+	waitFor("file:///workspace/api,file:///workspace/web")
+	if err := cl.RemoveRoot("file:///workspace/api"); err != nil {
+		t.Fatalf("RemoveRoot failed: %v", err)
+	}
+	waitFor("file:///workspace/web")
+}
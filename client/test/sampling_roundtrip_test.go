@@ -0,0 +1,108 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+// newSamplingServer returns a server with a tool that asks the client's
+// model to summarize its input.
+func newSamplingServer(t *testing.T) *inproc.ClientTransport {
+	t.Helper()
+	c, s := inproc.Pair()
+
+	temperature := 0.2
+	srv := server.NewServer("sampling-test", server.WithTransport(s)).
+		Tool("summarize", "Summarize text", func(ctx *server.Context, args struct {
+			Text string `json:"text"`
+		}) (interface{}, error) {
+			reply, err := ctx.CreateMessage(server.SamplingCreateMessageParams{
+				Messages:      []server.SamplingMessage{server.CreateTextSamplingMessage("user", args.Text)},
+				SystemPrompt:  "Summarize in one line.",
+				MaxTokens:     50,
+				Temperature:   &temperature,
+				StopSequences: []string{"\n"},
+			})
+			if err != nil {
+				return nil, err
+			}
+			return reply.Model + ": " + reply.Content.Text, nil
+		})
+	go srv.Run()
+	return c
+}
+
+func TestCreateMessageRoundTrip(t *testing.T) {
+	var got client.SamplingCreateMessageParams
+	cl, err := client.NewClient("sampling-client",
+		client.WithInProcess(newSamplingServer(t)),
+		client.OnSamplingRequest(func(params client.SamplingCreateMessageParams) (client.SamplingResponse, error) {
+			got = params
+			return client.SamplingResponse{
+				Role:       "assistant",
+				Content:    client.SamplingMessageContent{Type: "text", Text: "a short summary"},
+				Model:      "test-model",
+				StopReason: "endTurn",
+			}, nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	result, err := cl.CallTool("summarize", map[string]interface{}{"text": "a long document"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !strings.Contains(fmt.Sprint(result), "test-model: a short summary") {
+		t.Errorf("Expected the sampled message in the result, got %v", result)
+	}
+
+	if len(got.Messages) != 1 || got.Messages[0].Content.Text != "a long document" {
+		t.Errorf("Expected the tool's message, got %+v", got.Messages)
+	}
+	if got.SystemPrompt != "Summarize in one line." || got.MaxTokens != 50 {
+		t.Errorf("Expected the system prompt and token limit, got %q and %d", got.SystemPrompt, got.MaxTokens)
+	}
+	if got.Temperature == nil || *got.Temperature != 0.2 || len(got.StopSequences) != 1 {
+		t.Errorf("Expected the temperature and stop sequences, got %v and %v", got.Temperature, got.StopSequences)
+	}
+}
+
+func TestCreateMessageHandlerError(t *testing.T) {
+	cl, err := client.NewClient("sampling-client",
+		client.WithInProcess(newSamplingServer(t)),
+		client.OnSamplingRequest(func(params client.SamplingCreateMessageParams) (client.SamplingResponse, error) {
+			return client.SamplingResponse{}, errors.New("user declined")
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	result, err := cl.CallTool("summarize", map[string]interface{}{"text": "a long document"})
+	if err == nil && !strings.Contains(fmt.Sprint(result), "user declined") {
+		t.Errorf("Expected the handler's error to reach the tool, got %v", result)
+	}
+}
+
+func TestCreateMessageRequiresCapability(t *testing.T) {
+	cl, err := client.NewClient("sampling-client", client.WithInProcess(newSamplingServer(t)))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	result, err := cl.CallTool("summarize", map[string]interface{}{"text": "a long document"})
+	if err == nil && !strings.Contains(fmt.Sprint(result), "sampling") {
+		t.Errorf("Expected a missing capability error, got %v", result)
+	}
+}
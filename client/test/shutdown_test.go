@@ -0,0 +1,48 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+// TestServerShutdownEvent checks that a server's shutdown notice reaches
+// the client as a typed event.
+func TestServerShutdownEvent(t *testing.T) {
+	c, s := inproc.Pair()
+	srv := server.NewServer("shutdown-test", server.WithTransport(s))
+	go srv.Run()
+
+	events := make(chan client.ShutdownEvent, 1)
+	cl, err := client.NewClient("shutdown-client",
+		client.WithInProcess(c),
+		client.WithProtocolVersion("2025-03-26"),
+		client.OnServerShutdown(func(e client.ShutdownEvent) { events <- e }),
+	)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	err = srv.ShutdownWithNotice(context.Background(), server.ShutdownNotice{
+		Reason:         "upgrade",
+		Message:        "Restarting for an upgrade",
+		ReconnectAfter: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	select {
+	case e := <-events:
+		if e.Reason != "upgrade" || e.Message != "Restarting for an upgrade" || e.ReconnectAfter != 10*time.Second {
+			t.Errorf("Expected the server's notice, got %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a shutdown event")
+	}
+}
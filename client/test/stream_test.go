@@ -0,0 +1,78 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+func newStreamingPair(t *testing.T) client.Client {
+	t.Helper()
+	c, s := inproc.Pair()
+
+	srv := server.NewServer("stream-test", server.WithTransport(s)).
+		Tool("count", "Count to three", func(ctx *server.Context, args struct{}) (interface{}, error) {
+			for _, word := range []string{"one", "two", "three"} {
+				if err := ctx.StreamContent(server.TextContent(word)); err != nil {
+					return nil, err
+				}
+			}
+			return "done", nil
+		})
+	go srv.Run()
+
+	cl, err := client.NewClient("stream-client", client.WithInProcess(c))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { cl.Close() })
+	return cl
+}
+
+// TestCallToolStream checks that streamed content arrives in order before
+// the final result.
+func TestCallToolStream(t *testing.T) {
+	cl := newStreamingPair(t)
+
+	var texts []string
+	var sawFinal bool
+	for chunk, err := range cl.CallToolStream(context.Background(), "count", nil) {
+		if err != nil {
+			t.Fatalf("CallToolStream failed: %v", err)
+		}
+		if sawFinal {
+			t.Fatal("Expected the final chunk to come last")
+		}
+		sawFinal = chunk.Final
+		texts = append(texts, chunk.Text())
+	}
+
+	if got := strings.Join(texts, ","); got != "one,two,three,done" {
+		t.Errorf("Expected the streamed chunks then the result, got %q", got)
+	}
+	if !sawFinal {
+		t.Error("Expected a final chunk")
+	}
+}
+
+// TestStreamContentWithoutStreamingClient checks that clients that don't
+// stream get the streamed content in the final result.
+func TestStreamContentWithoutStreamingClient(t *testing.T) {
+	cl := newStreamingPair(t)
+
+	result, err := cl.CallTool("count", nil)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	text := fmt.Sprint(result)
+	for _, word := range []string{"one", "two", "three", "done"} {
+		if !strings.Contains(text, word) {
+			t.Errorf("Expected %q in the result, got %v", word, text)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package test
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+)
+
+// TestStreamableHTTPRoundTrip connects a client to a server over Streamable HTTP
+// and calls a tool.
+func TestStreamableHTTPRoundTrip(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	srv := server.NewServer("streamable-test").
+		Tool("echo", "Echo a message", func(ctx *server.Context, args struct {
+			Message string `json:"message"`
+		}) (interface{}, error) {
+			return args.Message, nil
+		}).
+		AsStreamableHTTP(addr)
+	go srv.Run()
+
+	url := fmt.Sprintf("http://%s/mcp", addr)
+	var c client.Client
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c, err = client.NewClient("streamable-client", client.WithStreamableHTTP(url))
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer c.Close()
+
+	result, err := c.CallTool("echo", map[string]interface{}{"message": "over one endpoint"})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if !strings.Contains(fmt.Sprint(result), "over one endpoint") {
+		t.Errorf("Expected the echoed message, got %v", result)
+	}
+}
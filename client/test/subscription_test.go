@@ -0,0 +1,305 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/mcp"
+	"github.com/localrivet/gomcp/protocol"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+// setupSubscriptionClient creates a client whose server advertises the given resource capabilities
+func setupSubscriptionClient(t *testing.T, resources map[string]interface{}) (client.Client, *MockTransport) {
+	t.Helper()
+
+	mockTransport := NewMockTransport()
+	EnsureConnected(mockTransport)
+	mockTransport.QueueConditionalResponse(
+		CreateInitializeResponse("2025-03-26", map[string]interface{}{"resources": resources}),
+		nil,
+		IsRequestMethod("initialize"),
+	)
+
+	c, err := client.NewClient("test://server",
+		client.WithTransport(mockTransport),
+		client.WithVersionDetector(mcp.NewVersionDetector()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	mockTransport.ClearHistory()
+	return c, mockTransport
+}
+
+func TestSubscribeResource(t *testing.T) {
+	c, mockTransport := setupSubscriptionClient(t, map[string]interface{}{"subscribe": true})
+
+	mockTransport.QueueConditionalResponse(CreateEmptyResponse(0), nil, IsRequestMethod("resources/subscribe"))
+
+	var mu sync.Mutex
+	var updates []string
+	err := c.SubscribeResource("file:///app.log", func(uri string) {
+		mu.Lock()
+		defer mu.Unlock()
+		updates = append(updates, uri)
+	})
+	if err != nil {
+		t.Fatalf("SubscribeResource failed: %v", err)
+	}
+
+	requests := mockTransport.GetRequestsByMethod("resources/subscribe")
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 subscribe request, got %d", len(requests))
+	}
+	AssertRequestParams(t, requests[0].Message, map[string]interface{}{"uri": "file:///app.log"})
+
+	// Subscribing again only replaces the handler
+	if err := c.SubscribeResource("file:///app.log", func(uri string) {
+		mu.Lock()
+		defer mu.Unlock()
+		updates = append(updates, "replaced:"+uri)
+	}); err != nil {
+		t.Fatalf("Second SubscribeResource failed: %v", err)
+	}
+	if got := len(mockTransport.GetRequestsByMethod("resources/subscribe")); got != 1 {
+		t.Errorf("Expected resubscribing to reuse the subscription, got %d requests", got)
+	}
+
+	mockTransport.SimulateNotification("notifications/resources/updated",
+		CreateNotificationResponse("notifications/resources/updated", map[string]interface{}{"uri": "file:///app.log"}))
+	mockTransport.SimulateNotification("notifications/resources/updated",
+		CreateNotificationResponse("notifications/resources/updated", map[string]interface{}{"uri": "file:///other.log"}))
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mu.Lock()
+		n := len(updates)
+		mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(updates) != 1 || updates[0] != "replaced:file:///app.log" {
+		t.Errorf("Expected a single update for the subscribed resource, got %v", updates)
+	}
+}
+
+func TestUnsubscribeResource(t *testing.T) {
+	c, mockTransport := setupSubscriptionClient(t, map[string]interface{}{"subscribe": true})
+
+	if err := c.UnsubscribeResource("file:///app.log"); err == nil {
+		t.Error("Expected error when unsubscribing from a resource that was never subscribed")
+	}
+
+	mockTransport.QueueConditionalResponse(CreateEmptyResponse(0), nil, IsRequestMethod("resources/subscribe"))
+	if err := c.SubscribeResource("file:///app.log", func(string) {}); err != nil {
+		t.Fatalf("SubscribeResource failed: %v", err)
+	}
+
+	mockTransport.QueueConditionalResponse(CreateEmptyResponse(0), nil, IsRequestMethod("resources/unsubscribe"))
+	if err := c.UnsubscribeResource("file:///app.log"); err != nil {
+		t.Fatalf("UnsubscribeResource failed: %v", err)
+	}
+
+	requests := mockTransport.GetRequestsByMethod("resources/unsubscribe")
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 unsubscribe request, got %d", len(requests))
+	}
+	AssertRequestParams(t, requests[0].Message, map[string]interface{}{"uri": "file:///app.log"})
+}
+
+func TestSubscribeResourceRequiresCapability(t *testing.T) {
+	c, mockTransport := setupSubscriptionClient(t, map[string]interface{}{"listChanged": true})
+
+	err := c.SubscribeResource("file:///app.log", func(string) {})
+	if !errors.Is(err, client.ErrSubscriptionsNotSupported) {
+		t.Errorf("Expected ErrSubscriptionsNotSupported, got %v", err)
+	}
+	if got := len(mockTransport.GetRequestsByMethod("resources/subscribe")); got != 0 {
+		t.Errorf("Expected no subscribe request to be sent, got %d", got)
+	}
+}
+
+func TestConcurrentSubscribesSendOneRequest(t *testing.T) {
+	c, s := inproc.Pair()
+	srv := server.NewServer("subscribe-test", server.WithTransport(s), server.WithResourceSubscriptions(true))
+	srv.Resource("file:///app.log", "Log", func(ctx *server.Context, args interface{}) (interface{}, error) {
+		return "log", nil
+	})
+	var mu sync.Mutex
+	subscribes := 0
+	srv.Use(func(next server.RequestHandler) server.RequestHandler {
+		return func(ctx *server.Context) (interface{}, error) {
+			if ctx.Request.Method == "resources/subscribe" {
+				mu.Lock()
+				subscribes++
+				mu.Unlock()
+				// Keep the first request in flight while the others arrive
+				time.Sleep(20 * time.Millisecond)
+			}
+			return next(ctx)
+		}
+	})
+	go srv.Run()
+
+	cl, err := client.NewClient("subscribe-client", client.WithInProcess(c), client.WithProtocolVersion("2025-03-26"))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := cl.SubscribeResource("file:///app.log", func(string) {}); err != nil {
+				t.Errorf("SubscribeResource failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if subscribes != 1 {
+		t.Errorf("Expected concurrent subscribes to send 1 request, got %d", subscribes)
+	}
+}
+
+func TestFailedSubscribeIsRolledBack(t *testing.T) {
+	c, mockTransport := setupSubscriptionClient(t, map[string]interface{}{"subscribe": true})
+
+	mockTransport.QueueConditionalResponse(nil, errors.New("connection reset"), IsRequestMethod("resources/subscribe"))
+	if err := c.SubscribeResource("file:///app.log", func(string) {}); err == nil {
+		t.Fatal("Expected the failed subscribe to return an error")
+	}
+
+	// The next attempt asks the server again
+	mockTransport.QueueConditionalResponse(CreateEmptyResponse(0), nil, IsRequestMethod("resources/subscribe"))
+	if err := c.SubscribeResource("file:///app.log", func(string) {}); err != nil {
+		t.Fatalf("SubscribeResource failed: %v", err)
+	}
+	if got := len(mockTransport.GetRequestsByMethod("resources/subscribe")); got != 2 {
+		t.Errorf("Expected the subscribe to be sent again after failing, got %d requests", got)
+	}
+}
+
+// newSlowSubscribeServer connects a client to a server whose subscribe
+// requests take a while, the first fail of which fail. It returns the
+// subscribe and unsubscribe requests completed, and the number of subscribe
+// requests received.
+func newSlowSubscribeServer(t *testing.T, fail int) (client.Client, func() []string, func() int) {
+	t.Helper()
+	c, s := inproc.Pair()
+	srv := server.NewServer("subscribe-test", server.WithTransport(s), server.WithResourceSubscriptions(true))
+	srv.Resource("file:///app.log", "Log", func(ctx *server.Context, args interface{}) (interface{}, error) {
+		return "log", nil
+	})
+	var mu sync.Mutex
+	var methods []string
+	subscribes := 0
+	srv.Use(func(next server.RequestHandler) server.RequestHandler {
+		return func(ctx *server.Context) (interface{}, error) {
+			method := ctx.Request.Method
+			switch method {
+			case "resources/subscribe":
+				mu.Lock()
+				subscribes++
+				failed := subscribes <= fail
+				mu.Unlock()
+				time.Sleep(30 * time.Millisecond)
+				if failed {
+					return nil, &server.RPCError{Code: protocol.InternalError, Message: "subscriptions unavailable"}
+				}
+			case "resources/unsubscribe":
+			default:
+				return next(ctx)
+			}
+			// Record requests as they complete
+			result, err := next(ctx)
+			mu.Lock()
+			methods = append(methods, method)
+			mu.Unlock()
+			return result, err
+		}
+	})
+	go srv.Run()
+
+	cl, err := client.NewClient("subscribe-client", client.WithInProcess(c), client.WithProtocolVersion("2025-03-26"))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { cl.Close() })
+	return cl, func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]string(nil), methods...)
+		}, func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return subscribes
+		}
+}
+
+func TestConcurrentSubscribesShareAFailure(t *testing.T) {
+	cl, _, subscribes := newSlowSubscribeServer(t, 1)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- cl.SubscribeResource("file:///app.log", func(string) {})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err == nil {
+			t.Error("Expected every caller to see the failed subscribe")
+		}
+	}
+
+	if err := cl.SubscribeResource("file:///app.log", func(string) {}); err != nil {
+		t.Fatalf("Expected a later subscribe to succeed, got %v", err)
+	}
+	if got := subscribes(); got != 2 {
+		t.Errorf("Expected one failed and one later subscribe request, got %d", got)
+	}
+}
+
+func TestUnsubscribeWaitsForSubscribe(t *testing.T) {
+	cl, methods, subscribes := newSlowSubscribeServer(t, 0)
+
+	subscribed := make(chan error, 1)
+	go func() {
+		subscribed <- cl.SubscribeResource("file:///app.log", func(string) {})
+	}()
+	deadline := time.Now().Add(time.Second)
+	for subscribes() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := cl.UnsubscribeResource("file:///app.log"); err != nil {
+		t.Fatalf("Expected the unsubscribe to wait for the subscribe, got %v", err)
+	}
+	if err := <-subscribed; err != nil {
+		t.Fatalf("SubscribeResource failed: %v", err)
+	}
+	if got := fmt.Sprint(methods()); got != "[resources/subscribe resources/unsubscribe]" {
+		t.Errorf("Expected the unsubscribe after the subscribe, got %s", got)
+	}
+}
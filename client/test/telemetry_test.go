@@ -0,0 +1,91 @@
+package test
+
+import (
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/telemetry"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+// findSpan returns the ended span with the given name and kind.
+func findSpan(spans []sdktrace.ReadOnlySpan, name string, kind trace.SpanKind) sdktrace.ReadOnlySpan {
+	for _, span := range spans {
+		if span.Name() == name && span.SpanKind() == kind {
+			return span
+		}
+	}
+	return nil
+}
+
+// TestTracingAcrossClientAndServer checks that a tool call is recorded as a
+// client span and a server span in the same trace.
+func TestTracingAcrossClientAndServer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	c, s := inproc.Pair()
+	var handlerTrace trace.TraceID
+	srv := server.NewServer("trace-test", server.WithTransport(s), server.WithTracerProvider(tp)).
+		Tool("echo", "Echo a message", func(ctx *server.Context, args struct {
+			Message string `json:"message"`
+		}) (interface{}, error) {
+			handlerTrace = trace.SpanContextFromContext(ctx.Context()).TraceID()
+			return args.Message, nil
+		})
+	go srv.Run()
+
+	cl, err := client.NewClient("trace-client", client.WithInProcess(c), client.WithTracerProvider(tp))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	if _, err := cl.CallTool("echo", map[string]interface{}{"message": "hi"}); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if _, err := cl.CallTool("missing", nil); err == nil {
+		t.Fatal("Expected calling an unknown tool to fail")
+	}
+
+	spans := recorder.Ended()
+	clientSpan := findSpan(spans, "tools/call echo", trace.SpanKindClient)
+	serverSpan := findSpan(spans, "tools/call echo", trace.SpanKindServer)
+	if clientSpan == nil || serverSpan == nil {
+		t.Fatalf("Expected client and server spans for the call, got %d spans", len(spans))
+	}
+	if serverSpan.Parent().SpanID() != clientSpan.SpanContext().SpanID() {
+		t.Error("Expected the server span to be a child of the client span")
+	}
+	if handlerTrace != clientSpan.SpanContext().TraceID() {
+		t.Error("Expected the handler's context to carry the call's trace")
+	}
+	var clientName string
+	for _, attr := range serverSpan.Attributes() {
+		if attr.Key == telemetry.ClientNameKey {
+			clientName = attr.Value.AsString()
+		}
+	}
+	if clientName == "" {
+		t.Error("Expected the server span to name the client application")
+	}
+
+	failed := findSpan(spans, "tools/call missing", trace.SpanKindClient)
+	if failed == nil {
+		t.Fatal("Expected a client span for the failed call")
+	}
+	var code int64
+	for _, attr := range failed.Attributes() {
+		if attr.Key == telemetry.ErrorCodeKey {
+			code = attr.Value.AsInt64()
+		}
+	}
+	if code == 0 {
+		t.Error("Expected the failed call's span to record its error code")
+	}
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+type convertIn struct {
+	Amount   float64 `json:"amount" required:"true"`
+	Currency string  `json:"currency" required:"true" enum:"EUR,USD"`
+}
+
+type convertOut struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+func newTypedClient(t *testing.T) client.Client {
+	t.Helper()
+	c, s := inproc.Pair()
+
+	srv := server.NewServer("typed-test", server.WithTransport(s)).
+		Tool("convert", "Convert an amount to EUR", func(ctx *server.Context, args convertIn) (convertOut, error) {
+			if args.Amount < 0 {
+				return convertOut{}, errors.New("amount must not be negative")
+			}
+			return convertOut{Amount: args.Amount * 0.5, Currency: "EUR"}, nil
+		}).
+		Tool("greet", "Greet someone", func(ctx *server.Context, args struct {
+			Name string `json:"name"`
+		}) (string, error) {
+			return "Hello, " + args.Name, nil
+		})
+	go srv.Run()
+
+	cl, err := client.NewClient("typed-client", client.WithInProcess(c))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { cl.Close() })
+	return cl
+}
+
+func TestCallToolTyped(t *testing.T) {
+	cl := newTypedClient(t)
+	ctx := context.Background()
+
+	out, err := client.CallToolTyped[convertIn, convertOut](ctx, cl, "convert", convertIn{Amount: 10, Currency: "USD"})
+	if err != nil {
+		t.Fatalf("CallToolTyped failed: %v", err)
+	}
+	if out.Amount != 5 || out.Currency != "EUR" {
+		t.Errorf("Unexpected result %+v", out)
+	}
+
+	greeting, err := client.CallToolTyped[map[string]string, string](ctx, cl, "greet", map[string]string{"name": "Ada"})
+	if err != nil {
+		t.Fatalf("CallToolTyped failed: %v", err)
+	}
+	if greeting != "Hello, Ada" {
+		t.Errorf("Expected the text result as a string, got %q", greeting)
+	}
+}
+
+func TestCallToolTypedErrors(t *testing.T) {
+	cl := newTypedClient(t)
+	ctx := context.Background()
+
+	_, err := client.CallToolTyped[convertIn, convertOut](ctx, cl, "convert", convertIn{Amount: -1, Currency: "USD"})
+	var toolErr *client.ToolError
+	if !errors.As(err, &toolErr) {
+		t.Fatalf("Expected a ToolError, got %v", err)
+	}
+	if toolErr.Tool != "convert" || toolErr.Message == "" {
+		t.Errorf("Unexpected tool error %+v", toolErr)
+	}
+
+	_, err = client.CallToolTyped[convertIn, convertOut](ctx, cl, "convert", convertIn{Amount: 1, Currency: "GBP"})
+	var argErr *client.ArgumentError
+	if !errors.As(err, &argErr) {
+		t.Fatalf("Expected an ArgumentError for a value outside the enum, got %v", err)
+	}
+
+	_, err = client.CallToolTyped[map[string]interface{}, convertOut](ctx, cl, "convert", map[string]interface{}{"amount": 1})
+	if !errors.As(err, &argErr) {
+		t.Fatalf("Expected an ArgumentError for a missing field, got %v", err)
+	}
+
+	if _, err := client.CallToolTyped[struct{}, string](ctx, cl, "missing", struct{}{}); err == nil {
+		t.Error("Expected an error for a tool the server does not offer")
+	}
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/protocol"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+)
+
+func TestUploadFileReachesTools(t *testing.T) {
+	c, s := inproc.Pair()
+	srv := server.NewServer("upload-test",
+		server.WithTransport(s),
+		server.WithUploads(server.UploadOptions{Dir: t.TempDir(), MaxSize: 1 << 20}),
+	).Tool("digest", "Digest an uploaded file", func(ctx *server.Context, args struct {
+		File string `json:"file"`
+	}) (string, error) {
+		upload, err := ctx.Upload(args.File)
+		if err != nil {
+			return "", err
+		}
+		file, err := upload.Open()
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+		digest := sha256.New()
+		n, err := io.Copy(digest, file)
+		return fmt.Sprintf("%s %d %s", upload.Name, n, hex.EncodeToString(digest.Sum(nil))), err
+	})
+	go srv.Run()
+
+	cl, err := client.NewClient("upload-client", client.WithInProcess(c), client.WithProtocolVersion("2025-03-26"))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	// Larger than one chunk, so it is sent in several
+	content := bytes.Repeat([]byte("0123456789abcdef"), 40<<10)
+	path := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+
+	upload, err := cl.UploadFile(context.Background(), path)
+	if err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	if !strings.HasPrefix(upload.URI, "upload://") || upload.Size != int64(len(content)) || upload.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Fatalf("Unexpected upload result: %+v", upload)
+	}
+
+	result, err := cl.CallTool("digest", map[string]interface{}{"file": upload.URI})
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	want := fmt.Sprintf("data.bin %d %s", len(content), hex.EncodeToString(sum[:]))
+	if !strings.Contains(fmt.Sprint(result), want) {
+		t.Errorf("Expected the tool to read the uploaded file, got %v", result)
+	}
+
+	// Files over the size limit are refused
+	_, err = cl.Upload(context.Background(), "big.bin", bytes.NewReader(make([]byte, 2<<20)))
+	if !protocol.IsErrorCode(err, protocol.InvalidParams) {
+		t.Errorf("Expected the size limit to refuse the upload, got %v", err)
+	}
+}
+
+func TestUploadWithoutServerSupport(t *testing.T) {
+	c, s := inproc.Pair()
+	srv := server.NewServer("upload-test", server.WithTransport(s))
+	go srv.Run()
+
+	cl, err := client.NewClient("upload-client", client.WithInProcess(c), client.WithProtocolVersion("2025-03-26"))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	_, err = cl.Upload(context.Background(), "notes.txt", strings.NewReader("notes"))
+	var capErr *client.CapabilityError
+	if !errors.As(err, &capErr) || capErr.Capability != "experimental.uploads" {
+		t.Errorf("Expected a missing uploads capability error, got %v", err)
+	}
+}
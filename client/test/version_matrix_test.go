@@ -505,43 +505,15 @@ func TestRootOperations(t *testing.T) {
 				// Clear history before the test
 				m.ClearHistory()
 
-				// First, set up mock response for add
-				addResponse := map[string]interface{}{
-					"jsonrpc": "2.0",
-					"id":      1,
-					"result":  map[string]interface{}{},
-				}
-				addJSON, _ := json.Marshal(addResponse)
-				m.QueueResponse(addJSON, nil)
-
 				err := c.AddRoot("/test/root", "Test Root")
 				if err != nil {
 					t.Fatalf("AddRoot failed: %v", err)
 				}
 
-				// Verify the add request
-				AssertMethodEquals(t, m.LastSentMessage, "roots/add")
-
-				// Clear history before the next operation
-				m.ClearHistory()
-
-				// Set up mock response for get roots
-				getRootsResponse := map[string]interface{}{
-					"jsonrpc": "2.0",
-					"id":      2,
-					"result": map[string]interface{}{
-						"roots": []interface{}{
-							map[string]interface{}{
-								"uri":  "/test/root",
-								"name": "Test Root",
-							},
-						},
-					},
-				}
-				getRootsJSON, _ := json.Marshal(getRootsResponse)
-				m.QueueResponse(getRootsJSON, nil)
+				// Roots are kept by the client, which tells the server they changed
+				AssertMethodEquals(t, m.LastSentMessage, "notifications/roots/list_changed")
 
-				// Get the roots
+				// Get the roots, which are answered locally
 				roots, err := c.GetRoots()
 				if err != nil {
 					t.Fatalf("GetRoots failed: %v", err)
@@ -556,29 +528,21 @@ func TestRootOperations(t *testing.T) {
 					t.Errorf("Root does not match expected: %+v", roots[0])
 				}
 
-				// Verify the list request
-				AssertMethodEquals(t, m.LastSentMessage, "roots/list")
-
 				// Clear history before the next operation
 				m.ClearHistory()
 
-				// Set up mock response for remove
-				removeResponse := map[string]interface{}{
-					"jsonrpc": "2.0",
-					"id":      3,
-					"result":  map[string]interface{}{},
-				}
-				removeJSON, _ := json.Marshal(removeResponse)
-				m.QueueResponse(removeJSON, nil)
-
 				// Remove the root
 				err = c.RemoveRoot("/test/root")
 				if err != nil {
 					t.Fatalf("RemoveRoot failed: %v", err)
 				}
 
-				// Verify the remove request
-				AssertMethodEquals(t, m.LastSentMessage, "roots/remove")
+				// Verify the change notification
+				AssertMethodEquals(t, m.LastSentMessage, "notifications/roots/list_changed")
+
+				if roots, _ := c.GetRoots(); len(roots) != 0 {
+					t.Errorf("Expected no roots after removal, got %+v", roots)
+				}
 			},
 		},
 	}
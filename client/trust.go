@@ -0,0 +1,358 @@
+// Package client provides the client-side implementation of the MCP protocol.
+package client
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TrustPolicy controls what happens when a server's fingerprint changes.
+type TrustPolicy int
+
+const (
+	// TrustPolicyWarn logs a warning and continues when a fingerprint changes.
+	TrustPolicyWarn TrustPolicy = iota
+
+	// TrustPolicyRefuse fails the connection when a fingerprint changes.
+	TrustPolicyRefuse
+)
+
+// ErrFingerprintMismatch is returned when a server's identity or tool schemas
+// differ from the fingerprint recorded on first use and the policy is TrustPolicyRefuse.
+var ErrFingerprintMismatch = errors.New("server fingerprint changed")
+
+// Fingerprint records what a server looked like the first time the client connected.
+type Fingerprint struct {
+	// Identity is a hash of the server's TLS certificate, or of its
+	// serverInfo when the transport does not use TLS. A serverInfo hash is
+	// advisory only: any server can claim the same name and version.
+	Identity string `json:"identity"`
+
+	// ToolsHash is a hash of the names, descriptions and input schemas of
+	// all tools the server exposes.
+	ToolsHash string `json:"toolsHash"`
+
+	// FirstSeen is when the fingerprint was recorded.
+	FirstSeen time.Time `json:"firstSeen"`
+}
+
+// TrustStore persists server fingerprints between connections.
+type TrustStore interface {
+	// Get returns the fingerprint recorded for a server, if any.
+	Get(server string) (Fingerprint, bool, error)
+
+	// Put records the fingerprint for a server, replacing any previous one.
+	// Call Put with a fresh fingerprint to accept a legitimate change.
+	Put(server string, fp Fingerprint) error
+}
+
+// MemoryTrustStore is a TrustStore that keeps fingerprints in memory.
+type MemoryTrustStore struct {
+	mu           sync.RWMutex
+	fingerprints map[string]Fingerprint
+}
+
+// NewMemoryTrustStore creates an empty in-memory trust store.
+func NewMemoryTrustStore() *MemoryTrustStore {
+	return &MemoryTrustStore{fingerprints: make(map[string]Fingerprint)}
+}
+
+// Get implements TrustStore.
+func (s *MemoryTrustStore) Get(server string) (Fingerprint, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fp, ok := s.fingerprints[server]
+	return fp, ok, nil
+}
+
+// Put implements TrustStore.
+func (s *MemoryTrustStore) Put(server string, fp Fingerprint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fingerprints[server] = fp
+	return nil
+}
+
+// FileTrustStore is a TrustStore backed by a JSON file, similar to ssh's known_hosts.
+type FileTrustStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileTrustStore creates a trust store that reads and writes the given file.
+// The file is created on the first Put.
+func NewFileTrustStore(path string) *FileTrustStore {
+	return &FileTrustStore{path: path}
+}
+
+// Get implements TrustStore.
+func (s *FileTrustStore) Get(server string) (Fingerprint, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fingerprints, err := s.load()
+	if err != nil {
+		return Fingerprint{}, false, err
+	}
+	fp, ok := fingerprints[server]
+	return fp, ok, nil
+}
+
+// Put implements TrustStore.
+func (s *FileTrustStore) Put(server string, fp Fingerprint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fingerprints, err := s.load()
+	if err != nil {
+		return err
+	}
+	fingerprints[server] = fp
+
+	data, err := json.MarshalIndent(fingerprints, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode trust store: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create trust store directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write trust store: %w", err)
+	}
+	return nil
+}
+
+// load reads all fingerprints from disk. A missing file is an empty store.
+func (s *FileTrustStore) load() (map[string]Fingerprint, error) {
+	fingerprints := make(map[string]Fingerprint)
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fingerprints, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust store: %w", err)
+	}
+	if err := json.Unmarshal(data, &fingerprints); err != nil {
+		return nil, fmt.Errorf("failed to parse trust store: %w", err)
+	}
+	return fingerprints, nil
+}
+
+// WithTrustOnFirstUse pins the server's identity and tool schemas on first connect.
+//
+// On later connects the client compares the server against the recorded
+// fingerprint. With TrustPolicyWarn a change is logged; with TrustPolicyRefuse
+// the connection fails with ErrFingerprintMismatch before the handshake is
+// acknowledged. Tools are checked again whenever the server reports they
+// changed, and under TrustPolicyRefuse a refused change disconnects the
+// client. This guards against a server silently swapping the tools a user
+// approved.
+//
+// The server's identity is pinned by its TLS certificate on HTTPS. Other
+// transports have no authenticated identity, so there the pin only covers
+// the tool schemas and the serverInfo the server reports about itself.
+//
+// Example:
+//
+//	store := client.NewFileTrustStore(filepath.Join(home, ".gomcp", "known_servers.json"))
+//	c, err := client.NewClient("https://tools.example.com/mcp",
+//	    client.WithTrustOnFirstUse(store, client.TrustPolicyRefuse),
+//	)
+func WithTrustOnFirstUse(store TrustStore, policy TrustPolicy) Option {
+	return func(c *clientImpl) {
+		c.trustStore = store
+		c.trustPolicy = policy
+	}
+}
+
+// peerCertificateTransport is implemented by transports that can report the
+// server's TLS certificate.
+type peerCertificateTransport interface {
+	PeerCertificate() *x509.Certificate
+}
+
+// verifyServerIdentity compares the server's identity against its recorded
+// fingerprint. It runs before the client sends notifications/initialized, so
+// a server that fails the check under TrustPolicyRefuse never sees the
+// handshake acknowledged. It must be called with c.mu held.
+func (c *clientImpl) verifyServerIdentity(initResult map[string]interface{}) error {
+	if c.trustStore == nil {
+		return nil
+	}
+
+	identity, err := c.serverIdentity(initResult)
+	if err != nil {
+		return fmt.Errorf("failed to fingerprint server: %w", err)
+	}
+	c.serverIdentityHash = identity
+
+	known, found, err := c.trustStore.Get(c.url)
+	if err != nil {
+		return fmt.Errorf("failed to read trust store: %w", err)
+	}
+	if !found || known.Identity == identity {
+		return nil
+	}
+	return c.fingerprintChanged(known, "identity")
+}
+
+// verifyToolSchemas compares the server's tools against its recorded
+// fingerprint, recording one if this is the first connection. Listing tools
+// needs an initialized session, so it runs after the handshake completes and
+// again whenever the server reports its tools changed. It must be called with
+// c.mu held.
+func (c *clientImpl) verifyToolSchemas() error {
+	if c.trustStore == nil {
+		return nil
+	}
+
+	toolsHash, err := c.toolSchemaHash()
+	if err != nil {
+		return fmt.Errorf("failed to fingerprint server: %w", err)
+	}
+
+	known, found, err := c.trustStore.Get(c.url)
+	if err != nil {
+		return fmt.Errorf("failed to read trust store: %w", err)
+	}
+
+	if !found {
+		c.logger.Info("trusting server on first use", "url", c.url)
+		return c.trustStore.Put(c.url, Fingerprint{
+			Identity:  c.serverIdentityHash,
+			ToolsHash: toolsHash,
+			FirstSeen: time.Now().UTC(),
+		})
+	}
+
+	if known.ToolsHash == toolsHash {
+		return nil
+	}
+	return c.fingerprintChanged(known, "tool schemas")
+}
+
+// fingerprintChanged applies the trust policy to a change from the recorded
+// fingerprint, returning ErrFingerprintMismatch under TrustPolicyRefuse.
+func (c *clientImpl) fingerprintChanged(known Fingerprint, changed string) error {
+	if c.trustPolicy == TrustPolicyRefuse {
+		return fmt.Errorf("%w: %s differ from the fingerprint recorded on %s",
+			ErrFingerprintMismatch, changed, known.FirstSeen.Format(time.RFC3339))
+	}
+
+	c.logger.Warn("server fingerprint changed since first use",
+		"url", c.url,
+		"changed", changed,
+		"firstSeen", known.FirstSeen)
+	return nil
+}
+
+// recheckToolSchemas verifies the server's tools again after it reports they
+// changed, disconnecting if the change is refused.
+func (c *clientImpl) recheckToolSchemas() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.initialized {
+		return
+	}
+
+	err := c.verifyToolSchemas()
+	if err == nil {
+		return
+	}
+	if !errors.Is(err, ErrFingerprintMismatch) {
+		c.logger.Warn("failed to verify changed tools", "url", c.url, "error", err)
+		return
+	}
+
+	c.logger.Error("disconnecting from server", "url", c.url, "error", err)
+	if err := c.transport.Disconnect(); err != nil {
+		c.logger.Warn("failed to disconnect from server", "error", err)
+	}
+	c.connected = false
+	c.initialized = false
+}
+
+// serverIdentity hashes the server's TLS certificate if available, and its
+// serverInfo otherwise. serverInfo is whatever the server says about itself,
+// so without TLS the identity only notices an honest change of server; it
+// does not authenticate one.
+func (c *clientImpl) serverIdentity(initResult map[string]interface{}) (string, error) {
+	if t, ok := c.transport.(peerCertificateTransport); ok {
+		if cert := t.PeerCertificate(); cert != nil {
+			sum := sha256.Sum256(cert.Raw)
+			return "cert:" + hex.EncodeToString(sum[:]), nil
+		}
+	}
+
+	data, err := json.Marshal(initResult["serverInfo"])
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "info:" + hex.EncodeToString(sum[:]), nil
+}
+
+// toolSchemaHash lists all tools and hashes their names, descriptions and schemas.
+// It must be called with c.mu held.
+func (c *clientImpl) toolSchemaHash() (string, error) {
+	type toolShape struct {
+		Name        string      `json:"name"`
+		Description string      `json:"description"`
+		InputSchema interface{} `json:"inputSchema"`
+	}
+
+	var tools []toolShape
+	cursor := ""
+	_, hasTools := c.serverCapabilities["tools"]
+	for hasTools {
+		var params map[string]interface{}
+		if cursor != "" {
+			params = map[string]interface{}{"cursor": cursor}
+		}
+
+		result, err := c.doRequest("tools/list", params, 0)
+		if err != nil {
+			return "", fmt.Errorf("failed to list tools: %w", err)
+		}
+
+		// Round-trip through JSON to decode the page into typed values
+		data, err := json.Marshal(result)
+		if err != nil {
+			return "", err
+		}
+		var page struct {
+			Tools      []toolShape `json:"tools"`
+			NextCursor string      `json:"nextCursor"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return "", fmt.Errorf("failed to parse tools list: %w", err)
+		}
+
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+
+	// Maps marshal with sorted keys, so the encoding is stable
+	data, err := json.Marshal(tools)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,252 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/localrivet/gomcp/util/schema"
+)
+
+// ToolError is returned by CallToolTyped when the tool ran but reported a
+// failure with isError set in its result.
+type ToolError struct {
+	// Tool is the name of the tool that failed.
+	Tool string
+
+	// Message is the text content of the result.
+	Message string
+
+	// Content is the result content as sent by the server.
+	Content []interface{}
+}
+
+// Error implements the error interface.
+func (e *ToolError) Error() string {
+	return fmt.Sprintf("tool %s failed: %s", e.Tool, e.Message)
+}
+
+// ArgumentError is returned by CallToolTyped when the input does not match
+// the input schema the server advertises for the tool. The tool is not
+// called.
+type ArgumentError struct {
+	// Tool is the name of the tool.
+	Tool string
+
+	// Problems describes each mismatch.
+	Problems []string
+}
+
+// Error implements the error interface.
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("invalid arguments for tool %s: %s", e.Tool, strings.Join(e.Problems, "; "))
+}
+
+// CallToolTyped calls a tool with a typed input and decodes its result into
+// Out.
+//
+// The input is marshaled to JSON and checked against the input schema the
+// server advertises for the tool before the call is made. The result is
+// decoded from its structuredContent if present, and otherwise from its
+// text content: as the text itself when Out is a string, or as JSON.
+//
+// A result with isError set is returned as a *ToolError, and input that
+// doesn't match the schema as an *ArgumentError.
+//
+// Example:
+//
+//	type WeatherIn struct {
+//	    City string `json:"city"`
+//	}
+//	type WeatherOut struct {
+//	    TempC float64 `json:"tempC"`
+//	}
+//
+//	out, err := client.CallToolTyped[WeatherIn, WeatherOut](ctx, c, "weather", WeatherIn{City: "Oslo"})
+func CallToolTyped[In, Out any](ctx context.Context, c Client, name string, in In, opts ...CallOption) (Out, error) {
+	var out Out
+	if err := ctx.Err(); err != nil {
+		return out, err
+	}
+
+	args, err := toArguments(in)
+	if err != nil {
+		return out, fmt.Errorf("failed to encode arguments for tool %s: %w", name, err)
+	}
+
+	inputSchema, err := lookupInputSchema(ctx, c, name)
+	if err != nil {
+		return out, err
+	}
+	if problems := validateArguments(inputSchema, args); len(problems) > 0 {
+		return out, &ArgumentError{Tool: name, Problems: problems}
+	}
+
+	result, err := c.CallTool(name, args, opts...)
+	if err != nil {
+		return out, err
+	}
+	if err := ctx.Err(); err != nil {
+		return out, err
+	}
+
+	if err := decodeToolResult(name, result, &out); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// toArguments converts a tool input into the arguments object of a call.
+func toArguments(in interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	if string(data) == "null" {
+		return map[string]interface{}{}, nil
+	}
+
+	var args map[string]interface{}
+	if err := json.Unmarshal(data, &args); err != nil {
+		return nil, fmt.Errorf("input must encode to a JSON object: %w", err)
+	}
+	return args, nil
+}
+
+// lookupInputSchema returns the input schema the server advertises for a
+// tool. The client's schemas are cached until the server reports that its
+// tool list changed.
+func lookupInputSchema(ctx context.Context, c Client, name string) (map[string]interface{}, error) {
+	impl, cached := c.(*clientImpl)
+	if cached {
+		impl.mu.RLock()
+		inputSchema, ok := impl.toolSchemas[name]
+		impl.mu.RUnlock()
+		if ok {
+			return inputSchema, nil
+		}
+	}
+
+	schemas := make(map[string]map[string]interface{})
+	for tool, err := range c.Tools(ctx) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tools: %w", err)
+		}
+		schemas[tool.Name] = tool.InputSchema
+	}
+
+	if cached {
+		impl.mu.Lock()
+		impl.toolSchemas = schemas
+		impl.mu.Unlock()
+	}
+
+	inputSchema, ok := schemas[name]
+	if !ok {
+		return nil, fmt.Errorf("tool %s is not offered by the server", name)
+	}
+	return inputSchema, nil
+}
+
+// validateArguments checks arguments against a tool's input schema and
+// returns the problems found, sorted for stable output.
+func validateArguments(inputSchema map[string]interface{}, args map[string]interface{}) []string {
+	if inputSchema == nil {
+		return nil
+	}
+
+	var problems []string
+	for _, field := range requiredFields(inputSchema["required"]) {
+		if _, ok := args[field]; !ok {
+			problems = append(problems, fmt.Sprintf("Field '%s' is required", field))
+		}
+	}
+
+	properties, _ := inputSchema["properties"].(map[string]interface{})
+	validator := schema.NewValidator()
+	for field, value := range args {
+		property, ok := properties[field].(map[string]interface{})
+		if !ok {
+			if inputSchema["additionalProperties"] == false {
+				problems = append(problems, fmt.Sprintf("Field '%s' is not accepted", field))
+			}
+			continue
+		}
+		schema.ValidateValueAgainstSchema(validator, field, value, property)
+	}
+	problems = append(problems, validator.Errors()...)
+
+	sort.Strings(problems)
+	return problems
+}
+
+// requiredFields reads the required list of a schema, which is []string
+// when built in Go and []interface{} when decoded from JSON.
+func requiredFields(value interface{}) []string {
+	switch fields := value.(type) {
+	case []string:
+		return fields
+	case []interface{}:
+		names := make([]string, 0, len(fields))
+		for _, field := range fields {
+			if name, ok := field.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// decodeToolResult decodes a tools/call result into out.
+func decodeToolResult(name string, result interface{}, out interface{}) error {
+	object, ok := result.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected result from tool %s: %T", name, result)
+	}
+
+	content, _ := object["content"].([]interface{})
+	var texts []string
+	for _, item := range content {
+		if entry, ok := item.(map[string]interface{}); ok && entry["type"] == "text" {
+			if text, ok := entry["text"].(string); ok {
+				texts = append(texts, text)
+			}
+		}
+	}
+	text := strings.Join(texts, "\n")
+
+	if isError, _ := object["isError"].(bool); isError {
+		return &ToolError{Tool: name, Message: text, Content: content}
+	}
+
+	if structured, ok := object["structuredContent"]; ok && structured != nil {
+		data, err := json.Marshal(structured)
+		if err != nil {
+			return fmt.Errorf("failed to decode result of tool %s: %w", name, err)
+		}
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode result of tool %s: %w", name, err)
+		}
+		return nil
+	}
+
+	if len(texts) == 0 {
+		return fmt.Errorf("tool %s returned no text content to decode", name)
+	}
+
+	// Plain text decodes as itself into strings
+	if target := reflect.ValueOf(out).Elem(); target.Kind() == reflect.String {
+		if err := json.Unmarshal([]byte(text), out); err != nil {
+			target.SetString(text)
+		}
+		return nil
+	}
+	if err := json.Unmarshal([]byte(text), out); err != nil {
+		return fmt.Errorf("failed to decode result of tool %s: %w", name, err)
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 // Package client provides the client-side implementation of the MCP protocol.
 package client
 
+import "encoding/json"
+
 // Root represents a filesystem root exposed to the MCP server.
 type Root struct {
 	URI      string                 `json:"uri"`
@@ -15,7 +17,51 @@ type ClientCapabilities struct {
 	Experimental map[string]interface{} `json:"experimental,omitempty"`
 }
 
+// MarshalJSON encodes the capabilities, keeping an enabled sampling
+// capability that has no settings as {} rather than omitting it.
+func (c ClientCapabilities) MarshalJSON() ([]byte, error) {
+	type capabilities ClientCapabilities
+	var sampling *map[string]interface{}
+	if c.Sampling != nil {
+		sampling = &c.Sampling
+	}
+	return json.Marshal(struct {
+		capabilities
+		Sampling *map[string]interface{} `json:"sampling,omitempty"`
+	}{capabilities(c), sampling})
+}
+
 // RootsCapability represents the client's roots capability.
 type RootsCapability struct {
 	ListChanged bool `json:"listChanged"`
 }
+
+// Tool describes a tool offered by the server.
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"inputSchema,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+}
+
+// Resource describes a resource offered by the server.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// Prompt describes a prompt offered by the server.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument describes an argument accepted by a prompt.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+)
+
+// UploadResult describes a file uploaded to the server.
+type UploadResult struct {
+	// URI is the upload:// URI to pass to the server's tools.
+	URI string `json:"uri"`
+
+	// Size is the size of the file in bytes.
+	Size int64 `json:"size"`
+
+	// SHA256 is the hex-encoded SHA-256 digest of the file.
+	SHA256 string `json:"sha256"`
+}
+
+// Upload sends the contents of r to the server as a file with the given
+// name, in chunks, and returns the upload:// URI its tools read it by. The
+// server must support uploads, which it enables with server.WithUploads.
+//
+// Example:
+//
+//	upload, err := c.Upload(ctx, "report.csv", strings.NewReader(csv))
+//	if err != nil {
+//	    return err
+//	}
+//	result, err := c.CallTool("analyze", map[string]interface{}{"file": upload.URI})
+func (c *clientImpl) Upload(ctx context.Context, name string, r io.Reader) (*UploadResult, error) {
+	return c.upload(ctx, name, "", -1, r)
+}
+
+// UploadFile uploads a local file to the server, keeping its base name.
+func (c *clientImpl) UploadFile(ctx context.Context, path string) (*UploadResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Base(path)
+	return c.upload(ctx, name, mime.TypeByExtension(filepath.Ext(name)), info.Size(), file)
+}
+
+// upload runs the uploads/create, uploads/append and uploads/complete
+// requests. A negative size means the size is unknown.
+func (c *clientImpl) upload(ctx context.Context, name, mimeType string, size int64, r io.Reader) (*UploadResult, error) {
+	params := map[string]interface{}{"name": name}
+	if mimeType != "" {
+		params["mimeType"] = mimeType
+	}
+	if size >= 0 {
+		params["size"] = size
+	}
+	var created struct {
+		UploadID  string `json:"uploadId"`
+		ChunkSize int    `json:"chunkSize"`
+	}
+	if err := c.uploadRequest("uploads/create", params, &created); err != nil {
+		return nil, err
+	}
+	if created.UploadID == "" || created.ChunkSize <= 0 {
+		return nil, fmt.Errorf("invalid uploads/create result")
+	}
+
+	digest := sha256.New()
+	chunk := make([]byte, created.ChunkSize)
+	var offset int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, readErr := io.ReadFull(r, chunk)
+		if n > 0 {
+			digest.Write(chunk[:n])
+			err := c.uploadRequest("uploads/append", map[string]interface{}{
+				"uploadId": created.UploadID,
+				"offset":   offset,
+				"data":     base64.StdEncoding.EncodeToString(chunk[:n]),
+			}, nil)
+			if err != nil {
+				return nil, err
+			}
+			offset += int64(n)
+		}
+		if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+			break
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read upload: %w", readErr)
+		}
+	}
+
+	var result UploadResult
+	err := c.uploadRequest("uploads/complete", map[string]interface{}{
+		"uploadId": created.UploadID,
+		"sha256":   hex.EncodeToString(digest.Sum(nil)),
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// uploadRequest sends an upload request, decoding its result into out if
+// it isn't nil.
+func (c *clientImpl) uploadRequest(method string, params map[string]interface{}, out interface{}) error {
+	result, err := c.sendRequest(method, params)
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("invalid %s result: %w", method, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid %s result: %w", method, err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Command gomcp provides development tools for MCP servers built with gomcp.
+//
+// Usage:
+//
+//	gomcp doctor --server ./my-server [--timeout 30s] [--json] [-- server args...]
+//
+// doctor launches the server over stdio, initializes it with every protocol
+// revision and simulated host, validates every tool schema, and prints a
+// pass/fail report with suggested fixes. It exits with status 1 if any check
+// fails.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/localrivet/gomcp/doctor"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+
+	switch args[0] {
+	case "doctor":
+		return runDoctor(args[1:], stdout, stderr)
+	case "help", "-h", "--help":
+		usage(stdout)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "gomcp: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: gomcp <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	fmt.Fprintln(w, "  doctor   check a server for protocol, host and schema compatibility problems")
+}
+
+func runDoctor(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	serverCmd := flags.String("server", "", "command that starts the server on stdio, e.g. \"./my-server --verbose\"")
+	timeout := flags.Duration("timeout", doctor.DefaultTimeout, "time limit for each check")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	command := strings.Fields(*serverCmd)
+	command = append(command, flags.Args()...)
+	if len(command) == 0 {
+		fmt.Fprintln(stderr, "gomcp doctor: --server is required")
+		flags.Usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report := doctor.Run(ctx, doctor.CommandLauncher(command[0], command[1:]...), doctor.Options{
+		Name:    strings.Join(command, " "),
+		Timeout: *timeout,
+	})
+
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			fmt.Fprintf(stderr, "gomcp doctor: %v\n", err)
+			return 2
+		}
+	} else if err := report.WriteText(stdout); err != nil {
+		fmt.Fprintf(stderr, "gomcp doctor: %v\n", err)
+		return 2
+	}
+
+	if !report.Passed() {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,407 @@
+// Package chart renders basic line, bar and pie charts as PNG image content
+// for tool results, so data tools can return visualizations without each
+// embedding a charting stack.
+//
+// Charts are drawn with gonum/plot from series the handler supplies. The
+// rendered image is capped in dimensions, data points and encoded size, as
+// it travels inline in the tool result.
+//
+// # Basic Usage
+//
+//	srv.Tool("sales_chart", "Chart monthly sales", func(ctx *server.Context, args struct{}) (interface{}, error) {
+//	    return chart.Result(chart.Chart{
+//	        Kind:   chart.Bar,
+//	        Title:  "Sales by month",
+//	        Labels: []string{"Jan", "Feb", "Mar"},
+//	        Series: []chart.Series{
+//	            {Name: "2024", Y: []float64{120, 98, 143}},
+//	            {Name: "2025", Y: []float64{131, 110, 160}},
+//	        },
+//	    })
+//	})
+package chart
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/plotutil"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+)
+
+// Kind is the kind of a chart.
+type Kind string
+
+// Chart kinds.
+const (
+	Line Kind = "line"
+	Bar  Kind = "bar"
+	Pie  Kind = "pie"
+)
+
+// Default and maximum image sizes.
+const (
+	DefaultWidth  = 800
+	DefaultHeight = 500
+
+	DefaultMaxWidth  = 1600
+	DefaultMaxHeight = 1200
+	DefaultMaxPoints = 10000
+	DefaultMaxBytes  = 1 << 20
+)
+
+// dpi is the resolution charts are drawn at, at which a width in pixels
+// is 3/4 of a width in points.
+const dpi = 96
+
+// ErrTooLarge is returned for charts exceeding the limits set with options.
+var ErrTooLarge = errors.New("chart too large")
+
+// Series is one set of values of a chart.
+type Series struct {
+	// Name labels the series in the legend.
+	Name string
+
+	// X holds the x values of a line chart's points. It defaults to the
+	// indexes of Y, which Labels then name.
+	X []float64
+
+	// Y holds the values: a line's y values, a bar per category, or a
+	// pie's slices.
+	Y []float64
+}
+
+// Chart describes a chart to render.
+type Chart struct {
+	Kind   Kind
+	Title  string
+	XLabel string
+	YLabel string
+
+	// Labels names the categories of a bar chart, the slices of a pie
+	// chart, or the x values of a line chart without X.
+	Labels []string
+
+	// Series holds the values. Pie charts use the first series only.
+	Series []Series
+
+	// Width and Height are the image size in pixels. They default to
+	// DefaultWidth and DefaultHeight.
+	Width  int
+	Height int
+}
+
+// options holds the limits a chart is rendered with.
+type options struct {
+	maxWidth  int
+	maxHeight int
+	maxPoints int
+	maxBytes  int
+}
+
+// Option sets a limit of Render.
+type Option func(*options)
+
+// WithMaxSize caps the image size in pixels. Larger charts are scaled down
+// to fit. The default is DefaultMaxWidth by DefaultMaxHeight.
+func WithMaxSize(width, height int) Option {
+	return func(o *options) {
+		o.maxWidth, o.maxHeight = width, height
+	}
+}
+
+// WithMaxPoints caps the number of values across all series, DefaultMaxPoints
+// by default. Charts with more are rejected with ErrTooLarge.
+func WithMaxPoints(n int) Option {
+	return func(o *options) {
+		o.maxPoints = n
+	}
+}
+
+// WithMaxBytes caps the size of the encoded PNG, DefaultMaxBytes by
+// default. Larger images are rejected with ErrTooLarge.
+func WithMaxBytes(n int) Option {
+	return func(o *options) {
+		o.maxBytes = n
+	}
+}
+
+// Render draws a chart as a PNG image.
+func Render(c Chart, opts ...Option) ([]byte, error) {
+	o := options{
+		maxWidth:  DefaultMaxWidth,
+		maxHeight: DefaultMaxHeight,
+		maxPoints: DefaultMaxPoints,
+		maxBytes:  DefaultMaxBytes,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := c.validate(o.maxPoints); err != nil {
+		return nil, err
+	}
+	width, height := c.size(o.maxWidth, o.maxHeight)
+
+	p := plot.New()
+	p.Title.Text = c.Title
+	p.X.Label.Text = c.XLabel
+	p.Y.Label.Text = c.YLabel
+	p.Legend.Top = true
+
+	var err error
+	switch c.Kind {
+	case Line:
+		err = c.addLines(p)
+	case Bar:
+		err = c.addBars(p, width)
+	case Pie:
+		c.addPie(p)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Kind != Pie && c.named() {
+		// Leave room above the data for the legend
+		p.Y.Max += (p.Y.Max - p.Y.Min) * 0.15
+	}
+
+	canvas := vgimg.PngCanvas{Canvas: vgimg.NewWith(
+		vgimg.UseWH(pixels(width), pixels(height)),
+		vgimg.UseDPI(dpi),
+	)}
+	p.Draw(draw.New(canvas))
+	var buf bytes.Buffer
+	if _, err := canvas.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("failed to encode chart: %w", err)
+	}
+	if buf.Len() > o.maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes encoded, the limit is %d", ErrTooLarge, buf.Len(), o.maxBytes)
+	}
+	return buf.Bytes(), nil
+}
+
+// Content renders a chart as an image content item of a tool result.
+func Content(c Chart, opts ...Option) (map[string]interface{}, error) {
+	png, err := Render(c, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"type":     "image",
+		"data":     base64.StdEncoding.EncodeToString(png),
+		"mimeType": "image/png",
+	}, nil
+}
+
+// Result renders a chart as a tool result holding its image, for returning
+// from a tool handler.
+func Result(c Chart, opts ...Option) (map[string]interface{}, error) {
+	content, err := Content(c, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"content": []interface{}{content},
+	}, nil
+}
+
+// validate checks a chart's kind and values.
+func (c Chart) validate(maxPoints int) error {
+	switch c.Kind {
+	case Line, Bar, Pie:
+	default:
+		return fmt.Errorf("unknown chart kind %q", c.Kind)
+	}
+	if len(c.Series) == 0 {
+		return errors.New("chart has no series")
+	}
+
+	points := 0
+	for _, s := range c.Series {
+		if len(s.Y) == 0 {
+			return fmt.Errorf("series %q has no values", s.Name)
+		}
+		if s.X != nil && len(s.X) != len(s.Y) {
+			return fmt.Errorf("series %q has %d x values for %d y values", s.Name, len(s.X), len(s.Y))
+		}
+		for _, v := range append(append([]float64(nil), s.X...), s.Y...) {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return fmt.Errorf("series %q has a value that is not finite", s.Name)
+			}
+		}
+		points += len(s.Y)
+	}
+	if points > maxPoints {
+		return fmt.Errorf("%w: %d values, the limit is %d", ErrTooLarge, points, maxPoints)
+	}
+
+	if c.Kind == Pie {
+		for _, v := range c.Series[0].Y {
+			if v < 0 {
+				return errors.New("pie chart has a negative value")
+			}
+		}
+	}
+	return nil
+}
+
+// named reports whether any series has a name, for the legend.
+func (c Chart) named() bool {
+	for _, s := range c.Series {
+		if s.Name != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// size returns the image size in pixels, scaled down to fit the maximum
+// while keeping its aspect ratio.
+func (c Chart) size(maxWidth, maxHeight int) (int, int) {
+	width, height := c.Width, c.Height
+	if width <= 0 {
+		width = DefaultWidth
+	}
+	if height <= 0 {
+		height = DefaultHeight
+	}
+	scale := math.Min(1, math.Min(float64(maxWidth)/float64(width), float64(maxHeight)/float64(height)))
+	return max(1, int(float64(width)*scale)), max(1, int(float64(height)*scale))
+}
+
+// addLines adds a line per series.
+func (c Chart) addLines(p *plot.Plot) error {
+	for i, s := range c.Series {
+		xys := make(plotter.XYs, len(s.Y))
+		for j, y := range s.Y {
+			xys[j].X, xys[j].Y = float64(j), y
+			if s.X != nil {
+				xys[j].X = s.X[j]
+			}
+		}
+		line, err := plotter.NewLine(xys)
+		if err != nil {
+			return err
+		}
+		line.Color = plotutil.Color(i)
+		line.Width = vg.Points(2)
+		p.Add(line)
+		if s.Name != "" {
+			p.Legend.Add(s.Name, line)
+		}
+	}
+	if len(c.Labels) > 0 && c.Series[0].X == nil {
+		p.NominalX(c.Labels...)
+	}
+	p.Add(plotter.NewGrid())
+	return nil
+}
+
+// addBars adds a group of bars per category, one per series.
+func (c Chart) addBars(p *plot.Plot, width int) error {
+	categories := 0
+	for _, s := range c.Series {
+		categories = max(categories, len(s.Y))
+	}
+	// Leave a bar's width between groups
+	barWidth := pixels(width) * 0.7 / vg.Length(categories*(len(c.Series)+1))
+
+	for i, s := range c.Series {
+		bars, err := plotter.NewBarChart(plotter.Values(s.Y), barWidth)
+		if err != nil {
+			return err
+		}
+		bars.Color = plotutil.Color(i)
+		bars.LineStyle.Width = 0
+		bars.Offset = barWidth * (vg.Length(i) - vg.Length(len(c.Series)-1)/2)
+		p.Add(bars)
+		if s.Name != "" {
+			p.Legend.Add(s.Name, bars)
+		}
+	}
+	if len(c.Labels) > 0 {
+		p.NominalX(c.Labels...)
+	}
+	// Keep the outer bars inside the axes
+	p.X.Min, p.X.Max = -0.5, float64(categories)-0.5
+	return nil
+}
+
+// addPie adds the first series as the slices of a pie.
+func (c Chart) addPie(p *plot.Plot) {
+	p.HideAxes()
+	slices := &pie{values: c.Series[0].Y}
+	p.Add(slices)
+	for i := range slices.values {
+		label := fmt.Sprintf("#%d", i+1)
+		if i < len(c.Labels) {
+			label = c.Labels[i]
+		}
+		p.Legend.Add(label, swatch{color: plotutil.Color(i)})
+	}
+	p.Legend.Left = true
+}
+
+// pie draws slices in the middle of the plot.
+type pie struct {
+	values []float64
+}
+
+// Plot implements plot.Plotter.
+func (pc *pie) Plot(c draw.Canvas, _ *plot.Plot) {
+	total := 0.0
+	for _, v := range pc.values {
+		total += v
+	}
+	if total == 0 {
+		return
+	}
+
+	center := c.Center()
+	radius := min(c.Max.X-c.Min.X, c.Max.Y-c.Min.Y) / 2 * 0.9
+	angle := math.Pi / 2 // Start at twelve o'clock and go clockwise
+	for i, v := range pc.values {
+		sweep := 2 * math.Pi * v / total
+		// A point per degree keeps the arc smooth
+		steps := max(2, int(sweep*180/math.Pi))
+		points := []vg.Point{center}
+		for step := 0; step <= steps; step++ {
+			a := angle - sweep*float64(step)/float64(steps)
+			points = append(points, vg.Point{
+				X: center.X + radius*vg.Length(math.Cos(a)),
+				Y: center.Y + radius*vg.Length(math.Sin(a)),
+			})
+		}
+		c.FillPolygon(plotutil.Color(i), points)
+		angle -= sweep
+	}
+}
+
+// swatch is a legend entry showing a slice's color.
+type swatch struct {
+	color color.Color
+}
+
+// Thumbnail implements plot.Thumbnailer.
+func (s swatch) Thumbnail(c *draw.Canvas) {
+	c.FillPolygon(s.color, []vg.Point{
+		{X: c.Min.X, Y: c.Min.Y},
+		{X: c.Min.X, Y: c.Max.Y},
+		{X: c.Max.X, Y: c.Max.Y},
+		{X: c.Max.X, Y: c.Min.Y},
+	})
+}
+
+// pixels converts pixels to the length they span at the drawing resolution.
+func pixels(n int) vg.Length {
+	return vg.Length(n) * vg.Inch / dpi
+}
@@ -0,0 +1,76 @@
+package chart_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image/png"
+	"testing"
+
+	"github.com/localrivet/gomcp/contrib/chart"
+)
+
+func TestRenderKinds(t *testing.T) {
+	series := []chart.Series{
+		{Name: "2024", Y: []float64{120, 98, 143}},
+		{Name: "2025", Y: []float64{131, 110, 160}},
+	}
+	for _, kind := range []chart.Kind{chart.Line, chart.Bar, chart.Pie} {
+		data, err := chart.Render(chart.Chart{
+			Kind:   kind,
+			Title:  "Sales",
+			Labels: []string{"Jan", "Feb", "Mar"},
+			Series: series,
+			Width:  400,
+			Height: 300,
+		})
+		if err != nil {
+			t.Fatalf("%s: render failed: %v", kind, err)
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: not a PNG: %v", kind, err)
+		}
+		if size := img.Bounds().Size(); size.X != 400 || size.Y != 300 {
+			t.Errorf("%s: expected a 400x300 image, got %v", kind, size)
+		}
+	}
+}
+
+func TestRenderLimits(t *testing.T) {
+	line := chart.Chart{Kind: chart.Line, Series: []chart.Series{{Y: []float64{1, 2, 3, 4}}}, Width: 4000, Height: 1000}
+
+	data, err := chart.Render(line, chart.WithMaxSize(800, 800))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	img, _ := png.Decode(bytes.NewReader(data))
+	if size := img.Bounds().Size(); size.X != 800 || size.Y != 200 {
+		t.Errorf("Expected the chart scaled to 800x200, got %v", size)
+	}
+
+	if _, err := chart.Render(line, chart.WithMaxPoints(3)); !errors.Is(err, chart.ErrTooLarge) {
+		t.Errorf("Expected too many points to be rejected, got %v", err)
+	}
+	if _, err := chart.Render(line, chart.WithMaxBytes(100)); !errors.Is(err, chart.ErrTooLarge) {
+		t.Errorf("Expected a large image to be rejected, got %v", err)
+	}
+	if _, err := chart.Render(chart.Chart{Kind: chart.Pie, Series: []chart.Series{{Y: []float64{1, -1}}}}); err == nil {
+		t.Error("Expected negative pie slices to be rejected")
+	}
+}
+
+func TestResult(t *testing.T) {
+	result, err := chart.Result(chart.Chart{Kind: chart.Bar, Series: []chart.Series{{Y: []float64{3, 5}}}})
+	if err != nil {
+		t.Fatalf("Result failed: %v", err)
+	}
+	item := result["content"].([]interface{})[0].(map[string]interface{})
+	if item["type"] != "image" || item["mimeType"] != "image/png" {
+		t.Errorf("Unexpected content item %v", item)
+	}
+	data, err := base64.StdEncoding.DecodeString(item["data"].(string))
+	if err != nil || !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		t.Errorf("Expected base64 PNG data, got error %v", err)
+	}
+}
@@ -0,0 +1,272 @@
+// Package extract serves the text of PDF, Word and Excel resources to
+// clients that ask for it, so LLM hosts get usable text instead of base64
+// binary they can't read.
+//
+// Its middleware watches resources/read results. When the request carries
+// an Accept-style "accept" param that admits text/plain but not a content
+// item's own type, items of a supported document type are replaced with a
+// text/plain item holding the extracted text:
+//
+//	{"uri": "file:///reports/q3.pdf", "accept": "text/plain"}
+//
+// Clients that send no accept param, or accept the document's type, get
+// the document as it is. Documents whose text can't be extracted are
+// returned unchanged, as a server would fall back to the representation it
+// has.
+//
+// # Basic Usage
+//
+//	srv := server.NewServer("documents")
+//	srv.Use(extract.New().Middleware())
+//	srv.Resource("file:///reports/q3.pdf", "Q3 report", server.WithFileContent("/srv/reports/q3.pdf"))
+package extract
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// MIME types of the documents extracted by default.
+const (
+	PDF  = "application/pdf"
+	DOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	XLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// DefaultMaxText is the default limit on the bytes of text served for a
+// document.
+const DefaultMaxText = 1 << 20
+
+// ErrUnsupported is returned for documents of a type without an extractor.
+var ErrUnsupported = errors.New("unsupported document type")
+
+// Func extracts the text of a document.
+type Func func(data []byte) (string, error)
+
+// Extractor extracts the text of documents by MIME type.
+type Extractor struct {
+	funcs   map[string]Func
+	maxText int
+}
+
+// Option configures an Extractor.
+type Option func(*Extractor)
+
+// WithFunc sets the extractor of a MIME type, replacing the default one of
+// PDF, DOCX and XLSX documents or adding another type.
+func WithFunc(mimeType string, fn Func) Option {
+	return func(e *Extractor) {
+		e.funcs[baseType(mimeType)] = fn
+	}
+}
+
+// WithMaxText limits the bytes of text served for a document,
+// DefaultMaxText by default. Longer text is cut at the limit and the item's
+// _meta marked truncated.
+func WithMaxText(n int) Option {
+	return func(e *Extractor) {
+		e.maxText = n
+	}
+}
+
+// New creates an Extractor for PDF, DOCX and XLSX documents.
+func New(options ...Option) *Extractor {
+	e := &Extractor{
+		funcs: map[string]Func{
+			PDF:  PDFText,
+			DOCX: DOCXText,
+			XLSX: XLSXText,
+		},
+		maxText: DefaultMaxText,
+	}
+	for _, option := range options {
+		option(e)
+	}
+	return e
+}
+
+// Supports reports whether documents of a MIME type can be extracted.
+func (e *Extractor) Supports(mimeType string) bool {
+	_, ok := e.funcs[baseType(mimeType)]
+	return ok
+}
+
+// Text extracts the text of a document of a MIME type.
+func (e *Extractor) Text(mimeType string, data []byte) (string, error) {
+	fn, ok := e.funcs[baseType(mimeType)]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupported, mimeType)
+	}
+	return fn(data)
+}
+
+// Middleware returns server middleware that replaces documents in
+// resources/read results with their text for requests accepting text/plain.
+func (e *Extractor) Middleware() server.Middleware {
+	return func(next server.RequestHandler) server.RequestHandler {
+		return func(ctx *server.Context) (interface{}, error) {
+			result, err := next(ctx)
+			if err != nil || ctx.Request == nil || ctx.Request.Method != "resources/read" {
+				return result, err
+			}
+
+			var params struct {
+				Accept string `json:"accept"`
+			}
+			if json.Unmarshal(ctx.Request.Params, &params) != nil || params.Accept == "" {
+				return result, nil
+			}
+			accept := parseAccept(params.Accept)
+			if !accept.allows("text/plain") {
+				return result, nil
+			}
+
+			response, ok := result.(map[string]interface{})
+			if !ok {
+				return result, nil
+			}
+			for _, item := range items(response["contents"]) {
+				mimeType, _ := item["mimeType"].(string)
+				if accept.allows(mimeType) || !e.Supports(mimeType) {
+					continue
+				}
+				if err := e.replace(item, mimeType); err != nil && ctx.Logger != nil {
+					ctx.Logger.Debug("failed to extract document text", "uri", item["uri"], "mimeType", mimeType, "error", err)
+				}
+			}
+			return result, nil
+		}
+	}
+}
+
+// replace turns a blob item into a text/plain item holding its text.
+func (e *Extractor) replace(item map[string]interface{}, mimeType string) error {
+	encoded, ok := item["blob"].(string)
+	if !ok {
+		return errors.New("item has no blob")
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return err
+	}
+	text, err := e.Text(mimeType, data)
+	if err != nil {
+		return err
+	}
+
+	meta, ok := item["_meta"].(map[string]interface{})
+	if !ok {
+		meta = make(map[string]interface{})
+	}
+	meta["extractedFrom"] = mimeType
+	if e.maxText > 0 && len(text) > e.maxText {
+		text = truncate(text, e.maxText)
+		meta["truncated"] = true
+	}
+	// Keep a digest added by the server valid for the new content
+	if _, ok := meta["sha256"]; ok {
+		sum := sha256.Sum256([]byte(text))
+		meta["sha256"] = hex.EncodeToString(sum[:])
+	}
+
+	delete(item, "blob")
+	item["mimeType"] = "text/plain; charset=utf-8"
+	item["text"] = text
+	item["_meta"] = meta
+	return nil
+}
+
+// acceptRanges are the media ranges of an accept param.
+type acceptRanges []string
+
+// parseAccept parses a comma-separated list of media ranges, as in an
+// HTTP Accept header. Ranges with q=0 are left out.
+func parseAccept(accept string) acceptRanges {
+	var ranges acceptRanges
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+			continue
+		}
+		ranges = append(ranges, mediaType)
+	}
+	return ranges
+}
+
+// allows reports whether a MIME type matches any of the ranges.
+func (a acceptRanges) allows(mimeType string) bool {
+	mimeType = baseType(mimeType)
+	major, _, _ := strings.Cut(mimeType, "/")
+	for _, r := range a {
+		if r == "*/*" || r == mimeType || r == major+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// baseType returns a MIME type without parameters, in lower case.
+func baseType(mimeType string) string {
+	base, _, _ := strings.Cut(mimeType, ";")
+	return strings.ToLower(strings.TrimSpace(base))
+}
+
+// items returns the content items of a contents array.
+func items(contents interface{}) []map[string]interface{} {
+	switch v := contents.(type) {
+	case []map[string]interface{}:
+		return v
+	case []interface{}:
+		items := make([]map[string]interface{}, 0, len(v))
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				items = append(items, m)
+			}
+		}
+		return items
+	}
+	return nil
+}
+
+// truncate cuts text to at most n bytes on a character boundary.
+func truncate(text string, n int) string {
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n]
+}
+
+// normalize tidies extracted text: line endings become \n, trailing spaces
+// are dropped and runs of blank lines collapse to one.
+func normalize(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var b bytes.Buffer
+	blank := 0
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank++
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(strings.Repeat("\n", min(blank, 1)+1))
+		}
+		blank = 0
+		b.WriteString(line)
+	}
+	return b.String()
+}
@@ -0,0 +1,162 @@
+package extract_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/contrib/extract"
+	"github.com/localrivet/gomcp/server"
+)
+
+// office builds an Office document from its parts.
+func office(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range parts {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func docx(t *testing.T) []byte {
+	return office(t, map[string]string{
+		"word/document.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Quarterly</w:t></w:r><w:r><w:t xml:space="preserve"> report</w:t></w:r></w:p>
+<w:p><w:r><w:t>Revenue</w:t><w:tab/><w:t>up</w:t></w:r></w:p>
+<w:tbl><w:tr><w:tc><w:p><w:r><w:t>Region</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Sales</w:t></w:r></w:p></w:tc></w:tr>
+<w:tr><w:tc><w:p><w:r><w:t>EMEA</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>42</w:t></w:r></w:p></w:tc></w:tr></w:tbl>
+</w:body></w:document>`,
+	})
+}
+
+func xlsx(t *testing.T) []byte {
+	return office(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Sales" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>Region</t></si><si><t>Sales</t></si><si><r><t>EM</t></r><r><t>EA</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="inlineStr"><is><t>Note</t></is></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>42.5</v></c><c r="C2" t="b"><v>1</v></c></row>
+</sheetData></worksheet>`,
+	})
+}
+
+// minimalPDF builds a one-page PDF showing text in a standard font.
+func minimalPDF(text string) []byte {
+	stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestDocuments(t *testing.T) {
+	text, err := extract.DOCXText(docx(t))
+	if err != nil {
+		t.Fatalf("DOCX: %v", err)
+	}
+	if want := "Quarterly report\nRevenue\tup\nRegion\tSales\nEMEA\t42"; text != want {
+		t.Errorf("DOCX: expected %q, got %q", want, text)
+	}
+
+	text, err = extract.XLSXText(xlsx(t))
+	if err != nil {
+		t.Fatalf("XLSX: %v", err)
+	}
+	if want := "# Sales\nRegion\tSales\t\tNote\nEMEA\t42.5\tTRUE"; text != want {
+		t.Errorf("XLSX: expected %q, got %q", want, text)
+	}
+
+	text, err = extract.PDFText(minimalPDF("Hello PDF"))
+	if err != nil {
+		t.Fatalf("PDF: %v", err)
+	}
+	if !strings.Contains(text, "Hello PDF") {
+		t.Errorf("PDF: expected the page text, got %q", text)
+	}
+
+	if _, err := extract.PDFText([]byte("not a pdf")); err == nil {
+		t.Error("Expected an error for a malformed PDF")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.docx")
+	if err := os.WriteFile(path, docx(t), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := server.NewServer("documents", server.WithContentDigests())
+	srv.Use(extract.New(extract.WithMaxText(10)).Middleware())
+	srv.Resource("file:///report.docx", "Report", server.WithFileContent(path, server.WithFileMimeType(extract.DOCX)))
+
+	read := func(params string) map[string]interface{} {
+		t.Helper()
+		response, err := server.HandleMessage(srv.GetServer(), []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":`+params+`}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded struct {
+			Result struct {
+				Contents []map[string]interface{} `json:"contents"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(response, &decoded); err != nil || len(decoded.Result.Contents) != 1 {
+			t.Fatalf("Unexpected response %s", response)
+		}
+		return decoded.Result.Contents[0]
+	}
+
+	if item := read(`{"uri":"file:///report.docx"}`); item["blob"] == nil {
+		t.Errorf("Expected the document without an accept param, got %v", item)
+	}
+	if item := read(`{"uri":"file:///report.docx","accept":"text/plain, ` + extract.DOCX + `"}`); item["blob"] == nil {
+		t.Errorf("Expected the document when its type is accepted, got %v", item)
+	}
+
+	item := read(`{"uri":"file:///report.docx","accept":"text/plain"}`)
+	meta, _ := item["_meta"].(map[string]interface{})
+	if item["text"] != "Quarterly " || item["blob"] != nil || !strings.HasPrefix(item["mimeType"].(string), "text/plain") {
+		t.Errorf("Expected truncated text, got %v", item)
+	}
+	if meta["truncated"] != true || meta["extractedFrom"] != extract.DOCX {
+		t.Errorf("Unexpected _meta %v", meta)
+	}
+	if digest, _ := meta["sha256"].(string); len(digest) != 64 {
+		t.Errorf("Expected the digest to be kept, got %v", meta)
+	}
+}
@@ -0,0 +1,276 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxPartSize limits the decompressed size of a part of an Office
+// document, so a small archive can't expand without bound.
+const maxPartSize = 64 << 20
+
+// DOCXText extracts the text of a Word document: a line per paragraph,
+// with table cells separated by tabs and a line per table row.
+func DOCXText(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("not a DOCX document: %w", err)
+	}
+	decoder, closer, err := openPart(archive, "word/document.xml")
+	if err != nil {
+		return "", err
+	}
+	defer closer.Close()
+
+	var b strings.Builder
+	inText, cells, pendingSpace := false, 0, false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse DOCX document: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte('\t')
+			case "br", "cr":
+				b.WriteByte('\n')
+			case "tc":
+				cells++
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				// Paragraphs in a table cell continue the row
+				if cells == 0 {
+					b.WriteByte('\n')
+				} else {
+					pendingSpace = true
+				}
+			case "tc":
+				cells--
+				pendingSpace = false
+				b.WriteByte('\t')
+			case "tr":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				if pendingSpace {
+					b.WriteByte(' ')
+					pendingSpace = false
+				}
+				b.Write(t)
+			}
+		}
+	}
+	return normalize(b.String()), nil
+}
+
+// XLSXText extracts the text of an Excel workbook: each sheet under a line
+// naming it, with a line per row and cells separated by tabs. Cells hold
+// their stored values, not number formats.
+func XLSXText(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("not an XLSX workbook: %w", err)
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodePart(archive, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	var relationships struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodePart(archive, "xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return "", err
+	}
+	targets := make(map[string]string)
+	for _, r := range relationships.Relationships {
+		if strings.HasPrefix(r.Target, "/") {
+			targets[r.ID] = strings.TrimPrefix(r.Target, "/")
+		} else {
+			targets[r.ID] = path.Join("xl", r.Target)
+		}
+	}
+
+	shared, err := sharedStrings(archive)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for _, sheet := range workbook.Sheets {
+		target, ok := targets[sheet.ID]
+		if !ok {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString("# " + sheet.Name + "\n")
+		if err := writeSheet(&b, archive, target, shared); err != nil {
+			return "", err
+		}
+	}
+	return normalize(b.String()), nil
+}
+
+// xlsxCell is a cell of a worksheet.
+type xlsxCell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline struct {
+		Text string   `xml:"t"`
+		Runs []string `xml:"r>t"`
+	} `xml:"is"`
+}
+
+// writeSheet writes the rows of a worksheet, streaming them so large
+// sheets aren't decoded whole.
+func writeSheet(b *strings.Builder, archive *zip.Reader, name string, shared []string) error {
+	decoder, closer, err := openPart(archive, name)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	column := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse worksheet %s: %w", name, err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			if end, ok := token.(xml.EndElement); ok && end.Name.Local == "row" {
+				b.WriteByte('\n')
+			}
+			continue
+		}
+		switch start.Name.Local {
+		case "row":
+			column = 0
+		case "c":
+			var cell xlsxCell
+			if err := decoder.DecodeElement(&cell, &start); err != nil {
+				return fmt.Errorf("failed to parse worksheet %s: %w", name, err)
+			}
+			// Cells may skip empty columns
+			target := max(columnIndex(cell.Ref), column)
+			tabs := target - column
+			if column > 0 {
+				tabs++
+			}
+			b.WriteString(strings.Repeat("\t", tabs))
+			column = target + 1
+			b.WriteString(strings.NewReplacer("\t", " ", "\n", " ").Replace(cellText(cell, shared)))
+		}
+	}
+}
+
+// cellText returns the value of a cell as text.
+func cellText(cell xlsxCell, shared []string) string {
+	switch cell.Type {
+	case "s":
+		i, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+		if err != nil || i < 0 || i >= len(shared) {
+			return ""
+		}
+		return shared[i]
+	case "inlineStr":
+		return cell.Inline.Text + strings.Join(cell.Inline.Runs, "")
+	case "b":
+		if cell.Value == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return cell.Value
+}
+
+// columnIndex returns the zero-based column of a cell reference such as
+// "C7", or -1 if it has none.
+func columnIndex(ref string) int {
+	column := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A') + 1
+	}
+	return column - 1
+}
+
+// sharedStrings reads the shared string table of a workbook, which may be
+// missing.
+func sharedStrings(archive *zip.Reader) ([]string, error) {
+	var table struct {
+		Items []struct {
+			Text string   `xml:"t"`
+			Runs []string `xml:"r>t"`
+		} `xml:"si"`
+	}
+	if err := decodePart(archive, "xl/sharedStrings.xml", &table); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	shared := make([]string, len(table.Items))
+	for i, item := range table.Items {
+		shared[i] = item.Text + strings.Join(item.Runs, "")
+	}
+	return shared, nil
+}
+
+// openPart returns a decoder reading a part of an Office document.
+func openPart(archive *zip.Reader, name string) (*xml.Decoder, io.Closer, error) {
+	part, err := archive.Open(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("document has no %s: %w", name, err)
+	}
+	return xml.NewDecoder(io.LimitReader(part, maxPartSize)), part, nil
+}
+
+// decodePart decodes a part of an Office document into v.
+func decodePart(archive *zip.Reader, name string, v interface{}) error {
+	decoder, closer, err := openPart(archive, name)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// PDFText extracts the text of a PDF document, page by page with a blank
+// line between pages. Scanned pages without a text layer have no text.
+func PDFText(data []byte) (text string, err error) {
+	// The parser panics on some malformed documents
+	defer func() {
+		if r := recover(); r != nil {
+			text, err = "", fmt.Errorf("failed to parse PDF document: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("not a PDF document: %w", err)
+	}
+
+	var b strings.Builder
+	fonts := make(map[string]*pdf.Font)
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		// Fonts are shared by pages, so their encodings are parsed once
+		for _, name := range page.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := page.Font(name)
+				fonts[name] = &font
+			}
+		}
+		pageText, err := page.GetPlainText(fonts)
+		if err != nil {
+			return "", fmt.Errorf("failed to extract the text of page %d: %w", i, err)
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(pageText)
+	}
+	return normalize(b.String()), nil
+}
@@ -0,0 +1,198 @@
+// Package markdown converts web pages to Markdown, so that tools and
+// resources hand LLMs compact, structured text instead of raw HTML.
+//
+// By default a page is reduced to its main content first, in the manner of
+// browser reading modes: navigation, headers, footers, sidebars, ads and
+// comment sections are dropped, and the element holding the article's
+// paragraphs is kept. Headings, lists, tables, code blocks, quotes and
+// emphasis are converted to their Markdown (GFM) forms.
+//
+// # Basic Usage
+//
+// In a fetch tool:
+//
+//	conv := markdown.New(markdown.WithLinks(markdown.LinksReference))
+//
+//	srv.Tool("fetch", "Fetch a web page as Markdown", func(ctx *server.Context, args FetchArgs) (string, error) {
+//	    resp, err := server.HTTPClient(ctx).Get(args.URL)
+//	    if err != nil {
+//	        return "", err
+//	    }
+//	    defer resp.Body.Close()
+//	    page, err := conv.ConvertResponse(resp)
+//	    if err != nil {
+//	        return "", err
+//	    }
+//	    return "# " + page.Title + "\n\n" + page.Markdown, nil
+//	})
+//
+// In a resource handler, with HTML from anywhere:
+//
+//	srv.Resource("docs://guide", "User guide", func(ctx *server.Context, args interface{}) (string, error) {
+//	    return markdown.Convert(renderGuide(), markdown.WithImages(markdown.ImagesAlt))
+//	})
+package markdown
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// LinkStyle is how links are written.
+type LinkStyle int
+
+const (
+	// LinksInline writes links as [text](url), the default.
+	LinksInline LinkStyle = iota
+
+	// LinksReference writes links as [text][n], with the URLs listed at
+	// the end, which keeps paragraphs readable on link-heavy pages.
+	LinksReference
+
+	// LinksText keeps only the text of links.
+	LinksText
+)
+
+// ImageStyle is how images are written.
+type ImageStyle int
+
+const (
+	// ImagesInline writes images as ![alt](url), the default. Images
+	// embedded as data URLs are written as their alt text.
+	ImagesInline ImageStyle = iota
+
+	// ImagesAlt writes images as their alt text, for models that can't
+	// fetch them.
+	ImagesAlt
+
+	// ImagesDrop leaves images out.
+	ImagesDrop
+)
+
+// Page is a converted web page.
+type Page struct {
+	// Title is the page's title, from its title element or else its first
+	// heading.
+	Title string
+
+	// Markdown is the page's content in Markdown.
+	Markdown string
+}
+
+// Converter converts HTML to Markdown.
+type Converter struct {
+	links    LinkStyle
+	images   ImageStyle
+	baseURL  *url.URL
+	fullPage bool
+}
+
+// Option configures a Converter.
+type Option func(*Converter)
+
+// WithLinks sets how links are written, LinksInline by default.
+func WithLinks(style LinkStyle) Option {
+	return func(c *Converter) {
+		c.links = style
+	}
+}
+
+// WithImages sets how images are written, ImagesInline by default.
+func WithImages(style ImageStyle) Option {
+	return func(c *Converter) {
+		c.images = style
+	}
+}
+
+// WithBaseURL resolves relative link and image URLs against the URL the
+// page came from. A base element in the page takes precedence.
+func WithBaseURL(base string) Option {
+	return func(c *Converter) {
+		if u, err := url.Parse(base); err == nil {
+			c.baseURL = u
+		}
+	}
+}
+
+// WithFullPage converts the whole body of pages instead of their main
+// content, for pages that aren't articles, such as indexes and forms.
+// Scripts, styles and hidden elements are still left out.
+func WithFullPage() Option {
+	return func(c *Converter) {
+		c.fullPage = true
+	}
+}
+
+// New creates a Converter.
+func New(options ...Option) *Converter {
+	c := &Converter{}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Convert converts an HTML page to Markdown.
+func Convert(page string, options ...Option) (string, error) {
+	converted, err := New(options...).Convert(strings.NewReader(page))
+	if err != nil {
+		return "", err
+	}
+	return converted.Markdown, nil
+}
+
+// Convert converts an HTML page read from r, which must be UTF-8.
+func (c *Converter) Convert(r io.Reader) (*Page, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	base := c.baseURL
+	if href := attr(find(doc, "base"), "href"); href != "" {
+		if u, err := url.Parse(href); err == nil {
+			if base != nil {
+				u = base.ResolveReference(u)
+			}
+			base = u
+		}
+	}
+
+	page := &Page{Title: collapse(textContent(find(doc, "title")))}
+	root := find(doc, "body")
+	if root == nil {
+		root = doc
+	}
+	prune(root, !c.fullPage)
+	if !c.fullPage {
+		root = mainContent(root)
+	}
+	if page.Title == "" {
+		page.Title = collapse(textContent(find(root, "h1")))
+	}
+
+	rd := &renderer{links: c.links, images: c.images, base: base, refs: make(map[string]int)}
+	page.Markdown = rd.document(root)
+	return page, nil
+}
+
+// ConvertResponse converts the HTML page of an HTTP response, decoding it
+// from the charset it declares and resolving relative URLs against the
+// URL it was fetched from.
+func (c *Converter) ConvertResponse(resp *http.Response) (*Page, error) {
+	body, err := charset.NewReader(resp.Body, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode page: %w", err)
+	}
+	conv := *c
+	if conv.baseURL == nil && resp.Request != nil {
+		conv.baseURL = resp.Request.URL
+	}
+	return conv.Convert(body)
+}
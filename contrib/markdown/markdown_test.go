@@ -0,0 +1,110 @@
+package markdown_test
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/contrib/markdown"
+)
+
+const article = `<!DOCTYPE html>
+<html><head><title>Release notes</title><style>body { color: red }</style></head>
+<body>
+<nav><a href="/">Home</a> <a href="/blog">Blog</a></nav>
+<div class="sidebar"><p>Subscribe to our newsletter for weekly updates, offers and more.</p></div>
+<div id="content">
+  <h1>Version 2.0</h1>
+  <p>This release adds <strong>streaming</strong>, <em>typed</em> results and a new
+  <a href="/docs/cli">command line</a>, with help from <a href="https://example.org">our users</a>.</p>
+  <h2>Upgrading</h2>
+  <ol>
+    <li>Update the module: <code>go get example.com/mod@v2</code></li>
+    <li>Rename calls:
+      <ul><li>Old<br>style</li><li>New_style</li></ul>
+    </li>
+  </ol>
+  <pre><code class="language-go">func main() {
+	fmt.Println("hi")
+}</code></pre>
+  <blockquote><p>Quoted text, with a comma, that goes on for a while.</p></blockquote>
+  <table>
+    <tr><th>Name</th><th>Limit</th></tr>
+    <tr><td>free | basic</td><td>10</td></tr>
+    <tr><td>pro</td></tr>
+  </table>
+  <p><img src="/img/chart.png" alt="Usage chart"> 1. Not a list, # not a heading, *not* emphasis.</p>
+  <script>track()</script>
+  <div hidden>Hidden text</div>
+</div>
+<footer><p>Copyright 2024 Example Corp, all rights reserved, and so on.</p></footer>
+</body></html>`
+
+func TestConvertArticle(t *testing.T) {
+	page, err := markdown.New(markdown.WithBaseURL("https://example.com/blog/v2")).Convert(strings.NewReader(article))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Title != "Release notes" {
+		t.Errorf("Expected the page title, got %q", page.Title)
+	}
+
+	want := "# Version 2.0\n\n" +
+		"This release adds **streaming**, *typed* results and a new [command line](https://example.com/docs/cli), with help from [our users](https://example.org).\n\n" +
+		"## Upgrading\n\n" +
+		"1. Update the module: `go get example.com/mod@v2`\n" +
+		"2. Rename calls:\n" +
+		"   - Old  \n" +
+		"     style\n" +
+		"   - New_style\n\n" +
+		"```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n\n" +
+		"> Quoted text, with a comma, that goes on for a while.\n\n" +
+		"| Name | Limit |\n| --- | --- |\n| free \\| basic | 10 |\n| pro |  |\n\n" +
+		"![Usage chart](https://example.com/img/chart.png) 1. Not a list, # not a heading, \\*not\\* emphasis."
+	if page.Markdown != want {
+		t.Errorf("Unexpected Markdown:\n%s\n\nwant:\n%s", page.Markdown, want)
+	}
+}
+
+func TestConvertOptions(t *testing.T) {
+	page := `<body><nav><a href="/">Home</a></nav><p>See <a href="a.html">the docs</a>, <a href="a.html">again</a>
+		and <a href="#top">top</a>. <img src="x.png" alt="X"> <img src="data:image/png;base64,AAAA" alt="inline"></p></body>`
+
+	for _, tc := range []struct {
+		name    string
+		options []markdown.Option
+		want    string
+	}{
+		{"reference links", []markdown.Option{markdown.WithLinks(markdown.LinksReference), markdown.WithImages(markdown.ImagesAlt)},
+			"See [the docs][1], [again][1] and top. X inline\n\n[1]: a.html"},
+		{"text links", []markdown.Option{markdown.WithLinks(markdown.LinksText), markdown.WithImages(markdown.ImagesDrop)},
+			"See the docs, again and top."},
+		{"full page", []markdown.Option{markdown.WithFullPage(), markdown.WithLinks(markdown.LinksText)},
+			"Home\n\nSee the docs, again and top. ![X](x.png) inline"},
+	} {
+		got, err := markdown.Convert(page, tc.options...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestConvertResponse(t *testing.T) {
+	resp := &http.Response{
+		Header:  http.Header{"Content-Type": {"text/html; charset=iso-8859-1"}},
+		Body:    io.NopCloser(strings.NewReader("<p>Caf\xe9 <a href=\"menu\">menu</a></p>")),
+		Request: &http.Request{URL: &url.URL{Scheme: "https", Host: "bistro.example", Path: "/fr/"}},
+	}
+	page, err := markdown.New().ConvertResponse(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Café [menu](https://bistro.example/fr/menu)"; page.Markdown != want {
+		t.Errorf("Expected %q, got %q", want, page.Markdown)
+	}
+}
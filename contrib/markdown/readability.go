@@ -0,0 +1,289 @@
+package markdown
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Elements that never hold readable content.
+var skipped = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Math: true, atom.Iframe: true, atom.Object: true, atom.Embed: true,
+	atom.Canvas: true, atom.Head: true, atom.Link: true, atom.Meta: true,
+	atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true,
+}
+
+// Elements that hold page furniture rather than the main content.
+var furniture = map[atom.Atom]bool{
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true, atom.Dialog: true,
+}
+
+// Roles of page furniture.
+var furnitureRoles = map[string]bool{
+	"navigation": true, "banner": true, "contentinfo": true, "complementary": true,
+	"search": true, "dialog": true, "alert": true, "menu": true, "menubar": true,
+}
+
+// Words of class names and IDs that mark boilerplate, and content.
+var (
+	negativeWords = map[string]bool{
+		"nav": true, "navbar": true, "navigation": true, "menu": true, "footer": true, "sidebar": true,
+		"comment": true, "comments": true, "share": true, "sharing": true, "social": true,
+		"sponsor": true, "sponsored": true, "ad": true, "ads": true, "advert": true, "advertisement": true,
+		"promo": true, "related": true, "recommended": true, "cookie": true, "cookies": true,
+		"consent": true, "banner": true, "popup": true, "modal": true, "newsletter": true,
+		"subscribe": true, "breadcrumb": true, "breadcrumbs": true, "masthead": true, "skip": true,
+	}
+	positiveWords = map[string]bool{
+		"article": true, "body": true, "content": true, "entry": true, "main": true,
+		"post": true, "text": true, "story": true, "blog": true, "prose": true,
+	}
+)
+
+// prune removes the elements of a tree that hold no readable content and,
+// with removeFurniture set, the page furniture around the main content.
+func prune(n *html.Node, removeFurniture bool) {
+	pruneIn(n, removeFurniture, false)
+}
+
+// pruneIn prunes a tree within an article or main element or not. The
+// header and footer of an article hold its title and byline, so they are
+// kept.
+func pruneIn(n *html.Node, removeFurniture, inArticle bool) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		switch {
+		case child.Type == html.CommentNode:
+			n.RemoveChild(child)
+		case child.Type != html.ElementNode:
+		case skipped[child.DataAtom] || hidden(child):
+			n.RemoveChild(child)
+		case removeFurniture && isFurniture(child, inArticle):
+			n.RemoveChild(child)
+		default:
+			pruneIn(child, removeFurniture, inArticle || child.DataAtom == atom.Article || child.DataAtom == atom.Main)
+		}
+		child = next
+	}
+}
+
+// hidden reports whether an element isn't shown to readers.
+func hidden(n *html.Node) bool {
+	if _, ok := attrValue(n, "hidden"); ok || attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	style := strings.ReplaceAll(strings.ToLower(attr(n, "style")), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+// isFurniture reports whether an element holds page furniture. Elements
+// containing an article or the main element are kept whatever their
+// names, as some sites wrap everything in a "nav-wrapper".
+func isFurniture(n *html.Node, inArticle bool) bool {
+	switch n.DataAtom {
+	case atom.Article, atom.Main, atom.Body, atom.Html:
+		return false
+	case atom.Header, atom.Footer:
+		if inArticle {
+			return false
+		}
+	}
+	boilerplate := furniture[n.DataAtom] || furnitureRoles[attr(n, "role")] || classWeight(n) < 0
+	return boilerplate && find(n, "article") == nil && find(n, "main") == nil
+}
+
+// classWeight scores an element by the words of its class names and ID.
+func classWeight(n *html.Node) int {
+	weight := 0
+	for _, name := range []string{attr(n, "class"), attr(n, "id")} {
+		words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+			return r == ' ' || r == '-' || r == '_' || r == '\t' || r == '\n'
+		})
+		for _, word := range words {
+			if negativeWords[word] {
+				weight -= 25
+			}
+			if positiveWords[word] {
+				weight += 25
+			}
+		}
+	}
+	return weight
+}
+
+// mainContent returns the element holding the main content of a page's
+// body: its single article or main element, or else the element holding
+// the most paragraph text, weighed down by its share of link text. The
+// body is returned when no element holds a good part of its text.
+func mainContent(body *html.Node) *html.Node {
+	if articles := findAll(body, "article"); len(articles) == 1 {
+		return articles[0]
+	}
+	if main := find(body, "main"); main != nil {
+		return main
+	}
+	if main := findFunc(body, func(n *html.Node) bool { return attr(n, "role") == "main" }); main != nil {
+		return main
+	}
+
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+	add := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = float64(classWeight(n))
+			candidates = append(candidates, n)
+		}
+		scores[n] += score
+	}
+	for _, p := range findAllFunc(body, isParagraph) {
+		text := collapse(textContent(p))
+		if len(text) < 25 {
+			continue
+		}
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+		add(p.Parent, score)
+		if p.Parent != nil {
+			add(p.Parent.Parent, score/2)
+		}
+	}
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, n := range candidates {
+		score := scores[n] * (1 - linkDensity(n))
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil {
+		return body
+	}
+
+	// Keep the body when the best element misses most of the text
+	bodyText, bestText := len(collapse(textContent(body))), len(collapse(textContent(best)))
+	if bestText < 500 && bestText*4 < bodyText {
+		return body
+	}
+	return best
+}
+
+// isParagraph reports whether an element is a paragraph of text: a p or
+// pre element, or a div holding only text and inline elements.
+func isParagraph(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.P, atom.Pre:
+		return true
+	case atom.Div:
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && isBlock(child) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// linkDensity returns the share of an element's text that is link text.
+func linkDensity(n *html.Node) float64 {
+	total := len(collapse(textContent(n)))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	for _, a := range findAll(n, "a") {
+		linked += len(collapse(textContent(a)))
+	}
+	return float64(linked) / float64(total)
+}
+
+// find returns the first element named tag in a tree, or nil.
+func find(n *html.Node, tag string) *html.Node {
+	return findFunc(n, func(n *html.Node) bool { return n.Data == tag })
+}
+
+// findFunc returns the first element of a tree matching match, or nil.
+func findFunc(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if n == nil {
+		return nil
+	}
+	if n.Type == html.ElementNode && match(n) {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findFunc(child, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// findAll returns the elements named tag in a tree.
+func findAll(n *html.Node, tag string) []*html.Node {
+	return findAllFunc(n, func(n *html.Node) bool { return n.Data == tag })
+}
+
+// findAllFunc returns the elements of a tree matching match.
+func findAllFunc(n *html.Node, match func(*html.Node) bool) []*html.Node {
+	var found []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && match(n) {
+			found = append(found, n)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return found
+}
+
+// attr returns the value of an attribute of an element, or "".
+func attr(n *html.Node, key string) string {
+	value, _ := attrValue(n, key)
+	return value
+}
+
+// attrValue returns the value of an attribute and whether it is set.
+func attrValue(n *html.Node, key string) (string, bool) {
+	if n == nil {
+		return "", false
+	}
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// textContent returns the text of a tree.
+func textContent(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// collapse replaces runs of whitespace with single spaces and trims the
+// ends.
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,518 @@
+package markdown
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Elements rendered as blocks of their own.
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true, atom.Body: true,
+	atom.Center: true, atom.Dd: true, atom.Details: true, atom.Dialog: true, atom.Div: true,
+	atom.Dl: true, atom.Dt: true, atom.Fieldset: true, atom.Figcaption: true, atom.Figure: true,
+	atom.Footer: true, atom.Form: true, atom.H1: true, atom.H2: true, atom.H3: true,
+	atom.H4: true, atom.H5: true, atom.H6: true, atom.Header: true, atom.Hgroup: true,
+	atom.Hr: true, atom.Html: true, atom.Li: true, atom.Main: true, atom.Nav: true,
+	atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true, atom.Summary: true,
+	atom.Table: true, atom.Ul: true,
+}
+
+// isBlock reports whether an element is rendered as a block.
+func isBlock(n *html.Node) bool {
+	return blockElements[n.DataAtom]
+}
+
+var (
+	// orderedMarker matches text that would start an ordered list item.
+	orderedMarker = regexp.MustCompile(`^(\d+)([.)])(\s|$)`)
+
+	// spaces matches runs of spaces left where inline elements meet.
+	spaces = regexp.MustCompile(` {2,}`)
+
+	// blankLines matches more than one blank line.
+	blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// renderer writes an HTML tree as Markdown.
+type renderer struct {
+	links  LinkStyle
+	images ImageStyle
+	base   *url.URL
+
+	// refs numbers the URLs of reference links, listed in refList
+	refs    map[string]int
+	refList []string
+}
+
+// document renders a tree, followed by the URLs of reference links.
+func (r *renderer) document(root *html.Node) string {
+	out := strings.Join(r.blocks(root), "\n\n")
+	if len(r.refList) > 0 {
+		var refs strings.Builder
+		for i, link := range r.refList {
+			fmt.Fprintf(&refs, "[%d]: %s\n", i+1, link)
+		}
+		out += "\n\n" + refs.String()
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(out, "\n\n"))
+}
+
+// blocks renders the children of an element as blocks, runs of inline
+// content becoming paragraphs.
+func (r *renderer) blocks(n *html.Node) []string {
+	var out []string
+	var run strings.Builder
+	flush := func() {
+		if p := paragraph(run.String()); p != "" {
+			out = append(out, p)
+		}
+		run.Reset()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && isBlock(child) {
+			flush()
+			if block := r.block(child); block != "" {
+				out = append(out, block)
+			}
+			continue
+		}
+		run.WriteString(r.inline(child))
+	}
+	flush()
+	return out
+}
+
+// block renders a block element.
+func (r *renderer) block(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := strings.ReplaceAll(paragraph(r.inlineChildren(n)), "  \n", " ")
+		if text == "" {
+			return ""
+		}
+		level := int(n.Data[1] - '0')
+		return strings.Repeat("#", level) + " " + strings.TrimPrefix(text, `\#`)
+	case atom.P:
+		return paragraph(r.inlineChildren(n))
+	case atom.Pre:
+		return r.pre(n)
+	case atom.Ul, atom.Ol:
+		return r.list(n)
+	case atom.Blockquote:
+		return prefixLines(strings.Join(r.blocks(n), "\n\n"), "> ", ">")
+	case atom.Table:
+		return r.table(n)
+	case atom.Hr:
+		return "---"
+	case atom.Dt:
+		if text := paragraph(r.inlineChildren(n)); text != "" {
+			return "**" + text + "**"
+		}
+		return ""
+	case atom.Figcaption:
+		if text := paragraph(r.inlineChildren(n)); text != "" {
+			return "*" + text + "*"
+		}
+		return ""
+	default:
+		return strings.Join(r.blocks(n), "\n\n")
+	}
+}
+
+// inlineChildren renders the children of an element as inline content.
+func (r *renderer) inlineChildren(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(r.inline(child))
+	}
+	return b.String()
+}
+
+// inline renders a node as inline content.
+func (r *renderer) inline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return escape(collapseSpaces(n.Data))
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return "\n"
+	case atom.A:
+		return r.link(n)
+	case atom.Img:
+		return r.image(n)
+	case atom.Code, atom.Kbd, atom.Samp, atom.Tt:
+		return codeSpan(textContent(n))
+	case atom.Strong, atom.B:
+		return wrap("**", r.inlineChildren(n))
+	case atom.Em, atom.I, atom.Cite, atom.Dfn:
+		return wrap("*", r.inlineChildren(n))
+	case atom.Del, atom.S, atom.Strike:
+		return wrap("~~", r.inlineChildren(n))
+	case atom.Q:
+		return wrap(`"`, r.inlineChildren(n))
+	}
+	if isBlock(n) {
+		// Blocks inside inline content, such as a div in a link, run on
+		return " " + r.inlineChildren(n) + " "
+	}
+	return r.inlineChildren(n)
+}
+
+// link renders a link in the renderer's link style.
+func (r *renderer) link(n *html.Node) string {
+	text := r.inlineChildren(n)
+	href := strings.TrimSpace(attr(n, "href"))
+	lower := strings.ToLower(href)
+	if r.links == LinksText || href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(lower, "javascript:") {
+		return text
+	}
+	trimmed := strings.TrimSpace(strings.ReplaceAll(text, "\n", " "))
+	if trimmed == "" {
+		return text
+	}
+	lead, trail := spaceAround(text)
+
+	link := r.resolve(href)
+	if r.links == LinksReference {
+		return lead + "[" + trimmed + "][" + strconv.Itoa(r.ref(link)) + "]" + trail
+	}
+	return lead + "[" + trimmed + "](" + link + ")" + trail
+}
+
+// ref returns the number of a reference link's URL.
+func (r *renderer) ref(link string) int {
+	if n, ok := r.refs[link]; ok {
+		return n
+	}
+	r.refList = append(r.refList, link)
+	r.refs[link] = len(r.refList)
+	return len(r.refList)
+}
+
+// image renders an image in the renderer's image style.
+func (r *renderer) image(n *html.Node) string {
+	alt := escape(collapse(attr(n, "alt")))
+	src := strings.TrimSpace(attr(n, "src"))
+	if src == "" || strings.HasPrefix(src, "data:") {
+		// Lazily loaded images keep their URL in a data attribute
+		if lazy := strings.TrimSpace(attr(n, "data-src")); lazy != "" {
+			src = lazy
+		}
+	}
+	switch {
+	case r.images == ImagesDrop:
+		return ""
+	case r.images == ImagesAlt, src == "", strings.HasPrefix(src, "data:"):
+		return alt
+	}
+	return "![" + alt + "](" + r.resolve(src) + ")"
+}
+
+// resolve returns a URL resolved against the page's base URL, escaped to
+// fit in a Markdown link.
+func (r *renderer) resolve(ref string) string {
+	u, err := url.Parse(ref)
+	if err == nil && r.base != nil {
+		u = r.base.ResolveReference(u)
+	}
+	if err == nil {
+		ref = u.String()
+	}
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(ref)
+}
+
+// pre renders preformatted text as a fenced code block.
+func (r *renderer) pre(n *html.Node) string {
+	code := strings.TrimRight(textContent(n), "\n")
+	if strings.TrimSpace(code) == "" {
+		return ""
+	}
+	language := codeLanguage(n)
+	if inner := find(n, "code"); inner != nil && language == "" {
+		language = codeLanguage(inner)
+	}
+	fence := strings.Repeat("`", max(3, longestRun(code, '`')+1))
+	return fence + language + "\n" + code + "\n" + fence
+}
+
+// codeLanguage returns the language named by an element's language-x or
+// lang-x class.
+func codeLanguage(n *html.Node) string {
+	for _, class := range strings.Fields(attr(n, "class")) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(class, prefix) {
+				return strings.TrimPrefix(class, prefix)
+			}
+		}
+	}
+	return ""
+}
+
+// list renders an ordered or unordered list, with the blocks of each item
+// indented under its marker.
+func (r *renderer) list(n *html.Node) string {
+	ordered := n.DataAtom == atom.Ol
+	number := 1
+	if start, err := strconv.Atoi(attr(n, "start")); err == nil {
+		number = start
+	}
+
+	var items []string
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		switch child.DataAtom {
+		case atom.Li:
+			content := strings.Join(r.blocks(child), "\n")
+			if content == "" {
+				continue
+			}
+			marker := "- "
+			if ordered {
+				marker = strconv.Itoa(number) + ". "
+				number++
+			}
+			items = append(items, marker+prefixLines(content, strings.Repeat(" ", len(marker)), "")[len(marker):])
+		case atom.Ul, atom.Ol:
+			// A list nested directly in a list belongs to the item before it
+			if nested := r.list(child); nested != "" {
+				items = append(items, prefixLines(nested, "  ", ""))
+			}
+		}
+	}
+	return strings.Join(items, "\n")
+}
+
+// table renders a table as a GFM table, its first row as the header.
+// Tables used for layout, with a single column or tables inside, are
+// rendered as their cells' blocks.
+func (r *renderer) table(n *html.Node) string {
+	var rows [][]*html.Node
+	columns := 0
+	for _, tr := range tableRows(n) {
+		var cells []*html.Node
+		for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+				cells = append(cells, cell)
+			}
+		}
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+			columns = max(columns, len(cells))
+		}
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+
+	nested := false
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		nested = nested || find(child, "table") != nil
+	}
+	if columns == 1 || nested {
+		var out []string
+		for _, cells := range rows {
+			for _, cell := range cells {
+				out = append(out, r.blocks(cell)...)
+			}
+		}
+		return strings.Join(out, "\n\n")
+	}
+
+	var lines []string
+	for i, cells := range rows {
+		texts := make([]string, columns)
+		for j, cell := range cells {
+			text := paragraph(r.inlineChildren(cell))
+			text = strings.ReplaceAll(text, "  \n", " ")
+			texts[j] = strings.ReplaceAll(text, "|", `\|`)
+		}
+		lines = append(lines, "| "+strings.Join(texts, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tableRows returns the rows of a table, leaving out those of tables
+// inside it.
+func tableRows(table *html.Node) []*html.Node {
+	var rows []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Tr:
+				rows = append(rows, child)
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(child)
+			}
+		}
+	}
+	walk(table)
+	return rows
+}
+
+// paragraph finishes a run of inline content: lines are trimmed, spaces
+// collapsed, text that would read as Markdown syntax at the start of a
+// line escaped, and line breaks kept as hard breaks. Blank lines, from
+// consecutive br elements, separate paragraphs.
+func paragraph(run string) string {
+	var groups []string
+	var lines []string
+	for _, line := range strings.Split(run, "\n") {
+		line = strings.TrimSpace(spaces.ReplaceAllString(line, " "))
+		if line == "" {
+			if len(lines) > 0 {
+				groups = append(groups, strings.Join(lines, "  \n"))
+				lines = nil
+			}
+			continue
+		}
+		lines = append(lines, escapeLineStart(line))
+	}
+	if len(lines) > 0 {
+		groups = append(groups, strings.Join(lines, "  \n"))
+	}
+	return strings.Join(groups, "\n\n")
+}
+
+// escapeLineStart escapes text at the start of a line that Markdown would
+// read as a heading, quote, list item or rule.
+func escapeLineStart(line string) string {
+	switch {
+	case strings.HasPrefix(line, "#"), strings.HasPrefix(line, ">"):
+		return `\` + line
+	case strings.HasPrefix(line, "- "), strings.HasPrefix(line, "+ "), line == "-", line == "+":
+		return `\` + line
+	case strings.Trim(line, "-") == "", strings.Trim(line, "=") == "":
+		return `\` + line
+	}
+	if m := orderedMarker.FindStringSubmatchIndex(line); m != nil {
+		return line[:m[3]] + `\` + line[m[3]:]
+	}
+	return line
+}
+
+// escape escapes the characters of text that Markdown would read as
+// syntax. Underscores inside words, as in snake_case names, are left as
+// they are.
+func escape(text string) string {
+	runes := []rune(text)
+	var b strings.Builder
+	for i, c := range runes {
+		switch c {
+		case '\\', '*', '`', '[', ']':
+			b.WriteRune('\\')
+		case '_':
+			if i == 0 || i == len(runes)-1 || !isWordRune(runes[i-1]) || !isWordRune(runes[i+1]) {
+				b.WriteRune('\\')
+			}
+		case '<':
+			if i+1 < len(runes) && (unicode.IsLetter(runes[i+1]) || strings.ContainsRune("/!?", runes[i+1])) {
+				b.WriteRune('\\')
+			}
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// isWordRune reports whether a rune is part of a word.
+func isWordRune(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// collapseSpaces replaces runs of whitespace with single spaces, keeping
+// a space at either end so words of neighbouring nodes stay apart.
+func collapseSpaces(text string) string {
+	collapsed := collapse(text)
+	if collapsed == "" {
+		if text != "" {
+			return " "
+		}
+		return ""
+	}
+	lead, trail := spaceAround(text)
+	return lead + collapsed + trail
+}
+
+// spaceAround returns a space for each end of text that has whitespace.
+func spaceAround(text string) (lead, trail string) {
+	if strings.TrimLeftFunc(text, unicode.IsSpace) != text {
+		lead = " "
+	}
+	if strings.TrimRightFunc(text, unicode.IsSpace) != text {
+		trail = " "
+	}
+	return lead, trail
+}
+
+// wrap puts emphasis markers around inline content, outside its spaces.
+func wrap(marker, content string) string {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return content
+	}
+	lead, trail := spaceAround(content)
+	return lead + marker + trimmed + marker + trail
+}
+
+// codeSpan renders text as a code span, delimited by more backticks than
+// it contains in a row.
+func codeSpan(text string) string {
+	text = collapse(text)
+	if text == "" {
+		return ""
+	}
+	fence := strings.Repeat("`", longestRun(text, '`')+1)
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+	return fence + text + fence
+}
+
+// longestRun returns the length of the longest run of c in text.
+func longestRun(text string, c rune) int {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == c {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+// prefixLines prefixes each line of text, using blankPrefix for blank
+// lines.
+func prefixLines(text, prefix, blankPrefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = blankPrefix
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
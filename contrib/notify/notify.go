@@ -0,0 +1,297 @@
+// Package notify posts selected server events to a Slack or Discord channel
+// through an incoming webhook.
+//
+// A Notifier turns server events into short chat messages: the server
+// starting, tool error spikes (the server's error-rate SLO signal), quota
+// rejections and panics recovered from request handlers. Messages are sent
+// in the background and rate limited, so a burst of failures produces a
+// handful of posts and a count of what was suppressed rather than flooding
+// the channel.
+//
+// # Basic Usage
+//
+//	alerts := notify.NewSlack(os.Getenv("SLACK_WEBHOOK_URL"), notify.WithName("billing-mcp"))
+//	defer alerts.Close()
+//
+//	srv := server.NewServer("billing",
+//	    alerts.Events(),
+//	    alerts.RecoverPanics(),
+//	    server.WithToolErrorSpike(20, 5*time.Minute),
+//	)
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/webhook"
+)
+
+// DefaultEvents are the event types posted unless WithEvents is used.
+var DefaultEvents = []string{
+	webhook.EventServerStarted,
+	webhook.EventToolErrorSpike,
+	webhook.EventQuotaExceeded,
+	EventPanic,
+}
+
+// EventPanic is the event type of panics recovered by RecoverPanics.
+const EventPanic = "server.panic"
+
+// format is the payload shape of a chat service.
+type format int
+
+const (
+	slackFormat format = iota
+	discordFormat
+)
+
+// Option configures a Notifier.
+type Option func(*Notifier)
+
+// WithHTTPClient sets the client used to post messages.
+func WithHTTPClient(client *http.Client) Option {
+	return func(n *Notifier) {
+		n.client = client
+	}
+}
+
+// WithName sets the label messages are prefixed with, to tell servers
+// posting to the same channel apart.
+func WithName(name string) Option {
+	return func(n *Notifier) {
+		n.name = name
+	}
+}
+
+// WithEvents sets the event types that are posted.
+func WithEvents(types ...string) Option {
+	return func(n *Notifier) {
+		n.events = make(map[string]bool, len(types))
+		for _, t := range types {
+			n.events[t] = true
+		}
+	}
+}
+
+// WithRateLimit allows at most count messages per period. Messages over the
+// limit are dropped, and the next message sent says how many were. The
+// default is 10 messages per minute.
+func WithRateLimit(count int, per time.Duration) Option {
+	return func(n *Notifier) {
+		if count > 0 {
+			n.limit = count
+		}
+		if per > 0 {
+			n.period = per
+		}
+	}
+}
+
+// WithLogger sets the logger delivery failures are reported to.
+func WithLogger(logger *slog.Logger) Option {
+	return func(n *Notifier) {
+		n.logger = logger
+	}
+}
+
+// Notifier posts messages to a Slack or Discord incoming webhook.
+type Notifier struct {
+	url    string
+	format format
+	client *http.Client
+	name   string
+	events map[string]bool
+	limit  int
+	period time.Duration
+	logger *slog.Logger
+
+	mu          sync.Mutex
+	windowStart time.Time
+	sent        int
+	suppressed  int
+	closed      bool
+
+	queue    chan string
+	finished chan struct{}
+}
+
+// NewSlack creates a notifier that posts to a Slack incoming webhook.
+func NewSlack(url string, options ...Option) *Notifier {
+	return newNotifier(url, slackFormat, options)
+}
+
+// NewDiscord creates a notifier that posts to a Discord webhook.
+func NewDiscord(url string, options ...Option) *Notifier {
+	return newNotifier(url, discordFormat, options)
+}
+
+func newNotifier(url string, f format, options []Option) *Notifier {
+	n := &Notifier{
+		url:      url,
+		format:   f,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		limit:    10,
+		period:   time.Minute,
+		logger:   slog.Default(),
+		queue:    make(chan string, 100),
+		finished: make(chan struct{}),
+	}
+	WithEvents(DefaultEvents...)(n)
+	for _, option := range options {
+		option(n)
+	}
+	go n.run()
+	return n
+}
+
+// Events returns a server option that posts the server's events of the
+// selected types.
+func (n *Notifier) Events() server.Option {
+	return server.WithEventHandler(n.HandleEvent)
+}
+
+// RecoverPanics returns a server option that recovers panics in request
+// handling, posts them with their stack trace and answers the request with
+// an internal error instead of crashing the server.
+func (n *Notifier) RecoverPanics() server.Option {
+	return server.WithMiddleware(func(next server.RequestHandler) server.RequestHandler {
+		return func(ctx *server.Context) (result interface{}, err error) {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					stack := debug.Stack()
+					if p, ok := recovered.(*server.HandlerPanic); ok {
+						stack = p.Stack
+					}
+					n.HandleEvent(EventPanic, map[string]interface{}{
+						"method": ctx.Request.Method,
+						"value":  fmt.Sprint(recovered),
+						"stack":  string(stack),
+					})
+					result = nil
+					err = &server.RPCError{Code: -32603, Message: "Internal error", Data: "request handler panicked"}
+				}
+			}()
+			return next(ctx)
+		}
+	})
+}
+
+// HandleEvent posts an event if its type is selected.
+func (n *Notifier) HandleEvent(eventType string, data map[string]interface{}) {
+	if !n.events[eventType] {
+		return
+	}
+	n.Notify(describe(eventType, data))
+}
+
+// Notify posts a message, subject to the rate limit. It does not block.
+func (n *Notifier) Notify(text string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(n.windowStart) >= n.period {
+		n.windowStart = now
+		n.sent = 0
+	}
+	if n.sent >= n.limit {
+		n.suppressed++
+		return
+	}
+
+	if n.suppressed > 0 {
+		text += fmt.Sprintf("\n(%d earlier messages suppressed by rate limit)", n.suppressed)
+	}
+	select {
+	case n.queue <- text:
+		n.sent++
+		n.suppressed = 0
+	default:
+		n.suppressed++
+	}
+}
+
+// Close stops accepting messages and waits for queued ones to be posted.
+func (n *Notifier) Close() {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return
+	}
+	n.closed = true
+	close(n.queue)
+	n.mu.Unlock()
+	<-n.finished
+}
+
+func (n *Notifier) run() {
+	defer close(n.finished)
+	for text := range n.queue {
+		if err := n.post(text); err != nil {
+			n.logger.Error("failed to post notification", "error", err)
+		}
+	}
+}
+
+// post sends one message in the chat service's payload format.
+func (n *Notifier) post(text string) error {
+	if n.name != "" {
+		text = "[" + n.name + "] " + text
+	}
+	payload := map[string]string{"text": text}
+	if n.format == discordFormat {
+		payload = map[string]string{"content": text}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// describe renders an event as a one-line message.
+func describe(eventType string, data map[string]interface{}) string {
+	switch eventType {
+	case webhook.EventServerStarted:
+		return fmt.Sprintf("Server %v started", data["name"])
+	case webhook.EventToolErrorSpike:
+		return fmt.Sprintf("Tool error spike: %v failed calls in %v (last failure in %v)", data["errors"], data["window"], data["tool"])
+	case webhook.EventQuotaExceeded:
+		return fmt.Sprintf("Quota %v exceeded: %v of %v used (tool %v)", data["quota"], data["used"], data["limit"], data["tool"])
+	case EventPanic:
+		return fmt.Sprintf("Recovered panic in %v: %v", data["method"], data["value"])
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fields := make([]string, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, fmt.Sprintf("%s=%v", key, data[key]))
+	}
+	return strings.TrimSpace(eventType + " " + strings.Join(fields, " "))
+}
@@ -0,0 +1,117 @@
+package notify_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/contrib/notify"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/webhook"
+)
+
+// channel records the messages posted to it.
+type channel struct {
+	mu       sync.Mutex
+	payloads []map[string]string
+}
+
+func (c *channel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var payload map[string]string
+	json.NewDecoder(r.Body).Decode(&payload)
+	c.mu.Lock()
+	c.payloads = append(c.payloads, payload)
+	c.mu.Unlock()
+}
+
+func (c *channel) messages(key string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var texts []string
+	for _, payload := range c.payloads {
+		texts = append(texts, payload[key])
+	}
+	return texts
+}
+
+func TestSlackAndDiscordPayloads(t *testing.T) {
+	slack, discord := &channel{}, &channel{}
+	slackServer, discordServer := httptest.NewServer(slack), httptest.NewServer(discord)
+	defer slackServer.Close()
+	defer discordServer.Close()
+
+	s := notify.NewSlack(slackServer.URL, notify.WithName("billing"))
+	s.HandleEvent(webhook.EventServerStarted, map[string]interface{}{"name": "billing"})
+	s.HandleEvent(webhook.EventAuditToolCall, map[string]interface{}{"tool": "charge"})
+	s.Close()
+
+	d := notify.NewDiscord(discordServer.URL, notify.WithEvents(webhook.EventAuditToolCall))
+	d.HandleEvent(webhook.EventServerStarted, nil)
+	d.HandleEvent(webhook.EventAuditToolCall, map[string]interface{}{"tool": "charge", "outcome": "ok"})
+	d.Close()
+
+	if got := slack.messages("text"); len(got) != 1 || got[0] != "[billing] Server billing started" {
+		t.Errorf("Expected only the startup message on Slack, got %q", got)
+	}
+	if got := discord.messages("content"); len(got) != 1 || got[0] != "audit.tool_call outcome=ok tool=charge" {
+		t.Errorf("Expected only the audit message on Discord, got %q", got)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	slack := &channel{}
+	endpoint := httptest.NewServer(slack)
+	defer endpoint.Close()
+
+	n := notify.NewSlack(endpoint.URL, notify.WithRateLimit(2, 50*time.Millisecond))
+	for i := 0; i < 5; i++ {
+		n.Notify("failure")
+	}
+	time.Sleep(60 * time.Millisecond)
+	n.Notify("recovered")
+	n.Close()
+
+	got := slack.messages("text")
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 messages within the rate limit, got %q", got)
+	}
+	if !strings.HasPrefix(got[2], "recovered") || !strings.Contains(got[2], "3 earlier messages suppressed") {
+		t.Errorf("Expected the next message to count the suppressed ones, got %q", got[2])
+	}
+}
+
+func TestRecoverPanics(t *testing.T) {
+	slack := &channel{}
+	endpoint := httptest.NewServer(slack)
+	defer endpoint.Close()
+
+	n := notify.NewSlack(endpoint.URL)
+	srv := server.NewServer("panic-test", n.Events(), n.RecoverPanics()).
+		Tool("explode", "Always panics", func(ctx *server.Context, args struct{}) (interface{}, error) {
+			panic("boom")
+		})
+
+	server.HandleMessage(srv.GetServer(), []byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1.0"}}}`))
+	response, err := server.HandleMessage(srv.GetServer(), []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"explode","arguments":{}}}`))
+	if err != nil {
+		t.Fatalf("HandleMessage failed: %v", err)
+	}
+	n.Close()
+
+	var decoded struct {
+		Error struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	json.Unmarshal(response, &decoded)
+	if decoded.Error.Code != -32603 {
+		t.Errorf("Expected an internal error response, got %s", response)
+	}
+	if got := slack.messages("text"); len(got) != 1 || got[0] != "Recovered panic in tools/call: boom" {
+		t.Errorf("Expected the panic to be posted, got %q", got)
+	}
+}
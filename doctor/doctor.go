@@ -0,0 +1,223 @@
+// Package doctor checks an MCP server for compatibility problems before it
+// reaches real hosts.
+//
+// Run launches the server once per check, so each check sees a fresh
+// session. It initializes with every protocol revision gomcp supports,
+// connects as each simulated host from client/hostsim, and lints every tool's
+// input schema with schema.Lint, catching problems such as array parameters
+// without items that strict hosts reject. The report lists each check with a
+// suggested fix for every failure.
+//
+// The gomcp command runs the same checks from the command line:
+//
+//	gomcp doctor --server ./my-server
+//
+// # Basic Usage
+//
+//	report := doctor.Run(ctx, doctor.CommandLauncher("./my-server"), doctor.Options{})
+//	report.WriteText(os.Stdout)
+//	if !report.Passed() {
+//		os.Exit(1)
+//	}
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/localrivet/gomcp/client/hostsim"
+	"github.com/localrivet/gomcp/mcp"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/util/schema"
+)
+
+// DefaultTimeout bounds each check when Options.Timeout is zero.
+const DefaultTimeout = 30 * time.Second
+
+// Launcher starts a fresh instance of the server under test and returns a
+// connection to it and a closer that stops it.
+type Launcher func(ctx context.Context) (hostsim.Conn, io.Closer, error)
+
+// CommandLauncher launches the server as a child process speaking stdio.
+func CommandLauncher(command string, args ...string) Launcher {
+	return func(ctx context.Context) (hostsim.Conn, io.Closer, error) {
+		process, err := hostsim.StartProcess(ctx, command, args...)
+		if err != nil {
+			return nil, nil, err
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			process.Timeout = time.Until(deadline)
+		}
+		return process, process, nil
+	}
+}
+
+// ServerLauncher runs the server in-process. newServer is called once per
+// check so each check starts with a fresh session.
+func ServerLauncher(newServer func() server.Server) Launcher {
+	return func(ctx context.Context) (hostsim.Conn, io.Closer, error) {
+		return hostsim.ServerConn(newServer()), closerFunc(func() error { return nil }), nil
+	}
+}
+
+// closerFunc adapts a function to io.Closer.
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+// Options configures a doctor run.
+type Options struct {
+	// Name identifies the server in the report.
+	Name string
+
+	// Timeout bounds each check. Zero means DefaultTimeout.
+	Timeout time.Duration
+
+	// Revisions are the protocol revisions to initialize with. Empty means
+	// every revision gomcp supports.
+	Revisions []string
+
+	// Profiles are the hosts to simulate. Empty means hostsim.Profiles().
+	Profiles []hostsim.Profile
+}
+
+// Run performs every check and returns the report.
+func Run(ctx context.Context, launch Launcher, opts Options) *Report {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if len(opts.Revisions) == 0 {
+		opts.Revisions = mcp.SupportedVersions
+	}
+	if len(opts.Profiles) == 0 {
+		opts.Profiles = hostsim.Profiles()
+	}
+
+	report := &Report{Server: opts.Name, Started: time.Now()}
+	defer func() { report.Duration = time.Since(report.Started) }()
+
+	// The launch check loads the tool list the schema checks run against
+	var tools []hostsim.Tool
+	launchCheck := runCheck(ctx, launch, opts.Timeout, "launch", baseProfile(mcp.SupportedVersions[0]), func(session *hostsim.Session) Check {
+		tools = session.Tools()
+		return Check{Status: StatusPass, Details: []string{fmt.Sprintf("%d tools", len(tools))}}
+	})
+	if launchCheck.Status == StatusFail {
+		launchCheck.Fixes = append(launchCheck.Fixes,
+			"make sure the command starts an MCP server that reads JSON-RPC from stdin and writes responses to stdout")
+	}
+	report.Checks = append(report.Checks, launchCheck)
+	if launchCheck.Status == StatusFail {
+		return report
+	}
+
+	for _, revision := range opts.Revisions {
+		report.Checks = append(report.Checks, checkRevision(ctx, launch, opts.Timeout, revision))
+	}
+	for _, profile := range opts.Profiles {
+		report.Checks = append(report.Checks, checkHost(ctx, launch, opts.Timeout, profile))
+	}
+	for _, tool := range tools {
+		report.Checks = append(report.Checks, checkToolSchema(tool))
+	}
+	return report
+}
+
+// baseProfile is a plain host that asks for revision and lists tools.
+func baseProfile(revision string) hostsim.Profile {
+	return hostsim.Profile{
+		Name:               "doctor",
+		ClientName:         "gomcp-doctor",
+		ClientVersion:      "1.0.0",
+		ProtocolVersion:    revision,
+		Capabilities:       map[string]interface{}{},
+		ListToolsOnConnect: true,
+	}
+}
+
+// runCheck launches the server, connects with profile and passes the session
+// to fn. Launch and handshake failures fail the check.
+func runCheck(ctx context.Context, launch Launcher, timeout time.Duration, name string, profile hostsim.Profile, fn func(*hostsim.Session) Check) Check {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, closer, err := launch(ctx)
+	if err != nil {
+		return Check{Name: name, Status: StatusFail, Details: []string{err.Error()}}
+	}
+	defer closer.Close()
+
+	session, err := hostsim.Dial(conn, profile)
+	if err != nil {
+		check := Check{Name: name, Status: StatusFail}
+		var schemaErr *hostsim.SchemaError
+		if errors.As(err, &schemaErr) {
+			check.Details = schemaErr.Problems
+			check.Fixes = []string{"fix the tool schemas reported by the schema checks below"}
+		} else {
+			check.Details = []string{err.Error()}
+		}
+		return check
+	}
+
+	check := fn(session)
+	check.Name = name
+	return check
+}
+
+// checkRevision initializes with a protocol revision and reports what the
+// server negotiated.
+func checkRevision(ctx context.Context, launch Launcher, timeout time.Duration, revision string) Check {
+	return runCheck(ctx, launch, timeout, "revision "+revision, baseProfile(revision), func(session *hostsim.Session) Check {
+		negotiated := session.ProtocolVersion()
+		if negotiated == revision {
+			return Check{Status: StatusPass}
+		}
+		return Check{
+			Status:  StatusWarn,
+			Details: []string{fmt.Sprintf("asked for %s, server negotiated %q", revision, negotiated)},
+			Fixes:   []string{"hosts that only speak " + revision + " will disconnect; upgrade the server's gomcp version"},
+		}
+	})
+}
+
+// checkHost connects as a simulated host and reproduces its quirks.
+func checkHost(ctx context.Context, launch Launcher, timeout time.Duration, profile hostsim.Profile) Check {
+	return runCheck(ctx, launch, timeout, "host "+profile.Name, profile, func(session *hostsim.Session) Check {
+		if profile.ReconnectEvery > 0 {
+			if err := session.Reconnect(); err != nil {
+				return Check{
+					Status:  StatusFail,
+					Details: []string{"repeated initialize failed: " + err.Error()},
+					Fixes: []string{fmt.Sprintf("%s re-initializes open connections; accept repeated initialize requests "+
+						"(server.LifecycleLenient, the default policy)", profile.Name)},
+				}
+			}
+		}
+		return Check{Status: StatusPass}
+	})
+}
+
+// checkToolSchema lints one tool's input schema. Warnings alone only warn.
+func checkToolSchema(tool hostsim.Tool) Check {
+	check := Check{Name: fmt.Sprintf("schema %s", tool.Name), Status: StatusPass}
+
+	seenFix := make(map[string]bool)
+	for _, issue := range schema.Lint(schema.Tool{Name: tool.Name, InputSchema: tool.InputSchema}) {
+		switch {
+		case issue.Severity == schema.SeverityError:
+			check.Status = StatusFail
+		case check.Status == StatusPass:
+			check.Status = StatusWarn
+		}
+		check.Details = append(check.Details, fmt.Sprintf("%s: %s: %s", issue.Severity, issue.Path, issue.Message))
+		if issue.Fix != "" && !seenFix[issue.Fix] {
+			seenFix[issue.Fix] = true
+			check.Fixes = append(check.Fixes, issue.Fix)
+		}
+	}
+	return check
+}
@@ -0,0 +1,98 @@
+package doctor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/client/hostsim"
+	"github.com/localrivet/gomcp/server"
+)
+
+type echoArgs struct {
+	Message string `json:"message"`
+}
+
+type tagArgs struct {
+	Tags []string `json:"tags"`
+}
+
+// untypedTagsSchema declares tags as an array without items, which strict
+// hosts reject.
+var untypedTagsSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"tags": map[string]interface{}{"type": "array"},
+	},
+}
+
+func echoServer(options ...server.Option) server.Server {
+	return server.NewServer("doctor-test", options...).
+		Tool("echo", "Echo a message", func(ctx *server.Context, args echoArgs) (interface{}, error) {
+			return args.Message, nil
+		})
+}
+
+func findCheck(t *testing.T, report *Report, name string) Check {
+	t.Helper()
+	for _, check := range report.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	t.Fatalf("No check named %q in %+v", name, report.Checks)
+	return Check{}
+}
+
+func TestRunHealthyServer(t *testing.T) {
+	report := Run(context.Background(), ServerLauncher(func() server.Server { return echoServer() }), Options{})
+
+	if !report.Passed() {
+		var b strings.Builder
+		report.WriteText(&b)
+		t.Fatalf("Expected a healthy server to pass:\n%s", b.String())
+	}
+	for _, name := range []string{"launch", "revision 2025-03-26", "host cursor", "schema echo"} {
+		if check := findCheck(t, report, name); check.Status != StatusPass {
+			t.Errorf("Expected %s to pass, got %+v", name, check)
+		}
+	}
+}
+
+func TestRunReportsSchemaAndHostProblems(t *testing.T) {
+	newServer := func() server.Server {
+		return echoServer(server.WithLifecyclePolicy(server.LifecycleReject)).
+			Tool("tag", "Tag things", func(ctx *server.Context, args tagArgs) (interface{}, error) {
+				return "ok", nil
+			}).
+			WithSchema("tag", untypedTagsSchema)
+	}
+	report := Run(context.Background(), ServerLauncher(newServer), Options{
+		Profiles: []hostsim.Profile{hostsim.ClaudeDesktop, hostsim.Cursor},
+	})
+
+	if report.Passed() {
+		t.Fatal("Expected the run to fail")
+	}
+
+	schemaCheck := findCheck(t, report, "schema tag")
+	if schemaCheck.Status != StatusFail || len(schemaCheck.Fixes) == 0 ||
+		!strings.Contains(strings.Join(schemaCheck.Details, "\n"), "inputSchema.properties.tags.items") {
+		t.Errorf("Expected the missing items to be reported with a fix, got %+v", schemaCheck)
+	}
+	if check := findCheck(t, report, "host claude-desktop"); check.Status != StatusFail {
+		t.Errorf("Expected the strict host to reject the tool list, got %+v", check)
+	}
+	if check := findCheck(t, report, "host cursor"); check.Status != StatusFail ||
+		!strings.Contains(strings.Join(check.Fixes, "\n"), "LifecycleLenient") {
+		t.Errorf("Expected the reconnecting host to fail with a lifecycle fix, got %+v", check)
+	}
+
+	var b strings.Builder
+	if err := report.WriteText(&b); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	if !strings.Contains(b.String(), "FAIL schema tag") || !strings.Contains(b.String(), "fix: ") {
+		t.Errorf("Unexpected text report:\n%s", b.String())
+	}
+}
@@ -0,0 +1,82 @@
+package doctor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	// StatusPass means the check found no problems.
+	StatusPass Status = "pass"
+
+	// StatusWarn means the server works but some hosts may misbehave.
+	StatusWarn Status = "warn"
+
+	// StatusFail means hosts will reject the server or the check could not run.
+	StatusFail Status = "fail"
+)
+
+// Check is the outcome of one check.
+type Check struct {
+	Name    string   `json:"name"`
+	Status  Status   `json:"status"`
+	Details []string `json:"details,omitempty"`
+	Fixes   []string `json:"fixes,omitempty"`
+}
+
+// Report is the outcome of a doctor run.
+type Report struct {
+	Server   string        `json:"server,omitempty"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration"`
+	Checks   []Check       `json:"checks"`
+}
+
+// Passed reports whether no check failed. Warnings do not fail a run.
+func (r *Report) Passed() bool {
+	return r.Count(StatusFail) == 0
+}
+
+// Count returns the number of checks with the given status.
+func (r *Report) Count(status Status) int {
+	n := 0
+	for _, check := range r.Checks {
+		if check.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteText writes a human-readable report: one line per check, followed by
+// the details and suggested fixes of checks that did not pass.
+func (r *Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+	if r.Server != "" {
+		fmt.Fprintf(&b, "gomcp doctor: %s\n", r.Server)
+	}
+
+	for _, check := range r.Checks {
+		fmt.Fprintf(&b, "  %s %s\n", strings.ToUpper(string(check.Status)), check.Name)
+		if check.Status == StatusPass {
+			continue
+		}
+		for _, detail := range check.Details {
+			fmt.Fprintf(&b, "      %s\n", detail)
+		}
+		for _, fix := range check.Fixes {
+			fmt.Fprintf(&b, "      fix: %s\n", fix)
+		}
+	}
+
+	fmt.Fprintf(&b, "%d passed, %d warnings, %d failed in %s\n",
+		r.Count(StatusPass), r.Count(StatusWarn), r.Count(StatusFail), r.Duration.Round(time.Millisecond))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
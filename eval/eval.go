@@ -0,0 +1,219 @@
+// Package eval runs scripted tool-call scenarios against an MCP server and
+// reports which ones regressed.
+//
+// A Suite lists scenarios: a tool call, the output it should produce and how
+// long it may take. Suites can be written in Go or loaded from YAML or JSON
+// files, and run either in-process against a server.Server or over the wire
+// through a client.Client.
+//
+// # Basic Usage
+//
+//	suite, err := eval.LoadSuite(os.DirFS("evals"), "weather.yaml")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	report := eval.Run(ctx, eval.ServerTarget(srv), suite)
+//	report.WriteText(os.Stdout)
+//	if !report.Passed() {
+//		os.Exit(1)
+//	}
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Suite is a named set of scenarios.
+type Suite struct {
+	Name      string     `json:"name" yaml:"name"`
+	Scenarios []Scenario `json:"scenarios" yaml:"scenarios"`
+}
+
+// Scenario is a single scripted tool call and its expected outcome.
+type Scenario struct {
+	// Name identifies the scenario in reports.
+	Name string `json:"name" yaml:"name"`
+
+	// Tool is the tool to call.
+	Tool string `json:"tool" yaml:"tool"`
+
+	// Arguments are passed to the tool.
+	Arguments map[string]interface{} `json:"arguments,omitempty" yaml:"arguments,omitempty"`
+
+	// Expect describes the expected output.
+	Expect Expectation `json:"expect" yaml:"expect"`
+
+	// LatencyBudget fails the scenario if the call takes longer. Zero means no budget.
+	LatencyBudget time.Duration `json:"latencyBudget,omitempty" yaml:"latencyBudget,omitempty"`
+}
+
+// Expectation describes the output a scenario should produce. Every field
+// that is set must hold for the scenario to pass.
+type Expectation struct {
+	// Text must equal the tool's text output exactly.
+	Text *string `json:"text,omitempty" yaml:"text,omitempty"`
+
+	// Contains lists substrings the text output must contain.
+	Contains []string `json:"contains,omitempty" yaml:"contains,omitempty"`
+
+	// Matches is a regular expression the text output must match.
+	Matches string `json:"matches,omitempty" yaml:"matches,omitempty"`
+
+	// JSON must equal the text output parsed as JSON. Numbers are compared
+	// within Tolerance.
+	JSON interface{} `json:"json,omitempty" yaml:"json,omitempty"`
+
+	// Tolerance is the absolute difference allowed between expected and
+	// actual numbers in JSON.
+	Tolerance float64 `json:"tolerance,omitempty" yaml:"tolerance,omitempty"`
+
+	// IsError requires the result's isError flag to have this value.
+	// When nil, any result that is not an error is expected.
+	IsError *bool `json:"isError,omitempty" yaml:"isError,omitempty"`
+}
+
+// Run executes every scenario in the suite, in order, and returns a report.
+// A cancelled context stops the run; remaining scenarios are reported as failed.
+func Run(ctx context.Context, target Target, suite Suite) *Report {
+	report := &Report{Suite: suite.Name, Started: time.Now()}
+
+	for _, scenario := range suite.Scenarios {
+		report.Results = append(report.Results, runScenario(ctx, target, scenario))
+	}
+
+	report.Duration = time.Since(report.Started)
+	return report
+}
+
+// runScenario executes one scenario and checks its expectations.
+func runScenario(ctx context.Context, target Target, scenario Scenario) ScenarioResult {
+	result := ScenarioResult{Name: scenario.Name, Tool: scenario.Tool}
+	if result.Name == "" {
+		result.Name = scenario.Tool
+	}
+
+	if err := ctx.Err(); err != nil {
+		result.Failures = append(result.Failures, fmt.Sprintf("not run: %v", err))
+		return result
+	}
+
+	start := time.Now()
+	output, err := target.CallTool(ctx, scenario.Tool, scenario.Arguments)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Failures = append(result.Failures, fmt.Sprintf("call failed: %v", err))
+		return result
+	}
+	result.Output = output.Text
+
+	if scenario.LatencyBudget > 0 && result.Latency > scenario.LatencyBudget {
+		result.Failures = append(result.Failures,
+			fmt.Sprintf("latency %s exceeds budget %s", result.Latency.Round(time.Microsecond), scenario.LatencyBudget))
+	}
+
+	result.Failures = append(result.Failures, scenario.Expect.check(output)...)
+	result.Passed = len(result.Failures) == 0
+	return result
+}
+
+// check returns a description of each expectation the output violates.
+func (e Expectation) check(output Output) []string {
+	var failures []string
+
+	wantError := e.IsError != nil && *e.IsError
+	if output.IsError != wantError {
+		failures = append(failures, fmt.Sprintf("expected isError=%t, got %t", wantError, output.IsError))
+	}
+
+	if e.Text != nil && output.Text != *e.Text {
+		failures = append(failures, fmt.Sprintf("expected text %q, got %q", *e.Text, output.Text))
+	}
+
+	for _, substring := range e.Contains {
+		if !strings.Contains(output.Text, substring) {
+			failures = append(failures, fmt.Sprintf("expected output to contain %q", substring))
+		}
+	}
+
+	if e.Matches != "" {
+		re, err := regexp.Compile(e.Matches)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("invalid pattern %q: %v", e.Matches, err))
+		} else if !re.MatchString(output.Text) {
+			failures = append(failures, fmt.Sprintf("expected output to match %q", e.Matches))
+		}
+	}
+
+	if e.JSON != nil {
+		var actual interface{}
+		if err := json.Unmarshal([]byte(output.Text), &actual); err != nil {
+			failures = append(failures, fmt.Sprintf("expected JSON output: %v", err))
+		} else if path, ok := jsonEqual(normalizeJSON(e.JSON), actual, e.Tolerance, "$"); !ok {
+			failures = append(failures, fmt.Sprintf("JSON output differs at %s", path))
+		}
+	}
+
+	return failures
+}
+
+// normalizeJSON converts a value decoded from YAML or built in Go into the
+// shapes encoding/json produces, so it can be compared with parsed output.
+func normalizeJSON(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return value
+	}
+	return normalized
+}
+
+// jsonEqual compares decoded JSON values, allowing numbers to differ by
+// tolerance. It returns the path of the first difference.
+func jsonEqual(expected, actual interface{}, tolerance float64, path string) (string, bool) {
+	switch want := expected.(type) {
+	case float64:
+		got, ok := actual.(float64)
+		if !ok || math.Abs(want-got) > tolerance {
+			return path, false
+		}
+		return "", true
+	case map[string]interface{}:
+		got, ok := actual.(map[string]interface{})
+		if !ok || len(got) != len(want) {
+			return path, false
+		}
+		for key, value := range want {
+			if p, ok := jsonEqual(value, got[key], tolerance, path+"."+key); !ok {
+				return p, false
+			}
+		}
+		return "", true
+	case []interface{}:
+		got, ok := actual.([]interface{})
+		if !ok || len(got) != len(want) {
+			return path, false
+		}
+		for i := range want {
+			if p, ok := jsonEqual(want[i], got[i], tolerance, fmt.Sprintf("%s[%d]", path, i)); !ok {
+				return p, false
+			}
+		}
+		return "", true
+	default:
+		if !reflect.DeepEqual(expected, actual) {
+			return path, false
+		}
+		return "", true
+	}
+}
@@ -0,0 +1,114 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func newEvalServer() server.Server {
+	return server.NewServer("eval-test").
+		Tool("add", "Add two numbers", func(ctx *server.Context, args struct {
+			A float64 `json:"a"`
+			B float64 `json:"b"`
+		}) (map[string]interface{}, error) {
+			return map[string]interface{}{"sum": args.A + args.B}, nil
+		}).
+		Tool("greet", "Greet someone", func(ctx *server.Context, args struct {
+			Name string `json:"name"`
+		}) (string, error) {
+			return "Hello, " + args.Name + "!", nil
+		}).
+		Tool("slow", "Sleep briefly", func(ctx *server.Context, args interface{}) (string, error) {
+			time.Sleep(20 * time.Millisecond)
+			return "done", nil
+		})
+}
+
+func TestRunSuite(t *testing.T) {
+	greeting := "Hello, Ada!"
+	suite := Suite{
+		Name: "basics",
+		Scenarios: []Scenario{
+			{Name: "exact text", Tool: "greet", Arguments: map[string]interface{}{"name": "Ada"}, Expect: Expectation{Text: &greeting}},
+			{Name: "contains", Tool: "greet", Arguments: map[string]interface{}{"name": "Bob"}, Expect: Expectation{Contains: []string{"Bob"}, Matches: `^Hello, \w+!$`}},
+			{Name: "tolerance", Tool: "add", Arguments: map[string]interface{}{"a": 0.1, "b": 0.2}, Expect: Expectation{JSON: map[string]interface{}{"sum": 0.3}, Tolerance: 1e-9}},
+			{Name: "budget", Tool: "slow", LatencyBudget: time.Millisecond},
+			{Name: "regression", Tool: "greet", Arguments: map[string]interface{}{"name": "Eve"}, Expect: Expectation{Contains: []string{"Goodbye"}}},
+		},
+	}
+
+	report := Run(context.Background(), ServerTarget(newEvalServer()), suite)
+
+	want := map[string]bool{"exact text": true, "contains": true, "tolerance": true, "budget": false, "regression": false}
+	for _, result := range report.Results {
+		if result.Passed != want[result.Name] {
+			t.Errorf("Scenario %q: expected passed=%t, got %t (%v)", result.Name, want[result.Name], result.Passed, result.Failures)
+		}
+	}
+	if report.Passed() || report.FailedCount() != 2 {
+		t.Errorf("Expected 2 failures, got %d", report.FailedCount())
+	}
+
+	var out bytes.Buffer
+	if err := report.WriteText(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "3/5 passed") || !strings.Contains(out.String(), "FAIL budget") {
+		t.Errorf("Unexpected report:\n%s", out.String())
+	}
+}
+
+func TestLoadSuite(t *testing.T) {
+	fsys := fstest.MapFS{
+		"greet.yaml": {Data: []byte(`
+scenarios:
+  - name: greets by name
+    tool: greet
+    arguments:
+      name: Ada
+    expect:
+      text: "Hello, Ada!"
+    latencyBudget: 1s
+  - tool: add
+    arguments: {a: 2, b: 3}
+    expect:
+      json: {sum: 5}
+`)},
+		"broken.json": {Data: []byte(`{"scenarios": [{"name": "no tool"}]}`)},
+	}
+
+	suite, err := LoadSuite(fsys, "greet.yaml")
+	if err != nil {
+		t.Fatalf("LoadSuite failed: %v", err)
+	}
+	if suite.Name != "greet" || len(suite.Scenarios) != 2 || suite.Scenarios[0].LatencyBudget != time.Second {
+		t.Errorf("Unexpected suite: %+v", suite)
+	}
+
+	report := Run(context.Background(), ServerTarget(newEvalServer()), suite)
+	if !report.Passed() {
+		var out bytes.Buffer
+		report.WriteText(&out)
+		t.Errorf("Expected loaded suite to pass:\n%s", out.String())
+	}
+
+	if _, err := LoadSuite(fsys, "broken.json"); err == nil {
+		t.Error("Expected an error for a scenario without a tool")
+	}
+}
+
+func TestRunStopsOnCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report := Run(ctx, ServerTarget(newEvalServer()), Suite{Scenarios: []Scenario{{Tool: "greet"}}})
+	if report.Passed() || len(report.Results[0].Failures) == 0 {
+		t.Errorf("Expected cancelled run to fail, got %+v", report.Results)
+	}
+}
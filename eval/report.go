@@ -0,0 +1,95 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Report is the outcome of running a suite.
+type Report struct {
+	Suite    string           `json:"suite"`
+	Started  time.Time        `json:"started"`
+	Duration time.Duration    `json:"duration"`
+	Results  []ScenarioResult `json:"results"`
+}
+
+// ScenarioResult is the outcome of one scenario.
+type ScenarioResult struct {
+	Name     string        `json:"name"`
+	Tool     string        `json:"tool"`
+	Passed   bool          `json:"passed"`
+	Latency  time.Duration `json:"latency"`
+	Output   string        `json:"output,omitempty"`
+	Failures []string      `json:"failures,omitempty"`
+}
+
+// Passed reports whether every scenario passed.
+func (r *Report) Passed() bool {
+	return r.FailedCount() == 0
+}
+
+// FailedCount returns the number of failed scenarios.
+func (r *Report) FailedCount() int {
+	failed := 0
+	for _, result := range r.Results {
+		if !result.Passed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// WriteText writes a human-readable summary, one line per scenario followed
+// by the reasons for each failure.
+func (r *Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "suite %s: %d/%d passed in %s\n",
+		r.Suite, len(r.Results)-r.FailedCount(), len(r.Results), r.Duration.Round(time.Millisecond))
+
+	for _, result := range r.Results {
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(&b, "  %s %s (%s)\n", status, result.Name, result.Latency.Round(time.Microsecond))
+		for _, failure := range result.Failures {
+			fmt.Fprintf(&b, "      %s\n", failure)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// LoadSuite reads a suite from a YAML or JSON file. Latency budgets are
+// written as durations such as "250ms". A suite without a name is named
+// after its file.
+func LoadSuite(fsys fs.FS, name string) (Suite, error) {
+	var suite Suite
+
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return suite, fmt.Errorf("failed to read suite %s: %w", name, err)
+	}
+
+	// YAML is a superset of JSON, so one decoder handles both formats
+	if err := yaml.Unmarshal(data, &suite); err != nil {
+		return suite, fmt.Errorf("failed to parse suite %s: %w", name, err)
+	}
+
+	if suite.Name == "" {
+		suite.Name = strings.TrimSuffix(path.Base(name), path.Ext(name))
+	}
+	for i, scenario := range suite.Scenarios {
+		if scenario.Tool == "" {
+			return suite, fmt.Errorf("scenario %d in %s has no tool", i+1, name)
+		}
+	}
+	return suite, nil
+}
@@ -0,0 +1,112 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+)
+
+// Output is the normalized result of a tool call.
+type Output struct {
+	// Text is the concatenated text content of the result.
+	Text string
+
+	// IsError reports whether the tool flagged the result as an error.
+	IsError bool
+}
+
+// Target is something scenarios can call tools on.
+type Target interface {
+	CallTool(ctx context.Context, name string, args map[string]interface{}) (Output, error)
+}
+
+// ServerTarget runs scenarios in-process against a server, without a transport.
+func ServerTarget(srv server.Server) Target {
+	return &serverTarget{srv: srv}
+}
+
+// ClientTarget runs scenarios over the wire through a connected client.
+func ClientTarget(c client.Client) Target {
+	return clientTarget{client: c}
+}
+
+type serverTarget struct {
+	srv    server.Server
+	nextID atomic.Int64
+}
+
+// CallTool sends a tools/call request straight to the server's message handler.
+func (t *serverTarget) CallTool(ctx context.Context, name string, args map[string]interface{}) (Output, error) {
+	request, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      t.nextID.Add(1),
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": name, "arguments": args},
+	})
+	if err != nil {
+		return Output{}, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	responseBytes, err := server.HandleMessage(t.srv.GetServer(), request)
+	if err != nil {
+		return Output{}, err
+	}
+
+	var response struct {
+		Result interface{} `json:"result"`
+		Error  *struct {
+			Code    int         `json:"code"`
+			Message string      `json:"message"`
+			Data    interface{} `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(responseBytes, &response); err != nil {
+		return Output{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if response.Error != nil {
+		return Output{}, fmt.Errorf("server returned error: %s (code %d): %v",
+			response.Error.Message, response.Error.Code, response.Error.Data)
+	}
+	return outputFromResult(response.Result), nil
+}
+
+type clientTarget struct {
+	client client.Client
+}
+
+// CallTool calls the tool through the client.
+func (t clientTarget) CallTool(ctx context.Context, name string, args map[string]interface{}) (Output, error) {
+	result, err := t.client.CallTool(name, args)
+	if err != nil {
+		return Output{}, err
+	}
+	return outputFromResult(result), nil
+}
+
+// outputFromResult extracts the text content and error flag of a tool result.
+func outputFromResult(result interface{}) Output {
+	object, ok := result.(map[string]interface{})
+	if !ok {
+		return Output{Text: fmt.Sprint(result)}
+	}
+
+	var output Output
+	output.IsError, _ = object["isError"].(bool)
+
+	items, _ := object["content"].([]interface{})
+	texts := make([]string, 0, len(items))
+	for _, item := range items {
+		if content, ok := item.(map[string]interface{}); ok {
+			if text, ok := content["text"].(string); ok {
+				texts = append(texts, text)
+			}
+		}
+	}
+	output.Text = strings.Join(texts, "\n")
+	return output
+}
@@ -2,6 +2,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -22,7 +23,7 @@ func main() {
 	address := "localhost:8080"
 
 	// Start the server in a goroutine
-	startServer(address)
+	srv := startServer(address)
 
 	// Wait a bit for the server to initialize
 	time.Sleep(1 * time.Second)
@@ -32,10 +33,17 @@ func main() {
 
 	// Wait for termination signal
 	<-signals
-	fmt.Println("\nShutdown signal received, exiting...")
+	fmt.Println("\nShutdown signal received, finishing in-flight requests...")
+
+	// Give running tool calls up to ten seconds to finish
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Shutdown: %v", err)
+	}
 }
 
-func startServer(address string) {
+func startServer(address string) server.Server {
 	// Create a new server
 	srv := server.NewServer("http-example-server")
 
@@ -59,6 +67,7 @@ func startServer(address string) {
 			log.Fatalf("Server error: %v", err)
 		}
 	}()
+	return srv
 }
 
 func runClient(address string) {
@@ -0,0 +1,141 @@
+// Package flags connects MCP servers to a feature flag service, so tools,
+// their schemas and middleware can be switched per environment or tenant
+// while the server runs.
+//
+// A Provider evaluates flags for an EvaluationContext describing the caller.
+// Static serves values held in memory, which is enough for tests and for
+// flags set from configuration at startup; other services are plugged in by
+// implementing Provider.
+//
+// # Basic Usage
+//
+//	provider := flags.NewStatic(map[string]interface{}{"reports.v2": false})
+//	provider.SetFor(tenantKey, "reports.v2", true)
+//
+//	srv := server.NewServer("my-service", server.WithFlags(provider))
+//	srv.Tool("reports", "Build a report", reportHandler)
+//	srv.WithToolFlag("reports", "reports.v2")
+//
+// # OpenFeature
+//
+// Provider has the shape of the OpenFeature evaluation API, so an
+// OpenFeature client is adapted in a few lines:
+//
+//	type openFeatureFlags struct{ client *openfeature.Client }
+//
+//	func (f openFeatureFlags) BooleanValue(ctx context.Context, flag string, def bool, ec flags.EvaluationContext) (bool, error) {
+//	    return f.client.BooleanValue(ctx, flag, def, openfeature.NewEvaluationContext(ec.TargetingKey, ec.Attributes))
+//	}
+//
+//	func (f openFeatureFlags) StringValue(ctx context.Context, flag string, def string, ec flags.EvaluationContext) (string, error) {
+//	    return f.client.StringValue(ctx, flag, def, openfeature.NewEvaluationContext(ec.TargetingKey, ec.Attributes))
+//	}
+//
+//	srv := server.NewServer("my-service",
+//	    server.WithFlags(openFeatureFlags{client: openfeature.NewClient("mcp")}),
+//	)
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrFlagNotFound is returned, with the default value, for flags the
+// provider doesn't know.
+var ErrFlagNotFound = errors.New("flag not found")
+
+// EvaluationContext describes who a flag is evaluated for.
+type EvaluationContext struct {
+	// TargetingKey identifies the caller, such as a tenant or API key, for
+	// per-caller values and percentage rollouts.
+	TargetingKey string
+
+	// Attributes holds further facts rules can match on, such as the
+	// session ID and the tool being listed or called.
+	Attributes map[string]interface{}
+}
+
+// Provider evaluates feature flags. On error, implementations return the
+// default value along with it.
+type Provider interface {
+	BooleanValue(ctx context.Context, flag string, defaultValue bool, evalCtx EvaluationContext) (bool, error)
+	StringValue(ctx context.Context, flag string, defaultValue string, evalCtx EvaluationContext) (string, error)
+}
+
+// Static is a Provider serving values held in memory. Values may be changed
+// while the server runs and take effect at the next evaluation.
+type Static struct {
+	mu      sync.RWMutex
+	values  map[string]interface{}
+	targets map[string]map[string]interface{}
+}
+
+// NewStatic returns a provider serving values, keyed by flag name. Values
+// are bools or strings.
+func NewStatic(values map[string]interface{}) *Static {
+	s := &Static{
+		values:  make(map[string]interface{}, len(values)),
+		targets: make(map[string]map[string]interface{}),
+	}
+	for flag, value := range values {
+		s.values[flag] = value
+	}
+	return s
+}
+
+// Set sets the value of a flag for every caller without a value of its own.
+func (s *Static) Set(flag string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[flag] = value
+}
+
+// SetFor sets the value of a flag for callers with the given targeting key.
+func (s *Static) SetFor(targetingKey, flag string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.targets[targetingKey] == nil {
+		s.targets[targetingKey] = make(map[string]interface{})
+	}
+	s.targets[targetingKey][flag] = value
+}
+
+// lookup returns the value of a flag for a caller.
+func (s *Static) lookup(flag string, evalCtx EvaluationContext) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if value, ok := s.targets[evalCtx.TargetingKey][flag]; ok {
+		return value, true
+	}
+	value, ok := s.values[flag]
+	return value, ok
+}
+
+// BooleanValue implements Provider.
+func (s *Static) BooleanValue(ctx context.Context, flag string, defaultValue bool, evalCtx EvaluationContext) (bool, error) {
+	value, ok := s.lookup(flag, evalCtx)
+	if !ok {
+		return defaultValue, fmt.Errorf("%w: %s", ErrFlagNotFound, flag)
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return defaultValue, fmt.Errorf("flag %s is a %T, not a bool", flag, value)
+	}
+	return b, nil
+}
+
+// StringValue implements Provider.
+func (s *Static) StringValue(ctx context.Context, flag string, defaultValue string, evalCtx EvaluationContext) (string, error) {
+	value, ok := s.lookup(flag, evalCtx)
+	if !ok {
+		return defaultValue, fmt.Errorf("%w: %s", ErrFlagNotFound, flag)
+	}
+	str, ok := value.(string)
+	if !ok {
+		return defaultValue, fmt.Errorf("flag %s is a %T, not a string", flag, value)
+	}
+	return str, nil
+}
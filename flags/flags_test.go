@@ -0,0 +1,38 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStaticTargeting(t *testing.T) {
+	provider := NewStatic(map[string]interface{}{"beta": false, "theme": "light"})
+	provider.SetFor("tenant-a", "beta", true)
+
+	ctx := context.Background()
+	if on, err := provider.BooleanValue(ctx, "beta", false, EvaluationContext{TargetingKey: "tenant-a"}); err != nil || !on {
+		t.Errorf("Expected beta on for tenant-a, got %v, %v", on, err)
+	}
+	if on, err := provider.BooleanValue(ctx, "beta", true, EvaluationContext{TargetingKey: "tenant-b"}); err != nil || on {
+		t.Errorf("Expected beta off for tenant-b, got %v, %v", on, err)
+	}
+
+	provider.Set("theme", "dark")
+	if theme, _ := provider.StringValue(ctx, "theme", "", EvaluationContext{}); theme != "dark" {
+		t.Errorf("Expected the updated theme, got %q", theme)
+	}
+}
+
+func TestStaticErrorsReturnDefault(t *testing.T) {
+	provider := NewStatic(map[string]interface{}{"theme": "light"})
+	ctx := context.Background()
+
+	on, err := provider.BooleanValue(ctx, "missing", true, EvaluationContext{})
+	if !errors.Is(err, ErrFlagNotFound) || !on {
+		t.Errorf("Expected the default with ErrFlagNotFound, got %v, %v", on, err)
+	}
+	if on, err := provider.BooleanValue(ctx, "theme", true, EvaluationContext{}); err == nil || !on {
+		t.Errorf("Expected the default with a type error, got %v, %v", on, err)
+	}
+}
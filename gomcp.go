@@ -27,6 +27,20 @@
 //   - github.com/localrivet/gomcp/server: Server implementation for hosting MCP services
 //   - github.com/localrivet/gomcp/transport: Transport layer implementations
 //   - github.com/localrivet/gomcp/mcp: Core protocol definitions and version handling
+//   - github.com/localrivet/gomcp/eval: Scripted regression evals for MCP servers
+//   - github.com/localrivet/gomcp/client/hostsim: Simulated MCP hosts for compatibility testing
+//   - github.com/localrivet/gomcp/doctor: Compatibility checks behind the gomcp doctor command
+//   - github.com/localrivet/gomcp/typegen: TypeScript and Python types for tool inputs and outputs
+//   - github.com/localrivet/gomcp/ratelimit: In-memory and Redis-backed rate limit stores and token buckets
+//   - github.com/localrivet/gomcp/persist: Embedded file-backed storage for server state kept across restarts
+//   - github.com/localrivet/gomcp/protocol: JSON-RPC error codes and the Error type shared by clients and servers
+//   - github.com/localrivet/gomcp/serverconfig: Tools, resources and prompts registered from a hot-reloaded config file
+//   - github.com/localrivet/gomcp/auth: OAuth 2.1 authorization for HTTP transports, with JWKS token validation and a PKCE client flow
+//   - github.com/localrivet/gomcp/webhook: Signed, batched webhook delivery of server events
+//   - github.com/localrivet/gomcp/contrib/notify: Rate-limited Slack and Discord alerts for server events
+//   - github.com/localrivet/gomcp/contrib/chart: Line, bar and pie charts rendered as PNG image content
+//   - github.com/localrivet/gomcp/contrib/extract: Plain text of PDF, Word and Excel resources for clients that accept it
+//   - github.com/localrivet/gomcp/contrib/markdown: Markdown of web pages, reduced to their main content, for fetch tools and resources
 //
 // # Basic Usage
 //
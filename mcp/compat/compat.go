@@ -0,0 +1,178 @@
+// Package compat converts between revisions of the MCP protocol structures.
+//
+// Peers built against older gomcp releases, or against older revisions of the
+// specification, still send prompts keyed by URI, tool schemas under "schema"
+// and tool hints outside "annotations". The functions in this package rewrite
+// such messages into the current shape and report each legacy field they
+// encountered as a Deprecation, so callers can log a warning instead of
+// failing to decode.
+package compat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Deprecation describes a legacy field that was converted.
+type Deprecation struct {
+	// Field is the legacy field, e.g. "prompts/get.uri".
+	Field string
+
+	// Replacement is the field it was converted to, or empty if the field
+	// was dropped because the target revision cannot represent it.
+	Replacement string
+}
+
+// String returns a human-readable warning.
+func (d Deprecation) String() string {
+	if d.Replacement == "" {
+		return fmt.Sprintf("%s is not supported by the target revision and was dropped", d.Field)
+	}
+	return fmt.Sprintf("%s is deprecated, use %s instead", d.Field, d.Replacement)
+}
+
+// annotationHints maps legacy annotation names to their current names.
+var annotationHints = map[string]string{
+	"readOnly":    "readOnlyHint",
+	"destructive": "destructiveHint",
+	"idempotent":  "idempotentHint",
+	"openWorld":   "openWorldHint",
+}
+
+// UpgradeRequest rewrites the params of an incoming request in place.
+// It handles prompts/get keyed by "uri" instead of "name", and prompts/get
+// and tools/call arguments sent as "variables" or "parameters".
+func UpgradeRequest(method string, params map[string]interface{}) []Deprecation {
+	if params == nil {
+		return nil
+	}
+
+	var deprecations []Deprecation
+	switch method {
+	case "prompts/get":
+		if _, hasName := params["name"]; !hasName {
+			if uri, ok := params["uri"].(string); ok {
+				params["name"] = PromptNameFromURI(uri)
+				delete(params, "uri")
+				deprecations = append(deprecations, Deprecation{Field: method + ".uri", Replacement: method + ".name"})
+			}
+		}
+		deprecations = append(deprecations, renameField(params, method, "variables", "arguments")...)
+	case "tools/call":
+		deprecations = append(deprecations, renameField(params, method, "parameters", "arguments")...)
+	}
+	return deprecations
+}
+
+// UpgradeResult rewrites the result of a list request in place, upgrading
+// each tool or prompt entry.
+func UpgradeResult(method string, result map[string]interface{}) []Deprecation {
+	var key string
+	var upgrade func(map[string]interface{}) []Deprecation
+	switch method {
+	case "tools/list":
+		key, upgrade = "tools", UpgradeTool
+	case "prompts/list":
+		key, upgrade = "prompts", UpgradePrompt
+	default:
+		return nil
+	}
+
+	entries, _ := result[key].([]interface{})
+	var deprecations []Deprecation
+	for _, entry := range entries {
+		if object, ok := entry.(map[string]interface{}); ok {
+			deprecations = append(deprecations, upgrade(object)...)
+		}
+	}
+	return deprecations
+}
+
+// UpgradeTool rewrites a tool description in place. Schemas under "schema"
+// or "parameters" move to "inputSchema", and behavior hints found at the top
+// level or under "metadata.annotations" move to "annotations" with their
+// current "...Hint" names.
+func UpgradeTool(tool map[string]interface{}) []Deprecation {
+	var deprecations []Deprecation
+	for _, legacy := range []string{"schema", "parameters"} {
+		deprecations = append(deprecations, renameField(tool, "tool", legacy, "inputSchema")...)
+	}
+
+	annotations, _ := tool["annotations"].(map[string]interface{})
+	setHint := func(field, name string, value interface{}) {
+		if annotations == nil {
+			annotations = make(map[string]interface{})
+			tool["annotations"] = annotations
+		}
+		if _, exists := annotations[name]; !exists {
+			annotations[name] = value
+		}
+		deprecations = append(deprecations, Deprecation{Field: field, Replacement: "tool.annotations." + name})
+	}
+
+	for legacy, current := range annotationHints {
+		if value, ok := tool[legacy]; ok {
+			delete(tool, legacy)
+			setHint("tool."+legacy, current, value)
+		}
+		if annotations != nil {
+			if value, ok := annotations[legacy]; ok {
+				delete(annotations, legacy)
+				setHint("tool.annotations."+legacy, current, value)
+			}
+		}
+	}
+
+	if metadata, ok := tool["metadata"].(map[string]interface{}); ok {
+		if legacy, ok := metadata["annotations"].(map[string]interface{}); ok {
+			for name, value := range legacy {
+				if current, known := annotationHints[name]; known {
+					name = current
+				}
+				setHint("tool.metadata.annotations."+name, name, value)
+			}
+			delete(metadata, "annotations")
+		}
+	}
+	return deprecations
+}
+
+// UpgradePrompt rewrites a prompt description in place. Prompts identified
+// only by "uri" get a name derived from it, and "variables" become "arguments".
+func UpgradePrompt(prompt map[string]interface{}) []Deprecation {
+	var deprecations []Deprecation
+	if _, hasName := prompt["name"]; !hasName {
+		if uri, ok := prompt["uri"].(string); ok {
+			prompt["name"] = PromptNameFromURI(uri)
+			deprecations = append(deprecations, Deprecation{Field: "prompt.uri", Replacement: "prompt.name"})
+		}
+	}
+	return append(deprecations, renameField(prompt, "prompt", "variables", "arguments")...)
+}
+
+// PromptNameFromURI derives a prompt name from a legacy prompt URI, such as
+// "prompt://greeting" or "/prompts/greeting".
+func PromptNameFromURI(uri string) string {
+	if _, rest, found := strings.Cut(uri, "://"); found {
+		uri = rest
+	}
+	uri = strings.Trim(uri, "/")
+	if i := strings.LastIndex(uri, "/"); i >= 0 {
+		uri = uri[i+1:]
+	}
+	return uri
+}
+
+// renameField moves object[legacy] to object[current] unless current is
+// already set, in which case the legacy field is dropped.
+func renameField(object map[string]interface{}, scope, legacy, current string) []Deprecation {
+	value, ok := object[legacy]
+	if !ok {
+		return nil
+	}
+	delete(object, legacy)
+	if _, exists := object[current]; !exists {
+		object[current] = value
+	}
+	return []Deprecation{{Field: scope + "." + legacy, Replacement: scope + "." + current}}
+}
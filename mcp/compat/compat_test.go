@@ -0,0 +1,120 @@
+package compat
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/localrivet/gomcp/mcp/v20241105"
+	"github.com/localrivet/gomcp/mcp/v20250326"
+)
+
+func TestUpgradeRequestPromptURI(t *testing.T) {
+	params := map[string]interface{}{
+		"uri":       "prompt://greeting",
+		"variables": map[string]interface{}{"name": "Ada"},
+	}
+
+	deprecations := UpgradeRequest("prompts/get", params)
+	if len(deprecations) != 2 {
+		t.Fatalf("Expected 2 deprecations, got %v", deprecations)
+	}
+	if params["name"] != "greeting" {
+		t.Errorf("Expected name greeting, got %v", params["name"])
+	}
+	if _, ok := params["arguments"].(map[string]interface{}); !ok {
+		t.Errorf("Expected variables to move to arguments, got %v", params)
+	}
+	if _, ok := params["uri"]; ok {
+		t.Error("Expected uri to be removed")
+	}
+}
+
+func TestUpgradeRequestCurrentShapeUnchanged(t *testing.T) {
+	params := map[string]interface{}{"name": "echo", "arguments": map[string]interface{}{}}
+	if deprecations := UpgradeRequest("tools/call", params); len(deprecations) != 0 {
+		t.Errorf("Expected no deprecations, got %v", deprecations)
+	}
+}
+
+func TestUpgradeTool(t *testing.T) {
+	tool := map[string]interface{}{
+		"name":     "delete",
+		"schema":   map[string]interface{}{"type": "object"},
+		"readOnly": false,
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{"destructive": true, "title": "Delete"},
+		},
+	}
+
+	UpgradeTool(tool)
+
+	want := map[string]interface{}{
+		"readOnlyHint":    false,
+		"destructiveHint": true,
+		"title":           "Delete",
+	}
+	if !reflect.DeepEqual(tool["annotations"], want) {
+		t.Errorf("Unexpected annotations: %v", tool["annotations"])
+	}
+	if _, ok := tool["inputSchema"]; !ok {
+		t.Error("Expected schema to move to inputSchema")
+	}
+}
+
+func TestUpgradeResultPrompts(t *testing.T) {
+	result := map[string]interface{}{
+		"prompts": []interface{}{
+			map[string]interface{}{"uri": "/prompts/summarize"},
+			map[string]interface{}{"name": "current"},
+		},
+	}
+
+	if deprecations := UpgradeResult("prompts/list", result); len(deprecations) != 1 {
+		t.Errorf("Expected 1 deprecation, got %v", deprecations)
+	}
+	first := result["prompts"].([]interface{})[0].(map[string]interface{})
+	if first["name"] != "summarize" {
+		t.Errorf("Expected name summarize, got %v", first["name"])
+	}
+}
+
+func TestPromptRoundTrip(t *testing.T) {
+	old := v20241105.PromptDefinition{
+		Name:        "greet",
+		Description: "Greet someone",
+		Template: []v20241105.PromptElement{{
+			Role:    "user",
+			Content: []v20241105.ContentElement{{Type: "text", Content: "Hello {{name}}"}},
+		}},
+		Variables: []v20241105.PromptVar{{Name: "name", Required: true, Default: "world"}},
+	}
+
+	upgraded := PromptToV20250326(old)
+	if upgraded.Variables[0].Default != "world" {
+		t.Errorf("Expected default to be kept, got %v", upgraded.Variables[0].Default)
+	}
+
+	back, dropped := PromptToV20241105(upgraded)
+	if len(dropped) != 0 {
+		t.Errorf("Expected nothing dropped, got %v", dropped)
+	}
+	if !reflect.DeepEqual(back, old) {
+		t.Errorf("Round trip changed the prompt:\n got %+v\nwant %+v", back, old)
+	}
+}
+
+func TestDowngradeReportsDroppedFields(t *testing.T) {
+	tool := v20250326.ToolDefinition{Name: "stream", Description: "Streams", Streamable: true}
+	if _, dropped := ToolToV20241105(tool); len(dropped) != 1 || dropped[0].Field != "tool.streamable" {
+		t.Errorf("Expected streamable to be reported as dropped, got %v", dropped)
+	}
+
+	prompt := v20250326.PromptDefinition{
+		Name:      "p",
+		Variables: []v20250326.PromptVar{{Name: "count", Default: 3}},
+	}
+	converted, _ := PromptToV20241105(prompt)
+	if converted.Variables[0].Default != "3" {
+		t.Errorf("Expected numeric default to be formatted, got %q", converted.Variables[0].Default)
+	}
+}
@@ -0,0 +1,137 @@
+package compat
+
+import (
+	"fmt"
+
+	"github.com/localrivet/gomcp/mcp/v20241105"
+	"github.com/localrivet/gomcp/mcp/v20250326"
+)
+
+// ToolToV20250326 converts a 2024-11-05 tool definition to the 2025-03-26 shape.
+func ToolToV20250326(tool v20241105.ToolDefinition) v20250326.ToolDefinition {
+	return v20250326.ToolDefinition{
+		Name:        tool.Name,
+		Description: tool.Description,
+		Schema:      tool.Schema,
+	}
+}
+
+// ToolToV20241105 converts a 2025-03-26 tool definition to the 2024-11-05
+// shape, reporting the fields that revision cannot represent.
+func ToolToV20241105(tool v20250326.ToolDefinition) (v20241105.ToolDefinition, []Deprecation) {
+	var dropped []Deprecation
+	if tool.Streamable {
+		dropped = append(dropped, unsupported("tool.streamable"))
+	}
+	if tool.Metadata.Cost != nil || tool.Metadata.Performance != nil {
+		dropped = append(dropped, unsupported("tool.metadata"))
+	}
+
+	return v20241105.ToolDefinition{
+		Name:        tool.Name,
+		Description: tool.Description,
+		Schema:      tool.Schema,
+	}, dropped
+}
+
+// PromptToV20250326 converts a 2024-11-05 prompt definition to the 2025-03-26 shape.
+func PromptToV20250326(prompt v20241105.PromptDefinition) v20250326.PromptDefinition {
+	converted := v20250326.PromptDefinition{
+		Name:        prompt.Name,
+		Description: prompt.Description,
+		Metadata: v20250326.PromptMetadata{
+			Version:     prompt.Metadata.Version,
+			Author:      prompt.Metadata.Author,
+			Tags:        prompt.Metadata.Tags,
+			Category:    prompt.Metadata.Category,
+			Properties:  prompt.Metadata.Properties,
+			Annotations: prompt.Metadata.Annotations,
+		},
+	}
+
+	for _, element := range prompt.Template {
+		content := make([]v20250326.ContentElement, 0, len(element.Content))
+		for _, c := range element.Content {
+			content = append(content, v20250326.ContentElement(c))
+		}
+		converted.Template = append(converted.Template, v20250326.PromptElement{
+			Role:     element.Role,
+			Content:  content,
+			Metadata: element.Metadata,
+		})
+	}
+
+	for _, v := range prompt.Variables {
+		variable := v20250326.PromptVar{
+			Name:        v.Name,
+			Description: v.Description,
+			Type:        v.Type,
+			Required:    v.Required,
+		}
+		if v.Default != "" {
+			variable.Default = v.Default
+		}
+		converted.Variables = append(converted.Variables, variable)
+	}
+	return converted
+}
+
+// PromptToV20241105 converts a 2025-03-26 prompt definition to the 2024-11-05
+// shape. Non-string defaults are formatted as strings; fields that revision
+// cannot represent are dropped and reported.
+func PromptToV20241105(prompt v20250326.PromptDefinition) (v20241105.PromptDefinition, []Deprecation) {
+	var dropped []Deprecation
+	if len(prompt.Formats) > 0 {
+		dropped = append(dropped, unsupported("prompt.formats"))
+	}
+	if len(prompt.Metadata.Models) > 0 || prompt.Metadata.MaxTokens > 0 {
+		dropped = append(dropped, unsupported("prompt.metadata.models"))
+	}
+
+	converted := v20241105.PromptDefinition{
+		Name:        prompt.Name,
+		Description: prompt.Description,
+		Metadata: v20241105.PromptMetadata{
+			Version:     prompt.Metadata.Version,
+			Author:      prompt.Metadata.Author,
+			Tags:        prompt.Metadata.Tags,
+			Category:    prompt.Metadata.Category,
+			Properties:  prompt.Metadata.Properties,
+			Annotations: prompt.Metadata.Annotations,
+		},
+	}
+
+	for _, element := range prompt.Template {
+		content := make([]v20241105.ContentElement, 0, len(element.Content))
+		for _, c := range element.Content {
+			content = append(content, v20241105.ContentElement(c))
+		}
+		converted.Template = append(converted.Template, v20241105.PromptElement{
+			Role:     element.Role,
+			Content:  content,
+			Metadata: element.Metadata,
+		})
+	}
+
+	for _, v := range prompt.Variables {
+		variable := v20241105.PromptVar{
+			Name:        v.Name,
+			Description: v.Description,
+			Type:        v.Type,
+			Required:    v.Required,
+		}
+		if v.Default != nil {
+			variable.Default = fmt.Sprint(v.Default)
+		}
+		if v.Validation != "" || len(v.Examples) > 0 {
+			dropped = append(dropped, unsupported("prompt.variables."+v.Name+".validation"))
+		}
+		converted.Variables = append(converted.Variables, variable)
+	}
+	return converted, dropped
+}
+
+// unsupported reports a field dropped when converting to an older revision.
+func unsupported(field string) Deprecation {
+	return Deprecation{Field: field}
+}
@@ -0,0 +1,38 @@
+package mcp
+
+// WellKnownPath is the path at which HTTP-based servers publish their discovery document.
+const WellKnownPath = "/.well-known/mcp.json"
+
+// Transport types advertised in a discovery document
+const (
+	TransportHTTP           = "http"
+	TransportSSE            = "sse"
+	TransportStreamableHTTP = "streamable-http"
+	TransportWebsocket      = "websocket"
+)
+
+// DiscoveryDocument describes how to connect to an MCP server.
+// It is served as JSON at WellKnownPath so clients don't have to guess
+// which transport and endpoint paths a server uses.
+type DiscoveryDocument struct {
+	Name             string              `json:"name"`
+	Version          string              `json:"version"`
+	ProtocolVersions []string            `json:"protocolVersions"`
+	Transports       []TransportEndpoint `json:"transports"`
+	Auth             *AuthRequirements   `json:"auth,omitempty"`
+}
+
+// TransportEndpoint describes a single transport offered by a server.
+// Endpoint paths are relative to the server's base URL.
+type TransportEndpoint struct {
+	Type            string `json:"type"`
+	Endpoint        string `json:"endpoint"`
+	MessageEndpoint string `json:"messageEndpoint,omitempty"` // SSE only: where clients POST messages
+}
+
+// AuthRequirements describes how clients must authenticate with a server.
+type AuthRequirements struct {
+	Type                 string   `json:"type"` // e.g. "bearer" or "oauth2"
+	AuthorizationServers []string `json:"authorizationServers,omitempty"`
+	Scopes               []string `json:"scopes,omitempty"`
+}
@@ -0,0 +1,212 @@
+package persist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// compactMinRecords is how many records the file must hold before it is
+// compacted, so small stores aren't rewritten on every change.
+const compactMinRecords = 1024
+
+// FileStore is a Store kept in a single file. The values are held in memory
+// and every change is appended to the file, which is synced before the
+// change returns. When most records in the file have been overwritten or
+// deleted, it is rewritten with only the live ones.
+//
+// Only one process may open a file at a time.
+type FileStore struct {
+	mem     *MemoryStore
+	path    string
+	file    *os.File
+	records int
+}
+
+// record is one line of the file.
+type record struct {
+	Op     string `json:"op"`
+	Bucket string `json:"b"`
+	Key    string `json:"k"`
+	Value  []byte `json:"v,omitempty"`
+}
+
+// Open opens the store in the file at path, creating it if needed. A
+// record left incomplete by a crash at the end of the file is discarded.
+func Open(path string) (*FileStore, error) {
+	s := &FileStore{mem: NewMemoryStore(), path: path}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %w", err)
+	}
+	valid, err := s.load(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	// Drop a torn final record, so appends start on a fresh line
+	if err := file.Truncate(valid); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to truncate store: %w", err)
+	}
+	if _, err := file.Seek(valid, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to seek store: %w", err)
+	}
+	s.file = file
+	return s, nil
+}
+
+// load replays the records of the file and returns the length of the part
+// holding complete records.
+func (s *FileStore) load(file *os.File) (int64, error) {
+	reader := bufio.NewReader(file)
+	var valid int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return valid, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read store: %w", err)
+		}
+
+		var r record
+		if jsonErr := json.Unmarshal(bytes.TrimSpace(line), &r); jsonErr != nil {
+			return 0, fmt.Errorf("corrupt store record at offset %d: %w", valid, jsonErr)
+		}
+		s.apply(r)
+		s.records++
+		valid += int64(len(line))
+	}
+}
+
+func (s *FileStore) apply(r record) {
+	switch r.Op {
+	case "put":
+		s.mem.put(r.Bucket, r.Key, r.Value)
+	case "delete":
+		s.mem.delete(r.Bucket, r.Key)
+	}
+}
+
+// Get implements Store.
+func (s *FileStore) Get(bucket, key string) ([]byte, bool, error) {
+	return s.mem.Get(bucket, key)
+}
+
+// Scan implements Store.
+func (s *FileStore) Scan(bucket string, fn func(key string, value []byte) bool) error {
+	return s.mem.Scan(bucket, fn)
+}
+
+// Put implements Store.
+func (s *FileStore) Put(bucket, key string, value []byte) error {
+	return s.write(record{Op: "put", Bucket: bucket, Key: key, Value: value})
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(bucket, key string) error {
+	return s.write(record{Op: "delete", Bucket: bucket, Key: key})
+}
+
+// write appends a record to the file and applies it once it is synced.
+func (s *FileStore) write(r record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+	if s.mem.closed {
+		return ErrClosed
+	}
+	end, err := s.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("failed to seek store: %w", err)
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		s.rewind(end)
+		return fmt.Errorf("failed to write store: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		s.rewind(end)
+		return fmt.Errorf("failed to sync store: %w", err)
+	}
+	s.apply(r)
+	s.records++
+
+	if live := s.mem.count(); s.records >= compactMinRecords && s.records > 2*live {
+		if err := s.compact(); err != nil {
+			// The change is already durable; compaction is retried later
+			return nil
+		}
+	}
+	return nil
+}
+
+// rewind drops what a failed write left after end, so that a torn record
+// doesn't run into the next one. The caller holds the lock.
+func (s *FileStore) rewind(end int64) {
+	if err := s.file.Truncate(end); err == nil {
+		s.file.Seek(end, io.SeekStart)
+	}
+}
+
+// compact rewrites the file with only the live records, replacing it
+// atomically. The caller holds the lock.
+func (s *FileStore) compact() error {
+	temp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".compact-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	writer := bufio.NewWriter(temp)
+	records := 0
+	for bucket, values := range s.mem.buckets {
+		for key, value := range values {
+			line, err := json.Marshal(record{Op: "put", Bucket: bucket, Key: key, Value: value})
+			if err != nil {
+				temp.Close()
+				return err
+			}
+			writer.Write(append(line, '\n'))
+			records++
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := os.Rename(temp.Name(), s.path); err != nil {
+		temp.Close()
+		return err
+	}
+
+	s.file.Close()
+	s.file = temp
+	s.records = records
+	return nil
+}
+
+// Close implements Store.
+func (s *FileStore) Close() error {
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+	if s.mem.closed {
+		return nil
+	}
+	s.mem.closed = true
+	return s.file.Close()
+}
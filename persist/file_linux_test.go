@@ -0,0 +1,54 @@
+package persist
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestFileStoreRecoversFromTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("b", "first", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cap the file size so the next record is only partly written
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_FSIZE, &limit); err != nil {
+		t.Skip("file size limit unavailable:", err)
+	}
+	size, err := store.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	capped := limit
+	capped.Cur = uint64(size) + 10
+	if err := syscall.Setrlimit(syscall.RLIMIT_FSIZE, &capped); err != nil {
+		t.Skip("cannot limit file size:", err)
+	}
+	err = store.Put("b", "torn", []byte(strings.Repeat("x", 100)))
+	syscall.Setrlimit(syscall.RLIMIT_FSIZE, &limit)
+	if err == nil {
+		t.Fatal("Expected the write past the size limit to fail")
+	}
+
+	if err := store.Put("b", "second", []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected the store to reopen after a torn write, got %v", err)
+	}
+	defer reopened.Close()
+	if got := strings.Join(scanKeys(t, reopened, "b"), ","); got != "first=1,second=2" {
+		t.Errorf("Expected the records around the torn write, got %s", got)
+	}
+}
@@ -0,0 +1,159 @@
+// Package persist provides embedded key-value stores for server state that
+// should survive restarts, such as idempotent tool results and the audit
+// trail, without external infrastructure.
+//
+// A Store keeps values in named buckets. MemoryStore keeps them in process
+// memory, which is useful in tests. FileStore keeps them in a single file
+// next to the server binary: every change is appended to the file and synced
+// before it is acknowledged, and the file is compacted as it accumulates
+// overwritten records.
+//
+// # Basic Usage
+//
+//	store, err := persist.Open("/var/lib/my-service/state.db")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer store.Close()
+//
+//	srv := server.NewServer("my-service", server.WithPersistence(store))
+package persist
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ErrClosed is returned by stores used after Close.
+var ErrClosed = errors.New("store closed")
+
+// Store is a key-value store with named buckets. Implementations must be
+// safe for concurrent use.
+type Store interface {
+	// Get returns the value of key in bucket, and whether it exists.
+	Get(bucket, key string) ([]byte, bool, error)
+
+	// Put sets the value of key in bucket.
+	Put(bucket, key string, value []byte) error
+
+	// Delete removes key from bucket. Deleting a missing key is not an
+	// error.
+	Delete(bucket, key string) error
+
+	// Scan calls fn with the keys of bucket in order, and their values,
+	// until fn returns false. fn must not modify the store.
+	Scan(bucket string, fn func(key string, value []byte) bool) error
+
+	// Close releases the store.
+	Close() error
+}
+
+// MemoryStore is a Store that keeps values in process memory.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	buckets map[string]map[string][]byte
+	closed  bool
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]map[string][]byte)}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(bucket, key string) ([]byte, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return nil, false, ErrClosed
+	}
+	value, ok := s.buckets[bucket][key]
+	return clone(value), ok, nil
+}
+
+// Put implements Store.
+func (s *MemoryStore) Put(bucket, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	s.put(bucket, key, value)
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	s.delete(bucket, key)
+	return nil
+}
+
+// Scan implements Store.
+func (s *MemoryStore) Scan(bucket string, fn func(key string, value []byte) bool) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrClosed
+	}
+	values := s.buckets[bucket]
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !fn(key, clone(values[key])) {
+			break
+		}
+	}
+	return nil
+}
+
+// Close implements Store.
+func (s *MemoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *MemoryStore) put(bucket, key string, value []byte) {
+	values := s.buckets[bucket]
+	if values == nil {
+		values = make(map[string][]byte)
+		s.buckets[bucket] = values
+	}
+	values[key] = clone(value)
+}
+
+func (s *MemoryStore) delete(bucket, key string) {
+	if values := s.buckets[bucket]; values != nil {
+		delete(values, key)
+		if len(values) == 0 {
+			delete(s.buckets, bucket)
+		}
+	}
+}
+
+// count returns the number of keys in every bucket.
+func (s *MemoryStore) count() int {
+	n := 0
+	for _, values := range s.buckets {
+		n += len(values)
+	}
+	return n
+}
+
+// clone copies a value so callers can't change what the store holds.
+func clone(value []byte) []byte {
+	if value == nil {
+		return nil
+	}
+	return append([]byte{}, value...)
+}
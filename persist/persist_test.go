@@ -0,0 +1,84 @@
+package persist
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func scanKeys(t *testing.T, store Store, bucket string) []string {
+	t.Helper()
+	var keys []string
+	if err := store.Scan(bucket, func(key string, value []byte) bool {
+		keys = append(keys, key+"="+string(value))
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestFileStoreSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Put("a", "2", []byte("two"))
+	store.Put("a", "1", []byte("one"))
+	store.Put("a", "3", []byte("three"))
+	store.Delete("a", "3")
+	store.Put("b", "1", []byte("other"))
+	store.Close()
+
+	if err := store.Put("a", "4", nil); err != ErrClosed {
+		t.Errorf("Expected ErrClosed after Close, got %v", err)
+	}
+
+	// Simulate a crash in the middle of writing a record
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	file.WriteString(`{"op":"put","b":"a","k":"5"`)
+	file.Close()
+
+	store, err = Open(path)
+	if err != nil {
+		t.Fatalf("Expected the torn record to be discarded, got %v", err)
+	}
+	defer store.Close()
+	if keys := scanKeys(t, store, "a"); len(keys) != 2 || keys[0] != "1=one" || keys[1] != "2=two" {
+		t.Errorf("Expected keys 1 and 2 in order, got %v", keys)
+	}
+	if value, found, _ := store.Get("b", "1"); !found || string(value) != "other" {
+		t.Errorf("Expected bucket b to be kept apart, got %q, %v", value, found)
+	}
+	if err := store.Put("a", "6", []byte("six")); err != nil {
+		t.Fatalf("Expected writes after the torn record to succeed, got %v", err)
+	}
+}
+
+func TestFileStoreCompacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3*compactMinRecords; i++ {
+		if err := store.Put("counter", "value", []byte(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if store.records >= compactMinRecords {
+		t.Errorf("Expected the file to be compacted, got %d records", store.records)
+	}
+	store.Close()
+
+	store, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if value, _, _ := store.Get("counter", "value"); string(value) != strconv.Itoa(3*compactMinRecords-1) {
+		t.Errorf("Expected the last value to survive compaction, got %q", value)
+	}
+}
@@ -0,0 +1,111 @@
+// Package protocol defines the JSON-RPC errors and the resource contents
+// exchanged by MCP clients and servers, so that both sides can branch on
+// error codes rather than on error text, and agree on the shape of what
+// resources/read returns.
+//
+// Servers return an *Error from a handler or middleware to answer with its
+// code, and clients return the errors servers answer with as an *Error:
+//
+//	result, err := c.CallTool("search", args)
+//	switch {
+//	case protocol.IsErrorCode(err, protocol.RateLimited):
+//	    // back off and try again
+//	case protocol.IsErrorCode(err, protocol.Unauthorized):
+//	    // refresh credentials
+//	case err != nil:
+//	    return err
+//	}
+package protocol
+
+import "errors"
+
+// Error codes defined by JSON-RPC 2.0.
+const (
+	// ParseError means the message was not valid JSON.
+	ParseError = -32700
+
+	// InvalidRequest means the message was not a valid request.
+	InvalidRequest = -32600
+
+	// MethodNotFound means the method does not exist or the server does not
+	// offer the capability it belongs to.
+	MethodNotFound = -32601
+
+	// InvalidParams means the parameters of the request were invalid,
+	// including calls to tools the server doesn't have.
+	InvalidParams = -32602
+
+	// InternalError means the server failed to handle the request.
+	InternalError = -32603
+)
+
+// Error codes used by gomcp servers.
+const (
+	// Unauthorized means the request carried no valid credentials.
+	Unauthorized = -32001
+
+	// Forbidden means the credentials don't allow the request.
+	Forbidden = -32003
+
+	// RateLimited means a rate limit, quota or the server's memory
+	// pressure rejected the request. The data's retryAfter, when present,
+	// is the number of seconds to wait before retrying.
+	RateLimited = 1006
+
+	// ShuttingDown means the server is shutting down and no longer accepts
+	// requests.
+	ShuttingDown = 1007
+
+	// Maintenance means the server is in maintenance mode and refuses tool
+	// calls.
+	Maintenance = 1011
+)
+
+// ReasonUnknownTool is the reason in the data of the InvalidParams error
+// servers answer calls to unknown tools with.
+const ReasonUnknownTool = "unknown_tool"
+
+// ReasonInsufficientScope is the reason in the data of the Forbidden error
+// servers answer requests with when the caller lacks the scopes a tool or
+// resource requires. The data's scopes lists them.
+const ReasonInsufficientScope = "insufficient_scope"
+
+// ReasonResourceTooLarge is the reason in the data of the InvalidParams
+// error servers answer resources/read with when the resource is larger than
+// a read may return. The data's size and maxReadSize, when present, give
+// the resource's size and the limit in bytes.
+const ReasonResourceTooLarge = "resource_too_large"
+
+// Error is a JSON-RPC error.
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Error returns the message, as servers send it to clients.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Reason returns the reason field of the error's data, which tells apart
+// errors sharing a code, or "" if there is none.
+func (e *Error) Reason() string {
+	data, _ := e.Data.(map[string]interface{})
+	reason, _ := data["reason"].(string)
+	return reason
+}
+
+// IsErrorCode reports whether err, or an error it wraps, is an *Error with
+// the given code.
+func IsErrorCode(err error, code int) bool {
+	var protocolErr *Error
+	return errors.As(err, &protocolErr) && protocolErr.Code == code
+}
+
+// IsUnknownTool reports whether err, or an error it wraps, is the error
+// servers answer calls to unknown tools with.
+func IsUnknownTool(err error) bool {
+	var protocolErr *Error
+	return errors.As(err, &protocolErr) && protocolErr.Code == InvalidParams && protocolErr.Reason() == ReasonUnknownTool
+}
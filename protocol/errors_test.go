@@ -0,0 +1,34 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsErrorCode(t *testing.T) {
+	err := fmt.Errorf("call failed: %w", &Error{Code: RateLimited, Message: "Rate limit exceeded"})
+
+	if !IsErrorCode(err, RateLimited) {
+		t.Error("Expected a wrapped error to match its code")
+	}
+	if IsErrorCode(err, InternalError) {
+		t.Error("Expected other codes not to match")
+	}
+	if IsErrorCode(errors.New("plain"), RateLimited) || IsErrorCode(nil, RateLimited) {
+		t.Error("Expected errors without a code not to match")
+	}
+	if got := err.Error(); got != "call failed: Rate limit exceeded" {
+		t.Errorf("Unexpected message %q", got)
+	}
+}
+
+func TestIsUnknownTool(t *testing.T) {
+	unknown := &Error{Code: InvalidParams, Message: "Unknown tool: x", Data: map[string]interface{}{"reason": ReasonUnknownTool}}
+	if !IsUnknownTool(unknown) {
+		t.Error("Expected an unknown tool error to match")
+	}
+	if IsUnknownTool(&Error{Code: InvalidParams, Message: "invalid arguments"}) {
+		t.Error("Expected other invalid params errors not to match")
+	}
+}
@@ -0,0 +1,29 @@
+package protocol
+
+import "encoding/base64"
+
+// TextResourceContents is a text item of a resources/read result.
+type TextResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// BlobResourceContents is a binary item of a resources/read result. Its
+// data is base64-encoded, so that any bytes survive the JSON encoding of
+// messages.
+type BlobResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Blob     string `json:"blob"`
+}
+
+// NewBlobResourceContents returns the blob contents holding data.
+func NewBlobResourceContents(uri, mimeType string, data []byte) BlobResourceContents {
+	return BlobResourceContents{URI: uri, MimeType: mimeType, Blob: base64.StdEncoding.EncodeToString(data)}
+}
+
+// Data returns the decoded data of the blob.
+func (b BlobResourceContents) Data() ([]byte, error) {
+	return base64.StdEncoding.DecodeString(b.Blob)
+}
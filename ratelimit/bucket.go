@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// TokenBuckets limits requests per key with a token bucket per key. Each
+// bucket holds up to burst tokens and refills at rate tokens per second; a
+// request takes one token, so short bursts are allowed while the average
+// stays at rate. Buckets live in process memory.
+type TokenBuckets struct {
+	rate  float64
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+	taken   int
+}
+
+// tokenBucket is the bucket of one key.
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewTokenBuckets creates buckets refilling at rate tokens per second and
+// holding up to burst tokens. A burst below one allows no requests.
+func NewTokenBuckets(rate float64, burst int) *TokenBuckets {
+	return &TokenBuckets{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// Take takes a token from the bucket of key, which starts full. Rejected
+// requests take nothing.
+func (b *TokenBuckets) Take(key string) Result {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	b.taken++
+	if b.taken%sweepEvery == 0 {
+		b.sweep(now)
+	}
+
+	bucket := b.buckets[key]
+	if bucket == nil {
+		bucket = &tokenBucket{tokens: float64(b.burst), updated: now}
+		b.buckets[key] = bucket
+	}
+	bucket.refill(now, b.rate, b.burst)
+
+	if bucket.tokens < 1 {
+		// A bucket that can never hold a whole token never allows a request
+		retryAfter := time.Duration(math.MaxInt64)
+		if b.rate > 0 && b.burst >= 1 {
+			retryAfter = time.Duration((1 - bucket.tokens) / b.rate * float64(time.Second))
+		}
+		return Result{RetryAfter: retryAfter}
+	}
+
+	bucket.tokens--
+	return Result{Allowed: true, Remaining: int(bucket.tokens)}
+}
+
+// refill adds the tokens earned since the bucket was last updated.
+func (t *tokenBucket) refill(now time.Time, rate float64, burst int) {
+	if elapsed := now.Sub(t.updated); elapsed > 0 {
+		t.tokens = math.Min(float64(burst), t.tokens+elapsed.Seconds()*rate)
+	}
+	t.updated = now
+}
+
+// sweep drops full buckets, which behave the same as buckets never seen, so
+// keys seen once don't stay in memory forever.
+func (b *TokenBuckets) sweep(now time.Time) {
+	for key, bucket := range b.buckets {
+		bucket.refill(now, b.rate, b.burst)
+		if bucket.tokens >= float64(b.burst) {
+			delete(b.buckets, key)
+		}
+	}
+}
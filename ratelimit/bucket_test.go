@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBuckets(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	buckets := NewTokenBuckets(2, 3)
+	buckets.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if result := buckets.Take("a"); !result.Allowed || result.Remaining != 2-i {
+			t.Fatalf("Request %d: expected allowed with %d remaining, got %+v", i+1, 2-i, result)
+		}
+	}
+	result := buckets.Take("a")
+	if result.Allowed {
+		t.Fatal("Expected the request after the burst to be rejected")
+	}
+	// One token comes back every half second
+	if result.RetryAfter != 500*time.Millisecond {
+		t.Errorf("Expected RetryAfter 500ms, got %v", result.RetryAfter)
+	}
+	if result := buckets.Take("b"); !result.Allowed {
+		t.Error("Expected b to have its own bucket")
+	}
+
+	now = now.Add(time.Second)
+	for i := 0; i < 2; i++ {
+		if result := buckets.Take("a"); !result.Allowed {
+			t.Fatalf("Expected the refilled token %d to be allowed", i+1)
+		}
+	}
+	if result := buckets.Take("a"); result.Allowed {
+		t.Error("Expected only the refilled tokens to be allowed")
+	}
+
+	// Buckets never hold more than the burst
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		buckets.Take("a")
+	}
+	if result := buckets.Take("a"); result.Allowed {
+		t.Error("Expected an idle bucket to refill only up to the burst")
+	}
+}
@@ -0,0 +1,188 @@
+// Package ratelimit provides rate limit stores and token buckets for MCP
+// servers.
+//
+// A Store counts requests per key over a sliding window. MemoryStore keeps
+// the counts in process memory, which is enough for a single server.
+// RedisStore keeps them in Redis, so every replica behind a load balancer
+// enforces the same limit. Both also implement CounterStore, which keeps the
+// running totals behind daily and monthly quotas.
+//
+// TokenBuckets limits a single server by an average rate while allowing
+// short bursts, which suits interactive clients better than a hard window.
+//
+// # Basic Usage
+//
+//	store := ratelimit.NewRedisStore(redis.NewClient(&redis.Options{Addr: "localhost:6379"}), "mcp:")
+//
+//	srv := server.NewServer("my-service",
+//	    server.WithRateLimitStore(store,
+//	        server.RateLimitRule{Name: "per-key", Limit: ratelimit.PerMinute(600), Key: server.RateLimitByAPIKey},
+//	    ),
+//	)
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limit is the number of requests allowed in a trailing window.
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// PerSecond returns a limit of n requests per second.
+func PerSecond(n int) Limit {
+	return Limit{Requests: n, Window: time.Second}
+}
+
+// PerMinute returns a limit of n requests per minute.
+func PerMinute(n int) Limit {
+	return Limit{Requests: n, Window: time.Minute}
+}
+
+// PerHour returns a limit of n requests per hour.
+func PerHour(n int) Limit {
+	return Limit{Requests: n, Window: time.Hour}
+}
+
+// Result is the outcome of counting a request.
+type Result struct {
+	// Allowed reports whether the request fits within the limit. Rejected
+	// requests are not counted.
+	Allowed bool
+
+	// Remaining is the number of further requests allowed in the current window.
+	Remaining int
+
+	// RetryAfter is how long to wait before a rejected request would be
+	// allowed. It is zero for allowed requests.
+	RetryAfter time.Duration
+}
+
+// Store counts requests per key over a sliding window.
+type Store interface {
+	// Allow counts a request for key if fewer than limit.Requests requests
+	// were counted in the trailing limit.Window.
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// CounterStore keeps running totals per key that expire at the end of a
+// period.
+type CounterStore interface {
+	// Add adds amount, which may be negative, to the total for key and
+	// returns the new total. A key without a total starts from zero and is
+	// removed at expiresAt.
+	Add(ctx context.Context, key string, amount float64, expiresAt time.Time) (float64, error)
+}
+
+// MemoryStore is a Store and CounterStore that keeps request times and
+// totals in process memory.
+type MemoryStore struct {
+	mu       sync.Mutex
+	keys     map[string]*memoryKey
+	counters map[string]*memoryCounter
+	now      func() time.Time
+	counted  int
+}
+
+// memoryKey holds the counted request times of one key, oldest first.
+type memoryKey struct {
+	hits   []time.Time
+	window time.Duration
+}
+
+// memoryCounter is the total of one CounterStore key.
+type memoryCounter struct {
+	total     float64
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		keys:     make(map[string]*memoryKey),
+		counters: make(map[string]*memoryCounter),
+		now:      time.Now,
+	}
+}
+
+// sweepEvery is how many counted requests pass between sweeps of idle keys.
+const sweepEvery = 1024
+
+// Allow implements Store.
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	k := s.keys[key]
+	if k == nil {
+		k = &memoryKey{}
+		s.keys[key] = k
+	}
+	k.window = limit.Window
+	k.hits = prune(k.hits, now.Add(-limit.Window))
+
+	if len(k.hits) >= limit.Requests {
+		// The request is allowed once enough of the counted ones leave the window
+		retryAfter := limit.Window
+		if limit.Requests > 0 {
+			retryAfter = k.hits[len(k.hits)-limit.Requests].Add(limit.Window).Sub(now)
+		}
+		return Result{RetryAfter: retryAfter}, nil
+	}
+
+	k.hits = append(k.hits, now)
+	s.counted++
+	if s.counted%sweepEvery == 0 {
+		s.sweep(now)
+	}
+	return Result{Allowed: true, Remaining: limit.Requests - len(k.hits)}, nil
+}
+
+// Add implements CounterStore.
+func (s *MemoryStore) Add(ctx context.Context, key string, amount float64, expiresAt time.Time) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	c := s.counters[key]
+	if c == nil || !c.expiresAt.After(now) {
+		c = &memoryCounter{expiresAt: expiresAt}
+		s.counters[key] = c
+	}
+	c.total += amount
+
+	s.counted++
+	if s.counted%sweepEvery == 0 {
+		s.sweep(now)
+	}
+	return c.total, nil
+}
+
+// sweep drops keys with no requests in their window and expired totals, so
+// keys seen once don't stay in memory forever.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, k := range s.keys {
+		if len(k.hits) == 0 || !k.hits[len(k.hits)-1].After(now.Add(-k.window)) {
+			delete(s.keys, key)
+		}
+	}
+	for key, c := range s.counters {
+		if !c.expiresAt.After(now) {
+			delete(s.counters, key)
+		}
+	}
+}
+
+// prune drops request times at or before cutoff.
+func prune(hits []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(hits) && !hits[i].After(cutoff) {
+		i++
+	}
+	return hits[i:]
+}
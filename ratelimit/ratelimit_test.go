@@ -0,0 +1,150 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// clock is a settable time source shared by the stores under test.
+type clock struct {
+	now   time.Time
+	redis *miniredis.Miniredis
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	if c.redis != nil {
+		c.redis.SetTime(c.now)
+		c.redis.FastForward(d)
+	}
+}
+
+func newStores(t *testing.T) (map[string]Store, *clock) {
+	t.Helper()
+	c := &clock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	memory := NewMemoryStore()
+	memory.now = func() time.Time { return c.now }
+
+	mr := miniredis.RunT(t)
+	mr.SetTime(c.now)
+	c.redis = mr
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return map[string]Store{
+		"memory": memory,
+		"redis":  NewRedisStore(client, "test:"),
+	}, c
+}
+
+func TestSlidingWindow(t *testing.T) {
+	stores, c := newStores(t)
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			limit := Limit{Requests: 3, Window: time.Minute}
+			key := "window-" + name
+
+			for i := 0; i < 3; i++ {
+				result, err := store.Allow(ctx, key, limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !result.Allowed || result.Remaining != 2-i {
+					t.Fatalf("Request %d: expected allowed with %d remaining, got %+v", i+1, 2-i, result)
+				}
+				c.advance(10 * time.Second)
+			}
+
+			result, err := store.Allow(ctx, key, limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Allowed {
+				t.Fatal("Expected the fourth request in the window to be rejected")
+			}
+			// The first request leaves the window 60s after it was made, 30s from now
+			if result.RetryAfter != 30*time.Second {
+				t.Errorf("Expected RetryAfter 30s, got %v", result.RetryAfter)
+			}
+
+			c.advance(30 * time.Second)
+			if result, _ := store.Allow(ctx, key, limit); !result.Allowed {
+				t.Errorf("Expected a request to be allowed once the oldest left the window, got %+v", result)
+			}
+		})
+	}
+}
+
+func TestKeysAreIndependent(t *testing.T) {
+	stores, _ := newStores(t)
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			limit := PerMinute(1)
+			if result, _ := store.Allow(ctx, "a-"+name, limit); !result.Allowed {
+				t.Fatal("Expected the first request for a to be allowed")
+			}
+			if result, _ := store.Allow(ctx, "a-"+name, limit); result.Allowed {
+				t.Fatal("Expected the second request for a to be rejected")
+			}
+			if result, _ := store.Allow(ctx, "b-"+name, limit); !result.Allowed {
+				t.Error("Expected b to have its own allowance")
+			}
+		})
+	}
+}
+
+func TestRedisStoreIsShared(t *testing.T) {
+	mr := miniredis.RunT(t)
+	replicas := make([]*RedisStore, 2)
+	for i := range replicas {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		replicas[i] = NewRedisStore(client, "shared:")
+	}
+
+	ctx := context.Background()
+	limit := PerMinute(2)
+	for i, replica := range []*RedisStore{replicas[0], replicas[1]} {
+		if result, err := replica.Allow(ctx, "key", limit); err != nil || !result.Allowed {
+			t.Fatalf("Request %d: expected allowed, got %+v, %v", i+1, result, err)
+		}
+	}
+	if result, _ := replicas[0].Allow(ctx, "key", limit); result.Allowed {
+		t.Error("Expected the limit to count requests made through both replicas")
+	}
+}
+
+func TestCounters(t *testing.T) {
+	stores, c := newStores(t)
+	for name, store := range stores {
+		counters := store.(CounterStore)
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			key := "counter-" + name
+			expiresAt := c.now.Add(time.Hour)
+
+			if total, err := counters.Add(ctx, key, 2.5, expiresAt); err != nil || total != 2.5 {
+				t.Fatalf("Expected a total of 2.5, got %v, %v", total, err)
+			}
+			if total, _ := counters.Add(ctx, key, 1.5, expiresAt.Add(time.Hour)); total != 4 {
+				t.Fatalf("Expected a total of 4, got %v", total)
+			}
+			if total, _ := counters.Add(ctx, key, -1, expiresAt); total != 3 {
+				t.Fatalf("Expected a negative amount to lower the total to 3, got %v", total)
+			}
+
+			// The expiry set when the key was created still applies
+			c.advance(time.Hour)
+			if total, _ := counters.Add(ctx, key, 1, c.now.Add(time.Hour)); total != 1 {
+				t.Errorf("Expected the total to start over after expiring, got %v", total)
+			}
+		})
+	}
+}
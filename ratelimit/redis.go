@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindow counts a request in a sorted set of request times. It runs
+// atomically and reads the clock from Redis, so replicas with skewed clocks
+// still agree on the window. Times are in microseconds.
+var slidingWindow = redis.NewScript(`
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[3])
+	redis.call('PEXPIRE', key, math.ceil(window / 1000))
+	return {1, limit - count - 1, 0}
+end
+
+local retry = window
+if limit > 0 then
+	local oldest = redis.call('ZRANGE', key, count - limit, count - limit, 'WITHSCORES')
+	retry = tonumber(oldest[2]) + window - now
+end
+return {0, 0, retry}
+`)
+
+// addToCounter adds to a total and sets its expiry when the key is new.
+// The expiry is a Unix time in milliseconds.
+var addToCounter = redis.NewScript(`
+local total = redis.call('INCRBYFLOAT', KEYS[1], ARGV[1])
+if redis.call('PTTL', KEYS[1]) < 0 then
+	redis.call('PEXPIREAT', KEYS[1], ARGV[2])
+end
+return total
+`)
+
+// RedisStore is a Store and CounterStore that keeps request times and
+// totals in Redis, so that all servers sharing the Redis instance enforce
+// limits together.
+type RedisStore struct {
+	client redis.Scripter
+	prefix string
+}
+
+// NewRedisStore creates a store that keeps its counters in Redis under keys
+// starting with prefix. The client may be a *redis.Client, a
+// *redis.ClusterClient or any other redis.Scripter.
+func NewRedisStore(client redis.Scripter, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Allow implements Store.
+func (s *RedisStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	// Requests counted in the same microsecond need distinct members
+	var member [8]byte
+	if _, err := rand.Read(member[:]); err != nil {
+		return Result{}, err
+	}
+
+	values, err := slidingWindow.Run(ctx, s.client, []string{s.prefix + key},
+		limit.Requests, limit.Window.Microseconds(), hex.EncodeToString(member[:])).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("rate limit check failed: %w", err)
+	}
+	if len(values) != 3 {
+		return Result{}, fmt.Errorf("rate limit check failed: unexpected reply %v", values)
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+	}, nil
+}
+
+// Add implements CounterStore.
+func (s *RedisStore) Add(ctx context.Context, key string, amount float64, expiresAt time.Time) (float64, error) {
+	reply, err := addToCounter.Run(ctx, s.client, []string{s.prefix + key},
+		strconv.FormatFloat(amount, 'f', -1, 64), expiresAt.UnixMilli()).Text()
+	if err != nil {
+		return 0, fmt.Errorf("counter update failed: %w", err)
+	}
+	total, err := strconv.ParseFloat(reply, 64)
+	if err != nil {
+		return 0, fmt.Errorf("counter update failed: unexpected reply %q", reply)
+	}
+	return total, nil
+}
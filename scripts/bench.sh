@@ -0,0 +1,45 @@
+#!/usr/bin/env bash
+#
+# Runs the benchmark suite and compares it with a baseline using benchstat.
+#
+#   scripts/bench.sh                 run the suite, write bench_output.txt and
+#                                    compare it with benchmarks/baseline.txt
+#   scripts/bench.sh -o new.txt      write results to new.txt instead
+#   scripts/bench.sh -b old.txt      compare with old.txt instead
+#   scripts/bench.sh -u              overwrite benchmarks/baseline.txt
+#
+# BENCH_COUNT (default 6) sets how many times each benchmark runs, which
+# benchstat needs to report confidence intervals. BENCH_TIME is passed to
+# -benchtime.
+set -euo pipefail
+
+cd "$(dirname "$0")/.."
+
+PACKAGES=(./server/test ./util/schema ./transport/sse ./transport/stdio)
+BASELINE=benchmarks/baseline.txt
+OUTPUT=bench_output.txt
+UPDATE=0
+
+while getopts "o:b:u" opt; do
+	case "$opt" in
+	o) OUTPUT="$OPTARG" ;;
+	b) BASELINE="$OPTARG" ;;
+	u) UPDATE=1 ;;
+	*) exit 2 ;;
+	esac
+done
+
+args=(-run '^$' -bench . -benchmem -count "${BENCH_COUNT:-6}")
+if [[ -n "${BENCH_TIME:-}" ]]; then
+	args+=(-benchtime "$BENCH_TIME")
+fi
+
+go test "${args[@]}" "${PACKAGES[@]}" | tee "$OUTPUT"
+
+if [[ "$UPDATE" == 1 ]]; then
+	cp "$OUTPUT" benchmarks/baseline.txt
+	echo "updated benchmarks/baseline.txt"
+	exit 0
+fi
+
+go run golang.org/x/perf/cmd/benchstat@latest "$BASELINE" "$OUTPUT"
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"strings"
+	"time"
+)
+
+// WithAdmin serves the debug endpoints of AdminHandler on a separate
+// listener at addr once Run has started the transport, so that they are
+// never exposed on the port clients connect to.
+//
+// Requests must carry "Authorization: Bearer <token>". An empty token
+// disables the check, which is only safe when addr is bound to a loopback
+// or otherwise private interface. Listener failures are logged and do not
+// stop the server.
+//
+// Example:
+//
+//	server.NewServer("weather",
+//	    server.WithAdmin("127.0.0.1:6060", os.Getenv("MCP_ADMIN_TOKEN")),
+//	).AsStreamableHTTP(":8080")
+//
+//	// curl -H "Authorization: Bearer $MCP_ADMIN_TOKEN" -o cpu.pprof \
+//	//     'http://127.0.0.1:6060/debug/pprof/profile?seconds=30'
+//	// go tool pprof -http=: cpu.pprof
+func WithAdmin(addr, token string) Option {
+	return func(s *serverImpl) {
+		s.adminAddr = addr
+		s.adminToken = token
+	}
+}
+
+// AdminHandler returns an http.Handler serving debug endpoints, for
+// mounting under a protected path of an existing HTTP server:
+//
+//   - /debug/pprof/: the net/http/pprof profiles
+//   - /debug/goroutines: a text dump of every goroutine's stack
+//   - /debug/sessions: a JSON table of open sessions with their usage
+//   - /debug/runtime: JSON runtime statistics
+//   - /health: the JSON HealthReport of the tool probes and maintenance
+//     mode, with status 503 while any probe fails
+//   - /metrics: the Prometheus metrics of WithMetrics, when its registerer
+//     is also a prometheus.Gatherer
+//
+// Requests must carry "Authorization: Bearer <token>" unless token is
+// empty.
+func (s *serverImpl) AdminHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/goroutines", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		runtimepprof.Lookup("goroutine").WriteTo(w, 2)
+	})
+	mux.HandleFunc("/debug/sessions", s.serveSessionTable)
+	mux.HandleFunc("/debug/runtime", s.serveRuntimeStats)
+	mux.HandleFunc("/health", s.serveHealth)
+	if s.metricsGatherer != nil {
+		mux.Handle("/metrics", MetricsHandler(s.metricsGatherer))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// adminSession is a row of the session table.
+type adminSession struct {
+	ID              string        `json:"id"`
+	ProtocolVersion string        `json:"protocolVersion"`
+	Created         time.Time     `json:"created"`
+	LastActive      time.Time     `json:"lastActive"`
+	Subscriptions   int           `json:"subscriptions"`
+	Pinned          int           `json:"pinned"`
+	Locale          string        `json:"locale,omitempty"`
+	RTTMs           float64       `json:"rttMs,omitempty"`
+	Usage           *UsageSummary `json:"usage,omitempty"`
+}
+
+func (s *serverImpl) serveSessionTable(w http.ResponseWriter, r *http.Request) {
+	usage := s.Usage()
+	sessions := s.sessionManager.Sessions()
+	rows := make([]adminSession, 0, len(sessions))
+	for _, session := range sessions {
+		row := adminSession{
+			ID:              string(session.ID),
+			ProtocolVersion: session.ProtocolVersion,
+			Created:         session.Created,
+			LastActive:      session.LastActive,
+			Subscriptions:   len(session.Subscriptions),
+			Pinned:          len(session.Pinned),
+			Locale:          session.Metadata[localeMetaKey],
+			RTTMs:           float64(session.RTT.Microseconds()) / 1000,
+		}
+		if summary, ok := usage.Sessions[string(session.ID)]; ok {
+			row.Usage = &summary
+		}
+		rows = append(rows, row)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"sessions": rows})
+}
+
+func (s *serverImpl) serveRuntimeStats(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"server":     s.name,
+		"goVersion":  runtime.Version(),
+		"goroutines": runtime.NumGoroutine(),
+		"cpus":       runtime.NumCPU(),
+		"memory": map[string]interface{}{
+			"heapAlloc":   mem.HeapAlloc,
+			"heapInuse":   mem.HeapInuse,
+			"heapObjects": mem.HeapObjects,
+			"sys":         mem.Sys,
+			"numGC":       mem.NumGC,
+			"pauseTotal":  time.Duration(mem.PauseTotalNs).String(),
+			"pressure":    s.MemoryPressure().String(),
+		},
+	})
+}
+
+// startAdmin starts the admin listener if one was configured with WithAdmin.
+func (s *serverImpl) startAdmin() {
+	if s.adminAddr == "" {
+		return
+	}
+
+	listener, err := net.Listen("tcp", s.adminAddr)
+	if err != nil {
+		s.logger.Warn("failed to start admin listener", "addr", s.adminAddr, "error", err)
+		return
+	}
+	admin := &http.Server{Handler: s.AdminHandler(s.adminToken), ReadHeaderTimeout: 10 * time.Second}
+
+	s.mu.Lock()
+	s.adminServer = admin
+	s.mu.Unlock()
+
+	s.logger.Info("admin endpoints listening", "addr", listener.Addr().String())
+	go func() {
+		if err := admin.Serve(listener); err != nil && err != http.ErrServerClosed {
+			s.logger.Error("admin listener stopped", "error", err)
+		}
+	}()
+}
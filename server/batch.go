@@ -0,0 +1,116 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// BatchPolicy sets how JSON-RPC batches are handled.
+type BatchPolicy struct {
+	// MaxSize is the largest number of messages accepted in a batch.
+	// Larger batches are rejected whole. It defaults to 100.
+	MaxSize int
+
+	// Workers is how many messages of a batch are handled at once. It
+	// defaults to 8.
+	Workers int
+}
+
+// WithBatchPolicy sets how JSON-RPC batch arrays are handled.
+//
+// Example:
+//
+//	srv := server.NewServer("search",
+//	    server.WithBatchPolicy(server.BatchPolicy{MaxSize: 20, Workers: 4}),
+//	)
+func WithBatchPolicy(policy BatchPolicy) Option {
+	return func(s *serverImpl) {
+		if policy.MaxSize <= 0 {
+			policy.MaxSize = 100
+		}
+		if policy.Workers <= 0 {
+			policy.Workers = 8
+		}
+		s.batchPolicy = policy
+	}
+}
+
+// isBatch reports whether a message is a JSON-RPC batch array.
+func isBatch(message []byte) bool {
+	trimmed := bytes.TrimLeft(message, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// handleBatch handles the messages of a batch concurrently, up to the
+// policy's worker count, and returns their responses in the order of the
+// requests. A batch of notifications and responses gets no reply.
+func (s *serverImpl) handleBatch(message []byte) []byte {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(message, &elements); err != nil {
+		return createErrorResponse(nil, -32700, "Parse error", err.Error())
+	}
+	if len(elements) == 0 {
+		return createErrorResponse(nil, -32600, "Invalid Request", "empty batch")
+	}
+	if len(elements) > s.batchPolicy.MaxSize {
+		return createErrorResponse(nil, -32600, "Invalid Request", "batch too large")
+	}
+
+	responses := make([][]byte, len(elements))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(s.batchPolicy.Workers, len(elements)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				responses[i] = s.handleBatchElement(elements[i])
+			}
+		}()
+	}
+	for i := range elements {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	var batch []json.RawMessage
+	for _, response := range responses {
+		if response != nil {
+			batch = append(batch, response)
+		}
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	reply, err := json.Marshal(batch)
+	if err != nil {
+		s.logger.Error("failed to marshal batch response", "error", err)
+		return createErrorResponse(nil, -32603, "Internal error", "Failed to marshal batch response")
+	}
+	return reply
+}
+
+// handleBatchElement handles one message of a batch. Elements that aren't
+// objects, nested batches and initialize requests, which must be sent
+// alone, are answered with an Invalid Request error.
+func (s *serverImpl) handleBatchElement(element json.RawMessage) []byte {
+	var request struct {
+		ID     interface{} `json:"id"`
+		Method string      `json:"method"`
+	}
+	if err := json.Unmarshal(element, &request); err != nil {
+		return createErrorResponse(nil, -32600, "Invalid Request", "batch element is not an object")
+	}
+	if request.Method == "initialize" {
+		return createErrorResponse(request.ID, -32600, "Invalid Request", "initialize must not be sent in a batch")
+	}
+
+	response, err := s.routeMessage(element)
+	if err != nil {
+		s.logger.Error("failed to handle batch element", "error", err)
+		return createErrorResponse(request.ID, -32603, "Internal error", err.Error())
+	}
+	return response
+}
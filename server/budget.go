@@ -0,0 +1,73 @@
+package server
+
+import (
+	"encoding/json"
+)
+
+// ContextBudgetCapability is the client capability, under "experimental",
+// with which a host declares how many tokens its model's context window
+// holds and how many of them a single tool result or resource should take:
+//
+//	"capabilities": {"experimental": {"contextBudget": {"contextWindow": 200000, "maxResultTokens": 8000}}}
+const ContextBudgetCapability = "experimental.contextBudget"
+
+// budgetShare is the part of its context window a result of a client that
+// declared no result budget is given, as a divisor.
+const budgetShare = 20
+
+// ClientBudget is the context budget a client declared with the
+// ContextBudgetCapability. Either field may be zero if the client left it
+// out. Budgets are approximate, counted with the client's tokenizer.
+type ClientBudget struct {
+	// ContextWindow is the size of the model's context window, in tokens.
+	ContextWindow int `json:"contextWindow,omitempty"`
+
+	// MaxResultTokens is how many tokens a single tool result or resource
+	// should take at most.
+	MaxResultTokens int `json:"maxResultTokens,omitempty"`
+}
+
+// ResultTokens returns how many tokens a single result should take:
+// MaxResultTokens if the client declared it, else a twentieth of its
+// context window, else fallback.
+func (b ClientBudget) ResultTokens(fallback int) int {
+	switch {
+	case b.MaxResultTokens > 0:
+		return b.MaxResultTokens
+	case b.ContextWindow > 0:
+		return max(b.ContextWindow/budgetShare, 1)
+	default:
+		return fallback
+	}
+}
+
+// ClientBudget returns the context budget the request's client declared at
+// initialize, so handlers can tune how verbose their results are, where
+// they truncate and how much a page holds to the host. It reports false if
+// the client declared none.
+//
+// Example:
+//
+//	srv.Tool("logs", "Show the service logs", func(ctx *server.Context, args LogsArgs) (map[string]interface{}, error) {
+//	    budget, _ := ctx.ClientBudget()
+//	    return ctx.OverflowResult(readLogs(args.Service), budget.ResultTokens(2000)), nil
+//	})
+func (c *Context) ClientBudget() (ClientBudget, bool) {
+	info, ok := c.ClientInfo()
+	if !ok || !hasCapability(info.Capabilities, ContextBudgetCapability) {
+		return ClientBudget{}, false
+	}
+	experimental, _ := info.Capabilities["experimental"].(map[string]interface{})
+	data, err := json.Marshal(experimental["contextBudget"])
+	if err != nil {
+		return ClientBudget{}, false
+	}
+	var budget ClientBudget
+	if err := json.Unmarshal(data, &budget); err != nil || budget.ContextWindow < 0 || budget.MaxResultTokens < 0 {
+		return ClientBudget{}, false
+	}
+	if budget == (ClientBudget{}) {
+		return ClientBudget{}, false
+	}
+	return budget, true
+}
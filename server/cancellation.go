@@ -1,11 +1,22 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 )
 
+// ErrRequestCancelled is the cause of a request context canceled by the
+// client with notifications/cancelled. Handlers can tell it from other
+// cancellations with context.Cause:
+//
+//	if errors.Is(context.Cause(ctx.Context()), server.ErrRequestCancelled) {
+//	    cleanup()
+//	}
+var ErrRequestCancelled = errors.New("request cancelled by client")
+
 // CancelledNotificationParams contains parameters for a cancelled notification
 type CancelledNotificationParams struct {
 	RequestID string `json:"requestId"`        // ID of the request being cancelled
@@ -16,26 +27,87 @@ type CancelledNotificationParams struct {
 type RequestCanceller struct {
 	mu            sync.RWMutex
 	cancellations map[interface{}]chan struct{} // Maps request IDs to cancellation channels
+	running       map[requestKey]context.CancelCauseFunc
+}
+
+// requestKey identifies a request in progress. Request IDs are chosen by
+// clients, so they are only unique within a session.
+type requestKey struct {
+	session SessionID
+	id      string
 }
 
 // NewRequestCanceller creates a new request canceller
 func NewRequestCanceller() *RequestCanceller {
 	return &RequestCanceller{
 		cancellations: make(map[interface{}]chan struct{}),
+		running:       make(map[requestKey]context.CancelCauseFunc),
 	}
 }
 
 // Register registers a request as cancellable and returns a channel that will be closed on cancellation
+// Registering a request again returns the same channel
 func (rc *RequestCanceller) Register(requestID interface{}) <-chan struct{} {
 	rc.mu.Lock()
 	defer rc.mu.Unlock()
 
+	if cancelCh, exists := rc.cancellations[requestID]; exists {
+		return cancelCh
+	}
+
 	// Create a cancellation channel for this request
 	cancelCh := make(chan struct{})
 	rc.cancellations[requestID] = cancelCh
 	return cancelCh
 }
 
+// start records the cancel function of a request's context until finish
+// is called, so that a notifications/cancelled can end the request.
+func (rc *RequestCanceller) start(key requestKey, cancel context.CancelCauseFunc) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.running[key] = cancel
+}
+
+// finish forgets a request once it has been answered.
+func (rc *RequestCanceller) finish(key requestKey) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	delete(rc.running, key)
+	delete(rc.cancellations, key)
+}
+
+// busy reports whether a session has requests in progress.
+func (rc *RequestCanceller) busy(session SessionID) bool {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	for key := range rc.running {
+		if key.session == session {
+			return true
+		}
+	}
+	return false
+}
+
+// cancelRunning cancels the context of a request in progress, returning
+// false if the request isn't running.
+func (rc *RequestCanceller) cancelRunning(key requestKey, reason string) bool {
+	rc.mu.Lock()
+	cancel, exists := rc.running[key]
+	delete(rc.running, key)
+	rc.mu.Unlock()
+
+	if !exists {
+		return false
+	}
+	cause := ErrRequestCancelled
+	if reason != "" {
+		cause = fmt.Errorf("%w: %s", ErrRequestCancelled, reason)
+	}
+	cancel(cause)
+	return true
+}
+
 // Cancel cancels a request by closing its cancellation channel
 // Returns true if the request was found and cancelled, false otherwise
 func (rc *RequestCanceller) Cancel(requestID interface{}, reason string) bool {
@@ -102,20 +174,23 @@ func (rc *RequestCanceller) IsCancelled(requestID interface{}) bool {
 }
 
 // HandleCancelledNotification processes a notifications/cancelled notification
-func (s *serverImpl) HandleCancelledNotification(message []byte) error {
-	// Parse the notification
+// It cancels the context of the named request of the sender's session, and
+// the response to the request is not sent
+func (s *serverImpl) HandleCancelledNotification(ctx *Context) error {
+	// Parse the notification; clients may use numbers or strings as IDs
 	var notification struct {
-		JSONRPC string                      `json:"jsonrpc"`
-		Method  string                      `json:"method"`
-		Params  CancelledNotificationParams `json:"params"`
+		Params struct {
+			RequestID interface{} `json:"requestId"`
+			Reason    string      `json:"reason,omitempty"`
+		} `json:"params"`
 	}
 
-	if err := json.Unmarshal(message, &notification); err != nil {
+	if err := json.Unmarshal(ctx.RequestBytes, &notification); err != nil {
 		return fmt.Errorf("failed to parse cancelled notification: %w", err)
 	}
 
 	// Extract the request ID
-	requestID := notification.Params.RequestID
+	requestID := stringify(notification.Params.RequestID)
 	reason := notification.Params.Reason
 
 	// Cancel the request
@@ -123,7 +198,8 @@ func (s *serverImpl) HandleCancelledNotification(message []byte) error {
 		return fmt.Errorf("invalid request ID in cancelled notification")
 	}
 
-	if cancelled := s.requestCanceller.Cancel(requestID, reason); cancelled {
+	key := requestKey{session: ctx.sessionID(), id: requestID}
+	if cancelled := s.requestCanceller.cancelRunning(key, reason); cancelled {
 		s.logger.Info("request cancelled", "requestId", requestID, "reason", reason)
 	} else {
 		s.logger.Debug("cancellation requested for unknown request", "requestId", requestID)
@@ -156,7 +232,8 @@ func (s *serverImpl) SendCancelledNotification(requestID string, reason string)
 
 	// Send the notification
 	if s.transport != nil {
-		if err := s.transport.Send(message); err != nil {
+		if err := s.sendMessage(message); err != nil {
+			s.metrics.notificationFailed("notifications/cancelled")
 			return fmt.Errorf("failed to send cancelled notification: %w", err)
 		}
 	} else {
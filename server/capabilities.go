@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CapabilityError is returned when a method requires a capability that the
+// receiving side did not advertise during initialization. The message names
+// the missing capability and how to enable it, instead of a bare
+// "method not found".
+type CapabilityError struct {
+	// Method is the JSON-RPC method that was called.
+	Method string
+
+	// Capability is the dotted path of the missing capability, e.g. "resources.subscribe".
+	Capability string
+
+	// Side is "server" or "client": whose capability is missing.
+	Side string
+
+	// Hint explains how to enable the capability.
+	Hint string
+}
+
+// Error implements the error interface.
+func (e *CapabilityError) Error() string {
+	msg := fmt.Sprintf("method %s requires the %s capability %q, which the %s did not advertise",
+		e.Method, e.Side, e.Capability, e.Side)
+	if e.Hint != "" {
+		msg += "; " + e.Hint
+	}
+	return msg
+}
+
+// Capabilities returns the capabilities the server advertises to clients in
+// its initialize response.
+//
+// Example:
+//
+//	caps := srv.Capabilities()
+//	if res, ok := caps["resources"].(map[string]interface{}); ok {
+//	    fmt.Println("subscriptions:", res["subscribe"])
+//	}
+func (s *serverImpl) Capabilities() map[string]interface{} {
+	capabilities := map[string]interface{}{
+		"logging": map[string]interface{}{},
+		"prompts": map[string]interface{}{
+			"listChanged": true,
+		},
+		"resources": map[string]interface{}{
+			"subscribe":   s.resourceSubscriptions,
+			"listChanged": true,
+		},
+		"tools": map[string]interface{}{
+			"listChanged": true,
+		},
+	}
+	if s.hasCompletions() {
+		capabilities["completions"] = map[string]interface{}{}
+	}
+	if s.uploads != nil {
+		capabilities["experimental"] = map[string]interface{}{
+			"uploads": map[string]interface{}{
+				"maxSize":   s.uploads.options.MaxSize,
+				"chunkSize": UploadChunkSize,
+			},
+		}
+	}
+	return capabilities
+}
+
+// requireClientCapability returns a CapabilityError if the client of the
+// given session declared its capabilities at initialize without including
+// the one a server-initiated method needs. Sessions that never declared
+// capabilities are not gated.
+func (s *serverImpl) requireClientCapability(sessionID SessionID, method, capability, hint string) error {
+	clientInfo, found := s.getClientInfoForSession(sessionID)
+	if !found || clientInfo.Capabilities == nil {
+		return nil
+	}
+	if hasCapability(clientInfo.Capabilities, capability) {
+		return nil
+	}
+	return &CapabilityError{Method: method, Capability: capability, Side: "client", Hint: hint}
+}
+
+// hasCapability reports whether a dotted capability path is present and not
+// explicitly disabled in a capabilities object.
+func hasCapability(capabilities map[string]interface{}, path string) bool {
+	var current interface{} = capabilities
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if current, ok = object[key]; !ok {
+			return false
+		}
+	}
+	enabled, isBool := current.(bool)
+	return !isBool || enabled
+}
+
+// extractClientCapabilities returns the capabilities object from initialize
+// params, and whether the client sent one.
+func extractClientCapabilities(params json.RawMessage) (map[string]interface{}, bool) {
+	if len(params) == 0 {
+		return nil, false
+	}
+
+	var p struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || p.Capabilities == nil {
+		return nil, false
+	}
+	return p.Capabilities, true
+}
+
+// extractClientIdentity returns the name and version from the clientInfo of
+// initialize params.
+func extractClientIdentity(params json.RawMessage) (name, version string) {
+	var p struct {
+		ClientInfo struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"clientInfo"`
+	}
+	if len(params) > 0 {
+		json.Unmarshal(params, &p)
+	}
+	return p.ClientInfo.Name, p.ClientInfo.Version
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/localrivet/gomcp/util/textutil"
+)
+
+// OverflowURIPrefix starts the URIs of the built-in resources holding the
+// parts of tool results that OverflowResult moved out of them.
+const OverflowURIPrefix = "session://overflow/"
+
+// maxOverflowResults is how many results' overflow the server keeps, across
+// sessions; older ones can no longer be read.
+const maxOverflowResults = 256
+
+// ChunkedResult returns a tool result holding text as text content blocks
+// of at most maxTokens tokens each, split by textutil.Chunk with overlap
+// tokens repeated between blocks. Hosts that cap the size of content blocks
+// rather than of results can then show the whole text.
+//
+// Example:
+//
+//	srv.Tool("diff", "Show the changes of a commit", func(ctx *server.Context, args DiffArgs) (map[string]interface{}, error) {
+//	    diff, err := repo.Diff(args.Commit)
+//	    if err != nil {
+//	        return nil, err
+//	    }
+//	    return server.ChunkedResult(diff, 4000, 0), nil
+//	})
+func ChunkedResult(text string, maxTokens, overlap int) map[string]interface{} {
+	chunks := textutil.Chunk(text, maxTokens, overlap, nil)
+	content := make([]map[string]interface{}, 0, max(len(chunks), 1))
+	for _, chunk := range chunks {
+		content = append(content, map[string]interface{}{"type": "text", "text": chunk})
+	}
+	if len(content) == 0 {
+		content = append(content, map[string]interface{}{"type": "text", "text": " "})
+	}
+	return map[string]interface{}{"content": content}
+}
+
+// OverflowResult returns a tool result holding the first maxTokens tokens
+// of text, and links to the rest, split into parts of maxTokens tokens, as
+// resources under OverflowURIPrefix. The model reads further parts with
+// resources/read only when it needs them, instead of the whole text
+// filling the host's context. Text within maxTokens is returned whole.
+//
+// The parts can be read by the session that called the tool only, until
+// results of 256 later calls have overflowed.
+//
+// Example:
+//
+//	srv.Tool("logs", "Show the service logs", func(ctx *server.Context, args LogsArgs) (map[string]interface{}, error) {
+//	    return ctx.OverflowResult(readLogs(args.Service), 2000), nil
+//	})
+func (c *Context) OverflowResult(text string, maxTokens int) map[string]interface{} {
+	chunks := textutil.Chunk(text, maxTokens, 0, nil)
+	if len(chunks) <= 1 || c.server == nil {
+		return ChunkedResult(text, 0, 0)
+	}
+
+	id, err := c.server.overflow.add(c.sessionID(), chunks[1:])
+	if err != nil {
+		c.server.logger.Warn("failed to keep overflowing tool result", "error", err)
+		return ChunkedResult(textutil.Truncate(text, maxTokens, nil), 0, 0)
+	}
+
+	content := []map[string]interface{}{
+		{"type": "text", "text": fmt.Sprintf("%s\n\n[Part 1 of %d. Read the resources linked below for the rest.]", chunks[0], len(chunks))},
+	}
+	for part := 2; part <= len(chunks); part++ {
+		content = append(content, map[string]interface{}{
+			"type":  "link",
+			"url":   overflowURI(id, part),
+			"title": fmt.Sprintf("Part %d of %d", part, len(chunks)),
+		})
+	}
+	return map[string]interface{}{"content": content}
+}
+
+// Summarizer returns a textutil.Summarizer that asks the client's language
+// model for summaries with sampling, for textutil.Fit to shorten long tool
+// output with. The client must support sampling.
+func (c *Context) Summarizer() textutil.Summarizer {
+	return func(ctx context.Context, text string, maxTokens int) (string, error) {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		prompt := fmt.Sprintf("Summarize the following text in at most %d tokens, keeping names, numbers, errors and conclusions:\n\n%s", maxTokens, text)
+		resp, err := c.RequestSampling(
+			[]SamplingMessage{CreateTextSamplingMessage("user", prompt)},
+			SamplingModelPreferences{},
+			"You summarize tool output for another model. Reply with the summary only.",
+			maxTokens,
+		)
+		if err != nil {
+			return "", fmt.Errorf("failed to summarize: %w", err)
+		}
+		if resp.Content.Type != "text" {
+			return "", fmt.Errorf("failed to summarize: client returned %s content", resp.Content.Type)
+		}
+		return resp.Content.Text, nil
+	}
+}
+
+// overflowStore keeps the overflowing parts of tool results, dropping the
+// oldest past maxOverflowResults.
+type overflowStore struct {
+	mu      sync.Mutex
+	results map[string]overflowResult
+	order   []string
+}
+
+// overflowResult is the overflow of one tool result.
+type overflowResult struct {
+	session SessionID
+	parts   []string // parts 2 and on
+}
+
+// add keeps the overflowing parts of a session's tool result, returning
+// their ID.
+func (o *overflowStore) add(session SessionID, parts []string) (string, error) {
+	var random [8]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(random[:])
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.results == nil {
+		o.results = make(map[string]overflowResult)
+	}
+	o.results[id] = overflowResult{session: session, parts: parts}
+	o.order = append(o.order, id)
+	if len(o.order) > maxOverflowResults {
+		delete(o.results, o.order[0])
+		o.order = o.order[1:]
+	}
+	return id, nil
+}
+
+// part returns a part of a session's tool result.
+func (o *overflowStore) part(session SessionID, id string, part int) (string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	result, ok := o.results[id]
+	if !ok || result.session != session || part < 2 || part-2 >= len(result.parts) {
+		return "", false
+	}
+	return result.parts[part-2], true
+}
+
+// overflowURI returns the URI of a part of an overflowing result.
+func overflowURI(id string, part int) string {
+	return OverflowURIPrefix + id + "/" + strconv.Itoa(part)
+}
+
+// readOverflow serves the resources/read of a part of an overflowing
+// result.
+func (s *serverImpl) readOverflow(ctx *Context, uri string) (interface{}, error) {
+	id, partText, _ := strings.Cut(strings.TrimPrefix(uri, OverflowURIPrefix), "/")
+	part, err := strconv.Atoi(partText)
+	if err != nil {
+		return nil, fmt.Errorf("resource not found: %s", uri)
+	}
+	text, ok := s.overflow.part(ctx.sessionID(), id, part)
+	if !ok {
+		return nil, fmt.Errorf("resource not found: %s", uri)
+	}
+	return map[string]interface{}{
+		"contents": []interface{}{
+			map[string]interface{}{
+				"uri":      uri,
+				"mimeType": "text/plain",
+				"text":     text,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"encoding/json"
+
+	"github.com/localrivet/gomcp/mcp/compat"
+)
+
+// upgradeLegacyParams rewrites request params sent by peers built against
+// older protocol revisions, logging a deprecation warning for each legacy
+// field instead of failing to decode the request.
+func (s *serverImpl) upgradeLegacyParams(ctx *Context) {
+	if len(ctx.Request.Params) == 0 {
+		return
+	}
+
+	var params map[string]interface{}
+	if err := json.Unmarshal(ctx.Request.Params, &params); err != nil {
+		return
+	}
+
+	deprecations := compat.UpgradeRequest(ctx.Request.Method, params)
+	if len(deprecations) == 0 {
+		return
+	}
+	for _, d := range deprecations {
+		s.logger.Warn("deprecated request field", "method", ctx.Request.Method, "warning", d.String())
+	}
+
+	upgraded, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+	ctx.Request.Params = upgraded
+}
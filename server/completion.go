@@ -1,19 +1,149 @@
 package server
 
+import (
+	"encoding/json"
+)
+
+// maxCompletionValues is the most values a completion/complete response
+// carries, as set by the protocol.
+const maxCompletionValues = 100
+
+// CompletionFunc suggests values for the argument named arg of a prompt, or
+// the variable named arg of a resource template, given the prefix the user
+// has typed so far. Suggestions are returned best first.
+type CompletionFunc func(arg, prefix string) []string
+
+// WithCompletions answers completion/complete requests for the arguments of
+// prompts and resource templates that have no provider registered with
+// Completion, and advertises the completions capability to clients.
+//
+// Example:
+//
+//	srv := server.NewServer("deploy",
+//	    server.WithCompletions(func(arg, prefix string) []string {
+//	        if arg != "environment" {
+//	            return nil
+//	        }
+//	        return matching([]string{"staging", "production"}, prefix)
+//	    }),
+//	)
+func WithCompletions(fn CompletionFunc) Option {
+	return func(s *serverImpl) {
+		s.defaultCompletion = fn
+	}
+}
+
+// Completion registers the provider that suggests argument values for one
+// prompt, given by name, or one resource template, given by its URI
+// template. Registering a provider advertises the completions capability to
+// clients.
+//
+// Example:
+//
+//	srv.Completion("code_review", func(arg, prefix string) []string {
+//	    if arg == "language" {
+//	        return matching(languages, prefix)
+//	    }
+//	    return nil
+//	})
+func (s *serverImpl) Completion(ref string, fn CompletionFunc) Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.completions == nil {
+		s.completions = make(map[string]CompletionFunc)
+	}
+	s.completions[ref] = fn
+	return s
+}
+
+// hasCompletions reports whether the server can answer completion requests.
+func (s *serverImpl) hasCompletions() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.defaultCompletion != nil || len(s.completions) > 0
+}
+
+// completionProvider returns the provider for a prompt or resource template,
+// falling back to the one set with WithCompletions.
+func (s *serverImpl) completionProvider(ref string) CompletionFunc {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if fn, ok := s.completions[ref]; ok {
+		return fn
+	}
+	return s.defaultCompletion
+}
+
 // ProcessCompletionComplete processes a completion request from the client.
-// This method handles requests for text completion operations, which allow clients
-// to receive completion suggestions for partially typed content.
+// This method suggests values for an argument of a prompt (ref/prompt) or a
+// variable of a resource template (ref/resource), using the provider
+// registered for it.
 //
 // Parameters:
 //   - ctx: The request context containing client information and request details
 //
 // Returns:
-//   - A response containing completion suggestions
-//   - An error if the completion operation fails
-//
-// Note: This is currently a placeholder implementation that will be expanded
-// in future versions of the protocol.
+//   - A response with up to 100 suggested values, their total and whether more exist
+//   - An error if the request is invalid or the server has no completion providers
 func (s *serverImpl) ProcessCompletionComplete(ctx *Context) (interface{}, error) {
-	// TODO: Implement completion
-	return map[string]interface{}{"completions": []interface{}{}}, nil
+	if !s.hasCompletions() {
+		return nil, &CapabilityError{
+			Method:     ctx.Request.Method,
+			Capability: "completions",
+			Side:       "server",
+			Hint:       "register a provider with server.WithCompletions or Completion",
+		}
+	}
+
+	var params struct {
+		Ref struct {
+			Type string `json:"type"`
+			Name string `json:"name"`
+			URI  string `json:"uri"`
+		} `json:"ref"`
+		Argument struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"argument"`
+	}
+	if len(ctx.Request.Params) == 0 {
+		return nil, NewInvalidParametersError("missing completion parameters")
+	}
+	if err := json.Unmarshal(ctx.Request.Params, &params); err != nil {
+		return nil, NewInvalidParametersError("invalid completion parameters: " + err.Error())
+	}
+
+	var ref string
+	switch params.Ref.Type {
+	case "ref/prompt":
+		ref = params.Ref.Name
+	case "ref/resource":
+		ref = params.Ref.URI
+	default:
+		return nil, NewInvalidParametersError("unsupported completion reference type: " + params.Ref.Type)
+	}
+	if ref == "" || params.Argument.Name == "" {
+		return nil, NewInvalidParametersError("completion requires a reference and an argument name")
+	}
+
+	var values []string
+	if fn := s.completionProvider(ref); fn != nil {
+		values = fn(params.Argument.Name, params.Argument.Value)
+	}
+
+	total := len(values)
+	if total > maxCompletionValues {
+		values = values[:maxCompletionValues]
+	}
+	if values == nil {
+		values = []string{}
+	}
+	return map[string]interface{}{
+		"completion": map[string]interface{}{
+			"values":  values,
+			"total":   total,
+			"hasMore": total > maxCompletionValues,
+		},
+	}, nil
 }
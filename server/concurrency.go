@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Go runs fns concurrently and waits for them to finish. The context
+// passed to them is canceled when the request is canceled or one of them
+// fails, so the rest can stop early. Go returns the errors of the failed
+// functions joined, leaving out the cancellations it caused, and nil if all
+// of them succeeded. A panic in a function is returned as its error.
+//
+// Progress is reported to the client as the functions finish.
+//
+// Example:
+//
+//	var weather, traffic string
+//	err := server.Go(ctx,
+//	    func(ctx context.Context) (err error) { weather, err = fetchWeather(ctx, city); return },
+//	    func(ctx context.Context) (err error) { traffic, err = fetchTraffic(ctx, city); return },
+//	)
+func Go(ctx *Context, fns ...func(ctx context.Context) error) error {
+	return Parallel(ctx, 0, fns, func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	})
+}
+
+// Parallel calls fn for each item, with at most limit calls running at
+// once, or all of them if limit is zero or less. When the request is
+// canceled or a call fails, the context passed to running calls is
+// canceled and the remaining items are skipped. Parallel returns the
+// errors of the failed calls joined, leaving out the cancellations it
+// caused, or the request's error if it was canceled first. A panic in fn
+// is returned as the error of its call.
+//
+// Progress is reported to the client as the calls finish.
+//
+// Example:
+//
+//	err := server.Parallel(ctx, 4, args.AccountIDs, func(ctx context.Context, id string) error {
+//	    return syncAccount(ctx, id)
+//	})
+func Parallel[T any](ctx *Context, limit int, items []T, fn func(ctx context.Context, item T) error) error {
+	if limit <= 0 || limit > len(items) {
+		limit = len(items)
+	}
+	runCtx, cancel := context.WithCancel(ctx.Context())
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		errs     []error
+		finished int
+		wg       sync.WaitGroup
+	)
+	done := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			// Calls stopped by an earlier failure or by the request aren't
+			// failures themselves
+			canceled := errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+			if runCtx.Err() == nil || !canceled {
+				errs = append(errs, err)
+			}
+			cancel()
+		}
+		finished++
+		ctx.ReportProgress(fmt.Sprintf("%d of %d done", finished, len(items)), float64(finished), float64(len(items)))
+	}
+
+	slots := make(chan struct{}, max(limit, 1))
+	for _, item := range items {
+		select {
+		case slots <- struct{}{}:
+		case <-runCtx.Done():
+		}
+		if runCtx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(item T) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			done(callParallel(runCtx, item, fn))
+		}(item)
+	}
+	wg.Wait()
+
+	if len(errs) == 0 {
+		// Canceled by the request before anything failed
+		return ctx.Context().Err()
+	}
+	return errors.Join(errs...)
+}
+
+// callParallel calls fn, turning a panic into an error.
+func callParallel[T any](ctx context.Context, item T, fn func(context.Context, T) error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("parallel call panicked: %v", recovered)
+		}
+	}()
+	return fn(ctx, item)
+}
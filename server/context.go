@@ -5,6 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"sync"
+
+	"github.com/localrivet/gomcp/auth"
+	"github.com/localrivet/gomcp/protocol"
 )
 
 // Context represents the execution context for a server request.
@@ -39,6 +43,21 @@ type Context struct {
 
 	// Metadata for storing contextual information during request processing
 	Metadata map[string]interface{}
+
+	// handlerErr is the error a tool handler returned, kept for error
+	// reporting after it has been turned into an isError result
+	handlerErr error
+
+	// stream holds the content a tool handler streamed with StreamContent
+	stream     *contentStream
+	streamOnce sync.Once
+
+	// principal is the caller, as set with SetPrincipal
+	principal *auth.Principal
+
+	// connectionID names the connection the request arrived on, on
+	// transports that serve several clients
+	connectionID string
 }
 
 // Request represents an incoming JSON-RPC 2.0 request.
@@ -76,14 +95,9 @@ type Response struct {
 
 // RPCError represents a JSON-RPC 2.0 error object.
 // It includes a numeric error code, a human-readable message, and optional additional data.
-// Error codes follow the JSON-RPC 2.0 specification: -32700 for parse errors,
-// -32600 for invalid requests, -32601 for method not found, -32602 for invalid params,
-// and -32603 for internal errors.
-type RPCError struct {
-	Code    int         `json:"code"`    // Error code
-	Message string      `json:"message"` // Error message
-	Data    interface{} `json:"data,omitempty"`
-}
+// Handlers and middleware return one to answer with its code; the codes are
+// defined in the protocol package, which clients use to branch on them.
+type RPCError = protocol.Error
 
 // NewContext creates a new request context for processing an incoming request.
 // It parses the request bytes, initializes response structures, and extracts method-specific
@@ -189,6 +203,50 @@ func stringify(id interface{}) string {
 	}
 }
 
+// sessionID returns the ID of the session this request belongs to.
+// It prefers the session recorded in the request metadata and falls back
+// to the server's default session for transports that don't track sessions.
+func (c *Context) sessionID() SessionID {
+	if sessionVal, ok := c.Metadata["sessionID"]; ok {
+		if sessionIDStr, ok := sessionVal.(string); ok && sessionIDStr != "" {
+			return SessionID(sessionIDStr)
+		}
+	}
+
+	if c.server == nil {
+		return ""
+	}
+
+	c.server.mu.RLock()
+	defer c.server.mu.RUnlock()
+	if c.server.defaultSession != nil {
+		return c.server.defaultSession.ID
+	}
+	return ""
+}
+
+// ClientInfo returns what the request's client declared at initialize: the
+// name and version of the host application, the negotiated protocol version
+// and its capabilities. It reports false for requests outside an
+// initialized session. The capabilities map is shared and must not be
+// modified.
+//
+// Example:
+//
+//	if info, ok := ctx.ClientInfo(); ok && info.Name == "acme-desktop" {
+//	    return richResult, nil
+//	}
+func (c *Context) ClientInfo() (ClientInfo, bool) {
+	if c.server == nil || c.server.sessionManager == nil {
+		return ClientInfo{}, false
+	}
+	session, ok := c.server.sessionManager.GetSession(c.sessionID())
+	if !ok || !session.initialized {
+		return ClientInfo{}, false
+	}
+	return session.ClientInfo, true
+}
+
 // Done returns a channel that's closed when this context is canceled.
 // This method implements part of the standard Go context.Context interface,
 // allowing the Context to be used with functions expecting a cancellable context.
@@ -214,6 +272,16 @@ func (c *Context) Value(key interface{}) interface{} {
 	return c.ctx.Value(key)
 }
 
+// Context returns the request's standard context.Context, for passing to
+// functions that take one. With WithTracerProvider it carries the span of
+// the request, so calls made with it join the request's trace.
+func (c *Context) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
+
 // ExecuteTool provides a convenient way to execute a tool from within another tool handler.
 // This is useful for tool composition and internal tool calls when one tool needs to
 // invoke another as part of its implementation. The method handles parameter validation
@@ -421,6 +489,41 @@ func (c *Context) RequestSampling(messages []SamplingMessage, preferences Sampli
 	return c.server.RequestSamplingFromContext(c, messages, preferences, systemPrompt, maxTokens)
 }
 
+// CreateMessage asks the connected client to generate a message with its
+// language model by sending it a sampling/createMessage request, and waits
+// for the reply. Unlike RequestSampling it takes the full request, including
+// temperature, stop sequences and context inclusion.
+//
+// The client must have declared the sampling capability; clients built with
+// this module do so when given a handler with client.OnSamplingRequest. The
+// wait ends early if the request that is calling CreateMessage is cancelled.
+//
+// Example:
+//
+//	reply, err := ctx.CreateMessage(server.SamplingCreateMessageParams{
+//	    Messages:     []server.SamplingMessage{server.CreateTextSamplingMessage("user", "Summarize: "+text)},
+//	    SystemPrompt: "You are a concise technical writer.",
+//	    MaxTokens:    200,
+//	})
+//	if err != nil {
+//	    return nil, err
+//	}
+//	return reply.Content.Text, nil
+func (c *Context) CreateMessage(params SamplingCreateMessageParams) (*SamplingResponse, error) {
+	if c.server == nil {
+		return nil, fmt.Errorf("server not available in context")
+	}
+	if len(params.Messages) == 0 {
+		return nil, fmt.Errorf("sampling request has no messages")
+	}
+
+	parent := c.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	return c.server.createMessage(parent, c.sessionID(), c.Version, params, DefaultSamplingOptions())
+}
+
 // RequestSamplingWithPriority sends a sampling request with a specific priority level.
 // The priority affects timeout and retry behavior according to the server's configuration.
 // Higher priority levels typically get more generous timeout and retry settings, while
@@ -520,7 +623,7 @@ func (c *Context) IsCancelled() bool {
 
 	// Then check MCP cancellation
 	if c.RequestID != "" && c.server != nil && c.server.requestCanceller != nil {
-		return c.server.requestCanceller.IsCancelled(c.RequestID)
+		return c.server.requestCanceller.IsCancelled(c.requestKey())
 	}
 
 	return false
@@ -542,14 +645,24 @@ func (c *Context) CheckCancellation() error {
 
 // RegisterForCancellation registers this context's request to be cancellable
 // Returns a channel that will be closed if the request is cancelled
+// For requests handled by the server this is the channel of Done
 func (c *Context) RegisterForCancellation() <-chan struct{} {
+	if done := c.Context().Done(); done != nil {
+		return done
+	}
 	if c.RequestID == "" || c.server == nil || c.server.requestCanceller == nil {
 		// Return a never-closing channel if we can't register properly
 		ch := make(chan struct{})
 		return ch
 	}
 
-	return c.server.requestCanceller.Register(c.RequestID)
+	return c.server.requestCanceller.Register(c.requestKey())
+}
+
+// requestKey returns the key of this context's request among the requests
+// in progress.
+func (c *Context) requestKey() requestKey {
+	return requestKey{session: c.sessionID(), id: c.RequestID}
 }
 
 // CancelRequest sends a cancellation notification for this context's request
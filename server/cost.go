@@ -0,0 +1,245 @@
+package server
+
+import (
+	"errors"
+	"sync"
+)
+
+// ToolCost is what a single call to a tool is billed at.
+type ToolCost struct {
+	// Credits charged for each successful call.
+	Credits float64 `json:"credits"`
+
+	// Tier is the pricing tier the tool belongs to, such as "standard" or
+	// "premium". Usage reports break credits down by tier.
+	Tier string `json:"tier,omitempty"`
+}
+
+// WithCost sets what a call to a tool is billed at. The cost is advertised
+// to clients in the tool's "cost" annotation, and successful calls add
+// cost.Credits to the caller's usage. Calls that fail are counted as errors
+// but are not charged.
+// The function returns the server instance to allow for method chaining.
+func (s *serverImpl) WithCost(toolName string, cost ToolCost) Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tool, exists := s.tools[toolName]
+	if !exists {
+		s.logger.Error("tool not found for cost", "name", toolName)
+		return s
+	}
+
+	tool.Cost = &cost
+	annotation := map[string]interface{}{"credits": cost.Credits}
+	if cost.Tier != "" {
+		annotation["tier"] = cost.Tier
+	}
+	tool.Annotations["cost"] = annotation
+	s.toolsChanged = true
+
+	return s
+}
+
+// ToolUsage counts the calls made to one tool.
+type ToolUsage struct {
+	Calls   int     `json:"calls"`
+	Errors  int     `json:"errors"`
+	Credits float64 `json:"credits"`
+}
+
+// UsageSummary totals the tool calls made by one session or API key.
+type UsageSummary struct {
+	ToolUsage
+
+	// Tools breaks the totals down by tool name.
+	Tools map[string]ToolUsage `json:"tools"`
+
+	// Tiers breaks the credits down by pricing tier. Tools without a tier
+	// are not included.
+	Tiers map[string]float64 `json:"tiers,omitempty"`
+}
+
+// UsageReport is the usage counted by a server, for chargeback and
+// metering exports.
+type UsageReport struct {
+	Total UsageSummary `json:"total"`
+
+	// Sessions holds the usage of each client session, keyed by session ID.
+	Sessions map[string]UsageSummary `json:"sessions"`
+
+	// APIKeys holds the usage of each API key, keyed by a hash of the key
+	// that matches the one used by RateLimitByAPIKey. Calls made without a
+	// key are only counted under their session.
+	APIKeys map[string]UsageSummary `json:"apiKeys"`
+}
+
+// usageMeter accumulates the counts behind UsageReport.
+type usageMeter struct {
+	mu       sync.Mutex
+	total    UsageSummary
+	sessions map[string]*UsageSummary
+	apiKeys  map[string]*UsageSummary
+}
+
+// add counts a call to toolName in summary.
+func (u *UsageSummary) add(toolName string, cost *ToolCost, failed bool) {
+	if u.Tools == nil {
+		u.Tools = make(map[string]ToolUsage)
+	}
+	tool := u.Tools[toolName]
+	tool.Calls++
+	u.Calls++
+	if failed {
+		tool.Errors++
+		u.Errors++
+	} else if cost != nil {
+		tool.Credits += cost.Credits
+		u.Credits += cost.Credits
+		if cost.Tier != "" {
+			if u.Tiers == nil {
+				u.Tiers = make(map[string]float64)
+			}
+			u.Tiers[cost.Tier] += cost.Credits
+		}
+	}
+	u.Tools[toolName] = tool
+}
+
+// copy returns a deep copy of the summary, safe to hand to callers.
+func (u *UsageSummary) copy() UsageSummary {
+	c := UsageSummary{ToolUsage: u.ToolUsage, Tools: make(map[string]ToolUsage, len(u.Tools))}
+	for name, tool := range u.Tools {
+		c.Tools[name] = tool
+	}
+	if u.Tiers != nil {
+		c.Tiers = make(map[string]float64, len(u.Tiers))
+		for tier, credits := range u.Tiers {
+			c.Tiers[tier] = credits
+		}
+	}
+	return c
+}
+
+// meterToolCall counts a tools/call request under its session and API key,
+// and reports whether the call was charged. Calls rejected with a protocol
+// error, such as for an unknown tool, are not counted.
+func (s *serverImpl) meterToolCall(ctx *Context, result interface{}, err error) bool {
+	if err != nil || ctx.Request == nil {
+		return false
+	}
+	failed := false
+	if formatted, ok := result.(map[string]interface{}); ok {
+		failed, _ = formatted["isError"].(bool)
+	}
+
+	var cost *ToolCost
+	s.mu.RLock()
+	if tool, ok := s.tools[ctx.Request.ToolName]; ok {
+		cost = tool.Cost
+	}
+	s.mu.RUnlock()
+
+	s.usage.record(string(ctx.sessionID()), apiKeyID(ctx.Meta().APIKey()), ctx.Request.ToolName, cost, failed)
+	return !failed
+}
+
+// record counts a call in the totals and under the session and key, if set.
+func (m *usageMeter) record(sessionID, keyID, toolName string, cost *ToolCost, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.total.add(toolName, cost, failed)
+	if sessionID != "" {
+		if m.sessions == nil {
+			m.sessions = make(map[string]*UsageSummary)
+		}
+		if m.sessions[sessionID] == nil {
+			m.sessions[sessionID] = &UsageSummary{}
+		}
+		m.sessions[sessionID].add(toolName, cost, failed)
+	}
+	if keyID != "" {
+		if m.apiKeys == nil {
+			m.apiKeys = make(map[string]*UsageSummary)
+		}
+		if m.apiKeys[keyID] == nil {
+			m.apiKeys[keyID] = &UsageSummary{}
+		}
+		m.apiKeys[keyID].add(toolName, cost, failed)
+	}
+}
+
+// Usage returns the tool calls and credits counted since the server started
+// or ResetUsage was last called.
+func (s *serverImpl) Usage() UsageReport {
+	return s.usage.report(false)
+}
+
+// ResetUsage clears the usage counters.
+func (s *serverImpl) ResetUsage() {
+	s.usage.report(true)
+}
+
+// report copies the counters, clearing them afterwards if reset is set.
+func (m *usageMeter) report(reset bool) UsageReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	report := UsageReport{
+		Total:    m.total.copy(),
+		Sessions: make(map[string]UsageSummary, len(m.sessions)),
+		APIKeys:  make(map[string]UsageSummary, len(m.apiKeys)),
+	}
+	for id, summary := range m.sessions {
+		report.Sessions[id] = summary.copy()
+	}
+	for id, summary := range m.apiKeys {
+		report.APIKeys[id] = summary.copy()
+	}
+
+	if reset {
+		m.total = UsageSummary{}
+		m.sessions = nil
+		m.apiKeys = nil
+	}
+	return report
+}
+
+// usageToolArgs are the arguments of the tool registered by WithUsageTool.
+type usageToolArgs struct {
+	Session string `json:"session,omitempty" description:"Only report the usage of this session ID"`
+	APIKey  string `json:"apiKey,omitempty" description:"Only report the usage of this API key hash"`
+	Reset   bool   `json:"reset,omitempty" description:"Clear the counters after reporting them"`
+}
+
+// WithUsageTool registers an admin tool under name that returns the server's
+// UsageReport, so operators can pull chargeback data over MCP. authorize is
+// called for every call and must return true for the report to be returned;
+// pass nil only when the transport is already restricted to operators.
+//
+// Example:
+//
+//	srv := server.NewServer("billing",
+//	    server.WithUsageTool("admin_usage", func(ctx *server.Context) bool {
+//	        return ctx.Meta().APIKey() == adminKey
+//	    }),
+//	)
+func WithUsageTool(name string, authorize func(ctx *Context) bool) Option {
+	return func(s *serverImpl) {
+		s.Tool(name, "Report tool calls and credits per session and per API key", func(ctx *Context, args usageToolArgs) (interface{}, error) {
+			if authorize != nil && !authorize(ctx) {
+				return nil, errors.New("not authorized to read usage")
+			}
+
+			report := s.usage.report(args.Reset)
+			if args.Session != "" {
+				report.Sessions = map[string]UsageSummary{args.Session: report.Sessions[args.Session]}
+			}
+			if args.APIKey != "" {
+				report.APIKeys = map[string]UsageSummary{args.APIKey: report.APIKeys[args.APIKey]}
+			}
+			return report, nil
+		})
+	}
+}
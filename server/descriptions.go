@@ -0,0 +1,67 @@
+package server
+
+import "github.com/localrivet/gomcp/util/schema"
+
+// DescriptionPolicy controls the registration-time check on tool parameter
+// descriptions.
+type DescriptionPolicy int
+
+const (
+	// DescriptionsUnchecked registers tools without looking at their
+	// parameter descriptions. This is the default.
+	DescriptionsUnchecked DescriptionPolicy = iota
+
+	// DescriptionsWarn logs a warning for each poorly described parameter
+	// and registers the tool anyway.
+	DescriptionsWarn
+
+	// DescriptionsStrict logs an error and refuses to register a tool with
+	// any poorly described parameter.
+	DescriptionsStrict
+)
+
+// WithDescriptionPolicy checks tool parameters when tools are registered.
+// A parameter fails the check if it has no description, its description is
+// shorter than minLength characters, or it is an enum whose description does
+// not mention any of the allowed values. See schema.LintDescriptions.
+//
+// Example:
+//
+//	srv := server.NewServer("weather",
+//	    server.WithDescriptionPolicy(server.DescriptionsStrict, 15),
+//	)
+func WithDescriptionPolicy(policy DescriptionPolicy, minLength int) Option {
+	return func(s *serverImpl) {
+		s.descriptionPolicy = policy
+		s.descriptionRules = schema.DescriptionRules{MinLength: minLength}
+	}
+}
+
+// checkDescriptions applies the description policy to a tool about to be
+// registered and reports whether registration may go ahead.
+func (s *serverImpl) checkDescriptions(name string, inputSchema map[string]interface{}) bool {
+	if s.descriptionPolicy == DescriptionsUnchecked {
+		return true
+	}
+
+	issues := schema.LintDescriptions(schema.Tool{Name: name, InputSchema: inputSchema}, s.descriptionRules)
+	if len(issues) == 0 {
+		return true
+	}
+
+	if s.descriptionPolicy == DescriptionsStrict {
+		problems := make([]string, len(issues))
+		for i, issue := range issues {
+			problems[i] = issue.Path + ": " + issue.Message
+		}
+		s.logger.Error("tool parameters are not described well enough, not registering",
+			"name", name, "problems", problems)
+		return false
+	}
+
+	for _, issue := range issues {
+		s.logger.Warn("poorly described tool parameter",
+			"name", name, "path", issue.Path, "problem", issue.Message, "fix", issue.Fix)
+	}
+	return true
+}
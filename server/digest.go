@@ -0,0 +1,94 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// digestMetaKey is the _meta key holding a content item's digest.
+const digestMetaKey = "sha256"
+
+// WithContentDigests adds the hex-encoded SHA-256 digest of each content
+// item of resources/read and tools/call results to its _meta, so clients
+// can detect content truncated or corrupted on the way. Blob and image
+// digests cover the decoded bytes and text digests the UTF-8 text:
+//
+//	{"uri": "file:///logo.png", "blob": "iVBOR...", "_meta": {"sha256": "9f86d0..."}}
+//
+// Ranged reads digest each range. The client's ReadResource and
+// DownloadResource verify the digests they are given.
+func WithContentDigests() Option {
+	return func(s *serverImpl) {
+		s.contentDigests = true
+	}
+}
+
+// digestResourceResult adds digests to a formatted resources/read result.
+func (s *serverImpl) digestResourceResult(result interface{}, err error) (interface{}, error) {
+	if err != nil || !s.contentDigests {
+		return result, err
+	}
+	if response, ok := result.(map[string]interface{}); ok {
+		addContentDigests(response["content"])
+		for _, item := range addContentDigests(response["contents"]) {
+			addContentDigests(item["content"])
+		}
+	}
+	return result, nil
+}
+
+// digestToolResult adds digests to a formatted tool result.
+func (s *serverImpl) digestToolResult(result map[string]interface{}) map[string]interface{} {
+	if s.contentDigests && result != nil {
+		for _, item := range addContentDigests(result["content"]) {
+			// Embedded resources carry their own content item
+			if resource, ok := item["resource"].(map[string]interface{}); ok {
+				addContentDigest(resource)
+			}
+		}
+	}
+	return result
+}
+
+// addContentDigests digests each item of a content array in place and
+// returns the items.
+func addContentDigests(content interface{}) []map[string]interface{} {
+	items := contentItems(content)
+	for _, item := range items {
+		addContentDigest(item)
+	}
+	return items
+}
+
+// addContentDigest records the digest of an item's blob, data or text in
+// its _meta. Items with none of them, or with invalid base64, are left
+// alone.
+func addContentDigest(item map[string]interface{}) {
+	var payload []byte
+	if encoded, ok := item["blob"].(string); ok {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return
+		}
+		payload = decoded
+	} else if encoded, ok := item["data"].(string); ok {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return
+		}
+		payload = decoded
+	} else if text, ok := item["text"].(string); ok {
+		payload = []byte(text)
+	} else {
+		return
+	}
+
+	meta, ok := item["_meta"].(map[string]interface{})
+	if !ok {
+		meta = make(map[string]interface{})
+		item["_meta"] = meta
+	}
+	sum := sha256.Sum256(payload)
+	meta[digestMetaKey] = hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/localrivet/gomcp/mcp"
+	"github.com/localrivet/gomcp/transport"
+	httptransport "github.com/localrivet/gomcp/transport/http"
+	"github.com/localrivet/gomcp/transport/sse"
+	"github.com/localrivet/gomcp/transport/streamablehttp"
+	"github.com/localrivet/gomcp/transport/ws"
+)
+
+// handlerRegistrar is implemented by HTTP-based transports that can serve
+// additional endpoints next to their own.
+type handlerRegistrar interface {
+	RegisterHandler(pattern string, handler http.Handler)
+}
+
+// WithDiscoveryAuth sets the authentication requirements advertised in the
+// /.well-known/mcp.json discovery document.
+//
+// Example:
+//
+//	server.NewServer("my-service",
+//	    server.WithDiscoveryAuth(mcp.AuthRequirements{Type: "bearer"}),
+//	)
+func WithDiscoveryAuth(auth mcp.AuthRequirements) Option {
+	return func(s *serverImpl) {
+		s.discoveryAuth = &auth
+	}
+}
+
+// DiscoveryDocument returns the document served at /.well-known/mcp.json.
+//
+// It describes the server's name, version, supported protocol versions,
+// transport endpoints and authentication requirements. Transports that are
+// not reachable over HTTP are not listed.
+func (s *serverImpl) DiscoveryDocument() mcp.DiscoveryDocument {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	doc := mcp.DiscoveryDocument{
+		Name:             s.name,
+		Version:          serverVersion,
+		ProtocolVersions: append([]string(nil), s.versionDetector.Supported...),
+		Transports:       []mcp.TransportEndpoint{},
+		Auth:             s.discoveryAuth,
+	}
+	if doc.Auth == nil && s.oauth != nil {
+		doc.Auth = &mcp.AuthRequirements{
+			Type:                 "oauth2",
+			AuthorizationServers: s.oauth.Metadata.AuthorizationServers,
+			Scopes:               s.oauth.scopesSupported(),
+		}
+	}
+
+	switch t := s.transport.(type) {
+	case *httptransport.Transport:
+		doc.Transports = append(doc.Transports, mcp.TransportEndpoint{
+			Type:     mcp.TransportHTTP,
+			Endpoint: t.GetFullAPIPath(),
+		})
+	case *sse.Transport:
+		doc.Transports = append(doc.Transports, mcp.TransportEndpoint{
+			Type:            mcp.TransportSSE,
+			Endpoint:        t.GetFullEventsPath(),
+			MessageEndpoint: t.GetFullMessagePath(),
+		})
+	case *streamablehttp.Transport:
+		doc.Transports = append(doc.Transports, mcp.TransportEndpoint{
+			Type:     mcp.TransportStreamableHTTP,
+			Endpoint: t.GetEndpoint(),
+		})
+	case *ws.Transport:
+		doc.Transports = append(doc.Transports, mcp.TransportEndpoint{
+			Type:     mcp.TransportWebsocket,
+			Endpoint: t.GetFullWSPath(),
+		})
+	}
+
+	return doc
+}
+
+// registerDiscoveryHandler serves the discovery document on transports that
+// support additional HTTP handlers.
+func (s *serverImpl) registerDiscoveryHandler(t transport.Transport) {
+	registrar, ok := t.(handlerRegistrar)
+	if !ok {
+		return
+	}
+	registrar.RegisterHandler(mcp.WellKnownPath, http.HandlerFunc(s.serveDiscovery))
+}
+
+// serveDiscovery writes the discovery document as JSON.
+func (s *serverImpl) serveDiscovery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if err := json.NewEncoder(w).Encode(s.DiscoveryDocument()); err != nil {
+		s.logger.Error("failed to write discovery document", "error", err)
+	}
+}
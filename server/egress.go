@@ -0,0 +1,222 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// EgressPolicy restricts the hosts that the clients returned by HTTPClient
+// connect to. The zero policy allows every destination.
+type EgressPolicy struct {
+	// AllowHosts are the host names requests may go to. A name starting
+	// with "*." allows the subdomains of the rest. When AllowHosts or
+	// AllowNetworks is set, destinations matching neither are refused.
+	AllowHosts []string
+
+	// AllowNetworks are the networks requests may connect to, matched
+	// against the resolved address. They are allowed even when
+	// DenyInternal is set.
+	AllowNetworks []netip.Prefix
+
+	// DenyInternal refuses connections to loopback, private, link-local,
+	// shared, unspecified and multicast addresses, such as cloud metadata
+	// endpoints, so that tools fetching URLs supplied by clients can't be
+	// used to reach the server's own network.
+	DenyInternal bool
+}
+
+// EgressError is the error of a request refused by the egress policy.
+type EgressError struct {
+	// Host is the host name the request was for.
+	Host string
+
+	// Address is the refused address, or empty if the host name itself
+	// isn't allowed.
+	Address string
+
+	// Reason tells why the connection was refused.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *EgressError) Error() string {
+	target := e.Host
+	if e.Address != "" && e.Address != e.Host {
+		target += " (" + e.Address + ")"
+	}
+	return fmt.Sprintf("connection to %s blocked by egress policy: %s", target, e.Reason)
+}
+
+// sharedNetwork is the carrier-grade NAT range, which netip doesn't count
+// as private.
+var sharedNetwork = netip.MustParsePrefix("100.64.0.0/10")
+
+// WithEgressPolicy enforces an egress policy on the clients HTTPClient
+// returns. Addresses are checked when connecting, after name resolution and
+// for each redirect, so host names resolving or redirecting to a refused
+// address are refused too. Refused requests fail with an *EgressError,
+// which the tool returns as its error, and are logged.
+//
+// Requests under a policy connect directly, ignoring HTTP_PROXY and
+// HTTPS_PROXY, since the policy can only check the addresses it connects
+// to.
+//
+// Example:
+//
+//	srv := server.NewServer("fetcher",
+//	    server.WithEgressPolicy(server.EgressPolicy{
+//	        AllowHosts:   []string{"api.github.com", "*.wikipedia.org"},
+//	        DenyInternal: true,
+//	    }),
+//	)
+func WithEgressPolicy(policy EgressPolicy) Option {
+	return func(s *serverImpl) {
+		transport := httpTransport.Clone()
+		transport.Proxy = nil
+		transport.DialContext = policy.dialContext
+		s.egressTransport = transport
+	}
+}
+
+// restricted reports whether the policy only allows listed destinations.
+func (p EgressPolicy) restricted() bool {
+	return len(p.AllowHosts) > 0 || len(p.AllowNetworks) > 0
+}
+
+// allowsHost reports whether a host name is in AllowHosts.
+func (p EgressPolicy) allowsHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range p.AllowHosts {
+		allowed = strings.ToLower(allowed)
+		if suffix, wildcard := strings.CutPrefix(allowed, "*"); wildcard {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsNetwork reports whether an address is in AllowNetworks.
+func (p EgressPolicy) allowsNetwork(addr netip.Addr) bool {
+	for _, network := range p.AllowNetworks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns an *EgressError if the policy refuses connecting to addr
+// for a request to host.
+func (p EgressPolicy) check(host string, addr netip.Addr) error {
+	addr = addr.Unmap()
+	if p.allowsNetwork(addr) {
+		return nil
+	}
+	if p.restricted() && !p.allowsHost(host) {
+		return &EgressError{Host: host, Address: addr.String(), Reason: "destination not allowed"}
+	}
+	if p.DenyInternal && internalAddr(addr) {
+		return &EgressError{Host: host, Address: addr.String(), Reason: "internal address"}
+	}
+	return nil
+}
+
+// internalAddr reports whether addr belongs to a network that isn't
+// reachable from the internet.
+func internalAddr(addr netip.Addr) bool {
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() ||
+		sharedNetwork.Contains(addr)
+}
+
+// dialContext resolves the host of addr and connects to the first of its
+// addresses the policy allows.
+func (p EgressPolicy) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Skip resolving names that no address could make allowed
+	if p.restricted() && len(p.AllowNetworks) == 0 && !p.allowsHost(host) {
+		return nil, &EgressError{Host: host, Reason: "destination not allowed"}
+	}
+
+	addrs, err := resolveHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	var lastErr error
+	for _, ip := range addrs {
+		if err := p.check(host, ip); err != nil {
+			lastErr = err
+			continue
+		}
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// resolveHost returns the addresses of a host name, or the host itself if
+// it is an address.
+func resolveHost(ctx context.Context, host string) ([]netip.Addr, error) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{addr}, nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+	return addrs, nil
+}
+
+// egressBase returns the transport HTTPClient sends requests with.
+func (s *serverImpl) egressBase() http.RoundTripper {
+	if s == nil || s.egressTransport == nil {
+		return httpTransport
+	}
+	return s.egressTransport
+}
+
+// politeFetcher returns the fetch policy of HTTPClient, or nil without
+// WithFetchPolicy.
+func (s *serverImpl) politeFetcher() *politeFetcher {
+	if s == nil {
+		return nil
+	}
+	return s.fetchPolicy
+}
+
+// logEgressViolation logs a request the egress policy refused.
+func (ctx *Context) logEgressViolation(err *EgressError) {
+	if ctx.server == nil {
+		return
+	}
+	var tool string
+	if ctx.Request != nil {
+		tool = ctx.Request.ToolName
+	}
+	ctx.server.logger.Warn("blocked outbound connection",
+		"tool", tool,
+		"host", err.Host,
+		"address", err.Address,
+		"reason", err.Reason)
+}
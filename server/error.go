@@ -1,6 +1,10 @@
 package server
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/localrivet/gomcp/protocol"
+)
 
 // createErrorResponse creates a JSON-RPC 2.0 error response.
 // This function formats error information according to the JSON-RPC 2.0 specification,
@@ -40,11 +44,12 @@ func createErrorResponse(id interface{}, code int, message string, data interfac
 	return responseBytes
 }
 
-// Error returns the error message, implementing the error interface.
-// This method allows RPCError to be used as a standard Go error.
-//
-// Returns:
-//   - The error message as a string
-func (e *RPCError) Error() string {
-	return e.Message
+// unknownToolError is the error calls to tools the server doesn't have are
+// answered with, which clients detect with protocol.IsUnknownTool.
+func unknownToolError(name string) error {
+	return &RPCError{
+		Code:    protocol.InvalidParams,
+		Message: "Unknown tool: " + name,
+		Data:    map[string]interface{}{"reason": protocol.ReasonUnknownTool, "tool": name},
+	}
 }
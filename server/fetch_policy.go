@@ -0,0 +1,337 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrResponseTooLarge is the error of reading a response body larger than
+// the FetchPolicy's MaxResponseSize.
+var ErrResponseTooLarge = errors.New("response too large")
+
+// ErrDisallowedByRobots is the error of a request the host's robots.txt
+// disallows, when the FetchPolicy respects it.
+var ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+const (
+	// robotsMaxSize is the most of a robots.txt that is read, as RFC 9309
+	// allows crawlers to ignore the rest past 500 KiB.
+	robotsMaxSize = 500 << 10
+
+	// robotsCacheTime is how long a host's robots.txt is kept, and
+	// robotsRetryTime how long a failure to fetch it is.
+	robotsCacheTime = 24 * time.Hour
+	robotsRetryTime = time.Minute
+
+	// maxCrawlDelay is the longest Crawl-delay honored, so a robots.txt
+	// can't stall calls indefinitely.
+	maxCrawlDelay = time.Minute
+)
+
+// FetchPolicy makes the requests of the clients HTTPClient returns polite
+// to the hosts they go to, so that agents calling fetch-style tools in a
+// loop don't get the server's address banned. Limits are shared by all
+// calls. The zero policy only widens retries to every 5xx response but 501.
+type FetchPolicy struct {
+	// MaxConcurrentPerHost is the most requests in progress to a host,
+	// counting until their response body is closed. Further requests wait
+	// their turn. Zero sets no limit.
+	MaxConcurrentPerHost int
+
+	// MinInterval is the least time between the starts of two requests to
+	// a host.
+	MinInterval time.Duration
+
+	// MaxRetries is how many times an idempotent request is retried after
+	// connection errors, 429 responses and 5xx responses other than 501,
+	// with jittered backoff. Zero keeps the default of 2; a negative
+	// value disables retries.
+	MaxRetries int
+
+	// MaxRetryAfter is the longest Retry-After, in seconds or as a date,
+	// that a request waits out before retrying; longer ones return the
+	// response as it is. Until a host's Retry-After passes, other requests
+	// to it wait too. Zero keeps the default of 10 seconds.
+	MaxRetryAfter time.Duration
+
+	// MaxResponseSize is the most bytes of a response body that can be
+	// read. Larger responses fail with ErrResponseTooLarge, before the
+	// body is read if their Content-Length is over. Zero sets no limit.
+	MaxResponseSize int64
+
+	// RespectRobots refuses requests the host's robots.txt disallows for
+	// UserAgent with ErrDisallowedByRobots, and spaces requests by its
+	// Crawl-delay when that is longer than MinInterval. Hosts whose
+	// robots.txt can't be fetched because of a server or network error are
+	// disallowed until it can be, as RFC 9309 asks.
+	RespectRobots bool
+
+	// UserAgent is sent with requests that don't set one, and matched
+	// against the user-agent lines of robots.txt by its product token.
+	UserAgent string
+}
+
+// WithFetchPolicy applies a fetch policy to the clients HTTPClient
+// returns.
+//
+// Example:
+//
+//	srv := server.NewServer("browser",
+//	    server.WithFetchPolicy(server.FetchPolicy{
+//	        MaxConcurrentPerHost: 2,
+//	        MinInterval:          500 * time.Millisecond,
+//	        MaxResponseSize:      5 << 20,
+//	        RespectRobots:        true,
+//	        UserAgent:            "acme-agent/1.0 (+https://acme.example/bot)",
+//	    }),
+//	)
+func WithFetchPolicy(policy FetchPolicy) Option {
+	return func(s *serverImpl) {
+		s.fetchPolicy = &politeFetcher{
+			policy: policy,
+			hosts:  make(map[string]*fetchHost),
+		}
+	}
+}
+
+// politeFetcher applies a FetchPolicy, keeping the state of each host.
+type politeFetcher struct {
+	policy FetchPolicy
+
+	mu    sync.Mutex
+	hosts map[string]*fetchHost
+}
+
+// fetchHost is the state of the requests to one host.
+type fetchHost struct {
+	// slots holds a token per request in progress, when the number is
+	// limited
+	slots chan struct{}
+
+	mu   sync.Mutex
+	next time.Time // earliest start of the next request
+
+	robotsMu      sync.Mutex
+	robots        *robotsRules
+	robotsExpires time.Time
+}
+
+// host returns the state of the host of u.
+func (p *politeFetcher) host(u *url.URL) *fetchHost {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := strings.ToLower(u.Scheme + "://" + u.Host)
+	h, ok := p.hosts[key]
+	if !ok {
+		h = &fetchHost{}
+		if p.policy.MaxConcurrentPerHost > 0 {
+			h.slots = make(chan struct{}, p.policy.MaxConcurrentPerHost)
+		}
+		p.hosts[key] = h
+	}
+	return h
+}
+
+// retries returns how many times a request is retried and the longest
+// Retry-After waited out.
+func (p *politeFetcher) retries() (int, time.Duration) {
+	maxRetries, maxRetryAfter := httpMaxRetries, httpMaxRetryAfter
+	if p.policy.MaxRetries != 0 {
+		maxRetries = max(0, p.policy.MaxRetries)
+	}
+	if p.policy.MaxRetryAfter > 0 {
+		maxRetryAfter = p.policy.MaxRetryAfter
+	}
+	return maxRetries, maxRetryAfter
+}
+
+// prepare sets the policy's user agent on a request and checks it against
+// robots.txt.
+func (p *politeFetcher) prepare(base http.RoundTripper, req *http.Request) error {
+	if p.policy.UserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", p.policy.UserAgent)
+	}
+	if !p.policy.RespectRobots || req.URL.Path == "/robots.txt" {
+		return nil
+	}
+	rules := p.host(req.URL).robotsRules(req.Context(), base, req.URL, p.policy.UserAgent)
+	if !rules.allows(robotsPath(req.URL)) {
+		return fmt.Errorf("%w: %s", ErrDisallowedByRobots, req.URL.Redacted())
+	}
+	return nil
+}
+
+// send sends a request once the host has a free slot and its interval
+// since the last request has passed. The slot is held until the response
+// body is closed.
+func (p *politeFetcher) send(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	host := p.host(req.URL)
+	interval := p.policy.MinInterval
+	if p.policy.RespectRobots {
+		interval = max(interval, host.crawlDelay())
+	}
+	release, err := host.acquire(req.Context(), interval)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// limit enforces the policy's MaxResponseSize on a response.
+func (p *politeFetcher) limit(req *http.Request, resp *http.Response) error {
+	limit := p.policy.MaxResponseSize
+	if limit <= 0 {
+		return nil
+	}
+	if resp.ContentLength > limit {
+		return fmt.Errorf("%w: %s is %d bytes, over %d", ErrResponseTooLarge, req.URL.Redacted(), resp.ContentLength, limit)
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: limit, url: req.URL.Redacted()}
+	return nil
+}
+
+// acquire waits for a free slot and the host's next start time, returning
+// the function releasing the slot.
+func (h *fetchHost) acquire(ctx context.Context, interval time.Duration) (func(), error) {
+	if h.slots != nil {
+		select {
+		case h.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		}
+	}
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			if h.slots != nil {
+				<-h.slots
+			}
+		})
+	}
+
+	h.mu.Lock()
+	start := time.Now()
+	if h.next.After(start) {
+		start = h.next
+	}
+	h.next = start.Add(interval)
+	h.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			release()
+			return nil, context.Cause(ctx)
+		}
+	}
+	return release, nil
+}
+
+// holdOff delays the requests to the host that start before until.
+func (h *fetchHost) holdOff(until time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if until.After(h.next) {
+		h.next = until
+	}
+}
+
+// crawlDelay returns the Crawl-delay of the host's robots.txt, if it has
+// been fetched.
+func (h *fetchHost) crawlDelay() time.Duration {
+	h.robotsMu.Lock()
+	defer h.robotsMu.Unlock()
+	if h.robots == nil {
+		return 0
+	}
+	return h.robots.crawlDelay
+}
+
+// robotsRules returns the rules of the host's robots.txt for agent,
+// fetching it if it isn't cached.
+func (h *fetchHost) robotsRules(ctx context.Context, base http.RoundTripper, u *url.URL, agent string) *robotsRules {
+	h.robotsMu.Lock()
+	defer h.robotsMu.Unlock()
+	if h.robots != nil && time.Now().Before(h.robotsExpires) {
+		return h.robots
+	}
+
+	rules, cacheTime := fetchRobots(ctx, base, u, agent)
+	if ctx.Err() == nil {
+		h.robots, h.robotsExpires = rules, time.Now().Add(cacheTime)
+	}
+	return rules
+}
+
+// fetchRobots fetches and parses the robots.txt of the host of u,
+// returning its rules and how long to keep them.
+func fetchRobots(ctx context.Context, base http.RoundTripper, u *url.URL, agent string) (*robotsRules, time.Duration) {
+	robotsURL := &url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL.String(), nil)
+	if err != nil {
+		return &robotsRules{disallowAll: true}, robotsRetryTime
+	}
+	if agent != "" {
+		req.Header.Set("User-Agent", agent)
+	}
+
+	// Follows up to 10 redirects, more than the 5 RFC 9309 asks for
+	resp, err := (&http.Client{Transport: base}).Do(req)
+	if err != nil {
+		return &robotsRules{disallowAll: true}, robotsRetryTime
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		data, err := io.ReadAll(io.LimitReader(resp.Body, robotsMaxSize))
+		if err != nil {
+			return &robotsRules{disallowAll: true}, robotsRetryTime
+		}
+		return parseRobots(data, agent), robotsCacheTime
+	case resp.StatusCode >= 400 && resp.StatusCode <= 499:
+		// Hosts without a robots.txt allow everything
+		return &robotsRules{}, robotsCacheTime
+	default:
+		return &robotsRules{disallowAll: true}, robotsRetryTime
+	}
+}
+
+// limitedBody fails reads past a response size limit.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	url       string
+}
+
+// Read implements io.Reader.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	// Read a byte past the limit to tell a body ending at it from a longer one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = 0
+		return n, fmt.Errorf("%w: %s is longer than allowed", ErrResponseTooLarge, b.url)
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
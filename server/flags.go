@@ -0,0 +1,209 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/localrivet/gomcp/flags"
+	"github.com/localrivet/gomcp/util/schema"
+)
+
+// WithFlags evaluates feature flags with provider, for tools gated with
+// WithToolFlag, schemas chosen with WithSchemaVariants, FlagMiddleware and
+// Context.Flag. Flags are evaluated on every list and call, so changes in
+// the provider take effect without a restart.
+//
+// Unless WithFlagContext is set, flags are evaluated with the caller's API
+// key ID, or its session ID if it sent no key, as the targeting key, and
+// with the attributes "sessionID", "method", "apiKeyID" and, for tools,
+// "tool".
+func WithFlags(provider flags.Provider) Option {
+	return func(s *serverImpl) {
+		s.flags = provider
+	}
+}
+
+// WithFlagContext sets how the evaluation context of a request is built,
+// for example to target flags by a tenant named in the request's _meta.
+//
+// Example:
+//
+//	server.WithFlagContext(func(ctx *server.Context) flags.EvaluationContext {
+//	    tenant := ctx.Meta().String("tenant")
+//	    return flags.EvaluationContext{TargetingKey: tenant, Attributes: map[string]interface{}{"tenant": tenant}}
+//	})
+func WithFlagContext(build func(ctx *Context) flags.EvaluationContext) Option {
+	return func(s *serverImpl) {
+		s.flagContext = build
+	}
+}
+
+// schemaVariant is an input schema selected by a string flag.
+type schemaVariant struct {
+	schema    map[string]interface{}
+	validator *schema.ArgumentValidator
+}
+
+// WithToolFlag makes a tool available only to callers for whom the boolean
+// flag is on. Otherwise the tool is left out of tools/list and calls to it
+// fail as for an unknown tool. Flags the provider can't evaluate count as
+// off, so a tool is never exposed by accident.
+// The function returns the server instance to allow for method chaining.
+func (s *serverImpl) WithToolFlag(toolName, flag string) Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tool, exists := s.tools[toolName]
+	if !exists {
+		s.logger.Error("tool not found for flag", "name", toolName)
+		return s
+	}
+	tool.flag = flag
+	s.toolsChanged = true
+	return s
+}
+
+// WithSchemaVariants lets a string flag choose a tool's input schema: the
+// schema listed and validated against is variants[value], or the tool's own
+// schema for values without a variant.
+// The function returns the server instance to allow for method chaining.
+func (s *serverImpl) WithSchemaVariants(toolName, flag string, variants map[string]map[string]interface{}) Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tool, exists := s.tools[toolName]
+	if !exists {
+		s.logger.Error("tool not found for schema variants", "name", toolName)
+		return s
+	}
+	tool.schemaFlag = flag
+	tool.schemaVariants = make(map[string]*schemaVariant, len(variants))
+	for value, variant := range variants {
+		tool.schemaVariants[value] = &schemaVariant{
+			schema:    variant,
+			validator: s.newArgumentValidator(toolName, variant),
+		}
+	}
+	s.toolsChanged = true
+	return s
+}
+
+// FlagMiddleware applies middleware only to requests for which the boolean
+// flag is on, for rolling out new request handling gradually. Without
+// WithFlags, or if the flag can't be evaluated, the middleware is skipped.
+//
+// Example:
+//
+//	srv.Use(server.FlagMiddleware("audit.verbose", verboseAudit))
+func FlagMiddleware(flag string, middleware Middleware) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		wrapped := middleware(next)
+		return func(ctx *Context) (interface{}, error) {
+			if ctx.Flag(flag, false) {
+				return wrapped(ctx)
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// Flag evaluates a boolean feature flag for the request, returning
+// defaultValue without WithFlags or if the flag can't be evaluated.
+func (c *Context) Flag(flag string, defaultValue bool) bool {
+	if c.server == nil || c.server.flags == nil {
+		return defaultValue
+	}
+	value, err := c.server.flags.BooleanValue(c.Context(), flag, defaultValue, c.server.evaluationContext(c, ""))
+	if err != nil {
+		c.server.logFlagError(flag, err)
+		return defaultValue
+	}
+	return value
+}
+
+// FlagString evaluates a string feature flag for the request, returning
+// defaultValue without WithFlags or if the flag can't be evaluated.
+func (c *Context) FlagString(flag string, defaultValue string) string {
+	if c.server == nil || c.server.flags == nil {
+		return defaultValue
+	}
+	value, err := c.server.flags.StringValue(c.Context(), flag, defaultValue, c.server.evaluationContext(c, ""))
+	if err != nil {
+		c.server.logFlagError(flag, err)
+		return defaultValue
+	}
+	return value
+}
+
+// evaluationContext builds the evaluation context of a request, naming the
+// tool a flag is evaluated for, if any.
+func (s *serverImpl) evaluationContext(ctx *Context, toolName string) flags.EvaluationContext {
+	var evalCtx flags.EvaluationContext
+	if s.flagContext != nil {
+		evalCtx = s.flagContext(ctx)
+	} else {
+		sessionID := string(ctx.sessionID())
+		evalCtx.TargetingKey = apiKeyID(ctx.Meta().APIKey())
+		evalCtx.Attributes = map[string]interface{}{"sessionID": sessionID}
+		if evalCtx.TargetingKey != "" {
+			evalCtx.Attributes["apiKeyID"] = evalCtx.TargetingKey
+		} else {
+			evalCtx.TargetingKey = sessionID
+		}
+		if ctx.Request != nil {
+			evalCtx.Attributes["method"] = ctx.Request.Method
+		}
+	}
+
+	if toolName != "" {
+		attributes := make(map[string]interface{}, len(evalCtx.Attributes)+1)
+		for key, value := range evalCtx.Attributes {
+			attributes[key] = value
+		}
+		attributes["tool"] = toolName
+		evalCtx.Attributes = attributes
+	}
+	return evalCtx
+}
+
+// logFlagError logs a failed evaluation. Unknown flags are expected while a
+// rollout is being set up, so they are only logged at debug level.
+func (s *serverImpl) logFlagError(flag string, err error) {
+	if errors.Is(err, flags.ErrFlagNotFound) {
+		s.logger.Debug("feature flag not found", "flag", flag)
+		return
+	}
+	s.logger.Warn("failed to evaluate feature flag", "flag", flag, "error", err)
+}
+
+// toolEnabled reports whether a tool's flag, if it has one, is on for the
+// request.
+func (s *serverImpl) toolEnabled(ctx *Context, tool *Tool) bool {
+	if tool.flag == "" {
+		return true
+	}
+	if s.flags == nil {
+		return false
+	}
+	enabled, err := s.flags.BooleanValue(ctx.Context(), tool.flag, false, s.evaluationContext(ctx, tool.Name))
+	if err != nil {
+		s.logFlagError(tool.flag, err)
+		return false
+	}
+	return enabled
+}
+
+// toolSchema returns the input schema of a tool for the request and the
+// validator for it, taking the variant chosen by the tool's schema flag.
+func (s *serverImpl) toolSchema(ctx *Context, tool *Tool) (interface{}, *schema.ArgumentValidator) {
+	if tool.schemaFlag == "" || s.flags == nil {
+		return tool.Schema, tool.validator
+	}
+	value, err := s.flags.StringValue(ctx.Context(), tool.schemaFlag, "", s.evaluationContext(ctx, tool.Name))
+	if err != nil {
+		s.logFlagError(tool.schemaFlag, err)
+	}
+	if variant, ok := tool.schemaVariants[value]; ok {
+		return variant.schema, variant.validator
+	}
+	return tool.Schema, tool.validator
+}
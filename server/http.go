@@ -22,6 +22,7 @@ func (s *serverImpl) AsHTTP(address string) Server {
 
 	// Configure the transport
 	httpTransport.SetMessageHandler(s.handleMessage)
+	s.registerDiscoveryHandler(httpTransport)
 
 	// Set as the server's transport
 	s.transport = httpTransport
@@ -61,6 +62,7 @@ func (s *serverImpl) AsHTTPWithPaths(address, pathPrefix, apiPath string) Server
 
 	// Configure the message handler
 	httpTransport.SetMessageHandler(s.handleMessage)
+	s.registerDiscoveryHandler(httpTransport)
 
 	// Set as the server's transport
 	s.transport = httpTransport
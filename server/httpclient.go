@@ -0,0 +1,271 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/localrivet/gomcp/telemetry"
+)
+
+const (
+	// httpClientTimeout bounds a request made with HTTPClient, including
+	// reading the response body, when the call's deadline is later.
+	httpClientTimeout = 30 * time.Second
+
+	// httpMaxRetries is how many times a failed idempotent request is
+	// retried.
+	httpMaxRetries = 2
+
+	// httpRetryDelay is the wait before the first retry, doubled for each
+	// retry after it.
+	httpRetryDelay = 200 * time.Millisecond
+
+	// httpMaxRetryAfter is the longest Retry-After a request waits out
+	// before retrying; longer ones return the response as it is.
+	httpMaxRetryAfter = 10 * time.Second
+)
+
+// httpTransport is shared by the clients HTTPClient returns, so handlers
+// reuse connections across calls.
+var httpTransport = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   8,
+	MaxConnsPerHost:       32,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ResponseHeaderTimeout: 30 * time.Second,
+	ExpectContinueTimeout: time.Second,
+}
+
+// HTTPClient returns an HTTP client for the requests a handler makes while
+// serving the call in ctx. The client:
+//
+//   - stops waiting when the call is canceled or its deadline passes, and
+//     after 30 seconds otherwise;
+//   - retries GET, HEAD and OPTIONS requests twice, with jittered backoff,
+//     after connection errors and 502, 503 and 504 responses, and after
+//     429 responses asking for a retry within 10 seconds, waiting out the
+//     Retry-After of 429 and 503 responses;
+//   - uses the proxy set in HTTP_PROXY, HTTPS_PROXY and NO_PROXY;
+//   - opens at most 32 connections to each host, shared across calls;
+//   - sends the trace context of the call in traceparent headers, so the
+//     requests join its trace;
+//   - refuses destinations the server's WithEgressPolicy doesn't allow;
+//   - limits and spaces requests to each host as the server's
+//     WithFetchPolicy asks.
+//
+// Example:
+//
+//	srv.Tool("weather", "Current weather", func(ctx *server.Context, args WeatherArgs) (string, error) {
+//	    resp, err := server.HTTPClient(ctx).Get("https://api.example.com/weather?city=" + url.QueryEscape(args.City))
+//	    if err != nil {
+//	        return "", err
+//	    }
+//	    defer resp.Body.Close()
+//	    body, err := io.ReadAll(resp.Body)
+//	    return string(body), err
+//	})
+func HTTPClient(ctx *Context) *http.Client {
+	return &http.Client{
+		Transport: &handlerTransport{ctx: ctx.Context(), base: ctx.server.egressBase(), call: ctx, polite: ctx.server.politeFetcher()},
+		Timeout:   httpClientTimeout,
+	}
+}
+
+// handlerTransport ties requests to the call a handler is serving.
+type handlerTransport struct {
+	ctx    context.Context
+	base   http.RoundTripper
+	call   *Context
+	polite *politeFetcher
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Cancel the request with the call, until its body has been read
+	reqCtx, cancel := context.WithCancelCause(req.Context())
+	stop := context.AfterFunc(t.ctx, func() { cancel(context.Cause(t.ctx)) })
+	release := func() {
+		stop()
+		cancel(nil)
+	}
+
+	out := req.Clone(reqCtx)
+	telemetry.InjectHTTP(t.ctx, out.Header)
+	if t.polite != nil {
+		if err := t.polite.prepare(t.base, out); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	resp, err := t.roundTrip(out)
+	if err == nil && t.polite != nil {
+		if err = t.polite.limit(out, resp); err != nil {
+			resp.Body.Close()
+		}
+	}
+	if err != nil {
+		release()
+		var egressErr *EgressError
+		if errors.As(err, &egressErr) {
+			t.call.logEgressViolation(egressErr)
+		}
+		return nil, err
+	}
+	resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// roundTrip sends a request, retrying it if it is idempotent and failed
+// for a reason a retry may fix.
+func (t *handlerTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	maxRetries, maxRetryAfter := httpMaxRetries, httpMaxRetryAfter
+	if t.polite != nil {
+		maxRetries, maxRetryAfter = t.polite.retries()
+	}
+
+	delay := httpRetryDelay
+	for attempt := 0; ; attempt++ {
+		resp, err := t.send(req)
+		if t.polite != nil && resp != nil {
+			// Hold back the other requests to a host asking for a pause
+			if after, ok := retryAfter(resp); ok {
+				t.polite.host(req.URL).holdOff(time.Now().Add(min(after, maxRetryAfter)))
+			}
+		}
+		if attempt >= maxRetries || !retryableHTTPRequest(req) {
+			return resp, err
+		}
+		wait, retry := httpRetryWait(req.Context(), resp, err, delay, maxRetryAfter, t.polite != nil)
+		if !retry {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			req.Body = body
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, context.Cause(req.Context())
+		}
+		delay *= 2
+	}
+}
+
+// send sends a request once, within the server's fetch policy.
+func (t *handlerTransport) send(req *http.Request) (*http.Response, error) {
+	if t.polite != nil {
+		return t.polite.send(t.base, req)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// retryableHTTPRequest reports whether a request can be sent again.
+func retryableHTTPRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// httpRetryWait returns how long to wait before retrying a request that
+// got resp or err, and whether to retry it at all. Retry-After is waited
+// out up to maxRetryAfter, and with serverErrors every 5xx status but 501
+// is retried.
+func httpRetryWait(ctx context.Context, resp *http.Response, err error, delay, maxRetryAfter time.Duration, serverErrors bool) (time.Duration, bool) {
+	wait := jitterHTTPDelay(delay)
+	if err != nil {
+		var egressErr *EgressError
+		if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.As(err, &egressErr) {
+			return 0, false
+		}
+	} else {
+		after, hasAfter := retryAfter(resp)
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests && !hasAfter:
+			return 0, false
+		case resp.StatusCode == http.StatusTooManyRequests:
+		case resp.StatusCode == http.StatusBadGateway, resp.StatusCode == http.StatusServiceUnavailable, resp.StatusCode == http.StatusGatewayTimeout:
+		case serverErrors && resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented:
+		default:
+			return 0, false
+		}
+		if hasAfter {
+			if after > maxRetryAfter {
+				return 0, false
+			}
+			wait = max(wait, after)
+		}
+	}
+
+	// Don't wait past the call's deadline for a retry that can't finish
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		return 0, false
+	}
+	return wait, true
+}
+
+// retryAfter returns the wait a 429 or 503 response asks for in its
+// Retry-After header, given in seconds or as a date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(0, time.Duration(seconds)*time.Second), true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(0, time.Until(date)), true
+	}
+	return 0, false
+}
+
+// jitterHTTPDelay spreads a delay by up to a fifth either way, so that
+// handlers retrying together don't hit a recovering host in step.
+func jitterHTTPDelay(delay time.Duration) time.Duration {
+	spread := float64(delay) * 0.2
+	return delay + time.Duration(spread*(2*rand.Float64()-1))
+}
+
+// releaseBody releases the request's context once its response body is
+// closed.
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close implements io.Closer.
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// localeMetaKey is the _meta key clients use to declare their preferred language.
+// HTTP transports also populate it from the Accept-Language header.
+const localeMetaKey = "locale"
+
+// WithI18n enables localized tool output using a go-i18n message bundle.
+//
+// Handlers call ctx.Localize with a message ID; the text is rendered in the
+// language the client declared in the initialize request's _meta.locale, in a
+// per-request _meta.locale, or via the Accept-Language header on HTTP
+// transports. Messages missing for that language fall back to the bundle's
+// default language.
+//
+// Example:
+//
+//	bundle := i18n.NewBundle(language.English)
+//	if err := server.LoadMessageFiles(bundle, locales, "locales/*.json"); err != nil {
+//	    log.Fatal(err)
+//	}
+//	srv := server.NewServer("weather", server.WithI18n(bundle))
+func WithI18n(bundle *i18n.Bundle) Option {
+	return func(s *serverImpl) {
+		s.i18nBundle = bundle
+	}
+}
+
+// LoadMessageFiles loads every message file in fsys matching pattern into the
+// bundle. The language of each file is taken from its name, e.g. "fr.json" or
+// "active.de-CH.yaml". Formats other than JSON require an unmarshal function
+// registered on the bundle with RegisterUnmarshalFunc.
+func LoadMessageFiles(bundle *i18n.Bundle, fsys fs.FS, pattern string) error {
+	paths, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return fmt.Errorf("invalid message file pattern: %w", err)
+	}
+
+	for _, path := range paths {
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return fmt.Errorf("failed to read message file %s: %w", path, err)
+		}
+		if _, err := bundle.ParseMessageFileBytes(data, path); err != nil {
+			return fmt.Errorf("failed to parse message file %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Localize renders the message with the given ID in the client's language.
+// The optional templateData fills placeholders such as {{.City}}. If the
+// server has no bundle or the message is unknown, the message ID is returned.
+//
+// Example:
+//
+//	return ctx.Localize("ForecastSunny", map[string]interface{}{"City": args.City}), nil
+func (c *Context) Localize(messageID string, templateData ...interface{}) string {
+	localizer := c.Localizer()
+	if localizer == nil {
+		return messageID
+	}
+
+	config := &i18n.LocalizeConfig{MessageID: messageID}
+	if len(templateData) > 0 {
+		config.TemplateData = templateData[0]
+	}
+
+	text, err := localizer.Localize(config)
+	if err != nil && text == "" {
+		c.Logger.Debug("failed to localize message", "messageID", messageID, "error", err)
+		return messageID
+	}
+	return text
+}
+
+// Localizer returns a go-i18n localizer for the client's language, or nil if
+// the server was not configured with WithI18n. Use it for plural forms and
+// other features beyond Localize.
+func (c *Context) Localizer() *i18n.Localizer {
+	if c.server == nil || c.server.i18nBundle == nil {
+		return nil
+	}
+	return i18n.NewLocalizer(c.server.i18nBundle, c.languagePreferences()...)
+}
+
+// languagePreferences returns the client's language preferences in priority
+// order: the current request's _meta.locale, then the locale declared at
+// initialize. Each entry may be a tag or an Accept-Language header value.
+func (c *Context) languagePreferences() []string {
+	var langs []string
+	if c.Request != nil {
+		if locale := extractMetaString(c.Request.Params, localeMetaKey); locale != "" {
+			langs = append(langs, locale)
+		}
+	}
+	if locale := c.sessionHint(localeMetaKey); locale != "" {
+		langs = append(langs, locale)
+	}
+	return langs
+}
+
+// extractMetaString returns a string value from the _meta object of request params.
+func extractMetaString(params json.RawMessage, key string) string {
+	return requestMeta(params).String(key)
+}
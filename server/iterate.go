@@ -0,0 +1,65 @@
+package server
+
+import (
+	"maps"
+	"slices"
+)
+
+// EachTool yields every registered tool in name order. The registry is
+// snapshotted before iteration starts, so the loop body may register or
+// remove tools without deadlocking.
+//
+// Example:
+//
+//	for tool := range srv.EachTool {
+//	    fmt.Println(tool.Name, tool.Description)
+//	}
+func (s *serverImpl) EachTool(yield func(*Tool) bool) {
+	s.mu.RLock()
+	tools := snapshotSorted(s.tools)
+	s.mu.RUnlock()
+
+	for _, tool := range tools {
+		if !yield(tool) {
+			return
+		}
+	}
+}
+
+// EachResource yields every registered resource, including templates, in
+// path order. Like EachTool it iterates over a snapshot.
+func (s *serverImpl) EachResource(yield func(*Resource) bool) {
+	s.mu.RLock()
+	resources := snapshotSorted(s.resources)
+	s.mu.RUnlock()
+
+	for _, resource := range resources {
+		if !yield(resource) {
+			return
+		}
+	}
+}
+
+// EachPrompt yields every registered prompt in name order. Like EachTool it
+// iterates over a snapshot.
+func (s *serverImpl) EachPrompt(yield func(*Prompt) bool) {
+	s.mu.RLock()
+	prompts := snapshotSorted(s.prompts)
+	s.mu.RUnlock()
+
+	for _, prompt := range prompts {
+		if !yield(prompt) {
+			return
+		}
+	}
+}
+
+// snapshotSorted copies the values of a registry map ordered by key.
+func snapshotSorted[V any](registry map[string]V) []V {
+	keys := slices.Sorted(maps.Keys(registry))
+	values := make([]V, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, registry[key])
+	}
+	return values
+}
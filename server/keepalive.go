@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// KeepaliveOptions configures WithKeepalive. Zero values select the
+// defaults.
+type KeepaliveOptions struct {
+	// Interval is how long a session may be idle before it is pinged, and
+	// how often sessions are checked. The default is 30s.
+	Interval time.Duration
+
+	// Timeout is how long a client has to answer a ping. The default is
+	// 10s, and it is capped at Interval.
+	Timeout time.Duration
+
+	// MaxFailures is how many pings in a row a client may leave unanswered
+	// before its session is closed. The default is 3.
+	MaxFailures int
+}
+
+// keepalive holds the keepalive state of a server.
+type keepalive struct {
+	options KeepaliveOptions
+
+	mu       sync.Mutex
+	failures map[SessionID]int
+	inFlight map[SessionID]bool
+}
+
+// WithKeepalive pings sessions that have been idle for the interval, records
+// the round-trip time of each answered ping in the session's RTT, and closes
+// sessions whose client misses MaxFailures pings in a row, so that servers
+// with long-lived connections don't accumulate sessions of clients that
+// went away without closing them. Closed sessions are announced with a
+// session.ended event with reason "keepalive_timeout".
+//
+// Example:
+//
+//	srv := server.NewServer("my-service",
+//	    server.WithKeepalive(server.KeepaliveOptions{Interval: time.Minute}),
+//	).AsWebsocket(":8080")
+func WithKeepalive(options KeepaliveOptions) Option {
+	return func(s *serverImpl) {
+		if options.Interval <= 0 {
+			options.Interval = 30 * time.Second
+		}
+		if options.Timeout <= 0 {
+			options.Timeout = 10 * time.Second
+		}
+		if options.Timeout > options.Interval {
+			options.Timeout = options.Interval
+		}
+		if options.MaxFailures <= 0 {
+			options.MaxFailures = 3
+		}
+		s.keepalive = &keepalive{
+			options:  options,
+			failures: make(map[SessionID]int),
+			inFlight: make(map[SessionID]bool),
+		}
+	}
+}
+
+// startKeepalive pings idle sessions until the server stops.
+func (s *serverImpl) startKeepalive() {
+	if s.keepalive == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.keepalive.options.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stopped:
+				return
+			case <-ticker.C:
+				s.pingIdleSessions()
+			}
+		}
+	}()
+}
+
+// pingIdleSessions pings every session that has been idle for the interval
+// and isn't already being pinged. Sessions with requests in progress aren't
+// idle, however long ago their client last wrote.
+func (s *serverImpl) pingIdleSessions() {
+	k := s.keepalive
+	cutoff := time.Now().Add(-k.options.Interval)
+	for _, session := range s.sessionManager.Sessions() {
+		if !session.initialized || session.LastActive.After(cutoff) || s.requestCanceller.busy(session.ID) {
+			continue
+		}
+		k.mu.Lock()
+		if k.inFlight[session.ID] {
+			k.mu.Unlock()
+			continue
+		}
+		k.inFlight[session.ID] = true
+		k.mu.Unlock()
+
+		go s.pingSession(session.ID)
+	}
+}
+
+// pingSession pings the client of a session, closing the session when the
+// client has missed too many pings.
+func (s *serverImpl) pingSession(id SessionID) {
+	k := s.keepalive
+	ctx, cancel := context.WithTimeout(context.Background(), k.options.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := s.requestClient(ctx, id, "ping", nil)
+	rtt := time.Since(start)
+
+	// Clients that answer with an error, such as ones that don't know
+	// ping, are still there
+	var rpcErr *RPCError
+	alive := err == nil || errors.As(err, &rpcErr)
+
+	// Pings cut short by the server stopping say nothing about the client
+	select {
+	case <-s.stopped:
+		k.mu.Lock()
+		delete(k.inFlight, id)
+		k.mu.Unlock()
+		return
+	default:
+	}
+
+	k.mu.Lock()
+	delete(k.inFlight, id)
+	if alive {
+		delete(k.failures, id)
+		k.mu.Unlock()
+		s.sessionManager.setRTT(id, rtt)
+		return
+	}
+	k.failures[id]++
+	failures := k.failures[id]
+	if failures >= k.options.MaxFailures {
+		delete(k.failures, id)
+	}
+	k.mu.Unlock()
+
+	s.logger.Debug("keepalive ping failed", "session", id, "failures", failures, "error", err)
+	if failures < k.options.MaxFailures {
+		return
+	}
+	if s.endSession(id, "keepalive_timeout") {
+		s.logger.Info("closed unresponsive session", "session", id, "missedPings", failures)
+	}
+}
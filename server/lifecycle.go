@@ -0,0 +1,293 @@
+package server
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/localrivet/gomcp/transport"
+)
+
+// LifecyclePolicy controls how the server treats requests that arrive before
+// the client has completed the initialize handshake.
+type LifecyclePolicy int
+
+const (
+	// LifecycleLenient processes every request regardless of the handshake.
+	// This is the default, for compatibility with hosts that skip it.
+	LifecycleLenient LifecyclePolicy = iota
+
+	// LifecycleReject answers requests received before the initialized
+	// notification with an Invalid Request error, and rejects a second
+	// initialize on the same session.
+	LifecycleReject
+
+	// LifecycleQueue holds requests received before the initialized
+	// notification and processes them, in order, once it arrives. A second
+	// initialize is rejected as with LifecycleReject.
+	LifecycleQueue
+)
+
+// maxQueuedRequests bounds the requests held under LifecycleQueue; beyond it
+// early requests are rejected.
+const maxQueuedRequests = 64
+
+// LifecycleMetrics counts handshake violations seen by the server.
+type LifecycleMetrics struct {
+	// RejectedBeforeInitialized counts requests rejected because they arrived
+	// before the initialized notification.
+	RejectedBeforeInitialized int64
+
+	// QueuedBeforeInitialized counts requests held until the initialized notification.
+	QueuedBeforeInitialized int64
+
+	// DuplicateInitialize counts initialize requests on an already initialized session.
+	DuplicateInitialize int64
+
+	// InitializeTimeouts counts sessions closed for not completing the handshake in time.
+	InitializeTimeouts int64
+}
+
+// lifecycleCounters holds the live counters behind LifecycleMetrics.
+type lifecycleCounters struct {
+	rejected   atomic.Int64
+	queued     atomic.Int64
+	duplicates atomic.Int64
+	timeouts   atomic.Int64
+}
+
+// WithLifecyclePolicy sets how requests received before the initialized
+// notification are handled.
+//
+// Example:
+//
+//	srv := server.NewServer("strict", server.WithLifecyclePolicy(server.LifecycleReject))
+func WithLifecyclePolicy(policy LifecyclePolicy) Option {
+	return func(s *serverImpl) {
+		s.lifecyclePolicy = policy
+	}
+}
+
+// WithInitializeTimeout closes the session of a client that has not completed
+// the initialize handshake within timeout of its first message. Zero, the
+// default, waits forever. A connection that never sends initialize has no
+// session to close; the timeout only discards its queued requests, so limit
+// idle connections at the transport as well.
+func WithInitializeTimeout(timeout time.Duration) Option {
+	return func(s *serverImpl) {
+		s.initializeTimeout = timeout
+	}
+}
+
+// LifecycleMetrics returns a snapshot of the handshake violation counters.
+func (s *serverImpl) LifecycleMetrics() LifecycleMetrics {
+	return LifecycleMetrics{
+		RejectedBeforeInitialized: s.lifecycleCounters.rejected.Load(),
+		QueuedBeforeInitialized:   s.lifecycleCounters.queued.Load(),
+		DuplicateInitialize:       s.lifecycleCounters.duplicates.Load(),
+		InitializeTimeouts:        s.lifecycleCounters.timeouts.Load(),
+	}
+}
+
+// handshake is the progress of one client through the initialize
+// handshake. Transports serving several clients have one per connection;
+// others have a single one, under the empty connection ID.
+type handshake struct {
+	received    bool // initialize was received
+	initialized bool // the initialized notification was received
+	queued      [][]byte
+	timer       *time.Timer
+}
+
+// handshakeFor returns the handshake of a connection, starting it if it
+// hasn't started. Callers hold s.mu.
+func (s *serverImpl) handshakeFor(connectionID string) *handshake {
+	h, ok := s.handshakes[connectionID]
+	if !ok {
+		if s.handshakes == nil {
+			s.handshakes = make(map[string]*handshake)
+		}
+		h = &handshake{}
+		s.handshakes[connectionID] = h
+	}
+	return h
+}
+
+// endSession closes a session, reporting why it ended, and forgets the
+// handshake and stored state of the connection it ran over. It returns
+// whether the session was open.
+func (s *serverImpl) endSession(id SessionID, reason string) bool {
+	session, found := s.sessionManager.GetSession(id)
+	if !found || !s.sessionManager.CloseSession(id) {
+		return false
+	}
+	s.emitSessionEnded(id, reason)
+	if session.ConnectionID != "" {
+		s.forgetHandshake(session.ConnectionID)
+		s.forgetSession(session.ConnectionID)
+	}
+	return true
+}
+
+// watchConnections has the transport report the connections that close,
+// if it can.
+func (s *serverImpl) watchConnections(t transport.Transport) {
+	if notifier, ok := t.(transport.ConnectionNotifier); ok {
+		notifier.SetConnectionClosedHandler(s.connectionClosed)
+	}
+}
+
+// connectionClosed ends the session of a connection its client closed and
+// forgets the connection.
+func (s *serverImpl) connectionClosed(connectionID string) {
+	if session, ok := s.sessionManager.SessionForConnection(connectionID); ok {
+		s.endSession(session.ID, "client_closed")
+	}
+	// The client may close its connection before initializing it
+	s.forgetHandshake(connectionID)
+	s.forgetSession(connectionID)
+}
+
+// forgetHandshake drops the handshake of a connection whose session ended.
+func (s *serverImpl) forgetHandshake(connectionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if h, ok := s.handshakes[connectionID]; ok {
+		if h.timer != nil {
+			h.timer.Stop()
+		}
+		delete(s.handshakes, connectionID)
+	}
+}
+
+// enforceLifecycle checks an incoming message against the initialize
+// handshake of the connection it arrived on. It returns a response to send
+// instead of processing the message, and whether the message should be
+// processed at all. A nil response with handled set means the message was
+// queued.
+func (s *serverImpl) enforceLifecycle(ctx *Context, message []byte) (response []byte, handled bool) {
+	s.startInitializeTimer(ctx.connectionID)
+
+	method := ctx.Request.Method
+	isNotification := ctx.Request.ID == nil
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h := s.handshakeFor(ctx.connectionID)
+
+	if method == "initialize" {
+		if !h.received {
+			h.received = true
+			return nil, false
+		}
+		s.lifecycleCounters.duplicates.Add(1)
+		s.logger.Warn("duplicate initialize request", "policy", s.lifecyclePolicy)
+		if s.lifecyclePolicy == LifecycleLenient {
+			return nil, false
+		}
+		return createErrorResponse(ctx.Request.ID, -32600, "Invalid Request",
+			"initialize has already been called for this session"), true
+	}
+
+	// Pings and notifications are allowed at any point of the handshake
+	if s.lifecyclePolicy == LifecycleLenient || h.initialized || isNotification || method == "ping" {
+		return nil, false
+	}
+
+	if s.lifecyclePolicy == LifecycleQueue && len(h.queued) < maxQueuedRequests {
+		s.lifecycleCounters.queued.Add(1)
+		h.queued = append(h.queued, message)
+		s.logger.Debug("queued request until initialized", "method", method)
+		return nil, true
+	}
+
+	s.lifecycleCounters.rejected.Add(1)
+	s.logger.Warn("rejected request before initialized notification", "method", method)
+	return createErrorResponse(ctx.Request.ID, -32600, "Invalid Request",
+		fmt.Sprintf("received %s before the initialized notification; complete the initialize handshake first", method)), true
+}
+
+// startInitializeTimer arms the initialize timeout on the first message of a
+// connection, if one is configured.
+func (s *serverImpl) startInitializeTimer(connectionID string) {
+	if s.initializeTimeout <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h := s.handshakeFor(connectionID)
+	if h.initialized || h.timer != nil {
+		return
+	}
+	h.timer = time.AfterFunc(s.initializeTimeout, func() { s.expireInitialize(connectionID) })
+}
+
+// expireInitialize closes the session of a connection that did not finish
+// the handshake in time and drops any requests queued for it. A connection
+// that never sent initialize has no session to close, and transports can't
+// be asked to drop a single connection, so it only loses its handshake; its
+// next message starts the timeout again.
+func (s *serverImpl) expireInitialize(connectionID string) {
+	s.mu.Lock()
+	h, ok := s.handshakes[connectionID]
+	if !ok || h.initialized {
+		s.mu.Unlock()
+		return
+	}
+	dropped := len(h.queued)
+	received := h.received
+	delete(s.handshakes, connectionID)
+	session := s.defaultSession
+	s.mu.Unlock()
+
+	if connectionID != "" {
+		session, ok = s.sessionManager.SessionForConnection(connectionID)
+		if !ok {
+			session = nil
+		}
+	}
+
+	s.lifecycleCounters.timeouts.Add(1)
+	s.logger.Warn("client did not complete initialization in time",
+		"timeout", s.initializeTimeout, "droppedRequests", dropped, "connectionID", connectionID)
+
+	if received && session != nil {
+		s.endSession(session.ID, "initialize_timeout")
+	}
+}
+
+// completeHandshake marks a connection initialized, and processes the
+// requests held until then, sending their responses to the connection.
+func (s *serverImpl) completeHandshake(connectionID string) {
+	s.mu.Lock()
+	h := s.handshakeFor(connectionID)
+	h.initialized = true
+	queued := h.queued
+	h.queued = nil
+	if h.timer != nil {
+		h.timer.Stop()
+		h.timer = nil
+	}
+	s.mu.Unlock()
+
+	for _, message := range queued {
+		response, err := HandleMessage(s, message)
+		if err != nil {
+			s.logger.Error("failed to process queued request", "error", err)
+			continue
+		}
+		if response == nil || s.transport == nil {
+			continue
+		}
+		if sender, ok := s.transport.(transport.SessionSender); ok && connectionID != "" {
+			err = sender.SendTo(connectionID, response)
+		} else {
+			err = s.sendMessage(response)
+		}
+		if err != nil {
+			s.logger.Error("failed to send response to queued request", "error", err)
+		}
+	}
+}
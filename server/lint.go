@@ -0,0 +1,20 @@
+package server
+
+import "github.com/localrivet/gomcp/util/schema"
+
+// LintAll runs schema.Lint over every registered tool, in name order, and
+// returns the issues found. Adding a test that fails on any issue stops a
+// new parameter type from shipping a schema hosts reject or models misuse:
+//
+//	func TestToolSchemas(t *testing.T) {
+//	    for _, issue := range server.LintAll(newServer()) {
+//	        t.Error(issue)
+//	    }
+//	}
+func LintAll(srv Server) []schema.Issue {
+	var issues []schema.Issue
+	for tool := range srv.EachTool {
+		issues = append(issues, schema.Lint(schema.Tool{Name: tool.Name, InputSchema: tool.Schema})...)
+	}
+	return issues
+}
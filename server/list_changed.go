@@ -0,0 +1,64 @@
+package server
+
+import (
+	"time"
+
+	"github.com/localrivet/gomcp/transport"
+)
+
+// listChangedWindow is how long changes to a list are gathered before
+// clients are told, so registering several tools in a row sends a single
+// notification.
+const listChangedWindow = 50 * time.Millisecond
+
+// The list_changed notifications of the lists clients can watch.
+const (
+	toolsListChanged     = "notifications/tools/list_changed"
+	resourcesListChanged = "notifications/resources/list_changed"
+	promptsListChanged   = "notifications/prompts/list_changed"
+)
+
+// notifyListChanged tells initialized clients that a list changed, once
+// the changes of the current window are in. It doesn't take s.mu, so the
+// registration methods call it while holding the lock. Changes made before
+// any client initialized aren't announced, as clients list after the
+// handshake anyway.
+func (s *serverImpl) notifyListChanged(method string) {
+	s.listChanges.record(method, listChangedWindow)
+}
+
+// sendListChanged sends a list_changed notification to the initialized
+// sessions. On transports that can address a single client, sessions bound
+// to a connection get their own copy, so clients still in the handshake
+// don't hear of it; otherwise the notification is broadcast.
+func (s *serverImpl) sendListChanged(method string) {
+	s.mu.RLock()
+	initialized := s.initialized
+	s.mu.RUnlock()
+	if !initialized {
+		return
+	}
+
+	sender, addressable := s.transport.(transport.SessionSender)
+	if !addressable {
+		s.sendNotification(method, nil)
+		return
+	}
+
+	unbound := false
+	for _, session := range s.sessionManager.Sessions() {
+		switch {
+		case !session.initialized:
+		case session.ConnectionID == "":
+			unbound = true
+		default:
+			if err := s.sendNotificationTo(sender, session.ConnectionID, method, nil); err != nil {
+				s.logger.Debug("failed to deliver list change", "method", method, "connectionID", session.ConnectionID, "error", err)
+			}
+		}
+	}
+	if unbound {
+		s.sendNotification(method, nil)
+	}
+	s.logger.Debug("sent list changed notification", "method", method)
+}
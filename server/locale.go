@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// timezoneMetaKey is the _meta key clients use to declare their IANA time zone.
+const timezoneMetaKey = "timezone"
+
+// recordClientHints stores the locale and time zone the client declared in
+// the initialize request's _meta on its session.
+func (s *serverImpl) recordClientHints(session *ClientSession, params json.RawMessage) {
+	for _, key := range []string{localeMetaKey, timezoneMetaKey} {
+		if value := extractMetaString(params, key); value != "" {
+			session.Metadata[key] = value
+		}
+	}
+}
+
+// clientHint returns a hint for the current request, preferring the request's
+// own _meta over the value the client declared at initialize.
+func (c *Context) clientHint(key string) string {
+	if c.Request != nil {
+		if value := extractMetaString(c.Request.Params, key); value != "" {
+			return value
+		}
+	}
+	return c.sessionHint(key)
+}
+
+// sessionHint returns a hint the client declared at initialize.
+func (c *Context) sessionHint(key string) string {
+	if c.server == nil || c.server.sessionManager == nil {
+		return ""
+	}
+	if session, ok := c.server.sessionManager.GetSession(c.sessionID()); ok {
+		return session.Metadata[key]
+	}
+	return ""
+}
+
+// Locale returns the client's preferred language, or language.Und if the
+// client did not declare one. Clients declare it with the "locale" key in the
+// _meta of the initialize request (or of an individual request); HTTP
+// transports also fill it from the Accept-Language header.
+//
+// Example:
+//
+//	printer := message.NewPrinter(ctx.Locale())
+//	return printer.Sprintf("%d items", count), nil
+func (c *Context) Locale() language.Tag {
+	locale := c.clientHint(localeMetaKey)
+	if locale == "" {
+		return language.Und
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(locale)
+	if err != nil || len(tags) == 0 {
+		return language.Und
+	}
+	return tags[0]
+}
+
+// Location returns the client's time zone, or time.UTC if the client did not
+// declare one or declared an unknown zone. Clients declare it with the
+// "timezone" key in the _meta of the initialize request, using an IANA name
+// such as "America/New_York".
+//
+// Example:
+//
+//	return time.Now().In(ctx.Location()).Format(time.Kitchen), nil
+func (c *Context) Location() *time.Location {
+	name := c.clientHint(timezoneMetaKey)
+	if name == "" {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		if c.Logger != nil {
+			c.Logger.Debug("ignoring unknown client time zone", "timezone", name, "error", err)
+		}
+		return time.UTC
+	}
+	return loc
+}
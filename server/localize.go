@@ -0,0 +1,147 @@
+package server
+
+import (
+	"github.com/localrivet/gomcp/util/schema"
+	"golang.org/x/text/language"
+)
+
+// Localization is the text of a tool or prompt in one language. Fields left
+// empty fall back to the text the tool or prompt was registered with.
+type Localization struct {
+	// Title is the human-readable name hosts show in place of the name,
+	// which stays the same in every language as clients call it by name.
+	Title string
+
+	// Description replaces the registered description.
+	Description string
+
+	// Arguments holds argument descriptions by argument name: the
+	// properties of a tool's input schema and the arguments of a prompt.
+	Arguments map[string]string
+}
+
+// localizations are the translations of a tool or prompt, and the matcher
+// choosing among their languages. The first tag of the matcher is
+// language.Und, standing for the registered text.
+type localizations struct {
+	matcher language.Matcher
+	texts   []Localization
+}
+
+// WithLocalizations registers translations of the tool, and of the prompt,
+// named name, keyed by BCP 47 language tag such as "fr" or "pt-BR".
+// tools/list and prompts/list give each client the translation that best
+// matches the language it declared (see Context.Locale), and the registered
+// text to clients whose language has none. Registering again replaces the
+// translations.
+//
+// Example:
+//
+//	srv.WithLocalizations("weather", map[string]server.Localization{
+//	    "fr": {Title: "Météo", Description: "Donne la météo actuelle d'une ville",
+//	        Arguments: map[string]string{"city": "La ville"}},
+//	    "de": {Title: "Wetter", Description: "Zeigt das aktuelle Wetter einer Stadt"},
+//	})
+func (s *serverImpl) WithLocalizations(name string, translations map[string]Localization) Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, isTool := s.tools[name]
+	_, isPrompt := s.prompts[name]
+	if !isTool && !isPrompt {
+		s.logger.Error("tool or prompt not found for localizations", "name", name)
+		return s
+	}
+
+	tags := []language.Tag{language.Und}
+	texts := []Localization{{}}
+	for lang, text := range translations {
+		tag, err := language.Parse(lang)
+		if err != nil {
+			s.logger.Error("invalid language for localization", "name", name, "language", lang, "error", err)
+			continue
+		}
+		tags = append(tags, tag)
+		texts = append(texts, text)
+	}
+
+	if s.localizations == nil {
+		s.localizations = make(map[string]*localizations)
+	}
+	s.localizations[name] = &localizations{matcher: language.NewMatcher(tags), texts: texts}
+	if isTool {
+		s.toolsChanged = true
+		s.notifyListChanged(toolsListChanged)
+	}
+	if isPrompt {
+		s.notifyListChanged(promptsListChanged)
+	}
+	return s
+}
+
+// localization returns the translation of a tool or prompt for the
+// request's client, and false if the registered text applies. Callers hold
+// s.mu.
+func (s *serverImpl) localization(ctx *Context, name string) (Localization, bool) {
+	translations, ok := s.localizations[name]
+	if !ok {
+		return Localization{}, false
+	}
+
+	var preferred []language.Tag
+	for _, lang := range ctx.languagePreferences() {
+		tags, _, err := language.ParseAcceptLanguage(lang)
+		if err == nil {
+			preferred = append(preferred, tags...)
+		}
+	}
+	if len(preferred) == 0 {
+		return Localization{}, false
+	}
+
+	_, index, confidence := translations.matcher.Match(preferred...)
+	if index == 0 || confidence == language.No {
+		return Localization{}, false
+	}
+	return translations.texts[index], true
+}
+
+// localizeInfo sets the title and description of a tools/list or
+// prompts/list entry from a translation.
+func localizeInfo(info map[string]interface{}, text Localization) {
+	if text.Title != "" {
+		info["title"] = text.Title
+	}
+	if text.Description != "" {
+		info["description"] = text.Description
+	}
+}
+
+// describeArguments returns a schema transform setting the descriptions of
+// the properties of a tool's input schema.
+func describeArguments(descriptions map[string]string) schema.Transform {
+	return func(toolSchema map[string]interface{}) {
+		properties, _ := toolSchema["properties"].(map[string]interface{})
+		for name, description := range descriptions {
+			if property, ok := properties[name].(map[string]interface{}); ok && description != "" {
+				property["description"] = description
+			}
+		}
+	}
+}
+
+// localizedArguments returns prompt arguments with translated
+// descriptions.
+func localizedArguments(arguments []PromptArgument, descriptions map[string]string) []PromptArgument {
+	if len(descriptions) == 0 {
+		return arguments
+	}
+	localized := make([]PromptArgument, len(arguments))
+	for i, argument := range arguments {
+		if description := descriptions[argument.Name]; description != "" {
+			argument.Description = description
+		}
+		localized[i] = argument
+	}
+	return localized
+}
@@ -5,28 +5,123 @@ import (
 	"fmt"
 )
 
+// LogLevel is the severity of a log message sent to clients, one of the
+// eight syslog levels MCP defines.
+type LogLevel string
+
+// Log levels, least severe first.
+const (
+	LogDebug     LogLevel = "debug"
+	LogInfo      LogLevel = "info"
+	LogNotice    LogLevel = "notice"
+	LogWarning   LogLevel = "warning"
+	LogError     LogLevel = "error"
+	LogCritical  LogLevel = "critical"
+	LogAlert     LogLevel = "alert"
+	LogEmergency LogLevel = "emergency"
+)
+
+// DefaultLogLevel is the least severe level sent to clients that haven't
+// set one with logging/setLevel.
+const DefaultLogLevel = LogInfo
+
+// logLevelKey is the session metadata key holding the level a client set,
+// so it is saved with the session.
+const logLevelKey = "logLevel"
+
+// logLevels ranks the log levels by severity.
+var logLevels = map[LogLevel]int{
+	LogDebug:     0,
+	LogInfo:      1,
+	LogNotice:    2,
+	LogWarning:   3,
+	LogError:     4,
+	LogCritical:  5,
+	LogAlert:     6,
+	LogEmergency: 7,
+}
+
 // ProcessLoggingSetLevel processes a logging set level request.
 // This method handles client requests to change the server's logging level,
 // allowing dynamic control of log verbosity during server operation.
 //
+// The level applies to the requesting session: Context.Log sends it
+// messages at the level and above.
+//
 // Parameters:
 //   - ctx: The request context containing client information and request details
 //
 // Returns:
-//   - A success response if the log level was updated
-//   - An error if the request is invalid or the operation fails
+//   - An empty result if the log level was updated
+//   - An invalid params error if the level isn't one of the eight levels
 func (s *serverImpl) ProcessLoggingSetLevel(ctx *Context) (interface{}, error) {
 	// Parse the request
 	var params struct {
-		Level string `json:"level"`
+		Level LogLevel `json:"level"`
 	}
 	if err := json.Unmarshal(ctx.Request.Params, &params); err != nil {
-		return nil, fmt.Errorf("invalid params: %w", err)
+		return nil, NewInvalidParametersError(fmt.Sprintf("invalid params: %v", err))
+	}
+	if _, ok := logLevels[params.Level]; !ok {
+		return nil, NewInvalidParametersError(fmt.Sprintf("unknown log level %q", params.Level))
+	}
+
+	ctx.SetSessionValue(logLevelKey, string(params.Level))
+	s.logger.Debug("set client log level", "session", ctx.SessionID(), "level", params.Level)
+
+	return map[string]interface{}{}, nil
+}
+
+// Log sends a notifications/message to the client of the current request,
+// if level is at or above the level the client set with logging/setLevel,
+// or DefaultLogLevel if it set none. The logger names the message's source
+// and may be empty; data is any JSON-serializable value, typically a
+// string or a map of details.
+//
+// Example:
+//
+//	srv.Tool("sync", "Sync the repository", func(ctx *server.Context, args SyncArgs) (string, error) {
+//	    ctx.Log(server.LogInfo, "git", map[string]interface{}{"message": "fetching", "remote": args.Remote})
+//	    if err := fetch(args.Remote); err != nil {
+//	        ctx.Log(server.LogError, "git", err.Error())
+//	        return "", err
+//	    }
+//	    return "synced", nil
+//	})
+func (c *Context) Log(level LogLevel, logger string, data interface{}) error {
+	severity, ok := logLevels[level]
+	if !ok {
+		return fmt.Errorf("unknown log level %q", level)
+	}
+	if c.server == nil {
+		return fmt.Errorf("cannot send log message: context has no server")
 	}
 
-	// Update the logger level
-	// TODO: Implement proper level setting
-	s.logger.Debug("setting log level", "level", params.Level)
+	minimum := LogLevel(c.sessionHint(logLevelKey))
+	if _, ok := logLevels[minimum]; !ok {
+		minimum = DefaultLogLevel
+	}
+	if severity < logLevels[minimum] {
+		return nil
+	}
 
-	return map[string]interface{}{"success": true}, nil
+	params := map[string]interface{}{
+		"level": level,
+		"data":  data,
+	}
+	if logger != "" {
+		params["logger"] = logger
+	}
+	message, err := notificationMessage("notifications/message", params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal log message: %w", err)
+	}
+	if c.server.transport == nil {
+		return fmt.Errorf("cannot send log message: no transport configured")
+	}
+	if err := c.server.sendToSession(c.sessionID(), message); err != nil {
+		c.server.metrics.notificationFailed("notifications/message")
+		return fmt.Errorf("failed to send log message: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/localrivet/gomcp/protocol"
+)
+
+// MaintenanceCode is the JSON-RPC error code of tool calls refused while the
+// server is in maintenance mode. The gRPC transport maps it to Unavailable.
+const MaintenanceCode = protocol.Maintenance
+
+// MaintenanceStatus describes maintenance mode while it is on.
+type MaintenanceStatus struct {
+	Message string    `json:"message,omitempty"`
+	Since   time.Time `json:"since"`
+}
+
+// maintenanceMode holds whether maintenance mode is on.
+type maintenanceMode struct {
+	mu     sync.RWMutex
+	status *MaintenanceStatus
+}
+
+// current returns the maintenance status, or nil when it is off.
+func (m *maintenanceMode) current() *MaintenanceStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.status == nil {
+		return nil
+	}
+	status := *m.status
+	return &status
+}
+
+// SetMaintenance turns maintenance mode on or off. While it is on, sessions
+// stay open and resources, prompts and tool lists are served as usual, but
+// new tool calls fail with a MaintenanceCode error whose data carries
+// message, telling clients to retry later. Calls already running finish.
+// Health reports the mode, so operators can see it from the admin /health
+// endpoint.
+//
+// Example:
+//
+//	srv.SetMaintenance(true, "Migrating the orders database, back in 10 minutes")
+//	defer srv.SetMaintenance(false, "")
+//	migrate()
+func (s *serverImpl) SetMaintenance(on bool, message string) {
+	s.maintenance.mu.Lock()
+	defer s.maintenance.mu.Unlock()
+
+	if !on {
+		if s.maintenance.status != nil {
+			s.logger.Info("maintenance mode off")
+		}
+		s.maintenance.status = nil
+		return
+	}
+	since := time.Now()
+	if s.maintenance.status != nil {
+		since = s.maintenance.status.Since
+	}
+	s.maintenance.status = &MaintenanceStatus{Message: message, Since: since}
+	s.logger.Info("maintenance mode on", "message", message)
+}
+
+// rejectUnderMaintenance refuses tool calls while maintenance mode is on.
+func (s *serverImpl) rejectUnderMaintenance(ctx *Context) ([]byte, bool) {
+	if ctx.Request.Method != "tools/call" || ctx.Request.ID == nil {
+		return nil, false
+	}
+	status := s.maintenance.current()
+	if status == nil {
+		return nil, false
+	}
+	return createErrorResponse(ctx.Request.ID, MaintenanceCode, "Server under maintenance", map[string]interface{}{
+		"reason":  "maintenance",
+		"message": status.Message,
+	}), true
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"github.com/localrivet/gomcp/util/textutil"
+)
+
+// MarkdownHTMLCapability is the client capability, under "experimental",
+// declaring that the host renders raw HTML in Markdown. MarkdownPolicy's
+// StripHTML leaves HTML in place for clients that declare it:
+//
+//	"capabilities": {"experimental": {"markdown": {"html": true}}}
+const MarkdownHTMLCapability = "experimental.markdown.html"
+
+// MarkdownPolicy sets how WithMarkdown post-processes the text of tool
+// results.
+type MarkdownPolicy struct {
+	// LinkBase resolves relative link and image targets against a resource
+	// URI such as "docs://handbook/", so hosts can follow them with
+	// resources/read. Links are left unchanged if it is empty.
+	LinkBase string
+
+	// StripHTML removes raw HTML for clients that don't declare the
+	// MarkdownHTMLCapability.
+	StripHTML bool
+
+	// NormalizeFences rewrites code fences in one form that every host
+	// renders alike, closing any left open.
+	NormalizeFences bool
+}
+
+// WithMarkdown post-processes the text content of every tool result as
+// Markdown, after the sanitizer and before the content scanner, to make
+// results render the same across hosts.
+//
+// Example:
+//
+//	srv := server.NewServer("docs",
+//	    server.WithMarkdown(server.MarkdownPolicy{
+//	        LinkBase:        "docs://handbook/",
+//	        StripHTML:       true,
+//	        NormalizeFences: true,
+//	    }),
+//	)
+func WithMarkdown(policy MarkdownPolicy) Option {
+	return func(s *serverImpl) {
+		s.markdown = &policy
+		s.markdownLinks = textutil.ResolveLinks(policy.LinkBase)
+	}
+}
+
+// processMarkdown applies the WithMarkdown policy to the text items of a
+// formatted tool result.
+func (s *serverImpl) processMarkdown(ctx *Context, result map[string]interface{}) map[string]interface{} {
+	if s.markdown == nil {
+		return result
+	}
+
+	stages := []textutil.Sanitizer{}
+	if s.markdown.NormalizeFences {
+		stages = append(stages, textutil.NormalizeCodeFences)
+	}
+	if s.markdown.LinkBase != "" {
+		stages = append(stages, s.markdownLinks)
+	}
+	if s.markdown.StripHTML && !s.clientRendersHTML(ctx) {
+		stages = append(stages, textutil.StripHTML)
+	}
+	process := textutil.Chain(stages...)
+
+	for _, item := range contentItems(result["content"]) {
+		if text, ok := item["text"].(string); ok && item["type"] == "text" {
+			item["text"] = process(text)
+		}
+	}
+	return result
+}
+
+// clientRendersHTML reports whether the request's client declared the
+// MarkdownHTMLCapability.
+func (s *serverImpl) clientRendersHTML(ctx *Context) bool {
+	clientInfo, found := s.getClientInfoForSession(ctx.sessionID())
+	return found && clientInfo.Capabilities != nil && hasCapability(clientInfo.Capabilities, MarkdownHTMLCapability)
+}
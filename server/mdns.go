@@ -0,0 +1,103 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/localrivet/gomcp/transport"
+	"github.com/localrivet/gomcp/util/mdns"
+)
+
+// addrTransport is implemented by network transports that expose their listen address.
+type addrTransport interface {
+	GetAddr() string
+}
+
+// WithMDNS advertises the server on the local network via mDNS as an
+// "_mcp._tcp" service once Run starts an HTTP-based transport.
+//
+// The instance parameter is the advertised service name; if empty, the server
+// name is used. Advertisement failures are logged and do not stop the server.
+//
+// Example:
+//
+//	server.NewServer("weather",
+//	    server.WithMDNS(""),
+//	).AsSSE(":8080")
+func WithMDNS(instance string) Option {
+	return func(s *serverImpl) {
+		if instance == "" {
+			instance = s.name
+		}
+		s.mdnsInstance = instance
+	}
+}
+
+// startMDNS begins advertising the server if mDNS was enabled with WithMDNS.
+func (s *serverImpl) startMDNS(t transport.Transport) {
+	if s.mdnsInstance == "" {
+		return
+	}
+
+	service, err := s.mdnsService(t)
+	if err != nil {
+		s.logger.Warn("not advertising server via mDNS", "error", err)
+		return
+	}
+
+	responder, err := mdns.Advertise(service)
+	if err != nil {
+		s.logger.Warn("failed to advertise server via mDNS", "error", err)
+		return
+	}
+
+	s.mu.Lock()
+	s.mdnsResponder = responder
+	s.mu.Unlock()
+
+	s.logger.Info("advertising server via mDNS",
+		"instance", service.Instance,
+		"service", mdns.ServiceType,
+		"port", service.Port)
+}
+
+// mdnsService describes the server's HTTP-based transport as an mDNS service.
+func (s *serverImpl) mdnsService(t transport.Transport) (mdns.Service, error) {
+	addrT, ok := t.(addrTransport)
+	if !ok {
+		return mdns.Service{}, fmt.Errorf("transport %T is not network based", t)
+	}
+
+	doc := s.DiscoveryDocument()
+	if len(doc.Transports) == 0 {
+		return mdns.Service{}, fmt.Errorf("transport %T is not HTTP based", t)
+	}
+
+	_, portStr, err := net.SplitHostPort(addrT.GetAddr())
+	if err != nil {
+		return mdns.Service{}, fmt.Errorf("invalid listen address: %w", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port == 0 {
+		return mdns.Service{}, fmt.Errorf("listen address %q has no fixed port", addrT.GetAddr())
+	}
+
+	endpoint := doc.Transports[0]
+	text := map[string]string{
+		"name":      doc.Name,
+		"version":   doc.Version,
+		"transport": endpoint.Type,
+		"path":      endpoint.Endpoint,
+	}
+	if endpoint.MessageEndpoint != "" {
+		text["messagePath"] = endpoint.MessageEndpoint
+	}
+
+	return mdns.Service{
+		Instance: s.mdnsInstance,
+		Port:     port,
+		Text:     text,
+		Logger:   s.logger,
+	}, nil
+}
@@ -0,0 +1,346 @@
+package server
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"runtime/debug"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/localrivet/gomcp/webhook"
+)
+
+// MemoryPressure is how close the process is to its memory limit.
+type MemoryPressure int32
+
+const (
+	// MemoryNormal means there is headroom; nothing is shed.
+	MemoryNormal MemoryPressure = iota
+
+	// MemoryHigh means usage has crossed MemoryPolicy.High. Optional work is
+	// shed: resource updates are coalesced, freed memory is returned to the
+	// operating system and handlers are asked to shrink caches and pause
+	// prefetching.
+	MemoryHigh
+
+	// MemoryCritical means usage has crossed MemoryPolicy.Critical. On top
+	// of what MemoryHigh sheds, new sessions are rejected.
+	MemoryCritical
+)
+
+// String returns "normal", "high" or "critical".
+func (p MemoryPressure) String() string {
+	switch p {
+	case MemoryHigh:
+		return "high"
+	case MemoryCritical:
+		return "critical"
+	default:
+		return "normal"
+	}
+}
+
+// MemoryUsage is one reading of the process's memory.
+type MemoryUsage struct {
+	// RSS is the resident set size, or zero where it can't be read.
+	RSS uint64
+
+	// Runtime is the memory mapped by the Go runtime and not returned to the
+	// operating system, which is what GOMEMLIMIT is measured against.
+	Runtime uint64
+
+	// Limit is the memory the process may use.
+	Limit uint64
+}
+
+// Ratio returns the larger of RSS and Runtime as a fraction of Limit.
+func (u MemoryUsage) Ratio() float64 {
+	if u.Limit == 0 {
+		return 0
+	}
+	used := u.RSS
+	if u.Runtime > used {
+		used = u.Runtime
+	}
+	return float64(used) / float64(u.Limit)
+}
+
+// MemoryPolicy configures memory pressure handling. The zero value watches
+// the process against GOMEMLIMIT, or failing that its cgroup limit.
+type MemoryPolicy struct {
+	// Limit is the memory the process may use in bytes. It defaults to
+	// GOMEMLIMIT if set, then to the memory limit of the cgroup the process
+	// runs in.
+	Limit uint64
+
+	// High and Critical are the fractions of Limit at which pressure becomes
+	// MemoryHigh and MemoryCritical. They default to 0.8 and 0.9. Pressure
+	// drops a level once usage falls five points below the level's threshold,
+	// so readings hovering around a threshold don't flap.
+	High     float64
+	Critical float64
+
+	// Interval is how often usage is read. It defaults to two seconds.
+	Interval time.Duration
+
+	// CoalesceWindow is the smallest window resource updates are coalesced
+	// in while under pressure, whatever WithResourceUpdateWindow sets. It
+	// defaults to DefaultResourceUpdateWindow.
+	CoalesceWindow time.Duration
+
+	// OnChange, if set, is called when the pressure level changes, from the
+	// monitor's goroutine. Use it to shrink caches and pause prefetching,
+	// and to resume once pressure returns to MemoryNormal.
+	OnChange func(level MemoryPressure, usage MemoryUsage)
+
+	// Sample reads the current usage. It defaults to reading the Go runtime's
+	// metrics and, on Linux, the RSS from /proc. A zero Limit in the result
+	// is replaced by the policy's Limit.
+	Sample func() MemoryUsage
+}
+
+// memoryHysteresis is how far below a threshold usage must fall before the
+// pressure level drops.
+const memoryHysteresis = 0.05
+
+// WithMemoryPressure sheds load when the process nears its memory limit,
+// rather than letting it be killed for running out.
+//
+// While usage is over the policy's High threshold, resource updates are
+// coalesced even if WithResourceUpdateWindow disabled it, memory freed by the
+// garbage collector is returned to the operating system, and OnChange and
+// Context.MemoryPressure let handlers shrink caches and defer prefetching.
+// Over the Critical threshold, initialize requests are also rejected with a
+// RateLimitedCode error carrying retryAfter, so clients back off as they do
+// from rate limits while existing sessions carry on.
+//
+// Every change of level is logged and sent as a memory.pressure event to
+// webhooks and event handlers, for alerting. Monitoring starts with Run; if
+// no limit is configured or found, it is disabled with a warning.
+//
+// Example:
+//
+//	srv := server.NewServer("my-service",
+//	    server.WithMemoryPressure(server.MemoryPolicy{
+//	        OnChange: func(level server.MemoryPressure, _ server.MemoryUsage) {
+//	            if level > server.MemoryNormal {
+//	                cache.Purge()
+//	            }
+//	        },
+//	    }),
+//	)
+func WithMemoryPressure(policy MemoryPolicy) Option {
+	return func(s *serverImpl) {
+		if policy.High <= 0 {
+			policy.High = 0.8
+		}
+		if policy.Critical <= 0 {
+			policy.Critical = 0.9
+		}
+		if policy.Critical < policy.High {
+			policy.Critical = policy.High
+		}
+		if policy.Interval <= 0 {
+			policy.Interval = 2 * time.Second
+		}
+		if policy.CoalesceWindow <= 0 {
+			policy.CoalesceWindow = DefaultResourceUpdateWindow
+		}
+		if policy.Sample == nil {
+			policy.Sample = readMemoryUsage
+		}
+		s.memory = &memoryMonitor{policy: policy, stop: make(chan struct{})}
+	}
+}
+
+// MemoryPressure returns the current memory pressure level, which is always
+// MemoryNormal unless WithMemoryPressure is set.
+func (s *serverImpl) MemoryPressure() MemoryPressure {
+	return s.memory.current()
+}
+
+// MemoryPressure returns the server's memory pressure level, so handlers can
+// skip optional work such as prefetching while memory is short.
+func (c *Context) MemoryPressure() MemoryPressure {
+	if c.server == nil {
+		return MemoryNormal
+	}
+	return c.server.MemoryPressure()
+}
+
+// memoryMonitor tracks the pressure level set by WithMemoryPressure.
+type memoryMonitor struct {
+	policy   MemoryPolicy
+	level    atomic.Int32
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func (m *memoryMonitor) current() MemoryPressure {
+	if m == nil {
+		return MemoryNormal
+	}
+	return MemoryPressure(m.level.Load())
+}
+
+// next returns the level for a usage ratio given the current level.
+func (m *memoryMonitor) next(current MemoryPressure, ratio float64) MemoryPressure {
+	switch {
+	case ratio >= m.policy.Critical:
+		return MemoryCritical
+	case ratio >= m.policy.High:
+		if current == MemoryCritical && ratio >= m.policy.Critical-memoryHysteresis {
+			return MemoryCritical
+		}
+		return MemoryHigh
+	case current >= MemoryHigh && ratio >= m.policy.High-memoryHysteresis:
+		return MemoryHigh
+	default:
+		return MemoryNormal
+	}
+}
+
+func (m *memoryMonitor) close() {
+	if m != nil {
+		m.stopOnce.Do(func() { close(m.stop) })
+	}
+}
+
+// startMemoryMonitor starts watching memory if WithMemoryPressure was set.
+func (s *serverImpl) startMemoryMonitor() {
+	m := s.memory
+	if m == nil {
+		return
+	}
+	if m.policy.Limit == 0 {
+		m.policy.Limit = detectMemoryLimit()
+	}
+	if m.policy.Limit == 0 && m.policy.Sample().Limit == 0 {
+		s.logger.Warn("memory pressure handling disabled: no memory limit set or detected")
+		return
+	}
+
+	s.logger.Info("watching memory pressure", "limit", m.policy.Limit, "high", m.policy.High, "critical", m.policy.Critical)
+	go func() {
+		ticker := time.NewTicker(m.policy.Interval)
+		defer ticker.Stop()
+		for {
+			s.checkMemory()
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// checkMemory takes a reading and acts on a change of level.
+func (s *serverImpl) checkMemory() {
+	m := s.memory
+	usage := m.policy.Sample()
+	if usage.Limit == 0 {
+		usage.Limit = m.policy.Limit
+	}
+	previous := m.current()
+	level := m.next(previous, usage.Ratio())
+	if level == previous {
+		return
+	}
+	m.level.Store(int32(level))
+
+	attrs := []interface{}{"level", level.String(), "previous", previous.String(),
+		"rss", usage.RSS, "runtime", usage.Runtime, "limit", usage.Limit}
+	if level > previous {
+		s.logger.Warn("memory pressure rising, shedding load", attrs...)
+		// Hand what the collector frees back to the system now rather than
+		// when the scavenger gets to it
+		debug.FreeOSMemory()
+	} else {
+		s.logger.Info("memory pressure easing", attrs...)
+	}
+
+	s.emitEvent(webhook.EventMemoryPressure, map[string]interface{}{
+		"level":        level.String(),
+		"previous":     previous.String(),
+		"rssBytes":     usage.RSS,
+		"runtimeBytes": usage.Runtime,
+		"limitBytes":   usage.Limit,
+		"ratio":        math.Round(usage.Ratio()*1000) / 1000,
+	})
+	if m.policy.OnChange != nil {
+		m.policy.OnChange(level, usage)
+	}
+}
+
+// rejectUnderMemoryPressure returns the error response for an initialize
+// request received while memory is critical.
+func (s *serverImpl) rejectUnderMemoryPressure(ctx *Context) ([]byte, bool) {
+	if ctx.Request.Method != "initialize" || s.memory.current() < MemoryCritical {
+		return nil, false
+	}
+	s.logger.Warn("rejecting new session under memory pressure")
+	return createErrorResponse(ctx.Request.ID, RateLimitedCode, "Server under memory pressure", map[string]interface{}{
+		"reason":     "memory_pressure",
+		"retryAfter": int(math.Ceil(s.memory.policy.Interval.Seconds())),
+	}), true
+}
+
+// resourceUpdateWindowNow returns the window resource updates are coalesced
+// in, widened while memory is under pressure.
+func (s *serverImpl) resourceUpdateWindowNow() time.Duration {
+	window := s.resourceUpdateWindow
+	if s.memory.current() >= MemoryHigh && window < s.memory.policy.CoalesceWindow {
+		window = s.memory.policy.CoalesceWindow
+	}
+	return window
+}
+
+// readMemoryUsage reads the runtime's mapped memory and the process RSS.
+func readMemoryUsage() MemoryUsage {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+
+	var usage MemoryUsage
+	if samples[0].Value.Kind() == metrics.KindUint64 && samples[1].Value.Kind() == metrics.KindUint64 {
+		usage.Runtime = samples[0].Value.Uint64() - samples[1].Value.Uint64()
+	}
+	// The second field of statm is the resident page count
+	if statm, err := os.ReadFile("/proc/self/statm"); err == nil {
+		if fields := strings.Fields(string(statm)); len(fields) > 1 {
+			if pages, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+				usage.RSS = pages * uint64(os.Getpagesize())
+			}
+		}
+	}
+	return usage
+}
+
+// detectMemoryLimit returns GOMEMLIMIT if set, then the cgroup v2 or v1
+// memory limit, or zero if the process is unlimited.
+func detectMemoryLimit() uint64 {
+	if limit := debug.SetMemoryLimit(-1); limit > 0 && limit < math.MaxInt64 {
+		return uint64(limit)
+	}
+	for _, path := range []string{"/sys/fs/cgroup/memory.max", "/sys/fs/cgroup/memory/memory.limit_in_bytes"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		limit, err := strconv.ParseUint(string(bytes.TrimSpace(data)), 10, 64)
+		// "max", or v1's page-rounded MaxInt64, mean no limit
+		if err != nil || limit >= 1<<62 {
+			continue
+		}
+		return limit
+	}
+	return 0
+}
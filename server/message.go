@@ -5,13 +5,26 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
+
+	"github.com/localrivet/gomcp/transport"
 )
 
 // handleMessage processes incoming JSON-RPC messages from clients.
-// It determines if the message is a request or response and routes it appropriately.
-// For requests, it calls HandleMessage to process them; for responses, it calls
-// HandleJSONRPCResponse to match them with pending requests.
+// It counts their bytes for WithMetrics and routes them with routeMessage.
 func (s *serverImpl) handleMessage(message []byte) ([]byte, error) {
+	transportLabel := s.transportLabel()
+	s.metrics.countBytes(transportLabel, "in", len(message))
+	response, err := s.routeMessage(message)
+	s.metrics.countBytes(transportLabel, "out", len(response))
+	return response, err
+}
+
+// routeMessage determines if the message is a request or response and routes
+// it appropriately. For requests, it calls HandleMessage to process them; for
+// responses, it calls HandleJSONRPCResponse to match them with pending requests.
+func (s *serverImpl) routeMessage(message []byte) ([]byte, error) {
 	// Check if this is a response (has no "method" field but has "id")
 	var msg map[string]interface{}
 	if err := json.Unmarshal(message, &msg); err == nil {
@@ -30,17 +43,165 @@ func (s *serverImpl) handleMessage(message []byte) ([]byte, error) {
 	return HandleMessage(s, message)
 }
 
+// attachConnectionSession records the connection a request arrived on, as
+// named by the transport in its _meta, and the session bound to it.
+func (s *serverImpl) attachConnectionSession(ctx *Context) {
+	connectionID := ctx.Meta().String(transport.MetaConnectionID)
+	if connectionID == "" {
+		return
+	}
+	ctx.connectionID = connectionID
+	session, ok := s.sessionManager.SessionForConnection(connectionID)
+	if !ok {
+		// The session may have started on another replica
+		session = s.resumeSession(ctx.Context(), connectionID)
+		ok = session != nil
+	}
+	if ok {
+		if ctx.Metadata == nil {
+			ctx.Metadata = make(map[string]interface{})
+		}
+		ctx.Metadata["sessionID"] = string(session.ID)
+	}
+}
+
 // HandleMessage handles an incoming message from the transport.
 // It parses the message, routes it to the appropriate handler, and returns the response.
-func HandleMessage(s *serverImpl, message []byte) ([]byte, error) {
-	// Create a new context with the incoming message
-	ctx, err := NewContext(context.Background(), message, s)
+// Batch arrays are handled as set by WithBatchPolicy and answered with an
+// array of responses.
+func HandleMessage(s *serverImpl, message []byte) (reply []byte, err error) {
+	if isBatch(message) {
+		return s.handleBatch(message), nil
+	}
+
+	// Create a new context with the incoming message, canceled when the
+	// client cancels the request or it is answered
+	requestCtx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	ctx, err := NewContext(requestCtx, message, s)
 	if err != nil {
 		s.logger.Error("failed to create context", "error", err)
 		return createErrorResponse(nil, -32700, "Parse error", err.Error()), nil
 	}
 
+	// Attribute requests on transports serving several clients to the
+	// client's own session
+	s.attachConnectionSession(ctx)
+
+	// Any message from the client shows it is still there
+	s.sessionManager.touch(ctx.sessionID())
+
+	// Record the request in its trace, whatever the outcome
+	if s.tracer != nil {
+		end := s.traceRequest(ctx)
+		defer func() { end(reply) }()
+	}
+	if s.metrics != nil {
+		started := time.Now()
+		defer func() {
+			code, _ := responseError(reply)
+			s.metrics.observeRequest(ctx.Request.Method, code, time.Since(started))
+		}()
+	}
+
+	// Refuse new work once Shutdown has begun, and let it wait for the rest
+	if ctx.Request.ID != nil {
+		if !s.drain.begin() {
+			return createErrorResponse(ctx.Request.ID, ShuttingDownCode, "Server shutting down", nil), nil
+		}
+		defer s.drain.end()
+
+		// The handshake can't be cancelled
+		if ctx.Request.Method != "initialize" {
+			key := ctx.requestKey()
+			s.requestCanceller.start(key, cancel)
+			defer s.requestCanceller.finish(key)
+		}
+	}
+
+	// Hold back or reject requests that arrive out of handshake order
+	if response, handled := s.enforceLifecycle(ctx, message); handled {
+		return response, nil
+	}
+
+	// Turn away new sessions while memory is critical
+	if response, rejected := s.rejectUnderMemoryPressure(ctx); rejected {
+		return response, nil
+	}
+
+	// Refuse tool calls during maintenance
+	if response, rejected := s.rejectUnderMaintenance(ctx); rejected {
+		return response, nil
+	}
+
+	// Reject requests over a configured rate limit
+	if response, limited := s.enforceRateLimits(ctx); limited {
+		return response, nil
+	}
+
+	// Accept params shaped by older protocol revisions
+	s.upgradeLegacyParams(ctx)
+
+	result, err := s.handleRequest(ctx)
+
+	// Clients don't expect responses to the requests they cancelled
+	if ctx.Request.ID != nil && errors.Is(context.Cause(requestCtx), ErrRequestCancelled) {
+		s.logger.Debug("dropping response to cancelled request", "method", ctx.Request.Method, "requestId", ctx.RequestID)
+		return nil, nil
+	}
+
+	// Notifications don't need responses
+	if err == nil && strings.HasPrefix(ctx.Request.Method, "notifications/") {
+		return nil, nil
+	}
+
+	// Errors carrying their own JSON-RPC code are sent as they are
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return createErrorResponse(ctx.Request.ID, rpcErr.Code, rpcErr.Message, rpcErr.Data), nil
+	}
+
+	if err != nil {
+		s.logger.Error("failed to process message", "method", ctx.Request.Method, "error", err)
+		// Use the right error code:
+		// -32601 for "Method not implemented" messages
+		// -32602 for "Invalid parameters" errors
+		// -32603 for other internal errors
+		if err.Error() == fmt.Sprintf("method not implemented: %s", ctx.Request.Method) {
+			return createErrorResponse(ctx.Request.ID, -32601, "Method not implemented", err.Error()), nil
+		}
+
+		// A missing capability is reported as method not found, with an explanation
+		if _, ok := err.(*CapabilityError); ok {
+			return createErrorResponse(ctx.Request.ID, -32601, "Method not found", err.Error()), nil
+		}
+
+		// Check if it's an invalid parameters error
+		if _, ok := err.(*InvalidParametersError); ok {
+			return createErrorResponse(ctx.Request.ID, -32602, "Invalid params", err.Error()), nil
+		}
+
+		return createErrorResponse(ctx.Request.ID, -32603, "Internal error", err.Error()), nil
+	}
+
+	// Set the result in the response
+	ctx.Response.Result = result
+
+	// Encode the response as JSON
+	responseBytes, err := json.Marshal(ctx.Response)
+	if err != nil {
+		s.logger.Error("failed to marshal response", "error", err)
+		return createErrorResponse(ctx.Request.ID, -32603, "Internal error", "Failed to marshal response"), nil
+	}
+
+	return responseBytes, nil
+}
+
+// dispatch routes a request to the handler for its method. It is the
+// innermost RequestHandler of the middleware chain.
+func (s *serverImpl) dispatch(ctx *Context) (interface{}, error) {
 	var result interface{}
+	var err error
 
 	// Process the message based on its method
 	switch ctx.Request.Method {
@@ -57,7 +218,22 @@ func HandleMessage(s *serverImpl, message []byte) ([]byte, error) {
 	case "tools/list":
 		result, err = s.ProcessToolList(ctx)
 	case "tools/call":
+		if replayed, ok := s.replayIdempotent(ctx); ok {
+			result = replayed
+			break
+		}
+		reservation, rejection := s.reserveQuotas(ctx)
+		if rejection != nil {
+			return nil, rejection
+		}
+		started := time.Now()
 		result, err = s.ProcessToolCall(ctx)
+		s.rememberIdempotent(ctx, result, err)
+		if !s.meterToolCall(ctx, result, err) {
+			reservation.release()
+		}
+		s.observeToolCall(ctx, result, err, time.Since(started))
+		s.meterToolCallDuration(ctx, result, err, time.Since(started))
 
 	// Resource methods
 	case "resources/list":
@@ -77,6 +253,14 @@ func HandleMessage(s *serverImpl, message []byte) ([]byte, error) {
 	case "prompts/get":
 		result, err = s.ProcessPromptRequest(ctx)
 
+	// Upload methods
+	case "uploads/create":
+		result, err = s.ProcessUploadCreate(ctx)
+	case "uploads/append":
+		result, err = s.ProcessUploadAppend(ctx)
+	case "uploads/complete":
+		result, err = s.ProcessUploadComplete(ctx)
+
 	// Utility methods
 	case "logging/setLevel":
 		result, err = s.ProcessLoggingSetLevel(ctx)
@@ -93,58 +277,27 @@ func HandleMessage(s *serverImpl, message []byte) ([]byte, error) {
 	// Notifications
 	case "notifications/initialized":
 		// The client has finished initialization, process any pending notifications
-		s.handleInitializedNotification()
-		return nil, nil
+		s.handleInitializedNotification(ctx)
 	case "notifications/cancelled":
 		// Handle cancellation notification
-		if err := s.HandleCancelledNotification(message); err != nil {
+		if err := s.HandleCancelledNotification(ctx); err != nil {
 			s.logger.Error("failed to handle cancellation notification", "error", err)
 		}
-		return nil, nil
 	case "notifications/progress":
 	case "notifications/message":
 	case "notifications/resources/list_changed":
 	case "notifications/resources/updated":
 	case "notifications/tools/list_changed":
 	case "notifications/prompts/list_changed":
-	case "notifications/roots/list_changed":
 		// Notifications don't need responses
-		return nil, nil
+	case "notifications/roots/list_changed":
+		s.handleRootsListChanged(ctx)
 
 	default:
-		err = fmt.Errorf("method not found: %s", ctx.Request.Method)
-		return createErrorResponse(ctx.Request.ID, -32601, "Method not found", err.Error()), nil
+		return nil, &RPCError{Code: -32601, Message: "Method not found", Data: fmt.Sprintf("method not found: %s", ctx.Request.Method)}
 	}
 
-	if err != nil {
-		s.logger.Error("failed to process message", "method", ctx.Request.Method, "error", err)
-		// Use the right error code:
-		// -32601 for "Method not implemented" messages
-		// -32602 for "Invalid parameters" errors
-		// -32603 for other internal errors
-		if err.Error() == fmt.Sprintf("method not implemented: %s", ctx.Request.Method) {
-			return createErrorResponse(ctx.Request.ID, -32601, "Method not implemented", err.Error()), nil
-		}
-
-		// Check if it's an invalid parameters error
-		if _, ok := err.(*InvalidParametersError); ok {
-			return createErrorResponse(ctx.Request.ID, -32602, "Invalid params", err.Error()), nil
-		}
-
-		return createErrorResponse(ctx.Request.ID, -32603, "Internal error", err.Error()), nil
-	}
-
-	// Set the result in the response
-	ctx.Response.Result = result
-
-	// Encode the response as JSON
-	responseBytes, err := json.Marshal(ctx.Response)
-	if err != nil {
-		s.logger.Error("failed to marshal response", "error", err)
-		return createErrorResponse(ctx.Request.ID, -32603, "Internal error", "Failed to marshal response"), nil
-	}
-
-	return responseBytes, nil
+	return result, err
 }
 
 // HandleMessageWithVersion handles a JSON-RPC message with a forced MCP version.
@@ -0,0 +1,92 @@
+package server
+
+import "encoding/json"
+
+// Well-known _meta keys.
+const (
+	// MetaProgressToken is the token a client sends to receive progress notifications.
+	MetaProgressToken = "progressToken"
+
+	// MetaTraceParent carries a W3C trace context traceparent header value.
+	MetaTraceParent = "traceparent"
+
+	// MetaTraceState carries a W3C trace context tracestate header value.
+	MetaTraceState = "tracestate"
+
+	// MetaIdempotencyKey identifies retries of the same logical request.
+	MetaIdempotencyKey = "idempotencyKey"
+
+	// MetaAPIKey carries the caller's API key. HTTP-based transports fill it
+	// in from the X-API-Key header.
+	MetaAPIKey = "apiKey"
+)
+
+// Meta holds the _meta object sent with a request. It is never nil when
+// returned by Context.Meta, so lookups are always safe.
+type Meta map[string]interface{}
+
+// ProgressToken returns the request's progress token, or nil if the client
+// did not ask for progress notifications. Tokens may be strings or numbers.
+func (m Meta) ProgressToken() interface{} {
+	return m[MetaProgressToken]
+}
+
+// TraceParent returns the W3C traceparent sent with the request, if any.
+func (m Meta) TraceParent() string {
+	return m.String(MetaTraceParent)
+}
+
+// TraceState returns the W3C tracestate sent with the request, if any.
+func (m Meta) TraceState() string {
+	return m.String(MetaTraceState)
+}
+
+// IdempotencyKey returns the idempotency key sent with the request, if any.
+func (m Meta) IdempotencyKey() string {
+	return m.String(MetaIdempotencyKey)
+}
+
+// APIKey returns the API key sent with the request, if any.
+func (m Meta) APIKey() string {
+	return m.String(MetaAPIKey)
+}
+
+// String returns the value of key if it is a string, or "" otherwise.
+func (m Meta) String(key string) string {
+	value, _ := m[key].(string)
+	return value
+}
+
+// Meta returns the _meta fields of the current request, including custom
+// keys. Keys injected from transport headers, such as the locale derived
+// from Accept-Language, are included as well.
+//
+// Example:
+//
+//	srv.Tool("charge", "Charge a card", func(ctx *server.Context, args ChargeArgs) (string, error) {
+//	    if key := ctx.Meta().IdempotencyKey(); key != "" && alreadyCharged(key) {
+//	        return "already charged", nil
+//	    }
+//	    return charge(args)
+//	})
+func (c *Context) Meta() Meta {
+	if c.Request == nil {
+		return Meta{}
+	}
+	return requestMeta(c.Request.Params)
+}
+
+// requestMeta decodes the _meta object of request params.
+func requestMeta(params json.RawMessage) Meta {
+	if len(params) == 0 {
+		return Meta{}
+	}
+
+	var p struct {
+		Meta Meta `json:"_meta"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || p.Meta == nil {
+		return Meta{}
+	}
+	return p.Meta
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metric names are prefixed with MetricsNamespace and "server", as in
+// "mcp_server_requests_total".
+const MetricsNamespace = "mcp"
+
+// WithMetrics registers Prometheus metrics for the server with registerer:
+//
+//   - mcp_server_requests_total: requests handled, by method and JSON-RPC
+//     error code ("0" for success)
+//   - mcp_server_request_duration_seconds: request latency, by method
+//   - mcp_server_tool_call_duration_seconds: tool call latency, by tool and
+//     outcome ("ok", "error" or "rejected")
+//   - mcp_server_active_sessions: sessions currently open
+//   - mcp_server_notification_failures_total: notifications that could not
+//     be sent, by method
+//   - mcp_server_transport_bytes_total: message bytes received and sent,
+//     by transport and direction ("in" or "out")
+//
+// Serve them with MetricsHandler, or from the admin listener's /metrics
+// endpoint when registerer is also a prometheus.Gatherer, such as a
+// *prometheus.Registry. Registration errors are logged and leave metrics
+// disabled.
+//
+// Example:
+//
+//	registry := prometheus.NewRegistry()
+//	srv := server.NewServer("my-service", server.WithMetrics(registry))
+//	http.Handle("/metrics", server.MetricsHandler(registry))
+func WithMetrics(registerer prometheus.Registerer) Option {
+	return func(s *serverImpl) {
+		m := newServerMetrics(s)
+		if err := m.register(registerer); err != nil {
+			s.logger.Error("failed to register metrics", "error", err)
+			return
+		}
+		s.metrics = m
+		if gatherer, ok := registerer.(prometheus.Gatherer); ok {
+			s.metricsGatherer = gatherer
+		}
+	}
+}
+
+// MetricsHandler returns an http.Handler serving the metrics gathered by
+// gatherer in the Prometheus exposition format.
+func MetricsHandler(gatherer prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+}
+
+// serverMetrics holds the collectors registered by WithMetrics. Its methods
+// do nothing on a nil receiver, so call sites need no checks.
+type serverMetrics struct {
+	requests             *prometheus.CounterVec
+	requestDuration      *prometheus.HistogramVec
+	toolCallDuration     *prometheus.HistogramVec
+	activeSessions       prometheus.GaugeFunc
+	notificationFailures *prometheus.CounterVec
+	transportBytes       *prometheus.CounterVec
+}
+
+func newServerMetrics(s *serverImpl) *serverMetrics {
+	return &serverMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace, Subsystem: "server", Name: "requests_total",
+			Help: "Requests handled, by method and JSON-RPC error code.",
+		}, []string{"method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: MetricsNamespace, Subsystem: "server", Name: "request_duration_seconds",
+			Help:    "Time taken to handle requests, by method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method"}),
+		toolCallDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: MetricsNamespace, Subsystem: "server", Name: "tool_call_duration_seconds",
+			Help:    "Time taken by tool calls, by tool and outcome.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"tool", "outcome"}),
+		activeSessions: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: MetricsNamespace, Subsystem: "server", Name: "active_sessions",
+			Help: "Sessions currently open.",
+		}, func() float64 {
+			return float64(s.sessionManager.Count())
+		}),
+		notificationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace, Subsystem: "server", Name: "notification_failures_total",
+			Help: "Notifications that could not be sent, by method.",
+		}, []string{"method"}),
+		transportBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: MetricsNamespace, Subsystem: "server", Name: "transport_bytes_total",
+			Help: "Message bytes received and sent, by transport and direction.",
+		}, []string{"transport", "direction"}),
+	}
+}
+
+func (m *serverMetrics) register(registerer prometheus.Registerer) error {
+	for _, collector := range []prometheus.Collector{
+		m.requests, m.requestDuration, m.toolCallDuration,
+		m.activeSessions, m.notificationFailures, m.transportBytes,
+	} {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// observeRequest records a handled request. Methods the server doesn't
+// implement are counted as "other", so clients can't grow the number of
+// series without bound.
+func (m *serverMetrics) observeRequest(method string, code int, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	if code == -32601 {
+		method = "other"
+	}
+	m.requests.WithLabelValues(method, strconv.Itoa(code)).Inc()
+	m.requestDuration.WithLabelValues(method).Observe(elapsed.Seconds())
+}
+
+// observeToolCall records a finished call to a registered tool.
+func (m *serverMetrics) observeToolCall(tool, outcome string, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	m.toolCallDuration.WithLabelValues(tool, outcome).Observe(elapsed.Seconds())
+}
+
+// notificationFailed counts a notification that could not be sent.
+func (m *serverMetrics) notificationFailed(method string) {
+	if m == nil {
+		return
+	}
+	m.notificationFailures.WithLabelValues(method).Inc()
+}
+
+// countBytes counts message bytes moved by a transport.
+func (m *serverMetrics) countBytes(transport, direction string, n int) {
+	if m == nil || n == 0 {
+		return
+	}
+	m.transportBytes.WithLabelValues(transport, direction).Add(float64(n))
+}
+
+// transportLabel names the server's transport by its package, such as
+// "stdio" or "streamablehttp".
+func (s *serverImpl) transportLabel() string {
+	if s.transport == nil {
+		return "none"
+	}
+	name := strings.TrimPrefix(fmt.Sprintf("%T", s.transport), "*")
+	if pkg, _, found := strings.Cut(name, "."); found {
+		return pkg
+	}
+	return name
+}
+
+// sendMessage sends a message on the server's transport, counting its bytes.
+func (s *serverImpl) sendMessage(message []byte) error {
+	err := s.transport.Send(message)
+	if err == nil {
+		s.metrics.countBytes(s.transportLabel(), "out", len(message))
+	}
+	return err
+}
+
+// meterToolCallDuration records the latency of a tool call. Calls to tools
+// that aren't registered are left out, like unknown methods.
+func (s *serverImpl) meterToolCallDuration(ctx *Context, result interface{}, err error, elapsed time.Duration) {
+	if s.metrics == nil || ctx.Request == nil {
+		return
+	}
+	s.mu.RLock()
+	_, registered := s.tools[ctx.Request.ToolName]
+	s.mu.RUnlock()
+	if !registered {
+		return
+	}
+	s.metrics.observeToolCall(ctx.Request.ToolName, toolCallOutcome(result, err), elapsed)
+}
@@ -0,0 +1,70 @@
+package server
+
+// RequestHandler processes a JSON-RPC request or notification and returns
+// its result. Returning an *RPCError sends that error to the client as is;
+// other errors are mapped to JSON-RPC error codes as for method handlers.
+// The result of a notification is discarded.
+type RequestHandler func(ctx *Context) (interface{}, error)
+
+// Middleware wraps the handling of every incoming request and notification.
+// It can inspect the method, session and params through ctx, call next to
+// continue, or return without calling next to short-circuit.
+type Middleware func(next RequestHandler) RequestHandler
+
+// Use adds middleware around the handling of every incoming request and
+// notification, outermost first.
+//
+// Middleware runs after the initialize handshake ordering and any rate
+// limits set with WithRateLimitStore have been enforced, so requests held
+// until the client is initialized pass through it once, when they are
+// processed.
+//
+// Example:
+//
+//	srv.Use(func(next server.RequestHandler) server.RequestHandler {
+//	    return func(ctx *server.Context) (interface{}, error) {
+//	        if ctx.Request.Method == "tools/call" && ctx.Meta().APIKey() != apiKey {
+//	            return nil, &server.RPCError{Code: protocol.Unauthorized, Message: "Unauthorized"}
+//	        }
+//	        return next(ctx)
+//	    }
+//	})
+func (s *serverImpl) Use(middleware ...Middleware) Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.middleware = append(s.middleware, middleware...)
+	return s
+}
+
+// WithMiddleware adds middleware when the server is created, as Use does.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(s *serverImpl) {
+		s.middleware = append(s.middleware, middleware...)
+	}
+}
+
+// handleRequest runs a request through the middleware chain to dispatch.
+func (s *serverImpl) handleRequest(ctx *Context) (interface{}, error) {
+	s.mu.RLock()
+	middleware := s.middleware
+	tokenPrincipals := s.tokenPrincipals
+	s.mu.RUnlock()
+
+	handler := RequestHandler(s.dispatch)
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	if tokenPrincipals {
+		handler = tokenPrincipal(handler)
+	}
+	if s.errorReporting != nil {
+		handler = s.reportErrors(handler)
+	}
+	return handler(ctx)
+}
+
+// SessionID returns the ID of the session the request belongs to, or an
+// empty string before a session exists.
+func (c *Context) SessionID() string {
+	return string(c.sessionID())
+}
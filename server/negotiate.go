@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/json"
+)
+
+// Client capabilities, under "experimental", with which a host declares the
+// representations of tool results it renders. Clients speaking the draft
+// protocol accept structured content without declaring it.
+//
+//	"capabilities": {"experimental": {"markdown": {}, "structuredContent": true}}
+const (
+	MarkdownCapability          = "experimental.markdown"
+	StructuredContentCapability = "experimental.structuredContent"
+)
+
+// Representations is a tool result a handler produces in several forms, of
+// which the server sends the ones the calling client accepts: Markdown or
+// Text as the text content, and Structured as structuredContent. Forms left
+// empty are skipped.
+//
+// Example:
+//
+//	srv.Tool("weather", "Current weather", func(ctx *server.Context, args WeatherArgs) (interface{}, error) {
+//	    w := lookup(args.City)
+//	    return server.Representations{
+//	        Text:       fmt.Sprintf("%s: %d°C, %s", w.City, w.Temp, w.Sky),
+//	        Markdown:   fmt.Sprintf("**%s**: %d°C, _%s_", w.City, w.Temp, w.Sky),
+//	        Structured: w,
+//	    }, nil
+//	})
+type Representations struct {
+	Text       string
+	Markdown   string
+	Structured interface{}
+}
+
+// ClientFormats lists the representations a client accepts beyond plain
+// text.
+type ClientFormats struct {
+	Markdown   bool
+	Structured bool
+}
+
+// WithContentNegotiation sets how the representations a client accepts are
+// decided, replacing the default of checking its declared capabilities. The
+// function receives what the client sent at initialize, so hosts can also be
+// matched by name.
+//
+// Example:
+//
+//	server.WithContentNegotiation(func(client server.ClientInfo) server.ClientFormats {
+//	    if client.Name == "legacy-ide" {
+//	        return server.ClientFormats{}
+//	    }
+//	    return server.DefaultClientFormats(client)
+//	})
+func WithContentNegotiation(negotiate func(client ClientInfo) ClientFormats) Option {
+	return func(s *serverImpl) {
+		s.negotiate = negotiate
+	}
+}
+
+// DefaultClientFormats returns the representations a client accepts by its
+// declared capabilities and protocol version.
+func DefaultClientFormats(client ClientInfo) ClientFormats {
+	return ClientFormats{
+		Markdown:   hasCapability(client.Capabilities, MarkdownCapability),
+		Structured: client.ProtocolVersion == "draft" || hasCapability(client.Capabilities, StructuredContentCapability),
+	}
+}
+
+// ClientFormats returns the representations the request's client accepts,
+// for handlers choosing what to produce themselves.
+func (c *Context) ClientFormats() ClientFormats {
+	if c.server == nil {
+		return ClientFormats{}
+	}
+	return c.server.clientFormats(c)
+}
+
+// clientFormats negotiates the representations for the request's client.
+// Requests outside any initialized session get plain text only.
+func (s *serverImpl) clientFormats(ctx *Context) ClientFormats {
+	clientInfo, found := s.getClientInfoForSession(ctx.sessionID())
+	if !found {
+		return ClientFormats{}
+	}
+	if s.negotiate != nil {
+		return s.negotiate(clientInfo)
+	}
+	return DefaultClientFormats(clientInfo)
+}
+
+// negotiatedContent formats a Representations result for the request's
+// client. The text content falls back from the accepted form to any other,
+// and to the JSON of Structured if no text was given.
+func (s *serverImpl) negotiatedContent(ctx *Context, r Representations, result map[string]interface{}) {
+	formats := s.clientFormats(ctx)
+
+	text := r.Text
+	if (formats.Markdown && r.Markdown != "") || text == "" {
+		text = r.Markdown
+	}
+	if text == "" && r.Structured != nil {
+		jsonData, _ := json.MarshalIndent(r.Structured, "", "  ")
+		text = string(jsonData)
+	}
+	result["content"] = []map[string]interface{}{
+		{
+			"type": "text",
+			"text": text,
+		},
+	}
+
+	if formats.Structured && r.Structured != nil {
+		result["structuredContent"] = r.Structured
+	}
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/localrivet/gomcp/auth"
+	"github.com/localrivet/gomcp/transport"
+)
+
+// OAuthConfig configures the OAuth 2.1 authorization of a server's HTTP
+// endpoints.
+type OAuthConfig struct {
+	// Metadata is the protected resource metadata published at
+	// auth.MetadataPath, naming the server's authorization servers.
+	// ScopesSupported defaults to the scopes of ToolScopes and MethodScopes.
+	Metadata auth.ProtectedResourceMetadata
+
+	// Validator validates bearer tokens, usually an auth.JWKSValidator.
+	Validator auth.TokenValidator
+
+	// MetadataURL is the public URL of the metadata, for servers behind
+	// proxies that rewrite paths. It defaults to auth.MetadataPath on the
+	// host of each request.
+	MetadataURL string
+
+	// ToolScopes maps tool names to the scopes calling them requires.
+	ToolScopes map[string][]string
+
+	// MethodScopes maps JSON-RPC methods, such as "resources/read", to the
+	// scopes they require.
+	MethodScopes map[string][]string
+}
+
+// WithOAuth requires OAuth 2.1 bearer tokens on the server's HTTP
+// endpoints, as the MCP authorization specification describes. Requests
+// without a valid token are answered 401 with a challenge pointing clients
+// at the protected resource metadata, which the server publishes. Calls to
+// tools listed in ToolScopes are answered 403 unless the token grants their
+// scopes, as are calls to tools and reads of resources set
+// WithRequiredScopes. Handlers find the caller with Context.Principal.
+// An auth.JWKSValidator without an audience is set to require the
+// resource of the metadata in the tokens' aud claim.
+//
+// Stdio and in-process transports are not affected.
+//
+// Example:
+//
+//	srv := server.NewServer("my-service",
+//	    server.WithOAuth(server.OAuthConfig{
+//	        Metadata: auth.ProtectedResourceMetadata{
+//	            Resource:             "https://mcp.example.com",
+//	            AuthorizationServers: []string{"https://auth.example.com"},
+//	        },
+//	        Validator:  auth.NewJWKSValidator(jwksConfig),
+//	        ToolScopes: map[string][]string{"delete_file": {"files:write"}},
+//	    }),
+//	).AsStreamableHTTP(":8080")
+func WithOAuth(config OAuthConfig) Option {
+	return func(s *serverImpl) {
+		if len(config.Metadata.ScopesSupported) == 0 {
+			config.Metadata.ScopesSupported = config.scopesSupported()
+		}
+		// Only accept tokens issued for this server
+		if validator, ok := config.Validator.(*auth.JWKSValidator); ok && config.Metadata.Resource != "" {
+			validator.DefaultAudience(config.Metadata.Resource)
+		}
+		s.oauth = &config
+	}
+}
+
+// protectEndpoints publishes the protected resource metadata and wraps the
+// transport's endpoints with token validation.
+func (s *serverImpl) protectEndpoints(t transport.Transport) {
+	if s.oauth == nil {
+		return
+	}
+	if registrar, ok := t.(handlerRegistrar); ok {
+		registrar.RegisterHandler(auth.MetadataPath, auth.MetadataHandler(s.oauth.Metadata))
+	}
+	wrapper, ok := t.(transport.EndpointWrapper)
+	if !ok {
+		return
+	}
+	wrapper.WrapEndpoints(auth.Middleware(auth.MiddlewareConfig{
+		Validator:   s.oauth.Validator,
+		MetadataURL: s.oauth.MetadataURL,
+		Scopes:      s.requiredScopes,
+	}))
+
+	// The middleware replaces whatever metadata clients send about who
+	// they are, so it can be trusted
+	s.mu.Lock()
+	s.tokenPrincipals = true
+	s.mu.Unlock()
+}
+
+// requiredScopes returns the scopes a JSON-RPC request requires, from the
+// configuration and the tools and resources set WithRequiredScopes.
+func (s *serverImpl) requiredScopes(method string, params json.RawMessage) []string {
+	scopes := append([]string(nil), s.oauth.requiredScopes(method, params)...)
+
+	var target struct {
+		Name string `json:"name"`
+		URI  string `json:"uri"`
+	}
+	switch method {
+	case "tools/call":
+		if json.Unmarshal(params, &target) == nil {
+			s.mu.RLock()
+			if tool, ok := s.tools[target.Name]; ok {
+				scopes = append(scopes, tool.scopes...)
+			}
+			s.mu.RUnlock()
+		}
+	case "resources/read":
+		if json.Unmarshal(params, &target) == nil {
+			if resource, _, found := s.findResourceAndExtractParams(target.URI); found {
+				scopes = append(scopes, resource.scopes...)
+			}
+		}
+	}
+	return scopes
+}
+
+// requiredScopes returns the scopes the configuration requires of a
+// JSON-RPC request.
+func (c *OAuthConfig) requiredScopes(method string, params json.RawMessage) []string {
+	scopes := c.MethodScopes[method]
+	if method == "tools/call" && len(c.ToolScopes) > 0 {
+		var call struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(params, &call) == nil {
+			scopes = append(append([]string(nil), scopes...), c.ToolScopes[call.Name]...)
+		}
+	}
+	return scopes
+}
+
+// scopesSupported returns the scopes the configuration requires anywhere,
+// sorted.
+func (c *OAuthConfig) scopesSupported() []string {
+	if len(c.Metadata.ScopesSupported) > 0 {
+		return c.Metadata.ScopesSupported
+	}
+	seen := make(map[string]bool)
+	var scopes []string
+	for _, table := range []map[string][]string{c.ToolScopes, c.MethodScopes} {
+		for _, required := range table {
+			for _, scope := range required {
+				if !seen[scope] {
+					seen[scope] = true
+					scopes = append(scopes, scope)
+				}
+			}
+		}
+	}
+	sort.Strings(scopes)
+	return scopes
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// WithPageSize paginates tools/list, resources/list,
+// resources/templates/list and prompts/list, returning size entries per
+// page with a nextCursor for the rest. Lists aren't paginated unless it is
+// set, as clients that ignore nextCursor would only see the first page;
+// enable it only for clients that follow it. A size of zero or less
+// returns every entry in one page.
+func WithPageSize(size int) Option {
+	return func(s *serverImpl) {
+		if size < 0 {
+			size = 0
+		}
+		s.pageSize = size
+	}
+}
+
+// listCursor returns the key a list request resumes after, or "" for the
+// first page. Cursors are opaque to clients.
+func listCursor(ctx *Context) (string, error) {
+	if ctx.Request.Params == nil {
+		return "", nil
+	}
+	var params struct {
+		Cursor string `json:"cursor"`
+	}
+	if err := json.Unmarshal(ctx.Request.Params, &params); err != nil {
+		return "", fmt.Errorf("invalid params: %w", err)
+	}
+	if params.Cursor == "" {
+		return "", nil
+	}
+	key, err := base64.RawURLEncoding.DecodeString(params.Cursor)
+	if err != nil || len(key) == 0 {
+		return "", NewInvalidParametersError("invalid cursor")
+	}
+	return string(key), nil
+}
+
+// paginate calls add with the keys of registry in order, starting after
+// the cursor key, until a page's worth have been added. add reports whether
+// it added the entry, so entries hidden from the caller don't count. It
+// returns the cursor of the next page, or "" after the last page.
+func paginate[V any](s *serverImpl, registry map[string]V, after string, add func(key string, value V) bool) string {
+	size := s.pageSize
+	keys := slices.Sorted(maps.Keys(registry))
+	added := 0
+	for i, key := range keys {
+		if after != "" && key <= after {
+			continue
+		}
+		if size > 0 && added >= size {
+			return base64.RawURLEncoding.EncodeToString([]byte(keys[i-1]))
+		}
+		if add(key, registry[key]) {
+			added++
+		}
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+)
+
+// PageRequest gives the arguments of a paginated tool its cursor argument.
+// Embed it in the arguments struct of a handler wrapped by Paginated, or of
+// any tool returning a Page, so the input schema tells clients about it.
+//
+// Example:
+//
+//	type SearchArgs struct {
+//	    server.PageRequest
+//	    Query string `json:"query" required:"true"`
+//	}
+type PageRequest struct {
+	Cursor string `json:"cursor,omitempty" description:"The nextCursor of the previous page; omit for the first page"`
+}
+
+// PageCursor returns the cursor the page was requested with.
+func (p PageRequest) PageCursor() string {
+	return p.Cursor
+}
+
+// Page is one page of a tool's list-like result. Tools returning a Page send
+// it as structured content, and the client calls the tool again with
+// nextCursor as the cursor argument for the next page; client.IterateTool
+// does so until a page has no nextCursor.
+type Page[Item any] struct {
+	Items      []Item `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// EncodeCursor encodes the state a tool needs to resume a list as an opaque
+// cursor, for tools that page through their data themselves rather than
+// with Paginated.
+func EncodeCursor(state interface{}) (string, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor decodes a cursor made by EncodeCursor into state. A cursor
+// that doesn't decode returns an invalid parameters error.
+func DecodeCursor(cursor string, state interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || json.Unmarshal(data, state) != nil {
+		return NewInvalidParametersError("invalid cursor")
+	}
+	return nil
+}
+
+// toolCursor is the state of a cursor made by Paginated.
+type toolCursor struct {
+	Offset int    `json:"o"`
+	Args   string `json:"a,omitempty"`
+}
+
+// defaultToolPageSize is how many items a page of a Paginated tool holds
+// unless WithPageSize is set.
+const defaultToolPageSize = 50
+
+// Paginated wraps a tool handler returning a whole list into one returning
+// a Page of it at a time, holding as many items as WithPageSize sets for
+// the list methods, or 50 when it isn't set. The cursor remembers the other arguments, and is
+// rejected when they change between pages.
+//
+// The handler is called for every page, so it should be cheap to call
+// again; tools over large data sets should return a Page themselves, with a
+// cursor from EncodeCursor.
+//
+// Example:
+//
+//	srv.Tool("search", "Search the issues", server.Paginated(func(ctx *server.Context, args SearchArgs) ([]Issue, error) {
+//	    return tracker.Search(args.Query)
+//	}))
+func Paginated[Args, Item any](handler func(ctx *Context, args Args) ([]Item, error)) func(ctx *Context, args Args) (*Page[Item], error) {
+	return func(ctx *Context, args Args) (*Page[Item], error) {
+		cursor, fingerprint := pageCursor(ctx, args)
+
+		var state toolCursor
+		if cursor != "" {
+			if err := DecodeCursor(cursor, &state); err != nil {
+				return nil, err
+			}
+			if state.Offset < 0 || state.Args != fingerprint {
+				return nil, NewInvalidParametersError("invalid cursor")
+			}
+		}
+
+		items, err := handler(ctx, args)
+		if err != nil {
+			return nil, err
+		}
+
+		size := defaultToolPageSize
+		if ctx != nil && ctx.server != nil && ctx.server.pageSize > 0 {
+			size = ctx.server.pageSize
+		}
+
+		start := min(state.Offset, len(items))
+		end := len(items)
+		if size > 0 {
+			end = min(start+size, len(items))
+		}
+
+		page := &Page[Item]{Items: items[start:end]}
+		if page.Items == nil {
+			page.Items = []Item{}
+		}
+		if end < len(items) {
+			page.NextCursor, err = EncodeCursor(toolCursor{Offset: end, Args: fingerprint})
+			if err != nil {
+				return nil, err
+			}
+		}
+		return page, nil
+	}
+}
+
+// pageCursor returns the cursor a paginated tool was called with, and a
+// fingerprint of its other arguments.
+func pageCursor(ctx *Context, args interface{}) (string, string) {
+	var cursor string
+	if request, ok := args.(interface{ PageCursor() string }); ok {
+		cursor = request.PageCursor()
+	}
+
+	var toolArgs map[string]interface{}
+	if ctx != nil && ctx.Request != nil {
+		toolArgs = ctx.Request.ToolArgs
+	}
+	if cursor == "" {
+		cursor, _ = toolArgs["cursor"].(string)
+	}
+
+	others := make(map[string]interface{}, len(toolArgs))
+	for name, value := range toolArgs {
+		if name != "cursor" {
+			others[name] = value
+		}
+	}
+	if len(others) == 0 {
+		return cursor, ""
+	}
+	// Map keys marshal in order, so equal arguments hash equally
+	data, err := json.Marshal(others)
+	if err != nil {
+		return cursor, ""
+	}
+	hash := fnv.New64a()
+	hash.Write(data)
+	return cursor, strconv.FormatUint(hash.Sum64(), 36)
+}
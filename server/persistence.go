@@ -0,0 +1,217 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/localrivet/gomcp/persist"
+	"github.com/localrivet/gomcp/webhook"
+)
+
+// Buckets of the persistence store used by the server.
+const (
+	idempotencyBucket = "idempotency"
+	auditBucket       = "audit"
+)
+
+const (
+	// idempotencyTTL is how long the result of an idempotent tool call is
+	// replayed for.
+	idempotencyTTL = 24 * time.Hour
+
+	// auditRetention is how long tool calls stay in the audit trail.
+	auditRetention = 30 * 24 * time.Hour
+)
+
+// AuditRecord is a tool call recorded in the audit trail.
+type AuditRecord struct {
+	Time       time.Time `json:"time"`
+	Tool       string    `json:"tool"`
+	SessionID  string    `json:"sessionID"`
+	APIKeyID   string    `json:"apiKeyID,omitempty"`
+	Outcome    string    `json:"outcome"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+// storedResult is the result of an idempotent tool call.
+type storedResult struct {
+	StoredAt time.Time       `json:"storedAt"`
+	Result   json.RawMessage `json:"result"`
+}
+
+// WithPersistence keeps server state in store, so a single-binary server
+// keeps it across restarts:
+//
+//   - the results of tool calls sent with an idempotency key in _meta are
+//     replayed, without calling the tool again, to retries with the same
+//     key, tool and API key for 24 hours;
+//   - every tool call is recorded in the audit trail, read with AuditTrail,
+//     for 30 days.
+//
+// Expired entries are removed when the server starts. The server does not
+// close the store.
+//
+// Example:
+//
+//	store, err := persist.Open("state.db")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer store.Close()
+//	srv := server.NewServer("my-service", server.WithPersistence(store))
+func WithPersistence(store persist.Store) Option {
+	return func(s *serverImpl) {
+		s.persistence = store
+		s.eventHandlers = append(s.eventHandlers, s.persistAuditEvent)
+	}
+}
+
+// AuditTrail returns the tool calls recorded since the given time, oldest
+// first. It returns nothing without WithPersistence.
+func (s *serverImpl) AuditTrail(since time.Time) ([]AuditRecord, error) {
+	if s.persistence == nil {
+		return nil, nil
+	}
+	var records []AuditRecord
+	var decodeErr error
+	err := s.persistence.Scan(auditBucket, func(key string, value []byte) bool {
+		var record AuditRecord
+		if err := json.Unmarshal(value, &record); err != nil {
+			decodeErr = fmt.Errorf("failed to decode audit record %s: %w", key, err)
+			return false
+		}
+		if !record.Time.Before(since) {
+			records = append(records, record)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, decodeErr
+}
+
+// auditSequence tells apart audit records made in the same nanosecond.
+var auditSequence atomic.Uint64
+
+// persistAuditEvent records audit.tool_call events in the store.
+func (s *serverImpl) persistAuditEvent(eventType string, data map[string]interface{}) {
+	if eventType != webhook.EventAuditToolCall {
+		return
+	}
+	record := AuditRecord{Time: time.Now().UTC()}
+	record.Tool, _ = data["tool"].(string)
+	record.SessionID, _ = data["sessionID"].(string)
+	record.APIKeyID, _ = data["apiKeyID"].(string)
+	record.Outcome, _ = data["outcome"].(string)
+	record.DurationMs, _ = data["durationMs"].(int64)
+
+	value, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	// Keys sort in time order
+	key := fmt.Sprintf("%s-%08d", record.Time.Format("20060102T150405.000000000"), auditSequence.Add(1)%100000000)
+	if err := s.persistence.Put(auditBucket, key, value); err != nil {
+		s.logger.Error("failed to record audit event", "error", err)
+	}
+}
+
+// idempotencyKey returns the store key of a tool call's result, or "" if
+// the call carries no idempotency key. Keys are scoped to the caller, so
+// one caller can't replay another's results.
+func idempotencyKey(ctx *Context) string {
+	key := ctx.Meta().IdempotencyKey()
+	if key == "" || ctx.Request.ToolName == "" {
+		return ""
+	}
+	var subject, clientID string
+	if principal := ctx.Principal(); principal != nil {
+		subject, clientID = principal.Subject, principal.ClientID
+	}
+	return strings.Join([]string{ctx.Request.ToolName, apiKeyID(ctx.Meta().APIKey()), subject, clientID, key}, "\n")
+}
+
+// replayIdempotent returns the stored result of an earlier call with the
+// same idempotency key, if there is one. Calls the caller may not make
+// aren't replayed, so that they fail as they would otherwise.
+func (s *serverImpl) replayIdempotent(ctx *Context) (interface{}, bool) {
+	key := idempotencyKey(ctx)
+	if s.persistence == nil || key == "" {
+		return nil, false
+	}
+	if _, err := s.toolFor(ctx, ctx.Request.ToolName); err != nil {
+		return nil, false
+	}
+	value, found, err := s.persistence.Get(idempotencyBucket, key)
+	if err != nil {
+		s.logger.Error("failed to read idempotent result", "error", err)
+		return nil, false
+	}
+	if !found {
+		return nil, false
+	}
+	var stored storedResult
+	if err := json.Unmarshal(value, &stored); err != nil || time.Since(stored.StoredAt) > idempotencyTTL {
+		return nil, false
+	}
+	s.logger.Debug("replaying idempotent tool call", "tool", ctx.Request.ToolName)
+	return stored.Result, true
+}
+
+// rememberIdempotent stores the result of a successful call made with an
+// idempotency key. Failed calls are not stored, so they can be retried.
+func (s *serverImpl) rememberIdempotent(ctx *Context, result interface{}, err error) {
+	key := idempotencyKey(ctx)
+	if s.persistence == nil || key == "" || toolCallOutcome(result, err) != "ok" {
+		return
+	}
+	encoded, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		return
+	}
+	value, _ := json.Marshal(storedResult{StoredAt: time.Now().UTC(), Result: encoded})
+	if err := s.persistence.Put(idempotencyBucket, key, value); err != nil {
+		s.logger.Error("failed to store idempotent result", "error", err)
+	}
+}
+
+// prunePersisted removes expired idempotent results and audit records.
+func (s *serverImpl) prunePersisted() {
+	if s.persistence == nil {
+		return
+	}
+	now := time.Now()
+
+	var expired []string
+	s.persistence.Scan(idempotencyBucket, func(key string, value []byte) bool {
+		var stored storedResult
+		if err := json.Unmarshal(value, &stored); err != nil || now.Sub(stored.StoredAt) > idempotencyTTL {
+			expired = append(expired, key)
+		}
+		return true
+	})
+	for _, key := range expired {
+		s.persistence.Delete(idempotencyBucket, key)
+	}
+
+	cutoff := now.Add(-auditRetention).UTC().Format("20060102T150405.000000000")
+	var old []string
+	s.persistence.Scan(auditBucket, func(key string, value []byte) bool {
+		if key >= cutoff {
+			return false
+		}
+		old = append(old, key)
+		return true
+	})
+	for _, key := range old {
+		s.persistence.Delete(auditBucket, key)
+	}
+
+	if len(expired) > 0 || len(old) > 0 {
+		s.logger.Debug("pruned persisted state", "idempotentResults", len(expired), "auditRecords", len(old))
+	}
+}
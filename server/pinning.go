@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PinnedResourcesURI is the built-in resource listing the resources pinned to
+// the current session. Hosts can show it as context attached by the server,
+// and subscribe to it to learn when pins change.
+const PinnedResourcesURI = "session://pinned"
+
+// PinResource attaches a registered resource to the client's session, so the
+// host can show it as attached context and the agent can re-read it cheaply.
+// Pinned resources are listed by the session://pinned resource.
+//
+// Example:
+//
+//	srv.Tool("open_ticket", "Open a support ticket", func(ctx *server.Context, args struct {
+//	    ID string `json:"id"`
+//	}) (string, error) {
+//	    if err := ctx.PinResource("tickets://" + args.ID); err != nil {
+//	        return "", err
+//	    }
+//	    return "Ticket opened", nil
+//	})
+func (c *Context) PinResource(uri string) error {
+	if c.server == nil {
+		return fmt.Errorf("cannot pin resource %s: context has no server", uri)
+	}
+	if _, _, found := c.server.findResourceAndExtractParams(uri); !found {
+		return fmt.Errorf("cannot pin resource %s: resource not found", uri)
+	}
+
+	added, err := c.server.sessionManager.Pin(c.sessionID(), uri)
+	if err != nil {
+		return fmt.Errorf("cannot pin resource %s: %w", uri, err)
+	}
+	if added {
+		c.server.saveSession(c.sessionID())
+		c.server.notifyPinsChanged()
+	}
+	return nil
+}
+
+// UnpinResource detaches a resource from the client's session.
+// It reports whether the resource was pinned.
+func (c *Context) UnpinResource(uri string) bool {
+	if c.server == nil {
+		return false
+	}
+
+	removed := c.server.sessionManager.Unpin(c.sessionID(), uri)
+	if removed {
+		c.server.saveSession(c.sessionID())
+		c.server.notifyPinsChanged()
+	}
+	return removed
+}
+
+// PinnedResources returns the URIs pinned to the client's session, in pin order.
+func (c *Context) PinnedResources() []string {
+	if c.server == nil {
+		return nil
+	}
+	return c.server.sessionManager.PinnedResources(c.sessionID())
+}
+
+// notifyPinsChanged tells subscribed clients that the pinned listing changed.
+func (s *serverImpl) notifyPinsChanged() {
+	if err := s.NotifyResourceUpdated(PinnedResourcesURI); err != nil {
+		s.logger.Warn("failed to notify pinned resources change", "error", err)
+	}
+}
+
+// readPinnedResources renders the session://pinned listing for the current session.
+func (s *serverImpl) readPinnedResources(ctx *Context) (interface{}, error) {
+	pinned := make([]map[string]interface{}, 0)
+	for _, uri := range s.sessionManager.PinnedResources(ctx.sessionID()) {
+		entry := map[string]interface{}{"uri": uri}
+		if resource, _, found := s.findResourceAndExtractParams(uri); found && resource.Description != "" {
+			entry["description"] = resource.Description
+		}
+		pinned = append(pinned, entry)
+	}
+
+	listing, err := json.Marshal(map[string]interface{}{"resources": pinned})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode pinned resources: %w", err)
+	}
+
+	return map[string]interface{}{
+		"contents": []interface{}{
+			map[string]interface{}{
+				"uri":      PinnedResourcesURI,
+				"mimeType": "application/json",
+				"text":     string(listing),
+			},
+		},
+	}, nil
+}
+
+// pinnedResourcesInfo returns the resources/list entry for session://pinned,
+// or nil if the session has nothing pinned.
+func (s *serverImpl) pinnedResourcesInfo(ctx *Context) map[string]interface{} {
+	if len(s.sessionManager.PinnedResources(ctx.sessionID())) == 0 {
+		return nil
+	}
+	return map[string]interface{}{
+		"uri":         PinnedResourcesURI,
+		"name":        "Pinned resources",
+		"description": "Resources the server attached to this session",
+		"mimeType":    "application/json",
+	}
+}
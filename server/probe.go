@@ -0,0 +1,281 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Probe checks that something a tool depends on is usable, such as that its
+// database is reachable or its API key is valid. It returns nil when the
+// dependency is healthy.
+type Probe func(ctx context.Context) error
+
+// ProbePolicy sets how tool probes are run.
+type ProbePolicy struct {
+	// Interval is how often probes run after the check at startup. It
+	// defaults to 30 seconds.
+	Interval time.Duration
+
+	// Timeout bounds each probe. It defaults to five seconds.
+	Timeout time.Duration
+
+	// HideUnhealthy leaves tools whose probe fails out of tools/list until
+	// it passes again, sending notifications/tools/list_changed as they
+	// leave and return. Calls to hidden tools are still dispatched.
+	HideUnhealthy bool
+}
+
+// WithProbePolicy sets how the probes added with WithProbe are run.
+//
+// Example:
+//
+//	srv := server.NewServer("orders",
+//	    server.WithProbePolicy(server.ProbePolicy{Interval: time.Minute, HideUnhealthy: true}),
+//	)
+func WithProbePolicy(policy ProbePolicy) Option {
+	return func(s *serverImpl) {
+		if policy.Interval <= 0 {
+			policy.Interval = 30 * time.Second
+		}
+		if policy.Timeout <= 0 {
+			policy.Timeout = 5 * time.Second
+		}
+		s.probePolicy = policy
+	}
+}
+
+// ToolHealth is the latest probe result of a tool.
+type ToolHealth struct {
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// HealthReport describes the health of the server's tools.
+type HealthReport struct {
+	// Status is "ok" when every probe passes and "degraded" otherwise.
+	Status string `json:"status"`
+
+	// Tools holds the probe results of the tools that have a probe.
+	Tools map[string]ToolHealth `json:"tools,omitempty"`
+
+	// Maintenance is set while maintenance mode is on.
+	Maintenance *MaintenanceStatus `json:"maintenance,omitempty"`
+}
+
+// toolProbe is a tool's probe and its latest result.
+type toolProbe struct {
+	probe Probe
+
+	mu     sync.Mutex
+	health ToolHealth
+	ran    bool
+}
+
+// result returns the latest probe result, and false if it hasn't run yet.
+func (p *toolProbe) result() (ToolHealth, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.health, p.ran
+}
+
+// unhealthy reports whether the latest probe run failed.
+func (p *toolProbe) unhealthy() bool {
+	health, ran := p.result()
+	return ran && !health.Healthy
+}
+
+// WithProbe attaches a probe to a registered tool. Probes run when Run
+// starts the server and then at the interval set by WithProbePolicy; their
+// results are reported by Health and the admin /health endpoint.
+// The function returns the server instance to allow for method chaining.
+func (s *serverImpl) WithProbe(toolName string, probe Probe) Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tool, exists := s.tools[toolName]
+	if !exists {
+		s.logger.Error("tool not found for probe", "name", toolName)
+		return s
+	}
+	if probe == nil {
+		tool.probe = nil
+		return s
+	}
+	tool.probe = &toolProbe{probe: probe}
+	return s
+}
+
+// Health returns the latest probe results of the server's tools and whether
+// maintenance mode is on. Probes that haven't run yet are left out.
+// Maintenance mode doesn't change the status, since the server still serves
+// requests.
+func (s *serverImpl) Health() HealthReport {
+	report := HealthReport{Status: "ok", Maintenance: s.maintenance.current()}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for name, tool := range s.tools {
+		if tool.probe == nil {
+			continue
+		}
+		health, ran := tool.probe.result()
+		if !ran {
+			continue
+		}
+		if report.Tools == nil {
+			report.Tools = make(map[string]ToolHealth)
+		}
+		report.Tools[name] = health
+		if !health.Healthy {
+			report.Status = "degraded"
+		}
+	}
+	return report
+}
+
+// startProbes runs every probe once, then keeps running them at the policy's
+// interval until Shutdown.
+func (s *serverImpl) startProbes() {
+	if !s.hasProbes() {
+		return
+	}
+	s.runProbes(false)
+
+	go func() {
+		ticker := time.NewTicker(s.probePolicy.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stopped:
+				return
+			case <-ticker.C:
+				s.runProbes(true)
+			}
+		}
+	}()
+}
+
+func (s *serverImpl) hasProbes() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, tool := range s.tools {
+		if tool.probe != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// runProbes runs every probe concurrently and records the results. When
+// unhealthy tools are hidden, notify is set and the set of them changes,
+// clients are told to list tools again.
+func (s *serverImpl) runProbes(notify bool) {
+	s.mu.RLock()
+	probes := make(map[string]*toolProbe)
+	for name, tool := range s.tools {
+		if tool.probe != nil {
+			probes[name] = tool.probe
+		}
+	}
+	s.mu.RUnlock()
+
+	names := make([]string, 0, len(probes))
+	for name := range probes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var wg sync.WaitGroup
+	changed := make([]bool, len(names))
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string, p *toolProbe) {
+			defer wg.Done()
+			changed[i] = s.runProbe(name, p)
+		}(i, name, probes[name])
+	}
+	wg.Wait()
+
+	if !notify || !s.probePolicy.HideUnhealthy {
+		return
+	}
+	for _, c := range changed {
+		if c {
+			s.mu.Lock()
+			s.toolsChanged = true
+			s.mu.Unlock()
+			s.SendToolsListChangedNotification()
+			return
+		}
+	}
+}
+
+// runProbe runs one probe, logs a change in its health and reports whether
+// there was one.
+func (s *serverImpl) runProbe(name string, p *toolProbe) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), s.probePolicy.Timeout)
+	defer cancel()
+
+	err := callProbe(ctx, p.probe)
+	health := ToolHealth{Healthy: err == nil, CheckedAt: time.Now()}
+	if err != nil {
+		health.Error = err.Error()
+	}
+
+	p.mu.Lock()
+	previous, ran := p.health, p.ran
+	p.health, p.ran = health, true
+	p.mu.Unlock()
+
+	if ran && previous.Healthy == health.Healthy {
+		return false
+	}
+	if health.Healthy {
+		s.logger.Info("tool probe passed", "tool", name)
+	} else {
+		s.logger.Warn("tool probe failed", "tool", name, "error", err)
+	}
+	// A tool found healthy at startup never left the listing
+	return ran || !health.Healthy
+}
+
+// callProbe runs a probe, turning a panic or an overrun of ctx into an error.
+func callProbe(ctx context.Context, probe Probe) (err error) {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- fmt.Errorf("probe panicked: %v", recovered)
+			}
+		}()
+		done <- probe(ctx)
+	}()
+
+	select {
+	case err = <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("probe timed out: %w", ctx.Err())
+	}
+}
+
+// hiddenByProbe reports whether a tool is left out of listings because its
+// probe failed.
+func (s *serverImpl) hiddenByProbe(tool *Tool) bool {
+	return s.probePolicy.HideUnhealthy && tool.probe != nil && tool.probe.unhealthy()
+}
+
+func (s *serverImpl) serveHealth(w http.ResponseWriter, r *http.Request) {
+	report := s.Health()
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ReportProgress sends a notifications/progress message for the current
+// request. It is a no-op returning nil when the client did not send a
+// progress token with the request, so handlers can report progress
+// unconditionally.
+//
+// current must increase with each call. Pass a total of 0 when the total is
+// not known. The message is omitted for clients on protocol revisions that
+// predate progress messages.
+//
+// Example:
+//
+//	srv.Tool("import", "Import records", func(ctx *server.Context, args ImportArgs) (string, error) {
+//	    for i, record := range args.Records {
+//	        ctx.ReportProgress("importing "+record.ID, float64(i), float64(len(args.Records)))
+//	        importRecord(record)
+//	    }
+//	    return "done", nil
+//	})
+func (c *Context) ReportProgress(message string, current, total float64) error {
+	token := c.Meta().ProgressToken()
+	if token == nil {
+		return nil
+	}
+	if c.server == nil {
+		return fmt.Errorf("cannot report progress: context has no server")
+	}
+
+	params := map[string]interface{}{
+		"progressToken": token,
+		"progress":      current,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" && c.Version != "2024-11-05" {
+		params["message"] = message
+	}
+
+	notification, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/progress",
+		"params":  params,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress notification: %w", err)
+	}
+
+	c.server.mu.RLock()
+	t := c.server.transport
+	c.server.mu.RUnlock()
+	if t == nil {
+		return fmt.Errorf("cannot report progress: no transport configured")
+	}
+	if err := t.Send(notification); err != nil {
+		return fmt.Errorf("failed to send progress notification: %w", err)
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"text/template"
 )
 
 // InvalidParametersError represents an error with invalid parameters
@@ -77,6 +78,10 @@ type PromptTemplate struct {
 
 	// Variables holds the variable names extracted from the Content
 	Variables []string
+
+	// ResourceURI, when set, makes this message an embedded resource. The URI
+	// may contain {{variable}} placeholders and is read when the prompt is rendered.
+	ResourceURI string
 }
 
 // PromptArgument represents an argument for a prompt.
@@ -127,6 +132,19 @@ func Assistant(content string) PromptTemplate {
 	return PromptTemplate{Role: "assistant", Content: content}
 }
 
+// EmbeddedResource creates a prompt template that embeds a registered
+// resource in a message with the given role. The resource is read each time
+// the prompt is rendered, so the message always carries current content.
+//
+// Example:
+//
+//	server.Prompt("review", "Review against the style guide",
+//	    server.EmbeddedResource("user", "docs://style-guide"),
+//	    server.User("Review this change: {{diff}}"))
+func EmbeddedResource(role string, uri string) PromptTemplate {
+	return PromptTemplate{Role: role, ResourceURI: uri}
+}
+
 // Prompt registers a prompt with the server.
 // The function returns the server instance to allow for method chaining.
 // The name parameter is used as the identifier for the prompt.
@@ -161,8 +179,11 @@ func (s *serverImpl) Prompt(name string, description string, templates ...interf
 		}
 	}
 
+	// Include shared partial messages
+	promptTemplates = s.expandPartials(promptTemplates)
+
 	// Extract variables from templates for argument extraction
-	arguments := extractArguments(promptTemplates)
+	arguments := extractArguments(promptTemplates, s.promptFuncs)
 
 	s.prompts[name] = &Prompt{
 		Name:        name,
@@ -171,27 +192,36 @@ func (s *serverImpl) Prompt(name string, description string, templates ...interf
 		Arguments:   arguments,
 	}
 
-	// Send notification that prompts list has changed
-	s.sendNotification("notifications/prompts/list_changed", nil)
+	// Tell initialized clients about the new prompt
+	s.notifyListChanged(promptsListChanged)
 
 	return s
 }
 
+// UnregisterPrompt removes a prompt and sends
+// notifications/prompts/list_changed.
+func (s *serverImpl) UnregisterPrompt(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.prompts[name]; !exists {
+		return false
+	}
+	delete(s.prompts, name)
+	s.notifyListChanged(promptsListChanged)
+	return true
+}
+
 // extractArguments extracts variable names from templates and creates arguments list.
-// It uses a regular expression to find all {{variable}} patterns in the templates
-// and creates a corresponding list of required arguments.
-func extractArguments(templates []PromptTemplate) []PromptArgument {
+// It finds all {{variable}} patterns in the templates, skipping the names of
+// template functions, and creates a corresponding list of required arguments.
+func extractArguments(templates []PromptTemplate, funcs template.FuncMap) []PromptArgument {
 	variableMap := make(map[string]bool)
-	re := regexp.MustCompile(`\{\{([^}]+)\}\}`)
 
 	// Collect all unique variable names
 	for _, template := range templates {
-		matches := re.FindAllStringSubmatch(template.Content, -1)
-		for _, match := range matches {
-			if len(match) > 1 {
-				varName := strings.TrimSpace(match[1])
-				variableMap[varName] = true
-			}
+		for _, varName := range templateVariables(template.Content+template.ResourceURI, funcs) {
+			variableMap[varName] = true
 		}
 	}
 
@@ -214,53 +244,37 @@ func extractArguments(templates []PromptTemplate) []PromptArgument {
 // The response includes prompt metadata such as name, description, and arguments.
 func (s *serverImpl) ProcessPromptList(ctx *Context) (interface{}, error) {
 	// Get pagination cursor if provided
-	var cursor string
-	if ctx.Request.Params != nil {
-		var params struct {
-			Cursor string `json:"cursor"`
-		}
-		if err := json.Unmarshal(ctx.Request.Params, &params); err != nil {
-			return nil, fmt.Errorf("invalid params: %w", err)
-		}
-		cursor = params.Cursor
+	cursor, err := listCursor(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	// For now, we'll use a simple pagination that returns all prompts
-	const maxPageSize = 50
-	var prompts = make([]map[string]interface{}, 0)
-	var nextCursor string
-
-	// Convert prompts to the expected format
-	i := 0
-	for name, prompt := range s.prompts {
-		// If we have a cursor, skip until we find it
-		if cursor != "" && name <= cursor {
-			continue
-		}
-
+	prompts := make([]map[string]interface{}, 0)
+	nextCursor := paginate(s, s.prompts, cursor, func(name string, prompt *Prompt) bool {
 		// Add the prompt to the result
 		promptInfo := map[string]interface{}{
 			"name":        prompt.Name,
 			"description": prompt.Description,
 		}
+		arguments := prompt.Arguments
+
+		// Translate the prompt's text for the client's language, if registered
+		if text, localized := s.localization(ctx, prompt.Name); localized {
+			localizeInfo(promptInfo, text)
+			arguments = localizedArguments(arguments, text.Arguments)
+		}
 
 		// Include arguments if available
-		if len(prompt.Arguments) > 0 {
-			promptInfo["arguments"] = prompt.Arguments
+		if len(arguments) > 0 {
+			promptInfo["arguments"] = arguments
 		}
 
 		prompts = append(prompts, promptInfo)
-
-		i++
-		if i >= maxPageSize {
-			// Set cursor for next page
-			nextCursor = name
-			break
-		}
-	}
+		return true
+	})
 
 	// Return the list of prompts
 	result := map[string]interface{}{
@@ -351,6 +365,7 @@ func (s *serverImpl) ProcessPromptRequest(ctx *Context) (interface{}, error) {
 	// Find the prompt
 	s.mu.RLock()
 	prompt, exists := s.prompts[promptName]
+	funcs := s.promptFuncs
 	s.mu.RUnlock()
 
 	if !exists {
@@ -369,8 +384,17 @@ func (s *serverImpl) ProcessPromptRequest(ctx *Context) (interface{}, error) {
 	// Render the prompt templates
 	renderedTemplates := make([]map[string]interface{}, 0, len(prompt.Templates))
 	for _, template := range prompt.Templates {
+		if template.ResourceURI != "" {
+			message, err := s.renderEmbeddedResource(ctx, template, args, funcs)
+			if err != nil {
+				return nil, err
+			}
+			renderedTemplates = append(renderedTemplates, message)
+			continue
+		}
+
 		// Substitute variables in the content
-		renderedContent, err := SubstituteVariables(template.Content, args)
+		renderedContent, err := renderTemplate(template.Content, args, funcs)
 		if err != nil {
 			return nil, err
 		}
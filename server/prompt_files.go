@@ -0,0 +1,303 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PromptFormat is a file format for importing and exporting prompts.
+type PromptFormat string
+
+const (
+	// PromptFormatMarkdown stores a prompt as Markdown with YAML front-matter.
+	// Each message starts with a "## system", "## user" or "## assistant"
+	// heading; text before the first heading is a user message. A heading
+	// such as "## user resource docs://guide" embeds a resource instead.
+	PromptFormatMarkdown PromptFormat = "markdown"
+
+	// PromptFormatYAML stores a prompt as a YAML document with a messages list.
+	PromptFormatYAML PromptFormat = "yaml"
+)
+
+// promptFile is the on-disk representation of a prompt.
+type promptFile struct {
+	Name        string              `yaml:"name"`
+	Description string              `yaml:"description,omitempty"`
+	Arguments   []promptFileArg     `yaml:"arguments,omitempty"`
+	Messages    []promptFileMessage `yaml:"messages,omitempty"`
+}
+
+type promptFileArg struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Required    *bool  `yaml:"required,omitempty"`
+}
+
+type promptFileMessage struct {
+	Role     string `yaml:"role"`
+	Content  string `yaml:"content,omitempty"`
+	Resource string `yaml:"resource,omitempty"`
+}
+
+// ImportPrompts registers every prompt file in fsys matching pattern.
+// Files ending in .md are read as Markdown with front-matter and files ending
+// in .yaml or .yml as YAML; other files are skipped. Arguments declared in a
+// file refine those extracted from the templates, so descriptions and
+// optional flags survive a round trip.
+//
+// Example:
+//
+//	if err := srv.ImportPrompts(os.DirFS("prompts"), "*"); err != nil {
+//	    log.Fatal(err)
+//	}
+func (s *serverImpl) ImportPrompts(fsys fs.FS, pattern string) error {
+	paths, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return fmt.Errorf("invalid prompt file pattern: %w", err)
+	}
+
+	for _, p := range paths {
+		var format PromptFormat
+		switch strings.ToLower(path.Ext(p)) {
+		case ".md", ".markdown":
+			format = PromptFormatMarkdown
+		case ".yaml", ".yml":
+			format = PromptFormatYAML
+		default:
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return fmt.Errorf("failed to read prompt file %s: %w", p, err)
+		}
+
+		file, err := parsePromptFile(data, format)
+		if err != nil {
+			return fmt.Errorf("failed to parse prompt file %s: %w", p, err)
+		}
+		if file.Name == "" {
+			file.Name = strings.TrimSuffix(path.Base(p), path.Ext(p))
+		}
+
+		s.registerPromptFile(file)
+	}
+	return nil
+}
+
+// ExportPrompts writes every registered prompt to dir, one file per prompt,
+// named after the prompt. Files are written deterministically so that
+// exported prompts diff cleanly under version control.
+func (s *serverImpl) ExportPrompts(dir string, format PromptFormat) error {
+	ext := ".md"
+	if format == PromptFormatYAML {
+		ext = ".yaml"
+	} else if format != PromptFormatMarkdown {
+		return fmt.Errorf("unsupported prompt format: %q", format)
+	}
+
+	s.mu.RLock()
+	files := make([]promptFile, 0, len(s.prompts))
+	for _, prompt := range s.prompts {
+		files = append(files, promptToFile(prompt))
+	}
+	s.mu.RUnlock()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create prompt directory: %w", err)
+	}
+
+	for _, file := range files {
+		data, err := marshalPromptFile(file, format)
+		if err != nil {
+			return fmt.Errorf("failed to encode prompt %s: %w", file.Name, err)
+		}
+		target := filepath.Join(dir, filepath.Base(file.Name)+ext)
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return fmt.Errorf("failed to write prompt %s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
+
+// registerPromptFile registers a parsed prompt and applies its argument metadata.
+func (s *serverImpl) registerPromptFile(file promptFile) {
+	templates := make([]interface{}, 0, len(file.Messages))
+	for _, msg := range file.Messages {
+		templates = append(templates, PromptTemplate{Role: msg.Role, Content: msg.Content, ResourceURI: msg.Resource})
+	}
+	s.Prompt(file.Name, file.Description, templates...)
+
+	if len(file.Arguments) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prompt, ok := s.prompts[file.Name]
+	if !ok {
+		return
+	}
+	for _, declared := range file.Arguments {
+		index := -1
+		for i, arg := range prompt.Arguments {
+			if arg.Name == declared.Name {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			prompt.Arguments = append(prompt.Arguments, PromptArgument{Name: declared.Name, Required: true})
+			index = len(prompt.Arguments) - 1
+		}
+		if declared.Description != "" {
+			prompt.Arguments[index].Description = declared.Description
+		}
+		if declared.Required != nil {
+			prompt.Arguments[index].Required = *declared.Required
+		}
+	}
+}
+
+// promptToFile converts a registered prompt to its file representation.
+func promptToFile(prompt *Prompt) promptFile {
+	file := promptFile{Name: prompt.Name, Description: prompt.Description}
+
+	for _, arg := range prompt.Arguments {
+		required := arg.Required
+		file.Arguments = append(file.Arguments, promptFileArg{
+			Name:        arg.Name,
+			Description: arg.Description,
+			Required:    &required,
+		})
+	}
+	sort.Slice(file.Arguments, func(i, j int) bool { return file.Arguments[i].Name < file.Arguments[j].Name })
+
+	for _, t := range prompt.Templates {
+		file.Messages = append(file.Messages, promptFileMessage{Role: t.Role, Content: t.Content, Resource: t.ResourceURI})
+	}
+	return file
+}
+
+// parsePromptFile decodes a prompt file in the given format.
+func parsePromptFile(data []byte, format PromptFormat) (promptFile, error) {
+	var file promptFile
+
+	if format == PromptFormatYAML {
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return file, err
+		}
+		return file, validatePromptRoles(file.Messages)
+	}
+
+	frontMatter, body := splitFrontMatter(data)
+	if len(frontMatter) > 0 {
+		if err := yaml.Unmarshal(frontMatter, &file); err != nil {
+			return file, fmt.Errorf("invalid front-matter: %w", err)
+		}
+	}
+	file.Messages = parseMarkdownMessages(body)
+	return file, nil
+}
+
+// marshalPromptFile encodes a prompt file in the given format.
+func marshalPromptFile(file promptFile, format PromptFormat) ([]byte, error) {
+	if format == PromptFormatYAML {
+		return yaml.Marshal(file)
+	}
+
+	messages := file.Messages
+	file.Messages = nil
+	frontMatter, err := yaml.Marshal(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(frontMatter)
+	buf.WriteString("---\n")
+	for _, msg := range messages {
+		if msg.Resource != "" {
+			fmt.Fprintf(&buf, "\n## %s resource %s\n", msg.Role, msg.Resource)
+			continue
+		}
+		fmt.Fprintf(&buf, "\n## %s\n\n%s\n", msg.Role, strings.TrimSpace(msg.Content))
+	}
+	return buf.Bytes(), nil
+}
+
+// splitFrontMatter separates a leading "---" delimited YAML block from the body.
+func splitFrontMatter(data []byte) ([]byte, string) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if !strings.HasPrefix(text, "---\n") {
+		return nil, text
+	}
+
+	rest := text[len("---\n"):]
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return nil, text
+	}
+
+	body := rest[end+len("\n---"):]
+	body = strings.TrimPrefix(body, "\n")
+	return []byte(rest[:end+1]), body
+}
+
+// parseMarkdownMessages splits a Markdown body into messages at role headings.
+func parseMarkdownMessages(body string) []promptFileMessage {
+	var messages []promptFileMessage
+	role := "user"
+	var content []string
+
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(content, "\n"))
+		if text != "" {
+			messages = append(messages, promptFileMessage{Role: role, Content: text})
+		}
+		content = nil
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		if heading, ok := strings.CutPrefix(line, "## "); ok {
+			fields := strings.Fields(heading)
+			if len(fields) > 0 && isPromptRole(strings.ToLower(fields[0])) {
+				flush()
+				role = strings.ToLower(fields[0])
+				if len(fields) == 3 && strings.EqualFold(fields[1], "resource") {
+					messages = append(messages, promptFileMessage{Role: role, Resource: fields[2]})
+				}
+				continue
+			}
+		}
+		content = append(content, line)
+	}
+	flush()
+
+	return messages
+}
+
+// validatePromptRoles checks that every message has a known role.
+func validatePromptRoles(messages []promptFileMessage) error {
+	for _, msg := range messages {
+		if !isPromptRole(msg.Role) {
+			return fmt.Errorf("unknown message role %q", msg.Role)
+		}
+	}
+	return nil
+}
+
+// isPromptRole reports whether role is a valid prompt message role.
+func isPromptRole(role string) bool {
+	return role == "system" || role == "user" || role == "assistant"
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// renderEmbeddedResource reads the resource referenced by a template and
+// returns a prompt message whose content is an embedded resource.
+func (s *serverImpl) renderEmbeddedResource(ctx *Context, t PromptTemplate, args map[string]interface{}, funcs template.FuncMap) (map[string]interface{}, error) {
+	uri, err := renderTemplate(t.ResourceURI, args, funcs)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := s.readEmbeddedResource(ctx, uri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed resource %s: %w", uri, err)
+	}
+
+	return map[string]interface{}{
+		"role": t.Role,
+		"content": map[string]interface{}{
+			"type":     "resource",
+			"resource": resource,
+		},
+	}, nil
+}
+
+// readEmbeddedResource reads a registered resource as the current client
+// would and converts the first content item to the spec's resource contents
+// shape: uri, mimeType and either text or blob.
+func (s *serverImpl) readEmbeddedResource(ctx *Context, uri string) (map[string]interface{}, error) {
+	params, err := json.Marshal(map[string]string{"uri": uri})
+	if err != nil {
+		return nil, err
+	}
+
+	readCtx := &Context{
+		ctx:          ctx.ctx,
+		RequestBytes: ctx.RequestBytes,
+		Request: &Request{
+			JSONRPC:      "2.0",
+			Method:       "resources/read",
+			Params:       params,
+			ResourcePath: uri,
+		},
+		server:    s,
+		Logger:    ctx.Logger,
+		Version:   ctx.Version,
+		RequestID: ctx.RequestID,
+		Metadata:  ctx.Metadata,
+
+		principal:    ctx.principal,
+		connectionID: ctx.connectionID,
+	}
+
+	result, err := s.ProcessResourceRequest(readCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	response, _ := result.(map[string]interface{})
+	items := contentItems(response["contents"])
+	if len(items) == 0 {
+		items = contentItems(response["content"])
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("resource returned no content")
+	}
+
+	item := items[0]
+	if nested := contentItems(item["content"]); len(nested) > 0 {
+		item = nested[0]
+	}
+
+	embedded := map[string]interface{}{"uri": uri}
+	if itemURI, ok := item["uri"].(string); ok && itemURI != "" {
+		embedded["uri"] = itemURI
+	}
+
+	mimeType, _ := item["mimeType"].(string)
+	switch {
+	case item["blob"] != nil:
+		embedded["blob"] = item["blob"]
+	case item["data"] != nil:
+		embedded["blob"] = item["data"]
+	default:
+		text, _ := item["text"].(string)
+		embedded["text"] = text
+		if mimeType == "" {
+			mimeType = "text/plain"
+		}
+	}
+	if mimeType != "" {
+		embedded["mimeType"] = mimeType
+	}
+	return embedded, nil
+}
@@ -0,0 +1,424 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// partialRole marks a PromptTemplate created by Partial. Such templates are
+// replaced by the partial's messages when the prompt is registered.
+const partialRole = "partial"
+
+// maxPartialDepth limits how deeply partials may include other partials.
+const maxPartialDepth = 8
+
+// placeholderPattern matches {{...}} expressions in prompt templates.
+var placeholderPattern = regexp.MustCompile(`\{\{([^}]+)\}\}`)
+
+// WithPromptFuncs registers functions that prompt templates can call.
+//
+// A placeholder whose first word names a registered function calls it with
+// the remaining words as arguments. Arguments are prompt variables, quoted
+// strings or numbers, and a pipe passes the result of one call as the last
+// argument of the next. Functions return one value, or a value and an error.
+//
+// Example:
+//
+//	srv := server.NewServer("docs",
+//	    server.WithPromptFuncs(template.FuncMap{
+//	        "upper": strings.ToUpper,
+//	        "truncate": func(n int, s string) string {
+//	            if len(s) > n {
+//	                return s[:n]
+//	            }
+//	            return s
+//	        },
+//	    }),
+//	)
+//	srv.Prompt("review", "Review code",
+//	    server.User(`Review this {{upper language}} code: {{code | truncate 4000}}`))
+func WithPromptFuncs(funcs template.FuncMap) Option {
+	return func(s *serverImpl) {
+		if s.promptFuncs == nil {
+			s.promptFuncs = make(template.FuncMap)
+		}
+		for name, fn := range funcs {
+			if reflect.TypeOf(fn).Kind() != reflect.Func {
+				s.logger.Error("ignoring prompt function that is not a func", "name", name)
+				continue
+			}
+			s.promptFuncs[name] = fn
+		}
+	}
+}
+
+// WithPromptPartial registers reusable messages that prompts can include,
+// such as a shared system preamble. Include the messages with Partial(name)
+// in a prompt's template list, or inline the partial's text inside another
+// message with {{> name}}. Partials are expanded when a prompt is
+// registered, so they must be declared before the prompts that use them.
+//
+// Example:
+//
+//	srv := server.NewServer("support",
+//	    server.WithPromptPartial("tone", server.System("You are a friendly support agent for {{product}}.")),
+//	)
+//	srv.Prompt("refund", "Handle a refund request",
+//	    server.Partial("tone"),
+//	    server.User("The customer writes: {{message}}"))
+func WithPromptPartial(name string, templates ...PromptTemplate) Option {
+	return func(s *serverImpl) {
+		if s.promptPartials == nil {
+			s.promptPartials = make(map[string][]PromptTemplate)
+		}
+		s.promptPartials[name] = templates
+	}
+}
+
+// Partial creates a placeholder template that includes the messages of the
+// partial registered under name with WithPromptPartial.
+func Partial(name string) PromptTemplate {
+	return PromptTemplate{Role: partialRole, Content: name}
+}
+
+// expandPartials replaces Partial placeholders with the partial's messages
+// and inlines {{> name}} references. Unknown partials are logged and dropped.
+func (s *serverImpl) expandPartials(templates []PromptTemplate) []PromptTemplate {
+	return s.expandPartialsDepth(templates, 0)
+}
+
+func (s *serverImpl) expandPartialsDepth(templates []PromptTemplate, depth int) []PromptTemplate {
+	expanded := make([]PromptTemplate, 0, len(templates))
+	for _, t := range templates {
+		if t.Role != partialRole {
+			t.Content = s.inlinePartials(t.Content, depth)
+			expanded = append(expanded, t)
+			continue
+		}
+
+		partial, ok := s.promptPartials[t.Content]
+		if !ok {
+			s.logger.Error("unknown prompt partial", "name", t.Content)
+			continue
+		}
+		if depth >= maxPartialDepth {
+			s.logger.Error("prompt partials nested too deeply", "name", t.Content)
+			continue
+		}
+		expanded = append(expanded, s.expandPartialsDepth(partial, depth+1)...)
+	}
+	return expanded
+}
+
+// inlinePartials replaces {{> name}} references with the text of the partial's
+// messages, separated by blank lines.
+func (s *serverImpl) inlinePartials(content string, depth int) string {
+	if !strings.Contains(content, "{{") {
+		return content
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(content, func(match string) string {
+		expr := strings.TrimSpace(match[2 : len(match)-2])
+		if !strings.HasPrefix(expr, ">") {
+			return match
+		}
+
+		name := strings.TrimSpace(expr[1:])
+		partial, ok := s.promptPartials[name]
+		if !ok || depth >= maxPartialDepth {
+			s.logger.Error("cannot inline prompt partial", "name", name)
+			return ""
+		}
+
+		parts := make([]string, 0, len(partial))
+		for _, t := range s.expandPartialsDepth(partial, depth+1) {
+			parts = append(parts, t.Content)
+		}
+		return strings.Join(parts, "\n\n")
+	})
+}
+
+// renderTemplate substitutes variables and evaluates function calls in a
+// prompt template. Placeholders that are plain names behave exactly as in
+// SubstituteVariables.
+func renderTemplate(content string, variables map[string]interface{}, funcs template.FuncMap) (string, error) {
+	var renderErr error
+	result := placeholderPattern.ReplaceAllStringFunc(content, func(match string) string {
+		if renderErr != nil {
+			return match
+		}
+
+		value, err := evalExpression(strings.TrimSpace(match[2:len(match)-2]), variables, funcs)
+		if err != nil {
+			renderErr = err
+			return match
+		}
+		return stringifyValue(value)
+	})
+	if renderErr != nil {
+		return "", renderErr
+	}
+	return result, nil
+}
+
+// evalExpression evaluates a single placeholder expression.
+func evalExpression(expr string, variables map[string]interface{}, funcs template.FuncMap) (interface{}, error) {
+	commands, err := parseCommands(expr)
+	if err != nil {
+		return nil, NewInvalidParametersError(fmt.Sprintf("invalid template expression %q: %v", expr, err))
+	}
+
+	// A lone name that isn't a function is a variable; names may contain spaces
+	if len(commands) == 1 && !isFuncCall(commands[0], funcs) {
+		return lookupVariable(expr, variables)
+	}
+
+	var piped interface{}
+	for i, cmd := range commands {
+		if !isFuncCall(cmd, funcs) {
+			if i > 0 || len(cmd) != 1 {
+				return nil, NewInvalidParametersError(fmt.Sprintf("unknown template function: %s", cmd[0].text))
+			}
+			if piped, err = evalArg(cmd[0], variables); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		args := make([]interface{}, 0, len(cmd))
+		for _, tok := range cmd[1:] {
+			arg, err := evalArg(tok, variables)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+		if i > 0 {
+			args = append(args, piped)
+		}
+
+		if piped, err = callFunc(cmd[0].text, funcs[cmd[0].text], args); err != nil {
+			return nil, err
+		}
+	}
+	return piped, nil
+}
+
+// templateVariables returns the variable names referenced by a template.
+func templateVariables(content string, funcs template.FuncMap) []string {
+	var names []string
+	for _, match := range placeholderPattern.FindAllStringSubmatch(content, -1) {
+		expr := strings.TrimSpace(match[1])
+		if strings.HasPrefix(expr, ">") {
+			continue
+		}
+
+		commands, err := parseCommands(expr)
+		if err != nil {
+			continue
+		}
+		if len(commands) == 1 && !isFuncCall(commands[0], funcs) {
+			names = append(names, expr)
+			continue
+		}
+
+		for _, cmd := range commands {
+			args := cmd
+			if isFuncCall(cmd, funcs) {
+				args = cmd[1:]
+			}
+			for _, tok := range args {
+				if tok.isVariable() {
+					names = append(names, tok.text)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// token is a word in a placeholder expression.
+type token struct {
+	text   string
+	quoted bool
+}
+
+// isVariable reports whether the token refers to a prompt variable.
+func (t token) isVariable() bool {
+	if t.quoted {
+		return false
+	}
+	_, err := strconv.ParseFloat(t.text, 64)
+	return err != nil
+}
+
+// parseCommands splits an expression into pipeline commands of tokens.
+func parseCommands(expr string) ([][]token, error) {
+	var commands [][]token
+	var current []token
+
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '|':
+			if len(current) == 0 {
+				return nil, fmt.Errorf("empty command in pipeline")
+			}
+			commands = append(commands, current)
+			current = nil
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(expr) && expr[end] != '"' {
+				if expr[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			text, err := strconv.Unquote(expr[i : end+1])
+			if err != nil {
+				return nil, err
+			}
+			current = append(current, token{text: text, quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for end < len(expr) && !unicode.IsSpace(rune(expr[end])) && expr[end] != '|' {
+				end++
+			}
+			current = append(current, token{text: expr[i:end]})
+			i = end
+		}
+	}
+
+	if len(current) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return append(commands, current), nil
+}
+
+// isFuncCall reports whether a command starts with a registered function.
+func isFuncCall(cmd []token, funcs template.FuncMap) bool {
+	if len(cmd) == 0 || cmd[0].quoted {
+		return false
+	}
+	_, ok := funcs[cmd[0].text]
+	return ok
+}
+
+// evalArg resolves a token to a literal or variable value.
+func evalArg(tok token, variables map[string]interface{}) (interface{}, error) {
+	if tok.quoted {
+		return tok.text, nil
+	}
+	if n, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+		return int(n), nil
+	}
+	if f, err := strconv.ParseFloat(tok.text, 64); err == nil {
+		return f, nil
+	}
+	return lookupVariable(tok.text, variables)
+}
+
+// lookupVariable returns a variable's value or an error if it is missing.
+func lookupVariable(name string, variables map[string]interface{}) (interface{}, error) {
+	value, exists := variables[name]
+	if !exists {
+		return nil, NewInvalidParametersError(fmt.Sprintf("missing required variable: %s", name))
+	}
+	return value, nil
+}
+
+// callFunc invokes a template function, converting arguments to its parameter types.
+func callFunc(name string, fn interface{}, args []interface{}) (interface{}, error) {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+
+	numIn := ft.NumIn()
+	if ft.IsVariadic() {
+		if len(args) < numIn-1 {
+			return nil, NewInvalidParametersError(fmt.Sprintf("template function %s expects at least %d arguments, got %d", name, numIn-1, len(args)))
+		}
+	} else if len(args) != numIn {
+		return nil, NewInvalidParametersError(fmt.Sprintf("template function %s expects %d arguments, got %d", name, numIn, len(args)))
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var paramType reflect.Type
+		if ft.IsVariadic() && i >= numIn-1 {
+			paramType = ft.In(numIn - 1).Elem()
+		} else {
+			paramType = ft.In(i)
+		}
+		value, err := convertArg(arg, paramType)
+		if err != nil {
+			return nil, NewInvalidParametersError(fmt.Sprintf("template function %s argument %d: %v", name, i+1, err))
+		}
+		in[i] = value
+	}
+
+	out := fv.Call(in)
+	switch len(out) {
+	case 1:
+		return out[0].Interface(), nil
+	case 2:
+		if err, _ := out[1].Interface().(error); err != nil {
+			return nil, fmt.Errorf("template function %s: %w", name, err)
+		}
+		return out[0].Interface(), nil
+	default:
+		return nil, fmt.Errorf("template function %s must return one value or a value and an error", name)
+	}
+}
+
+// convertArg converts a template value to the given parameter type.
+func convertArg(arg interface{}, paramType reflect.Type) (reflect.Value, error) {
+	if arg == nil {
+		return reflect.Zero(paramType), nil
+	}
+
+	value := reflect.ValueOf(arg)
+	switch {
+	case value.Type().AssignableTo(paramType):
+		return value, nil
+	case paramType.Kind() == reflect.String:
+		return reflect.ValueOf(stringifyValue(arg)).Convert(paramType), nil
+	case value.Kind() == reflect.String:
+		// Prompt arguments arrive as strings; parse them for numeric parameters
+		n, err := strconv.ParseFloat(value.String(), 64)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("cannot use %q as %s", value.String(), paramType)
+		}
+		return convertArg(n, paramType)
+	case value.Type().ConvertibleTo(paramType):
+		return value.Convert(paramType), nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use %T as %s", arg, paramType)
+}
+
+// stringifyValue renders a template value as text.
+func stringifyValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		if jsonBytes, err := json.Marshal(v); err == nil {
+			return string(jsonBytes)
+		}
+		return fmt.Sprintf("%v", v)
+	}
+}
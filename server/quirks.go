@@ -0,0 +1,74 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/localrivet/gomcp/util/schema"
+)
+
+// DefaultHostQuirks returns the schema workarounds applied to tools/list
+// for hosts known to mishandle parts of JSON Schema, keyed by the client
+// name they send at initialize. WithHostQuirks adds hosts or overrides
+// these entries.
+func DefaultHostQuirks() map[string][]schema.Transform {
+	return map[string][]schema.Transform{
+		"gemini-cli": {schema.InlineRefs},
+	}
+}
+
+// WithHostQuirks rewrites the tool schemas listed to clients that identify
+// themselves as clientName, matched without regard to case, replacing any
+// default entry for that host. Passing no transforms turns the workarounds
+// off for it.
+//
+// Example:
+//
+//	srv := server.NewServer("my-service",
+//	    server.WithHostQuirks("acme-desktop", schema.IntegerAsNumber, schema.DropEmptyRequired),
+//	    server.WithHostQuirks("gemini-cli"),
+//	)
+func WithHostQuirks(clientName string, transforms ...schema.Transform) Option {
+	return func(s *serverImpl) {
+		if s.quirks == nil {
+			s.quirks = DefaultHostQuirks()
+		}
+		for name := range s.quirks {
+			if strings.EqualFold(name, clientName) {
+				delete(s.quirks, name)
+			}
+		}
+		s.quirks[clientName] = transforms
+	}
+}
+
+// hostQuirks returns the schema transforms for the request's client.
+func (s *serverImpl) hostQuirks(ctx *Context) []schema.Transform {
+	clientInfo, found := s.getClientInfoForSession(ctx.sessionID())
+	if !found || clientInfo.Name == "" {
+		return nil
+	}
+	quirks := s.quirks
+	if quirks == nil {
+		quirks = DefaultHostQuirks()
+	}
+	for name, transforms := range quirks {
+		if strings.EqualFold(name, clientInfo.Name) {
+			return transforms
+		}
+	}
+	return nil
+}
+
+// applyQuirks returns a tool schema rewritten by transforms, or unchanged if
+// there are none or it can't be rewritten.
+func (s *serverImpl) applyQuirks(toolName string, toolSchema interface{}, transforms []schema.Transform) interface{} {
+	if len(transforms) == 0 || toolSchema == nil {
+		return toolSchema
+	}
+	rewritten, err := schema.Rewrite(toolSchema, transforms...)
+	if err != nil {
+		s.logger.Warn("failed to apply host quirks to tool schema", "name", toolName, "error", err)
+		return toolSchema
+	}
+	return rewritten
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/localrivet/gomcp/ratelimit"
+	"github.com/localrivet/gomcp/webhook"
+)
+
+// QuotaPeriod is the calendar period after which a quota resets. Periods
+// start at midnight UTC.
+type QuotaPeriod string
+
+const (
+	// QuotaDaily resets every day.
+	QuotaDaily QuotaPeriod = "daily"
+
+	// QuotaMonthly resets on the first day of every month.
+	QuotaMonthly QuotaPeriod = "monthly"
+)
+
+// bounds returns an identifier for the period containing t, and when the
+// period ends.
+func (p QuotaPeriod) bounds(t time.Time) (string, time.Time) {
+	t = t.UTC()
+	if p == QuotaMonthly {
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start.Format("2006-01"), start.AddDate(0, 1, 0)
+	}
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return start.Format("2006-01-02"), start.AddDate(0, 0, 1)
+}
+
+// Quota limits the credits a key may spend on tool calls in a period, as
+// set on tools with WithCost. Calls to tools without a cost are not counted
+// and are never rejected.
+type Quota struct {
+	// Name identifies the quota in store keys, warnings and rejection errors.
+	Name string
+
+	Period QuotaPeriod
+
+	// Soft is the usage at which the client is warned with a
+	// notifications/message of level "warning". Zero disables the warning.
+	Soft float64
+
+	// Hard is the usage the quota may not exceed. Calls that would exceed it
+	// are rejected until the period ends. Zero disables the limit.
+	Hard float64
+
+	// Key selects what calls share the quota. It defaults to
+	// RateLimitByAPIKey.
+	Key RateLimitKeyFunc
+}
+
+// WithQuotas enforces per-key quotas on tool call credits, keeping the
+// running totals in store. With a shared store such as
+// ratelimit.RedisStore, usage survives restarts and is shared by every
+// replica of the server.
+//
+// Credits are taken from the quotas when a call starts and given back if it
+// fails. Rejected calls get a RateLimitedCode error whose data names the
+// quota and says how many seconds remain until it resets in retryAfter. If
+// the store fails, the error is logged and the call is allowed.
+//
+// Example:
+//
+//	srv := server.NewServer("my-service",
+//	    server.WithQuotas(ratelimit.NewRedisStore(redisClient, "mcp:"),
+//	        server.Quota{Name: "daily", Period: server.QuotaDaily, Soft: 800, Hard: 1000},
+//	        server.Quota{Name: "monthly", Period: server.QuotaMonthly, Hard: 20000},
+//	    ),
+//	)
+func WithQuotas(store ratelimit.CounterStore, quotas ...Quota) Option {
+	return func(s *serverImpl) {
+		s.quotaStore = store
+		s.quotas = append(s.quotas, quotas...)
+	}
+}
+
+// quotaReservation is the credits taken from quotas for a call in progress.
+type quotaReservation struct {
+	ctx     context.Context
+	store   ratelimit.CounterStore
+	logger  *slog.Logger
+	credits float64
+	keys    []string
+	resets  []time.Time
+}
+
+// release gives the reserved credits back, for calls that were not charged.
+func (r *quotaReservation) release() {
+	if r == nil {
+		return
+	}
+	for i, key := range r.keys {
+		if _, err := r.store.Add(r.ctx, key, -r.credits, r.resets[i]); err != nil {
+			r.logger.Error("failed to refund quota", "key", key, "error", err)
+		}
+	}
+}
+
+// reserveQuotas takes the cost of a tool call from every quota that applies
+// to it. If a hard limit would be exceeded, the credits are given back and
+// the error to reject the call with is returned instead.
+func (s *serverImpl) reserveQuotas(ctx *Context) (*quotaReservation, *RPCError) {
+	if s.quotaStore == nil || ctx.Request == nil {
+		return nil, nil
+	}
+
+	s.mu.RLock()
+	var credits float64
+	if tool, ok := s.tools[ctx.Request.ToolName]; ok && tool.Cost != nil {
+		credits = tool.Cost.Credits
+	}
+	s.mu.RUnlock()
+	if credits <= 0 {
+		return nil, nil
+	}
+
+	stdCtx := ctx.ctx
+	if stdCtx == nil {
+		stdCtx = context.Background()
+	}
+	reservation := &quotaReservation{ctx: stdCtx, store: s.quotaStore, logger: s.logger, credits: credits}
+	now := time.Now()
+	var warnings []func()
+
+	for _, quota := range s.quotas {
+		keyFunc := quota.Key
+		if keyFunc == nil {
+			keyFunc = RateLimitByAPIKey
+		}
+		key := keyFunc(ctx)
+		if key == "" {
+			continue
+		}
+
+		period, resetsAt := quota.Period.bounds(now)
+		storeKey := "quota:" + quota.Name + ":" + period + ":" + key
+		used, err := s.quotaStore.Add(stdCtx, storeKey, credits, resetsAt)
+		if err != nil {
+			s.logger.Error("quota check failed", "quota", quota.Name, "error", err)
+			continue
+		}
+		reservation.keys = append(reservation.keys, storeKey)
+		reservation.resets = append(reservation.resets, resetsAt)
+
+		if quota.Hard > 0 && used > quota.Hard {
+			reservation.release()
+			s.logger.Warn("tool call over quota", "quota", quota.Name, "tool", ctx.Request.ToolName)
+			s.emitEvent(webhook.EventQuotaExceeded, map[string]interface{}{
+				"quota":    quota.Name,
+				"used":     used - credits,
+				"limit":    quota.Hard,
+				"apiKeyID": apiKeyID(ctx.Meta().APIKey()),
+				"tool":     ctx.Request.ToolName,
+			})
+			return nil, &RPCError{Code: RateLimitedCode, Message: "Quota exceeded", Data: map[string]interface{}{
+				"quota":      quota.Name,
+				"limit":      quota.Hard,
+				"used":       used - credits,
+				"retryAfter": int(math.Ceil(resetsAt.Sub(now).Seconds())),
+			}}
+		}
+
+		if quota.Soft > 0 && used >= quota.Soft && used-credits < quota.Soft {
+			warnings = append(warnings, func() { s.warnQuota(ctx, quota, used, resetsAt) })
+		}
+	}
+
+	// Warn only once no hard limit rejected the call
+	for _, warn := range warnings {
+		warn()
+	}
+	return reservation, nil
+}
+
+// warnQuota tells the operator, the webhooks and the client that a soft limit was reached.
+func (s *serverImpl) warnQuota(ctx *Context, quota Quota, used float64, resetsAt time.Time) {
+	s.logger.Warn("quota soft limit reached", "quota", quota.Name, "used", used, "soft", quota.Soft)
+	s.emitEvent(webhook.EventQuotaWarning, map[string]interface{}{
+		"quota":    quota.Name,
+		"used":     used,
+		"limit":    quota.Soft,
+		"apiKeyID": apiKeyID(ctx.Meta().APIKey()),
+		"tool":     ctx.Request.ToolName,
+	})
+
+	data := map[string]interface{}{
+		"message":  "quota " + quota.Name + " soft limit reached",
+		"quota":    quota.Name,
+		"used":     used,
+		"soft":     quota.Soft,
+		"resetsAt": resetsAt.Format(time.RFC3339),
+	}
+	if quota.Hard > 0 {
+		data["hard"] = quota.Hard
+	}
+	s.sendNotification("notifications/message", map[string]interface{}{
+		"level":  "warning",
+		"logger": "quota",
+		"data":   data,
+	})
+}
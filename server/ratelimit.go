@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"time"
+
+	"github.com/localrivet/gomcp/protocol"
+	"github.com/localrivet/gomcp/ratelimit"
+)
+
+// RateLimitedCode is the JSON-RPC error code of requests rejected by a rate
+// limit. The gRPC transport maps it to ResourceExhausted.
+const RateLimitedCode = protocol.RateLimited
+
+// RateLimitKeyFunc returns the key a request is counted under, or "" if the
+// rule does not apply to the request.
+type RateLimitKeyFunc func(ctx *Context) string
+
+// RateLimitRule limits how many requests may share a key within a window.
+type RateLimitRule struct {
+	// Name identifies the rule in store keys and in rejection errors.
+	Name string
+
+	Limit ratelimit.Limit
+
+	// Key selects what requests are counted together.
+	Key RateLimitKeyFunc
+}
+
+// RateLimitBySession counts requests per client session.
+func RateLimitBySession(ctx *Context) string {
+	return string(ctx.sessionID())
+}
+
+// RateLimitByAPIKey counts requests per API key, as sent in the X-API-Key
+// header or in _meta.apiKey. Requests without a key are not counted. The key
+// is hashed before it reaches the store.
+//
+// The key is whatever the client sent; authenticate it before relying on
+// the limit to hold per customer.
+func RateLimitByAPIKey(ctx *Context) string {
+	return apiKeyID(ctx.Meta().APIKey())
+}
+
+// RateLimitByTool counts tool calls per tool, across all callers. Other
+// requests are not counted.
+func RateLimitByTool(ctx *Context) string {
+	if ctx.Request.Method != "tools/call" {
+		return ""
+	}
+	return ctx.Request.ToolName
+}
+
+// apiKeyID returns a stable identifier for an API key that does not reveal
+// the key, or "" for an empty key.
+func apiKeyID(key string) string {
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// WithRateLimitStore enforces rate limit rules, counting requests in store.
+// With a shared store such as ratelimit.RedisStore, every replica of the
+// server enforces the limits together.
+//
+// Rejected requests get a RateLimitedCode error whose data names the rule
+// and says how many seconds to wait in retryAfter. If the store fails, the
+// error is logged and the request is allowed.
+//
+// Example:
+//
+//	store := ratelimit.NewRedisStore(redisClient, "mcp:")
+//	srv := server.NewServer("my-service",
+//	    server.WithRateLimitStore(store,
+//	        server.RateLimitRule{Name: "per-session", Limit: ratelimit.PerSecond(20), Key: server.RateLimitBySession},
+//	        server.RateLimitRule{Name: "per-key", Limit: ratelimit.PerHour(5000), Key: server.RateLimitByAPIKey},
+//	    ),
+//	)
+func WithRateLimitStore(store ratelimit.Store, rules ...RateLimitRule) Option {
+	return func(s *serverImpl) {
+		s.rateLimitStore = store
+		s.rateLimitRules = append(s.rateLimitRules, rules...)
+	}
+}
+
+// tokenBucketLimit is a limit added with WithRateLimit.
+type tokenBucketLimit struct {
+	buckets *ratelimit.TokenBuckets
+	key     RateLimitKeyFunc
+}
+
+// WithRateLimit limits requests with a token bucket per key: each key may
+// make burst requests at once, and rate requests per second on average.
+// The buckets are kept in memory; use WithRateLimitStore to share limits
+// between replicas. The option may be given more than once, for example to
+// limit both sessions and tools, and a request must fit within every limit.
+//
+// Rejected requests get a RateLimitedCode error whose data says how many
+// seconds to wait in retryAfter.
+//
+// Example:
+//
+//	srv := server.NewServer("my-service",
+//	    server.WithRateLimit(5, 20, server.RateLimitBySession),
+//	    server.WithRateLimit(1, 2, server.RateLimitByTool),
+//	)
+func WithRateLimit(rate float64, burst int, key RateLimitKeyFunc) Option {
+	return func(s *serverImpl) {
+		s.tokenBuckets = append(s.tokenBuckets, tokenBucketLimit{
+			buckets: ratelimit.NewTokenBuckets(rate, burst),
+			key:     key,
+		})
+	}
+}
+
+// enforceRateLimits counts a request against every rule that applies to it,
+// and returns an error response if one of them is exceeded. Notifications
+// and initialize are never limited.
+func (s *serverImpl) enforceRateLimits(ctx *Context) ([]byte, bool) {
+	if ctx.Request.ID == nil || ctx.Request.Method == "initialize" {
+		return nil, false
+	}
+
+	for _, limit := range s.tokenBuckets {
+		if limit.key == nil {
+			continue
+		}
+		key := limit.key(ctx)
+		if key == "" {
+			continue
+		}
+		if result := limit.buckets.Take(key); !result.Allowed {
+			s.logger.Warn("request rate limited", "method", ctx.Request.Method)
+			return createErrorResponse(ctx.Request.ID, RateLimitedCode, "Rate limit exceeded", map[string]interface{}{
+				"retryAfter": retryAfterSeconds(result.RetryAfter),
+			}), true
+		}
+	}
+
+	if s.rateLimitStore == nil {
+		return nil, false
+	}
+
+	stdCtx := ctx.ctx
+	if stdCtx == nil {
+		stdCtx = context.Background()
+	}
+
+	for _, rule := range s.rateLimitRules {
+		if rule.Key == nil {
+			continue
+		}
+		key := rule.Key(ctx)
+		if key == "" {
+			continue
+		}
+
+		result, err := s.rateLimitStore.Allow(stdCtx, rule.Name+":"+key, rule.Limit)
+		if err != nil {
+			s.logger.Error("rate limit check failed", "rule", rule.Name, "error", err)
+			continue
+		}
+		if !result.Allowed {
+			s.logger.Warn("request rate limited", "rule", rule.Name, "method", ctx.Request.Method)
+			return createErrorResponse(ctx.Request.ID, RateLimitedCode, "Rate limit exceeded", map[string]interface{}{
+				"rule":       rule.Name,
+				"retryAfter": retryAfterSeconds(result.RetryAfter),
+			}), true
+		}
+	}
+	return nil, false
+}
+
+// retryAfterSeconds rounds a wait up to whole seconds.
+func retryAfterSeconds(wait time.Duration) int {
+	return int(math.Ceil(wait.Seconds()))
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"errors"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// Severity ranks how serious a reported error is.
+type Severity int
+
+const (
+	// SeverityWarning is for requests rejected as invalid, such as unknown
+	// methods and bad params.
+	SeverityWarning Severity = iota + 1
+
+	// SeverityError is for errors returned by handlers.
+	SeverityError
+
+	// SeverityFatal is for panics recovered from handlers.
+	SeverityFatal
+)
+
+// String returns the level name used by Sentry and Rollbar.
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityFatal:
+		return "fatal"
+	}
+	return "info"
+}
+
+// Severe can be implemented by errors returned from handlers to set the
+// severity they are reported at. Errors that don't implement it are
+// reported at SeverityError.
+type Severe interface {
+	Severity() Severity
+}
+
+// ErrorReport describes a recovered panic or a handler error.
+type ErrorReport struct {
+	Severity Severity
+	Time     time.Time
+
+	// Err is the error. For panics it is a *HandlerPanic.
+	Err error
+
+	// Stack is the stack trace of a panic, and empty for errors.
+	Stack []byte
+
+	// Method, Tool and SessionID locate the request that failed. Tool is
+	// empty for methods other than tools/call.
+	Method    string
+	Tool      string
+	SessionID string
+
+	// Arguments are the tool or prompt arguments, with the values of
+	// sensitive fields replaced by "[REDACTED]".
+	Arguments map[string]interface{}
+
+	// Server, Release and Environment describe the deployment.
+	Server      string
+	Release     string
+	Environment string
+}
+
+// ErrorReporter receives error reports, typically to forward them to a
+// crash reporting service. Report is called on the request's goroutine, so
+// implementations should hand reports off without blocking, as the Sentry
+// and Rollbar clients do.
+type ErrorReporter interface {
+	Report(report ErrorReport)
+}
+
+// ErrorReporterFunc adapts a function to the ErrorReporter interface.
+type ErrorReporterFunc func(report ErrorReport)
+
+// Report calls f.
+func (f ErrorReporterFunc) Report(report ErrorReport) {
+	f(report)
+}
+
+// ErrorReporting configures WithErrorReporting.
+type ErrorReporting struct {
+	Reporter ErrorReporter
+
+	// MinSeverity is the least severity reported. It defaults to
+	// SeverityError.
+	MinSeverity Severity
+
+	// Release and Environment are copied into every report.
+	Release     string
+	Environment string
+
+	// Redact lists argument names whose values are not reported. Names
+	// match case-insensitively, anywhere in a field name and at any depth.
+	// It defaults to DefaultRedactedFields.
+	Redact []string
+}
+
+// DefaultRedactedFields are the argument names redacted unless
+// ErrorReporting.Redact is set.
+var DefaultRedactedFields = []string{
+	"password", "passwd", "secret", "token", "apikey", "api_key",
+	"authorization", "credential", "cookie", "private_key",
+}
+
+// WithErrorReporting recovers panics in request handling and reports them,
+// along with handler errors at or above the configured severity, to a
+// crash reporter. A request whose handler panicked gets an internal error
+// response, and the server keeps running.
+//
+// Example:
+//
+//	srv := server.NewServer("my-service",
+//	    server.WithErrorReporting(server.ErrorReporting{
+//	        Reporter: server.ErrorReporterFunc(func(r server.ErrorReport) {
+//	            event := sentry.NewEvent()
+//	            event.Level = sentry.Level(r.Severity.String())
+//	            event.Message = r.Err.Error()
+//	            event.Release = r.Release
+//	            event.Tags = map[string]string{"method": r.Method, "tool": r.Tool}
+//	            event.Extra = map[string]interface{}{"arguments": r.Arguments}
+//	            sentry.CaptureEvent(event)
+//	        }),
+//	        Release: version,
+//	    }),
+//	)
+func WithErrorReporting(config ErrorReporting) Option {
+	return func(s *serverImpl) {
+		if config.MinSeverity == 0 {
+			config.MinSeverity = SeverityError
+		}
+		if config.Redact == nil {
+			config.Redact = DefaultRedactedFields
+		}
+		s.errorReporting = &config
+	}
+}
+
+// reportErrors wraps the handling of a request to report its failures.
+func (s *serverImpl) reportErrors(next RequestHandler) RequestHandler {
+	return func(ctx *Context) (result interface{}, err error) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			handlerPanic, ok := recovered.(*HandlerPanic)
+			if !ok {
+				handlerPanic = &HandlerPanic{Value: recovered, Stack: debug.Stack()}
+			}
+			s.logger.Error("recovered panic in request handler", "method", ctx.Request.Method, "panic", handlerPanic.Value)
+			s.reportError(ctx, SeverityFatal, handlerPanic, handlerPanic.Stack)
+			result = nil
+			err = &RPCError{Code: -32603, Message: "Internal error", Data: "request handler panicked"}
+		}()
+
+		result, err = next(ctx)
+		switch {
+		case ctx.handlerErr != nil:
+			severity := SeverityError
+			var severe Severe
+			if errors.As(ctx.handlerErr, &severe) {
+				severity = severe.Severity()
+			}
+			s.reportError(ctx, severity, ctx.handlerErr, nil)
+		case err != nil:
+			s.reportError(ctx, requestErrorSeverity(err), err, nil)
+		}
+		return result, err
+	}
+}
+
+// requestErrorSeverity ranks an error that failed a request.
+func requestErrorSeverity(err error) Severity {
+	var severe Severe
+	if errors.As(err, &severe) {
+		return severe.Severity()
+	}
+	var rpcErr *RPCError
+	var invalid *InvalidParametersError
+	var capability *CapabilityError
+	if errors.As(err, &rpcErr) || errors.As(err, &invalid) || errors.As(err, &capability) {
+		return SeverityWarning
+	}
+	return SeverityError
+}
+
+// reportError sends a report if it is severe enough.
+func (s *serverImpl) reportError(ctx *Context, severity Severity, err error, stack []byte) {
+	config := s.errorReporting
+	if severity < config.MinSeverity {
+		return
+	}
+
+	args := ctx.Request.ToolArgs
+	if args == nil {
+		args = ctx.Request.PromptArgs
+	}
+	config.Reporter.Report(ErrorReport{
+		Severity:    severity,
+		Time:        time.Now().UTC(),
+		Err:         err,
+		Stack:       stack,
+		Method:      ctx.Request.Method,
+		Tool:        ctx.Request.ToolName,
+		SessionID:   ctx.SessionID(),
+		Arguments:   redactFields(args, config.Redact),
+		Server:      s.name,
+		Release:     config.Release,
+		Environment: config.Environment,
+	})
+}
+
+// redactFields copies a map, replacing the values of sensitive fields.
+func redactFields(fields map[string]interface{}, sensitive []string) map[string]interface{} {
+	if fields == nil {
+		return nil
+	}
+	redacted := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		if isSensitiveField(name, sensitive) {
+			redacted[name] = "[REDACTED]"
+			continue
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			redacted[name] = redactFields(v, sensitive)
+		case []interface{}:
+			items := make([]interface{}, len(v))
+			for i, item := range v {
+				if nested, ok := item.(map[string]interface{}); ok {
+					items[i] = redactFields(nested, sensitive)
+				} else {
+					items[i] = item
+				}
+			}
+			redacted[name] = items
+		default:
+			redacted[name] = value
+		}
+	}
+	return redacted
+}
+
+func isSensitiveField(name string, sensitive []string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitive {
+		if strings.Contains(name, strings.ToLower(s)) {
+			return true
+		}
+	}
+	return false
+}
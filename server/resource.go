@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"reflect"
 	"strings"
 	"time"
@@ -37,6 +38,14 @@ type Resource struct {
 
 	// IsTemplate indicates whether this resource path contains parameters
 	IsTemplate bool // Whether this resource is a template with parameters
+
+	// scopes are required of callers; see WithRequiredScopes
+	scopes []string
+
+	// queryParams are the parameters of the path's query expressions, such
+	// as "{?format}", which are matched against the query of URIs rather
+	// than by Template
+	queryParams []string
 }
 
 // Resource registers a resource with the server.
@@ -64,8 +73,10 @@ func (s *serverImpl) Resource(path string, description string, handler interface
 		}
 	}
 
-	// Parse the path template using wilduri
-	template, err := wilduri.New(path)
+	// Parse the path template using wilduri, which matches queries as part
+	// of the last path variable, so query expressions are matched apart
+	templatePath, queryParams := splitQueryExpressions(path)
+	template, err := wilduri.New(templatePath)
 	if err != nil {
 		s.logger.Error("failed to parse path template", "path", path, "error", err)
 		return s
@@ -83,32 +94,98 @@ func (s *serverImpl) Resource(path string, description string, handler interface
 		Schema:      handlerSchema,
 		Template:    template,
 		IsTemplate:  isTemplate,
+		queryParams: queryParams,
 	}
 
 	// Store the resource
 	s.resources[path] = resource
 
-	// Send notification asynchronously to avoid blocking
-	go func() {
-		// TODO: Implement SendResourcesListChangedNotification
-	}()
+	// Tell initialized clients about the new resource
+	s.notifyListChanged(resourcesListChanged)
 
 	return s
 }
 
+// UnregisterResource removes the resource registered with path and sends
+// notifications/resources/list_changed.
+func (s *serverImpl) UnregisterResource(path string) bool {
+	s.mu.Lock()
+	_, exists := s.resources[path]
+	delete(s.resources, path)
+	s.mu.Unlock()
+
+	if !exists {
+		return false
+	}
+	s.notifyListChanged(resourcesListChanged)
+	return true
+}
+
+// SendResourcesListChangedNotification tells clients that the resource
+// list has changed, so they list resources again.
+func (s *serverImpl) SendResourcesListChangedNotification() {
+	s.mu.RLock()
+	initialized := s.initialized
+	s.mu.RUnlock()
+
+	// Clients that haven't initialized will list resources anyway
+	if initialized {
+		s.sendNotification("notifications/resources/list_changed", nil)
+	}
+}
+
 // ProcessResourceSubscribe processes a resource subscription request.
 // Resource subscriptions allow clients to receive notifications when resource data changes.
+// The subscription is recorded on the requesting session so that NotifyResourceUpdated
+// only reaches clients that asked for updates.
 // Returns a response indicating whether the subscription was successful.
 func (s *serverImpl) ProcessResourceSubscribe(ctx *Context) (interface{}, error) {
-	// TODO: Implement resource subscription
+	if !s.resourceSubscriptions {
+		return nil, &CapabilityError{
+			Method:     ctx.Request.Method,
+			Capability: "resources.subscribe",
+			Side:       "server",
+			Hint:       "enable it with server.WithResourceSubscriptions(true)",
+		}
+	}
+
+	uri, err := parseResourceURIParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionManager.Subscribe(ctx.sessionID(), uri, s.maxSubscriptions); err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", uri, err)
+	}
+	s.saveSession(ctx.sessionID())
+
+	s.logger.Debug("resource subscribed", "uri", uri, "sessionID", string(ctx.sessionID()))
 	return map[string]interface{}{"subscribed": true}, nil
 }
 
 // ProcessResourceUnsubscribe processes a resource unsubscription request.
 // This allows clients to stop receiving notifications for a previously subscribed resource.
+// Unsubscribing from a URI the session was not subscribed to is not an error.
 // Returns a response indicating whether the unsubscription was successful.
 func (s *serverImpl) ProcessResourceUnsubscribe(ctx *Context) (interface{}, error) {
-	// TODO: Implement resource unsubscription
+	if !s.resourceSubscriptions {
+		return nil, &CapabilityError{
+			Method:     ctx.Request.Method,
+			Capability: "resources.subscribe",
+			Side:       "server",
+			Hint:       "enable it with server.WithResourceSubscriptions(true)",
+		}
+	}
+
+	uri, err := parseResourceURIParam(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.sessionManager.Unsubscribe(ctx.sessionID(), uri) {
+		s.logger.Debug("resource unsubscribed", "uri", uri, "sessionID", string(ctx.sessionID()))
+		s.saveSession(ctx.sessionID())
+	}
 	return map[string]interface{}{"unsubscribed": true}, nil
 }
 
@@ -120,28 +197,15 @@ func (s *serverImpl) ProcessResourceTemplatesList(ctx *Context) (interface{}, er
 	defer s.mu.RUnlock()
 
 	// Get pagination cursor if provided
-	var cursor string
-	if ctx.Request.Params != nil {
-		var params struct {
-			Cursor string `json:"cursor"`
-		}
-		if err := json.Unmarshal(ctx.Request.Params, &params); err != nil {
-			return nil, fmt.Errorf("invalid params: %w", err)
-		}
-		cursor = params.Cursor
+	cursor, err := listCursor(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// For now, we'll use a simple pagination that returns all template resources
-	const maxPageSize = 50
 	templates := make([]map[string]interface{}, 0)
-	var nextCursor string
-
-	// Convert resources to the expected format
-	i := 0
-	for path, resource := range s.resources {
-		// Skip if not a template or if we haven't reached the cursor yet
-		if !resource.IsTemplate || (cursor != "" && path <= cursor) {
-			continue
+	nextCursor := paginate(s, s.resources, cursor, func(path string, resource *Resource) bool {
+		if !resource.IsTemplate || !ctx.permitted(resource.scopes) {
+			return false
 		}
 
 		// Use the full path as the name if no other name is available
@@ -165,14 +229,8 @@ func (s *serverImpl) ProcessResourceTemplatesList(ctx *Context) (interface{}, er
 			"description": resource.Description,
 			"mimeType":    mimeType,
 		})
-
-		i++
-		if i >= maxPageSize {
-			// Set cursor for next page
-			nextCursor = path
-			break
-		}
-	}
+		return true
+	})
 
 	// Return the list of resource templates
 	result := map[string]interface{}{
@@ -444,17 +502,44 @@ func (s *serverImpl) ProcessResourceRequest(ctx *Context) (interface{}, error) {
 		return nil, errors.New("missing or empty uri in resource request")
 	}
 
+	if uri == PinnedResourcesURI {
+		return s.readPinnedResources(ctx)
+	}
+	if strings.HasPrefix(uri, OverflowURIPrefix) {
+		return s.readOverflow(ctx, uri)
+	}
+
 	// Find the resource and extract params
 	resource, pathParams, found := s.findResourceAndExtractParams(uri)
 	if !found {
 		return nil, fmt.Errorf("resource not found: %s", uri)
 	}
+	if !ctx.permitted(resource.scopes) {
+		return nil, insufficientScopeError("resource", uri, resource.scopes)
+	}
 
 	// Execute the resource handler
 	result, err := resource.Handler(ctx, pathParams)
+	if errors.Is(err, ErrResourceTooLarge) {
+		return nil, resourceTooLargeError(uri, -1, s.resourceSizeLimit())
+	}
 	if err != nil {
 		return nil, fmt.Errorf("resource handler error: %w", err)
 	}
+	// Blobs are typed and, if text, decoded by normalizeResourceResult
+	typed, err := typedResourceContents(uri, result, resourceFormat(pathParams), s.resourceSizeLimit())
+	if err != nil {
+		return nil, err
+	}
+	if typed != nil {
+		result = typed
+	}
+	if contents, ok := result.(binaryContents); ok {
+		return s.digestResourceResult(s.scanResourceResult(uri, map[string]interface{}(contents)))
+	}
+	if contents, ok := result.(resourceContents); ok {
+		return s.digestResourceResult(s.scanResourceResult(uri, normalizeResourceResult(uri, map[string]interface{}(contents))))
+	}
 
 	// Format the response based on the protocol version
 	// Get the protocol version from the context
@@ -464,7 +549,7 @@ func (s *serverImpl) ProcessResourceRequest(ctx *Context) (interface{}, error) {
 		version = "2025-03-26"
 	}
 
-	return formatResourceResponse(result, version), nil
+	return s.digestResourceResult(s.scanResourceResult(uri, normalizeResourceResult(uri, formatResourceResponse(result, version))))
 }
 
 // ProcessResourceList processes a resource list request.
@@ -476,28 +561,15 @@ func (s *serverImpl) ProcessResourceList(ctx *Context) (interface{}, error) {
 	defer s.mu.RUnlock()
 
 	// Get pagination cursor if provided
-	var cursor string
-	if ctx.Request.Params != nil {
-		var params struct {
-			Cursor string `json:"cursor"`
-		}
-		if err := json.Unmarshal(ctx.Request.Params, &params); err != nil {
-			return nil, fmt.Errorf("invalid params: %w", err)
-		}
-		cursor = params.Cursor
+	cursor, err := listCursor(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// For now, we'll use a simple pagination that returns all resources
-	const maxPageSize = 50
 	resources := make([]map[string]interface{}, 0)
-	var nextCursor string
-
-	// Convert resources to the expected format
-	i := 0
-	for path, resource := range s.resources {
-		// Skip if we haven't reached the cursor yet
-		if cursor != "" && path <= cursor {
-			continue
+	nextCursor := paginate(s, s.resources, cursor, func(path string, resource *Resource) bool {
+		if !ctx.permitted(resource.scopes) {
+			return false
 		}
 
 		// Use the full path as the name if no other name is available
@@ -528,12 +600,13 @@ func (s *serverImpl) ProcessResourceList(ctx *Context) (interface{}, error) {
 		}
 
 		resources = append(resources, resourceInfo)
+		return true
+	})
 
-		i++
-		if i >= maxPageSize {
-			// Set cursor for next page
-			nextCursor = path
-			break
+	// Surface the session's pinned resources on the first page
+	if cursor == "" {
+		if pinned := s.pinnedResourcesInfo(ctx); pinned != nil {
+			resources = append(resources, pinned)
 		}
 	}
 
@@ -569,13 +642,23 @@ func (s *serverImpl) findResourceAndExtractParams(uri string) (*Resource, map[st
 		}
 
 		// Use the template to match the URI
-		matches, matched := resource.Template.Match(uri)
+		target, rawQuery := uri, ""
+		if len(resource.queryParams) > 0 {
+			target, rawQuery, _ = strings.Cut(uri, "?")
+		}
+		matches, matched := resource.Template.Match(target)
 		if matched && matches != nil {
 			// Convert matches to a map for the handler
 			params := make(map[string]interface{})
 			for key, value := range matches {
 				params[key] = value
 			}
+			query, _ := url.ParseQuery(rawQuery)
+			for _, name := range resource.queryParams {
+				if values, ok := query[name]; ok {
+					params[name] = strings.Join(values, ",")
+				}
+			}
 			return resource, params, true
 		}
 	}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// DefaultFileCacheSize is how many bytes of served files the server keeps
+// in memory unless WithFileCacheSize is used.
+const DefaultFileCacheSize = 64 << 20
+
+// fileCacheBlock is the size of the aligned blocks files are cached in.
+const fileCacheBlock = 1 << 20
+
+// WithFileCacheSize sets how many bytes of the files served by
+// WithFileContent and WithBinaryContent the server keeps in memory, 64 MiB
+// by default. A client reading a large file in ranges then doesn't have the
+// server read the file again where ranges meet, as text ranges overlap at
+// character boundaries, and clients reading the same file share its
+// blocks. Blocks of a file are dropped once its size or modification time
+// changes. A size of zero or less disables the cache.
+func WithFileCacheSize(n int64) Option {
+	return func(s *serverImpl) {
+		if n <= 0 {
+			n = -1
+		}
+		s.fileCache.mu.Lock()
+		s.fileCache.capacity = n
+		s.fileCache.mu.Unlock()
+	}
+}
+
+// fileCache keeps the most recently read blocks of files, up to its
+// capacity in bytes.
+type fileCache struct {
+	mu       sync.Mutex
+	capacity int64 // zero for DefaultFileCacheSize, negative when disabled
+	used     int64
+	blocks   map[fileBlockKey]*list.Element
+	recent   list.List // of *fileBlock, most recently used first
+	versions map[string]fileBlockKey
+}
+
+// fileBlockKey identifies a block of a version of a file.
+type fileBlockKey struct {
+	path    string
+	modTime int64
+	size    int64
+	index   int64
+}
+
+// fileBlock is a cached block of a file.
+type fileBlock struct {
+	key  fileBlockKey
+	data []byte
+}
+
+// reader returns a reader of length bytes of file at offset, read from
+// cached blocks where it can be. info is the file's Stat.
+func (c *fileCache) reader(file *os.File, info os.FileInfo, offset, length int64) (io.Reader, error) {
+	if c == nil || c.limit() < 0 || length <= 0 {
+		return io.NewSectionReader(file, offset, length), nil
+	}
+
+	key := fileBlockKey{path: file.Name(), modTime: info.ModTime().UnixNano(), size: info.Size()}
+	c.forgetOtherVersions(key)
+	end := offset + length
+	var readers []io.Reader
+	for index := offset / fileCacheBlock; index*fileCacheBlock < end; index++ {
+		key.index = index
+		data, err := c.block(file, key)
+		if err != nil {
+			return nil, err
+		}
+		start := index * fileCacheBlock
+		data = data[max(offset-start, 0):min(end-start, int64(len(data)))]
+		readers = append(readers, bytes.NewReader(data))
+	}
+	return io.MultiReader(readers...), nil
+}
+
+// limit returns the capacity of the cache.
+func (c *fileCache) limit() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.capacity == 0 {
+		return DefaultFileCacheSize
+	}
+	return c.capacity
+}
+
+// forgetOtherVersions drops the blocks of a file that was read while it had
+// another size or modification time.
+func (c *fileCache) forgetOtherVersions(key fileBlockKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version, ok := c.versions[key.path]; ok && version == key {
+		return
+	}
+	if c.versions == nil {
+		c.versions = make(map[string]fileBlockKey)
+	}
+	c.versions[key.path] = key
+	for element := c.recent.Front(); element != nil; {
+		next := element.Next()
+		if block := element.Value.(*fileBlock); block.key.path == key.path {
+			c.recent.Remove(element)
+			delete(c.blocks, block.key)
+			c.used -= int64(len(block.data))
+		}
+		element = next
+	}
+}
+
+// block returns a block of a file, reading and caching it if it isn't
+// cached.
+func (c *fileCache) block(file *os.File, key fileBlockKey) ([]byte, error) {
+	c.mu.Lock()
+	if element, ok := c.blocks[key]; ok {
+		c.recent.MoveToFront(element)
+		c.mu.Unlock()
+		return element.Value.(*fileBlock).data, nil
+	}
+	c.mu.Unlock()
+
+	// Read outside the lock, so reads of other files aren't held up
+	start := key.index * fileCacheBlock
+	data := make([]byte, min(fileCacheBlock, key.size-start))
+	if n, err := file.ReadAt(data, start); n != len(data) {
+		if err == nil || err == io.EOF {
+			err = fmt.Errorf("file changed while being read: got %d of %d bytes", n, len(data))
+		}
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	capacity := c.capacity
+	if capacity == 0 {
+		capacity = DefaultFileCacheSize
+	}
+	if _, ok := c.blocks[key]; ok || int64(len(data)) > capacity {
+		return data, nil
+	}
+	if c.blocks == nil {
+		c.blocks = make(map[fileBlockKey]*list.Element)
+	}
+	c.blocks[key] = c.recent.PushFront(&fileBlock{key: key, data: data})
+	c.used += int64(len(data))
+	for c.used > capacity {
+		oldest := c.recent.Remove(c.recent.Back()).(*fileBlock)
+		delete(c.blocks, oldest.key)
+		c.used -= int64(len(oldest.data))
+		if !c.holds(oldest.key.path) {
+			delete(c.versions, oldest.key.path)
+		}
+	}
+	return data, nil
+}
+
+// holds reports whether any block of the file at path is cached.
+func (c *fileCache) holds(path string) bool {
+	for element := c.recent.Front(); element != nil; element = element.Next() {
+		if element.Value.(*fileBlock).key.path == path {
+			return true
+		}
+	}
+	return false
+}
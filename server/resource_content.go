@@ -0,0 +1,347 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/localrivet/gomcp/protocol"
+	"gopkg.in/yaml.v3"
+)
+
+// FormatParam is the resource template parameter that selects the format
+// typed resource results are sent in, as in "users://{id}{?format}" or
+// "users://{id}.{format}". Its value is a format name or MIME type, or a
+// comma-separated list of them in order of preference like an HTTP Accept
+// header: json, yaml, markdown (or md), text (or txt) and csv, as far as
+// the result can be written in them.
+const FormatParam = "format"
+
+// resourceFormats are the MIME types of format names.
+var resourceFormats = map[string]string{
+	"json":     "application/json",
+	"yaml":     "application/yaml",
+	"yml":      "application/yaml",
+	"markdown": "text/markdown",
+	"md":       "text/markdown",
+	"text":     "text/plain",
+	"txt":      "text/plain",
+	"csv":      "text/csv",
+}
+
+// queryExpression matches the query expressions of URI templates, such as
+// "{?format,limit}".
+var queryExpression = regexp.MustCompile(`\{\?([^}]*)\}`)
+
+// splitQueryExpressions removes the query expressions of a resource path,
+// returning it and the names of their parameters.
+func splitQueryExpressions(path string) (string, []string) {
+	var names []string
+	for _, match := range queryExpression.FindAllStringSubmatch(path, -1) {
+		for _, name := range strings.Split(match[1], ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return queryExpression.ReplaceAllString(path, ""), names
+}
+
+// rendering is a form a typed resource result can be written in.
+type rendering struct {
+	mimeType string
+	render   func() (string, error)
+}
+
+// typedResourceContents maps the result of a resource handler to resource
+// contents when its type says what they are, returning nil otherwise:
+//
+//   - []byte and io.Reader results are read into a blob, whose MIME type is
+//     then inferred and which is sent as text if it is of a text type.
+//     Readers that are also io.Closers are closed.
+//   - protocol.BlobResourceContents results are sent as blobs, never as
+//     text, and protocol.TextResourceContents results as text; both may be
+//     single items, pointers or slices.
+//   - ContentItem and []ContentItem results become one contents item per
+//     content item, text or blob.
+//   - Representations and *Table results, structs, and typed slices and maps
+//     are written in the format selected by the template's FormatParam, or
+//     else their first: Markdown, then text, for Representations and
+//     tables; JSON for the others.
+//
+// Binary content over limit bytes fails with ErrResourceTooLarge. Strings,
+// generic maps and slices, and the response types of response.go keep their
+// established formatting.
+func typedResourceContents(uri string, result interface{}, format string, limit int64) (interface{}, error) {
+	switch v := result.(type) {
+	case nil, string, map[string]interface{}, []interface{}, resourceContents, binaryContents, ResourceResponse, *ResourceResponse, ResourceConverter:
+		return nil, nil
+	case []byte:
+		if int64(len(v)) > limit {
+			return nil, resourceTooLargeError(uri, int64(len(v)), limit)
+		}
+		return blobContents(uri, v), nil
+	case io.Reader:
+		if closer, ok := v.(io.Closer); ok {
+			defer closer.Close()
+		}
+		data, err := io.ReadAll(io.LimitReader(v, limit+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read resource %s: %w", uri, err)
+		}
+		if int64(len(data)) > limit {
+			return nil, resourceTooLargeError(uri, -1, limit)
+		}
+		return blobContents(uri, data), nil
+	case protocol.BlobResourceContents:
+		return protocolBlobContents(uri, []protocol.BlobResourceContents{v}, limit)
+	case *protocol.BlobResourceContents:
+		return protocolBlobContents(uri, []protocol.BlobResourceContents{*v}, limit)
+	case []protocol.BlobResourceContents:
+		return protocolBlobContents(uri, v, limit)
+	case protocol.TextResourceContents:
+		return protocolTextContents(uri, []protocol.TextResourceContents{v}), nil
+	case *protocol.TextResourceContents:
+		return protocolTextContents(uri, []protocol.TextResourceContents{*v}), nil
+	case []protocol.TextResourceContents:
+		return protocolTextContents(uri, v), nil
+	case ContentItem:
+		return contentItemContents(uri, []ContentItem{v}), nil
+	case []ContentItem:
+		return contentItemContents(uri, v), nil
+	case Representations:
+		return renderedContents(uri, representationRenderings(v), format)
+	case *Table:
+		return renderedContents(uri, tableRenderings(v), format)
+	}
+
+	value := reflect.ValueOf(result)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		return renderedContents(uri, structuredRenderings(result), format)
+	}
+	return nil, nil
+}
+
+// blobContents returns resource contents holding data as a blob.
+func blobContents(uri string, data []byte) resourceContents {
+	return resourceContents{"contents": []interface{}{
+		map[string]interface{}{"uri": uri, "blob": base64.StdEncoding.EncodeToString(data)},
+	}}
+}
+
+// protocolBlobContents returns resource contents holding blobs as they
+// are. Blobs without a MIME type get the type of their URI's extension or
+// content, unless that is a text type.
+func protocolBlobContents(uri string, blobs []protocol.BlobResourceContents, limit int64) (binaryContents, error) {
+	contents := make([]interface{}, 0, len(blobs))
+	for _, blob := range blobs {
+		if blob.URI == "" {
+			blob.URI = uri
+		}
+		if size := int64(base64.StdEncoding.DecodedLen(len(blob.Blob))); size > limit {
+			return nil, resourceTooLargeError(blob.URI, size, limit)
+		}
+		if blob.MimeType == "" {
+			head, _ := base64.StdEncoding.DecodeString(blob.Blob[:min(len(blob.Blob), base64.StdEncoding.EncodedLen(sniffLength))])
+			if blob.MimeType = detectMimeType(blob.URI, head); isTextMimeType(blob.MimeType) {
+				blob.MimeType = "application/octet-stream"
+			}
+		}
+		contents = append(contents, map[string]interface{}{"uri": blob.URI, "mimeType": blob.MimeType, "blob": blob.Blob})
+	}
+	return binaryContents{"contents": contents}, nil
+}
+
+// protocolTextContents returns resource contents holding text items.
+func protocolTextContents(uri string, texts []protocol.TextResourceContents) resourceContents {
+	contents := make([]interface{}, 0, len(texts))
+	for _, text := range texts {
+		if text.URI == "" {
+			text.URI = uri
+		}
+		item := map[string]interface{}{"uri": text.URI, "text": text.Text}
+		if text.MimeType != "" {
+			item["mimeType"] = text.MimeType
+		}
+		contents = append(contents, item)
+	}
+	return resourceContents{"contents": contents}
+}
+
+// contentItemContents returns resource contents holding content items.
+// Items carrying neither text nor data, such as links and images by URL,
+// are sent as text/uri-list.
+func contentItemContents(uri string, items []ContentItem) resourceContents {
+	contents := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		entry := map[string]interface{}{"uri": uri}
+		if item.MimeType != "" {
+			entry["mimeType"] = item.MimeType
+		}
+		data, _ := item.Data.(string)
+		switch {
+		case item.Blob != "":
+			entry["blob"] = item.Blob
+		case item.Type == "json":
+			text, _ := json.MarshalIndent(item.Data, "", "  ")
+			entry["text"] = string(text)
+			entry["mimeType"] = "application/json"
+		case data != "" && item.Type != "text":
+			entry["blob"] = data
+		case item.Type == "text":
+			entry["text"] = item.Text
+		case item.URL != "" || item.ImageURL != "":
+			entry["text"] = item.URL + item.ImageURL
+			entry["mimeType"] = "text/uri-list"
+		default:
+			continue
+		}
+		contents = append(contents, entry)
+	}
+	return resourceContents{"contents": contents}
+}
+
+// representationRenderings returns the forms of a Representations result.
+func representationRenderings(r Representations) []rendering {
+	var renderings []rendering
+	if r.Markdown != "" {
+		renderings = append(renderings, rendering{"text/markdown", func() (string, error) { return r.Markdown, nil }})
+	}
+	if r.Text != "" {
+		renderings = append(renderings, rendering{"text/plain", func() (string, error) { return r.Text, nil }})
+	}
+	if r.Structured != nil {
+		renderings = append(renderings, structuredRenderings(r.Structured)...)
+	}
+	return renderings
+}
+
+// tableRenderings returns the forms of a table.
+func tableRenderings(t *Table) []rendering {
+	renderings := []rendering{
+		{"text/markdown", func() (string, error) { return t.Markdown(), nil }},
+		{"text/csv", func() (string, error) { return tableCSV(t) }},
+	}
+	return append(renderings, structuredRenderings(t.Representations().Structured)...)
+}
+
+// structuredRenderings returns the JSON and YAML forms of a value. YAML
+// uses the field names of the JSON.
+func structuredRenderings(v interface{}) []rendering {
+	return []rendering{
+		{"application/json", func() (string, error) {
+			data, err := json.MarshalIndent(v, "", "  ")
+			return string(data), err
+		}},
+		{"application/yaml", func() (string, error) {
+			data, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			var generic interface{}
+			if err := json.Unmarshal(data, &generic); err != nil {
+				return "", err
+			}
+			data, err = yaml.Marshal(generic)
+			return string(data), err
+		}},
+	}
+}
+
+// tableCSV writes a table as CSV, with a header row of column names.
+func tableCSV(t *Table) (string, error) {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	header := make([]string, len(t.Columns))
+	for i, column := range t.Columns {
+		header[i] = column.Name
+	}
+	if err := w.Write(header); err != nil {
+		return "", err
+	}
+	for _, row := range t.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			if value != nil {
+				record[i] = fmt.Sprint(value)
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return b.String(), w.Error()
+}
+
+// renderedContents returns resource contents holding the form of a result
+// a format parameter selects.
+func renderedContents(uri string, renderings []rendering, format string) (interface{}, error) {
+	chosen, ok := chooseRendering(renderings, format)
+	if !ok {
+		available := make([]string, len(renderings))
+		for i, r := range renderings {
+			available[i] = r.mimeType
+		}
+		return nil, &RPCError{
+			Code:    protocol.InvalidParams,
+			Message: fmt.Sprintf("Resource %s is not available as %s", uri, format),
+			Data:    map[string]interface{}{"available": available},
+		}
+	}
+	text, err := chosen.render()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode resource %s as %s: %w", uri, chosen.mimeType, err)
+	}
+	return resourceContents{"contents": []interface{}{
+		map[string]interface{}{"uri": uri, "mimeType": chosen.mimeType, "text": text},
+	}}, nil
+}
+
+// chooseRendering returns the first rendering of the formats listed, in
+// their order, or the first rendering if none is listed.
+func chooseRendering(renderings []rendering, format string) (rendering, bool) {
+	if len(renderings) == 0 {
+		return rendering{}, false
+	}
+	if strings.TrimSpace(format) == "" {
+		return renderings[0], true
+	}
+	for _, accepted := range strings.Split(format, ",") {
+		accepted = strings.ToLower(strings.TrimSpace(accepted))
+		if mediaType, _, err := mime.ParseMediaType(accepted); err == nil {
+			accepted = mediaType
+		}
+		if mimeType, ok := resourceFormats[accepted]; ok {
+			accepted = mimeType
+		}
+		for _, r := range renderings {
+			if accepted == "*/*" || accepted == r.mimeType ||
+				(strings.HasSuffix(accepted, "/*") && strings.HasPrefix(r.mimeType, strings.TrimSuffix(accepted, "*"))) {
+				return r, true
+			}
+		}
+	}
+	return rendering{}, false
+}
+
+// resourceFormat returns the format parameter of a read, which a template
+// may take in its path or query.
+func resourceFormat(params map[string]interface{}) string {
+	format, _ := params[FormatParam].(string)
+	if unescaped, err := url.QueryUnescape(format); err == nil {
+		format = unescaped
+	}
+	return format
+}
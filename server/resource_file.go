@@ -0,0 +1,348 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/localrivet/gomcp/protocol"
+)
+
+// DefaultMaxReadSize is the most bytes of a file a single resources/read
+// returns unless WithMaxReadSize or WithMaxResourceSize is used.
+const DefaultMaxReadSize = 8 << 20
+
+// ErrResourceTooLarge is the error of reading a resource larger than a
+// single resources/read may return. Resource handlers can return it,
+// wrapped or not, for clients to be answered with an Invalid params error
+// whose reason is protocol.ReasonResourceTooLarge.
+var ErrResourceTooLarge = errors.New("resource too large")
+
+// fileReadChunk is the size of the reads files are copied in.
+const fileReadChunk = 64 << 10
+
+var fileChunkPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, fileReadChunk)
+		return &buf
+	},
+}
+
+// FileContentOption configures WithFileContent.
+type FileContentOption func(*fileContent)
+
+// WithMaxResourceSize sets the most bytes of binary or file content a
+// single resources/read returns, DefaultMaxReadSize by default: the size of
+// files served by WithFileContent and WithBinaryContent without their own
+// WithMaxReadSize, and of the []byte, io.Reader and
+// protocol.BlobResourceContents results of resource handlers. Larger
+// content fails with ErrResourceTooLarge.
+func WithMaxResourceSize(n int64) Option {
+	return func(s *serverImpl) {
+		if n > 0 {
+			s.maxResourceSize = n
+		}
+	}
+}
+
+// resourceSizeLimit returns the most bytes of binary or file content a
+// read returns.
+func (s *serverImpl) resourceSizeLimit() int64 {
+	if s == nil || s.maxResourceSize <= 0 {
+		return DefaultMaxReadSize
+	}
+	return s.maxResourceSize
+}
+
+// resourceTooLargeError is the error reads of resources over the size
+// limit are answered with. A negative size is unknown.
+func resourceTooLargeError(uri string, size, limit int64) error {
+	data := map[string]interface{}{"reason": protocol.ReasonResourceTooLarge, "maxReadSize": limit}
+	message := fmt.Sprintf("resource %s is more than the %d bytes that can be read at once", uri, limit)
+	if size >= 0 {
+		data["size"] = size
+		message = fmt.Sprintf("resource %s is %d bytes, more than the %d that can be read at once", uri, size, limit)
+	}
+	return &RPCError{Code: protocol.InvalidParams, Message: message, Data: data}
+}
+
+// WithMaxReadSize sets the most bytes a single read returns. Reads of
+// larger files must ask for a range.
+func WithMaxReadSize(n int64) FileContentOption {
+	return func(f *fileContent) {
+		if n > 0 {
+			f.maxRead = n
+		}
+	}
+}
+
+// WithFileMimeType sets the MIME type of the file instead of inferring it
+// from the file name's extension or, failing that, its content.
+func WithFileMimeType(mimeType string) FileContentOption {
+	return func(f *fileContent) {
+		f.mimeType = mimeType
+	}
+}
+
+// fileContent serves a file as resource contents.
+type fileContent struct {
+	path     string
+	mimeType string
+	maxRead  int64 // zero for the server's limit
+	binary   bool
+}
+
+// resourceContents is a resources/read result that is already in protocol
+// shape and is returned without reformatting.
+type resourceContents map[string]interface{}
+
+// binaryContents is a resources/read result of blobs that are sent as they
+// are, never decoded as text.
+type binaryContents map[string]interface{}
+
+// WithFileContent returns a resource handler that serves the file at path,
+// for registering with Resource:
+//
+//	srv.Resource("file:///artifacts/build.tar.gz", "Latest build",
+//	    server.WithFileContent("/var/builds/latest.tar.gz"))
+//
+// The file is opened on every read, so it may change between reads, and is
+// copied into the response in fixed-size chunks rather than read whole, so
+// memory use per read is bounded by the response itself and the server's
+// file cache (see WithFileCacheSize). Text types are
+// returned as text and other types as base64 blob contents.
+//
+// A read returns at most WithMaxReadSize bytes (the server's
+// WithMaxResourceSize, or DefaultMaxReadSize, by default). Larger files are read in ranges, by adding offset and length to
+// the resources/read params:
+//
+//	{"uri": "file:///artifacts/build.tar.gz", "offset": 8388608, "length": 8388608}
+//
+// The result's _meta holds the file's size and the offset and length
+// actually returned; ranges of text files end on a character boundary, so
+// the next range starts at offset+length. Reading a file over the limit
+// without a range fails with an Invalid params error giving its size.
+//
+// Files are read with positioned reads rather than memory-mapped: a mapped
+// file that is truncated while being read crashes the process, and served
+// files such as logs and build outputs are routinely rewritten in place.
+func WithFileContent(path string, options ...FileContentOption) ResourceHandler {
+	f := &fileContent{path: path}
+	for _, option := range options {
+		option(f)
+	}
+	if f.mimeType == "" {
+		f.mimeType = mime.TypeByExtension(filepath.Ext(path))
+	}
+	return f.read
+}
+
+// WithBinaryContent returns a resource handler that serves the file at path
+// as base64 blob contents, whatever its type, for files that must reach
+// clients byte for byte:
+//
+//	srv.Resource("file:///firmware/latest", "Latest firmware image",
+//	    server.WithBinaryContent("/var/firmware/latest.img"))
+//
+// It reads like WithFileContent, in ranges past the size limit, except that
+// the file is never decoded as text: its MIME type is that of its
+// extension, or else the type sniffed from its content if that is a binary
+// type, or else application/octet-stream.
+func WithBinaryContent(path string, options ...FileContentOption) ResourceHandler {
+	f := &fileContent{path: path, binary: true}
+	for _, option := range options {
+		option(f)
+	}
+	if f.mimeType == "" {
+		f.mimeType = mime.TypeByExtension(filepath.Ext(path))
+	}
+	return f.read
+}
+
+// readRange is the optional range of a resources/read request.
+type readRange struct {
+	URI    string `json:"uri"`
+	Offset *int64 `json:"offset"`
+	Length *int64 `json:"length"`
+}
+
+func (f *fileContent) read(ctx *Context, args interface{}) (interface{}, error) {
+	var params readRange
+	if ctx.Request != nil && ctx.Request.Params != nil {
+		if err := json.Unmarshal(ctx.Request.Params, &params); err != nil {
+			return nil, &RPCError{Code: -32602, Message: fmt.Sprintf("invalid read range: %v", err)}
+		}
+	}
+
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", f.path)
+	}
+	size := info.Size()
+
+	maxRead := f.maxRead
+	if maxRead <= 0 {
+		maxRead = ctx.server.resourceSizeLimit()
+	}
+	offset, length := int64(0), size
+	ranged := params.Offset != nil || params.Length != nil
+	if ranged {
+		if params.Offset != nil {
+			offset = *params.Offset
+		}
+		if offset < 0 || offset > size {
+			return nil, &RPCError{
+				Code:    -32602,
+				Message: fmt.Sprintf("offset %d is outside the resource, which is %d bytes", offset, size),
+				Data:    map[string]interface{}{"size": size},
+			}
+		}
+		length = size - offset
+		if params.Length != nil {
+			if *params.Length < 0 {
+				return nil, &RPCError{Code: -32602, Message: "length must not be negative"}
+			}
+			length = min(*params.Length, length)
+		}
+		length = min(length, maxRead)
+	} else if size > maxRead {
+		return nil, &RPCError{
+			Code: -32602,
+			Message: fmt.Sprintf("resource is %d bytes, more than the %d that can be read at once; read it in ranges with offset and length",
+				size, maxRead),
+			Data: map[string]interface{}{"reason": protocol.ReasonResourceTooLarge, "size": size, "maxReadSize": maxRead},
+		}
+	}
+
+	mimeType := f.mimeType
+	if mimeType == "" {
+		mimeType, err = sniffMimeType(file)
+		if err != nil {
+			return nil, err
+		}
+		if f.binary && isTextMimeType(mimeType) {
+			mimeType = "application/octet-stream"
+		}
+	}
+
+	var cache *fileCache
+	if ctx.server != nil {
+		cache = &ctx.server.fileCache
+	}
+	src, err := cache.reader(file, info, offset, length)
+	if err != nil {
+		return nil, err
+	}
+
+	item := map[string]interface{}{"uri": params.URI, "mimeType": mimeType}
+	if isTextMimeType(mimeType) && !f.binary {
+		text, err := readText(src, length, offset+length < size)
+		if err != nil {
+			return nil, err
+		}
+		item["text"] = text
+		length = int64(len(text))
+	} else {
+		blob, err := readBase64(src, length)
+		if err != nil {
+			return nil, err
+		}
+		item["blob"] = blob
+	}
+
+	result := map[string]interface{}{
+		"contents": []interface{}{item},
+		"_meta":    map[string]interface{}{"size": size, "offset": offset, "length": length},
+	}
+	if f.binary {
+		return binaryContents(result), nil
+	}
+	return resourceContents(result), nil
+}
+
+// sniffMimeType detects the MIME type of a file whose name doesn't reveal it
+// from its first bytes.
+func sniffMimeType(file *os.File) (string, error) {
+	head := make([]byte, 512)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return sniffContent(head[:n]), nil
+}
+
+// copyRange copies the length bytes of a range from src to w in pooled
+// chunks.
+func copyRange(w io.Writer, src io.Reader, length int64) error {
+	buf := fileChunkPool.Get().(*[]byte)
+	defer fileChunkPool.Put(buf)
+
+	n, err := io.CopyBuffer(w, io.LimitReader(src, length), *buf)
+	if err != nil {
+		return err
+	}
+	if n != length {
+		return fmt.Errorf("file changed while being read: got %d of %d bytes", n, length)
+	}
+	return nil
+}
+
+// readText reads a range of a text file. When more of the file follows, a
+// character split by the end of the range is left for the next range.
+func readText(src io.Reader, length int64, more bool) (string, error) {
+	var b strings.Builder
+	b.Grow(int(length))
+	if err := copyRange(&b, src, length); err != nil {
+		return "", err
+	}
+	text := b.String()
+	if trimmed := trimPartialRune(text); more && trimmed != "" {
+		text = trimmed
+	}
+	return text, nil
+}
+
+// trimPartialRune removes an incomplete UTF-8 sequence from the end of s.
+func trimPartialRune(s string) string {
+	for i := len(s) - 1; i >= 0 && i > len(s)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(s[i]) {
+			if !utf8.FullRuneInString(s[i:]) {
+				return s[:i]
+			}
+			break
+		}
+	}
+	return s
+}
+
+// readBase64 reads a range of a file as base64, encoding as it reads so the
+// raw bytes are never held alongside their encoding.
+func readBase64(src io.Reader, length int64) (string, error) {
+	var b strings.Builder
+	b.Grow(base64.StdEncoding.EncodedLen(int(length)))
+	encoder := base64.NewEncoder(base64.StdEncoding, &b)
+	if err := copyRange(encoder, src, length); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
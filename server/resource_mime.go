@@ -0,0 +1,211 @@
+package server
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// sniffLength is how many leading bytes content is sniffed from, as in
+// http.DetectContentType.
+const sniffLength = 512
+
+// normalizeResourceResult gives the items of a formatted resources/read
+// result an accurate mimeType and their text in UTF-8, so every read
+// reports content the same way whatever its handler returned:
+//
+//   - Items without a mimeType get the type of the URI's extension or,
+//     failing that, the type sniffed from their content. Text that parses
+//     as a JSON object or array is application/json.
+//   - Text that isn't valid UTF-8, and blobs of a text type, are decoded
+//     from the charset named by the mimeType's charset parameter or else
+//     detected. The charset parameter is dropped, as the text is UTF-8 once
+//     sent.
+//   - Resource blobs of a text type become text contents. Blobs declared
+//     application/octet-stream keep that type unless their content shows
+//     a more precise binary type.
+func normalizeResourceResult(uri string, result interface{}) interface{} {
+	response, ok := result.(map[string]interface{})
+	if !ok {
+		return result
+	}
+	for _, item := range contentItems(response["content"]) {
+		normalizeContentItem(uri, item, false)
+	}
+	for _, item := range contentItems(response["contents"]) {
+		itemURI := uri
+		if u, ok := item["uri"].(string); ok && u != "" {
+			itemURI = u
+		}
+		normalizeContentItem(itemURI, item, true)
+		for _, inner := range contentItems(item["content"]) {
+			normalizeContentItem(itemURI, inner, false)
+		}
+	}
+	return result
+}
+
+// normalizeContentItem normalizes one content item of a resource read.
+// Resource contents items, as opposed to content items of the older
+// result shape, may turn from blob into text.
+func normalizeContentItem(uri string, item map[string]interface{}, resource bool) {
+	if itemType, ok := item["type"].(string); ok && itemType != "text" && itemType != "blob" && itemType != "resource" {
+		return
+	}
+	mimeType, _ := item["mimeType"].(string)
+
+	if text, ok := item["text"].(string); ok {
+		// Handlers' strings are UTF-8 unless read from legacy content
+		if !utf8.ValidString(text) {
+			text = decodeText([]byte(text), mimeType)
+			item["text"] = text
+		}
+		if mimeType == "" {
+			mimeType = detectMimeType(uri, []byte(text))
+		}
+		item["mimeType"] = withoutCharset(mimeType)
+		return
+	}
+
+	encoded, ok := item["blob"].(string)
+	if !ok {
+		return
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return
+	}
+	if mimeType == "" {
+		mimeType = detectMimeType(uri, data)
+	} else if baseMimeType(mimeType) == "application/octet-stream" {
+		// Content declared binary may be typed more precisely, but never
+		// turned into text by bytes that happen to look like it
+		if detected := detectMimeType(uri, data); !isTextMimeType(detected) {
+			mimeType = detected
+		}
+	}
+	if resource && isTextMimeType(mimeType) {
+		delete(item, "blob")
+		item["text"] = decodeText(data, mimeType)
+		mimeType = withoutCharset(mimeType)
+	}
+	item["mimeType"] = mimeType
+}
+
+// detectMimeType returns the MIME type of content found at uri: the type
+// of its extension, or else the type sniffed from its first bytes.
+func detectMimeType(uri string, data []byte) string {
+	if mimeType := mimeTypeByName(uri); mimeType != "" {
+		return mimeType
+	}
+	return sniffContent(data)
+}
+
+// mimeTypeByName returns the MIME type of a file name, path or URI's
+// extension, or "" for names without a known extension.
+func mimeTypeByName(name string) string {
+	if u, err := url.Parse(name); err == nil && u.Path != "" {
+		name = u.Path
+	}
+	ext := path.Ext(name)
+	if ext == "" {
+		return ""
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// sniffContent returns the MIME type of content from its first bytes.
+func sniffContent(data []byte) string {
+	head := data
+	if len(head) > sniffLength {
+		head = head[:sniffLength]
+	}
+	mimeType := http.DetectContentType(head)
+	if baseMimeType(mimeType) != "text/plain" {
+		return mimeType
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return "application/json"
+	}
+	// The sniffer assumes UTF-8 for text without a byte order mark
+	if _, params, err := mime.ParseMediaType(mimeType); err == nil && params["charset"] == "utf-8" && !utf8.Valid(data) {
+		return "text/plain"
+	}
+	return mimeType
+}
+
+// decodeText returns text in the charset of mimeType as UTF-8. Without a
+// charset, byte order marks are honored and text that isn't valid UTF-8 is
+// read as Windows-1252, the usual legacy encoding of Western text, which
+// maps every byte.
+func decodeText(data []byte, mimeType string) string {
+	var enc encoding.Encoding
+	if _, params, err := mime.ParseMediaType(mimeType); err == nil && params["charset"] != "" {
+		if e, err := htmlindex.Get(params["charset"]); err == nil {
+			enc = e
+		}
+	}
+	if enc == nil || enc == unicode.UTF8 {
+		switch {
+		case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+			return string(data[3:])
+		case bytes.HasPrefix(data, []byte{0xFE, 0xFF}), bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+			enc = unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
+		case utf8.Valid(data):
+			return string(data)
+		default:
+			enc = charmap.Windows1252
+		}
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return strings.ToValidUTF8(string(data), "�")
+	}
+	return string(decoded)
+}
+
+// withoutCharset removes the charset parameter of a MIME type.
+func withoutCharset(mimeType string) string {
+	mediaType, params, err := mime.ParseMediaType(mimeType)
+	if err != nil || params["charset"] == "" {
+		return mimeType
+	}
+	delete(params, "charset")
+	return mime.FormatMediaType(mediaType, params)
+}
+
+// baseMimeType returns a MIME type without its parameters.
+func baseMimeType(mimeType string) string {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return mimeType
+	}
+	return mediaType
+}
+
+// isTextMimeType reports whether content of a MIME type is text.
+func isTextMimeType(mimeType string) bool {
+	mediaType := baseMimeType(mimeType)
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript",
+		"application/yaml", "application/x-yaml", "application/toml", "image/svg+xml":
+		return true
+	}
+	return strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
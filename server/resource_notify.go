@@ -0,0 +1,179 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/localrivet/gomcp/transport"
+)
+
+// DefaultResourceUpdateWindow is the default window within which repeated
+// updates for the same resource URI are coalesced into a single notification.
+const DefaultResourceUpdateWindow = 250 * time.Millisecond
+
+// resourceUpdateCoalescer collapses bursts of resource updates into a single
+// notifications/resources/updated message per URI and window.
+type resourceUpdateCoalescer struct {
+	mu      sync.Mutex
+	pending map[string]*time.Timer
+	send    func(uri string)
+}
+
+// newResourceUpdateCoalescer creates a coalescer that calls send once per URI
+// at the end of each window in which at least one update was recorded.
+func newResourceUpdateCoalescer(send func(uri string)) *resourceUpdateCoalescer {
+	return &resourceUpdateCoalescer{
+		pending: make(map[string]*time.Timer),
+		send:    send,
+	}
+}
+
+// record registers an update for the URI and reports whether it was merged
+// into an already pending notification. The window is passed per update so
+// it can widen while memory is under pressure.
+func (c *resourceUpdateCoalescer) record(uri string, window time.Duration) bool {
+	if window <= 0 {
+		c.send(uri)
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.pending[uri]; exists {
+		return true
+	}
+
+	c.pending[uri] = time.AfterFunc(window, func() {
+		c.mu.Lock()
+		delete(c.pending, uri)
+		c.mu.Unlock()
+		c.send(uri)
+	})
+	return false
+}
+
+// WithResourceUpdateWindow sets the window used to coalesce resource update notifications.
+//
+// Updates for the same URI that arrive within the window are merged into a single
+// notifications/resources/updated message sent at the end of the window. A window
+// of zero disables coalescing and sends every update immediately, except
+// while WithMemoryPressure reports pressure.
+//
+// Example:
+//
+//	srv := server.NewServer("files",
+//	    server.WithResourceUpdateWindow(time.Second),
+//	)
+func WithResourceUpdateWindow(window time.Duration) Option {
+	return func(s *serverImpl) {
+		s.resourceUpdateWindow = window
+	}
+}
+
+// WithResourceSubscriptions enables or disables resources/subscribe support.
+//
+// Subscriptions are enabled by default. When disabled, the server no longer
+// advertises the resources.subscribe capability and answers subscribe and
+// unsubscribe requests with a method not found error naming the capability.
+func WithResourceSubscriptions(enabled bool) Option {
+	return func(s *serverImpl) {
+		s.resourceSubscriptions = enabled
+	}
+}
+
+// WithMaxSubscriptions limits the number of resources a single session may subscribe to.
+//
+// Subscribe requests beyond the limit fail until the session unsubscribes from
+// another resource. A limit of zero, the default, allows unlimited subscriptions.
+func WithMaxSubscriptions(limit int) Option {
+	return func(s *serverImpl) {
+		s.maxSubscriptions = limit
+	}
+}
+
+// NotifyResourceUpdated signals that the resource identified by uri has changed.
+//
+// Only sessions that subscribed to the URI through resources/subscribe are
+// notified; if no session is subscribed the update is dropped. Rapid updates are
+// coalesced per URI according to the configured resource update window.
+//
+// Example:
+//
+//	// A file watcher reporting a change
+//	if err := srv.NotifyResourceUpdated("file:///logs/app.log"); err != nil {
+//	    log.Printf("notify failed: %v", err)
+//	}
+func (s *serverImpl) NotifyResourceUpdated(uri string) error {
+	if uri == "" {
+		return errors.New("resource uri cannot be empty")
+	}
+
+	if len(s.sessionManager.SubscribedSessions(uri)) == 0 {
+		s.logger.Debug("skipping resource update, no subscribers", "uri", uri)
+		return nil
+	}
+
+	s.mu.Lock()
+	if s.resourceUpdates == nil {
+		s.resourceUpdates = newResourceUpdateCoalescer(s.sendResourceUpdated)
+	}
+	coalescer := s.resourceUpdates
+	s.mu.Unlock()
+
+	if coalescer.record(uri, s.resourceUpdateWindowNow()) {
+		s.logger.Debug("coalesced resource update", "uri", uri)
+	}
+	return nil
+}
+
+// sendResourceUpdated emits a notifications/resources/updated message to
+// the sessions still subscribed to uri. On transports that can address a
+// single client, sessions bound to a connection get their own copy and
+// nobody else hears of it; otherwise the notification is broadcast, which
+// for single-client transports such as stdio reaches just the subscriber.
+func (s *serverImpl) sendResourceUpdated(uri string) {
+	subscribers := s.sessionManager.SubscribedSessions(uri)
+	if len(subscribers) == 0 {
+		return
+	}
+
+	params := map[string]interface{}{"uri": uri}
+	connections, unbound := s.sessionManager.subscriberConnections(uri)
+	sender, addressable := s.transport.(transport.SessionSender)
+	if !addressable {
+		unbound = true
+	} else {
+		for _, connectionID := range connections {
+			if err := s.sendNotificationTo(sender, connectionID, "notifications/resources/updated", params); err != nil {
+				s.logger.Debug("failed to deliver resource update", "uri", uri, "connectionID", connectionID, "error", err)
+			}
+		}
+	}
+	if unbound {
+		s.sendNotification("notifications/resources/updated", params)
+	}
+	s.logger.Debug("sent resource updated notification", "uri", uri, "subscribers", len(subscribers))
+}
+
+// parseResourceURIParam extracts the uri parameter shared by the
+// resources/subscribe and resources/unsubscribe requests.
+func parseResourceURIParam(ctx *Context) (string, error) {
+	if ctx.Request.Params == nil {
+		return "", NewInvalidParametersError("missing params")
+	}
+
+	var params struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(ctx.Request.Params, &params); err != nil {
+		return "", NewInvalidParametersError(fmt.Sprintf("invalid params: %v", err))
+	}
+	if params.URI == "" {
+		return "", NewInvalidParametersError("missing or empty uri")
+	}
+	return params.URI, nil
+}
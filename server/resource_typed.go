@@ -0,0 +1,211 @@
+package server
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/localrivet/wilduri"
+)
+
+// TypedResourceHandler handles reads of a resource whose URI template
+// variables are decoded into a Params struct.
+type TypedResourceHandler[Params, Result any] func(ctx *Context, params Params) (Result, error)
+
+// AddResource registers a resource whose URI template variables are bound to
+// the fields of Params. A field binds to the variable named by its `uri` tag,
+// or else by its `json` tag or field name, compared case-insensitively.
+// Fields may be strings, string slices (for exploded or wildcard variables),
+// integers, floats or booleans.
+//
+// The template and Params are checked against each other when the resource
+// is registered: every template variable but FormatParam must have a field
+// of a supported type, every `uri` tag must name a template variable, and no
+// variable may be bound to two fields. A mismatch is logged and the resource
+// is not registered.
+//
+// Example:
+//
+//	type RepoParams struct {
+//	    Owner string `uri:"owner"`
+//	    Repo  string `uri:"repo"`
+//	}
+//
+//	server.AddResource(srv, "repos://{owner}/{repo}", "A repository",
+//	    func(ctx *server.Context, p RepoParams) (Repository, error) {
+//	        return lookupRepo(p.Owner, p.Repo)
+//	    })
+func AddResource[Params, Result any](srv Server, pattern, description string, handler TypedResourceHandler[Params, Result]) Server {
+	s := srv.GetServer()
+
+	template, err := wilduri.New(pattern)
+	if err != nil {
+		s.logger.Error("failed to parse path template", "path", pattern, "error", err)
+		return srv
+	}
+
+	bindings, err := bindURIParams(reflect.TypeOf((*Params)(nil)).Elem(), template.Varnames())
+	if err != nil {
+		s.logger.Error("resource parameters do not match template", "path", pattern, "error", err)
+		return srv
+	}
+
+	return srv.Resource(pattern, description, ResourceHandler(func(ctx *Context, args interface{}) (interface{}, error) {
+		values, _ := args.(map[string]interface{})
+
+		var params Params
+		target := reflect.ValueOf(&params).Elem()
+		for _, b := range bindings {
+			value, ok := values[b.variable]
+			if !ok {
+				continue
+			}
+			if err := setURIParam(target.Field(b.field), value); err != nil {
+				return nil, NewInvalidParametersError(fmt.Sprintf("invalid value for %s: %v", b.variable, err))
+			}
+		}
+
+		return handler(ctx, params)
+	}))
+}
+
+// uriBinding maps a template variable to a struct field index.
+type uriBinding struct {
+	variable string
+	field    int
+}
+
+// bindURIParams matches template variables to the fields of a params struct.
+func bindURIParams(paramsType reflect.Type, variables []string) ([]uriBinding, error) {
+	if paramsType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("params type %s must be a struct", paramsType)
+	}
+
+	declared := make(map[string]bool, len(variables))
+	for _, v := range variables {
+		declared[v] = true
+	}
+
+	var bindings []uriBinding
+	bound := make(map[string]bool)
+	for i := 0; i < paramsType.NumField(); i++ {
+		field := paramsType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if tag, ok := field.Tag.Lookup("uri"); ok {
+			name, _, _ := strings.Cut(tag, ",")
+			if name == "-" {
+				continue
+			}
+			if !declared[name] {
+				return nil, fmt.Errorf("field %s is tagged uri:%q but the template has no such variable", field.Name, name)
+			}
+			if bound[name] {
+				return nil, fmt.Errorf("field %s is tagged uri:%q but the variable is already bound to another field", field.Name, name)
+			}
+			if !supportedURIParam(field.Type) {
+				return nil, fmt.Errorf("field %s has unsupported type %s for template variable %q", field.Name, field.Type, name)
+			}
+			bindings = append(bindings, uriBinding{variable: name, field: i})
+			bound[name] = true
+			continue
+		}
+
+		name := field.Name
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && tag != "-" {
+			name = tag
+		}
+		for _, v := range variables {
+			if strings.EqualFold(v, name) && !bound[v] {
+				if !supportedURIParam(field.Type) {
+					return nil, fmt.Errorf("field %s has unsupported type %s for template variable %q", field.Name, field.Type, v)
+				}
+				bindings = append(bindings, uriBinding{variable: v, field: i})
+				bound[v] = true
+				break
+			}
+		}
+	}
+
+	for _, v := range variables {
+		// The format parameter is for the server, if no field takes it
+		if !bound[v] && v != FormatParam {
+			return nil, fmt.Errorf("template variable %q has no matching field in %s", v, paramsType)
+		}
+	}
+	return bindings, nil
+}
+
+// supportedURIParam reports whether setURIParam can set a field of type t.
+func supportedURIParam(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// setURIParam converts a matched template value into a struct field.
+func setURIParam(field reflect.Value, value interface{}) error {
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String {
+		switch v := value.(type) {
+		case []string:
+			field.Set(reflect.ValueOf(v).Convert(field.Type()))
+		case string:
+			field.Set(reflect.ValueOf([]string{v}).Convert(field.Type()))
+		default:
+			return fmt.Errorf("unsupported value %T", value)
+		}
+		return nil
+	}
+
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case []string:
+		text = strings.Join(v, "/")
+	default:
+		text = fmt.Sprint(v)
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(text)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(text, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of WithURLContent.
+const (
+	DefaultURLTimeout      = 30 * time.Second
+	DefaultURLMaxRedirects = 5
+)
+
+// URLContentOption configures WithURLContent.
+type URLContentOption func(*urlContent)
+
+// WithURLTimeout bounds a fetch, including reading the body,
+// DefaultURLTimeout by default. Reads canceled by the client stop sooner.
+func WithURLTimeout(timeout time.Duration) URLContentOption {
+	return func(u *urlContent) {
+		if timeout > 0 {
+			u.timeout = timeout
+		}
+	}
+}
+
+// WithURLHeader adds a header to the requests, such as an API key. It may
+// be repeated.
+func WithURLHeader(key, value string) URLContentOption {
+	return func(u *urlContent) {
+		u.header.Add(key, value)
+	}
+}
+
+// WithURLAccept sets the MIME types the server is asked for, most
+// preferred first, as an Accept header, so a URL serving several
+// representations returns the one the resource should have.
+func WithURLAccept(mimeTypes ...string) URLContentOption {
+	return func(u *urlContent) {
+		accept := make([]string, len(mimeTypes))
+		for i, mimeType := range mimeTypes {
+			accept[i] = mimeType
+			if i > 0 {
+				// Rank the types in order, as servers ignore list order
+				accept[i] += fmt.Sprintf(";q=%.1f", max(0.1, 1-float64(i)*0.1))
+			}
+		}
+		u.header.Set("Accept", strings.Join(accept, ", "))
+	}
+}
+
+// WithURLMaxRedirects limits the redirects a fetch follows,
+// DefaultURLMaxRedirects by default. Zero refuses redirects.
+func WithURLMaxRedirects(n int) URLContentOption {
+	return func(u *urlContent) {
+		u.maxRedirects = max(0, n)
+	}
+}
+
+// WithURLMaxSize limits the bytes of a response, DefaultMaxReadSize by
+// default. Reads of larger responses fail.
+func WithURLMaxSize(n int64) URLContentOption {
+	return func(u *urlContent) {
+		if n > 0 {
+			u.maxSize = n
+		}
+	}
+}
+
+// WithURLMaxAge serves a fetched response for maxAge without contacting
+// the server, whatever its Cache-Control says. Later reads revalidate it.
+func WithURLMaxAge(maxAge time.Duration) URLContentOption {
+	return func(u *urlContent) {
+		u.maxAge = &maxAge
+	}
+}
+
+// WithURLNoCache fetches the URL in full on every read, without keeping
+// responses or making conditional requests.
+func WithURLNoCache() URLContentOption {
+	return func(u *urlContent) {
+		u.noCache = true
+	}
+}
+
+// urlContent serves the body of a URL as resource contents.
+type urlContent struct {
+	url          string
+	header       http.Header
+	timeout      time.Duration
+	maxRedirects int
+	maxSize      int64
+	maxAge       *time.Duration
+	noCache      bool
+
+	mu     sync.Mutex
+	cached *cachedURLResponse
+}
+
+// cachedURLResponse is the last response of a URL and when it goes stale.
+type cachedURLResponse struct {
+	body         []byte
+	mimeType     string
+	etag         string
+	lastModified string
+	expires      time.Time
+}
+
+// WithURLContent returns a resource handler that serves the body of a URL,
+// for registering with Resource:
+//
+//	srv.Resource("docs://changelog", "Project changelog",
+//	    server.WithURLContent("https://example.com/CHANGELOG.md",
+//	        server.WithURLAccept("text/markdown", "text/plain"),
+//	        server.WithURLTimeout(5*time.Second),
+//	    ))
+//
+// Fetches go through HTTPClient, so they follow the server's egress
+// policy, are retried after transient failures and end when the read is
+// canceled. The response's Content-Type becomes the contents' mimeType.
+//
+// Responses are cached as HTTP caches do: a response is served again
+// without a request while its Cache-Control max-age lasts, and afterwards
+// revalidated with If-None-Match and If-Modified-Since, so unchanged
+// content isn't downloaded again. Responses marked no-store aren't kept.
+// If revalidation fails, the cached response is served with stale set in
+// the result's _meta rather than failing the read.
+func WithURLContent(url string, options ...URLContentOption) ResourceHandler {
+	u := &urlContent{
+		url:          url,
+		header:       make(http.Header),
+		timeout:      DefaultURLTimeout,
+		maxRedirects: DefaultURLMaxRedirects,
+		maxSize:      DefaultMaxReadSize,
+	}
+	for _, option := range options {
+		option(u)
+	}
+	return u.read
+}
+
+func (u *urlContent) read(ctx *Context, args interface{}) (interface{}, error) {
+	var params struct {
+		URI string `json:"uri"`
+	}
+	if ctx.Request != nil && ctx.Request.Params != nil {
+		json.Unmarshal(ctx.Request.Params, &params)
+	}
+
+	u.mu.Lock()
+	cached := u.cached
+	u.mu.Unlock()
+	if cached != nil && time.Now().Before(cached.expires) {
+		return u.contents(params.URI, cached, false), nil
+	}
+
+	response, err := u.fetch(ctx, cached)
+	if err != nil {
+		if cached == nil {
+			return nil, err
+		}
+		ctx.Logger.Warn("serving cached resource after failed revalidation", "url", u.url, "error", err)
+		return u.contents(params.URI, cached, true), nil
+	}
+	return u.contents(params.URI, response, false), nil
+}
+
+// fetch requests the URL, conditionally if a cached response has
+// validators, and returns the current response.
+func (u *urlContent) fetch(ctx *Context, cached *cachedURLResponse) (*cachedURLResponse, error) {
+	req, err := http.NewRequestWithContext(ctx.Context(), http.MethodGet, u.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range u.header {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	if cached != nil {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
+	client := HTTPClient(ctx)
+	client.Timeout = u.timeout
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > u.maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", u.maxRedirects)
+		}
+		return nil
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", u.url, err)
+	}
+	defer resp.Body.Close()
+
+	maxAge, store := u.freshness(resp.Header)
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		refreshed := *cached
+		refreshed.expires = time.Now().Add(maxAge)
+		u.store(&refreshed, store)
+		return &refreshed, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("failed to fetch %s: %s", u.url, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, u.maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", u.url, err)
+	}
+	if int64(len(body)) > u.maxSize {
+		return nil, fmt.Errorf("response of %s is larger than %d bytes", u.url, u.maxSize)
+	}
+
+	response := &cachedURLResponse{
+		body:         body,
+		mimeType:     resp.Header.Get("Content-Type"),
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		expires:      time.Now().Add(maxAge),
+	}
+	u.store(response, store)
+	return response, nil
+}
+
+// store keeps a response for later reads, or forgets the cached one.
+func (u *urlContent) store(response *cachedURLResponse, store bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if store && !u.noCache {
+		u.cached = response
+	} else {
+		u.cached = nil
+	}
+}
+
+// freshness returns how long a response may be served without a request
+// and whether it may be kept at all.
+func (u *urlContent) freshness(header http.Header) (time.Duration, bool) {
+	maxAge, store := time.Duration(0), true
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store":
+			store = false
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && seconds > 0 {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	if u.maxAge != nil {
+		maxAge = *u.maxAge
+	}
+	return maxAge, store
+}
+
+// contents returns a response as resource contents. The body is sent as a
+// blob that the resource pipeline turns into text for text types.
+func (u *urlContent) contents(uri string, response *cachedURLResponse, stale bool) resourceContents {
+	result := resourceContents{
+		"contents": []interface{}{map[string]interface{}{
+			"uri":      uri,
+			"mimeType": response.mimeType,
+			"blob":     base64.StdEncoding.EncodeToString(response.body),
+		}},
+	}
+	if stale {
+		result["_meta"] = map[string]interface{}{"stale": true}
+	}
+	return result
+}
@@ -0,0 +1,253 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WithWatchedDirectory registers a resource for every file under root whose
+// slash-separated path relative to root matches glob, served as by
+// WithFileContent at a file:// URI of its absolute path. In glob, "**"
+// matches any number of directories, and the other segments are matched as
+// by path.Match:
+//
+//	srv := server.NewServer("docs",
+//	    server.WithWatchedDirectory("./handbook", "**/*.md"),
+//	)
+//
+// While the server runs, the directory is watched: files that appear are
+// registered and files that disappear unregistered, with
+// notifications/resources/list_changed, and changes to a file send
+// notifications/resources/updated to the sessions subscribed to it. Each
+// change also increments the file's version, which reads return in _meta
+// as "version", so clients can tell whether content they hold is current.
+func WithWatchedDirectory(root, glob string) Option {
+	return func(s *serverImpl) {
+		if glob == "" {
+			glob = "**"
+		}
+		if _, err := path.Match(glob, ""); err != nil {
+			s.logger.Error("invalid glob for watched directory", "root", root, "glob", glob, "error", err)
+			return
+		}
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			s.logger.Error("failed to resolve watched directory", "root", root, "error", err)
+			return
+		}
+
+		w := &directoryWatch{root: abs, glob: glob, files: make(map[string]*watchedFile)}
+		w.scan(s, abs)
+		s.watchedDirectories = append(s.watchedDirectories, w)
+	}
+}
+
+// directoryWatch keeps the resources of the files of a watched directory in
+// step with it.
+type directoryWatch struct {
+	root string
+	glob string
+
+	mu    sync.Mutex
+	files map[string]*watchedFile // by absolute path
+}
+
+// watchedFile is the state of a file registered as a resource.
+type watchedFile struct {
+	uri     string
+	modTime time.Time
+	size    int64
+	version int64
+}
+
+// startWatching watches the watched directories until Shutdown.
+func (s *serverImpl) startWatching() {
+	for _, w := range s.watchedDirectories {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			s.logger.Error("failed to watch directory", "root", w.root, "error", err)
+			continue
+		}
+		w.watchTree(s, watcher, w.root)
+		// Catch up on changes made before the watches were in place
+		w.scan(s, w.root)
+
+		go func() {
+			defer watcher.Close()
+			for {
+				select {
+				case <-s.stopped:
+					return
+				case event, ok := <-watcher.Events:
+					if !ok {
+						return
+					}
+					if event.Has(fsnotify.Create) {
+						if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+							w.watchTree(s, watcher, event.Name)
+						}
+					}
+					w.scan(s, event.Name)
+				case err, ok := <-watcher.Errors:
+					if !ok {
+						return
+					}
+					s.logger.Warn("error watching directory", "root", w.root, "error", err)
+				}
+			}
+		}()
+	}
+}
+
+// watchTree adds watches for dir and the directories under it, as
+// fsnotify doesn't watch subdirectories.
+func (w *directoryWatch) watchTree(s *serverImpl, watcher *fsnotify.Watcher, dir string) {
+	filepath.WalkDir(dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		if err := watcher.Add(name); err != nil {
+			s.logger.Warn("failed to watch directory", "path", name, "error", err)
+		}
+		return nil
+	})
+}
+
+// scan brings the resources of the files at or under name up to date with
+// the file system.
+func (w *directoryWatch) scan(s *serverImpl, name string) {
+	present := make(map[string]bool)
+	filepath.WalkDir(name, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		if relative, err := filepath.Rel(w.root, file); err == nil && matchGlob(w.glob, filepath.ToSlash(relative)) {
+			present[file] = true
+			w.update(s, file, info)
+		}
+		return nil
+	})
+
+	// Unregister the files at or under name that are gone
+	w.mu.Lock()
+	var removed []*watchedFile
+	for file, watched := range w.files {
+		if !present[file] && (file == name || strings.HasPrefix(file, name+string(filepath.Separator))) {
+			removed = append(removed, watched)
+			delete(w.files, file)
+		}
+	}
+	w.mu.Unlock()
+	for _, watched := range removed {
+		s.UnregisterResource(watched.uri)
+	}
+}
+
+// update registers a file that isn't yet, or else tells subscribers if it
+// changed.
+func (w *directoryWatch) update(s *serverImpl, file string, info fs.FileInfo) {
+	w.mu.Lock()
+	watched, known := w.files[file]
+	if known && watched.modTime.Equal(info.ModTime()) && watched.size == info.Size() {
+		w.mu.Unlock()
+		return
+	}
+	if !known {
+		watched = &watchedFile{uri: fileURI(file)}
+		w.files[file] = watched
+	}
+	watched.modTime, watched.size = info.ModTime(), info.Size()
+	watched.version++
+	w.mu.Unlock()
+
+	if !known {
+		relative, _ := filepath.Rel(w.root, file)
+		s.Resource(watched.uri, filepath.ToSlash(relative), w.handler(file))
+		return
+	}
+	if err := s.NotifyResourceUpdated(watched.uri); err != nil {
+		s.logger.Warn("failed to notify resource update", "uri", watched.uri, "error", err)
+	}
+}
+
+// handler returns the resource handler of a watched file, which reads it
+// as WithFileContent does and adds its version to the result's _meta.
+func (w *directoryWatch) handler(file string) ResourceHandler {
+	read := WithFileContent(file)
+	return func(ctx *Context, args interface{}) (interface{}, error) {
+		result, err := read(ctx, args)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// The watcher will unregister it shortly
+				return nil, fmt.Errorf("resource not found: %s", fileURI(file))
+			}
+			return nil, err
+		}
+		w.mu.Lock()
+		watched, ok := w.files[file]
+		var version int64
+		if ok {
+			version = watched.version
+		}
+		w.mu.Unlock()
+		if contents, isContents := result.(resourceContents); isContents && ok {
+			if meta, hasMeta := contents["_meta"].(map[string]interface{}); hasMeta {
+				meta["version"] = version
+			}
+		}
+		return result, nil
+	}
+}
+
+// fileURI returns the file:// URI of an absolute path.
+func fileURI(file string) string {
+	p := filepath.ToSlash(file)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// matchGlob reports whether a slash-separated path matches a glob whose
+// "**" segments match any number of directories.
+func matchGlob(glob, name string) bool {
+	patterns := strings.Split(glob, "/")
+	segments := strings.Split(name, "/")
+
+	var match func(p, n int) bool
+	match = func(p, n int) bool {
+		for p < len(patterns) {
+			if patterns[p] == "**" {
+				for skip := n; skip <= len(segments); skip++ {
+					if match(p+1, skip) {
+						return true
+					}
+				}
+				return false
+			}
+			if n >= len(segments) {
+				return false
+			}
+			if ok, _ := path.Match(patterns[p], segments[n]); !ok {
+				return false
+			}
+			p, n = p+1, n+1
+		}
+		return n == len(segments)
+	}
+	return match(0, 0)
+}
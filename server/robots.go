@@ -0,0 +1,155 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsRules are the rules of a robots.txt that apply to one user agent.
+type robotsRules struct {
+	rules       []robotsRule
+	crawlDelay  time.Duration
+	disallowAll bool
+}
+
+// robotsRule is an allow or disallow line of a robots.txt.
+type robotsRule struct {
+	pattern string
+	allow   bool
+}
+
+// parseRobots parses a robots.txt as RFC 9309 describes, returning the
+// rules of the groups naming agent's product token, or of the groups for
+// "*" if none does.
+func parseRobots(data []byte, agent string) *robotsRules {
+	token := strings.ToLower(agent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+	if token == "" {
+		token = "go-http-client"
+	}
+
+	var specific, wildcard robotsRules
+	var matchesAgent, matchesWildcard, foundAgent, inAgents bool
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			// Consecutive user-agent lines start one group
+			if !inAgents {
+				matchesAgent, matchesWildcard = false, false
+			}
+			inAgents = true
+			name := strings.ToLower(value)
+			if name == token {
+				matchesAgent, foundAgent = true, true
+			}
+			if name == "*" {
+				matchesWildcard = true
+			}
+			continue
+		}
+		inAgents = false
+
+		var groups []*robotsRules
+		if matchesAgent {
+			groups = append(groups, &specific)
+		}
+		if matchesWildcard {
+			groups = append(groups, &wildcard)
+		}
+		for _, group := range groups {
+			switch key {
+			case "allow", "disallow":
+				if value != "" {
+					group.rules = append(group.rules, robotsRule{pattern: normalizeRobotsPattern(value), allow: key == "allow"})
+				}
+			case "crawl-delay":
+				if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+					group.crawlDelay = min(time.Duration(seconds*float64(time.Second)), maxCrawlDelay)
+				}
+			}
+		}
+	}
+
+	if foundAgent {
+		return &specific
+	}
+	return &wildcard
+}
+
+// allows reports whether the rules allow a path. The longest matching
+// pattern decides, allow winning ties, and paths no pattern matches are
+// allowed.
+func (r *robotsRules) allows(path string) bool {
+	if r.disallowAll {
+		return false
+	}
+	allowed, longest := true, -1
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > longest || (len(rule.pattern) == longest && rule.allow) {
+			allowed, longest = rule.allow, len(rule.pattern)
+		}
+	}
+	return allowed
+}
+
+// robotsMatch reports whether a path matches a robots.txt pattern, where
+// "*" matches any characters and a final "$" the end of the path.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		j := strings.Index(rest, part)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(part):]
+	}
+	return !anchored || rest == ""
+}
+
+// normalizeRobotsPattern percent-encodes a pattern as paths are, so that
+// patterns written with raw characters match.
+func normalizeRobotsPattern(pattern string) string {
+	if u, err := url.Parse(pattern); err == nil && u.RawQuery == "" && !strings.Contains(pattern, "?") {
+		return strings.ReplaceAll(u.EscapedPath(), "%2A", "*")
+	}
+	return pattern
+}
+
+// robotsPath returns the path and query of a URL as robots.txt patterns
+// match them.
+func robotsPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return path
+}
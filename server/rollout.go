@@ -0,0 +1,171 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"reflect"
+	"runtime/debug"
+
+	"github.com/localrivet/gomcp/webhook"
+)
+
+// ToolRollout sets how calls to a tool are split between its current
+// implementation and a candidate version registered with WithToolVersion.
+type ToolRollout struct {
+	// Percent is the share of calls, from 0 to 100, served by the candidate.
+	Percent int
+
+	// Sticky pins each session to one version for all its calls, chosen by
+	// a hash of the session ID, so a client never sees the tool change
+	// behavior mid-conversation. Otherwise each call is assigned at random.
+	Sticky bool
+
+	// Shadow serves every call with the current version and runs the
+	// candidate alongside it with the same arguments, logging calls whose
+	// results differ. Percent and Sticky are ignored.
+	Shadow bool
+}
+
+// toolCandidate is a version of a tool being rolled out.
+type toolCandidate struct {
+	version string
+	handler ToolHandler
+	rollout ToolRollout
+}
+
+// WithToolVersion registers a candidate implementation of a tool, named by
+// version, and sets how calls are split between it and the current one.
+// The candidate takes the tool's arguments and keeps its name, description
+// and schema. Calling it again replaces the candidate, for example to raise
+// its share as confidence grows.
+// The function returns the server instance to allow for method chaining.
+func (s *serverImpl) WithToolVersion(toolName, version string, handler interface{}, rollout ToolRollout) Server {
+	toolHandler, ok := convertToToolHandler(handler)
+	if !ok {
+		s.logger.Error("invalid tool handler type", "name", toolName, "version", version)
+		return s
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tool, exists := s.tools[toolName]
+	if !exists {
+		s.logger.Error("tool not found for version", "name", toolName)
+		return s
+	}
+	tool.candidate = &toolCandidate{version: version, handler: toolHandler, rollout: rollout}
+	return s
+}
+
+// PromoteToolVersion makes a tool's candidate version its current
+// implementation, serving every call, and ends the rollout.
+// The function returns the server instance to allow for method chaining.
+func (s *serverImpl) PromoteToolVersion(toolName string) Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tool, exists := s.tools[toolName]
+	if !exists {
+		s.logger.Error("tool not found for promotion", "name", toolName)
+		return s
+	}
+	if tool.candidate == nil {
+		s.logger.Error("tool has no candidate version to promote", "name", toolName)
+		return s
+	}
+	s.logger.Info("promoted tool version", "name", toolName, "from", tool.versionName(), "to", tool.candidate.version)
+	tool.Handler = tool.candidate.handler
+	tool.version = tool.candidate.version
+	tool.candidate = nil
+	return s
+}
+
+// versionName names the tool's current implementation in logs.
+func (t *Tool) versionName() string {
+	if t.version == "" {
+		return "current"
+	}
+	return t.version
+}
+
+// routeToolCall picks the implementation serving a call to tool, and the
+// candidate to shadow it with, if any.
+func (s *serverImpl) routeToolCall(ctx *Context, tool *Tool) (ToolHandler, *toolCandidate) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	candidate := tool.candidate
+	switch {
+	case candidate == nil:
+		return tool.Handler, nil
+	case candidate.rollout.Shadow:
+		return tool.Handler, candidate
+	case rolloutBucket(ctx, tool.Name, candidate.rollout.Sticky) < candidate.rollout.Percent:
+		return candidate.handler, nil
+	}
+	return tool.Handler, nil
+}
+
+// rolloutBucket places a call in one of 100 buckets, fixed per session and
+// tool when sticky.
+func rolloutBucket(ctx *Context, toolName string, sticky bool) int {
+	if !sticky {
+		return rand.IntN(100)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(ctx.sessionID()))
+	h.Write([]byte{0})
+	h.Write([]byte(toolName))
+	return int(h.Sum32() % 100)
+}
+
+// shadowToolCall runs the candidate with the arguments of a call the
+// current version served, and reports whether their outcomes differ. It
+// runs after the response is sent, so the candidate never delays or affects
+// the caller.
+func (s *serverImpl) shadowToolCall(ctx *Context, tool *Tool, candidate *toolCandidate, args interface{}, result interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.logger.Error("shadow tool version panicked", "tool", tool.Name, "version", candidate.version,
+				"panic", recovered, "stack", string(debug.Stack()))
+		}
+	}()
+
+	shadowResult, shadowErr := candidate.handler(ctx, args)
+	current, shadow := toolOutcome(result, err), toolOutcome(shadowResult, shadowErr)
+	if reflect.DeepEqual(current, shadow) {
+		return
+	}
+
+	s.logger.Warn("tool versions diverged", "tool", tool.Name,
+		"current", tool.versionName(), "candidate", candidate.version,
+		"currentResult", current, "candidateResult", shadow)
+	s.emitEvent(webhook.EventToolDivergence, map[string]interface{}{
+		"tool":            tool.Name,
+		"sessionID":       string(ctx.sessionID()),
+		"current":         tool.versionName(),
+		"candidate":       candidate.version,
+		"currentResult":   current,
+		"candidateResult": shadow,
+	})
+}
+
+// toolOutcome normalizes a handler's result through JSON, as the client
+// would see it, or describes its error.
+func toolOutcome(result interface{}, err error) interface{} {
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	data, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		return fmt.Sprint(result)
+	}
+	var normalized interface{}
+	if json.Unmarshal(data, &normalized) != nil {
+		return string(data)
+	}
+	return normalized
+}
@@ -1,9 +1,20 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/localrivet/gomcp/transport"
 )
 
+// clientRequestTimeout bounds how long the server waits for the client to
+// answer a request it sent, such as roots/list.
+const clientRequestTimeout = 30 * time.Second
+
 // Root sets the allowed root paths for the server.
 // Root paths define the file system boundaries that the server is allowed to access,
 // providing a security boundary for file operations. This method can be called
@@ -90,3 +101,166 @@ func (s *serverImpl) IsPathInRoots(path string) bool {
 
 	return false
 }
+
+// ClientRoot is a root the client exposes to the server, such as a
+// project directory the user opened.
+type ClientRoot struct {
+	// URI locates the root, e.g. "file:///home/user/project".
+	URI string `json:"uri"`
+
+	// Name is a human-readable label for the root, if the client gave one.
+	Name string `json:"name,omitempty"`
+}
+
+// rootsCache holds the roots each session's client last listed, until it
+// announces a change.
+type rootsCache struct {
+	mu    sync.Mutex
+	roots map[SessionID][]ClientRoot
+}
+
+func (rc *rootsCache) get(sessionID SessionID) ([]ClientRoot, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	roots, ok := rc.roots[sessionID]
+	return roots, ok
+}
+
+func (rc *rootsCache) set(sessionID SessionID, roots []ClientRoot) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.roots == nil {
+		rc.roots = make(map[SessionID][]ClientRoot)
+	}
+	rc.roots[sessionID] = roots
+}
+
+func (rc *rootsCache) invalidate(sessionID SessionID) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	delete(rc.roots, sessionID)
+}
+
+// ListRoots asks the request's client for the roots it exposes with a
+// roots/list request. The result is cached for the session until the
+// client sends notifications/roots/list_changed, so handlers can call it on
+// every request. The client must have declared the roots capability.
+//
+// Example:
+//
+//	roots, err := ctx.ListRoots()
+//	if err != nil {
+//	    return nil, err
+//	}
+//	for _, root := range roots {
+//	    index(root.URI)
+//	}
+func (c *Context) ListRoots() ([]ClientRoot, error) {
+	if c.server == nil {
+		return nil, fmt.Errorf("server not available in context")
+	}
+	return c.server.listClientRoots(c.Context(), c.sessionID())
+}
+
+// listClientRoots returns the roots of a session's client, from the cache
+// or with a roots/list request.
+func (s *serverImpl) listClientRoots(ctx context.Context, sessionID SessionID) ([]ClientRoot, error) {
+	if roots, ok := s.clientRoots.get(sessionID); ok {
+		return append([]ClientRoot(nil), roots...), nil
+	}
+	if err := s.requireClientCapability(sessionID, "roots/list", "roots",
+		"enable it on the client with client.WithRootsCapability(true, true)"); err != nil {
+		return nil, err
+	}
+
+	result, err := s.requestClient(ctx, sessionID, "roots/list", nil)
+	if err != nil {
+		return nil, err
+	}
+	var listed struct {
+		Roots []ClientRoot `json:"roots"`
+	}
+	if err := json.Unmarshal(result, &listed); err != nil {
+		return nil, fmt.Errorf("invalid roots/list result: %w", err)
+	}
+	if listed.Roots == nil {
+		listed.Roots = []ClientRoot{}
+	}
+
+	s.clientRoots.set(sessionID, listed.Roots)
+	return append([]ClientRoot(nil), listed.Roots...), nil
+}
+
+// handleRootsListChanged drops the cached roots of the session whose client
+// announced a change.
+func (s *serverImpl) handleRootsListChanged(ctx *Context) {
+	sessionID := ctx.sessionID()
+	s.clientRoots.invalidate(sessionID)
+	s.logger.Debug("client roots changed", "sessionID", string(sessionID))
+}
+
+// requestClient sends a request to the client of a session and waits for
+// its result. On transports that serve several clients, a session bound to
+// a connection is sent the request on that connection alone.
+func (s *serverImpl) requestClient(ctx context.Context, sessionID SessionID, method string, params interface{}) (json.RawMessage, error) {
+	id := s.generateRequestID()
+	request := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  method,
+	}
+	if params != nil {
+		request["params"] = params
+	}
+	message, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s request: %w", method, err)
+	}
+	if s.requestTracker == nil {
+		return nil, fmt.Errorf("cannot send %s request: server is not running", method)
+	}
+
+	responseChan := s.requestTracker.addRequest(int(id))
+	if err := s.sendToSession(sessionID, message); err != nil {
+		s.requestTracker.removeRequest(int(id))
+		return nil, fmt.Errorf("failed to send %s request: %w", method, err)
+	}
+
+	var responseJSON json.RawMessage
+	select {
+	case responseJSON = <-responseChan:
+	case <-time.After(clientRequestTimeout):
+		s.requestTracker.removeRequest(int(id))
+		return nil, fmt.Errorf("timeout waiting for %s response", method)
+	case <-ctx.Done():
+		s.requestTracker.removeRequest(int(id))
+		return nil, ctx.Err()
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+	if err := json.Unmarshal(responseJSON, &response); err != nil {
+		return nil, fmt.Errorf("invalid %s response: %w", method, err)
+	}
+	if response.Error != nil {
+		return nil, response.Error
+	}
+	return response.Result, nil
+}
+
+// sendToSession sends a message to the client of a session, on its own
+// connection if the transport can address one.
+func (s *serverImpl) sendToSession(sessionID SessionID, message []byte) error {
+	if session, ok := s.sessionManager.GetSession(sessionID); ok && session.ConnectionID != "" {
+		if sender, addressable := s.transport.(transport.SessionSender); addressable {
+			if err := sender.SendTo(session.ConnectionID, message); err != nil {
+				return err
+			}
+			s.metrics.countBytes(s.transportLabel(), "out", len(message))
+			return nil
+		}
+	}
+	return s.sendMessage(message)
+}
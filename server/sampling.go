@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -76,6 +77,13 @@ type SamplingCreateMessageParams struct {
 	ModelPreferences SamplingModelPreferences `json:"modelPreferences"`
 	SystemPrompt     string                   `json:"systemPrompt,omitempty"`
 	MaxTokens        int                      `json:"maxTokens,omitempty"`
+
+	// IncludeContext asks the client to add context from MCP servers to the
+	// prompt: "none", "thisServer" or "allServers".
+	IncludeContext string                 `json:"includeContext,omitempty"`
+	Temperature    *float64               `json:"temperature,omitempty"`
+	StopSequences  []string               `json:"stopSequences,omitempty"`
+	Metadata       map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // SamplingResponse represents the response to a sampling/createMessage request.
@@ -500,6 +508,20 @@ func (s *serverImpl) RequestSamplingWithOptions(messages []SamplingMessage, pref
 // RequestSamplingWithSessionAndOptions sends a sampling request to the client with a specific session
 // and custom options for timeout and retry behavior
 func (s *serverImpl) RequestSamplingWithSessionAndOptions(sessionID SessionID, protocolVersion string, messages []SamplingMessage, preferences SamplingModelPreferences, systemPrompt string, maxTokens int, options RequestSamplingOptions) (*SamplingResponse, error) {
+	params := SamplingCreateMessageParams{
+		Messages:         messages,
+		ModelPreferences: preferences,
+		SystemPrompt:     systemPrompt,
+		MaxTokens:        maxTokens,
+	}
+	return s.createMessage(context.Background(), sessionID, protocolVersion, params, options)
+}
+
+// createMessage sends a sampling/createMessage request to the client of a
+// session and waits for its response, giving up early if ctx is done.
+func (s *serverImpl) createMessage(ctx context.Context, sessionID SessionID, protocolVersion string, params SamplingCreateMessageParams, options RequestSamplingOptions) (*SamplingResponse, error) {
+	messages, maxTokens := params.Messages, params.MaxTokens
+
 	// Apply default options if not specified
 	if options.Timeout == 0 {
 		options.Timeout = 30 * time.Second // Default 30-second timeout
@@ -533,6 +555,10 @@ func (s *serverImpl) RequestSamplingWithSessionAndOptions(sessionID SessionID, p
 
 	// Validate messages against client capabilities if not ignoring capability validation
 	if !options.IgnoreCapability {
+		if err := s.requireClientCapability(sessionID, "sampling/createMessage", "sampling",
+			"enable it on the client with client.WithSamplingCapability(true, nil)"); err != nil {
+			return nil, err
+		}
 		for _, msg := range messages {
 			switch msg.Content.Type {
 			case "audio":
@@ -553,14 +579,6 @@ func (s *serverImpl) RequestSamplingWithSessionAndOptions(sessionID SessionID, p
 		}
 	}
 
-	// Create sampling parameters
-	params := SamplingCreateMessageParams{
-		Messages:         messages,
-		ModelPreferences: preferences,
-		SystemPrompt:     systemPrompt,
-		MaxTokens:        maxTokens,
-	}
-
 	// Marshal the params
 	paramsJSON, err := json.Marshal(params)
 	if err != nil {
@@ -604,7 +622,7 @@ func (s *serverImpl) RequestSamplingWithSessionAndOptions(sessionID SessionID, p
 		"maxTokens", maxTokens)
 
 	// Send the request
-	err = s.transport.Send(requestJSON)
+	err = s.sendMessage(requestJSON)
 	if err != nil {
 		s.requestTracker.removeRequest(int(requestID))
 		return nil, fmt.Errorf("failed to send sampling request: %w", err)
@@ -619,6 +637,9 @@ func (s *serverImpl) RequestSamplingWithSessionAndOptions(sessionID SessionID, p
 		// Got a response
 	case <-time.After(options.Timeout):
 		timedOut = true
+	case <-ctx.Done():
+		s.requestTracker.removeRequest(int(requestID))
+		return nil, ctx.Err()
 	}
 
 	// Handle timeout with improved error handling and retry management
@@ -644,7 +665,7 @@ func (s *serverImpl) RequestSamplingWithSessionAndOptions(sessionID SessionID, p
 			// Add a small delay before retrying
 			time.Sleep(options.RetryInterval)
 
-			return s.RequestSamplingWithSessionAndOptions(sessionID, protocolVersion, messages, preferences, systemPrompt, maxTokens, newOptions)
+			return s.createMessage(ctx, sessionID, protocolVersion, params, newOptions)
 		}
 
 		// Handle graceful degradation if enabled
@@ -675,9 +696,9 @@ func (s *serverImpl) RequestSamplingWithSessionAndOptions(sessionID SessionID, p
 		ID      json.RawMessage   `json:"id"`
 		Result  *SamplingResponse `json:"result,omitempty"`
 		Error   *struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-			Data    string `json:"data,omitempty"`
+			Code    int             `json:"code"`
+			Message string          `json:"message"`
+			Data    json.RawMessage `json:"data,omitempty"`
 		} `json:"error,omitempty"`
 	}
 
@@ -726,11 +747,17 @@ func (s *serverImpl) RequestSamplingWithSessionAndOptions(sessionID SessionID, p
 			// Add a small delay before retrying
 			time.Sleep(options.RetryInterval)
 
-			return s.RequestSamplingWithSessionAndOptions(sessionID, protocolVersion, messages, preferences, systemPrompt, maxTokens, newOptions)
+			return s.createMessage(ctx, sessionID, protocolVersion, params, newOptions)
 		}
 
 		// Otherwise, return the error
-		return nil, fmt.Errorf("sampling error: %s (code %d)", response.Error.Message, response.Error.Code)
+		// Clients put the reason a request failed, such as the user
+		// declining it, in the error data
+		var reason string
+		if json.Unmarshal(response.Error.Data, &reason) == nil && reason != "" {
+			errMsg += ": " + reason
+		}
+		return nil, fmt.Errorf("sampling error: %s (code %d)", errMsg, response.Error.Code)
 	}
 
 	// Ensure we have a valid result
@@ -792,10 +819,18 @@ type SamplingCapabilities struct {
 
 // ClientInfo represents information about a connected client
 type ClientInfo struct {
+	// Name and Version identify the client application, as sent in the
+	// clientInfo of initialize.
+	Name    string
+	Version string
+
 	SamplingSupported bool
 	SamplingCaps      SamplingCapabilities
 	ProtocolVersion   string
-	// Add other client capabilities here
+
+	// Capabilities holds the capabilities the client declared at initialize,
+	// or nil if it declared none.
+	Capabilities map[string]interface{}
 }
 
 // getClientInfo returns information about the connected client
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"github.com/localrivet/gomcp/util/textutil"
+)
+
+// WithSanitizer sets the sanitizer applied to the text content of every tool
+// result. Individual tools can override it with WithToolSanitizer.
+//
+// Example:
+//
+//	srv := server.NewServer("shell",
+//	    server.WithSanitizer(textutil.SanitizeCLIOutput),
+//	)
+func WithSanitizer(sanitizer textutil.Sanitizer) Option {
+	return func(s *serverImpl) {
+		s.sanitizer = sanitizer
+	}
+}
+
+// WithToolSanitizer sets the sanitizer applied to the text content returned
+// by a single tool, overriding the server-wide sanitizer. Pass a sanitizer
+// that returns its input unchanged to opt a tool out.
+// The function returns the server instance to allow for method chaining.
+func (s *serverImpl) WithToolSanitizer(toolName string, sanitizer textutil.Sanitizer) Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tool, exists := s.tools[toolName]
+	if !exists {
+		s.logger.Error("tool not found for sanitizer", "name", toolName)
+		return s
+	}
+
+	tool.Sanitizer = sanitizer
+	return s
+}
+
+// sanitizeToolResult applies the tool's sanitizer, or the server-wide one, to
+// the text items of a formatted tools/call result.
+func (s *serverImpl) sanitizeToolResult(toolName string, result map[string]interface{}) map[string]interface{} {
+	s.mu.RLock()
+	sanitizer := s.sanitizer
+	if tool, exists := s.tools[toolName]; exists && tool.Sanitizer != nil {
+		sanitizer = tool.Sanitizer
+	}
+	s.mu.RUnlock()
+
+	if sanitizer == nil {
+		return result
+	}
+
+	for _, item := range contentItems(result["content"]) {
+		if text, ok := item["text"].(string); ok {
+			item["text"] = sanitizer(text)
+		}
+	}
+	return result
+}
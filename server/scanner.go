@@ -0,0 +1,169 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/localrivet/gomcp/util/scan"
+)
+
+// ScanAction determines what the server does with content flagged by a scanner.
+type ScanAction int
+
+const (
+	// ScanAnnotate delivers the content unchanged and lists the findings in
+	// the content item's _meta under "gomcp/scan".
+	ScanAnnotate ScanAction = iota
+
+	// ScanSanitize replaces flagged spans before delivery.
+	ScanSanitize
+
+	// ScanBlock withholds the content entirely.
+	ScanBlock
+)
+
+// scanMetaKey is the _meta key under which findings are reported.
+const scanMetaKey = "gomcp/scan"
+
+// WithContentScanner installs a scanner that inspects tool results and
+// resource contents before they are delivered to the client.
+//
+// Flagged text is annotated, sanitized or blocked depending on action.
+// The scan package ships a heuristic scanner that detects instruction-like
+// phrases, invisible Unicode and data exfiltration URLs.
+//
+// Example:
+//
+//	srv := server.NewServer("browser",
+//	    server.WithContentScanner(scan.NewHeuristic(), server.ScanSanitize),
+//	)
+func WithContentScanner(scanner scan.Scanner, action ScanAction) Option {
+	return func(s *serverImpl) {
+		s.contentScanner = scanner
+		s.scanAction = action
+	}
+}
+
+// scanToolResult applies the content scanner to a formatted tools/call result.
+func (s *serverImpl) scanToolResult(toolName string, result map[string]interface{}) map[string]interface{} {
+	if s.contentScanner == nil {
+		return result
+	}
+
+	blocked, findings := s.scanContentItems(result["content"], "text")
+	if !blocked {
+		return result
+	}
+
+	s.logger.Warn("blocked tool result flagged by content scanner", "tool", toolName, "findings", len(findings))
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("Tool result withheld: flagged by content scanner (%s)", findingRules(findings)),
+			},
+		},
+		"isError": true,
+	}
+}
+
+// scanResourceResult applies the content scanner to a formatted resources/read result.
+func (s *serverImpl) scanResourceResult(uri string, result interface{}) (interface{}, error) {
+	if s.contentScanner == nil {
+		return result, nil
+	}
+
+	response, ok := result.(map[string]interface{})
+	if !ok {
+		return result, nil
+	}
+
+	// Depending on the protocol version, text appears in a top-level content
+	// array, in contents items, or in content arrays nested in contents items.
+	blocked, findings := s.scanContentItems(response["content"], "text")
+	contents := contentItems(response["contents"])
+	nestedBlocked, nestedFindings := s.scanContentItems(contents, "text")
+	blocked = blocked || nestedBlocked
+	findings = append(findings, nestedFindings...)
+	for _, item := range contents {
+		nestedBlocked, nestedFindings := s.scanContentItems(item["content"], "text")
+		blocked = blocked || nestedBlocked
+		findings = append(findings, nestedFindings...)
+	}
+
+	if blocked {
+		s.logger.Warn("blocked resource flagged by content scanner", "uri", uri, "findings", len(findings))
+		return nil, fmt.Errorf("resource %s withheld: flagged by content scanner (%s)", uri, findingRules(findings))
+	}
+	return result, nil
+}
+
+// scanContentItems scans the text field of each content item in place.
+// It reports whether any item should be blocked along with all findings.
+func (s *serverImpl) scanContentItems(content interface{}, field string) (bool, []scan.Finding) {
+	items := contentItems(content)
+
+	var all []scan.Finding
+	for _, item := range items {
+		text, ok := item[field].(string)
+		if !ok || text == "" {
+			continue
+		}
+
+		findings := s.contentScanner.Scan(text)
+		if len(findings) == 0 {
+			continue
+		}
+		all = append(all, findings...)
+
+		switch s.scanAction {
+		case ScanBlock:
+			continue
+		case ScanSanitize:
+			item[field] = scan.Sanitize(text, findings)
+		}
+		annotateFindings(item, findings)
+	}
+
+	return s.scanAction == ScanBlock && len(all) > 0, all
+}
+
+// contentItems returns the map items of a content array.
+func contentItems(content interface{}) []map[string]interface{} {
+	switch v := content.(type) {
+	case []map[string]interface{}:
+		return v
+	case []interface{}:
+		items := make([]map[string]interface{}, 0, len(v))
+		for _, item := range v {
+			if itemMap, ok := item.(map[string]interface{}); ok {
+				items = append(items, itemMap)
+			}
+		}
+		return items
+	}
+	return nil
+}
+
+// annotateFindings records scanner findings in a content item's _meta.
+func annotateFindings(item map[string]interface{}, findings []scan.Finding) {
+	meta, ok := item["_meta"].(map[string]interface{})
+	if !ok {
+		meta = make(map[string]interface{})
+		item["_meta"] = meta
+	}
+	meta[scanMetaKey] = findings
+}
+
+// findingRules returns the distinct rule names of the findings, comma separated.
+func findingRules(findings []scan.Finding) string {
+	seen := make(map[string]bool)
+	var rules []string
+	for _, f := range findings {
+		if !seen[f.Rule] {
+			seen[f.Rule] = true
+			rules = append(rules, f.Rule)
+		}
+	}
+	return strings.Join(rules, ", ")
+}
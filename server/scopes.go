@@ -0,0 +1,96 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/localrivet/gomcp/auth"
+	"github.com/localrivet/gomcp/protocol"
+)
+
+// WithRequiredScopes restricts a tool, or the resource registered at a
+// path, to callers whose principal was granted all of scopes. Other
+// callers don't see it in tools/list, resources/list or
+// resources/templates/list, and their calls and reads are refused with a
+// protocol.Forbidden error. Callers without a principal have no scopes.
+//
+// Principals come from the bearer tokens of servers set up WithOAuth, whose
+// HTTP endpoints then also answer such requests with an insufficient_scope
+// challenge, or from middleware calling Context.SetPrincipal.
+// The function returns the server instance to allow for method chaining.
+func (s *serverImpl) WithRequiredScopes(name string, scopes ...string) Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := false
+	if tool, ok := s.tools[name]; ok {
+		tool.scopes = append([]string(nil), scopes...)
+		s.toolsChanged = true
+		found = true
+	}
+	if resource, ok := s.resources[name]; ok {
+		resource.scopes = append([]string(nil), scopes...)
+		found = true
+	}
+	if !found {
+		s.logger.Error("tool or resource not found for required scopes", "name", name)
+	}
+	return s
+}
+
+// Principal returns the caller the request is made on behalf of, or nil
+// if it is unknown.
+func (c *Context) Principal() *auth.Principal {
+	return c.principal
+}
+
+// SetPrincipal sets the caller the request is made on behalf of, for
+// middleware authenticating requests by other means than OAuth.
+//
+// Example:
+//
+//	srv.Use(func(next server.RequestHandler) server.RequestHandler {
+//	    return func(ctx *server.Context) (interface{}, error) {
+//	        if key, ok := apiKeys[ctx.Meta().APIKey()]; ok {
+//	            ctx.SetPrincipal(&auth.Principal{Subject: key.Owner, Scopes: key.Scopes})
+//	        }
+//	        return next(ctx)
+//	    }
+//	})
+func (c *Context) SetPrincipal(principal *auth.Principal) {
+	c.principal = principal
+}
+
+// permitted reports whether the caller was granted all of scopes.
+func (c *Context) permitted(scopes []string) bool {
+	return len(scopes) == 0 || c.principal.HasScopes(scopes...)
+}
+
+// tokenPrincipal is the middleware setting the principal of requests from
+// the token the HTTP middleware of WithOAuth validated.
+func tokenPrincipal(next RequestHandler) RequestHandler {
+	return func(ctx *Context) (interface{}, error) {
+		meta := ctx.Meta()
+		if subject := meta.String(auth.MetaSubject); subject != "" {
+			ctx.SetPrincipal(&auth.Principal{
+				Subject:  subject,
+				ClientID: meta.String(auth.MetaClientID),
+				Scopes:   strings.Fields(meta.String(auth.MetaScopes)),
+			})
+		}
+		return next(ctx)
+	}
+}
+
+// insufficientScopeError is the error requests for tools and resources the
+// caller lacks the scopes of are answered with.
+func insufficientScopeError(kind, name string, scopes []string) error {
+	return &RPCError{
+		Code:    protocol.Forbidden,
+		Message: "Insufficient scope for " + kind + ": " + name,
+		Data: map[string]interface{}{
+			"reason": protocol.ReasonInsufficientScope,
+			kind:     name,
+			"scopes": scopes,
+		},
+	}
+}
@@ -4,21 +4,39 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
+	"net/http"
 	"os"
 	"sync"
+	"text/template"
 	"time"
 
+	"github.com/localrivet/gomcp/flags"
 	"github.com/localrivet/gomcp/mcp"
+	"github.com/localrivet/gomcp/persist"
+	"github.com/localrivet/gomcp/ratelimit"
+	"github.com/localrivet/gomcp/telemetry"
 	"github.com/localrivet/gomcp/transport"
 	"github.com/localrivet/gomcp/transport/mqtt"
 	"github.com/localrivet/gomcp/transport/nats"
+	"github.com/localrivet/gomcp/transport/sse"
 	"github.com/localrivet/gomcp/transport/stdio"
+	"github.com/localrivet/gomcp/transport/streamablehttp"
 	"github.com/localrivet/gomcp/transport/udp"
 	"github.com/localrivet/gomcp/transport/unix"
-	"github.com/localrivet/gomcp/transport/sse"
+	"github.com/localrivet/gomcp/transport/ws"
+	"github.com/localrivet/gomcp/util/mdns"
+	"github.com/localrivet/gomcp/util/scan"
+	"github.com/localrivet/gomcp/util/schema"
+	"github.com/localrivet/gomcp/util/textutil"
+	"github.com/localrivet/gomcp/webhook"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // Server represents an MCP server with fluent configuration methods.
@@ -29,7 +47,7 @@ type Server interface {
 	//
 	// This method initializes the server, starts listening for connections,
 	// and processes incoming requests. It blocks until the server encounters
-	// an error or is explicitly stopped.
+	// an error or is stopped with Shutdown.
 	//
 	// Example:
 	//  if err := server.Run(); err != nil {
@@ -49,6 +67,9 @@ type Server interface {
 	//
 	// Where T is a struct type that defines the expected arguments for the tool.
 	//
+	// Tools may be registered while the server runs; initialized clients are
+	// then sent notifications/tools/list_changed.
+	//
 	// Example:
 	//  server.Tool("echo", "Echo the input text", func(ctx *Context, args struct {
 	//      Text string `json:"text" required:"true" description:"Text to echo"`
@@ -87,11 +108,123 @@ type Server interface {
 	//  })
 	WithAnnotations(toolName string, annotations map[string]interface{}) Server
 
+	// WithToolSanitizer sets the sanitizer applied to a tool's text output.
+	//
+	// Use it for tools that wrap command line programs, whose raw output may
+	// contain ANSI escapes, control characters or invalid UTF-8.
+	//
+	// Example:
+	//  server.WithToolSanitizer("git_log", textutil.SanitizeCLIOutput)
+	WithToolSanitizer(toolName string, sanitizer textutil.Sanitizer) Server
+
+	// WithCost sets what a call to a tool is billed at. The cost is
+	// advertised in the tool's "cost" annotation and counted in Usage.
+	//
+	// Example:
+	//
+	//  server.WithCost("generate_report", server.ToolCost{Credits: 5, Tier: "premium"})
+	WithCost(toolName string, cost ToolCost) Server
+
+	// WithProbe attaches a check of a tool's dependencies, run at startup
+	// and periodically and reported by Health.
+	//
+	// Example:
+	//
+	//  server.WithProbe("query_orders", func(ctx context.Context) error {
+	//      return db.PingContext(ctx)
+	//  })
+	WithProbe(toolName string, probe Probe) Server
+
+	// Health returns the latest results of the tool probes.
+	Health() HealthReport
+
+	// AuditTrail returns the tool calls recorded since the given time with
+	// WithPersistence.
+	AuditTrail(since time.Time) ([]AuditRecord, error)
+
+	// SetMaintenance turns maintenance mode on or off. While it is on, new
+	// tool calls are refused with message and everything else is served.
+	SetMaintenance(on bool, message string)
+
+	// WithToolFlag makes a tool available only to callers for whom a
+	// boolean feature flag is on, as evaluated by the WithFlags provider.
+	//
+	// Example:
+	//
+	//  server.WithToolFlag("reports_v2", "reports.v2")
+	WithToolFlag(toolName, flag string) Server
+
+	// WithRequiredScopes restricts a tool, or the resource registered at
+	// a path, to callers whose principal was granted all of scopes.
+	//
+	// Example:
+	//
+	//  server.WithRequiredScopes("delete_file", "files:write")
+	WithRequiredScopes(name string, scopes ...string) Server
+
+	// WithLocalizations registers translations of the title, description
+	// and argument descriptions of a tool or prompt, keyed by language tag,
+	// which tools/list and prompts/list serve by the client's locale.
+	//
+	// Example:
+	//
+	//  server.WithLocalizations("weather", map[string]server.Localization{
+	//      "fr": {Title: "Météo", Description: "Donne la météo actuelle d'une ville"},
+	//  })
+	WithLocalizations(name string, translations map[string]Localization) Server
+
+	// WithSchemaVariants lets a string feature flag choose a tool's input
+	// schema from variants, keyed by flag value.
+	WithSchemaVariants(toolName, flag string, variants map[string]map[string]interface{}) Server
+
+	// WithToolVersion registers a candidate implementation of a tool and
+	// splits calls between it and the current one, by percentage, pinned
+	// per session, or in shadow mode comparing their results.
+	//
+	// Example:
+	//
+	//  server.WithToolVersion("search", "v2", searchV2, server.ToolRollout{Percent: 10, Sticky: true})
+	WithToolVersion(toolName, version string, handler interface{}, rollout ToolRollout) Server
+
+	// PromoteToolVersion makes a tool's candidate version serve every call.
+	PromoteToolVersion(toolName string) Server
+
+	// AdminHandler returns an http.Handler serving pprof profiles, goroutine
+	// dumps, a session table and runtime statistics under /debug/, for
+	// mounting under a protected path. Requests must carry the bearer token
+	// unless it is empty.
+	//
+	// Example:
+	//
+	//  mux.Handle("/admin/", http.StripPrefix("/admin", srv.AdminHandler(token)))
+	AdminHandler(token string) http.Handler
+
+	// Use adds middleware around the handling of every incoming request and
+	// notification, for concerns such as authentication, logging and metrics.
+	//
+	// Example:
+	//
+	//  server.Use(func(next server.RequestHandler) server.RequestHandler {
+	//      return func(ctx *server.Context) (interface{}, error) {
+	//          start := time.Now()
+	//          result, err := next(ctx)
+	//          log.Printf("%s took %v", ctx.Request.Method, time.Since(start))
+	//          return result, err
+	//      }
+	//  })
+	Use(middleware ...Middleware) Server
+
 	// Resource registers a resource with the server.
 	//
 	// The pattern parameter is a URL path pattern that matches requests to this
 	// resource. The description parameter provides human-readable documentation.
 	// The handler parameter is a function that implements the resource's logic.
+	// Resources registered while the server runs are announced to initialized
+	// clients with notifications/resources/list_changed.
+	//
+	// Handlers may return []byte for raw content. Every read reports an
+	// accurate mimeType, from the URI's extension or sniffed from the
+	// content, and text in legacy charsets is transcoded to UTF-8.
 	//
 	// Example:
 	//  server.Resource("/users/:id", "Get user information", func(ctx *Context) (interface{}, error) {
@@ -104,7 +237,9 @@ type Server interface {
 	//
 	// The name parameter is the unique identifier for the prompt. The description
 	// parameter provides human-readable documentation. The template parameter is
-	// a string with placeholders for variables.
+	// a string with placeholders for variables. Prompts registered while the
+	// server runs are announced to initialized clients with
+	// notifications/prompts/list_changed.
 	//
 	// Example:
 	//  server.Prompt("greeting", "A friendly greeting", "Hello, {{name}}! How are you today?")
@@ -119,6 +254,16 @@ type Server interface {
 	//  server.Root("/api/v1", "/api/v2")
 	Root(paths ...string) Server
 
+	// Completion registers the provider that suggests values for the
+	// arguments of a prompt, given by name, or the variables of a resource
+	// template, given by its URI template, answering completion/complete.
+	//
+	// Example:
+	//  server.Completion("code_review", func(arg, prefix string) []string {
+	//      return matching(languages, prefix)
+	//  })
+	Completion(ref string, fn CompletionFunc) Server
+
 	// IsPathInRoots checks if the given path is within any of the registered roots.
 	// This security method ensures that file operations can only access paths within
 	// the authorized boundaries defined by the registered root paths, preventing
@@ -152,10 +297,15 @@ type Server interface {
 	// AsWebsocket configures the server to use WebSocket for communication.
 	//
 	// The address parameter specifies the host and port to listen on.
+	// Optional WebSocket configuration options can be provided using ws.WS.With* functions.
 	//
 	// Example:
+	//  // Basic configuration
 	//  server.AsWebsocket("localhost:8080")
-	AsWebsocket(address string) Server
+	//
+	//  // With a custom path
+	//  server.AsWebsocket("localhost:8080", ws.WS.WithPath("/mcp"))
+	AsWebsocket(address string, options ...ws.Option) Server
 
 	// AsSSE configures the server to use Server-Sent Events for communication.
 	//
@@ -170,6 +320,19 @@ type Server interface {
 	//  server.AsSSE("localhost:8080", sse.SSE.WithPathPrefix("/api"), sse.SSE.WithEventsPath("/events"))
 	AsSSE(address string, options ...sse.Option) Server
 
+	// AsStreamableHTTP configures the server to use the Streamable HTTP transport
+	// of the 2025-03-26 specification revision: a single endpoint that accepts
+	// POSTed messages and answers with JSON or an SSE stream.
+	//
+	// The address parameter specifies the host and port to listen on.
+	//
+	// Example:
+	//  server.AsStreamableHTTP("localhost:8080")
+	//
+	//  // With a custom endpoint path
+	//  server.AsStreamableHTTP("localhost:8080", streamablehttp.WithEndpoint("/api/mcp"))
+	AsStreamableHTTP(address string, options ...streamablehttp.Option) Server
+
 	// AsUnixSocket configures the server to use Unix Domain Sockets for communication.
 	//
 	// Unix Domain Sockets provide high-performance inter-process communication for
@@ -237,11 +400,116 @@ type Server interface {
 	//	    nats.WithSubjectPrefix("custom/subject/prefix"))
 	AsNATS(serverURL string, options ...nats.NATSOption) Server
 
+	// ImportPrompts registers the prompts stored in Markdown or YAML files.
+	//
+	// Markdown files carry name, description and arguments in YAML
+	// front-matter and separate messages with "## system", "## user" and
+	// "## assistant" headings.
+	//
+	// Example:
+	//
+	//	err := server.ImportPrompts(os.DirFS("prompts"), "*.md")
+	ImportPrompts(fsys fs.FS, pattern string) error
+
+	// ExportPrompts writes all registered prompts to dir, one file per prompt.
+	//
+	// Example:
+	//
+	//	err := server.ExportPrompts("prompts", server.PromptFormatMarkdown)
+	ExportPrompts(dir string, format PromptFormat) error
+
+	// ExportState writes a versioned snapshot of the server's prompts and
+	// persisted state to w, to be restored on another instance with
+	// ImportState.
+	ExportState(w io.Writer) error
+
+	// ImportState restores a snapshot written by ExportState.
+	ImportState(r io.Reader) error
+
+	// Capabilities returns the capabilities advertised in the initialize response.
+	//
+	// Example:
+	//
+	//	caps := server.Capabilities()
+	Capabilities() map[string]interface{}
+
+	// LifecycleMetrics returns counters for requests rejected or queued before
+	// initialization, duplicate initialize requests and handshake timeouts.
+	LifecycleMetrics() LifecycleMetrics
+
+	// EachTool yields the registered tools in name order. It has the shape of
+	// an iter.Seq, so it can be ranged over directly.
+	//
+	// Example:
+	//
+	//	for tool := range server.EachTool {
+	//	    fmt.Println(tool.Name)
+	//	}
+	EachTool(yield func(*Tool) bool)
+
+	// EachResource yields the registered resources in path order.
+	EachResource(yield func(*Resource) bool)
+
+	// EachPrompt yields the registered prompts in name order.
+	EachPrompt(yield func(*Prompt) bool)
+
+	// UnregisterTool removes a tool and tells clients the tool list
+	// changed. It reports whether the tool was registered.
+	UnregisterTool(name string) bool
+
+	// UnregisterResource removes the resource registered with path and
+	// tells clients the resource list changed. It reports whether the
+	// resource was registered.
+	UnregisterResource(path string) bool
+
+	// UnregisterPrompt removes a prompt and tells clients the prompt list
+	// changed. It reports whether the prompt was registered.
+	UnregisterPrompt(name string) bool
+
+	// NotifyResourceUpdated notifies subscribed clients that a resource has changed.
+	//
+	// Updates for the same URI are coalesced within the window configured by
+	// WithResourceUpdateWindow, and no notification is sent when no session is
+	// subscribed to the URI.
+	//
+	// Example:
+	//
+	//	server.NotifyResourceUpdated("file:///logs/app.log")
+	NotifyResourceUpdated(uri string) error
+
+	// DiscoveryDocument returns the metadata served at /.well-known/mcp.json
+	// on HTTP-based transports.
+	DiscoveryDocument() mcp.DiscoveryDocument
+
+	// Usage returns the tool calls and credits counted per session and per
+	// API key since the server started or usage was last reset.
+	Usage() UsageReport
+
+	// ResetUsage clears the usage counters, for example after a billing
+	// period has been exported.
+	ResetUsage()
+
+	// MemoryPressure returns how close the process is to its memory limit,
+	// as watched by WithMemoryPressure.
+	MemoryPressure() MemoryPressure
+
+	// Shutdown stops accepting requests, waits for those in progress to
+	// finish or for ctx to end, then closes the transport and makes Run
+	// return.
+	Shutdown(ctx context.Context) error
+
+	// ShutdownWithNotice shuts down like Shutdown, telling connected
+	// clients the reason and when to reconnect.
+	ShutdownWithNotice(ctx context.Context, notice ShutdownNotice) error
+
 	// GetServer returns the underlying server implementation
 	// This is primarily for internal use and testing.
 	GetServer() *serverImpl
 }
 
+// serverVersion is the version reported in serverInfo and the discovery document.
+const serverVersion = "1.0.0"
+
 // Option represents a server configuration option.
 // Server options are used to customize the behavior and configuration of a server instance
 // when it is created with NewServer.
@@ -261,6 +529,12 @@ type serverImpl struct {
 	// prompts is a map of registered prompt templates keyed by prompt name.
 	prompts map[string]*Prompt
 
+	// promptFuncs are the functions prompt templates can call.
+	promptFuncs template.FuncMap
+
+	// promptPartials are reusable messages that prompts can include by name.
+	promptPartials map[string][]PromptTemplate
+
 	// roots is a slice of registered root paths for resource navigation.
 	roots []string
 
@@ -282,6 +556,9 @@ type serverImpl struct {
 	// requestTracker manages pending requests and matches responses to requests.
 	requestTracker *requestTracker
 
+	// clientRoots caches the roots each session's client listed.
+	clientRoots rootsCache
+
 	// requestCanceller manages cancellable requests and processes cancellation notifications.
 	requestCanceller *RequestCanceller
 
@@ -311,8 +588,207 @@ type serverImpl struct {
 	// pendingNotifications stores notifications that should be sent after initialization
 	pendingNotifications [][]byte
 
+	// lifecyclePolicy controls requests received before the initialized notification.
+	lifecyclePolicy LifecyclePolicy
+
+	// initializeTimeout bounds how long a client may take to complete the handshake.
+	initializeTimeout time.Duration
+
+	// handshakes tracks the initialize handshake of each connection, or of
+	// the single client under the empty connection ID.
+	handshakes map[string]*handshake
+
+	// lifecycleCounters counts handshake violations.
+	lifecycleCounters lifecycleCounters
+
+	// descriptionPolicy controls the parameter description check on tool registration.
+	descriptionPolicy DescriptionPolicy
+
+	// descriptionRules configures the parameter description check.
+	descriptionRules schema.DescriptionRules
+
+	// rateLimitStore counts requests for rateLimitRules.
+	rateLimitStore ratelimit.Store
+	rateLimitRules []RateLimitRule
+
+	// tokenBuckets are the limits added with WithRateLimit.
+	tokenBuckets []tokenBucketLimit
+
+	// usage counts tool calls and credits for Usage.
+	usage usageMeter
+
+	// quotaStore keeps the credits spent against quotas.
+	quotaStore ratelimit.CounterStore
+	quotas     []Quota
+
+	// webhooks receives lifecycle, quota and audit events.
+	webhooks   *webhook.Dispatcher
+	errorSpike errorSpikeDetector
+
+	// eventHandlers receive the same events as webhooks, in process.
+	eventHandlers []func(eventType string, data map[string]interface{})
+
+	// middleware wraps the handling of incoming messages, outermost first.
+	middleware []Middleware
+
+	// errorReporting is set by WithErrorReporting.
+	errorReporting *ErrorReporting
+
+	// strictValidation checks tool arguments against their input schema
+	// before dispatch. It is on unless disabled with WithStrictValidation.
+	strictValidation bool
+
 	// toolsChanged indicates if tools have been modified since the last notification
 	toolsChanged bool
+
+	// resourceUpdateWindow is the window within which resource update notifications
+	// for the same URI are coalesced.
+	resourceUpdateWindow time.Duration
+
+	// resourceSubscriptions indicates whether resources/subscribe is supported.
+	resourceSubscriptions bool
+
+	// maxSubscriptions caps the number of resource subscriptions per session (0 = unlimited).
+	maxSubscriptions int
+
+	// resourceUpdates coalesces notifications/resources/updated messages per URI.
+	resourceUpdates *resourceUpdateCoalescer
+
+	// listChanges coalesces list_changed notifications per list
+	listChanges *resourceUpdateCoalescer
+
+	// discoveryAuth is the authentication requirement advertised in the discovery document.
+	discoveryAuth *mcp.AuthRequirements
+
+	// mdnsInstance is the service instance name advertised via mDNS; empty disables advertising.
+	mdnsInstance string
+
+	// mdnsResponder answers mDNS queries while the server is running.
+	mdnsResponder *mdns.Responder
+
+	// adminAddr and adminToken configure the debug listener started by Run.
+	adminAddr   string
+	adminToken  string
+	adminServer *http.Server
+
+	// contentScanner inspects tool results and resource contents before delivery.
+	contentScanner scan.Scanner
+
+	// scanAction is applied to content flagged by contentScanner.
+	scanAction ScanAction
+
+	// sanitizer cleans the text content of tool results unless a tool sets its own.
+	sanitizer textutil.Sanitizer
+
+	// markdown post-processes the text of tool results when set by
+	// WithMarkdown, with markdownLinks resolving relative links.
+	markdown      *MarkdownPolicy
+	markdownLinks textutil.Sanitizer
+
+	// sessionStore shares session state with other replicas when set by
+	// WithSessionStore.
+	sessionStore SessionStore
+
+	// contentDigests adds SHA-256 digests to content when set by
+	// WithContentDigests.
+	contentDigests bool
+
+	// oauth protects HTTP endpoints with bearer tokens when set by
+	// WithOAuth, and tokenPrincipals is set once the transport's endpoints
+	// are protected, making the request metadata the middleware sets
+	// trustworthy.
+	oauth           *OAuthConfig
+	tokenPrincipals bool
+
+	// negotiate decides which representations of a tool result a client
+	// accepts, if set by WithContentNegotiation.
+	negotiate func(client ClientInfo) ClientFormats
+
+	// quirks holds the schema workarounds per host name, if changed from
+	// DefaultHostQuirks with WithHostQuirks.
+	quirks map[string][]schema.Transform
+
+	// i18nBundle holds the message catalogs used by Context.Localize.
+	i18nBundle *i18n.Bundle
+
+	// memory tracks memory pressure when WithMemoryPressure is set.
+	memory *memoryMonitor
+
+	// maintenance is set with SetMaintenance.
+	maintenance maintenanceMode
+
+	// persistence keeps idempotent results and the audit trail when
+	// WithPersistence is set.
+	persistence persist.Store
+
+	// pageSize is the list page size set with WithPageSize, zero for no
+	// pagination.
+	pageSize int
+
+	// keepalive pings idle sessions when WithKeepalive is set.
+	keepalive *keepalive
+
+	// completions are the completion providers registered with Completion,
+	// keyed by prompt name or resource template URI, and defaultCompletion
+	// answers for the rest when WithCompletions is set.
+	completions       map[string]CompletionFunc
+	defaultCompletion CompletionFunc
+
+	// egressTransport sends the requests of HTTPClient when
+	// WithEgressPolicy is set.
+	egressTransport *http.Transport
+
+	// fetchPolicy limits the requests of HTTPClient to each host when
+	// WithFetchPolicy is set.
+	fetchPolicy *politeFetcher
+
+	// uploads stores the files clients upload when WithUploads is set.
+	uploads *uploadStore
+
+	// maxResourceSize bounds the binary and file content of a
+	// resources/read, if set by WithMaxResourceSize.
+	maxResourceSize int64
+
+	// overflow keeps the chunks of tool results that OverflowResult links
+	// to.
+	overflow overflowStore
+
+	// localizations holds the translations of tools and prompts by name.
+	localizations map[string]*localizations
+
+	// watchedDirectories are the directories whose files are registered as
+	// resources by WithWatchedDirectory.
+	watchedDirectories []*directoryWatch
+
+	// fileCache keeps recently read blocks of the files served by
+	// WithFileContent and WithBinaryContent.
+	fileCache fileCache
+
+	// flags evaluates feature flags, and flagContext builds the evaluation
+	// context of a request if set.
+	flags       flags.Provider
+	flagContext func(ctx *Context) flags.EvaluationContext
+
+	// tracer records a span per request when WithTracerProvider is set.
+	tracer *telemetry.Tracer
+
+	// metrics holds the Prometheus collectors set up by WithMetrics, and
+	// metricsGatherer serves them on the admin listener if set.
+	metrics         *serverMetrics
+	metricsGatherer prometheus.Gatherer
+
+	// probePolicy sets how tool probes are run.
+	probePolicy ProbePolicy
+
+	// batchPolicy sets how JSON-RPC batches are handled.
+	batchPolicy BatchPolicy
+
+	// drain counts requests in progress for Shutdown.
+	drain requestDrain
+
+	// shutdownOnce and stopped let Shutdown run once and Run return after it.
+	shutdownOnce sync.Once
+	stopped      chan struct{}
 }
 
 // GetName returns the server's name.
@@ -405,20 +881,29 @@ func (s *serverImpl) WithSamplingController(controller *SamplingController) Serv
 func NewServer(name string, options ...Option) Server {
 	// Create a new server instance
 	s := &serverImpl{
-		name:                 name,
-		tools:                make(map[string]*Tool),
-		resources:            make(map[string]*Resource),
-		prompts:              make(map[string]*Prompt),
-		roots:                []string{},
-		logger:               slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})),
-		versionDetector:      mcp.NewVersionDetector(),
-		sessionManager:       NewSessionManager(),
-		initialized:          false,
-		pendingNotifications: [][]byte{},
-		toolsChanged:         false,
-		requestCanceller:     NewRequestCanceller(),
+		name:                  name,
+		tools:                 make(map[string]*Tool),
+		resources:             make(map[string]*Resource),
+		prompts:               make(map[string]*Prompt),
+		roots:                 []string{},
+		logger:                slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})),
+		versionDetector:       mcp.NewVersionDetector(),
+		sessionManager:        NewSessionManager(),
+		stopped:               make(chan struct{}),
+		initialized:           false,
+		pendingNotifications:  [][]byte{},
+		toolsChanged:          false,
+		requestCanceller:      NewRequestCanceller(),
+		resourceUpdateWindow:  DefaultResourceUpdateWindow,
+		resourceSubscriptions: true,
+		errorSpike:            errorSpikeDetector{threshold: 10, window: time.Minute},
+		strictValidation:      true,
+		probePolicy:           ProbePolicy{Interval: 30 * time.Second, Timeout: 5 * time.Second},
+		batchPolicy:           BatchPolicy{MaxSize: 100, Workers: 8},
 	}
 
+	s.listChanges = newResourceUpdateCoalescer(s.sendListChanged)
+
 	// Set the default transport to stdio
 	s.transport = stdio.NewTransport()
 
@@ -468,6 +953,22 @@ func WithLogger(logger *slog.Logger) Option {
 	}
 }
 
+// WithTransport sets a custom transport for the server.
+//
+// This option is useful for transports that are not covered by the As* helpers,
+// or for injecting an in-memory transport in tests.
+//
+// Example:
+//
+//	server := server.NewServer("my-service",
+//	    server.WithTransport(myTransport),
+//	)
+func WithTransport(t transport.Transport) Option {
+	return func(s *serverImpl) {
+		s.transport = t
+	}
+}
+
 // Logger returns the server's logger.
 //
 // This method provides access to the server's configured logger for custom logging needs.
@@ -517,8 +1018,25 @@ func (s *serverImpl) ProcessInitialize(ctx *Context) (interface{}, error) {
 		ProtocolVersion:   protocolVersion,
 	}
 
+	// Remember what the client declared so server-initiated requests can be gated
+	if declared, ok := extractClientCapabilities(ctx.Request.Params); ok {
+		clientInfo.Capabilities = declared
+	}
+	clientInfo.Name, clientInfo.Version = extractClientIdentity(ctx.Request.Params)
+
 	// Create a new session for this client
 	session := s.sessionManager.CreateSession(clientInfo, protocolVersion)
+	s.sessionManager.UpdateSession(session.ID, func(session *ClientSession) {
+		session.initialized = true
+	})
+	if connectionID := ctx.Meta().String(transport.MetaConnectionID); connectionID != "" {
+		s.sessionManager.BindConnection(session.ID, connectionID)
+	}
+	s.emitSessionStarted(session, ctx.Request.Params)
+
+	// Remember the client's locale and time zone hints for handlers
+	s.recordClientHints(session, ctx.Request.Params)
+	s.saveSession(session.ID)
 
 	// Store the session ID in the context metadata
 	if ctx.Metadata == nil {
@@ -526,8 +1044,11 @@ func (s *serverImpl) ProcessInitialize(ctx *Context) (interface{}, error) {
 	}
 	ctx.Metadata["sessionID"] = string(session.ID)
 
-	// For simple implementations that don't track multiple sessions, update the default session without locking
+	// For simple implementations that don't track multiple sessions, update the default session.
+	// The lock guards against the initialize timeout reading it concurrently.
+	s.mu.Lock()
 	s.defaultSession = session
+	s.mu.Unlock()
 
 	// Log the session creation
 	s.logger.Info("client connected",
@@ -612,28 +1133,18 @@ func (s *serverImpl) ProcessInitialize(ctx *Context) (interface{}, error) {
 	}
 
 	// Return response with the validated protocol version and complete capabilities
+	capabilities := s.Capabilities()
+	capabilities["prompts"].(map[string]interface{})["prompts"] = promptList
+	capabilities["resources"].(map[string]interface{})["resources"] = resourceList
+	capabilities["tools"].(map[string]interface{})["tools"] = toolList
+	capabilities["sampling"] = samplingCapabilities
+
 	return map[string]interface{}{
 		"protocolVersion": protocolVersion,
-		"capabilities": map[string]interface{}{
-			"logging": map[string]interface{}{},
-			"prompts": map[string]interface{}{
-				"listChanged": true,
-				"prompts":     promptList,
-			},
-			"resources": map[string]interface{}{
-				"subscribe":   true,
-				"listChanged": true,
-				"resources":   resourceList,
-			},
-			"tools": map[string]interface{}{
-				"listChanged": true,
-				"tools":       toolList,
-			},
-			"sampling": samplingCapabilities,
-		},
+		"capabilities":    capabilities,
 		"serverInfo": map[string]interface{}{
 			"name":    s.name,
-			"version": "1.0.0",
+			"version": serverVersion,
 		},
 	}, nil
 }
@@ -646,6 +1157,8 @@ func (s *serverImpl) ProcessInitialize(ctx *Context) (interface{}, error) {
 // The ctx parameter contains the shutdown request. The method returns a simple
 // response indicating whether the shutdown was initiated successfully.
 func (s *serverImpl) ProcessShutdown(ctx *Context) (interface{}, error) {
+	s.emitSessionEnded(ctx.sessionID(), "shutdown")
+
 	// TODO: Implement proper shutdown handling
 	go func() {
 		s.logger.Info("shutdown requested, will exit soon")
@@ -660,7 +1173,7 @@ func (s *serverImpl) ProcessShutdown(ctx *Context) (interface{}, error) {
 //
 // This method initializes the server's transport, sets up message handling,
 // and begins processing client requests. It blocks until an error occurs or
-// the server is explicitly stopped.
+// the server is stopped with Shutdown, after which it returns nil.
 //
 // Run returns an error if the server fails to start or encounters a fatal error
 // during operation. Common error scenarios include transport initialization failure
@@ -683,7 +1196,7 @@ func (s *serverImpl) Run() error {
 	s.mu.RUnlock()
 
 	if t == nil {
-		return fmt.Errorf("no transport configured, use AsStdio(), AsWebsocket(), AsSSE(), or AsHTTP()")
+		return fmt.Errorf("no transport configured, use AsStdio(), AsWebsocket(), AsSSE(), AsStreamableHTTP(), or AsHTTP()")
 	}
 
 	// Initialize the request tracker
@@ -704,16 +1217,53 @@ func (s *serverImpl) Run() error {
 		return fmt.Errorf("failed to initialize transport: %w", err)
 	}
 
+	// Check tool dependencies before clients can list the tools
+	s.startProbes()
+
+	// Drop persisted state that has expired while the server was down
+	s.prunePersisted()
+
+	// Ping idle sessions and close the ones whose client went away
+	s.startKeepalive()
+
+	// Remove uploads as they expire
+	s.startUploads()
+
+	// Keep the resources of watched directories in step with their files
+	s.startWatching()
+
+	// Let sessions started on other replicas continue here
+	s.shareSessions(t)
+
+	// Forget the connections clients close
+	s.watchConnections(t)
+
+	// Require tokens on HTTP endpoints if requested
+	s.protectEndpoints(t)
+
 	// Start the transport
 	if err := t.Start(); err != nil {
 		return fmt.Errorf("failed to start transport: %w", err)
 	}
 
 	s.logger.Info("server started", "name", s.name, "transport", fmt.Sprintf("%T", t))
+	s.emitEvent(webhook.EventServerStarted, map[string]interface{}{
+		"name":      s.name,
+		"transport": fmt.Sprintf("%T", t),
+	})
 
-	// Block until the transport is done
-	// TODO: Implement proper shutdown handling
-	select {}
+	// Advertise on the local network if requested
+	s.startMDNS(t)
+
+	// Serve debug endpoints on their own listener if requested
+	s.startAdmin()
+
+	// Shed load as memory runs short if requested
+	s.startMemoryMonitor()
+
+	// Block until Shutdown has stopped the server
+	<-s.stopped
+	return nil
 }
 
 // GetServer returns the underlying server implementation
@@ -737,31 +1287,58 @@ func (s *serverImpl) sendNotification(method string, params interface{}) {
 		return
 	}
 
-	notification := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"method":  method,
-	}
-
-	if params != nil {
-		notification["params"] = params
-	}
-
-	// Convert to JSON
-	message, err := json.Marshal(notification)
+	message, err := notificationMessage(method, params)
 	if err != nil {
 		s.logger.Error("failed to marshal notification", "error", err)
 		return
 	}
 
 	// Send the notification
-	if err := s.transport.Send(message); err != nil {
+	if err := s.sendMessage(message); err != nil {
+		s.metrics.notificationFailed(method)
 		s.logger.Error("failed to send notification", "error", err)
 	}
 }
 
+// sendNotificationTo sends a notification to the client on one connection
+// of a transport that serves several.
+func (s *serverImpl) sendNotificationTo(sender transport.SessionSender, connectionID, method string, params interface{}) error {
+	message, err := notificationMessage(method, params)
+	if err != nil {
+		return err
+	}
+	if err := sender.SendTo(connectionID, message); err != nil {
+		s.metrics.notificationFailed(method)
+		return err
+	}
+	s.metrics.countBytes(s.transportLabel(), "out", len(message))
+	return nil
+}
+
+// notificationMethod returns the method of an encoded notification.
+func notificationMethod(message []byte) string {
+	var notification struct {
+		Method string `json:"method"`
+	}
+	json.Unmarshal(message, &notification)
+	return notification.Method
+}
+
+// notificationMessage encodes a JSON-RPC notification.
+func notificationMessage(method string, params interface{}) ([]byte, error) {
+	notification := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+	}
+	if params != nil {
+		notification["params"] = params
+	}
+	return json.Marshal(notification)
+}
+
 // handleInitializedNotification processes the initialized notification from the client
 // and sends any pending notifications that were queued during the initialization phase.
-func (s *serverImpl) handleInitializedNotification() {
+func (s *serverImpl) handleInitializedNotification(ctx *Context) {
 	s.mu.Lock()
 	s.initialized = true
 
@@ -779,12 +1356,16 @@ func (s *serverImpl) handleInitializedNotification() {
 	// Send any pending notifications
 	for _, notification := range pendingNotifications {
 		if s.transport != nil {
-			if err := s.transport.Send(notification); err != nil {
+			if err := s.sendMessage(notification); err != nil {
+				s.metrics.notificationFailed(notificationMethod(notification))
 				s.logger.Error("failed to send pending notification after initialization", "error", err)
 			}
 		}
 	}
 
+	// Answer requests that were held until the handshake completed
+	s.completeHandshake(ctx.connectionID)
+
 	// Always send tools/list_changed notification after initialization
 	// This ensures the client is aware of available tools even if no tools were
 	// added since the server started
@@ -1,10 +1,22 @@
 package server
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
 
+// ErrSessionNotFound is returned when an operation references an unknown session.
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrSubscriptionLimitReached is returned when a session already holds the
+// maximum number of resource subscriptions allowed by the server.
+var ErrSubscriptionLimitReached = errors.New("resource subscription limit reached")
+
 // SessionID is a unique identifier for a client session.
 // It's used to track and manage individual client connections to the server.
 type SessionID string
@@ -20,6 +32,14 @@ type ClientSession struct {
 	LastActive      time.Time         // Last time the session was active
 	ProtocolVersion string            // Negotiated protocol version
 	Metadata        map[string]string // Additional session metadata
+	Subscriptions   map[string]bool   // Resource URIs the client has subscribed to
+	Pinned          []string          // Resource URIs pinned to the session by handlers, in pin order
+	ConnectionID    string            // Transport connection the session runs over, if the transport serves several
+	RTT             time.Duration     // Round-trip time of the last keepalive ping the client answered, if any
+
+	// initialized is set for sessions created by initialize, as opposed to
+	// the placeholder session a server starts with.
+	initialized bool
 }
 
 // SessionManager manages client sessions.
@@ -29,6 +49,9 @@ type SessionManager struct {
 	mu       sync.RWMutex
 	sessions map[SessionID]*ClientSession
 	nextID   int64
+
+	// connections indexes sessions by their transport connection
+	connections map[string]SessionID
 }
 
 // NewSessionManager creates a new session manager.
@@ -38,7 +61,8 @@ type SessionManager struct {
 //   - A new SessionManager instance ready for use
 func NewSessionManager() *SessionManager {
 	return &SessionManager{
-		sessions: make(map[SessionID]*ClientSession),
+		sessions:    make(map[SessionID]*ClientSession),
+		connections: make(map[string]SessionID),
 	}
 }
 
@@ -68,6 +92,7 @@ func (sm *SessionManager) CreateSession(clientInfo ClientInfo, protocolVersion s
 		LastActive:      time.Now(),
 		ProtocolVersion: protocolVersion,
 		Metadata:        make(map[string]string),
+		Subscriptions:   make(map[string]bool),
 	}
 
 	// Store the session
@@ -121,6 +146,38 @@ func (sm *SessionManager) UpdateSession(id SessionID, update func(*ClientSession
 	return true
 }
 
+// Count returns the number of open sessions.
+func (sm *SessionManager) Count() int {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return len(sm.sessions)
+}
+
+// Sessions returns copies of all open sessions, oldest first.
+func (sm *SessionManager) Sessions() []ClientSession {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	sessions := make([]ClientSession, 0, len(sm.sessions))
+	for _, session := range sm.sessions {
+		copied := *session
+		copied.Metadata = make(map[string]string, len(session.Metadata))
+		for key, value := range session.Metadata {
+			copied.Metadata[key] = value
+		}
+		copied.Subscriptions = make(map[string]bool, len(session.Subscriptions))
+		for uri, subscribed := range session.Subscriptions {
+			copied.Subscriptions[uri] = subscribed
+		}
+		copied.Pinned = append([]string(nil), session.Pinned...)
+		sessions = append(sessions, copied)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Created.Before(sessions[j].Created)
+	})
+	return sessions
+}
+
 // CloseSession removes a session.
 // This method deletes a client session from the session manager,
 // typically called when a client disconnects or times out.
@@ -134,15 +191,237 @@ func (sm *SessionManager) CloseSession(id SessionID) bool {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
-	_, exists := sm.sessions[id]
+	session, exists := sm.sessions[id]
 	if !exists {
 		return false
 	}
 
+	if session.ConnectionID != "" && sm.connections[session.ConnectionID] == id {
+		delete(sm.connections, session.ConnectionID)
+	}
 	delete(sm.sessions, id)
 	return true
 }
 
+// setRTT records the round-trip time of a keepalive ping. It leaves the
+// session's last active time alone, since the server sent the ping.
+func (sm *SessionManager) setRTT(id SessionID, rtt time.Duration) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if session, exists := sm.sessions[id]; exists {
+		session.RTT = rtt
+	}
+}
+
+// touch records that a session's client was just heard from.
+func (sm *SessionManager) touch(id SessionID) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if session, exists := sm.sessions[id]; exists {
+		session.LastActive = time.Now()
+	}
+}
+
+// BindConnection records the transport connection a session runs over, so
+// that later requests on the connection are attributed to the session. A
+// connection re-initialized by its client moves to the new session.
+//
+// Parameters:
+//   - id: The unique identifier of the session
+//   - connectionID: The connection ID the transport set in the request's _meta
+//
+// Returns:
+//   - A boolean indicating whether the session exists
+func (sm *SessionManager) BindConnection(id SessionID, connectionID string) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	session, exists := sm.sessions[id]
+	if !exists {
+		return false
+	}
+	if previous, ok := sm.sessions[sm.connections[connectionID]]; ok && previous != session {
+		previous.ConnectionID = ""
+	}
+	session.ConnectionID = connectionID
+	sm.connections[connectionID] = id
+	return true
+}
+
+// SessionForConnection returns the session bound to a transport connection.
+//
+// Parameters:
+//   - connectionID: The connection ID the transport set in the request's _meta
+//
+// Returns:
+//   - The ClientSession if one is bound to the connection
+//   - A boolean indicating whether one was found
+func (sm *SessionManager) SessionForConnection(connectionID string) (*ClientSession, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	session, exists := sm.sessions[sm.connections[connectionID]]
+	return session, exists
+}
+
+// Subscribe records that a session wants notifications for a resource URI.
+// Subscribing to a URI the session is already subscribed to is a no-op and
+// does not count against the limit.
+//
+// Parameters:
+//   - id: The unique identifier of the subscribing session
+//   - uri: The resource URI to subscribe to
+//   - limit: The maximum number of subscriptions per session, 0 for no limit
+//
+// Returns:
+//   - ErrSessionNotFound if the session does not exist
+//   - ErrSubscriptionLimitReached if the session is at its subscription limit
+func (sm *SessionManager) Subscribe(id SessionID, uri string, limit int) error {
+	var err error
+	found := sm.UpdateSession(id, func(session *ClientSession) {
+		if session.Subscriptions == nil {
+			session.Subscriptions = make(map[string]bool)
+		}
+		if session.Subscriptions[uri] {
+			return
+		}
+		if limit > 0 && len(session.Subscriptions) >= limit {
+			err = ErrSubscriptionLimitReached
+			return
+		}
+		session.Subscriptions[uri] = true
+	})
+	if !found {
+		return ErrSessionNotFound
+	}
+	return err
+}
+
+// Unsubscribe removes a session's subscription to a resource URI.
+//
+// Parameters:
+//   - id: The unique identifier of the session
+//   - uri: The resource URI to unsubscribe from
+//
+// Returns:
+//   - A boolean indicating whether the session was subscribed to the URI
+func (sm *SessionManager) Unsubscribe(id SessionID, uri string) bool {
+	var removed bool
+	sm.UpdateSession(id, func(session *ClientSession) {
+		if session.Subscriptions[uri] {
+			delete(session.Subscriptions, uri)
+			removed = true
+		}
+	})
+	return removed
+}
+
+// SubscribedSessions returns the IDs of all sessions subscribed to a resource URI.
+//
+// Parameters:
+//   - uri: The resource URI to look up
+//
+// Returns:
+//   - A slice of session IDs, empty if nobody is subscribed
+func (sm *SessionManager) SubscribedSessions(uri string) []SessionID {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	var ids []SessionID
+	for id, session := range sm.sessions {
+		if session.Subscriptions[uri] {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// subscriberConnections returns the connections of the sessions subscribed
+// to a resource URI, and whether any subscribed session has no connection.
+func (sm *SessionManager) subscriberConnections(uri string) (connections []string, unbound bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	for _, session := range sm.sessions {
+		if !session.Subscriptions[uri] {
+			continue
+		}
+		if session.ConnectionID == "" {
+			unbound = true
+		} else {
+			connections = append(connections, session.ConnectionID)
+		}
+	}
+	return connections, unbound
+}
+
+// Pin attaches a resource URI to a session's pinned context.
+// Pinning a URI that is already pinned is a no-op.
+//
+// Parameters:
+//   - id: The unique identifier of the session
+//   - uri: The resource URI to pin
+//
+// Returns:
+//   - A boolean indicating whether the URI was newly pinned
+//   - ErrSessionNotFound if the session does not exist
+func (sm *SessionManager) Pin(id SessionID, uri string) (bool, error) {
+	var added bool
+	found := sm.UpdateSession(id, func(session *ClientSession) {
+		for _, pinned := range session.Pinned {
+			if pinned == uri {
+				return
+			}
+		}
+		session.Pinned = append(session.Pinned, uri)
+		added = true
+	})
+	if !found {
+		return false, ErrSessionNotFound
+	}
+	return added, nil
+}
+
+// Unpin removes a resource URI from a session's pinned context.
+//
+// Parameters:
+//   - id: The unique identifier of the session
+//   - uri: The resource URI to unpin
+//
+// Returns:
+//   - A boolean indicating whether the URI was pinned
+func (sm *SessionManager) Unpin(id SessionID, uri string) bool {
+	var removed bool
+	sm.UpdateSession(id, func(session *ClientSession) {
+		for i, pinned := range session.Pinned {
+			if pinned == uri {
+				session.Pinned = append(session.Pinned[:i], session.Pinned[i+1:]...)
+				removed = true
+				return
+			}
+		}
+	})
+	return removed
+}
+
+// PinnedResources returns the resource URIs pinned to a session, in pin order.
+//
+// Parameters:
+//   - id: The unique identifier of the session
+//
+// Returns:
+//   - A copy of the pinned URIs, empty if the session has none or does not exist
+func (sm *SessionManager) PinnedResources(id SessionID) []string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	session, exists := sm.sessions[id]
+	if !exists {
+		return nil
+	}
+	return append([]string(nil), session.Pinned...)
+}
+
 // DetectClientCapabilities infers client capabilities from the protocol version.
 // This function analyzes the protocol version to determine which features
 // and content types the client is likely to support, particularly for sampling operations.
@@ -196,8 +475,8 @@ func (sm *SessionManager) UpdateClientCapabilities(id SessionID, caps SamplingCa
 }
 
 // generateUniqueID creates a unique session identifier.
-// This is a simplified implementation that combines the current timestamp
-// with a sequence number to create reasonably unique identifiers.
+// It combines the current timestamp and a sequence number, which keep IDs
+// unique and roughly ordered, with random bits that make them unguessable.
 //
 // Parameters:
 //   - id: A sequence number to incorporate into the ID
@@ -205,7 +484,10 @@ func (sm *SessionManager) UpdateClientCapabilities(id SessionID, caps SamplingCa
 // Returns:
 //   - A string containing the unique session identifier
 func generateUniqueID(id int64) string {
-	// In a real implementation, this would generate a secure random ID
-	// For this example, we'll just use a simple string representation
-	return time.Now().Format("20060102150405") + "-" + time.Now().Format("000000000") + "-" + time.Now().Format("000000000000000")
+	var random [8]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		// crypto/rand does not fail on supported platforms
+		panic(err)
+	}
+	return fmt.Sprintf("%s-%09d-%s", time.Now().Format("20060102150405"), id, hex.EncodeToString(random[:]))
 }
@@ -0,0 +1,330 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/localrivet/gomcp/transport"
+)
+
+// sessionStoreTimeout bounds the store calls made while handling a request.
+const sessionStoreTimeout = 5 * time.Second
+
+// SessionRecord is the state of a session kept in a SessionStore: enough to
+// resume the session on another replica without the client initializing
+// again.
+type SessionRecord struct {
+	// ID is the connection ID the client presents, such as its
+	// Mcp-Session-Id on Streamable HTTP or sessionId on SSE.
+	ID string `json:"id"`
+
+	// SessionID is the server's ID for the session, kept across replicas.
+	SessionID string `json:"sessionId"`
+
+	ProtocolVersion string                 `json:"protocolVersion"`
+	ClientName      string                 `json:"clientName,omitempty"`
+	ClientVersion   string                 `json:"clientVersion,omitempty"`
+	Capabilities    map[string]interface{} `json:"capabilities,omitempty"`
+
+	// Metadata holds the client's hints and the values handlers set with
+	// Context.SetSessionValue.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	Subscriptions []string  `json:"subscriptions,omitempty"`
+	Pinned        []string  `json:"pinned,omitempty"`
+	Created       time.Time `json:"created"`
+	Updated       time.Time `json:"updated"`
+}
+
+// SessionStore keeps session state outside the server process, so servers
+// behind a load balancer can resume each other's sessions. Implementations
+// must be safe for concurrent use.
+type SessionStore interface {
+	// Get returns the record with the given ID, or ErrSessionNotFound.
+	Get(ctx context.Context, id string) (*SessionRecord, error)
+
+	// Put creates or replaces a record.
+	Put(ctx context.Context, record *SessionRecord) error
+
+	// Delete removes a record. Deleting a missing record is not an error.
+	Delete(ctx context.Context, id string) error
+
+	// List returns all records, ordered by ID.
+	List(ctx context.Context) ([]*SessionRecord, error)
+}
+
+// MemorySessionStore is a SessionStore that keeps records in process
+// memory. It lets a single server resume sessions its transport has
+// forgotten, and is useful in tests.
+type MemorySessionStore struct {
+	mu      sync.RWMutex
+	records map[string][]byte
+}
+
+// NewMemorySessionStore creates an empty in-memory session store.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{records: make(map[string][]byte)}
+}
+
+// Get implements SessionStore.
+func (m *MemorySessionStore) Get(ctx context.Context, id string) (*SessionRecord, error) {
+	m.mu.RLock()
+	data, ok := m.records[id]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return decodeSessionRecord(data)
+}
+
+// Put implements SessionStore.
+func (m *MemorySessionStore) Put(ctx context.Context, record *SessionRecord) error {
+	// Records are kept encoded so callers can't change them in place
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[record.ID] = data
+	return nil
+}
+
+// Delete implements SessionStore.
+func (m *MemorySessionStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, id)
+	return nil
+}
+
+// List implements SessionStore.
+func (m *MemorySessionStore) List(ctx context.Context) ([]*SessionRecord, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	records := make([]*SessionRecord, 0, len(m.records))
+	for _, data := range m.records {
+		record, err := decodeSessionRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records, nil
+}
+
+// decodeSessionRecord decodes a record encoded as JSON.
+func decodeSessionRecord(data []byte) (*SessionRecord, error) {
+	var record SessionRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// WithSessionStore keeps the state of sessions on the SSE and Streamable
+// HTTP transports in store, so that with a shared store such as
+// RedisSessionStore, requests on a session can reach any replica of the
+// server behind a load balancer. A replica that receives a request for a
+// session it doesn't know loads the session from the store and carries on
+// with the negotiated protocol version, the client's capabilities, its
+// subscriptions and pinned resources and the values set with
+// Context.SetSessionValue.
+//
+// Records are saved at initialize and when their state changes, and deleted
+// when the client ends the session or keepalive closes it. Store failures
+// are logged; the session continues on the replica that has it.
+//
+// Server-to-client messages still leave from the replica holding the
+// client's stream, so subscription notifications only reach clients
+// connected to the replica that sends them.
+//
+// Example:
+//
+//	redisClient := redis.NewClient(&redis.Options{Addr: "redis:6379"})
+//	srv := server.NewServer("my-service",
+//	    server.WithSessionStore(server.NewRedisSessionStore(redisClient, "mcp:", 24*time.Hour)),
+//	).AsStreamableHTTP(":8080")
+func WithSessionStore(store SessionStore) Option {
+	return func(s *serverImpl) {
+		s.sessionStore = store
+	}
+}
+
+// shareSessions closes the sessions clients of the transport end, and lets
+// the transport resume sessions from the session store.
+func (s *serverImpl) shareSessions(t transport.Transport) {
+	resumer, ok := t.(transport.SessionResumer)
+	if !ok {
+		return
+	}
+	hooks := transport.SessionHooks{
+		Ended: s.connectionClosed,
+	}
+	if s.sessionStore != nil {
+		hooks.Resolve = func(connectionID string) bool {
+			return s.resumeSession(context.Background(), connectionID) != nil
+		}
+	}
+	resumer.SetSessionHooks(hooks)
+}
+
+// saveSession writes a session bound to a connection to the session store.
+func (s *serverImpl) saveSession(id SessionID) {
+	if s.sessionStore == nil {
+		return
+	}
+
+	// Copy under the manager's lock, as handlers may be changing it
+	var record *SessionRecord
+	s.sessionManager.UpdateSession(id, func(session *ClientSession) {
+		record = newSessionRecord(session)
+	})
+	if record == nil || record.ID == "" {
+		// Sessions without a connection can't be resumed elsewhere
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sessionStoreTimeout)
+	defer cancel()
+	if err := s.sessionStore.Put(ctx, record); err != nil {
+		s.logger.Error("failed to save session", "sessionID", record.SessionID, "error", err)
+	}
+}
+
+// forgetSession deletes a session's record from the session store.
+func (s *serverImpl) forgetSession(connectionID string) {
+	if s.sessionStore == nil || connectionID == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sessionStoreTimeout)
+	defer cancel()
+	if err := s.sessionStore.Delete(ctx, connectionID); err != nil {
+		s.logger.Error("failed to delete session", "connectionID", connectionID, "error", err)
+	}
+}
+
+// resumeSession loads the session of a connection this server doesn't know
+// from the session store, returning nil if the store doesn't have it.
+func (s *serverImpl) resumeSession(ctx context.Context, connectionID string) *ClientSession {
+	if s.sessionStore == nil || connectionID == "" {
+		return nil
+	}
+	if session, ok := s.sessionManager.SessionForConnection(connectionID); ok {
+		return session
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sessionStoreTimeout)
+	defer cancel()
+	record, err := s.sessionStore.Get(ctx, connectionID)
+	if err != nil {
+		if !errors.Is(err, ErrSessionNotFound) {
+			s.logger.Error("failed to load session", "connectionID", connectionID, "error", err)
+		}
+		return nil
+	}
+
+	session := s.sessionManager.restore(record)
+	s.logger.Info("resumed session", "sessionID", string(session.ID), "protocolVersion", session.ProtocolVersion)
+	return session
+}
+
+// newSessionRecord captures the state of a session.
+func newSessionRecord(session *ClientSession) *SessionRecord {
+	record := &SessionRecord{
+		ID:              session.ConnectionID,
+		SessionID:       string(session.ID),
+		ProtocolVersion: session.ProtocolVersion,
+		ClientName:      session.ClientInfo.Name,
+		ClientVersion:   session.ClientInfo.Version,
+		Capabilities:    session.ClientInfo.Capabilities,
+		Pinned:          append([]string(nil), session.Pinned...),
+		Created:         session.Created,
+		Updated:         time.Now(),
+	}
+	if len(session.Metadata) > 0 {
+		record.Metadata = make(map[string]string, len(session.Metadata))
+		for key, value := range session.Metadata {
+			record.Metadata[key] = value
+		}
+	}
+	for uri, subscribed := range session.Subscriptions {
+		if subscribed {
+			record.Subscriptions = append(record.Subscriptions, uri)
+		}
+	}
+	sort.Strings(record.Subscriptions)
+	return record
+}
+
+// restore adds a session loaded from a SessionStore and binds it to its
+// connection. If the connection already has a session, that one is kept.
+func (sm *SessionManager) restore(record *SessionRecord) *ClientSession {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if existing, ok := sm.sessions[sm.connections[record.ID]]; ok {
+		return existing
+	}
+
+	samplingCaps := DetectClientCapabilities(record.ProtocolVersion)
+	session := &ClientSession{
+		ID: SessionID(record.SessionID),
+		ClientInfo: ClientInfo{
+			Name:              record.ClientName,
+			Version:           record.ClientVersion,
+			SamplingSupported: samplingCaps.Supported,
+			SamplingCaps:      samplingCaps,
+			ProtocolVersion:   record.ProtocolVersion,
+			Capabilities:      record.Capabilities,
+		},
+		Created:         record.Created,
+		LastActive:      time.Now(),
+		ProtocolVersion: record.ProtocolVersion,
+		Metadata:        make(map[string]string, len(record.Metadata)),
+		Subscriptions:   make(map[string]bool, len(record.Subscriptions)),
+		Pinned:          append([]string(nil), record.Pinned...),
+		ConnectionID:    record.ID,
+		initialized:     true,
+	}
+	for key, value := range record.Metadata {
+		session.Metadata[key] = value
+	}
+	for _, uri := range record.Subscriptions {
+		session.Subscriptions[uri] = true
+	}
+	sm.sessions[session.ID] = session
+	sm.connections[record.ID] = session.ID
+	return session
+}
+
+// SetSessionValue stores a value on the client's session, where later
+// requests of the session read it with SessionValue. With WithSessionStore,
+// values are saved with the session and survive it moving to another
+// replica.
+//
+// Example:
+//
+//	ctx.SetSessionValue("workspace", args.Workspace)
+func (c *Context) SetSessionValue(key, value string) {
+	if c.server == nil {
+		return
+	}
+	id := c.sessionID()
+	if c.server.sessionManager.UpdateSession(id, func(session *ClientSession) {
+		session.Metadata[key] = value
+	}) {
+		c.server.saveSession(id)
+	}
+}
+
+// SessionValue returns a value stored on the client's session with
+// SetSessionValue, or "" if none was.
+func (c *Context) SessionValue(key string) string {
+	return c.sessionHint(key)
+}
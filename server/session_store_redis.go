@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisSessionStore is a SessionStore that keeps records in Redis, so every
+// replica of a server sharing the Redis instance can resume the sessions of
+// the others.
+type RedisSessionStore struct {
+	client redis.Cmdable
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisSessionStore creates a session store that keeps records in Redis
+// under keys starting with prefix. Records expire ttl after they were last
+// saved, or never if ttl is zero. The client may be a *redis.Client, a
+// *redis.ClusterClient or any other redis.Cmdable.
+func NewRedisSessionStore(client redis.Cmdable, prefix string, ttl time.Duration) *RedisSessionStore {
+	return &RedisSessionStore{client: client, prefix: prefix + "session:", ttl: ttl}
+}
+
+// Get implements SessionStore.
+func (r *RedisSessionStore) Get(ctx context.Context, id string) (*SessionRecord, error) {
+	data, err := r.client.Get(ctx, r.prefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("session lookup failed: %w", err)
+	}
+	return decodeSessionRecord(data)
+}
+
+// Put implements SessionStore.
+func (r *RedisSessionStore) Put(ctx context.Context, record *SessionRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := r.client.Set(ctx, r.prefix+record.ID, data, r.ttl).Err(); err != nil {
+		return fmt.Errorf("session save failed: %w", err)
+	}
+	return nil
+}
+
+// Delete implements SessionStore.
+func (r *RedisSessionStore) Delete(ctx context.Context, id string) error {
+	if err := r.client.Del(ctx, r.prefix+id).Err(); err != nil {
+		return fmt.Errorf("session delete failed: %w", err)
+	}
+	return nil
+}
+
+// List implements SessionStore. It scans the keys under the store's
+// prefix, so it suits occasional use such as admin pages rather than the
+// request path.
+func (r *RedisSessionStore) List(ctx context.Context) ([]*SessionRecord, error) {
+	var records []*SessionRecord
+	iter := r.client.Scan(ctx, 0, r.prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		data, err := r.client.Get(ctx, iter.Val()).Bytes()
+		if errors.Is(err, redis.Nil) {
+			// Expired since the scan found it
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("session list failed: %w", err)
+		}
+		record, err := decodeSessionRecord(data)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("session list failed: %w", err)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records, nil
+}
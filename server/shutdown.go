@@ -0,0 +1,210 @@
+package server
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/localrivet/gomcp/protocol"
+)
+
+// ShuttingDownCode is the JSON-RPC error code of requests refused because
+// the server is shutting down. The gRPC transport maps it to Unavailable.
+const ShuttingDownCode = protocol.ShuttingDown
+
+// ShutdownEventType marks the data of the notifications/message that
+// Shutdown sends, in its "event" field, so clients can tell it apart from
+// other log messages.
+const ShutdownEventType = "shutdown"
+
+// ShutdownNotice is what connected clients are told when the server shuts
+// down, so hosts can say why instead of reporting a lost connection.
+type ShutdownNotice struct {
+	// Reason is a short machine-readable reason, such as "upgrade" or
+	// "maintenance". The default is "shutdown".
+	Reason string
+
+	// Message is shown to users. The default is "server shutting down".
+	Message string
+
+	// ReconnectAfter is how long clients should wait before reconnecting,
+	// when the server expects to be back. Zero gives no hint.
+	ReconnectAfter time.Duration
+}
+
+// data returns the notice as the data of a notifications/message.
+func (n ShutdownNotice) data() map[string]interface{} {
+	if n.Reason == "" {
+		n.Reason = "shutdown"
+	}
+	if n.Message == "" {
+		n.Message = "server shutting down"
+	}
+	data := map[string]interface{}{
+		"event":   ShutdownEventType,
+		"reason":  n.Reason,
+		"message": n.Message,
+	}
+	if n.ReconnectAfter > 0 {
+		data["reconnectAfter"] = int(math.Ceil(n.ReconnectAfter.Seconds()))
+	}
+	return data
+}
+
+// requestDrain counts requests in progress so Shutdown can wait for them.
+type requestDrain struct {
+	mu      sync.Mutex
+	active  int
+	closing bool
+	idle    chan struct{}
+}
+
+// begin counts a request in, or reports false once the drain has started.
+func (d *requestDrain) begin() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closing {
+		return false
+	}
+	d.active++
+	return true
+}
+
+// end counts a request out.
+func (d *requestDrain) end() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.active--
+	if d.closing && d.active == 0 {
+		close(d.idle)
+	}
+}
+
+// close refuses further requests and returns a channel closed once those in
+// progress have ended, with how many there are.
+func (d *requestDrain) close() (<-chan struct{}, int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.closing {
+		d.closing = true
+		d.idle = make(chan struct{})
+		if d.active == 0 {
+			close(d.idle)
+		}
+	}
+	return d.idle, d.active
+}
+
+// inFlight returns the number of requests in progress.
+func (d *requestDrain) inFlight() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.active
+}
+
+// Shutdown stops the server gracefully. It refuses new requests, including
+// initialize, with a ShuttingDownCode error, tells connected clients the
+// server is going away with a notifications/message of level "notice", and
+// waits for requests in progress, such as running tool handlers, to finish.
+// Then it stops the admin listener, mDNS advertising and memory monitoring,
+// ends every session, closes the transport and makes Run return.
+//
+// If ctx ends before the requests in progress do, Shutdown stops waiting,
+// shuts the rest down anyway and returns ctx's error; the responses of
+// unfinished requests are lost. Calling Shutdown again waits for the first
+// call to finish.
+//
+// Example:
+//
+//	go func() {
+//	    if err := srv.Run(); err != nil {
+//	        log.Fatal(err)
+//	    }
+//	}()
+//
+//	stop := make(chan os.Signal, 1)
+//	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+//	<-stop
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	if err := srv.Shutdown(ctx); err != nil {
+//	    log.Printf("shutdown: %v", err)
+//	}
+func (s *serverImpl) Shutdown(ctx context.Context) error {
+	return s.ShutdownWithNotice(ctx, ShutdownNotice{})
+}
+
+// ShutdownWithNotice stops the server gracefully like Shutdown, telling
+// connected clients why and when to reconnect. The notice is sent as the
+// data of the notifications/message, with "event" set to
+// ShutdownEventType and reconnectAfter in seconds.
+//
+// Example:
+//
+//	srv.ShutdownWithNotice(ctx, server.ShutdownNotice{
+//	    Reason:         "upgrade",
+//	    Message:        "Restarting for an upgrade",
+//	    ReconnectAfter: 10 * time.Second,
+//	})
+func (s *serverImpl) ShutdownWithNotice(ctx context.Context, notice ShutdownNotice) error {
+	first := false
+	s.shutdownOnce.Do(func() { first = true })
+	if !first {
+		select {
+		case <-s.stopped:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	idle, inFlight := s.drain.close()
+	s.logger.Info("shutting down", "inFlight", inFlight, "reason", notice.Reason)
+	s.sendNotification("notifications/message", map[string]interface{}{
+		"level":  "notice",
+		"logger": "server",
+		"data":   notice.data(),
+	})
+
+	var err error
+	select {
+	case <-idle:
+	case <-ctx.Done():
+		err = ctx.Err()
+		s.logger.Warn("shutdown deadline passed with requests in progress", "inFlight", s.drain.inFlight())
+	}
+
+	s.mu.Lock()
+	admin, responder, t := s.adminServer, s.mdnsResponder, s.transport
+	s.adminServer, s.mdnsResponder = nil, nil
+	s.mu.Unlock()
+
+	if admin != nil {
+		if shutdownErr := admin.Shutdown(ctx); shutdownErr != nil {
+			admin.Close()
+		}
+	}
+	if responder != nil {
+		responder.Close()
+	}
+	s.memory.close()
+
+	for _, session := range s.sessionManager.Sessions() {
+		if s.defaultSession != nil && session.ID == s.defaultSession.ID {
+			continue
+		}
+		s.emitSessionEnded(session.ID, "shutdown")
+	}
+
+	if t != nil {
+		if stopErr := t.Stop(); stopErr != nil {
+			s.logger.Error("failed to stop transport", "error", stopErr)
+		}
+	}
+
+	s.logger.Info("server stopped", "name", s.name)
+	close(s.stopped)
+	return err
+}
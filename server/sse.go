@@ -27,6 +27,9 @@ import (
 //
 //	// With custom path options
 //	server.AsSSE(":8080", sse.SSE.WithPathPrefix("/api/v1"), sse.SSE.WithEventsPath("/events"))
+//
+//	// Serving thousands of mostly idle clients from a shared event loop
+//	server.AsSSE(":8080", sse.SSE.WithEventLoop(eventloop.Config{}))
 func (s *serverImpl) AsSSE(address string, options ...sse.Option) Server {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -41,6 +44,7 @@ func (s *serverImpl) AsSSE(address string, options ...sse.Option) Server {
 
 	// Configure the message handler
 	sseTransport.SetMessageHandler(s.handleMessage)
+	s.registerDiscoveryHandler(sseTransport)
 
 	// Set as the server's transport
 	s.transport = sseTransport
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// stateFormatVersion is the version of the snapshots written by
+// ExportState. ImportState reads snapshots up to this version.
+const stateFormatVersion = 1
+
+// stateSnapshot is the document written by ExportState.
+type stateSnapshot struct {
+	Version  int                          `json:"version"`
+	Server   string                       `json:"server"`
+	Exported time.Time                    `json:"exported"`
+	Prompts  []promptFile                 `json:"prompts,omitempty"`
+	Store    map[string]map[string][]byte `json:"store,omitempty"`
+}
+
+// persistedBuckets are the buckets of the persistence store that snapshots
+// carry.
+var persistedBuckets = []string{idempotencyBucket, auditBucket}
+
+// ExportState writes a snapshot of the server's state to w as versioned
+// JSON, for moving it to another instance with ImportState during a
+// blue/green deploy or keeping it for disaster recovery.
+//
+// The snapshot holds the registered prompts and, with WithPersistence, the
+// stored idempotent tool results and audit trail. Tools and resources are
+// served by handlers in code, so the new instance registers them itself.
+//
+// Example:
+//
+//	f, err := os.Create("state.json")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer f.Close()
+//	if err := srv.ExportState(f); err != nil {
+//	    log.Fatal(err)
+//	}
+func (s *serverImpl) ExportState(w io.Writer) error {
+	snapshot := stateSnapshot{
+		Version:  stateFormatVersion,
+		Server:   s.name,
+		Exported: time.Now().UTC(),
+	}
+
+	s.mu.RLock()
+	for _, prompt := range s.prompts {
+		snapshot.Prompts = append(snapshot.Prompts, promptToFile(prompt))
+	}
+	s.mu.RUnlock()
+	sort.Slice(snapshot.Prompts, func(i, j int) bool { return snapshot.Prompts[i].Name < snapshot.Prompts[j].Name })
+
+	if s.persistence != nil {
+		snapshot.Store = make(map[string]map[string][]byte)
+		for _, bucket := range persistedBuckets {
+			values := make(map[string][]byte)
+			err := s.persistence.Scan(bucket, func(key string, value []byte) bool {
+				values[key] = value
+				return true
+			})
+			if err != nil {
+				return fmt.Errorf("failed to read %s store: %w", bucket, err)
+			}
+			if len(values) > 0 {
+				snapshot.Store[bucket] = values
+			}
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(snapshot); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return nil
+}
+
+// ImportState restores a snapshot written by ExportState. Prompts in the
+// snapshot replace registered prompts of the same name, and stored entries
+// are added to the persistence store. Stored entries are skipped without
+// WithPersistence.
+//
+// Snapshots written by a newer version of the server are rejected, so that
+// state isn't silently dropped.
+func (s *serverImpl) ImportState(r io.Reader) error {
+	var snapshot stateSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return fmt.Errorf("failed to read state: %w", err)
+	}
+	if snapshot.Version < 1 || snapshot.Version > stateFormatVersion {
+		return fmt.Errorf("unsupported state version %d", snapshot.Version)
+	}
+
+	for _, file := range snapshot.Prompts {
+		if err := validatePromptRoles(file.Messages); err != nil {
+			return fmt.Errorf("invalid prompt %s: %w", file.Name, err)
+		}
+	}
+	for _, file := range snapshot.Prompts {
+		s.registerPromptFile(file)
+	}
+
+	if len(snapshot.Store) > 0 && s.persistence == nil {
+		s.logger.Warn("skipping stored state without persistence", "buckets", len(snapshot.Store))
+		return nil
+	}
+	for _, bucket := range persistedBuckets {
+		for key, value := range snapshot.Store[bucket] {
+			if err := s.persistence.Put(bucket, key, value); err != nil {
+				return fmt.Errorf("failed to restore %s store: %w", bucket, err)
+			}
+		}
+	}
+
+	s.logger.Info("imported server state",
+		"from", snapshot.Server,
+		"exported", snapshot.Exported,
+		"prompts", len(snapshot.Prompts))
+	return nil
+}
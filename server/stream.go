@@ -0,0 +1,143 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// MetaStreamToken is the _meta key a client sets on tools/call to receive
+// the content a handler streams with Context.StreamContent as it is
+// produced. Its value is echoed in every notifications/tools/content
+// message of the call.
+const MetaStreamToken = "streamToken"
+
+// MetaStreamedChunks is the _meta key of a streamed call's final result
+// giving the number of chunks sent before it, so the client can wait for
+// any still in flight before treating the call as complete.
+const MetaStreamedChunks = "streamedChunks"
+
+// contentStream tracks the content streamed by a tool call.
+type contentStream struct {
+	mu       sync.Mutex
+	sent     int
+	buffered []map[string]interface{}
+}
+
+// StreamContent sends content items to the client ahead of the tool's
+// final result, in order, as notifications/tools/content messages carrying
+// the call's stream token, request ID and a sequence number starting at 0.
+//
+// Clients that didn't ask for streaming by setting a stream token receive
+// the items at the start of the final result's content instead, so
+// handlers can stream unconditionally. Items pass through the tool's
+// sanitizer and the content scanner like the final result; items the
+// scanner blocks are not sent and an error is returned.
+//
+// Example:
+//
+//	srv.Tool("summarize", "Summarize a document", func(ctx *server.Context, args SummarizeArgs) (string, error) {
+//	    for part := range model.Generate(args.Text) {
+//	        if err := ctx.StreamContent(server.TextContent(part)); err != nil {
+//	            return "", err
+//	        }
+//	    }
+//	    return "", nil
+//	})
+func (c *Context) StreamContent(items ...ContentItem) error {
+	if c.server == nil || c.Request == nil || c.Request.ToolName == "" {
+		return errors.New("cannot stream content: not handling a tool call")
+	}
+
+	content := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to encode stream content: %w", err)
+		}
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			return fmt.Errorf("failed to encode stream content: %w", err)
+		}
+		content = append(content, decoded)
+	}
+
+	stream := c.contentStream()
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+
+	// Buffered items are checked with the final result they join
+	token := c.Meta()[MetaStreamToken]
+	if token == nil {
+		stream.buffered = append(stream.buffered, content...)
+		return nil
+	}
+
+	checked := c.server.finishToolResult(c, map[string]interface{}{"content": content})
+	if checked["isError"] == true {
+		return errors.New("stream content withheld: flagged by content scanner")
+	}
+
+	notification, err := notificationMessage("notifications/tools/content", map[string]interface{}{
+		MetaStreamToken: token,
+		"requestId":     c.Request.ID,
+		"sequence":      stream.sent,
+		"content":       content,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal stream content: %w", err)
+	}
+
+	c.server.mu.RLock()
+	t := c.server.transport
+	c.server.mu.RUnlock()
+	if t == nil {
+		return errors.New("cannot stream content: no transport configured")
+	}
+	if err := c.server.sendMessage(notification); err != nil {
+		c.server.metrics.notificationFailed("notifications/tools/content")
+		return fmt.Errorf("failed to send stream content: %w", err)
+	}
+	stream.sent++
+	return nil
+}
+
+// contentStream returns the request's content stream, creating it on first use.
+func (c *Context) contentStream() *contentStream {
+	c.streamOnce.Do(func() {
+		c.stream = &contentStream{}
+	})
+	return c.stream
+}
+
+// completeStream folds what the handler streamed into its final result:
+// the count of chunks sent, or the content buffered for clients that didn't
+// ask for streaming.
+func (c *Context) completeStream(result map[string]interface{}) map[string]interface{} {
+	if c.stream == nil {
+		return result
+	}
+	c.stream.mu.Lock()
+	defer c.stream.mu.Unlock()
+
+	if len(c.stream.buffered) > 0 {
+		content := make([]interface{}, 0, len(c.stream.buffered)+1)
+		for _, item := range c.stream.buffered {
+			content = append(content, item)
+		}
+		for _, item := range contentItems(result["content"]) {
+			content = append(content, item)
+		}
+		result["content"] = content
+	}
+	if c.stream.sent > 0 {
+		meta, ok := result["_meta"].(map[string]interface{})
+		if !ok {
+			meta = make(map[string]interface{})
+			result["_meta"] = meta
+		}
+		meta[MetaStreamedChunks] = c.stream.sent
+	}
+	return result
+}
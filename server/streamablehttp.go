@@ -0,0 +1,45 @@
+package server
+
+import (
+	"github.com/localrivet/gomcp/transport/streamablehttp"
+)
+
+// AsStreamableHTTP configures the server to use the Streamable HTTP transport.
+// Streamable HTTP is the transport defined by the 2025-03-26 revision of the
+// MCP specification: clients POST messages to a single endpoint (/mcp by
+// default) and receive either a JSON response or an SSE stream, and may GET
+// the same endpoint to receive server-initiated messages.
+//
+// Parameters:
+//   - address: The listening address for the server (e.g., ":8080" for all interfaces on port 8080)
+//   - options: Optional configuration options for the transport
+//
+// Returns:
+//   - The server instance for method chaining
+//
+// Example usage:
+//
+//	server.AsStreamableHTTP(":8080")
+//
+//	// With a custom endpoint and a browser origin allowed to connect
+//	server.AsStreamableHTTP(":8080",
+//	    streamablehttp.WithEndpoint("/api/mcp"),
+//	    streamablehttp.WithAllowedOrigins("https://app.example.com"))
+func (s *serverImpl) AsStreamableHTTP(address string, options ...streamablehttp.Option) Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	streamableTransport := streamablehttp.NewTransport(address, options...)
+
+	// Configure the message handler
+	streamableTransport.SetMessageHandler(s.handleMessage)
+	s.registerDiscoveryHandler(streamableTransport)
+
+	// Set as the server's transport
+	s.transport = streamableTransport
+
+	s.logger.Info("server configured with Streamable HTTP transport",
+		"address", address,
+		"endpoint", streamableTransport.GetEndpoint())
+	return s
+}
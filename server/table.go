@@ -0,0 +1,314 @@
+package server
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column types of a Table, inferred from its values.
+const (
+	ColumnString   = "string"
+	ColumnInteger  = "integer"
+	ColumnNumber   = "number"
+	ColumnBoolean  = "boolean"
+	ColumnDateTime = "datetime"
+)
+
+// Column describes a column of a Table.
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Table is tabular data for tool results. Returned from a tool handler, it
+// is sent as structured content of columns, typed rows and row count to
+// clients that accept structured content, and as a Markdown table to the
+// others. Empty cells are nil.
+//
+// Example:
+//
+//	srv.Tool("top_customers", "List the top customers", func(ctx *server.Context, args struct{}) (interface{}, error) {
+//	    rows, err := db.QueryContext(ctx.Context(), "SELECT name, orders, revenue FROM customers ORDER BY revenue DESC LIMIT 20")
+//	    if err != nil {
+//	        return nil, err
+//	    }
+//	    defer rows.Close()
+//	    return server.TableFromRows(rows)
+//	})
+type Table struct {
+	Columns []Column        `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// NewTable creates a table from string records whose first record names
+// the columns, inferring each column's type from its values: integer,
+// number or boolean if all its non-empty values parse as one, and string
+// otherwise.
+func NewTable(records [][]string) *Table {
+	t := &Table{Columns: []Column{}, Rows: [][]interface{}{}}
+	if len(records) == 0 {
+		return t
+	}
+	header, records := records[0], records[1:]
+	for _, name := range header {
+		t.Columns = append(t.Columns, Column{Name: name})
+	}
+
+	for i := range t.Columns {
+		t.Columns[i].Type = inferColumnType(records, i)
+	}
+	for _, record := range records {
+		row := make([]interface{}, len(t.Columns))
+		for i, column := range t.Columns {
+			if i < len(record) {
+				row[i] = parseCell(strings.TrimSpace(record[i]), column.Type)
+			}
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
+// TableFromCSV reads a table from CSV whose first record names the
+// columns. Records may have differing numbers of fields; missing cells are
+// empty.
+func TableFromCSV(r io.Reader) (*Table, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	return NewTable(records), nil
+}
+
+// TableFromCSVFile reads a table from a CSV file, as TableFromCSV.
+func TableFromCSVFile(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return TableFromCSV(f)
+}
+
+// Rows is the part of *sql.Rows that TableFromRows uses.
+type Rows interface {
+	Columns() ([]string, error)
+	Next() bool
+	Scan(dest ...interface{}) error
+	Err() error
+}
+
+// TableFromRows reads a table from database rows, such as *sql.Rows, which
+// it reads to the end but doesn't close. Column types follow the scanned
+// values: integers, floats, booleans and times keep their types, and other
+// values, including []byte, become strings.
+func TableFromRows(rows Rows) (*Table, error) {
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	t := &Table{Columns: make([]Column, len(names)), Rows: [][]interface{}{}}
+	for i, name := range names {
+		t.Columns[i].Name = name
+	}
+
+	for rows.Next() {
+		row := make([]interface{}, len(names))
+		pointers := make([]interface{}, len(names))
+		for i := range row {
+			pointers[i] = &row[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		for i, value := range row {
+			if b, ok := value.([]byte); ok {
+				row[i] = string(b)
+			}
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range t.Columns {
+		t.Columns[i].Type = t.valueColumnType(i)
+	}
+	return t, nil
+}
+
+// valueColumnType returns the type of column i from its values, converting
+// them where the column's values are of mixed types.
+func (t *Table) valueColumnType(i int) string {
+	columnType := ""
+	for _, row := range t.Rows {
+		var valueType string
+		switch row[i].(type) {
+		case nil:
+			continue
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			valueType = ColumnInteger
+		case float32, float64:
+			valueType = ColumnNumber
+		case bool:
+			valueType = ColumnBoolean
+		case time.Time:
+			valueType = ColumnDateTime
+		default:
+			valueType = ColumnString
+		}
+		switch {
+		case columnType == "" || columnType == valueType:
+			columnType = valueType
+		case isNumeric(columnType) && isNumeric(valueType):
+			columnType = ColumnNumber
+		default:
+			columnType = ColumnString
+		}
+	}
+	if columnType == "" {
+		return ColumnString
+	}
+
+	for _, row := range t.Rows {
+		switch value := row[i].(type) {
+		case nil:
+		case time.Time:
+			row[i] = value.Format(time.RFC3339Nano)
+		default:
+			if columnType == ColumnString {
+				row[i] = fmt.Sprint(value)
+			}
+		}
+	}
+	return columnType
+}
+
+// Markdown renders the table as a Markdown table. Pipes and line breaks in
+// cells are escaped so they can't break the table's layout.
+func (t *Table) Markdown() string {
+	if len(t.Columns) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("|")
+	for _, column := range t.Columns {
+		b.WriteString(" " + markdownCell(column.Name) + " |")
+	}
+	b.WriteString("\n|")
+	for _, column := range t.Columns {
+		if isNumeric(column.Type) {
+			b.WriteString(" ---: |")
+		} else {
+			b.WriteString(" --- |")
+		}
+	}
+	for _, row := range t.Rows {
+		b.WriteString("\n|")
+		for _, value := range row {
+			cell := ""
+			if value != nil {
+				cell = markdownCell(fmt.Sprint(value))
+			}
+			b.WriteString(" " + cell + " |")
+		}
+	}
+	return b.String()
+}
+
+// Representations returns the table as a tool result: the Markdown table
+// as text, and the columns and rows as structured content.
+func (t *Table) Representations() Representations {
+	return Representations{
+		Markdown: t.Markdown(),
+		Structured: map[string]interface{}{
+			"columns":  t.Columns,
+			"rows":     t.Rows,
+			"rowCount": len(t.Rows),
+		},
+	}
+}
+
+// inferColumnType returns the type all non-empty values of column i of
+// records parse as.
+func inferColumnType(records [][]string, i int) string {
+	integer, number, boolean, seen := true, true, true, false
+	for _, record := range records {
+		if i >= len(record) {
+			continue
+		}
+		value := strings.TrimSpace(record[i])
+		if value == "" {
+			continue
+		}
+		seen = true
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			integer = false
+		}
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			number = false
+		}
+		if _, err := strconv.ParseBool(value); err != nil || isDigits(value) {
+			boolean = false
+		}
+	}
+	switch {
+	case !seen:
+		return ColumnString
+	case integer:
+		return ColumnInteger
+	case number:
+		return ColumnNumber
+	case boolean:
+		return ColumnBoolean
+	}
+	return ColumnString
+}
+
+// parseCell converts a cell to the type of its column.
+func parseCell(value, columnType string) interface{} {
+	if value == "" {
+		return nil
+	}
+	switch columnType {
+	case ColumnInteger:
+		n, _ := strconv.ParseInt(value, 10, 64)
+		return n
+	case ColumnNumber:
+		f, _ := strconv.ParseFloat(value, 64)
+		return f
+	case ColumnBoolean:
+		b, _ := strconv.ParseBool(value)
+		return b
+	}
+	return value
+}
+
+// isDigits reports whether s is all digits, such as "0" and "1", which
+// strconv.ParseBool accepts but are better read as numbers.
+func isDigits(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
+
+// isNumeric reports whether a column type is a number.
+func isNumeric(columnType string) bool {
+	return columnType == ColumnInteger || columnType == ColumnNumber
+}
+
+// markdownCell escapes a value for a Markdown table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
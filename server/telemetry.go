@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/localrivet/gomcp/telemetry"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTracerProvider records an OpenTelemetry span for every request the
+// server handles, as a child of the trace context the client sent in the
+// request's _meta, if any. Handlers reach the span through
+// Context.Context(), and pass it on to the requests they make themselves
+// to keep a call through a chain of proxies in a single trace.
+//
+// Example:
+//
+//	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+//	srv := server.NewServer("my-service", server.WithTracerProvider(tp))
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(s *serverImpl) {
+		s.tracer = telemetry.NewTracer(provider)
+	}
+}
+
+// traceRequest starts the span of a request and returns the function
+// ending it with the response sent.
+func (s *serverImpl) traceRequest(ctx *Context) func(response []byte) {
+	clientInfo, _ := ctx.ClientInfo()
+	spanCtx, span := s.tracer.StartServer(ctx.Context(), ctx.Meta(), telemetry.Request{
+		Method:     ctx.Request.Method,
+		Tool:       ctx.Request.ToolName,
+		SessionID:  string(ctx.sessionID()),
+		ID:         ctx.Request.ID,
+		ClientName: clientInfo.Name,
+	})
+	ctx.ctx = spanCtx
+
+	return func(response []byte) {
+		code, message := responseError(response)
+		var err error
+		if message != "" {
+			err = errors.New(message)
+		}
+		telemetry.End(span, code, err)
+	}
+}
+
+// responseError returns the code and message of a JSON-RPC error response.
+func responseError(response []byte) (int, string) {
+	if len(response) == 0 {
+		return 0, ""
+	}
+	var decoded struct {
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(response, &decoded) != nil || decoded.Error == nil {
+		return 0, ""
+	}
+	return decoded.Error.Code, decoded.Error.Message
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func adminGet(t *testing.T, url, token string) (int, string) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestAdminHandler(t *testing.T) {
+	srv := server.NewServer("admin-test").
+		Tool("echo", "Echo a message", func(ctx *server.Context, args struct{}) (interface{}, error) {
+			return "ok", nil
+		})
+	initializeWithCapabilities(t, srv, `{}`)
+	handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{}}}`)
+
+	mux := http.NewServeMux()
+	mux.Handle("/admin/", http.StripPrefix("/admin", srv.AdminHandler("s3cret")))
+	admin := httptest.NewServer(mux)
+	defer admin.Close()
+
+	if status, _ := adminGet(t, admin.URL+"/admin/debug/sessions", ""); status != http.StatusUnauthorized {
+		t.Errorf("Expected requests without the token to be refused, got %d", status)
+	}
+	if status, _ := adminGet(t, admin.URL+"/admin/debug/sessions", "wrong"); status != http.StatusUnauthorized {
+		t.Errorf("Expected requests with a wrong token to be refused, got %d", status)
+	}
+
+	status, body := adminGet(t, admin.URL+"/admin/debug/sessions", "s3cret")
+	if status != http.StatusOK {
+		t.Fatalf("Expected the session table, got %d: %s", status, body)
+	}
+	var table struct {
+		Sessions []struct {
+			ID              string `json:"id"`
+			ProtocolVersion string `json:"protocolVersion"`
+			Usage           *struct {
+				Calls int `json:"calls"`
+			} `json:"usage"`
+		} `json:"sessions"`
+	}
+	if err := json.Unmarshal([]byte(body), &table); err != nil {
+		t.Fatalf("Expected JSON, got %s", body)
+	}
+	var found bool
+	for _, session := range table.Sessions {
+		if session.ProtocolVersion == "2025-03-26" && session.Usage != nil && session.Usage.Calls == 1 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the initialized session with its call, got %s", body)
+	}
+
+	if status, body := adminGet(t, admin.URL+"/admin/debug/goroutines", "s3cret"); status != http.StatusOK || !strings.Contains(body, "goroutine ") {
+		t.Errorf("Expected a goroutine dump, got %d", status)
+	}
+	if status, body := adminGet(t, admin.URL+"/admin/debug/pprof/", "s3cret"); status != http.StatusOK || !strings.Contains(body, "heap") {
+		t.Errorf("Expected the pprof index, got %d", status)
+	}
+	if status, body := adminGet(t, admin.URL+"/admin/debug/runtime", "s3cret"); status != http.StatusOK || !strings.Contains(body, `"goroutines"`) {
+		t.Errorf("Expected runtime statistics, got %d: %s", status, body)
+	}
+}
@@ -0,0 +1,77 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func handleBatch(t *testing.T, srv server.Server, message string) []map[string]interface{} {
+	t.Helper()
+	responseBytes, err := server.HandleMessage(srv.GetServer(), []byte(message))
+	if err != nil {
+		t.Fatalf("Failed to handle batch: %v", err)
+	}
+	if responseBytes == nil {
+		return nil
+	}
+	var responses []map[string]interface{}
+	if err := json.Unmarshal(responseBytes, &responses); err != nil {
+		t.Fatalf("Expected a batch response, got %s", responseBytes)
+	}
+	return responses
+}
+
+func TestBatchPreservesOrder(t *testing.T) {
+	srv := server.NewServer("batch-test", server.WithBatchPolicy(server.BatchPolicy{Workers: 4})).
+		Tool("sleep", "Sleep then echo", func(ctx *server.Context, args struct {
+			Ms int `json:"ms"`
+		}) (interface{}, error) {
+			time.Sleep(time.Duration(args.Ms) * time.Millisecond)
+			return args.Ms, nil
+		})
+
+	responses := handleBatch(t, srv, `[
+		{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"sleep","arguments":{"ms":30}}},
+		{"jsonrpc":"2.0","method":"notifications/initialized"},
+		{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"sleep","arguments":{"ms":1}}},
+		{"jsonrpc":"2.0","id":3,"method":"no/such/method"},
+		42
+	]`)
+
+	if len(responses) != 4 {
+		t.Fatalf("Expected 4 responses, got %d: %v", len(responses), responses)
+	}
+	for i, want := range []interface{}{1.0, 2.0, 3.0, nil} {
+		if responses[i]["id"] != want {
+			t.Errorf("Expected response %d to have id %v, got %v", i, want, responses[i]["id"])
+		}
+	}
+	if responses[2]["error"] == nil {
+		t.Error("Expected an error for the unknown method")
+	}
+	if code := errorCode(responses[3]); code != -32600 {
+		t.Errorf("Expected -32600 for a non-object element, got %v", code)
+	}
+}
+
+func TestBatchRejections(t *testing.T) {
+	srv := server.NewServer("batch-test", server.WithBatchPolicy(server.BatchPolicy{MaxSize: 2}))
+
+	if code := errorCode(handleRaw(t, srv, `[]`)); code != -32600 {
+		t.Errorf("Expected -32600 for an empty batch, got %v", code)
+	}
+	tooLarge := `[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","id":2,"method":"ping"},{"jsonrpc":"2.0","id":3,"method":"ping"}]`
+	if code := errorCode(handleRaw(t, srv, tooLarge)); code != -32600 {
+		t.Errorf("Expected -32600 for a batch over MaxSize, got %v", code)
+	}
+	if responses := handleBatch(t, srv, `[{"jsonrpc":"2.0","method":"notifications/initialized"}]`); responses != nil {
+		t.Errorf("Expected no reply to a batch of notifications, got %v", responses)
+	}
+	responses := handleBatch(t, srv, `[{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}]`)
+	if len(responses) != 1 || errorCode(responses[0]) != -32600 {
+		t.Errorf("Expected initialize to be rejected in a batch, got %v", responses)
+	}
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// newBenchServer returns an initialized server with a handful of tools, a
+// discarding logger and a transport that records nothing worth keeping.
+func newBenchServer(b *testing.B) server.Server {
+	b.Helper()
+	srv := server.NewServer("bench",
+		server.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		server.WithTransport(NewRecordingTransport()),
+	)
+	for i := 0; i < 20; i++ {
+		srv.Tool(fmt.Sprintf("tool-%d", i), "A benchmark tool", func(ctx *server.Context, args struct {
+			Query string `json:"query" description:"Search query"`
+			Limit int    `json:"limit,omitempty" min:"1" max:"100"`
+		}) (interface{}, error) {
+			return args.Query, nil
+		})
+	}
+
+	messages := []string{
+		`{"jsonrpc":"2.0","id":0,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"bench","version":"1.0"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+	}
+	for _, message := range messages {
+		if _, err := server.HandleMessage(srv.GetServer(), []byte(message)); err != nil {
+			b.Fatalf("Failed to initialize: %v", err)
+		}
+	}
+	return srv
+}
+
+func BenchmarkHandleMessage(b *testing.B) {
+	benchmarks := []struct {
+		name    string
+		message string
+	}{
+		{"Ping", `{"jsonrpc":"2.0","id":1,"method":"ping"}`},
+		{"ToolsList", `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`},
+		{"ToolsCall", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"tool-7","arguments":{"query":"weather in Paris","limit":5}}}`},
+		{"MethodNotFound", `{"jsonrpc":"2.0","id":1,"method":"no/such/method"}`},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			srv := newBenchServer(b).GetServer()
+			message := []byte(bm.message)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := server.HandleMessage(srv, message); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkHandleMessageParallel(b *testing.B) {
+	srv := newBenchServer(b).GetServer()
+	message := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"tool-3","arguments":{"query":"q"}}}`)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := server.HandleMessage(srv, message); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestClientBudget(t *testing.T) {
+	tests := []struct {
+		capabilities string
+		expected     string
+	}{
+		{`{"experimental":{"contextBudget":{"contextWindow":200000,"maxResultTokens":8000}}}`, "true 8000"},
+		{`{"experimental":{"contextBudget":{"contextWindow":100000}}}`, "true 5000"},
+		{`{"experimental":{"contextBudget":{}}}`, "false 2000"},
+		{`{"experimental":{"contextBudget":{"contextWindow":"large"}}}`, "false 2000"},
+		{`{}`, "false 2000"},
+	}
+	for _, tc := range tests {
+		srv := server.NewServer("budget-test").
+			Tool("budget", "Report the client's budget", func(ctx *server.Context, args interface{}) (string, error) {
+				budget, ok := ctx.ClientBudget()
+				return fmt.Sprint(ok, " ", budget.ResultTokens(2000)), nil
+			})
+		initializeWithCapabilities(t, srv, tc.capabilities)
+
+		content := toolContent(t, callToolWithArgs(t, srv, "budget", nil))
+		if len(content) != 1 || content[0]["text"] != tc.expected {
+			t.Errorf("%s: expected %q, got %v", tc.capabilities, tc.expected, content)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func initializeWithCapabilities(t *testing.T, srv server.Server, capabilities string) {
+	t.Helper()
+	request := []byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":` +
+		capabilities + `,"clientInfo":{"name":"test","version":"1.0"}}}`)
+	if _, err := server.HandleMessage(srv.GetServer(), request); err != nil {
+		t.Fatalf("Failed to handle initialize request: %v", err)
+	}
+}
+
+func TestCapabilitiesAccessor(t *testing.T) {
+	srv := server.NewServer("caps-test", server.WithResourceSubscriptions(false))
+
+	resources, ok := srv.Capabilities()["resources"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected resources capability, got %v", srv.Capabilities())
+	}
+	if resources["subscribe"] != false {
+		t.Errorf("Expected resources.subscribe to be false, got %v", resources["subscribe"])
+	}
+	for _, name := range []string{"tools", "prompts", "logging"} {
+		if _, ok := srv.Capabilities()[name]; !ok {
+			t.Errorf("Expected %s capability to be advertised", name)
+		}
+	}
+}
+
+func TestMissingServerCapabilityError(t *testing.T) {
+	srv := server.NewServer("caps-test", server.WithResourceSubscriptions(false))
+
+	response := sendResourceRequest(t, srv, "resources/subscribe", "file:///a.txt")
+	errObj, ok := response["error"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected error response, got %v", response)
+	}
+	if code, _ := errObj["code"].(float64); code != -32601 {
+		t.Errorf("Expected error code -32601, got %v", errObj["code"])
+	}
+
+	data, _ := errObj["data"].(string)
+	for _, want := range []string{"resources.subscribe", "WithResourceSubscriptions(true)"} {
+		if !strings.Contains(data, want) {
+			t.Errorf("Expected error data to mention %q, got %q", want, data)
+		}
+	}
+}
+
+func TestMissingClientCapabilityError(t *testing.T) {
+	srv := server.NewServer("caps-test")
+	initializeWithCapabilities(t, srv, `{"roots":{"listChanged":true}}`)
+
+	_, err := srv.GetServer().RequestSampling(
+		[]server.SamplingMessage{server.CreateTextSamplingMessage("user", "Hello")},
+		server.SamplingModelPreferences{}, "", 100)
+
+	var capErr *server.CapabilityError
+	if !errors.As(err, &capErr) {
+		t.Fatalf("Expected a CapabilityError, got %v", err)
+	}
+	if capErr.Side != "client" || capErr.Capability != "sampling" {
+		t.Errorf("Unexpected capability error: %+v", capErr)
+	}
+	if !strings.Contains(err.Error(), "WithSamplingCapability") {
+		t.Errorf("Expected error to explain how to enable sampling, got %q", err.Error())
+	}
+}
@@ -0,0 +1,82 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestChunkedAndOverflowResults(t *testing.T) {
+	output := strings.Repeat("The build step finished without errors. ", 60)
+
+	srv := server.NewServer("chunking-test").
+		Tool("chunked", "Chunked output", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			return server.ChunkedResult(output, 200, 0), nil
+		}).
+		Tool("overflow", "Overflowing output", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			return ctx.OverflowResult(output, 200), nil
+		}).
+		Tool("short", "Short output", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			return ctx.OverflowResult("Done.", 200), nil
+		})
+
+	content := toolContent(t, callToolWithArgs(t, srv, "chunked", nil))
+	if len(content) != 3 {
+		t.Fatalf("Expected 3 text blocks, got %d", len(content))
+	}
+	var joined []string
+	for _, item := range content {
+		joined = append(joined, item["text"].(string))
+	}
+	if got := strings.Join(joined, " "); got != strings.TrimSpace(output) {
+		t.Error("Expected the blocks to hold the whole output")
+	}
+
+	content = toolContent(t, callToolWithArgs(t, srv, "overflow", nil))
+	if len(content) != 3 || content[1]["type"] != "link" || content[2]["type"] != "link" {
+		t.Fatalf("Expected the first part and links to two more, got %v", content)
+	}
+	if text := content[0]["text"].(string); !strings.HasSuffix(text, "[Part 1 of 3. Read the resources linked below for the rest.]") {
+		t.Errorf("Expected a note on the first part, got %q", text)
+	}
+	uri := content[2]["url"].(string)
+	if !strings.HasPrefix(uri, server.OverflowURIPrefix) {
+		t.Fatalf("Expected an overflow URI, got %q", uri)
+	}
+
+	response := sendResourceRequest(t, srv, "resources/read", uri)
+	result, ok := response["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected to read %s, got %v", uri, response)
+	}
+	contents := result["contents"].([]interface{})
+	if text := contents[0].(map[string]interface{})["text"].(string); !strings.HasSuffix(strings.TrimSpace(output), text) {
+		t.Errorf("Expected the last part of the output, got %q", text)
+	}
+
+	response = sendResourceRequest(t, srv, "resources/read", server.OverflowURIPrefix+"unknown/2")
+	if response["error"] == nil {
+		t.Errorf("Expected an error reading an unknown part, got %v", response)
+	}
+
+	content = toolContent(t, callToolWithArgs(t, srv, "short", nil))
+	if len(content) != 1 || content[0]["text"] != "Done." {
+		t.Errorf("Expected short output whole, got %v", content)
+	}
+}
+
+func toolContent(t *testing.T, response map[string]interface{}) []map[string]interface{} {
+	t.Helper()
+
+	result, ok := response["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected a tool result, got %v", response)
+	}
+	items, _ := result["content"].([]interface{})
+	content := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		content = append(content, item.(map[string]interface{}))
+	}
+	return content
+}
@@ -0,0 +1,53 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestContextClientInfo(t *testing.T) {
+	var seenByMiddleware string
+	srv := server.NewServer("clientinfo-test").
+		Tool("whoami", "Describe the client", func(ctx *server.Context, args struct{}) (interface{}, error) {
+			info, ok := ctx.ClientInfo()
+			if !ok {
+				return "unknown", nil
+			}
+			sampling, _ := info.Capabilities["sampling"].(map[string]interface{})
+			return map[string]interface{}{
+				"name":     info.Name,
+				"version":  info.Version,
+				"protocol": info.ProtocolVersion,
+				"sampling": sampling != nil,
+			}, nil
+		})
+	srv.Use(func(next server.RequestHandler) server.RequestHandler {
+		return func(ctx *server.Context) (interface{}, error) {
+			if info, ok := ctx.ClientInfo(); ok {
+				seenByMiddleware = info.Name
+			}
+			return next(ctx)
+		}
+	})
+
+	call := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"whoami","arguments":{}}}`
+	text := func() string {
+		response := handleRaw(t, srv, call)
+		content := response["result"].(map[string]interface{})["content"].([]interface{})
+		return content[0].(map[string]interface{})["text"].(string)
+	}
+
+	if got := text(); got != "unknown" {
+		t.Errorf("Expected no client info before initialize, got %s", got)
+	}
+
+	initializeWithCapabilities(t, srv, `{"sampling":{}}`)
+	want := "{\n  \"name\": \"test\",\n  \"protocol\": \"2025-03-26\",\n  \"sampling\": true,\n  \"version\": \"1.0\"\n}"
+	if got := text(); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+	if seenByMiddleware != "test" {
+		t.Errorf("Expected middleware to see the client name, got %q", seenByMiddleware)
+	}
+}
@@ -0,0 +1,22 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestLegacyPromptURIAccepted(t *testing.T) {
+	srv := server.NewServer("compat-test").
+		Prompt("greeting", "Greet someone", server.User("Hello {{name}}"))
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":{"uri":"prompt://greeting","variables":{"name":"Ada"}}}`)
+	if response["error"] != nil {
+		t.Fatalf("Expected legacy prompts/get to succeed, got %v", response["error"])
+	}
+
+	messages := promptMessages(t, response)
+	if len(messages) != 1 || messages[0][1] != "Hello Ada" {
+		t.Errorf("Unexpected messages: %v", messages)
+	}
+}
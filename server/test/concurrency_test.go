@@ -0,0 +1,115 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func newHandlerContext(t *testing.T, ctx context.Context) *server.Context {
+	t.Helper()
+	srv := server.NewServer("concurrency-test")
+	handlerCtx, err := server.NewContext(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"fanout"}}`), srv.GetServer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return handlerCtx
+}
+
+func TestParallelLimitsConcurrency(t *testing.T) {
+	ctx := newHandlerContext(t, context.Background())
+	var running, peak, calls atomic.Int32
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8}
+
+	err := server.Parallel(ctx, 3, items, func(ctx context.Context, item int) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		calls.Add(1)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Parallel failed: %v", err)
+	}
+	if calls.Load() != 8 {
+		t.Errorf("Expected 8 calls, got %d", calls.Load())
+	}
+	if peak.Load() > 3 {
+		t.Errorf("Expected at most 3 calls at once, got %d", peak.Load())
+	}
+}
+
+func TestParallelStopsOnFailure(t *testing.T) {
+	ctx := newHandlerContext(t, context.Background())
+	errBoom := errors.New("boom")
+	var started atomic.Int32
+
+	err := server.Parallel(ctx, 2, []int{1, 2, 3, 4, 5, 6}, func(ctx context.Context, item int) error {
+		started.Add(1)
+		if item == 1 {
+			return errBoom
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("Expected the failure, got %v", err)
+	}
+	if errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the cancellations caused by the failure to be left out, got %v", err)
+	}
+	if n := started.Load(); n > 2 {
+		t.Errorf("Expected the remaining items to be skipped, started %d", n)
+	}
+}
+
+func TestGoJoinsErrorsAndRecoversPanics(t *testing.T) {
+	ctx := newHandlerContext(t, context.Background())
+	first := errors.New("first API down")
+	release := make(chan struct{})
+
+	err := server.Go(ctx,
+		func(ctx context.Context) error {
+			<-release
+			return first
+		},
+		func(ctx context.Context) error {
+			defer close(release)
+			panic("second API client bug")
+		},
+		func(ctx context.Context) error {
+			return nil
+		},
+	)
+	if !errors.Is(err, first) {
+		t.Errorf("Expected the first error, got %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "second API client bug") {
+		t.Errorf("Expected the panic as an error, got %v", err)
+	}
+}
+
+func TestGoReturnsRequestCancellation(t *testing.T) {
+	requestCtx, cancel := context.WithCancel(context.Background())
+	ctx := newHandlerContext(t, requestCtx)
+	cancel()
+
+	err := server.Go(ctx, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the request's cancellation, got %v", err)
+	}
+}
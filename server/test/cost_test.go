@@ -0,0 +1,126 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func newCostServer(options ...server.Option) server.Server {
+	srv := server.NewServer("cost-test", options...).
+		Tool("report", "Generate a report", func(ctx *server.Context, args struct {
+			Fail bool `json:"fail,omitempty"`
+		}) (interface{}, error) {
+			if args.Fail {
+				return nil, errors.New("report failed")
+			}
+			return "ok", nil
+		}).
+		Tool("lookup", "Look up a record", func(ctx *server.Context, args struct{}) (interface{}, error) {
+			return "found", nil
+		})
+	return srv.WithCost("report", server.ToolCost{Credits: 2.5, Tier: "premium"}).
+		WithCost("lookup", server.ToolCost{Credits: 0.1})
+}
+
+func TestCostAnnotation(t *testing.T) {
+	srv := newCostServer()
+	tools := map[string]*server.Tool{}
+	for tool := range srv.EachTool {
+		tools[tool.Name] = tool
+	}
+
+	cost, ok := tools["report"].Annotations["cost"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected a cost annotation, got %v", tools["report"].Annotations)
+	}
+	if cost["credits"] != 2.5 || cost["tier"] != "premium" {
+		t.Errorf("Unexpected cost annotation %v", cost)
+	}
+	if _, ok := tools["lookup"].Annotations["cost"].(map[string]interface{})["tier"]; ok {
+		t.Error("Expected no tier in the annotation of a tool without one")
+	}
+}
+
+func TestUsageBySessionAndKey(t *testing.T) {
+	srv := newCostServer()
+	initializeWithCapabilities(t, srv, `{}`)
+
+	calls := []string{
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"report","arguments":{},"_meta":{"apiKey":"key-a"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"report","arguments":{"fail":true},"_meta":{"apiKey":"key-a"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"lookup","arguments":{},"_meta":{"apiKey":"key-b"}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"lookup","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"missing","arguments":{}}}`,
+	}
+	for _, call := range calls {
+		handleRaw(t, srv, call)
+	}
+
+	usage := srv.Usage()
+	if usage.Total.Calls != 4 || usage.Total.Errors != 1 {
+		t.Errorf("Expected 4 calls and 1 error in total, got %+v", usage.Total.ToolUsage)
+	}
+	if usage.Total.Credits < 2.69 || usage.Total.Credits > 2.71 {
+		t.Errorf("Expected 2.7 credits in total, got %v", usage.Total.Credits)
+	}
+	if usage.Total.Tiers["premium"] != 2.5 {
+		t.Errorf("Expected 2.5 premium credits, got %v", usage.Total.Tiers)
+	}
+	if report := usage.Total.Tools["report"]; report.Calls != 2 || report.Errors != 1 || report.Credits != 2.5 {
+		t.Errorf("Expected the failed report call not to be charged, got %+v", report)
+	}
+	if len(usage.Sessions) != 1 {
+		t.Errorf("Expected usage for one session, got %v", usage.Sessions)
+	}
+	if len(usage.APIKeys) != 2 {
+		t.Fatalf("Expected usage for two API keys, got %v", usage.APIKeys)
+	}
+	for id, summary := range usage.APIKeys {
+		if id == "key-a" || id == "key-b" {
+			t.Errorf("Expected API keys to be reported by hash, got %q", id)
+		}
+		if summary.Calls != 2 && summary.Calls != 1 {
+			t.Errorf("Unexpected key usage %+v", summary)
+		}
+	}
+
+	srv.ResetUsage()
+	if usage := srv.Usage(); usage.Total.Calls != 0 || len(usage.Sessions) != 0 {
+		t.Errorf("Expected no usage after reset, got %+v", usage)
+	}
+}
+
+func TestUsageTool(t *testing.T) {
+	srv := newCostServer(server.WithUsageTool("admin_usage", func(ctx *server.Context) bool {
+		return ctx.Meta().APIKey() == "admin"
+	}))
+	initializeWithCapabilities(t, srv, `{}`)
+	handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"report","arguments":{}}}`)
+
+	denied := handleRaw(t, srv, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"admin_usage","arguments":{}}}`)
+	if result := denied["result"].(map[string]interface{}); result["isError"] != true {
+		t.Errorf("Expected the usage tool to refuse callers without the admin key, got %v", result)
+	}
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"admin_usage","arguments":{"reset":true},"_meta":{"apiKey":"admin"}}}`)
+	result := response["result"].(map[string]interface{})
+	if result["isError"] == true {
+		t.Fatalf("Usage tool failed: %v", result)
+	}
+	content := result["content"].([]interface{})[0].(map[string]interface{})
+	var report server.UsageReport
+	if err := json.Unmarshal([]byte(content["text"].(string)), &report); err != nil {
+		t.Fatalf("Expected a JSON usage report, got %v: %v", content["text"], err)
+	}
+	if report.Total.Tools["report"].Credits != 2.5 {
+		t.Errorf("Expected the report call in the usage report, got %+v", report.Total)
+	}
+
+	// The reset leaves only the usage tool's own call
+	if usage := srv.Usage(); usage.Total.Calls != 1 || usage.Total.Tools["admin_usage"].Calls != 1 {
+		t.Errorf("Expected the counters to be reset, got %+v", usage.Total)
+	}
+}
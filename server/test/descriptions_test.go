@@ -0,0 +1,83 @@
+package test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+type describedArgs struct {
+	City  string `json:"city" description:"City name, e.g. Paris"`
+	Units string `json:"units" enum:"metric,imperial" description:"metric or imperial units"`
+}
+
+type undescribedArgs struct {
+	City string `json:"city"`
+	Days int    `json:"days" description:"Days"`
+}
+
+func describedServer(t *testing.T, policy server.DescriptionPolicy) (server.Server, *bytes.Buffer) {
+	t.Helper()
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	srv := server.NewServer("descriptions-test",
+		server.WithLogger(logger),
+		server.WithDescriptionPolicy(policy, 10),
+	).
+		Tool("forecast", "Weather forecast", func(ctx *server.Context, args describedArgs) (interface{}, error) {
+			return "sunny", nil
+		}).
+		Tool("vague", "Vague tool", func(ctx *server.Context, args undescribedArgs) (interface{}, error) {
+			return "?", nil
+		})
+	return srv, &logs
+}
+
+func registeredTools(srv server.Server) []string {
+	var names []string
+	for tool := range srv.EachTool {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func TestDescriptionPolicyWarn(t *testing.T) {
+	srv, logs := describedServer(t, server.DescriptionsWarn)
+
+	if got := strings.Join(registeredTools(srv), ","); got != "forecast,vague" {
+		t.Errorf("Expected both tools to be registered, got %s", got)
+	}
+	for _, want := range []string{"inputSchema.properties.city", "inputSchema.properties.days"} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("Expected a warning about %s, got:\n%s", want, logs.String())
+		}
+	}
+	if strings.Contains(logs.String(), "forecast") {
+		t.Errorf("Expected no warnings for the well described tool, got:\n%s", logs.String())
+	}
+}
+
+func TestDescriptionPolicyStrict(t *testing.T) {
+	srv, logs := describedServer(t, server.DescriptionsStrict)
+
+	if got := strings.Join(registeredTools(srv), ","); got != "forecast" {
+		t.Errorf("Expected only the well described tool to be registered, got %s", got)
+	}
+	if !strings.Contains(logs.String(), "level=ERROR") || !strings.Contains(logs.String(), "vague") {
+		t.Errorf("Expected an error about the vague tool, got:\n%s", logs.String())
+	}
+}
+
+func TestDescriptionPolicyDefaultUnchecked(t *testing.T) {
+	srv := server.NewServer("descriptions-test").
+		Tool("vague", "Vague tool", func(ctx *server.Context, args undescribedArgs) (interface{}, error) {
+			return "?", nil
+		})
+	if len(registeredTools(srv)) != 1 {
+		t.Error("Expected the tool to be registered without a description policy")
+	}
+}
@@ -0,0 +1,85 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/mcp"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/sse"
+)
+
+func TestDiscoveryDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		srv      server.Server
+		expected mcp.TransportEndpoint
+	}{
+		{
+			name:     "http",
+			srv:      server.NewServer("discovery").GetServer().AsHTTPWithPaths(":0", "/mcp", "/rpc"),
+			expected: mcp.TransportEndpoint{Type: mcp.TransportHTTP, Endpoint: "/mcp/rpc"},
+		},
+		{
+			name:     "sse",
+			srv:      server.NewServer("discovery").AsSSE(":0", sse.SSE.WithPathPrefix("/mcp")),
+			expected: mcp.TransportEndpoint{Type: mcp.TransportSSE, Endpoint: "/mcp/sse", MessageEndpoint: "/mcp/message"},
+		},
+		{
+			name:     "streamable-http",
+			srv:      server.NewServer("discovery").AsStreamableHTTP(":0"),
+			expected: mcp.TransportEndpoint{Type: mcp.TransportStreamableHTTP, Endpoint: "/mcp"},
+		},
+		{
+			name:     "websocket",
+			srv:      server.NewServer("discovery").AsWebsocket(":0"),
+			expected: mcp.TransportEndpoint{Type: mcp.TransportWebsocket, Endpoint: "/ws"},
+		},
+		{
+			name: "stdio",
+			srv:  server.NewServer("discovery").AsStdio(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := tc.srv.DiscoveryDocument()
+
+			if doc.Name != "discovery" {
+				t.Errorf("Expected name 'discovery', got %q", doc.Name)
+			}
+			if doc.Version == "" {
+				t.Error("Expected a server version")
+			}
+			if len(doc.ProtocolVersions) != len(mcp.SupportedVersions) {
+				t.Errorf("Expected %d protocol versions, got %v", len(mcp.SupportedVersions), doc.ProtocolVersions)
+			}
+			if doc.Auth != nil {
+				t.Errorf("Expected no auth requirements, got %+v", doc.Auth)
+			}
+
+			if tc.expected.Type == "" {
+				if len(doc.Transports) != 0 {
+					t.Errorf("Expected no HTTP transports, got %+v", doc.Transports)
+				}
+				return
+			}
+			if len(doc.Transports) != 1 || doc.Transports[0] != tc.expected {
+				t.Errorf("Expected transports [%+v], got %+v", tc.expected, doc.Transports)
+			}
+		})
+	}
+}
+
+func TestDiscoveryDocumentAuth(t *testing.T) {
+	srv := server.NewServer("discovery",
+		server.WithDiscoveryAuth(mcp.AuthRequirements{Type: "bearer", Scopes: []string{"tools:read"}}),
+	).AsHTTP(":0")
+
+	doc := srv.DiscoveryDocument()
+	if doc.Auth == nil || doc.Auth.Type != "bearer" {
+		t.Fatalf("Expected bearer auth requirement, got %+v", doc.Auth)
+	}
+	if len(doc.Auth.Scopes) != 1 || doc.Auth.Scopes[0] != "tools:read" {
+		t.Errorf("Expected scopes [tools:read], got %v", doc.Auth.Scopes)
+	}
+}
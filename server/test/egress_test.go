@@ -0,0 +1,93 @@
+package test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// newFetchServer returns a server with a fetch tool that gets a URL with
+// HTTPClient, under the given egress policy.
+func newFetchServer(policy server.EgressPolicy, fetchErr *error) server.Server {
+	return server.NewServer("egress-test", server.WithEgressPolicy(policy)).
+		Tool("fetch", "Fetch a URL", func(ctx *server.Context, args struct {
+			URL string `json:"url"`
+		}) (string, error) {
+			resp, err := server.HTTPClient(ctx).Get(args.URL)
+			*fetchErr = err
+			if err != nil {
+				return "", err
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			return string(body), err
+		})
+}
+
+func fetchCall(url string) string {
+	return `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"fetch","arguments":{"url":"` + url + `"}}}`
+}
+
+func TestEgressPolicyDeniesInternalAddresses(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "internal data")
+	}))
+	defer upstream.Close()
+
+	var fetchErr error
+	srv := newFetchServer(server.EgressPolicy{DenyInternal: true}, &fetchErr)
+	response := handleRaw(t, srv, fetchCall(upstream.URL))
+
+	result, _ := response["result"].(map[string]interface{})
+	if result["isError"] != true {
+		t.Fatalf("Expected the blocked fetch to be a tool error, got %v", response)
+	}
+	var egressErr *server.EgressError
+	if !errors.As(fetchErr, &egressErr) || egressErr.Reason != "internal address" {
+		t.Errorf("Expected an internal address EgressError, got %v", fetchErr)
+	}
+
+	// Redirects are checked like the first request
+	redirecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, strings.Replace(upstream.URL, "127.0.0.1", "127.0.0.2", 1), http.StatusFound)
+	}))
+	defer redirecting.Close()
+	srv = newFetchServer(server.EgressPolicy{
+		AllowNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")},
+		DenyInternal:  true,
+	}, &fetchErr)
+	handleRaw(t, srv, fetchCall(redirecting.URL))
+	if !errors.As(fetchErr, &egressErr) || egressErr.Address != "127.0.0.2" {
+		t.Errorf("Expected the redirect to 127.0.0.2 to be refused, got %v", fetchErr)
+	}
+}
+
+func TestEgressPolicyAllowsListedDestinations(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+
+	var fetchErr error
+	srv := newFetchServer(server.EgressPolicy{
+		AllowNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+		DenyInternal:  true,
+	}, &fetchErr)
+	response := handleRaw(t, srv, fetchCall(upstream.URL))
+	if fetchErr != nil {
+		t.Fatalf("Expected the allowed network to be reachable, got %v (%v)", fetchErr, response)
+	}
+
+	srv = newFetchServer(server.EgressPolicy{AllowHosts: []string{"*.example.com"}}, &fetchErr)
+	handleRaw(t, srv, fetchCall(strings.Replace(upstream.URL, "127.0.0.1", "localhost", 1)))
+	var egressErr *server.EgressError
+	if !errors.As(fetchErr, &egressErr) || egressErr.Host != "localhost" || egressErr.Reason != "destination not allowed" {
+		t.Errorf("Expected an unlisted host to be refused, got %v", fetchErr)
+	}
+}
@@ -0,0 +1,191 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// newPoliteContext returns the context of a call to a server with a fetch
+// policy.
+func newPoliteContext(t *testing.T, policy server.FetchPolicy) *server.Context {
+	t.Helper()
+	srv := server.NewServer("fetch-test", server.WithFetchPolicy(policy))
+	ctx, err := server.NewContext(context.Background(), []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"fetch"}}`), srv.GetServer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ctx
+}
+
+func TestFetchPolicyLimitsHosts(t *testing.T) {
+	var running, peak atomic.Int32
+	var mu sync.Mutex
+	var starts []time.Time
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		mu.Unlock()
+		n := running.Add(1)
+		defer running.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(20 * time.Millisecond)
+		io.WriteString(w, "page")
+	}))
+	defer upstream.Close()
+
+	client := server.HTTPClient(newPoliteContext(t, server.FetchPolicy{
+		MaxConcurrentPerHost: 2,
+		MinInterval:          10 * time.Millisecond,
+	}))
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(upstream.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > 2 {
+		t.Errorf("Expected at most 2 requests at once, got %d", p)
+	}
+	for i := 1; i < len(starts); i++ {
+		if gap := starts[i].Sub(starts[i-1]); gap < 8*time.Millisecond {
+			t.Errorf("Expected requests spaced by the interval, got %v between %d and %d", gap, i-1, i)
+		}
+	}
+}
+
+func TestFetchPolicyRetriesAndLimitsSize(t *testing.T) {
+	var attempts atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			switch attempts.Add(1) {
+			case 1:
+				w.WriteHeader(http.StatusInternalServerError)
+			case 2:
+				w.Header().Set("Retry-After", time.Now().UTC().Format(http.TimeFormat))
+				w.WriteHeader(http.StatusTooManyRequests)
+			default:
+				io.WriteString(w, "ok")
+			}
+		case "/busy":
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/large":
+			w.Header().Set("Content-Length", "2048")
+			w.Write(make([]byte, 2048))
+		case "/stream":
+			w.(http.Flusher).Flush()
+			w.Write(make([]byte, 2048))
+		}
+	}))
+	defer upstream.Close()
+
+	client := server.HTTPClient(newPoliteContext(t, server.FetchPolicy{MaxResponseSize: 1024}))
+	resp, err := client.Get(upstream.URL + "/flaky")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" || attempts.Load() != 3 {
+		t.Errorf("Expected the third attempt's response, got %q after %d attempts", body, attempts.Load())
+	}
+
+	if _, err := client.Get(upstream.URL + "/large"); !errors.Is(err, server.ErrResponseTooLarge) {
+		t.Errorf("Expected ErrResponseTooLarge for a large Content-Length, got %v", err)
+	}
+	resp, err = client.Get(upstream.URL + "/stream")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	body, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !errors.Is(err, server.ErrResponseTooLarge) || len(body) != 1024 {
+		t.Errorf("Expected ErrResponseTooLarge after 1024 bytes, got %d bytes and %v", len(body), err)
+	}
+
+	// A Retry-After past the limit returns the response as it is, and holds
+	// back later requests to the host
+	begun := time.Now()
+	resp, err = client.Get(upstream.URL + "/busy")
+	if err != nil || resp.StatusCode != http.StatusServiceUnavailable || time.Since(begun) > time.Second {
+		t.Errorf("Expected the 503 response without waiting, got %v after %v", err, time.Since(begun))
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL+"/flaky", nil)
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the next request to wait for the host, got %v", err)
+	}
+}
+
+func TestFetchPolicyRespectsRobots(t *testing.T) {
+	var agents sync.Map
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents.Store(r.URL.Path, r.Header.Get("User-Agent"))
+		if r.URL.Path == "/robots.txt" {
+			io.WriteString(w, strings.Join([]string{
+				"User-agent: *",
+				"Disallow: /",
+				"",
+				"User-agent: acme-agent",
+				"Disallow: /private",
+				"Allow: /private/press",
+				"Disallow: /*.pdf$",
+			}, "\n"))
+			return
+		}
+		io.WriteString(w, "page")
+	}))
+	defer upstream.Close()
+
+	client := server.HTTPClient(newPoliteContext(t, server.FetchPolicy{
+		RespectRobots: true,
+		UserAgent:     "acme-agent/1.0",
+	}))
+	for path, allowed := range map[string]bool{
+		"/":                  true,
+		"/docs/guide.pdf?x":  true,
+		"/docs/guide.pdf":    false,
+		"/private/keys":      false,
+		"/private/press/new": true,
+	} {
+		resp, err := client.Get(upstream.URL + path)
+		if allowed {
+			if err != nil {
+				t.Errorf("%s: expected the request to be allowed, got %v", path, err)
+				continue
+			}
+			resp.Body.Close()
+		} else if !errors.Is(err, server.ErrDisallowedByRobots) {
+			t.Errorf("%s: expected ErrDisallowedByRobots, got %v", path, err)
+		}
+	}
+	if agent, _ := agents.Load("/robots.txt"); agent != "acme-agent/1.0" {
+		t.Errorf("Expected robots.txt to be fetched with the policy's user agent, got %v", agent)
+	}
+}
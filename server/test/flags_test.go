@@ -0,0 +1,114 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/flags"
+	"github.com/localrivet/gomcp/server"
+)
+
+func newFlaggedServer(provider flags.Provider) server.Server {
+	srv := server.NewServer("flags-test",
+		server.WithFlags(provider),
+		server.WithFlagContext(func(ctx *server.Context) flags.EvaluationContext {
+			return flags.EvaluationContext{TargetingKey: ctx.Meta().String("tenant")}
+		}),
+	)
+	srv.Tool("reports", "Build a report", func(ctx *server.Context, args struct {
+		Year int `json:"year"`
+	}) (string, error) {
+		return "report", nil
+	})
+	srv.Tool("echo", "Echo", func(ctx *server.Context, args struct{}) (string, error) {
+		if ctx.Flag("echo.loud", false) {
+			return "ECHO", nil
+		}
+		return "echo", nil
+	})
+	srv.WithToolFlag("reports", "reports.enabled")
+	return srv
+}
+
+func TestToolFlagGatesListAndCall(t *testing.T) {
+	provider := flags.NewStatic(map[string]interface{}{"reports.enabled": false})
+	provider.SetFor("acme", "reports.enabled", true)
+	srv := newFlaggedServer(provider)
+
+	list := func(tenant string) []string {
+		response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{"_meta":{"tenant":"`+tenant+`"}}}`)
+		tools := response["result"].(map[string]interface{})["tools"].([]interface{})
+		names := []string{}
+		for _, tool := range tools {
+			names = append(names, tool.(map[string]interface{})["name"].(string))
+		}
+		return names
+	}
+	call := func(tenant string) map[string]interface{} {
+		return handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"reports","arguments":{"year":2024},"_meta":{"tenant":"`+tenant+`"}}}`)
+	}
+
+	if names := list("acme"); len(names) != 2 {
+		t.Errorf("Expected acme to see both tools, got %v", names)
+	}
+	if names := list("globex"); len(names) != 1 || names[0] != "echo" {
+		t.Errorf("Expected globex to see only echo, got %v", names)
+	}
+	if response := call("acme"); response["error"] != nil {
+		t.Errorf("Expected acme's call to succeed, got %v", response["error"])
+	}
+	if response := call("globex"); response["error"] == nil {
+		t.Error("Expected globex's call to fail while the flag is off")
+	}
+
+	// Turning the flag on takes effect without re-registering anything
+	provider.Set("reports.enabled", true)
+	if response := call("globex"); response["error"] != nil {
+		t.Errorf("Expected globex's call to succeed once enabled, got %v", response["error"])
+	}
+}
+
+func TestSchemaVariantsAndContextFlag(t *testing.T) {
+	provider := flags.NewStatic(map[string]interface{}{"reports.enabled": true, "echo.loud": true})
+	srv := newFlaggedServer(provider)
+	srv.WithSchemaVariants("reports", "reports.schema", map[string]map[string]interface{}{
+		"strict": {
+			"type":       "object",
+			"properties": map[string]interface{}{"year": map[string]interface{}{"type": "integer", "minimum": 2020}},
+			"required":   []interface{}{"year"},
+		},
+	})
+
+	callReports := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"reports","arguments":{"year":1999}}}`
+	if response := handleRaw(t, srv, callReports); response["error"] != nil {
+		t.Errorf("Expected the default schema to accept 1999, got %v", response["error"])
+	}
+	provider.Set("reports.schema", "strict")
+	if code := errorCode(handleRaw(t, srv, callReports)); code != -32602 {
+		t.Errorf("Expected the strict variant to reject 1999 with -32602, got %v", code)
+	}
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":{}}}`)
+	content := response["result"].(map[string]interface{})["content"].([]interface{})
+	if text := content[0].(map[string]interface{})["text"]; text != "ECHO" {
+		t.Errorf("Expected the handler to see echo.loud on, got %v", text)
+	}
+}
+
+func TestFlagMiddleware(t *testing.T) {
+	provider := flags.NewStatic(map[string]interface{}{"reports.enabled": true, "deny": false})
+	srv := newFlaggedServer(provider)
+	srv.Use(server.FlagMiddleware("deny", func(next server.RequestHandler) server.RequestHandler {
+		return func(ctx *server.Context) (interface{}, error) {
+			return nil, &server.RPCError{Code: -32001, Message: "denied"}
+		}
+	}))
+
+	ping := `{"jsonrpc":"2.0","id":4,"method":"ping"}`
+	if response := handleRaw(t, srv, ping); response["error"] != nil {
+		t.Errorf("Expected the middleware to be skipped while its flag is off, got %v", response["error"])
+	}
+	provider.Set("deny", true)
+	if code := errorCode(handleRaw(t, srv, ping)); code != -32001 {
+		t.Errorf("Expected the middleware to run once its flag is on, got %v", code)
+	}
+}
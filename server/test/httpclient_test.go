@@ -0,0 +1,75 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestHTTPClientRetriesIdempotentRequests(t *testing.T) {
+	var attempts atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+
+	client := server.HTTPClient(newHandlerContext(t, context.Background()))
+	resp, err := client.Get(upstream.URL)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+		t.Errorf("Expected the third attempt's response, got %d %q", resp.StatusCode, body)
+	}
+	if n := attempts.Load(); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+
+	// Requests that may change state are sent once
+	attempts.Store(0)
+	resp, err = client.Post(upstream.URL, "text/plain", strings.NewReader("order"))
+	if err != nil {
+		t.Fatalf("Post failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || attempts.Load() != 1 {
+		t.Errorf("Expected a single attempt, got %d attempts and status %d", attempts.Load(), resp.StatusCode)
+	}
+}
+
+func TestHTTPClientStopsWithTheCall(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer upstream.Close()
+
+	callCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client := server.HTTPClient(newHandlerContext(t, callCtx))
+
+	start := time.Now()
+	_, err := client.Get(upstream.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the call's deadline to end the request, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the request to stop with the call, took %v", elapsed)
+	}
+}
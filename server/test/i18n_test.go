@@ -0,0 +1,114 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func newLocalizedServer(t *testing.T) server.Server {
+	t.Helper()
+
+	locales := fstest.MapFS{
+		"locales/en.json": {Data: []byte(`{"Greeting": "Hello, {{.Name}}!"}`)},
+		"locales/fr.json": {Data: []byte(`{"Greeting": "Bonjour, {{.Name}} !"}`)},
+		"locales/de.json": {Data: []byte(`{"Greeting": "Hallo, {{.Name}}!"}`)},
+	}
+
+	bundle := i18n.NewBundle(language.English)
+	if err := server.LoadMessageFiles(bundle, locales, "locales/*.json"); err != nil {
+		t.Fatalf("Failed to load message files: %v", err)
+	}
+
+	return server.NewServer("i18n-test", server.WithI18n(bundle)).
+		Tool("greet", "Greet someone", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			return ctx.Localize("Greeting", map[string]interface{}{"Name": "Ada"}), nil
+		}).
+		Tool("unknown", "Unknown message", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			return ctx.Localize("Missing"), nil
+		})
+}
+
+func initializeWithMeta(t *testing.T, srv server.Server, meta map[string]interface{}) {
+	t.Helper()
+
+	request := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "initialize",
+		"params": map[string]interface{}{
+			"protocolVersion": "2025-03-26",
+			"capabilities":    map[string]interface{}{},
+			"clientInfo":      map[string]interface{}{"name": "test", "version": "1.0"},
+			"_meta":           meta,
+		},
+	}
+	requestJSON, _ := json.Marshal(request)
+	if _, err := server.HandleMessage(srv.GetServer(), requestJSON); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+}
+
+func callToolWithMeta(t *testing.T, srv server.Server, name string, meta map[string]interface{}) string {
+	t.Helper()
+
+	request := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      2,
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": name, "arguments": map[string]interface{}{}, "_meta": meta},
+	}
+	requestJSON, _ := json.Marshal(request)
+	responseBytes, err := server.HandleMessage(srv.GetServer(), requestJSON)
+	if err != nil {
+		t.Fatalf("Failed to call tool: %v", err)
+	}
+
+	var response struct {
+		Result struct {
+			Content []map[string]interface{} `json:"content"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(responseBytes, &response); err != nil || len(response.Result.Content) == 0 {
+		t.Fatalf("Unexpected tools/call response: %s", responseBytes)
+	}
+	text, _ := response.Result.Content[0]["text"].(string)
+	return text
+}
+
+func TestLocalizeUsesInitializeLocale(t *testing.T) {
+	srv := newLocalizedServer(t)
+	initializeWithMeta(t, srv, map[string]interface{}{"locale": "fr-CA"})
+
+	if got := firstContent(t, callTool(t, srv, "greet"))["text"]; got != "Bonjour, Ada !" {
+		t.Errorf("Expected French greeting, got %q", got)
+	}
+}
+
+func TestLocalizeRequestLocaleOverridesSession(t *testing.T) {
+	srv := newLocalizedServer(t)
+	initializeWithMeta(t, srv, map[string]interface{}{"locale": "fr"})
+
+	// Accept-Language style values are accepted, as sent by HTTP transports
+	got := callToolWithMeta(t, srv, "greet", map[string]interface{}{"locale": "de-DE,de;q=0.9,en;q=0.5"})
+	if got != "Hallo, Ada!" {
+		t.Errorf("Expected German greeting, got %q", got)
+	}
+}
+
+func TestLocalizeFallsBackToDefault(t *testing.T) {
+	srv := newLocalizedServer(t)
+	initializeWithMeta(t, srv, map[string]interface{}{"locale": "ja"})
+
+	if got := firstContent(t, callTool(t, srv, "greet"))["text"]; got != "Hello, Ada!" {
+		t.Errorf("Expected default English greeting, got %q", got)
+	}
+	if got := firstContent(t, callTool(t, srv, "unknown"))["text"]; got != "Missing" {
+		t.Errorf("Expected message ID for unknown message, got %q", got)
+	}
+}
@@ -0,0 +1,49 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestEachToolInNameOrder(t *testing.T) {
+	srv := server.NewServer("iterate-test")
+	for _, name := range []string{"charlie", "alpha", "bravo"} {
+		srv.Tool(name, "Tool "+name, func(ctx *server.Context, args interface{}) (interface{}, error) {
+			return nil, nil
+		})
+	}
+
+	var names []string
+	for tool := range srv.EachTool {
+		names = append(names, tool.Name)
+	}
+	if len(names) != 3 || names[0] != "alpha" || names[1] != "bravo" || names[2] != "charlie" {
+		t.Errorf("Unexpected order: %v", names)
+	}
+}
+
+func TestEachResourceAllowsRegistrationInLoop(t *testing.T) {
+	srv := server.NewServer("iterate-test").
+		Resource("docs://a", "A", func(ctx *server.Context, args interface{}) (interface{}, error) { return "a", nil }).
+		Resource("docs://b", "B", func(ctx *server.Context, args interface{}) (interface{}, error) { return "b", nil })
+
+	count := 0
+	for resource := range srv.EachResource {
+		count++
+		// Registering while iterating must not deadlock or extend the loop
+		srv.Resource(resource.Path+"/copy", "Copy", func(ctx *server.Context, args interface{}) (interface{}, error) { return "", nil })
+		break
+	}
+	if count != 1 {
+		t.Errorf("Expected loop to stop after one resource, got %d", count)
+	}
+
+	var prompts int
+	for range srv.EachPrompt {
+		prompts++
+	}
+	if prompts != 0 {
+		t.Errorf("Expected no prompts, got %d", prompts)
+	}
+}
@@ -0,0 +1,121 @@
+package test
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/client"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/inproc"
+	"github.com/localrivet/gomcp/webhook"
+)
+
+// sessionEnds collects the reasons of session.ended events.
+func sessionEnds() (chan string, server.Option) {
+	ends := make(chan string, 8)
+	return ends, server.WithEventHandler(func(eventType string, data map[string]interface{}) {
+		if eventType == webhook.EventSessionEnded {
+			ends <- data["reason"].(string)
+		}
+	})
+}
+
+func TestKeepaliveClosesUnresponsiveSessions(t *testing.T) {
+	ends, onEvent := sessionEnds()
+	recorder := NewRecordingTransport()
+	srv := server.NewServer("keepalive-test",
+		server.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		server.WithTransport(recorder),
+		server.WithKeepalive(server.KeepaliveOptions{
+			Interval:    10 * time.Millisecond,
+			Timeout:     5 * time.Millisecond,
+			MaxFailures: 2,
+		}),
+		onEvent,
+	)
+	go srv.Run()
+	handleRaw(t, srv, memoryInitialize)
+
+	// The recording transport never delivers the pings
+	select {
+	case reason := <-ends:
+		if reason != "keepalive_timeout" {
+			t.Errorf("Expected the session to end with keepalive_timeout, got %q", reason)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the unresponsive session to be closed")
+	}
+	if pings := recorder.SentWithMethod("ping"); len(pings) < 2 {
+		t.Errorf("Expected at least 2 pings before closing the session, got %d", len(pings))
+	}
+}
+
+func TestKeepaliveKeepsAnsweringClients(t *testing.T) {
+	ends, onEvent := sessionEnds()
+	c, s := inproc.Pair()
+	srv := server.NewServer("keepalive-test",
+		server.WithTransport(s),
+		server.WithKeepalive(server.KeepaliveOptions{
+			Interval:    10 * time.Millisecond,
+			Timeout:     50 * time.Millisecond,
+			MaxFailures: 1,
+		}),
+		onEvent,
+	)
+	go srv.Run()
+
+	cl, err := client.NewClient("keepalive-client", client.WithInProcess(c), client.WithProtocolVersion("2025-03-26"))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer cl.Close()
+
+	select {
+	case reason := <-ends:
+		t.Fatalf("Expected the session to stay open, ended with %q", reason)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestKeepaliveSparesActiveSessions(t *testing.T) {
+	recorder := NewRecordingTransport()
+	srv := server.NewServer("keepalive-test",
+		server.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		server.WithTransport(recorder),
+		server.WithKeepalive(server.KeepaliveOptions{
+			Interval: 30 * time.Millisecond,
+			Timeout:  5 * time.Millisecond,
+		}),
+	)
+	release := make(chan struct{})
+	srv.Tool("slow", "Runs until released", func(ctx *server.Context, args struct{}) (string, error) {
+		<-release
+		return "done", nil
+	})
+	go srv.Run()
+	handleRaw(t, srv, memoryInitialize)
+
+	// A client that keeps writing isn't idle
+	for i := 0; i < 20; i++ {
+		handleRaw(t, srv, `{"jsonrpc":"2.0","id":10,"method":"tools/list"}`)
+		time.Sleep(5 * time.Millisecond)
+	}
+	if pings := recorder.SentWithMethod("ping"); len(pings) != 0 {
+		t.Errorf("Expected no pings to a client that keeps writing, got %d", len(pings))
+	}
+
+	// Nor is one waiting on a request
+	done := make(chan struct{})
+	go func() {
+		server.HandleMessage(srv.GetServer(), []byte(`{"jsonrpc":"2.0","id":11,"method":"tools/call","params":{"name":"slow","arguments":{}}}`))
+		close(done)
+	}()
+	time.Sleep(150 * time.Millisecond)
+	if pings := recorder.SentWithMethod("ping"); len(pings) != 0 {
+		t.Errorf("Expected no pings while a request is in progress, got %d", len(pings))
+	}
+	close(release)
+	<-done
+}
@@ -0,0 +1,226 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/streamablehttp"
+)
+
+const (
+	lifecycleInitialize  = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1.0"}}}`
+	lifecycleInitialized = `{"jsonrpc":"2.0","method":"notifications/initialized"}`
+	lifecycleToolsList   = `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`
+)
+
+func newLifecycleServer(options ...server.Option) server.Server {
+	return server.NewServer("lifecycle-test", options...).
+		Tool("echo", "Echo", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			return "echo", nil
+		})
+}
+
+func handleRaw(t *testing.T, srv server.Server, message string) map[string]interface{} {
+	t.Helper()
+	responseBytes, err := server.HandleMessage(srv.GetServer(), []byte(message))
+	if err != nil {
+		t.Fatalf("Failed to handle message: %v", err)
+	}
+	if responseBytes == nil {
+		return nil
+	}
+	var response map[string]interface{}
+	if err := json.Unmarshal(responseBytes, &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	return response
+}
+
+func errorCode(response map[string]interface{}) float64 {
+	errObj, _ := response["error"].(map[string]interface{})
+	code, _ := errObj["code"].(float64)
+	return code
+}
+
+func TestLifecycleRejectsEarlyRequests(t *testing.T) {
+	srv := newLifecycleServer(server.WithLifecyclePolicy(server.LifecycleReject))
+
+	if code := errorCode(handleRaw(t, srv, lifecycleToolsList)); code != -32600 {
+		t.Errorf("Expected -32600 before initialize, got %v", code)
+	}
+	if response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":9,"method":"ping"}`); response["error"] != nil {
+		t.Errorf("Expected ping to be allowed before initialize, got %v", response)
+	}
+
+	handleRaw(t, srv, lifecycleInitialize)
+	if code := errorCode(handleRaw(t, srv, lifecycleToolsList)); code != -32600 {
+		t.Errorf("Expected -32600 before initialized notification, got %v", code)
+	}
+
+	handleRaw(t, srv, lifecycleInitialized)
+	if response := handleRaw(t, srv, lifecycleToolsList); response["error"] != nil {
+		t.Errorf("Expected tools/list to succeed after initialization, got %v", response["error"])
+	}
+
+	if got := srv.LifecycleMetrics().RejectedBeforeInitialized; got != 2 {
+		t.Errorf("Expected 2 rejected requests, got %d", got)
+	}
+}
+
+func TestLifecycleRejectsDuplicateInitialize(t *testing.T) {
+	srv := newLifecycleServer(server.WithLifecyclePolicy(server.LifecycleReject))
+
+	if response := handleRaw(t, srv, lifecycleInitialize); response["error"] != nil {
+		t.Fatalf("First initialize failed: %v", response["error"])
+	}
+	if code := errorCode(handleRaw(t, srv, lifecycleInitialize)); code != -32600 {
+		t.Errorf("Expected -32600 for a second initialize, got %v", code)
+	}
+	if got := srv.LifecycleMetrics().DuplicateInitialize; got != 1 {
+		t.Errorf("Expected 1 duplicate initialize, got %d", got)
+	}
+}
+
+func TestLifecycleLenientByDefault(t *testing.T) {
+	srv := newLifecycleServer()
+
+	if response := handleRaw(t, srv, lifecycleToolsList); response["error"] != nil {
+		t.Errorf("Expected early request to be processed by default, got %v", response["error"])
+	}
+	handleRaw(t, srv, lifecycleInitialize)
+	if response := handleRaw(t, srv, lifecycleInitialize); response["error"] != nil {
+		t.Errorf("Expected duplicate initialize to be allowed by default, got %v", response["error"])
+	}
+	if got := srv.LifecycleMetrics().DuplicateInitialize; got != 1 {
+		t.Errorf("Expected duplicate initialize to be counted, got %d", got)
+	}
+}
+
+func TestLifecycleQueuesEarlyRequests(t *testing.T) {
+	recorder := NewRecordingTransport()
+	srv := newLifecycleServer(
+		server.WithTransport(recorder),
+		server.WithLifecyclePolicy(server.LifecycleQueue),
+	)
+
+	handleRaw(t, srv, lifecycleInitialize)
+	if response := handleRaw(t, srv, lifecycleToolsList); response != nil {
+		t.Fatalf("Expected no immediate response for a queued request, got %v", response)
+	}
+	handleRaw(t, srv, lifecycleInitialized)
+
+	var answered bool
+	for _, message := range recorder.Sent() {
+		var response map[string]interface{}
+		if json.Unmarshal(message, &response) == nil && response["id"] == float64(2) && response["result"] != nil {
+			answered = true
+		}
+	}
+	if !answered {
+		t.Error("Expected the queued request to be answered after initialization")
+	}
+	if got := srv.LifecycleMetrics().QueuedBeforeInitialized; got != 1 {
+		t.Errorf("Expected 1 queued request, got %d", got)
+	}
+}
+
+func TestLifecycleInitializeTimeout(t *testing.T) {
+	srv := newLifecycleServer(server.WithInitializeTimeout(20 * time.Millisecond))
+
+	handleRaw(t, srv, lifecycleInitialize)
+
+	deadline := time.Now().Add(time.Second)
+	for srv.LifecycleMetrics().InitializeTimeouts == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := srv.LifecycleMetrics().InitializeTimeouts; got != 1 {
+		t.Fatalf("Expected 1 initialize timeout, got %d", got)
+	}
+
+	// The client may start the handshake again after a timeout
+	if response := handleRaw(t, srv, lifecycleInitialize); response["error"] != nil {
+		t.Errorf("Expected initialize to succeed after a timeout, got %v", response["error"])
+	}
+}
+
+func TestLifecycleTracksEachConnection(t *testing.T) {
+	srv := newLifecycleServer(
+		server.WithLifecyclePolicy(server.LifecycleReject),
+		server.WithInitializeTimeout(50*time.Millisecond),
+	)
+	on := func(connectionID, method string, id int) string {
+		request := map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  method,
+			"params":  map[string]interface{}{"_meta": map[string]interface{}{"connectionId": connectionID}},
+		}
+		if id != 0 {
+			request["id"] = id
+		}
+		if method == "initialize" {
+			params := request["params"].(map[string]interface{})
+			params["protocolVersion"] = "2025-03-26"
+			params["capabilities"] = map[string]interface{}{}
+			params["clientInfo"] = map[string]interface{}{"name": connectionID, "version": "1.0"}
+		}
+		message, _ := json.Marshal(request)
+		return string(message)
+	}
+
+	for _, connection := range []string{"a", "b"} {
+		if response := handleRaw(t, srv, on(connection, "initialize", 1)); response["error"] != nil {
+			t.Fatalf("Expected initialize on connection %s to succeed, got %v", connection, response["error"])
+		}
+	}
+	handleRaw(t, srv, on("a", "notifications/initialized", 0))
+
+	if response := handleRaw(t, srv, on("a", "tools/list", 2)); response["error"] != nil {
+		t.Errorf("Expected tools/list on the initialized connection to succeed, got %v", response["error"])
+	}
+	for _, connection := range []string{"b", "c"} {
+		if code := errorCode(handleRaw(t, srv, on(connection, "tools/list", 2))); code != -32600 {
+			t.Errorf("Expected -32600 on connection %s before its handshake completed, got %v", connection, code)
+		}
+	}
+
+	// Only the connection that didn't complete its handshake times out
+	deadline := time.Now().Add(time.Second)
+	for srv.LifecycleMetrics().InitializeTimeouts < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := srv.LifecycleMetrics().InitializeTimeouts; got != 2 {
+		t.Errorf("Expected connections b and c to time out, got %d timeouts", got)
+	}
+	if response := handleRaw(t, srv, on("a", "tools/list", 3)); response["error"] != nil {
+		t.Errorf("Expected connection a to be unaffected by the others' timeouts, got %v", response["error"])
+	}
+}
+
+func TestEndedConnectionsCloseTheirSessions(t *testing.T) {
+	ends, onEvent := sessionEnds()
+	tr := streamablehttp.NewTransport("127.0.0.1:0")
+	srv := newLifecycleServer(server.WithTransport(tr), onEvent)
+	go srv.Run()
+	t.Cleanup(func() { srv.Shutdown(context.Background()) })
+	ts := httptest.NewServer(tr)
+	t.Cleanup(ts.Close)
+
+	// No session store or keepalive is configured
+	resp, _ := postMCP(t, "POST", ts.URL, "", lifecycleInitialize)
+	sessionID := resp.Header.Get(streamablehttp.SessionIDHeader)
+	postMCP(t, "POST", ts.URL, sessionID, lifecycleInitialized)
+	postMCP(t, "DELETE", ts.URL, sessionID, "")
+
+	select {
+	case reason := <-ends:
+		if reason != "client_closed" {
+			t.Errorf("Expected the session to end with client_closed, got %q", reason)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the session the client ended to be closed")
+	}
+}
@@ -0,0 +1,54 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/util/schema"
+)
+
+type lintSearchArgs struct {
+	Query string   `json:"query"`
+	Tags  []string `json:"tags"`
+}
+
+type lintEchoArgs struct {
+	Message string `json:"message"`
+}
+
+func TestLintAll(t *testing.T) {
+	srv := server.NewServer("lint-test").
+		Tool("search", "Search", func(ctx *server.Context, args lintSearchArgs) (interface{}, error) {
+			return nil, nil
+		}).
+		Tool("echo", "Echo", func(ctx *server.Context, args lintEchoArgs) (interface{}, error) {
+			return nil, nil
+		}).
+		WithSchema("search", map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{"type": "string"},
+				"tags":  map[string]interface{}{"type": "array"},
+			},
+		})
+
+	issues := server.LintAll(srv)
+	if len(issues) != 1 {
+		t.Fatalf("Expected one issue, got %v", issues)
+	}
+	issue := issues[0]
+	if issue.Tool != "search" || issue.Path != "inputSchema.properties.tags.items" || issue.Severity != schema.SeverityError {
+		t.Errorf("Expected the missing items on search to be reported, got %+v", issue)
+	}
+}
+
+func TestLintAllCleanServer(t *testing.T) {
+	srv := server.NewServer("lint-test").
+		Tool("echo", "Echo", func(ctx *server.Context, args lintEchoArgs) (interface{}, error) {
+			return nil, nil
+		})
+
+	for _, issue := range server.LintAll(srv) {
+		t.Error(issue)
+	}
+}
@@ -0,0 +1,70 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestDynamicRegistrationNotifiesClients(t *testing.T) {
+	recorder := NewRecordingTransport()
+	srv := server.NewServer("dynamic", server.WithTransport(recorder))
+
+	counts := func() map[string]int {
+		return map[string]int{
+			"tools":     len(recorder.SentWithMethod("notifications/tools/list_changed")),
+			"resources": len(recorder.SentWithMethod("notifications/resources/list_changed")),
+			"prompts":   len(recorder.SentWithMethod("notifications/prompts/list_changed")),
+		}
+	}
+
+	// Registrations before the handshake aren't announced
+	srv.Resource("/static", "Static resource", func(ctx *server.Context, args interface{}) (string, error) {
+		return "static", nil
+	})
+	srv.Prompt("static", "Static prompt", "Hello")
+	time.Sleep(150 * time.Millisecond)
+	if got := counts(); got["resources"] != 0 || got["prompts"] != 0 {
+		t.Fatalf("Expected no notifications before initialization, got %v", got)
+	}
+
+	initializeWithCapabilities(t, srv, `{}`)
+	handleRaw(t, srv, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	time.Sleep(150 * time.Millisecond)
+	before := counts()
+
+	// A burst of registrations is announced once per list
+	for _, name := range []string{"a", "b", "c"} {
+		srv.Tool(name, "Dynamic tool", func(ctx *server.Context, args struct{}) (string, error) {
+			return "ok", nil
+		})
+	}
+	srv.Resource("/dynamic", "Dynamic resource", func(ctx *server.Context, args interface{}) (string, error) {
+		return "dynamic", nil
+	})
+	srv.Prompt("dynamic", "Dynamic prompt", "Hi")
+	time.Sleep(150 * time.Millisecond)
+
+	after := counts()
+	for _, list := range []string{"tools", "resources", "prompts"} {
+		if after[list]-before[list] != 1 {
+			t.Errorf("Expected one %s/list_changed notification, got %d", list, after[list]-before[list])
+		}
+	}
+
+	if !srv.UnregisterTool("a") || srv.UnregisterTool("a") {
+		t.Fatal("Expected the tool to be unregistered once")
+	}
+	time.Sleep(150 * time.Millisecond)
+	if got := counts()["tools"] - after["tools"]; got != 1 {
+		t.Errorf("Expected unregistering to send one tools/list_changed notification, got %d", got)
+	}
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	result, _ := response["result"].(map[string]interface{})
+	tools, _ := result["tools"].([]interface{})
+	if len(tools) != 2 {
+		t.Errorf("Expected the remaining two tools to be listed, got %v", result)
+	}
+}
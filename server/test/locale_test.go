@@ -0,0 +1,42 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func newLocaleServer() server.Server {
+	return server.NewServer("locale-test").
+		Tool("hints", "Report locale hints", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			return ctx.Locale().String() + " " + ctx.Location().String(), nil
+		})
+}
+
+func TestContextLocaleAndLocation(t *testing.T) {
+	srv := newLocaleServer()
+	initializeWithMeta(t, srv, map[string]interface{}{"locale": "pt-BR", "timezone": "America/Sao_Paulo"})
+
+	if got := firstContent(t, callTool(t, srv, "hints"))["text"]; got != "pt-BR America/Sao_Paulo" {
+		t.Errorf("Unexpected hints: %q", got)
+	}
+
+	// Hints on an individual request take precedence over the session
+	got := callToolWithMeta(t, srv, "hints", map[string]interface{}{"timezone": "Asia/Tokyo"})
+	if got != "pt-BR Asia/Tokyo" {
+		t.Errorf("Expected request time zone to override session, got %q", got)
+	}
+}
+
+func TestContextLocaleDefaults(t *testing.T) {
+	srv := newLocaleServer()
+	initializeWithMeta(t, srv, map[string]interface{}{"timezone": "Not/AZone"})
+
+	want := language.Und.String() + " " + time.UTC.String()
+	if got := firstContent(t, callTool(t, srv, "hints"))["text"]; got != want {
+		t.Errorf("Expected defaults %q, got %q", want, got)
+	}
+}
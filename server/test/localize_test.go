@@ -0,0 +1,71 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func newTranslatedServer() server.Server {
+	srv := server.NewServer("localize-test").
+		Tool("weather", "Current weather of a city", func(ctx *server.Context, args struct {
+			City string `json:"city" description:"The city"`
+		}) (string, error) {
+			return "sunny", nil
+		}).
+		Prompt("forecast", "Forecast for a city", server.User("What is the forecast for {{city}}?"))
+	srv.WithLocalizations("weather", map[string]server.Localization{
+		"fr": {Title: "Météo", Description: "Météo actuelle d'une ville", Arguments: map[string]string{"city": "La ville"}},
+		"de": {Description: "Aktuelles Wetter einer Stadt"},
+	})
+	srv.WithLocalizations("forecast", map[string]server.Localization{
+		"fr": {Description: "Prévisions pour une ville", Arguments: map[string]string{"city": "La ville"}},
+	})
+	return srv
+}
+
+func TestLocalizedDescriptions(t *testing.T) {
+	tests := []struct {
+		locale                     string
+		title, description, city   string
+		promptDescription, argDesc string
+	}{
+		{"fr-CA", "Météo", "Météo actuelle d'une ville", "La ville", "Prévisions pour une ville", "La ville"},
+		{"de-DE,de;q=0.9", "", "Aktuelles Wetter einer Stadt", "The city", "Forecast for a city", "Value for city"},
+		{"ja", "", "Current weather of a city", "The city", "Forecast for a city", "Value for city"},
+	}
+	for _, tc := range tests {
+		srv := newTranslatedServer()
+		initializeWithMeta(t, srv, map[string]interface{}{"locale": tc.locale})
+
+		response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+		tool := response["result"].(map[string]interface{})["tools"].([]interface{})[0].(map[string]interface{})
+		title, _ := tool["title"].(string)
+		if title != tc.title || tool["description"] != tc.description {
+			t.Errorf("%s: unexpected tool text %q, %q", tc.locale, title, tool["description"])
+		}
+		properties := tool["inputSchema"].(map[string]interface{})["properties"].(map[string]interface{})
+		if city := properties["city"].(map[string]interface{})["description"]; city != tc.city {
+			t.Errorf("%s: unexpected argument description %q", tc.locale, city)
+		}
+
+		response = handleRaw(t, srv, `{"jsonrpc":"2.0","id":3,"method":"prompts/list"}`)
+		prompt := response["result"].(map[string]interface{})["prompts"].([]interface{})[0].(map[string]interface{})
+		if prompt["description"] != tc.promptDescription {
+			t.Errorf("%s: unexpected prompt description %q", tc.locale, prompt["description"])
+		}
+		arguments, _ := prompt["arguments"].([]interface{})
+		if len(arguments) != 1 || arguments[0].(map[string]interface{})["description"] != tc.argDesc {
+			t.Errorf("%s: unexpected prompt arguments %v", tc.locale, arguments)
+		}
+	}
+
+	// A request's own locale takes precedence
+	srv := newTranslatedServer()
+	initializeWithMeta(t, srv, map[string]interface{}{"locale": "fr"})
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/list","params":{"_meta":{"locale":"de"}}}`)
+	tool := response["result"].(map[string]interface{})["tools"].([]interface{})[0].(map[string]interface{})
+	if tool["description"] != "Aktuelles Wetter einer Stadt" {
+		t.Errorf("Expected the request's locale to be used, got %q", tool["description"])
+	}
+}
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestLogMessagesHonorSetLevel(t *testing.T) {
+	recorder := NewRecordingTransport()
+	srv := server.NewServer("logging", server.WithTransport(recorder))
+	srv.Tool("work", "Logs at every level", func(ctx *server.Context, args struct{}) (string, error) {
+		for _, level := range []server.LogLevel{server.LogDebug, server.LogInfo, server.LogWarning, server.LogEmergency} {
+			if err := ctx.Log(level, "worker", map[string]interface{}{"level": string(level)}); err != nil {
+				return "", err
+			}
+		}
+		return "done", nil
+	})
+	initializeWithCapabilities(t, srv, `{}`)
+
+	seen := 0
+	levels := func() []string {
+		var levels []string
+		messages := recorder.SentWithMethod("notifications/message")
+		for _, message := range messages[seen:] {
+			params, _ := message["params"].(map[string]interface{})
+			if params["logger"] != "worker" {
+				t.Errorf("Expected the logger name, got %v", params)
+			}
+			level, _ := params["level"].(string)
+			levels = append(levels, level)
+		}
+		seen = len(messages)
+		return levels
+	}
+	call := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"work","arguments":{}}}`
+
+	handleRaw(t, srv, call)
+	if got := levels(); len(got) != 3 || got[0] != "info" {
+		t.Errorf("Expected info and above by default, got %v", got)
+	}
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":3,"method":"logging/setLevel","params":{"level":"warning"}}`)
+	if response["error"] != nil {
+		t.Fatalf("Expected the level to be set, got %v", response)
+	}
+	handleRaw(t, srv, call)
+	if got := levels(); len(got) != 2 || got[0] != "warning" || got[1] != "emergency" {
+		t.Errorf("Expected warning and above, got %v", got)
+	}
+
+	response = handleRaw(t, srv, `{"jsonrpc":"2.0","id":4,"method":"logging/setLevel","params":{"level":"verbose"}}`)
+	if response["error"] == nil {
+		t.Errorf("Expected an unknown level to be rejected, got %v", response)
+	}
+}
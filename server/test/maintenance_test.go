@@ -0,0 +1,52 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	srv := server.NewServer("maintenance-test")
+	srv.Tool("echo", "Echo", func(ctx *server.Context, args struct{}) (string, error) {
+		return "echo", nil
+	})
+	srv.Resource("/status", "Status", func(ctx *server.Context, args interface{}) (string, error) {
+		return "all good", nil
+	})
+
+	callTool := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{}}}`
+	readResource := `{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"/status"}}`
+
+	srv.SetMaintenance(true, "Migrating the database")
+	response := handleRaw(t, srv, callTool)
+	if code := errorCode(response); code != server.MaintenanceCode {
+		t.Fatalf("Expected code %d during maintenance, got %v", server.MaintenanceCode, response)
+	}
+	data, _ := response["error"].(map[string]interface{})["data"].(map[string]interface{})
+	if data["message"] != "Migrating the database" {
+		t.Errorf("Expected the maintenance message in the error data, got %v", data)
+	}
+	if response := handleRaw(t, srv, readResource); response["error"] != nil {
+		t.Errorf("Expected resources to be served during maintenance, got %v", response["error"])
+	}
+	if response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":4,"method":"tools/list"}`); response["error"] != nil {
+		t.Errorf("Expected tools to be listed during maintenance, got %v", response["error"])
+	}
+
+	health := srv.Health()
+	if health.Maintenance == nil || health.Maintenance.Message != "Migrating the database" {
+		t.Errorf("Expected Health to report maintenance, got %+v", health)
+	}
+	if health.Status != "ok" {
+		t.Errorf("Expected maintenance not to change the status, got %q", health.Status)
+	}
+
+	srv.SetMaintenance(false, "")
+	if response := handleRaw(t, srv, callTool); response["error"] != nil {
+		t.Errorf("Expected tool calls to succeed after maintenance, got %v", response["error"])
+	}
+	if health := srv.Health(); health.Maintenance != nil {
+		t.Errorf("Expected Health to stop reporting maintenance, got %+v", health.Maintenance)
+	}
+}
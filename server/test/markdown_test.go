@@ -0,0 +1,44 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func newMarkdownServer() server.Server {
+	return server.NewServer("markdown-test", server.WithMarkdown(server.MarkdownPolicy{
+		LinkBase:        "docs://handbook/",
+		StripHTML:       true,
+		NormalizeFences: true,
+	})).Tool("page", "Render a page", func(ctx *server.Context, args struct{}) (string, error) {
+		return "<b>See</b> [setup](setup.md)\n~~~go\nx := 1\n~~~", nil
+	})
+}
+
+func callPageText(t *testing.T, srv server.Server) string {
+	t.Helper()
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"page","arguments":{}}}`)
+	content := response["result"].(map[string]interface{})["content"].([]interface{})
+	return content[0].(map[string]interface{})["text"].(string)
+}
+
+func TestMarkdownPostProcessing(t *testing.T) {
+	srv := newMarkdownServer()
+	initializeWithCapabilities(t, srv, `{}`)
+
+	want := "See [setup](docs://handbook/setup.md)\n```go\nx := 1\n```"
+	if text := callPageText(t, srv); text != want {
+		t.Errorf("Expected %q, got %q", want, text)
+	}
+}
+
+func TestMarkdownKeepsHTMLForCapableClients(t *testing.T) {
+	srv := newMarkdownServer()
+	initializeWithCapabilities(t, srv, `{"experimental":{"markdown":{"html":true}}}`)
+
+	want := "<b>See</b> [setup](docs://handbook/setup.md)\n```go\nx := 1\n```"
+	if text := callPageText(t, srv); text != want {
+		t.Errorf("Expected %q, got %q", want, text)
+	}
+}
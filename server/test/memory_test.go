@@ -0,0 +1,130 @@
+package test
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/webhook"
+)
+
+const memoryInitialize = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1.0"}}}`
+
+func waitForPressure(t *testing.T, srv server.Server, want server.MemoryPressure) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for srv.MemoryPressure() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected memory pressure %v, got %v", want, srv.MemoryPressure())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMemoryPressureShedsNewSessions(t *testing.T) {
+	var used atomic.Uint64
+	var mu sync.Mutex
+	var levels []string
+	changes := make(chan server.MemoryPressure, 8)
+
+	srv := server.NewServer("memory-test",
+		server.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		server.WithTransport(NewRecordingTransport()),
+		server.WithMemoryPressure(server.MemoryPolicy{
+			Limit:    1000,
+			Interval: 5 * time.Millisecond,
+			Sample: func() server.MemoryUsage {
+				return server.MemoryUsage{Runtime: used.Load()}
+			},
+			OnChange: func(level server.MemoryPressure, _ server.MemoryUsage) {
+				changes <- level
+			},
+		}),
+		server.WithEventHandler(func(eventType string, data map[string]interface{}) {
+			if eventType == webhook.EventMemoryPressure {
+				mu.Lock()
+				levels = append(levels, data["level"].(string))
+				mu.Unlock()
+			}
+		}),
+	)
+	go srv.Run()
+
+	used.Store(950)
+	waitForPressure(t, srv, server.MemoryCritical)
+	response := handleRaw(t, srv, memoryInitialize)
+	if code := errorCode(response); code != server.RateLimitedCode {
+		t.Fatalf("Expected initialize to be rejected with %d, got %v", server.RateLimitedCode, response)
+	}
+
+	// Just under the threshold stays critical rather than flapping
+	used.Store(880)
+	time.Sleep(30 * time.Millisecond)
+	if level := srv.MemoryPressure(); level != server.MemoryCritical {
+		t.Errorf("Expected pressure to hold at critical, got %v", level)
+	}
+
+	used.Store(820)
+	waitForPressure(t, srv, server.MemoryHigh)
+	if response := handleRaw(t, srv, memoryInitialize); response["error"] != nil {
+		t.Errorf("Expected initialize to succeed under high pressure, got %v", response["error"])
+	}
+
+	used.Store(100)
+	waitForPressure(t, srv, server.MemoryNormal)
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"critical", "high", "normal"}
+	if len(levels) != len(want) {
+		t.Fatalf("Expected events %v, got %v", want, levels)
+	}
+	for i := range want {
+		if levels[i] != want[i] {
+			t.Errorf("Expected events %v, got %v", want, levels)
+			break
+		}
+	}
+	for _, level := range []server.MemoryPressure{server.MemoryCritical, server.MemoryHigh, server.MemoryNormal} {
+		if got := <-changes; got != level {
+			t.Errorf("Expected OnChange with %v, got %v", level, got)
+		}
+	}
+}
+
+func TestMemoryPressureCoalescesResourceUpdates(t *testing.T) {
+	var used atomic.Uint64
+	recorder := NewRecordingTransport()
+	srv := server.NewServer("memory-coalesce",
+		server.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		server.WithTransport(recorder),
+		server.WithResourceUpdateWindow(0),
+		server.WithMemoryPressure(server.MemoryPolicy{
+			Limit:          1000,
+			Interval:       5 * time.Millisecond,
+			CoalesceWindow: 50 * time.Millisecond,
+			Sample: func() server.MemoryUsage {
+				return server.MemoryUsage{Runtime: used.Load()}
+			},
+		}),
+	)
+	srv.Resource("/data", "data", func(ctx *server.Context) (string, error) { return "x", nil })
+	go srv.Run()
+
+	handleRaw(t, srv, memoryInitialize)
+	handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"/data"}}`)
+
+	used.Store(850)
+	waitForPressure(t, srv, server.MemoryHigh)
+	for i := 0; i < 5; i++ {
+		srv.NotifyResourceUpdated("/data")
+	}
+	time.Sleep(150 * time.Millisecond)
+	if sent := recorder.SentWithMethod("notifications/resources/updated"); len(sent) != 1 {
+		t.Errorf("Expected updates to be coalesced into 1 notification under pressure, got %d", len(sent))
+	}
+}
@@ -0,0 +1,41 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestContextMeta(t *testing.T) {
+	srv := server.NewServer("meta-test").
+		Tool("meta", "Report request meta", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			meta := ctx.Meta()
+			return fmt.Sprintf("%v|%s|%s|%s", meta.ProgressToken(), meta.TraceParent(), meta.IdempotencyKey(), meta.String("tenant")), nil
+		})
+
+	got := callToolWithMeta(t, srv, "meta", map[string]interface{}{
+		"progressToken":  "p-1",
+		"traceparent":    "00-abc-def-01",
+		"idempotencyKey": "order-42",
+		"tenant":         "acme",
+	})
+	if want := "p-1|00-abc-def-01|order-42|acme"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestContextMetaEmpty(t *testing.T) {
+	srv := server.NewServer("meta-test").
+		Tool("meta", "Report request meta", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			meta := ctx.Meta()
+			if meta == nil {
+				return "nil", nil
+			}
+			return fmt.Sprintf("%d %v", len(meta), meta.ProgressToken()), nil
+		})
+
+	if got := firstContent(t, callTool(t, srv, "meta"))["text"]; got != "0 <nil>" {
+		t.Errorf("Expected empty meta, got %q", got)
+	}
+}
@@ -0,0 +1,46 @@
+package test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	srv := server.NewServer("metrics-test", server.WithMetrics(registry)).
+		Tool("echo", "Echo a message", func(ctx *server.Context, args struct{}) (interface{}, error) {
+			return "ok", nil
+		})
+
+	handleRaw(t, srv, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{}}}`)
+	handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"missing","arguments":{}}}`)
+	handleRaw(t, srv, `{"jsonrpc":"2.0","id":3,"method":"no/such/method"}`)
+
+	admin := httptest.NewServer(srv.AdminHandler(""))
+	defer admin.Close()
+	status, body := adminGet(t, admin.URL+"/metrics", "")
+	if status != 200 {
+		t.Fatalf("Expected /metrics to be served, got %d", status)
+	}
+
+	for _, want := range []string{
+		`mcp_server_requests_total{code="0",method="tools/call"} 1`,
+		`mcp_server_requests_total{code="-32601",method="other"} 1`,
+		`mcp_server_tool_call_duration_seconds_count{outcome="ok",tool="echo"} 1`,
+		`mcp_server_active_sessions 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in metrics:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, `tool="missing"`) {
+		t.Error("Expected calls to unknown tools to be left out of the tool metrics")
+	}
+	if strings.Contains(body, "no/such/method") {
+		t.Error("Expected unknown methods to be counted as other")
+	}
+}
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestMiddlewareOrderAndShortCircuit(t *testing.T) {
+	var mu sync.Mutex
+	var trace []string
+	record := func(entry string) {
+		mu.Lock()
+		trace = append(trace, entry)
+		mu.Unlock()
+	}
+
+	srv := server.NewServer("middleware-test").
+		Tool("echo", "Echo a message", func(ctx *server.Context, args struct {
+			Message string `json:"message"`
+		}) (interface{}, error) {
+			record("handler")
+			return args.Message, nil
+		})
+
+	srv.Use(
+		func(next server.RequestHandler) server.RequestHandler {
+			return func(ctx *server.Context) (interface{}, error) {
+				record("outer:" + ctx.Request.Method)
+				return next(ctx)
+			}
+		},
+		func(next server.RequestHandler) server.RequestHandler {
+			return func(ctx *server.Context) (interface{}, error) {
+				if ctx.Request.Method != "tools/call" {
+					return next(ctx)
+				}
+				var params struct {
+					Arguments map[string]interface{} `json:"arguments"`
+				}
+				json.Unmarshal(ctx.Request.Params, &params)
+				if params.Arguments["message"] == "forbidden" {
+					return nil, &server.RPCError{Code: -32001, Message: "Forbidden", Data: map[string]interface{}{"session": ctx.SessionID()}}
+				}
+				record("inner")
+				return next(ctx)
+			}
+		},
+	)
+
+	initializeWithCapabilities(t, srv, `{}`)
+	mu.Lock()
+	trace = nil
+	mu.Unlock()
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"message":"hi"}}}`)
+	if response["error"] != nil {
+		t.Fatalf("Expected the call to pass through, got %v", response)
+	}
+	if got := []string{"outer:tools/call", "inner", "handler"}; len(trace) != 3 || trace[0] != got[0] || trace[1] != got[1] || trace[2] != got[2] {
+		t.Errorf("Expected middleware to run outermost first, got %v", trace)
+	}
+
+	trace = nil
+	response = handleRaw(t, srv, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo","arguments":{"message":"forbidden"}}}`)
+	if code := errorCode(response); code != -32001 {
+		t.Fatalf("Expected the middleware's error code, got %v", response)
+	}
+	data := response["error"].(map[string]interface{})["data"].(map[string]interface{})
+	if data["session"] == "" {
+		t.Errorf("Expected the session to be visible to middleware, got %v", data)
+	}
+	if len(trace) != 1 {
+		t.Errorf("Expected the handler not to run after a short-circuit, got %v", trace)
+	}
+}
+
+func TestMiddlewareSeesNotifications(t *testing.T) {
+	var methods []string
+	srv := server.NewServer("middleware-test").Use(func(next server.RequestHandler) server.RequestHandler {
+		return func(ctx *server.Context) (interface{}, error) {
+			methods = append(methods, ctx.Request.Method)
+			return next(ctx)
+		}
+	})
+
+	initializeWithCapabilities(t, srv, `{}`)
+	handleRaw(t, srv, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if len(methods) != 2 || methods[0] != "initialize" || methods[1] != "notifications/initialized" {
+		t.Errorf("Expected the handshake to pass through middleware, got %v", methods)
+	}
+
+	response, err := server.HandleMessage(srv.GetServer(), []byte(`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"t","progress":1}}`))
+	if err != nil || response != nil {
+		t.Errorf("Expected no response to a notification, got %s (%v)", response, err)
+	}
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func newNegotiatingServer(options ...server.Option) server.Server {
+	return server.NewServer("negotiate-test", options...).
+		Tool("weather", "Current weather", func(ctx *server.Context, args struct{}) (interface{}, error) {
+			return server.Representations{
+				Text:       "Paris: 21C, sunny",
+				Markdown:   "**Paris**: 21C, _sunny_",
+				Structured: map[string]interface{}{"city": "Paris", "temp": 21},
+			}, nil
+		})
+}
+
+func callWeather(t *testing.T, srv server.Server) (string, interface{}) {
+	t.Helper()
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"weather","arguments":{}}}`)
+	result := response["result"].(map[string]interface{})
+	content := result["content"].([]interface{})
+	return content[0].(map[string]interface{})["text"].(string), result["structuredContent"]
+}
+
+func TestContentNegotiationByCapability(t *testing.T) {
+	legacy := newNegotiatingServer()
+	initializeWithCapabilities(t, legacy, `{}`)
+	text, structured := callWeather(t, legacy)
+	if text != "Paris: 21C, sunny" || structured != nil {
+		t.Errorf("Expected plain text only for a legacy client, got %q and %v", text, structured)
+	}
+
+	modern := newNegotiatingServer()
+	initializeWithCapabilities(t, modern, `{"experimental":{"markdown":{},"structuredContent":true}}`)
+	text, structured = callWeather(t, modern)
+	if text != "**Paris**: 21C, _sunny_" {
+		t.Errorf("Expected Markdown for a client declaring it, got %q", text)
+	}
+	if structured.(map[string]interface{})["city"] != "Paris" {
+		t.Errorf("Expected structured content for a client declaring it, got %v", structured)
+	}
+}
+
+func TestContentNegotiationByName(t *testing.T) {
+	srv := newNegotiatingServer(server.WithContentNegotiation(func(client server.ClientInfo) server.ClientFormats {
+		if client.Name == "test" {
+			return server.ClientFormats{Structured: true}
+		}
+		return server.DefaultClientFormats(client)
+	}))
+	initializeWithCapabilities(t, srv, `{"experimental":{"markdown":{}}}`)
+
+	text, structured := callWeather(t, srv)
+	if text != "Paris: 21C, sunny" || structured == nil {
+		t.Errorf("Expected text and structured content for the named host, got %q and %v", text, structured)
+	}
+}
@@ -0,0 +1,117 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/auth"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/streamablehttp"
+)
+
+func TestOAuthProtectsStreamableHTTP(t *testing.T) {
+	// Opaque tokens of the form "<subject>:<scopes>"
+	validator := auth.TokenValidatorFunc(func(ctx context.Context, raw string) (*auth.Token, error) {
+		subject, scopes, ok := strings.Cut(raw, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown token", auth.ErrInvalidToken)
+		}
+		return &auth.Token{Subject: subject, Scopes: strings.Fields(scopes)}, nil
+	})
+
+	tr := streamablehttp.NewTransport("127.0.0.1:0")
+	srv := server.NewServer("oauth-test", server.WithTransport(tr), server.WithOAuth(server.OAuthConfig{
+		Metadata: auth.ProtectedResourceMetadata{
+			Resource:             "https://mcp.example.com",
+			AuthorizationServers: []string{"https://auth.example.com"},
+		},
+		Validator:  validator,
+		ToolScopes: map[string][]string{"delete": {"files:write"}},
+	}))
+	srv.Tool("delete", "Delete a file", func(ctx *server.Context, args struct{}) (string, error) {
+		return "deleted by " + ctx.Principal().Subject, nil
+	})
+	srv.Tool("purge", "Purge all files", func(ctx *server.Context, args struct{}) (string, error) {
+		return "purged", nil
+	}).WithRequiredScopes("purge", "admin")
+	go srv.Run()
+	defer srv.Shutdown(context.Background())
+	ts := httptest.NewServer(tr)
+	defer ts.Close()
+
+	post := func(token, sessionID, body string) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if sessionID != "" {
+			req.Header.Set(streamablehttp.SessionIDHeader, sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp, string(data)
+	}
+
+	// Wait for Run to have wrapped the endpoint
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","clientInfo":{"name":"c","version":"1"},"capabilities":{}}}`
+	for deadline := time.Now().Add(2 * time.Second); ; {
+		resp, _ := post("", "", initialize)
+		if resp.StatusCode == http.StatusUnauthorized {
+			if !strings.Contains(resp.Header.Get("WWW-Authenticate"), "resource_metadata=") {
+				t.Errorf("Expected a challenge, got %q", resp.Header.Get("WWW-Authenticate"))
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected a 401 without a token, got %d", resp.StatusCode)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	resp, body := post("alice:files:read", "", initialize)
+	sessionID := resp.Header.Get(streamablehttp.SessionIDHeader)
+	if resp.StatusCode != http.StatusOK || sessionID == "" {
+		t.Fatalf("Initialize failed: %d %s", resp.StatusCode, body)
+	}
+	post("alice:files:read", sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	call := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"delete","arguments":{}}}`
+	resp, _ = post("alice:files:read", sessionID, call)
+	if resp.StatusCode != http.StatusForbidden || !strings.Contains(resp.Header.Get("WWW-Authenticate"), `scope="files:write"`) {
+		t.Errorf("Expected an insufficient_scope challenge, got %d %q", resp.StatusCode, resp.Header.Get("WWW-Authenticate"))
+	}
+
+	_, body = post("alice:files:read files:write", sessionID, call)
+	if text := toolText(t, body); text != "deleted by alice" {
+		t.Errorf("Expected the handler to see the subject, got %q", text)
+	}
+
+	// Scopes set on the tool are enforced at the HTTP layer too
+	purge := `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"purge","arguments":{}}}`
+	if resp, _ := post("alice:files:write", sessionID, purge); !strings.Contains(resp.Header.Get("WWW-Authenticate"), `scope="admin"`) {
+		t.Errorf("Expected a challenge for the tool's scope, got %d %q", resp.StatusCode, resp.Header.Get("WWW-Authenticate"))
+	}
+
+	// Clients can't pose as another subject through the request metadata
+	forged := `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"delete","arguments":{},"_meta":{"authSubject":"mallory"}}}`
+	if _, body := post("alice:files:write", sessionID, forged); toolText(t, body) != "deleted by alice" {
+		t.Errorf("Expected the token's subject, got %s", body)
+	}
+
+	doc := srv.GetServer().DiscoveryDocument()
+	if doc.Auth == nil || doc.Auth.Type != "oauth2" || len(doc.Auth.Scopes) != 1 || doc.Auth.Scopes[0] != "files:write" {
+		t.Errorf("Unexpected discovery auth %+v", doc.Auth)
+	}
+}
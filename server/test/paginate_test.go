@@ -0,0 +1,89 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func newPagedServer(options ...server.Option) server.Server {
+	srv := server.NewServer("paginate-test", options...)
+	for _, name := range []string{"echo", "alpha", "delta", "charlie", "bravo"} {
+		srv.Tool(name, "Tool "+name, func(ctx *server.Context, args struct{}) (string, error) {
+			return "", nil
+		})
+	}
+	return srv
+}
+
+// listToolPage requests a tools/list page and returns its tool names and
+// next cursor.
+func listToolPage(t *testing.T, srv server.Server, cursor string) ([]string, string) {
+	t.Helper()
+	params := "{}"
+	if cursor != "" {
+		params = fmt.Sprintf(`{"cursor":%q}`, cursor)
+	}
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":1,"method":"tools/list","params":`+params+`}`)
+	result, ok := response["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected a result, got %v", response)
+	}
+	var names []string
+	for _, tool := range result["tools"].([]interface{}) {
+		names = append(names, tool.(map[string]interface{})["name"].(string))
+	}
+	next, _ := result["nextCursor"].(string)
+	return names, next
+}
+
+func TestToolListPagination(t *testing.T) {
+	srv := newPagedServer(server.WithPageSize(2))
+
+	var pages [][]string
+	cursor := ""
+	for {
+		names, next := listToolPage(t, srv, cursor)
+		pages = append(pages, names)
+		if next == "" {
+			break
+		}
+		if len(pages) > 5 {
+			t.Fatal("Expected pagination to end")
+		}
+		cursor = next
+	}
+
+	want := fmt.Sprint([][]string{{"alpha", "bravo"}, {"charlie", "delta"}, {"echo"}})
+	if got := fmt.Sprint(pages); got != want {
+		t.Errorf("Expected pages %s, got %s", want, got)
+	}
+}
+
+func TestToolListWithoutPagination(t *testing.T) {
+	srv := newPagedServer(server.WithPageSize(0))
+	if names, next := listToolPage(t, srv, ""); len(names) != 5 || next != "" {
+		t.Errorf("Expected every tool on one page, got %v and cursor %q", names, next)
+	}
+}
+
+func TestToolListUnpaginatedByDefault(t *testing.T) {
+	srv := server.NewServer("paginate-test")
+	for i := 0; i < 60; i++ {
+		srv.Tool(fmt.Sprintf("tool%02d", i), "A tool", func(ctx *server.Context, args struct{}) (string, error) {
+			return "", nil
+		})
+	}
+	if names, next := listToolPage(t, srv, ""); len(names) != 60 || next != "" {
+		t.Errorf("Expected all 60 tools on one page without WithPageSize, got %d and cursor %q", len(names), next)
+	}
+}
+
+func TestListRejectsInvalidCursor(t *testing.T) {
+	srv := newPagedServer()
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":1,"method":"tools/list","params":{"cursor":"not a cursor!"}}`)
+	if code := errorCode(response); code != -32602 {
+		t.Errorf("Expected -32602 for an invalid cursor, got %v", response)
+	}
+}
@@ -0,0 +1,56 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+type issueSearchArgs struct {
+	server.PageRequest
+	Label string `json:"label"`
+}
+
+func TestPaginatedToolResults(t *testing.T) {
+	issues := []string{"a", "b", "c", "d", "e"}
+	srv := server.NewServer("paginated", server.WithPageSize(2)).
+		Tool("search", "Search the issues", server.Paginated(func(ctx *server.Context, args issueSearchArgs) ([]string, error) {
+			return issues, nil
+		}))
+
+	var items []interface{}
+	args := map[string]interface{}{"label": "bug"}
+	for pages := 1; ; pages++ {
+		response := callToolWithArgs(t, srv, "search", args)
+		result, _ := response["result"].(map[string]interface{})
+		page, ok := result["structuredContent"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected a page as structured content, got %v", response)
+		}
+		items = append(items, page["items"].([]interface{})...)
+		cursor, _ := page["nextCursor"].(string)
+		if cursor == "" {
+			if pages != 3 {
+				t.Errorf("Expected 3 pages, got %d", pages)
+			}
+			break
+		}
+		args = map[string]interface{}{"label": "bug", "cursor": cursor}
+	}
+	if len(items) != 5 || items[0] != "a" || items[4] != "e" {
+		t.Errorf("Expected every item once, got %v", items)
+	}
+
+	first := callToolWithArgs(t, srv, "search", map[string]interface{}{"label": "bug"})
+	cursor := first["result"].(map[string]interface{})["structuredContent"].(map[string]interface{})["nextCursor"].(string)
+	for _, args := range []map[string]interface{}{
+		{"label": "feature", "cursor": cursor},
+		{"label": "bug", "cursor": "not a cursor"},
+	} {
+		response := callToolWithArgs(t, srv, "search", args)
+		result, _ := response["result"].(map[string]interface{})
+		if response["error"] == nil && result["isError"] != true {
+			t.Errorf("Expected the cursor rejected for %v, got %v", args, response)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/auth"
+	"github.com/localrivet/gomcp/persist"
+	"github.com/localrivet/gomcp/protocol"
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestPersistenceReplaysIdempotentCalls(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	calls := 0
+	newServer := func(store persist.Store) server.Server {
+		srv := server.NewServer("persist-test", server.WithPersistence(store))
+		srv.Tool("charge", "Charge a card", func(ctx *server.Context, args struct{}) (string, error) {
+			calls++
+			return "charged", nil
+		})
+		return srv
+	}
+	charge := func(srv server.Server, key string) map[string]interface{} {
+		return handleRaw(t, srv, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"charge","arguments":{},"_meta":{"idempotencyKey":"`+key+`"}}}`)
+	}
+
+	store, err := persist.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	charge(newServer(store), "order-1")
+	store.Close()
+
+	// A restarted server replays the result instead of charging again
+	store, err = persist.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	srv := newServer(store)
+	response := charge(srv, "order-1")
+	content := response["result"].(map[string]interface{})["content"].([]interface{})
+	if text := content[0].(map[string]interface{})["text"]; text != "charged" {
+		t.Errorf("Expected the stored result, got %v", response)
+	}
+	if calls != 1 {
+		t.Errorf("Expected the tool to run once, ran %d times", calls)
+	}
+
+	charge(srv, "order-2")
+	if calls != 2 {
+		t.Errorf("Expected a new key to run the tool, ran %d times", calls)
+	}
+
+	trail, err := srv.AuditTrail(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trail) != 2 || trail[0].Tool != "charge" || trail[0].Outcome != "ok" {
+		t.Errorf("Expected the two executed calls in the audit trail, got %+v", trail)
+	}
+}
+
+func TestIdempotentReplayIsScopedToTheCaller(t *testing.T) {
+	principals := map[string]*auth.Principal{
+		"admin":  {Subject: "admin", Scopes: []string{"admin"}},
+		"admin2": {Subject: "admin2", Scopes: []string{"admin"}},
+		"guest":  {Subject: "guest"},
+	}
+	calls := 0
+	srv := server.NewServer("persist-test",
+		server.WithPersistence(persist.NewMemoryStore()),
+		server.WithMiddleware(func(next server.RequestHandler) server.RequestHandler {
+			return func(ctx *server.Context) (interface{}, error) {
+				ctx.SetPrincipal(principals[ctx.Meta().String("user")])
+				return next(ctx)
+			}
+		}),
+	)
+	srv.Tool("secret", "Reveal the secret", func(ctx *server.Context, args struct{}) (string, error) {
+		calls++
+		return "TOP-SECRET", nil
+	})
+	srv.WithRequiredScopes("secret", "admin")
+	reveal := func(user string) map[string]interface{} {
+		return handleRaw(t, srv, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"secret","arguments":{},"_meta":{"idempotencyKey":"k1","user":"`+user+`"}}}`)
+	}
+
+	if response := reveal("admin"); response["error"] != nil {
+		t.Fatalf("Expected the admin's call to succeed, got %v", response["error"])
+	}
+
+	// A caller without the scope is refused rather than replayed the result
+	response := reveal("guest")
+	if rpcErr, _ := response["error"].(map[string]interface{}); rpcErr == nil || rpcErr["code"] != float64(protocol.Forbidden) {
+		t.Errorf("Expected the guest to be refused, got %v", response)
+	}
+
+	// Another caller with the same key gets a call of their own
+	reveal("admin2")
+	if calls != 2 {
+		t.Errorf("Expected idempotency keys to be scoped to the caller, got %d calls", calls)
+	}
+}
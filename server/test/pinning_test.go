@@ -0,0 +1,148 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func newPinningServer(recorder *RecordingTransport) server.Server {
+	return server.NewServer("pinning-test",
+		server.WithTransport(recorder),
+		server.WithResourceUpdateWindow(0),
+	).
+		Resource("tickets://{id}", "Support ticket", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			return "ticket", nil
+		}).
+		Tool("open", "Open a ticket", func(ctx *server.Context, args struct {
+			ID string `json:"id"`
+		}) (interface{}, error) {
+			if err := ctx.PinResource("tickets://" + args.ID); err != nil {
+				return nil, err
+			}
+			return "opened", nil
+		}).
+		Tool("close", "Close a ticket", func(ctx *server.Context, args struct {
+			ID string `json:"id"`
+		}) (interface{}, error) {
+			ctx.UnpinResource("tickets://" + args.ID)
+			return "closed", nil
+		}).
+		Tool("missing", "Pin a missing resource", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			return nil, ctx.PinResource("nothing://here")
+		})
+}
+
+func callToolWithArgs(t *testing.T, srv server.Server, name string, args map[string]interface{}) map[string]interface{} {
+	t.Helper()
+
+	request := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params":  map[string]interface{}{"name": name, "arguments": args},
+	}
+	requestJSON, _ := json.Marshal(request)
+	responseBytes, err := server.HandleMessage(srv.GetServer(), requestJSON)
+	if err != nil {
+		t.Fatalf("Failed to call %s: %v", name, err)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(responseBytes, &response); err != nil {
+		t.Fatalf("Failed to parse %s response: %v", name, err)
+	}
+	return response
+}
+
+// readPinned reads session://pinned and returns the pinned URIs
+func readPinned(t *testing.T, srv server.Server) []string {
+	t.Helper()
+
+	response := sendResourceRequest(t, srv, "resources/read", server.PinnedResourcesURI)
+	result, ok := response["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected result reading pinned resources, got %v", response)
+	}
+	contents, _ := result["contents"].([]interface{})
+	if len(contents) != 1 {
+		t.Fatalf("Expected one content item, got %v", result)
+	}
+
+	var listing struct {
+		Resources []struct {
+			URI string `json:"uri"`
+		} `json:"resources"`
+	}
+	text, _ := contents[0].(map[string]interface{})["text"].(string)
+	if err := json.Unmarshal([]byte(text), &listing); err != nil {
+		t.Fatalf("Failed to parse pinned listing %q: %v", text, err)
+	}
+
+	uris := make([]string, 0, len(listing.Resources))
+	for _, r := range listing.Resources {
+		uris = append(uris, r.URI)
+	}
+	return uris
+}
+
+func TestPinResource(t *testing.T) {
+	recorder := NewRecordingTransport()
+	srv := newPinningServer(recorder)
+	subscribeResource(t, srv, server.PinnedResourcesURI)
+
+	callToolWithArgs(t, srv, "open", map[string]interface{}{"id": "42"})
+	callToolWithArgs(t, srv, "open", map[string]interface{}{"id": "7"})
+	callToolWithArgs(t, srv, "open", map[string]interface{}{"id": "42"})
+
+	pinned := readPinned(t, srv)
+	if len(pinned) != 2 || pinned[0] != "tickets://42" || pinned[1] != "tickets://7" {
+		t.Errorf("Unexpected pinned resources: %v", pinned)
+	}
+	if got := len(recorder.SentWithMethod("notifications/resources/updated")); got != 2 {
+		t.Errorf("Expected 2 updates for 2 new pins, got %d", got)
+	}
+
+	callToolWithArgs(t, srv, "close", map[string]interface{}{"id": "42"})
+	if pinned := readPinned(t, srv); len(pinned) != 1 || pinned[0] != "tickets://7" {
+		t.Errorf("Expected only tickets://7 after unpin, got %v", pinned)
+	}
+}
+
+func TestPinnedResourcesListed(t *testing.T) {
+	srv := newPinningServer(NewRecordingTransport())
+
+	listed := func() bool {
+		response := sendResourceRequest(t, srv, "resources/list", "")
+		result, _ := response["result"].(map[string]interface{})
+		resources, _ := result["resources"].([]interface{})
+		for _, r := range resources {
+			if r.(map[string]interface{})["uri"] == server.PinnedResourcesURI {
+				return true
+			}
+		}
+		return false
+	}
+
+	if listed() {
+		t.Errorf("Expected session://pinned to be hidden when nothing is pinned")
+	}
+	callToolWithArgs(t, srv, "open", map[string]interface{}{"id": "1"})
+	if !listed() {
+		t.Errorf("Expected session://pinned to be listed after pinning")
+	}
+}
+
+func TestPinUnknownResource(t *testing.T) {
+	srv := newPinningServer(NewRecordingTransport())
+
+	response := callToolWithArgs(t, srv, "missing", map[string]interface{}{})
+	result, _ := response["result"].(map[string]interface{})
+	if response["error"] == nil && result["isError"] != true {
+		t.Errorf("Expected pinning an unknown resource to fail, got %v", response)
+	}
+	if pinned := readPinned(t, srv); len(pinned) != 0 {
+		t.Errorf("Expected nothing pinned, got %v", pinned)
+	}
+}
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func listedTools(t *testing.T, srv server.Server) []string {
+	t.Helper()
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":5,"method":"tools/list"}`)
+	tools, _ := response["result"].(map[string]interface{})["tools"].([]interface{})
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		names = append(names, tool.(map[string]interface{})["name"].(string))
+	}
+	return names
+}
+
+func waitForHealth(t *testing.T, srv server.Server, status string) server.HealthReport {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		report := srv.Health()
+		if report.Status == status && len(report.Tools) > 0 {
+			return report
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected health %q, got %+v", status, report)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestProbesHideUnhealthyTools(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+
+	recorder := NewRecordingTransport()
+	srv := server.NewServer("probe-test",
+		server.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		server.WithTransport(recorder),
+		server.WithProbePolicy(server.ProbePolicy{Interval: 10 * time.Millisecond, HideUnhealthy: true}),
+	)
+	srv.Tool("orders", "Query orders", func(ctx *server.Context, args struct{}) (string, error) {
+		return "ok", nil
+	})
+	srv.Tool("echo", "Echo", func(ctx *server.Context, args struct{}) (string, error) {
+		return "ok", nil
+	})
+	srv.WithProbe("orders", func(ctx context.Context) error {
+		if down.Load() {
+			return errors.New("database unreachable")
+		}
+		return nil
+	})
+	go srv.Run()
+	defer srv.Shutdown(context.Background())
+
+	report := waitForHealth(t, srv, "degraded")
+	if health := report.Tools["orders"]; health.Healthy || health.Error != "database unreachable" {
+		t.Errorf("Expected orders to report its probe error, got %+v", health)
+	}
+	if names := listedTools(t, srv); len(names) != 1 || names[0] != "echo" {
+		t.Errorf("Expected only echo to be listed, got %v", names)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.AdminHandler("").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected /health to answer 503 while degraded, got %d", rec.Code)
+	}
+
+	down.Store(false)
+	waitForHealth(t, srv, "ok")
+	if names := listedTools(t, srv); len(names) != 2 {
+		t.Errorf("Expected orders to be listed again, got %v", names)
+	}
+	rec = httptest.NewRecorder()
+	srv.AdminHandler("").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected /health to answer 200 once healthy, got %d", rec.Code)
+	}
+}
+
+func TestProbeTimeout(t *testing.T) {
+	srv := server.NewServer("probe-timeout",
+		server.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		server.WithTransport(NewRecordingTransport()),
+		server.WithProbePolicy(server.ProbePolicy{Timeout: 20 * time.Millisecond}),
+	)
+	srv.Tool("slow", "Slow", func(ctx *server.Context, args struct{}) (string, error) {
+		return "ok", nil
+	})
+	srv.WithProbe("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(time.Second)
+		return nil
+	})
+	go srv.Run()
+	defer srv.Shutdown(context.Background())
+
+	report := waitForHealth(t, srv, "degraded")
+	if health := report.Tools["slow"]; health.Healthy {
+		t.Errorf("Expected a probe overrunning its timeout to fail, got %+v", health)
+	}
+	// Without HideUnhealthy the tool stays listed
+	if names := listedTools(t, srv); len(names) != 1 {
+		t.Errorf("Expected the tool to stay listed, got %v", names)
+	}
+}
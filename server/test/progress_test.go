@@ -0,0 +1,60 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func newProgressServer(recorder *RecordingTransport) server.Server {
+	return server.NewServer("progress-test", server.WithTransport(recorder)).
+		Tool("import", "Import records", func(ctx *server.Context, args struct {
+			Count int `json:"count"`
+		}) (interface{}, error) {
+			for i := 1; i <= args.Count; i++ {
+				if err := ctx.ReportProgress("importing", float64(i), float64(args.Count)); err != nil {
+					return nil, err
+				}
+			}
+			return "done", nil
+		})
+}
+
+func TestReportProgressUsesRequestToken(t *testing.T) {
+	recorder := NewRecordingTransport()
+	srv := newProgressServer(recorder)
+	initializeWithCapabilities(t, srv, `{}`)
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"import","arguments":{"count":3},"_meta":{"progressToken":"import-1"}}}`)
+	if response["error"] != nil {
+		t.Fatalf("Tool call failed: %v", response)
+	}
+
+	notifications := recorder.SentWithMethod("notifications/progress")
+	if len(notifications) != 3 {
+		t.Fatalf("Expected 3 progress notifications, got %d", len(notifications))
+	}
+	for i, notification := range notifications {
+		params := notification["params"].(map[string]interface{})
+		if params["progressToken"] != "import-1" {
+			t.Errorf("Expected the request's token, got %v", params["progressToken"])
+		}
+		if params["progress"] != float64(i+1) || params["total"] != float64(3) || params["message"] != "importing" {
+			t.Errorf("Unexpected progress params %v", params)
+		}
+	}
+}
+
+func TestReportProgressWithoutToken(t *testing.T) {
+	recorder := NewRecordingTransport()
+	srv := newProgressServer(recorder)
+	initializeWithCapabilities(t, srv, `{}`)
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"import","arguments":{"count":2}}}`)
+	if response["error"] != nil {
+		t.Fatalf("Tool call failed: %v", response)
+	}
+	if notifications := recorder.SentWithMethod("notifications/progress"); len(notifications) != 0 {
+		t.Errorf("Expected no progress notifications without a token, got %v", notifications)
+	}
+}
@@ -0,0 +1,159 @@
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+var promptLibrary = fstest.MapFS{
+	"prompts/summarize.md": {Data: []byte(`---
+name: summarize
+description: Summarize a document
+arguments:
+  - name: text
+    description: The document to summarize
+  - name: style
+    description: Optional summary style
+    required: false
+---
+
+## system
+
+You write concise summaries.
+
+## user
+
+Summarize in {{style}} style:
+
+{{text}}
+`)},
+	"prompts/translate.yaml": {Data: []byte(`name: translate
+description: Translate text
+messages:
+  - role: user
+    content: Translate "{{text}}" into {{language}}.
+`)},
+	"prompts/README.txt": {Data: []byte("not a prompt")},
+}
+
+// listPromptArguments returns the arguments of each prompt keyed by prompt name
+func listPromptArguments(t *testing.T, srv server.Server) map[string]map[string]server.PromptArgument {
+	t.Helper()
+
+	responseBytes, err := server.HandleMessage(srv.GetServer(), []byte(`{"jsonrpc":"2.0","id":1,"method":"prompts/list"}`))
+	if err != nil {
+		t.Fatalf("Failed to list prompts: %v", err)
+	}
+
+	var response struct {
+		Result struct {
+			Prompts []struct {
+				Name        string                  `json:"name"`
+				Description string                  `json:"description"`
+				Arguments   []server.PromptArgument `json:"arguments"`
+			} `json:"prompts"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(responseBytes, &response); err != nil {
+		t.Fatalf("Failed to parse prompts/list response: %v", err)
+	}
+
+	prompts := make(map[string]map[string]server.PromptArgument)
+	for _, p := range response.Result.Prompts {
+		args := make(map[string]server.PromptArgument)
+		for _, arg := range p.Arguments {
+			args[arg.Name] = arg
+		}
+		prompts[p.Name] = args
+	}
+	return prompts
+}
+
+func TestImportPrompts(t *testing.T) {
+	srv := server.NewServer("prompt-library")
+	if err := srv.ImportPrompts(promptLibrary, "prompts/*"); err != nil {
+		t.Fatalf("ImportPrompts failed: %v", err)
+	}
+
+	prompts := listPromptArguments(t, srv)
+	if len(prompts) != 2 {
+		t.Fatalf("Expected 2 prompts, got %v", prompts)
+	}
+
+	summarize := prompts["summarize"]
+	if summarize["text"].Description != "The document to summarize" || !summarize["text"].Required {
+		t.Errorf("Unexpected text argument: %+v", summarize["text"])
+	}
+	if summarize["style"].Required {
+		t.Errorf("Expected style argument to be optional")
+	}
+
+	messages := promptMessages(t, getPrompt(t, srv, "summarize", map[string]interface{}{"text": "Hello", "style": "bullet"}))
+	if len(messages) != 2 || messages[0][0] != "system" || messages[1][1] != "Summarize in bullet style:\n\nHello" {
+		t.Errorf("Unexpected summarize messages: %q", messages)
+	}
+
+	messages = promptMessages(t, getPrompt(t, srv, "translate", map[string]interface{}{"text": "hi", "language": "French"}))
+	if len(messages) != 1 || messages[0][1] != `Translate "hi" into French.` {
+		t.Errorf("Unexpected translate messages: %q", messages)
+	}
+}
+
+func TestExportPromptsRoundTrip(t *testing.T) {
+	for _, format := range []server.PromptFormat{server.PromptFormatMarkdown, server.PromptFormatYAML} {
+		t.Run(string(format), func(t *testing.T) {
+			original := server.NewServer("prompt-library")
+			if err := original.ImportPrompts(promptLibrary, "prompts/*"); err != nil {
+				t.Fatalf("ImportPrompts failed: %v", err)
+			}
+
+			dir := t.TempDir()
+			if err := original.ExportPrompts(dir, format); err != nil {
+				t.Fatalf("ExportPrompts failed: %v", err)
+			}
+
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != 2 {
+				t.Fatalf("Expected 2 exported files, got %d", len(entries))
+			}
+
+			first, _ := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+			if err := original.ExportPrompts(dir, format); err != nil {
+				t.Fatalf("Second ExportPrompts failed: %v", err)
+			}
+			second, _ := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+			if string(first) != string(second) {
+				t.Errorf("Expected export to be deterministic")
+			}
+
+			restored := server.NewServer("restored")
+			if err := restored.ImportPrompts(os.DirFS(dir), "*"); err != nil {
+				t.Fatalf("Re-import failed: %v", err)
+			}
+
+			want := listPromptArguments(t, original)
+			got := listPromptArguments(t, restored)
+			if len(got) != len(want) {
+				t.Fatalf("Expected %d prompts after round trip, got %d", len(want), len(got))
+			}
+			for name, args := range want {
+				for argName, arg := range args {
+					if got[name][argName] != arg {
+						t.Errorf("Argument %s.%s = %+v, want %+v", name, argName, got[name][argName], arg)
+					}
+				}
+			}
+
+			messages := promptMessages(t, getPrompt(t, restored, "summarize", map[string]interface{}{"text": "Hello", "style": "short"}))
+			if len(messages) != 2 || !strings.Contains(messages[1][1], "Hello") {
+				t.Errorf("Unexpected messages after round trip: %q", messages)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestPromptEmbeddedResource(t *testing.T) {
+	version := 0
+	srv := server.NewServer("embedded-test").
+		Resource("docs://style-guide", "Style guide", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			version++
+			return fmt.Sprintf("Style guide v%d", version), nil
+		}).
+		Resource("tickets://{id}", "Ticket", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			return "Ticket body", nil
+		}).
+		Prompt("review", "Review against the style guide",
+			server.EmbeddedResource("user", "docs://style-guide"),
+			server.EmbeddedResource("user", "tickets://{{ticket}}"),
+			server.User("Review this change: {{diff}}"))
+
+	for want := 1; want <= 2; want++ {
+		response := getPrompt(t, srv, "review", map[string]interface{}{"diff": "+x", "ticket": "42"})
+		result, ok := response["result"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected result, got %v", response)
+		}
+		messages := result["messages"].([]interface{})
+		if len(messages) != 3 {
+			t.Fatalf("Expected 3 messages, got %d", len(messages))
+		}
+
+		content := messages[0].(map[string]interface{})["content"].(map[string]interface{})
+		if content["type"] != "resource" {
+			t.Fatalf("Expected resource content, got %v", content)
+		}
+		resource := content["resource"].(map[string]interface{})
+		if resource["uri"] != "docs://style-guide" || resource["text"] != fmt.Sprintf("Style guide v%d", want) {
+			t.Errorf("Expected current resource content v%d, got %v", want, resource)
+		}
+		if resource["mimeType"] == nil {
+			t.Errorf("Expected mimeType on embedded resource, got %v", resource)
+		}
+
+		ticket := messages[1].(map[string]interface{})["content"].(map[string]interface{})["resource"].(map[string]interface{})
+		if ticket["uri"] != "tickets://42" || ticket["text"] != "Ticket body" {
+			t.Errorf("Unexpected templated resource: %v", ticket)
+		}
+	}
+}
+
+func TestPromptEmbeddedResourceMissing(t *testing.T) {
+	srv := server.NewServer("embedded-test").
+		Prompt("broken", "References a missing resource", server.EmbeddedResource("user", "docs://missing"))
+
+	if response := getPrompt(t, srv, "broken", map[string]interface{}{}); response["error"] == nil {
+		t.Errorf("Expected error for missing embedded resource, got %v", response["result"])
+	}
+}
@@ -0,0 +1,140 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// getPrompt sends a prompts/get request and returns the decoded response
+func getPrompt(t *testing.T, srv server.Server, name string, args map[string]interface{}) map[string]interface{} {
+	t.Helper()
+
+	request := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "prompts/get",
+		"params":  map[string]interface{}{"name": name, "arguments": args},
+	}
+	requestJSON, _ := json.Marshal(request)
+
+	responseBytes, err := server.HandleMessage(srv.GetServer(), requestJSON)
+	if err != nil {
+		t.Fatalf("Failed to handle prompts/get: %v", err)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(responseBytes, &response); err != nil {
+		t.Fatalf("Failed to parse prompts/get response: %v", err)
+	}
+	return response
+}
+
+// promptMessages returns the role and text of each rendered message
+func promptMessages(t *testing.T, response map[string]interface{}) [][2]string {
+	t.Helper()
+
+	result, ok := response["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected result, got %v", response)
+	}
+	raw, _ := result["messages"].([]interface{})
+
+	var messages [][2]string
+	for _, m := range raw {
+		msg := m.(map[string]interface{})
+		text, _ := msg["content"].(string)
+		if content, ok := msg["content"].(map[string]interface{}); ok {
+			text, _ = content["text"].(string)
+		}
+		messages = append(messages, [2]string{msg["role"].(string), text})
+	}
+	return messages
+}
+
+func TestPromptFuncs(t *testing.T) {
+	srv := server.NewServer("prompt-funcs",
+		server.WithPromptFuncs(template.FuncMap{
+			"upper": strings.ToUpper,
+			"truncate": func(n int, s string) string {
+				if len(s) > n {
+					return s[:n]
+				}
+				return s
+			},
+			"fail": func(s string) (string, error) { return "", errors.New("boom") },
+		}),
+	).
+		Prompt("review", "Review code",
+			server.User(`Review this {{upper language}} snippet: {{code | truncate 5}} ({{ "done" | upper }})`)).
+		Prompt("broken", "Broken", server.User("{{fail text}}"))
+
+	messages := promptMessages(t, getPrompt(t, srv, "review", map[string]interface{}{"language": "go", "code": "fmt.Println()"}))
+	if len(messages) != 1 || messages[0][1] != "Review this GO snippet: fmt.P (DONE)" {
+		t.Errorf("Unexpected rendered prompt: %v", messages)
+	}
+
+	if response := getPrompt(t, srv, "broken", map[string]interface{}{"text": "x"}); response["error"] == nil {
+		t.Errorf("Expected function error to fail the request, got %v", response["result"])
+	}
+}
+
+func TestPromptFuncNamesAreNotArguments(t *testing.T) {
+	srv := server.NewServer("prompt-funcs",
+		server.WithPromptFuncs(template.FuncMap{"upper": strings.ToUpper}),
+	).Prompt("shout", "Shout", server.User("{{upper message}}"))
+
+	request := []byte(`{"jsonrpc":"2.0","id":1,"method":"prompts/list"}`)
+	responseBytes, err := server.HandleMessage(srv.GetServer(), request)
+	if err != nil {
+		t.Fatalf("Failed to list prompts: %v", err)
+	}
+
+	var response struct {
+		Result struct {
+			Prompts []struct {
+				Arguments []server.PromptArgument `json:"arguments"`
+			} `json:"prompts"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(responseBytes, &response); err != nil || len(response.Result.Prompts) != 1 {
+		t.Fatalf("Unexpected prompts/list response: %s", responseBytes)
+	}
+
+	args := response.Result.Prompts[0].Arguments
+	if len(args) != 1 || args[0].Name != "message" {
+		t.Errorf("Expected single argument 'message', got %v", args)
+	}
+}
+
+func TestPromptPartials(t *testing.T) {
+	srv := server.NewServer("prompt-partials",
+		server.WithPromptPartial("tone", server.System("You are a friendly agent for {{product}}.")),
+		server.WithPromptPartial("signoff", server.User("Always thank the customer.")),
+	).
+		Prompt("refund", "Handle a refund",
+			server.Partial("tone"),
+			server.User("{{> signoff}} The customer writes: {{message}}"))
+
+	messages := promptMessages(t, getPrompt(t, srv, "refund", map[string]interface{}{
+		"product": "Acme",
+		"message": "I want my money back",
+	}))
+
+	want := [][2]string{
+		{"system", "You are a friendly agent for Acme."},
+		{"user", "Always thank the customer. The customer writes: I want my money back"},
+	}
+	if len(messages) != len(want) {
+		t.Fatalf("Expected %d messages, got %v", len(want), messages)
+	}
+	for i := range want {
+		if messages[i] != want[i] {
+			t.Errorf("Message %d = %v, want %v", i, messages[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/util/schema"
+)
+
+func listedCountType(t *testing.T, srv server.Server, clientName string) interface{} {
+	t.Helper()
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"` + clientName + `","version":"1.0"}}}`
+	if _, err := server.HandleMessage(srv.GetServer(), []byte(initialize)); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+	tool := response["result"].(map[string]interface{})["tools"].([]interface{})[0].(map[string]interface{})
+	properties := tool["inputSchema"].(map[string]interface{})["properties"].(map[string]interface{})
+	return properties["count"].(map[string]interface{})["type"]
+}
+
+func newQuirkyServer(options ...server.Option) server.Server {
+	return server.NewServer("quirks-test", options...).
+		Tool("count", "Count things", func(ctx *server.Context, args struct {
+			Count int `json:"count"`
+		}) (interface{}, error) {
+			return args.Count, nil
+		})
+}
+
+func TestHostQuirksAppliedByClientName(t *testing.T) {
+	option := server.WithHostQuirks("Acme-Desktop", schema.IntegerAsNumber)
+
+	if got := listedCountType(t, newQuirkyServer(option), "acme-desktop"); got != "number" {
+		t.Errorf("Expected integer rewritten to number for the quirky host, got %v", got)
+	}
+	if got := listedCountType(t, newQuirkyServer(option), "other-host"); got != "integer" {
+		t.Errorf("Expected the schema unchanged for other hosts, got %v", got)
+	}
+}
+
+func TestHostQuirksOverride(t *testing.T) {
+	if _, ok := server.DefaultHostQuirks()["gemini-cli"]; !ok {
+		t.Fatal("Expected a default entry for gemini-cli")
+	}
+	srv := newQuirkyServer(server.WithHostQuirks("gemini-cli", schema.IntegerAsNumber))
+	if got := listedCountType(t, srv, "gemini-cli"); got != "number" {
+		t.Errorf("Expected the override to replace the default entry, got %v", got)
+	}
+}
@@ -0,0 +1,125 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/localrivet/gomcp/ratelimit"
+	"github.com/localrivet/gomcp/server"
+)
+
+func newQuotaServer(recorder *RecordingTransport, store ratelimit.CounterStore) server.Server {
+	srv := server.NewServer("quota-test",
+		server.WithTransport(recorder),
+		server.WithQuotas(store, server.Quota{Name: "daily", Period: server.QuotaDaily, Soft: 4, Hard: 6}),
+	).
+		Tool("render", "Render a page", func(ctx *server.Context, args struct {
+			Fail bool `json:"fail,omitempty"`
+		}) (interface{}, error) {
+			if args.Fail {
+				return nil, errors.New("render failed")
+			}
+			return "rendered", nil
+		}).
+		Tool("status", "Report status", func(ctx *server.Context, args struct{}) (interface{}, error) {
+			return "ok", nil
+		})
+	return srv.WithCost("render", server.ToolCost{Credits: 2})
+}
+
+func renderWithKey(key string, fail bool) string {
+	args := `{}`
+	if fail {
+		args = `{"fail":true}`
+	}
+	return `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"render","arguments":` + args + `,"_meta":{"apiKey":"` + key + `"}}}`
+}
+
+func TestQuotaSoftAndHardLimits(t *testing.T) {
+	recorder := NewRecordingTransport()
+	srv := newQuotaServer(recorder, ratelimit.NewMemoryStore())
+	initializeWithCapabilities(t, srv, `{}`)
+
+	if response := handleRaw(t, srv, renderWithKey("key-1", false)); response["error"] != nil {
+		t.Fatalf("Expected the first call within quota, got %v", response)
+	}
+	if warnings := recorder.SentWithMethod("notifications/message"); len(warnings) != 0 {
+		t.Fatalf("Expected no warning below the soft limit, got %v", warnings)
+	}
+
+	// The second call reaches the soft limit of 4 credits
+	if response := handleRaw(t, srv, renderWithKey("key-1", false)); response["error"] != nil {
+		t.Fatalf("Expected the second call within quota, got %v", response)
+	}
+	warnings := recorder.SentWithMethod("notifications/message")
+	if len(warnings) != 1 {
+		t.Fatalf("Expected one soft limit warning, got %v", warnings)
+	}
+	params := warnings[0]["params"].(map[string]interface{})
+	data := params["data"].(map[string]interface{})
+	if params["level"] != "warning" || data["quota"] != "daily" || data["used"] != float64(4) {
+		t.Errorf("Unexpected warning %v", params)
+	}
+
+	if response := handleRaw(t, srv, renderWithKey("key-1", false)); response["error"] != nil {
+		t.Fatalf("Expected the third call to reach the hard limit exactly, got %v", response)
+	}
+	if warnings := recorder.SentWithMethod("notifications/message"); len(warnings) != 1 {
+		t.Errorf("Expected the warning to be sent once per period, got %d", len(warnings))
+	}
+
+	response := handleRaw(t, srv, renderWithKey("key-1", false))
+	if code := errorCode(response); code != server.RateLimitedCode {
+		t.Fatalf("Expected code %d over the hard limit, got %v", server.RateLimitedCode, response)
+	}
+	errData := response["error"].(map[string]interface{})["data"].(map[string]interface{})
+	if errData["quota"] != "daily" || errData["used"] != float64(6) {
+		t.Errorf("Unexpected error data %v", errData)
+	}
+	if retryAfter, _ := errData["retryAfter"].(float64); retryAfter <= 0 || retryAfter > 24*60*60 {
+		t.Errorf("Expected a retryAfter before the end of the day, got %v", errData["retryAfter"])
+	}
+
+	// Free tools and other keys are not affected
+	if response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"status","arguments":{},"_meta":{"apiKey":"key-1"}}}`); response["error"] != nil {
+		t.Errorf("Expected a tool without a cost to be allowed, got %v", response)
+	}
+	if response := handleRaw(t, srv, renderWithKey("key-2", false)); response["error"] != nil {
+		t.Errorf("Expected another key to have its own quota, got %v", response)
+	}
+}
+
+func TestQuotaRefundsFailedCalls(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	srv := newQuotaServer(NewRecordingTransport(), store)
+	initializeWithCapabilities(t, srv, `{}`)
+
+	for i := 0; i < 5; i++ {
+		handleRaw(t, srv, renderWithKey("key-1", true))
+	}
+	for i := 0; i < 3; i++ {
+		if response := handleRaw(t, srv, renderWithKey("key-1", false)); response["error"] != nil {
+			t.Fatalf("Call %d: expected failed calls not to use the quota, got %v", i+1, response)
+		}
+	}
+}
+
+func TestQuotaSharedAcrossReplicas(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	replicas := []server.Server{
+		newQuotaServer(NewRecordingTransport(), store),
+		newQuotaServer(NewRecordingTransport(), store),
+	}
+	for _, srv := range replicas {
+		initializeWithCapabilities(t, srv, `{}`)
+	}
+
+	for i := 0; i < 3; i++ {
+		if response := handleRaw(t, replicas[i%2], renderWithKey("key-1", false)); response["error"] != nil {
+			t.Fatalf("Call %d: expected success, got %v", i+1, response)
+		}
+	}
+	if code := errorCode(handleRaw(t, replicas[1], renderWithKey("key-1", false))); code != server.RateLimitedCode {
+		t.Errorf("Expected the quota to count calls made through both replicas, got code %v", code)
+	}
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/ratelimit"
+	"github.com/localrivet/gomcp/server"
+)
+
+func pingWithKey(key string) string {
+	if key == "" {
+		return `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+	}
+	return `{"jsonrpc":"2.0","id":1,"method":"ping","params":{"_meta":{"apiKey":"` + key + `"}}}`
+}
+
+func TestRateLimitSharedAcrossReplicas(t *testing.T) {
+	// Two servers sharing a store stand in for replicas sharing Redis
+	store := ratelimit.NewMemoryStore()
+	rule := server.RateLimitRule{Name: "per-key", Limit: ratelimit.PerMinute(2), Key: server.RateLimitByAPIKey}
+	replicas := []server.Server{
+		server.NewServer("replica-a", server.WithRateLimitStore(store, rule)),
+		server.NewServer("replica-b", server.WithRateLimitStore(store, rule)),
+	}
+
+	for i, srv := range replicas {
+		if response := handleRaw(t, srv, pingWithKey("key-1")); response["error"] != nil {
+			t.Fatalf("Request %d: expected success, got %v", i+1, response)
+		}
+	}
+
+	response := handleRaw(t, replicas[0], pingWithKey("key-1"))
+	if code := errorCode(response); code != server.RateLimitedCode {
+		t.Fatalf("Expected code %d once the key is over its limit, got %v", server.RateLimitedCode, response)
+	}
+	data, _ := response["error"].(map[string]interface{})["data"].(map[string]interface{})
+	if data["rule"] != "per-key" {
+		t.Errorf("Expected the rule name in the error data, got %v", data)
+	}
+	if retryAfter, _ := data["retryAfter"].(float64); retryAfter <= 0 || retryAfter > 60 {
+		t.Errorf("Expected a retryAfter within the window, got %v", data["retryAfter"])
+	}
+
+	if response := handleRaw(t, replicas[1], pingWithKey("key-2")); response["error"] != nil {
+		t.Errorf("Expected another key to have its own limit, got %v", response)
+	}
+	for i := 0; i < 3; i++ {
+		if response := handleRaw(t, replicas[0], pingWithKey("")); response["error"] != nil {
+			t.Errorf("Expected requests without an API key to be exempt, got %v", response)
+		}
+	}
+}
+
+func TestRateLimitSkipsInitialize(t *testing.T) {
+	srv := server.NewServer("ratelimit-init", server.WithRateLimitStore(ratelimit.NewMemoryStore(),
+		server.RateLimitRule{Name: "all", Limit: ratelimit.PerMinute(0), Key: func(*server.Context) string { return "all" }}))
+
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1.0"}}}`
+	if response := handleRaw(t, srv, initialize); response["error"] != nil {
+		t.Fatalf("Expected initialize to be exempt, got %v", response)
+	}
+	if code := errorCode(handleRaw(t, srv, pingWithKey(""))); code != server.RateLimitedCode {
+		t.Errorf("Expected other requests to be limited, got code %v", code)
+	}
+}
+
+func TestRateLimitTokenBuckets(t *testing.T) {
+	srv := server.NewServer("ratelimit-buckets",
+		server.WithRateLimit(0.5, 2, server.RateLimitByTool),
+	)
+	srv.Tool("search", "Search", func(ctx *server.Context, args struct{}) (string, error) {
+		return "results", nil
+	})
+	srv.Tool("echo", "Echo", func(ctx *server.Context, args struct{}) (string, error) {
+		return "echo", nil
+	})
+	call := func(tool string) map[string]interface{} {
+		return handleRaw(t, srv, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"`+tool+`","arguments":{}}}`)
+	}
+
+	for i := 0; i < 2; i++ {
+		if response := call("search"); response["error"] != nil {
+			t.Fatalf("Call %d: expected the burst to be allowed, got %v", i+1, response)
+		}
+	}
+	response := call("search")
+	if code := errorCode(response); code != server.RateLimitedCode {
+		t.Fatalf("Expected code %d once the burst is spent, got %v", server.RateLimitedCode, response)
+	}
+	data, _ := response["error"].(map[string]interface{})["data"].(map[string]interface{})
+	if retryAfter, _ := data["retryAfter"].(float64); retryAfter != 2 {
+		t.Errorf("Expected a retryAfter of 2 seconds, got %v", data["retryAfter"])
+	}
+
+	if response := call("echo"); response["error"] != nil {
+		t.Errorf("Expected another tool to have its own bucket, got %v", response)
+	}
+	for i := 0; i < 3; i++ {
+		if response := handleRaw(t, srv, pingWithKey("")); response["error"] != nil {
+			t.Errorf("Expected requests other than tool calls to be exempt, got %v", response)
+		}
+	}
+}
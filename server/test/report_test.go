@@ -0,0 +1,117 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// minorError is reported below the default severity.
+type minorError struct{}
+
+func (minorError) Error() string             { return "cache miss" }
+func (minorError) Severity() server.Severity { return server.SeverityWarning }
+
+func newReportingServer(minSeverity server.Severity) (server.Server, func() []server.ErrorReport) {
+	var mu sync.Mutex
+	var reports []server.ErrorReport
+	srv := server.NewServer("report-test",
+		server.WithErrorReporting(server.ErrorReporting{
+			Reporter: server.ErrorReporterFunc(func(r server.ErrorReport) {
+				mu.Lock()
+				reports = append(reports, r)
+				mu.Unlock()
+			}),
+			MinSeverity: minSeverity,
+			Release:     "1.4.2",
+			Environment: "staging",
+		}),
+	).
+		Tool("login", "Log in", func(ctx *server.Context, args struct {
+			User     string `json:"user"`
+			Password string `json:"password"`
+		}) (interface{}, error) {
+			return nil, errors.New("account locked")
+		}).
+		Tool("crash", "Always panics", func(ctx *server.Context, args struct {
+			Options map[string]interface{} `json:"options"`
+		}) (interface{}, error) {
+			panic("nil map")
+		}).
+		Tool("lookup", "Fails quietly", func(ctx *server.Context, args struct{}) (interface{}, error) {
+			return nil, minorError{}
+		})
+	return srv, func() []server.ErrorReport {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]server.ErrorReport(nil), reports...)
+	}
+}
+
+func TestReportsPanicsWithContext(t *testing.T) {
+	srv, reports := newReportingServer(0)
+	initializeWithCapabilities(t, srv, `{}`)
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"crash","arguments":{"options":{"authToken":"abc","depth":2}}}}`)
+	if code := errorCode(response); code != -32603 {
+		t.Fatalf("Expected an internal error for the panic, got %v", response)
+	}
+
+	got := reports()
+	if len(got) != 1 {
+		t.Fatalf("Expected one report, got %d", len(got))
+	}
+	report := got[0]
+	if report.Severity != server.SeverityFatal || report.Err.Error() != "nil map" {
+		t.Errorf("Expected a fatal report of the panic, got %v: %v", report.Severity, report.Err)
+	}
+	if !strings.Contains(string(report.Stack), "report_test.go") {
+		t.Errorf("Expected the stack of the handler, got %s", report.Stack)
+	}
+	if report.Tool != "crash" || report.Method != "tools/call" || report.SessionID == "" {
+		t.Errorf("Expected the request context, got tool %q method %q session %q", report.Tool, report.Method, report.SessionID)
+	}
+	if report.Release != "1.4.2" || report.Environment != "staging" || report.Server != "report-test" {
+		t.Errorf("Expected release metadata, got %+v", report)
+	}
+	options := report.Arguments["options"].(map[string]interface{})
+	if options["authToken"] != "[REDACTED]" || options["depth"] != float64(2) {
+		t.Errorf("Expected nested secrets to be redacted, got %v", options)
+	}
+
+	// The server keeps handling requests
+	if response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":3,"method":"ping"}`); response["error"] != nil {
+		t.Errorf("Expected the server to survive the panic, got %v", response)
+	}
+}
+
+func TestReportsHandlerErrorsBySeverity(t *testing.T) {
+	srv, reports := newReportingServer(0)
+	initializeWithCapabilities(t, srv, `{}`)
+
+	handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"login","arguments":{"user":"ada","password":"hunter2"}}}`)
+	handleRaw(t, srv, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"lookup","arguments":{}}}`)
+	handleRaw(t, srv, `{"jsonrpc":"2.0","id":4,"method":"no/such/method"}`)
+
+	got := reports()
+	if len(got) != 1 {
+		t.Fatalf("Expected only the error-level report, got %+v", got)
+	}
+	if got[0].Err.Error() != "account locked" || got[0].Severity != server.SeverityError {
+		t.Errorf("Expected the handler's error, got %v (%v)", got[0].Err, got[0].Severity)
+	}
+	if got[0].Arguments["password"] != "[REDACTED]" || got[0].Arguments["user"] != "ada" {
+		t.Errorf("Expected the password to be redacted, got %v", got[0].Arguments)
+	}
+
+	srv, reports = newReportingServer(server.SeverityWarning)
+	initializeWithCapabilities(t, srv, `{}`)
+	handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"lookup","arguments":{}}}`)
+	handleRaw(t, srv, `{"jsonrpc":"2.0","id":3,"method":"no/such/method"}`)
+	if got := reports(); len(got) != 2 || got[0].Severity != server.SeverityWarning || got[1].Severity != server.SeverityWarning {
+		t.Errorf("Expected two warnings with a lower threshold, got %+v", got)
+	}
+}
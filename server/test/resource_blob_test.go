@@ -0,0 +1,94 @@
+package test
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/localrivet/gomcp/protocol"
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestBinaryResourceContents(t *testing.T) {
+	dir := t.TempDir()
+	// Binary data that starts out looking like text
+	firmware := append([]byte("FIRMWARE v2 build 1187 "), 0xc3, 0x28, 0xa0, 0xa1, 0xff)
+	files := map[string][]byte{
+		"firmware":  firmware,
+		"notes.txt": []byte("caf\xe9"),
+		"data.bin":  firmware,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	png := append([]byte("\x89PNG\r\n\x1a\n"), 0, 0, 0, 13)
+
+	srv := server.NewServer("binary")
+	srv.Resource("file:///firmware", "Firmware", server.WithBinaryContent(filepath.Join(dir, "firmware")))
+	srv.Resource("file:///notes.txt", "Notes", server.WithBinaryContent(filepath.Join(dir, "notes.txt")))
+	srv.Resource("file:///data.bin", "Data", server.WithFileContent(filepath.Join(dir, "data.bin")))
+	srv.Resource("images://logo", "Logo", func(ctx *server.Context, args interface{}) (protocol.BlobResourceContents, error) {
+		return protocol.NewBlobResourceContents("", "", png), nil
+	})
+
+	tests := []struct {
+		uri, mimeType string
+		data          []byte
+	}{
+		{"file:///firmware", "application/octet-stream", firmware},
+		{"file:///notes.txt", "text/plain; charset=utf-8", []byte("caf\xe9")},
+		{"file:///data.bin", "application/octet-stream", firmware},
+		{"images://logo", "image/png", png},
+	}
+	for _, tc := range tests {
+		item := readResourceItem(t, srv, tc.uri)
+		if item["mimeType"] != tc.mimeType {
+			t.Errorf("%s: expected %s, got %v", tc.uri, tc.mimeType, item["mimeType"])
+		}
+		blob, ok := item["blob"].(string)
+		if !ok {
+			t.Errorf("%s: expected a blob, got %v", tc.uri, item)
+			continue
+		}
+		if data, _ := base64.StdEncoding.DecodeString(blob); string(data) != string(tc.data) {
+			t.Errorf("%s: expected the bytes unchanged, got %q", tc.uri, data)
+		}
+	}
+}
+
+func TestResourceSizeLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "large.bin")
+	if err := os.WriteFile(path, make([]byte, 64), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := server.NewServer("limits", server.WithMaxResourceSize(32))
+	srv.Resource("file:///large.bin", "Large", server.WithBinaryContent(path))
+	srv.Resource("file:///small.bin", "Large, with its own limit", server.WithBinaryContent(path, server.WithMaxReadSize(128)))
+	srv.Resource("bytes://large", "Large bytes", func(ctx *server.Context, args interface{}) ([]byte, error) {
+		return make([]byte, 33), nil
+	})
+	srv.Resource("remote://large", "Large remote", func(ctx *server.Context, args interface{}) (interface{}, error) {
+		return nil, fmt.Errorf("download: %w", server.ErrResourceTooLarge)
+	})
+
+	for _, uri := range []string{"file:///large.bin", "bytes://large", "remote://large"} {
+		response := sendResourceRequest(t, srv, "resources/read", uri)
+		rpcErr, _ := response["error"].(map[string]interface{})
+		data, _ := rpcErr["data"].(map[string]interface{})
+		if rpcErr["code"] != float64(protocol.InvalidParams) || data["reason"] != protocol.ReasonResourceTooLarge {
+			t.Errorf("%s: expected a resource too large error, got %v", uri, response)
+		}
+		if data["maxReadSize"] != float64(32) {
+			t.Errorf("%s: expected the limit in the error, got %v", uri, data)
+		}
+	}
+
+	if item := readResourceItem(t, srv, "file:///small.bin"); item["blob"] == nil {
+		t.Errorf("Expected the file read under its own limit, got %v", item)
+	}
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+type userRecord struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestTypedResourceResults(t *testing.T) {
+	report := &closeRecorder{Reader: strings.NewReader("# Weekly report\n\nAll green.")}
+	table := server.NewTable([][]string{{"name", "orders"}, {"Ada", "12"}, {"Grace, R.", "7"}})
+
+	srv := server.NewServer("typed-resources")
+	srv.Resource("users://{id}{?format}", "A user", func(ctx *server.Context, args interface{}) (userRecord, error) {
+		return userRecord{ID: "42", Name: "Ada", Roles: []string{"admin"}}, nil
+	})
+	srv.Resource("reports://weekly.md", "Weekly report", func(ctx *server.Context, args interface{}) (io.Reader, error) {
+		return report, nil
+	})
+	srv.Resource("orders://top.{format}", "Top customers", func(ctx *server.Context, args interface{}) (*server.Table, error) {
+		return table, nil
+	})
+	srv.Resource("bundle://files", "Files", func(ctx *server.Context, args interface{}) ([]server.ContentItem, error) {
+		return []server.ContentItem{
+			server.TextContent("readme"),
+			server.BlobContent("iVBORw0KGgo=", "image/png"),
+		}, nil
+	})
+	server.AddResource(srv, "teams://{team}{?format}", "A team", func(ctx *server.Context, p struct {
+		Team string `uri:"team"`
+	}) (map[string]int, error) {
+		return map[string]int{p.Team: 3}, nil
+	})
+
+	tests := []struct {
+		uri, mimeType, text string
+	}{
+		{"users://42", "application/json", "{\n  \"id\": \"42\",\n  \"name\": \"Ada\",\n  \"roles\": [\n    \"admin\"\n  ]\n}"},
+		{"users://42?format=yaml", "application/yaml", "id: \"42\"\nname: Ada\nroles:\n    - admin\n"},
+		{"users://42?format=text/html,application/json;q=0.9", "application/json", ""},
+		{"reports://weekly.md", "text/markdown", "# Weekly report\n\nAll green."},
+		{"orders://top.csv", "text/csv", "name,orders\nAda,12\n\"Grace, R.\",7\n"},
+		{"orders://top.md", "text/markdown", ""},
+		{"teams://core?format=json", "application/json", "{\n  \"core\": 3\n}"},
+	}
+	for _, tc := range tests {
+		item := readResourceItem(t, srv, tc.uri)
+		if item["mimeType"] != tc.mimeType {
+			t.Errorf("%s: expected %s, got %v", tc.uri, tc.mimeType, item["mimeType"])
+		}
+		if tc.text != "" && item["text"] != tc.text {
+			t.Errorf("%s: expected %q, got %q", tc.uri, tc.text, item["text"])
+		}
+	}
+	if !report.closed {
+		t.Error("Expected the reader to be closed")
+	}
+
+	response := sendResourceRequest(t, srv, "resources/read", "bundle://files")
+	result, _ := response["result"].(map[string]interface{})
+	contents, _ := result["contents"].([]interface{})
+	if len(contents) != 2 {
+		t.Fatalf("Expected two contents items, got %v", response)
+	}
+	if text := contents[0].(map[string]interface{})["text"]; text != "readme" {
+		t.Errorf("Expected the text item, got %v", contents[0])
+	}
+	if blob := contents[1].(map[string]interface{}); blob["blob"] != "iVBORw0KGgo=" || blob["mimeType"] != "image/png" {
+		t.Errorf("Expected the blob item, got %v", blob)
+	}
+
+	response = sendResourceRequest(t, srv, "resources/read", "users://42?format=csv")
+	if response["error"] == nil {
+		t.Errorf("Expected an error for an unavailable format, got %v", response)
+	}
+}
@@ -0,0 +1,156 @@
+package test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func readResource(t *testing.T, srv server.Server, params string) map[string]interface{} {
+	t.Helper()
+	return handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"resources/read","params":`+params+`}`)
+}
+
+func firstContents(t *testing.T, response map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	t.Helper()
+	result, ok := response["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected a result, got %v", response)
+	}
+	contents := result["contents"].([]interface{})
+	meta, _ := result["_meta"].(map[string]interface{})
+	return contents[0].(map[string]interface{}), meta
+}
+
+func TestFileContentText(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	os.WriteFile(path, []byte("hello from disk"), 0o644)
+
+	srv := server.NewServer("file-test").Resource("file:///notes", "Notes", server.WithFileContent(path))
+	initializeWithCapabilities(t, srv, `{}`)
+
+	item, meta := firstContents(t, readResource(t, srv, `{"uri":"file:///notes"}`))
+	if item["text"] != "hello from disk" || item["uri"] != "file:///notes" {
+		t.Errorf("Unexpected contents %v", item)
+	}
+	if !strings.HasPrefix(item["mimeType"].(string), "text/plain") {
+		t.Errorf("Expected a text/plain MIME type, got %v", item["mimeType"])
+	}
+	if _, hasContent := item["content"]; hasContent {
+		t.Errorf("Expected contents in protocol shape, got %v", item)
+	}
+	if meta["size"] != float64(15) || meta["length"] != float64(15) {
+		t.Errorf("Unexpected _meta %v", meta)
+	}
+}
+
+func TestFileContentRanges(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	path := filepath.Join(t.TempDir(), "artifact.bin")
+	os.WriteFile(path, data, 0o644)
+
+	srv := server.NewServer("file-test").
+		Resource("file:///artifact", "Artifact", server.WithFileContent(path, server.WithMaxReadSize(300)))
+	initializeWithCapabilities(t, srv, `{}`)
+
+	// Too big to read at once
+	response := readResource(t, srv, `{"uri":"file:///artifact"}`)
+	if code := errorCode(response); code != -32602 {
+		t.Fatalf("Expected -32602 for a read over the limit, got %v", response)
+	}
+	if data := response["error"].(map[string]interface{})["data"].(map[string]interface{}); data["size"] != float64(1000) {
+		t.Errorf("Expected the size in the error data, got %v", data)
+	}
+
+	// Read it in ranges, asking for more than the limit each time
+	var assembled bytes.Buffer
+	for offset := 0; offset < len(data); {
+		item, meta := firstContents(t, readResource(t, srv, fmt.Sprintf(`{"uri":"file:///artifact","offset":%d,"length":500}`, offset)))
+		if item["mimeType"] != "application/octet-stream" {
+			t.Fatalf("Expected a binary MIME type, got %v", item["mimeType"])
+		}
+		chunk, err := base64.StdEncoding.DecodeString(item["blob"].(string))
+		if err != nil {
+			t.Fatalf("Invalid blob: %v", err)
+		}
+		if int(meta["length"].(float64)) != len(chunk) || len(chunk) > 300 {
+			t.Fatalf("Unexpected chunk of %d bytes with _meta %v", len(chunk), meta)
+		}
+		assembled.Write(chunk)
+		offset += len(chunk)
+	}
+	if !bytes.Equal(assembled.Bytes(), data) {
+		t.Error("Expected the ranges to reassemble the file")
+	}
+
+	if code := errorCode(readResource(t, srv, `{"uri":"file:///artifact","offset":2000}`)); code != -32602 {
+		t.Errorf("Expected -32602 for an offset past the end, got %v", code)
+	}
+}
+
+func TestFileContentTextRangesKeepCharacters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "greeting")
+	os.WriteFile(path, []byte("héllo wörld"), 0o644)
+
+	srv := server.NewServer("file-test").Resource("file:///greeting", "Greeting", server.WithFileContent(path))
+	initializeWithCapabilities(t, srv, `{}`)
+
+	// A range ending inside "é" stops before it
+	item, meta := firstContents(t, readResource(t, srv, `{"uri":"file:///greeting","offset":0,"length":2}`))
+	if item["text"] != "h" || meta["length"] != float64(1) {
+		t.Errorf("Expected the split character to be left for the next range, got %q with %v", item["text"], meta)
+	}
+	item, _ = firstContents(t, readResource(t, srv, `{"uri":"file:///greeting","offset":1,"length":100}`))
+	if item["text"] != "éllo wörld" {
+		t.Errorf("Unexpected text %q", item["text"])
+	}
+}
+
+func TestFileContentCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "large.log")
+	data := bytes.Repeat([]byte("0123456789abcdef"), (5<<20)/32) // 2.5 MiB, across three cache blocks
+	os.WriteFile(path, data, 0o644)
+
+	for _, srv := range []server.Server{
+		server.NewServer("file-test"),
+		server.NewServer("file-test", server.WithFileCacheSize(1<<20)),
+		server.NewServer("file-test", server.WithFileCacheSize(0)),
+	} {
+		srv.Resource("file:///large.log", "Log", server.WithFileContent(path, server.WithMaxReadSize(700<<10)))
+		initializeWithCapabilities(t, srv, `{}`)
+
+		var assembled bytes.Buffer
+		for offset := 0; offset < len(data); {
+			item, _ := firstContents(t, readResource(t, srv, fmt.Sprintf(`{"uri":"file:///large.log","offset":%d,"length":%d}`, offset, 700<<10)))
+			text := item["text"].(string)
+			assembled.WriteString(text)
+			offset += len(text)
+		}
+		if !bytes.Equal(assembled.Bytes(), data) {
+			t.Error("Expected the ranges to reassemble the file")
+		}
+	}
+
+	srv := server.NewServer("file-test").Resource("file:///small.log", "Log", server.WithFileContent(path))
+	os.WriteFile(path, []byte("first"), 0o644)
+	initializeWithCapabilities(t, srv, `{}`)
+	if item, _ := firstContents(t, readResource(t, srv, `{"uri":"file:///small.log"}`)); item["text"] != "first" {
+		t.Fatalf("Unexpected text %q", item["text"])
+	}
+	// A rewritten file isn't served from the blocks of its old version
+	os.WriteFile(path, []byte("later"), 0o644)
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Second))
+	if item, _ := firstContents(t, readResource(t, srv, `{"uri":"file:///small.log"}`)); item["text"] != "later" {
+		t.Errorf("Expected the rewritten file, got %q", item["text"])
+	}
+}
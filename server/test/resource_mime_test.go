@@ -0,0 +1,77 @@
+package test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// readResourceItem reads a resource and returns the first item of its
+// contents, or of its content in the older result shape.
+func readResourceItem(t *testing.T, srv server.Server, uri string) map[string]interface{} {
+	t.Helper()
+	request, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "resources/read",
+		"params":  map[string]interface{}{"uri": uri},
+	})
+	response := handleRaw(t, srv, string(request))
+	result, _ := response["result"].(map[string]interface{})
+	for _, key := range []string{"contents", "content"} {
+		if items, ok := result[key].([]interface{}); ok && len(items) > 0 {
+			item, _ := items[0].(map[string]interface{})
+			return item
+		}
+	}
+	t.Fatalf("Expected resource contents, got %v", response)
+	return nil
+}
+
+func TestResourceMimeTypes(t *testing.T) {
+	srv := server.NewServer("mime")
+	srv.Resource("/notes.txt", "Latin-1 notes", func(ctx *server.Context, args interface{}) ([]byte, error) {
+		return []byte("caf\xe9 cr\xe8me"), nil
+	})
+	srv.Resource("/logo", "Logo", func(ctx *server.Context, args interface{}) ([]byte, error) {
+		return []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), nil
+	})
+	srv.Resource("/config", "Config", func(ctx *server.Context, args interface{}) (string, error) {
+		return `{"debug": true}`, nil
+	})
+	srv.Resource("/export.csv", "Shift_JIS export", func(ctx *server.Context, args interface{}) (interface{}, error) {
+		return map[string]interface{}{"contents": []interface{}{map[string]interface{}{
+			"uri":      "/export.csv",
+			"mimeType": "text/csv; charset=shift_jis",
+			"blob":     base64.StdEncoding.EncodeToString([]byte("\x93\xfa\x96\x7b")),
+		}}}, nil
+	})
+
+	path := filepath.Join(t.TempDir(), "legacy")
+	if err := os.WriteFile(path, []byte("na\xefve"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	srv.Resource("file:///legacy", "Legacy file", server.WithFileContent(path, server.WithFileMimeType("text/plain; charset=iso-8859-1")))
+
+	for _, tc := range []struct {
+		uri, mimeType, text string
+	}{
+		{"/notes.txt", "text/plain", "café crème"},
+		{"/config", "application/json", `{"debug": true}`},
+		{"/export.csv", "text/csv", "日本"},
+		{"file:///legacy", "text/plain", "naïve"},
+	} {
+		item := readResourceItem(t, srv, tc.uri)
+		if item["mimeType"] != tc.mimeType || item["text"] != tc.text || item["blob"] != nil {
+			t.Errorf("%s: expected %s text %q, got %v", tc.uri, tc.mimeType, tc.text, item)
+		}
+	}
+
+	if item := readResourceItem(t, srv, "/logo"); item["mimeType"] != "image/png" || item["blob"] == nil {
+		t.Errorf("Expected a PNG blob, got %v", item)
+	}
+}
@@ -0,0 +1,263 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// sendResourceRequest sends a resources/subscribe or resources/unsubscribe request and returns the decoded response
+func sendResourceRequest(t *testing.T, srv server.Server, method, uri string) map[string]interface{} {
+	t.Helper()
+
+	request := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  map[string]interface{}{"uri": uri},
+	}
+	requestJSON, _ := json.Marshal(request)
+
+	responseBytes, err := server.HandleMessage(srv.GetServer(), requestJSON)
+	if err != nil {
+		t.Fatalf("Failed to handle %s request: %v", method, err)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(responseBytes, &response); err != nil {
+		t.Fatalf("Failed to parse %s response: %v", method, err)
+	}
+	return response
+}
+
+func subscribeResource(t *testing.T, srv server.Server, uri string) {
+	t.Helper()
+
+	if response := sendResourceRequest(t, srv, "resources/subscribe", uri); response["error"] != nil {
+		t.Fatalf("Subscribe returned error: %v", response["error"])
+	}
+}
+
+func TestResourceUpdatedSkipsUnsubscribed(t *testing.T) {
+	recorder := NewRecordingTransport()
+	srv := server.NewServer("test-server",
+		server.WithTransport(recorder),
+		server.WithResourceUpdateWindow(0),
+	)
+
+	if err := srv.NotifyResourceUpdated("file:///unwatched.txt"); err != nil {
+		t.Fatalf("NotifyResourceUpdated failed: %v", err)
+	}
+
+	if got := recorder.SentWithMethod("notifications/resources/updated"); len(got) != 0 {
+		t.Errorf("Expected no notifications without subscribers, got %d", len(got))
+	}
+}
+
+func TestResourceUpdatedCoalescesBursts(t *testing.T) {
+	recorder := NewRecordingTransport()
+	srv := server.NewServer("test-server",
+		server.WithTransport(recorder),
+		server.WithResourceUpdateWindow(50*time.Millisecond),
+	)
+
+	subscribeResource(t, srv, "file:///a.txt")
+	subscribeResource(t, srv, "file:///b.txt")
+
+	for i := 0; i < 10; i++ {
+		if err := srv.NotifyResourceUpdated("file:///a.txt"); err != nil {
+			t.Fatalf("NotifyResourceUpdated failed: %v", err)
+		}
+	}
+	if err := srv.NotifyResourceUpdated("file:///b.txt"); err != nil {
+		t.Fatalf("NotifyResourceUpdated failed: %v", err)
+	}
+
+	if got := recorder.SentWithMethod("notifications/resources/updated"); len(got) != 0 {
+		t.Fatalf("Expected notifications to be held until the window closes, got %d", len(got))
+	}
+
+	time.Sleep(150 * time.Millisecond)
+
+	counts := map[string]int{}
+	for _, notification := range recorder.SentWithMethod("notifications/resources/updated") {
+		params, _ := notification["params"].(map[string]interface{})
+		uri, _ := params["uri"].(string)
+		counts[uri]++
+	}
+
+	if counts["file:///a.txt"] != 1 {
+		t.Errorf("Expected 1 notification for a.txt, got %d", counts["file:///a.txt"])
+	}
+	if counts["file:///b.txt"] != 1 {
+		t.Errorf("Expected 1 notification for b.txt, got %d", counts["file:///b.txt"])
+	}
+
+	// A new update after the window closed starts a new window
+	if err := srv.NotifyResourceUpdated("file:///a.txt"); err != nil {
+		t.Fatalf("NotifyResourceUpdated failed: %v", err)
+	}
+	time.Sleep(150 * time.Millisecond)
+
+	if got := len(recorder.SentWithMethod("notifications/resources/updated")); got != 3 {
+		t.Errorf("Expected 3 notifications in total, got %d", got)
+	}
+}
+
+func TestResourceSubscribeRequiresURI(t *testing.T) {
+	srv := server.NewServer("test-server")
+
+	request := []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{}}`)
+	responseBytes, err := server.HandleMessage(srv.GetServer(), request)
+	if err != nil {
+		t.Fatalf("Failed to handle subscribe request: %v", err)
+	}
+
+	var response struct {
+		Error *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(responseBytes, &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response.Error == nil || response.Error.Code != -32602 {
+		t.Errorf("Expected invalid params error, got %s", string(responseBytes))
+	}
+}
+
+func TestResourceUnsubscribeStopsNotifications(t *testing.T) {
+	recorder := NewRecordingTransport()
+	srv := server.NewServer("test-server",
+		server.WithTransport(recorder),
+		server.WithResourceUpdateWindow(0),
+	)
+
+	subscribeResource(t, srv, "file:///a.txt")
+	if err := srv.NotifyResourceUpdated("file:///a.txt"); err != nil {
+		t.Fatalf("NotifyResourceUpdated failed: %v", err)
+	}
+
+	if response := sendResourceRequest(t, srv, "resources/unsubscribe", "file:///a.txt"); response["error"] != nil {
+		t.Fatalf("Unsubscribe returned error: %v", response["error"])
+	}
+	if err := srv.NotifyResourceUpdated("file:///a.txt"); err != nil {
+		t.Fatalf("NotifyResourceUpdated failed: %v", err)
+	}
+
+	if got := len(recorder.SentWithMethod("notifications/resources/updated")); got != 1 {
+		t.Errorf("Expected 1 notification before unsubscribing, got %d", got)
+	}
+}
+
+func TestResourceSubscriptionLimit(t *testing.T) {
+	srv := server.NewServer("test-server", server.WithMaxSubscriptions(2))
+
+	subscribeResource(t, srv, "file:///a.txt")
+	subscribeResource(t, srv, "file:///b.txt")
+
+	// Re-subscribing to a known URI does not count against the limit
+	subscribeResource(t, srv, "file:///a.txt")
+
+	if response := sendResourceRequest(t, srv, "resources/subscribe", "file:///c.txt"); response["error"] == nil {
+		t.Fatal("Expected error when exceeding the subscription limit")
+	}
+
+	// Freeing a slot allows a new subscription
+	sendResourceRequest(t, srv, "resources/unsubscribe", "file:///b.txt")
+	subscribeResource(t, srv, "file:///c.txt")
+}
+
+func TestResourceSubscriptionsDisabled(t *testing.T) {
+	srv := server.NewServer("test-server", server.WithResourceSubscriptions(false))
+
+	for _, method := range []string{"resources/subscribe", "resources/unsubscribe"} {
+		response := sendResourceRequest(t, srv, method, "file:///a.txt")
+		errObj, ok := response["error"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected error for %s when subscriptions are disabled", method)
+		}
+		if code, _ := errObj["code"].(float64); code != -32601 {
+			t.Errorf("Expected error code -32601 for %s, got %v", method, errObj["code"])
+		}
+	}
+
+	initRequest := []byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1.0"}}}`)
+	responseBytes, err := server.HandleMessage(srv.GetServer(), initRequest)
+	if err != nil {
+		t.Fatalf("Failed to handle initialize request: %v", err)
+	}
+
+	var response struct {
+		Result struct {
+			Capabilities struct {
+				Resources struct {
+					Subscribe bool `json:"subscribe"`
+				} `json:"resources"`
+			} `json:"capabilities"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(responseBytes, &response); err != nil {
+		t.Fatalf("Failed to parse initialize response: %v", err)
+	}
+	if response.Result.Capabilities.Resources.Subscribe {
+		t.Error("Expected resources.subscribe capability to be false")
+	}
+}
+
+// addressableTransport records messages sent to single connections as well
+// as broadcasts.
+type addressableTransport struct {
+	*RecordingTransport
+
+	mu     sync.Mutex
+	sentTo map[string][]string
+}
+
+func (a *addressableTransport) SendTo(connectionID string, message []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sentTo[connectionID] = append(a.sentTo[connectionID], string(message))
+	return nil
+}
+
+func TestResourceUpdatedRoutesToSubscribedConnection(t *testing.T) {
+	tr := &addressableTransport{RecordingTransport: NewRecordingTransport(), sentTo: make(map[string][]string)}
+	srv := server.NewServer("test-server",
+		server.WithTransport(tr),
+		server.WithResourceUpdateWindow(0),
+		server.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	)
+
+	// Two clients on one transport, told apart by their connection IDs
+	for _, conn := range []string{"conn-a", "conn-b"} {
+		handleRaw(t, srv, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"`+conn+`","version":"1.0"},"_meta":{"connectionId":"`+conn+`"}}}`)
+		handleRaw(t, srv, `{"jsonrpc":"2.0","method":"notifications/initialized","params":{"_meta":{"connectionId":"`+conn+`"}}}`)
+	}
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"file:///a.txt","_meta":{"connectionId":"conn-a"}}}`)
+	if response["error"] != nil {
+		t.Fatalf("Subscribe returned error: %v", response["error"])
+	}
+
+	if err := srv.NotifyResourceUpdated("file:///a.txt"); err != nil {
+		t.Fatalf("NotifyResourceUpdated failed: %v", err)
+	}
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	if len(tr.sentTo["conn-a"]) != 1 || !strings.Contains(tr.sentTo["conn-a"][0], "notifications/resources/updated") {
+		t.Errorf("Expected one update for conn-a, got %v", tr.sentTo["conn-a"])
+	}
+	if len(tr.sentTo["conn-b"]) != 0 {
+		t.Errorf("Expected nothing for conn-b, got %v", tr.sentTo["conn-b"])
+	}
+	if got := tr.SentWithMethod("notifications/resources/updated"); len(got) != 0 {
+		t.Errorf("Expected no broadcast, got %d", len(got))
+	}
+}
@@ -0,0 +1,110 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+type repoParams struct {
+	Owner string `uri:"owner"`
+	Repo  string `json:"repo"`
+}
+
+type issueParams struct {
+	Owner  string `uri:"owner"`
+	Repo   string `uri:"repo"`
+	Number int    `uri:"number"`
+}
+
+func resourceText(t *testing.T, response map[string]interface{}) string {
+	t.Helper()
+	result, ok := response["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected a result, got %v", response)
+	}
+	contents, _ := result["contents"].([]interface{})
+	if contents == nil {
+		contents, _ = result["content"].([]interface{})
+	}
+	if len(contents) == 0 {
+		t.Fatalf("Expected contents, got %v", result)
+	}
+	text, _ := contents[0].(map[string]interface{})["text"].(string)
+	return text
+}
+
+func TestAddResourceBindsTemplateVariables(t *testing.T) {
+	srv := server.NewServer("typed-resource-test")
+	server.AddResource(srv, "repos://{owner}/{repo}", "A repository",
+		func(ctx *server.Context, p repoParams) (string, error) {
+			return p.Owner + "/" + p.Repo, nil
+		})
+	server.AddResource(srv, "issues://{owner}/{repo}/{number}", "An issue",
+		func(ctx *server.Context, p issueParams) (string, error) {
+			return strings.Repeat("#", p.Number), nil
+		})
+
+	if got := resourceText(t, sendResourceRequest(t, srv, "resources/read", "repos://golang/go")); got != "golang/go" {
+		t.Errorf("Expected golang/go, got %q", got)
+	}
+	if got := resourceText(t, sendResourceRequest(t, srv, "resources/read", "issues://golang/go/3")); got != "###" {
+		t.Errorf("Expected ###, got %q", got)
+	}
+
+	response := sendResourceRequest(t, srv, "resources/read", "issues://golang/go/three")
+	if response["error"] == nil {
+		t.Error("Expected an error for a non-numeric issue number")
+	}
+}
+
+func TestAddResourceRejectsMismatchedParams(t *testing.T) {
+	srv := server.NewServer("typed-resource-test")
+
+	// The template has no {branch} variable
+	server.AddResource(srv, "repos://{owner}/{repo}", "A repository",
+		func(ctx *server.Context, p struct {
+			Owner  string `uri:"owner"`
+			Repo   string `uri:"repo"`
+			Branch string `uri:"branch"`
+		}) (string, error) {
+			return "", nil
+		})
+
+	// The {repo} variable has no field
+	server.AddResource(srv, "orgs://{owner}/{repo}", "An org",
+		func(ctx *server.Context, p struct {
+			Owner string `uri:"owner"`
+		}) (string, error) {
+			return "", nil
+		})
+
+	// The {owner} variable is bound twice
+	server.AddResource(srv, "users://{owner}", "A user",
+		func(ctx *server.Context, p struct {
+			Owner string `uri:"owner"`
+			Login string `uri:"owner"`
+		}) (string, error) {
+			return "", nil
+		})
+
+	// The {since} variable's field can't be set from text
+	server.AddResource(srv, "events://{since}", "Events",
+		func(ctx *server.Context, p struct {
+			Since time.Time `uri:"since"`
+		}) (string, error) {
+			return "", nil
+		})
+	server.AddResource(srv, "labels://{labels}", "Labels",
+		func(ctx *server.Context, p struct {
+			Labels map[string]string
+		}) (string, error) {
+			return "", nil
+		})
+
+	if n := len(srv.GetServer().GetResources()); n != 0 {
+		t.Errorf("Expected mismatched resources not to be registered, got %d", n)
+	}
+}
@@ -0,0 +1,101 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestURLContentRevalidates(t *testing.T) {
+	var fetches, notModified int32
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		if r.Header.Get("X-Api-Key") != "secret" || !strings.HasPrefix(r.Header.Get("Accept"), "text/markdown") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Write([]byte("# Changelog"))
+	}))
+
+	srv := server.NewServer("urls")
+	srv.Resource("docs://changelog", "Changelog", server.WithURLContent(origin.URL,
+		server.WithURLHeader("X-Api-Key", "secret"),
+		server.WithURLAccept("text/markdown", "text/plain"),
+	))
+
+	for i := 0; i < 3; i++ {
+		item := readResourceItem(t, srv, "docs://changelog")
+		if item["text"] != "# Changelog" || item["mimeType"] != "text/markdown" {
+			t.Fatalf("Read %d: unexpected contents %v", i, item)
+		}
+	}
+	if fetches != 3 || notModified != 2 {
+		t.Errorf("Expected a fetch and two conditional requests, got %d fetches, %d not modified", fetches, notModified)
+	}
+
+	// The cached copy is served while the origin is down
+	origin.Close()
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"docs://changelog"}}`)
+	result, _ := response["result"].(map[string]interface{})
+	if meta, _ := result["_meta"].(map[string]interface{}); meta["stale"] != true {
+		t.Errorf("Expected a stale cached copy, got %v", response)
+	}
+}
+
+func TestURLContentCachePolicy(t *testing.T) {
+	var fetches int32
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "public, max-age=60")
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+			return
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte("body"))
+	}))
+	defer origin.Close()
+
+	srv := server.NewServer("urls")
+	srv.Resource("web://fresh", "Fresh", server.WithURLContent(origin.URL+"/fresh"))
+	srv.Resource("web://uncached", "Uncached", server.WithURLContent(origin.URL+"/fresh", server.WithURLNoCache()))
+	srv.Resource("web://loop", "Loop", server.WithURLContent(origin.URL+"/loop", server.WithURLMaxRedirects(2)))
+	srv.Resource("web://slow", "Slow", server.WithURLContent(origin.URL+"/slow", server.WithURLTimeout(50*time.Millisecond)))
+
+	readResourceItem(t, srv, "web://fresh")
+	readResourceItem(t, srv, "web://fresh")
+	if got := atomic.SwapInt32(&fetches, 0); got != 1 {
+		t.Errorf("Expected a fresh response to be served from the cache, got %d fetches", got)
+	}
+
+	readResourceItem(t, srv, "web://uncached")
+	readResourceItem(t, srv, "web://uncached")
+	if got := atomic.SwapInt32(&fetches, 0); got != 2 {
+		t.Errorf("Expected a fetch per read without a cache, got %d", got)
+	}
+
+	for _, uri := range []string{"web://loop", "web://slow"} {
+		response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"`+uri+`"}}`)
+		if response["error"] == nil {
+			t.Errorf("%s: expected an error, got %v", uri, response)
+		}
+	}
+	if got := atomic.LoadInt32(&fetches); got != 4 {
+		t.Errorf("Expected the redirect loop to stop after 2 redirects, got %d requests", got)
+	}
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestWatchedDirectory(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "guides"), 0o755)
+	os.WriteFile(filepath.Join(dir, "index.md"), []byte("# Handbook"), 0o644)
+	os.WriteFile(filepath.Join(dir, "guides", "setup.md"), []byte("# Setup"), 0o644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a guide"), 0o644)
+	uri := func(name string) string { return "file://" + filepath.ToSlash(filepath.Join(dir, name)) }
+
+	recorder := NewRecordingTransport()
+	srv := server.NewServer("watch-test",
+		server.WithTransport(recorder),
+		server.WithResourceUpdateWindow(0),
+		server.WithWatchedDirectory(dir, "**/*.md"),
+	)
+	listed := func() map[string]bool {
+		response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":9,"method":"resources/list"}`)
+		result, _ := response["result"].(map[string]interface{})
+		resources, _ := result["resources"].([]interface{})
+		uris := make(map[string]bool)
+		for _, resource := range resources {
+			uris[resource.(map[string]interface{})["uri"].(string)] = true
+		}
+		return uris
+	}
+	waitFor := func(what string, done func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(3 * time.Second); !done(); time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s", what)
+			}
+		}
+	}
+
+	if uris := listed(); len(uris) != 2 || !uris[uri("index.md")] || !uris[uri("guides/setup.md")] {
+		t.Fatalf("Expected the two Markdown files, got %v", uris)
+	}
+
+	go srv.Run()
+	defer srv.Shutdown(context.Background())
+	initializeWithCapabilities(t, srv, `{}`)
+	handleRaw(t, srv, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	sendResourceRequest(t, srv, "resources/subscribe", uri("index.md"))
+
+	version := func() interface{} {
+		response := sendResourceRequest(t, srv, "resources/read", uri("index.md"))
+		result, _ := response["result"].(map[string]interface{})
+		meta, _ := result["_meta"].(map[string]interface{})
+		return meta["version"]
+	}
+	if v := version(); v != float64(1) {
+		t.Fatalf("Expected version 1, got %v", v)
+	}
+
+	// Wait for the watcher to be in place
+	waitFor("the watcher", func() bool {
+		os.WriteFile(filepath.Join(dir, "index.md"), []byte("# Handbook, revised"), 0o644)
+		return len(recorder.SentWithMethod("notifications/resources/updated")) > 0
+	})
+	if item := readResourceItem(t, srv, uri("index.md")); item["text"] != "# Handbook, revised" {
+		t.Errorf("Expected the revised file, got %v", item["text"])
+	}
+	if v := version(); v.(float64) < 2 {
+		t.Errorf("Expected the version to have increased, got %v", v)
+	}
+
+	os.MkdirAll(filepath.Join(dir, "api"), 0o755)
+	os.WriteFile(filepath.Join(dir, "api", "auth.md"), []byte("# Auth"), 0o644)
+	waitFor("the new file", func() bool { return listed()[uri("api/auth.md")] })
+
+	os.Remove(filepath.Join(dir, "guides", "setup.md"))
+	waitFor("the removed file", func() bool { return !listed()[uri("guides/setup.md")] })
+	waitFor("list changes", func() bool {
+		return len(recorder.SentWithMethod("notifications/resources/list_changed")) > 0
+	})
+}
@@ -0,0 +1,88 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/webhook"
+)
+
+type rolloutArgs struct {
+	Query string `json:"query"`
+}
+
+func newRolloutServer(options ...server.Option) server.Server {
+	return server.NewServer("rollout-test", options...).
+		Tool("search", "Search", func(ctx *server.Context, args rolloutArgs) (string, error) {
+			return "v1:" + args.Query, nil
+		})
+}
+
+func searchV2(ctx *server.Context, args rolloutArgs) (string, error) {
+	return "v2:" + args.Query, nil
+}
+
+func callSearch(t *testing.T, srv server.Server) string {
+	t.Helper()
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"search","arguments":{"query":"go"}}}`)
+	result, _ := response["result"].(map[string]interface{})
+	content, _ := result["content"].([]interface{})
+	if len(content) == 0 {
+		t.Fatalf("Expected content in %v", response)
+	}
+	return content[0].(map[string]interface{})["text"].(string)
+}
+
+func TestToolVersionSplitAndPromotion(t *testing.T) {
+	srv := newRolloutServer()
+
+	srv.WithToolVersion("search", "v2", searchV2, server.ToolRollout{Percent: 0})
+	if text := callSearch(t, srv); text != "v1:go" {
+		t.Errorf("Expected the current version at 0%%, got %q", text)
+	}
+
+	srv.WithToolVersion("search", "v2", searchV2, server.ToolRollout{Percent: 100})
+	if text := callSearch(t, srv); text != "v2:go" {
+		t.Errorf("Expected the candidate at 100%%, got %q", text)
+	}
+
+	// A sticky split gives the session the same version on every call
+	srv.WithToolVersion("search", "v2", searchV2, server.ToolRollout{Percent: 50, Sticky: true})
+	first := callSearch(t, srv)
+	for i := 0; i < 10; i++ {
+		if text := callSearch(t, srv); text != first {
+			t.Fatalf("Expected a sticky session to keep %q, got %q", first, text)
+		}
+	}
+
+	srv.PromoteToolVersion("search")
+	for i := 0; i < 5; i++ {
+		if text := callSearch(t, srv); text != "v2:go" {
+			t.Fatalf("Expected the promoted version on every call, got %q", text)
+		}
+	}
+}
+
+func TestToolVersionShadowReportsDivergence(t *testing.T) {
+	divergences := make(chan map[string]interface{}, 1)
+	srv := newRolloutServer(server.WithEventHandler(func(eventType string, data map[string]interface{}) {
+		if eventType == webhook.EventToolDivergence {
+			divergences <- data
+		}
+	}))
+	srv.WithToolVersion("search", "v2", searchV2, server.ToolRollout{Shadow: true, Percent: 100})
+
+	if text := callSearch(t, srv); text != "v1:go" {
+		t.Errorf("Expected shadow mode to serve the current version, got %q", text)
+	}
+
+	select {
+	case data := <-divergences:
+		if data["candidate"] != "v2" || data["candidateResult"] != "v2:go" {
+			t.Errorf("Unexpected divergence event %v", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a divergence event for differing results")
+	}
+}
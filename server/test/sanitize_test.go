@@ -0,0 +1,43 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/util/textutil"
+)
+
+const rawCLIOutput = "\x1b[32mPASS\x1b[0m ok\x00 \xff\n"
+
+func TestToolSanitizer(t *testing.T) {
+	srv := server.NewServer("sanitize-test").
+		Tool("run", "Run a command", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			return rawCLIOutput, nil
+		}).
+		WithToolSanitizer("run", textutil.SanitizeCLIOutput)
+
+	text := firstContent(t, callTool(t, srv, "run"))["text"]
+	if want := "PASS ok �\n"; text != want {
+		t.Errorf("Expected sanitized text %q, got %q", want, text)
+	}
+}
+
+func TestServerSanitizerOverriddenPerTool(t *testing.T) {
+	srv := server.NewServer("sanitize-test",
+		server.WithSanitizer(textutil.StripANSI),
+	).
+		Tool("colored", "Colored output", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			return "\x1b[1mbold\x1b[0m", nil
+		}).
+		Tool("raw", "Raw output", func(ctx *server.Context, args interface{}) (interface{}, error) {
+			return "\x1b[1mbold\x1b[0m", nil
+		}).
+		WithToolSanitizer("raw", func(text string) string { return text })
+
+	if text := firstContent(t, callTool(t, srv, "colored"))["text"]; text != "bold" {
+		t.Errorf("Expected server sanitizer to strip ANSI, got %q", text)
+	}
+	if text := firstContent(t, callTool(t, srv, "raw"))["text"]; text != "\x1b[1mbold\x1b[0m" {
+		t.Errorf("Expected tool override to keep raw output, got %q", text)
+	}
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/auth"
+	"github.com/localrivet/gomcp/protocol"
+	"github.com/localrivet/gomcp/server"
+)
+
+// newScopedServer serves a public tool, a tool and a resource requiring
+// scopes, and authenticates callers by the scopes in their API key.
+func newScopedServer() server.Server {
+	srv := server.NewServer("scopes-test", server.WithMiddleware(func(next server.RequestHandler) server.RequestHandler {
+		return func(ctx *server.Context) (interface{}, error) {
+			if key := ctx.Meta().APIKey(); key != "" {
+				ctx.SetPrincipal(&auth.Principal{Subject: "tenant", Scopes: strings.Split(key, ",")})
+			}
+			return next(ctx)
+		}
+	}))
+	srv.Tool("read_notes", "Read notes", func(ctx *server.Context, args struct{}) (string, error) {
+		return "notes", nil
+	})
+	srv.Tool("write_notes", "Write notes", func(ctx *server.Context, args struct{}) (string, error) {
+		return "written by " + ctx.Principal().Subject, nil
+	})
+	srv.Resource("notes://billing", "Billing notes", func(ctx *server.Context, args interface{}) (string, error) {
+		return "invoices", nil
+	})
+	return srv.WithRequiredScopes("write_notes", "tools:write").
+		WithRequiredScopes("notes://billing", "billing:read")
+}
+
+func toolNames(t *testing.T, srv server.Server, key string) []string {
+	t.Helper()
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/list","params":{"_meta":{"apiKey":"`+key+`"}}}`)
+	var names []string
+	for _, tool := range response["result"].(map[string]interface{})["tools"].([]interface{}) {
+		names = append(names, tool.(map[string]interface{})["name"].(string))
+	}
+	return names
+}
+
+func TestRequiredScopesFilterTools(t *testing.T) {
+	srv := newScopedServer()
+	initializeWithCapabilities(t, srv, `{}`)
+
+	if names := toolNames(t, srv, ""); len(names) != 1 || names[0] != "read_notes" {
+		t.Errorf("Expected only the public tool for an anonymous caller, got %v", names)
+	}
+	if names := toolNames(t, srv, "tools:write"); len(names) != 2 {
+		t.Errorf("Expected both tools for a caller with the scope, got %v", names)
+	}
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"write_notes","arguments":{},"_meta":{"apiKey":"tools:read"}}}`)
+	rpcErr, _ := response["error"].(map[string]interface{})
+	if rpcErr == nil || rpcErr["code"] != float64(protocol.Forbidden) ||
+		rpcErr["data"].(map[string]interface{})["reason"] != protocol.ReasonInsufficientScope {
+		t.Errorf("Expected a Forbidden error, got %v", response)
+	}
+
+	response = handleRaw(t, srv, `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"write_notes","arguments":{},"_meta":{"apiKey":"tools:write"}}}`)
+	content := response["result"].(map[string]interface{})["content"].([]interface{})
+	if text := content[0].(map[string]interface{})["text"]; text != "written by tenant" {
+		t.Errorf("Expected the call to be allowed, got %v", response)
+	}
+}
+
+func TestRequiredScopesFilterResources(t *testing.T) {
+	srv := newScopedServer()
+	initializeWithCapabilities(t, srv, `{}`)
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"resources/list","params":{}}`)
+	if resources := response["result"].(map[string]interface{})["resources"].([]interface{}); len(resources) != 0 {
+		t.Errorf("Expected the resource to be hidden, got %v", resources)
+	}
+
+	response = handleRaw(t, srv, `{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"notes://billing"}}`)
+	if rpcErr, _ := response["error"].(map[string]interface{}); rpcErr == nil || rpcErr["code"] != float64(protocol.Forbidden) {
+		t.Errorf("Expected the read to be refused, got %v", response)
+	}
+
+	response = handleRaw(t, srv, `{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":"notes://billing","_meta":{"apiKey":"billing:read"}}}`)
+	if data, _ := json.Marshal(response["result"]); !strings.Contains(string(data), "invoices") {
+		t.Errorf("Expected the read to be allowed, got %v", response)
+	}
+}
+
+func TestRequiredScopesApplyToEmbeddedResources(t *testing.T) {
+	srv := newScopedServer().
+		Prompt("billing_summary", "Summarize the billing notes", server.EmbeddedResource("user", "notes://billing"))
+	initializeWithCapabilities(t, srv, `{}`)
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"prompts/get","params":{"name":"billing_summary","_meta":{"apiKey":"billing:read"}}}`)
+	if data, _ := json.Marshal(response["result"]); !strings.Contains(string(data), "invoices") {
+		t.Errorf("Expected a caller with the scope to get the embedded resource, got %v", response)
+	}
+
+	response = handleRaw(t, srv, `{"jsonrpc":"2.0","id":3,"method":"prompts/get","params":{"name":"billing_summary"}}`)
+	if response["error"] == nil {
+		t.Errorf("Expected a caller without the scope to be refused, got %v", response["result"])
+	}
+}
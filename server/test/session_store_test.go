@@ -0,0 +1,178 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/streamablehttp"
+	"github.com/redis/go-redis/v9"
+)
+
+// newReplica starts a server on Streamable HTTP sharing sessions through
+// store, as one replica behind a load balancer.
+func newReplica(t *testing.T, name string, store server.SessionStore) string {
+	t.Helper()
+	tr := streamablehttp.NewTransport("127.0.0.1:0")
+	srv := server.NewServer(name, server.WithTransport(tr), server.WithSessionStore(store))
+	srv.Tool("use", "Choose a workspace", func(ctx *server.Context, args struct {
+		Workspace string `json:"workspace"`
+	}) (string, error) {
+		ctx.SetSessionValue("workspace", args.Workspace)
+		return "ok", nil
+	})
+	srv.Tool("whoami", "Describe the session", func(ctx *server.Context, args struct{}) (string, error) {
+		info, _ := ctx.ClientInfo()
+		return ctx.SessionID() + " " + info.Name + " " + ctx.SessionValue("workspace"), nil
+	})
+	go srv.Run()
+	t.Cleanup(func() { srv.Shutdown(context.Background()) })
+
+	ts := httptest.NewServer(tr)
+	t.Cleanup(ts.Close)
+	return ts.URL
+}
+
+// postMCP posts a JSON-RPC message and returns the response.
+func postMCP(t *testing.T, method, url, sessionID, body string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if sessionID != "" {
+		req.Header.Set(streamablehttp.SessionIDHeader, sessionID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp, string(data)
+}
+
+// toolText returns the text of a tools/call response.
+func toolText(t *testing.T, body string) string {
+	t.Helper()
+	var response struct {
+		Result struct {
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(body), &response); err != nil || len(response.Result.Content) == 0 {
+		t.Fatalf("Unexpected tool response: %s", body)
+	}
+	return response.Result.Content[0].Text
+}
+
+func TestSessionResumesOnAnotherReplica(t *testing.T) {
+	store := server.NewMemorySessionStore()
+	replicaA := newReplica(t, "replica-a", store)
+	replicaB := newReplica(t, "replica-b", store)
+
+	// Initialize on A once its server is running
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","clientInfo":{"name":"lb-client","version":"1.0"},"capabilities":{}}}`
+	var sessionID string
+	for deadline := time.Now().Add(2 * time.Second); sessionID == ""; {
+		resp, body := postMCP(t, http.MethodPost, replicaA, "", initialize)
+		if resp.StatusCode == http.StatusOK && strings.Contains(body, `"result"`) {
+			sessionID = resp.Header.Get(streamablehttp.SessionIDHeader)
+		} else if time.Now().After(deadline) {
+			t.Fatalf("Initialize failed: %d %s", resp.StatusCode, body)
+		} else {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	postMCP(t, http.MethodPost, replicaA, sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	_, body := postMCP(t, http.MethodPost, replicaA, sessionID,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"use","arguments":{"workspace":"docs"}}}`)
+	if toolText(t, body) != "ok" {
+		t.Fatalf("Unexpected response: %s", body)
+	}
+
+	whoami := `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"whoami","arguments":{}}}`
+	_, body = postMCP(t, http.MethodPost, replicaA, sessionID, whoami)
+	onA := toolText(t, body)
+
+	// The load balancer sends the next request to B
+	resp, body := postMCP(t, http.MethodPost, replicaB, sessionID, whoami)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected B to resume the session, got %d %s", resp.StatusCode, body)
+	}
+	if onB := toolText(t, body); onB != onA || !strings.HasSuffix(onB, " lb-client docs") {
+		t.Errorf("Expected the session as on A (%q), got %q", onA, onB)
+	}
+
+	record, err := store.Get(context.Background(), sessionID)
+	if err != nil || record.ProtocolVersion != "2025-03-26" || record.Metadata["workspace"] != "docs" {
+		t.Errorf("Unexpected record %+v: %v", record, err)
+	}
+
+	if resp, _ := postMCP(t, http.MethodPost, replicaB, "unknown", whoami); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected an unknown session to be rejected, got %d", resp.StatusCode)
+	}
+
+	// Ending the session on B removes it for every replica
+	if resp, _ := postMCP(t, http.MethodDelete, replicaB, sessionID, ""); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the session to end, got %d", resp.StatusCode)
+	}
+	if _, err := store.Get(context.Background(), sessionID); !errors.Is(err, server.ErrSessionNotFound) {
+		t.Errorf("Expected the record to be deleted, got %v", err)
+	}
+}
+
+func TestRedisSessionStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	store := server.NewRedisSessionStore(client, "test:", time.Hour)
+	ctx := context.Background()
+
+	for _, id := range []string{"b", "a"} {
+		record := &server.SessionRecord{
+			ID:              id,
+			SessionID:       "session-" + id,
+			ProtocolVersion: "2025-03-26",
+			Capabilities:    map[string]interface{}{"roots": map[string]interface{}{}},
+			Metadata:        map[string]string{"workspace": "docs"},
+			Subscriptions:   []string{"docs://motd"},
+		}
+		if err := store.Put(ctx, record); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	record, err := store.Get(ctx, "a")
+	if err != nil || record.SessionID != "session-a" || record.Metadata["workspace"] != "docs" || record.Capabilities["roots"] == nil {
+		t.Errorf("Unexpected record %+v: %v", record, err)
+	}
+	records, err := store.List(ctx)
+	if err != nil || len(records) != 2 || records[0].ID != "a" {
+		t.Errorf("Expected both records in order, got %v: %v", records, err)
+	}
+
+	if err := store.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Get(ctx, "a"); !errors.Is(err, server.ErrSessionNotFound) {
+		t.Errorf("Expected a deleted record to be gone, got %v", err)
+	}
+
+	mr.FastForward(2 * time.Hour)
+	if _, err := store.Get(ctx, "b"); !errors.Is(err, server.ErrSessionNotFound) {
+		t.Errorf("Expected the record to expire, got %v", err)
+	}
+}
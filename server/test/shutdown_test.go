@@ -0,0 +1,150 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// newBlockingServer runs a server whose "wait" tool blocks until release is
+// closed, returning a channel that receives Run's result.
+func newBlockingServer(t *testing.T, started chan<- struct{}, release <-chan struct{}) (server.Server, *RecordingTransport, <-chan error) {
+	t.Helper()
+	recorder := NewRecordingTransport()
+	srv := server.NewServer("shutdown-test",
+		server.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		server.WithTransport(recorder),
+	)
+	srv.Tool("wait", "Block until released", func(ctx *server.Context, args struct{}) (string, error) {
+		started <- struct{}{}
+		<-release
+		return "done", nil
+	})
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- srv.Run() }()
+	handleRaw(t, srv, memoryInitialize)
+	return srv, recorder, stopped
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	srv, recorder, stopped := newBlockingServer(t, started, release)
+
+	call := make(chan map[string]interface{}, 1)
+	go func() {
+		call <- handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"wait","arguments":{}}}`)
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- srv.Shutdown(ctx)
+	}()
+
+	// New requests are refused while the tool call drains
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+		if errorCode(response) == server.ShuttingDownCode {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected requests to be refused during shutdown, got %v", response)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if code := errorCode(handleRaw(t, srv, memoryInitialize)); code != server.ShuttingDownCode {
+		t.Errorf("Expected initialize to be refused during shutdown, got %v", code)
+	}
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned before the tool call finished: %v", err)
+	default:
+	}
+
+	close(release)
+	if err := <-shutdown; err != nil {
+		t.Errorf("Expected a clean shutdown, got %v", err)
+	}
+	if response := <-call; response["error"] != nil {
+		t.Errorf("Expected the in-flight call to complete, got %v", response["error"])
+	}
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("Expected Run to return nil, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("Run did not return after Shutdown")
+	}
+
+	notices := recorder.SentWithMethod("notifications/message")
+	if len(notices) != 1 {
+		t.Fatalf("Expected 1 shutdown notice, got %d", len(notices))
+	}
+	if params, _ := notices[0]["params"].(map[string]interface{}); params["level"] != "notice" {
+		t.Errorf("Expected a notice level message, got %v", notices[0])
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+	srv, _, stopped := newBlockingServer(t, started, release)
+
+	go server.HandleMessage(srv.GetServer(), []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"wait","arguments":{}}}`))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := srv.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to pass, got %v", err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Error("Run did not return after Shutdown gave up waiting")
+	}
+
+	// Later calls report the server as already stopped
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected a second Shutdown to return nil, got %v", err)
+	}
+}
+
+func TestShutdownWithNotice(t *testing.T) {
+	srv, recorder, stopped := newBlockingServer(t, make(chan struct{}, 1), nil)
+
+	err := srv.ShutdownWithNotice(context.Background(), server.ShutdownNotice{
+		Reason:         "upgrade",
+		Message:        "Restarting for an upgrade",
+		ReconnectAfter: 1500 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Expected a clean shutdown, got %v", err)
+	}
+	<-stopped
+
+	notices := recorder.SentWithMethod("notifications/message")
+	if len(notices) != 1 {
+		t.Fatalf("Expected 1 shutdown notice, got %d", len(notices))
+	}
+	params, _ := notices[0]["params"].(map[string]interface{})
+	data, _ := params["data"].(map[string]interface{})
+	if data["event"] != server.ShutdownEventType || data["reason"] != "upgrade" || data["message"] != "Restarting for an upgrade" {
+		t.Errorf("Expected the notice in the message data, got %v", data)
+	}
+	if data["reconnectAfter"] != float64(2) {
+		t.Errorf("Expected reconnectAfter rounded up to 2 seconds, got %v", data["reconnectAfter"])
+	}
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/persist"
+	"github.com/localrivet/gomcp/server"
+)
+
+func TestExportImportState(t *testing.T) {
+	calls := 0
+	newServer := func() server.Server {
+		srv := server.NewServer("state-test", server.WithPersistence(persist.NewMemoryStore()))
+		srv.Tool("charge", "Charge a card", func(ctx *server.Context, args struct{}) (string, error) {
+			calls++
+			return "charged", nil
+		})
+		return srv
+	}
+	charge := func(srv server.Server) {
+		handleRaw(t, srv, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"charge","arguments":{},"_meta":{"idempotencyKey":"order-1"}}}`)
+	}
+
+	blue := newServer()
+	if err := blue.ImportPrompts(promptLibrary, "prompts/*"); err != nil {
+		t.Fatalf("ImportPrompts failed: %v", err)
+	}
+	charge(blue)
+
+	var snapshot bytes.Buffer
+	if err := blue.ExportState(&snapshot); err != nil {
+		t.Fatalf("ExportState failed: %v", err)
+	}
+
+	green := newServer()
+	if err := green.ImportState(bytes.NewReader(snapshot.Bytes())); err != nil {
+		t.Fatalf("ImportState failed: %v", err)
+	}
+
+	prompts := listPromptArguments(t, green)
+	if len(prompts) != 2 {
+		t.Fatalf("Expected the 2 exported prompts, got %v", prompts)
+	}
+	if arg := prompts["summarize"]["style"]; arg.Required || arg.Description != "Optional summary style" {
+		t.Errorf("Expected argument metadata to be restored, got %+v", arg)
+	}
+
+	// The idempotent result moved with the state, so the retry is replayed
+	charge(green)
+	if calls != 1 {
+		t.Errorf("Expected the tool to run once, ran %d times", calls)
+	}
+	if trail, _ := green.AuditTrail(time.Time{}); len(trail) != 1 {
+		t.Errorf("Expected the audit trail to be restored, got %+v", trail)
+	}
+}
+
+func TestImportStateRejectsUnknownVersion(t *testing.T) {
+	srv := server.NewServer("state-test")
+	err := srv.ImportState(strings.NewReader(`{"version":99,"prompts":[]}`))
+	if err == nil || !strings.Contains(err.Error(), "unsupported state version") {
+		t.Errorf("Expected an unsupported version error, got %v", err)
+	}
+}
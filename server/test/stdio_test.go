@@ -0,0 +1,200 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport/stdio"
+)
+
+// stdioPeer plays the client of a server running over stdio, writing lines
+// to the server's input and reading the lines it writes.
+type stdioPeer struct {
+	t     *testing.T
+	in    *io.PipeWriter
+	lines chan map[string]interface{}
+}
+
+// runOverStdio runs a server over stdio and returns its client's end.
+func runOverStdio(t *testing.T, srv server.Server) *stdioPeer {
+	t.Helper()
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	impl := srv.GetServer()
+	server.WithTransport(stdio.NewTransportWithIO(serverIn, serverOut))(impl)
+	go srv.Run()
+
+	peer := &stdioPeer{t: t, in: clientOut, lines: make(chan map[string]interface{}, 16)}
+	go func() {
+		scanner := bufio.NewScanner(clientIn)
+		for scanner.Scan() {
+			// Keep IDs as sent; the server's don't survive a float64
+			decoder := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+			decoder.UseNumber()
+			var message map[string]interface{}
+			if err := decoder.Decode(&message); err == nil {
+				peer.lines <- message
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		srv.Shutdown(context.Background())
+		clientOut.Close()
+		clientIn.Close()
+	})
+	return peer
+}
+
+// send writes a message to the server.
+func (p *stdioPeer) send(message string) {
+	p.t.Helper()
+	if _, err := io.WriteString(p.in, message+"\n"); err != nil {
+		p.t.Fatalf("Failed to write to the server: %v", err)
+	}
+}
+
+// next returns the next message the server writes with the given method,
+// or the response to the request with the given ID if method is empty.
+func (p *stdioPeer) next(method string, id json.Number) map[string]interface{} {
+	p.t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case message := <-p.lines:
+			if method != "" && message["method"] == method {
+				return message
+			}
+			if method == "" && message["method"] == nil && message["id"] == id {
+				return message
+			}
+		case <-timeout:
+			p.t.Fatalf("Timed out waiting for %q (id %v) from the server", method, id)
+			return nil
+		}
+	}
+}
+
+// initialize performs the handshake with the given client capabilities.
+func (p *stdioPeer) initialize(capabilities string) {
+	p.t.Helper()
+	p.send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":` +
+		capabilities + `,"clientInfo":{"name":"stdio-test","version":"1.0"}}}`)
+	if response := p.next("", "1"); response["error"] != nil {
+		p.t.Fatalf("Initialize failed: %v", response["error"])
+	}
+	p.send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+}
+
+func TestStdioCancellation(t *testing.T) {
+	srv := server.NewServer("stdio-cancel")
+	started := make(chan struct{})
+	causes := make(chan error, 1)
+	srv.Tool("wait", "Waits until cancelled", func(ctx *server.Context, args struct{}) (string, error) {
+		close(started)
+		select {
+		case <-ctx.Done():
+			causes <- context.Cause(ctx.Context())
+			return "", ctx.Err()
+		case <-time.After(5 * time.Second):
+			causes <- nil
+			return "finished", nil
+		}
+	})
+	peer := runOverStdio(t, srv)
+	peer.initialize(`{}`)
+
+	peer.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"wait","arguments":{}}}`)
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the tool to start")
+	}
+
+	// Other requests are served while a handler runs
+	peer.send(`{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	if response := peer.next("", "3"); response["error"] != nil {
+		t.Errorf("Expected ping to succeed, got %v", response["error"])
+	}
+	peer.send(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"2","reason":"user"}}`)
+
+	select {
+	case cause := <-causes:
+		if !errors.Is(cause, server.ErrRequestCancelled) {
+			t.Errorf("Expected the handler to be cancelled by the client, got %v", cause)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the cancellation to reach the running handler")
+	}
+}
+
+func TestStdioListRoots(t *testing.T) {
+	srv := server.NewServer("stdio-roots")
+	srv.Tool("roots", "Lists the client's roots", func(ctx *server.Context, args struct{}) (string, error) {
+		roots, err := ctx.ListRoots()
+		if err != nil {
+			return "", err
+		}
+		return roots[0].URI, nil
+	})
+	peer := runOverStdio(t, srv)
+	peer.initialize(`{"roots":{"listChanged":true}}`)
+
+	peer.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"roots","arguments":{}}}`)
+	request := peer.next("roots/list", "")
+	id := request["id"].(json.Number)
+	peer.send(`{"jsonrpc":"2.0","id":` + id.String() + `,"result":{"roots":[{"uri":"file:///project","name":"project"}]}}`)
+
+	response := peer.next("", "2")
+	if text := stdioToolText(t, response); text != "file:///project" {
+		t.Errorf("Expected the tool to return the client's root, got %q", text)
+	}
+}
+
+func TestStdioCreateMessage(t *testing.T) {
+	srv := server.NewServer("stdio-sampling")
+	srv.Tool("ask", "Asks the client's model", func(ctx *server.Context, args struct{}) (string, error) {
+		reply, err := ctx.CreateMessage(server.SamplingCreateMessageParams{
+			Messages:  []server.SamplingMessage{{Role: "user", Content: server.SamplingMessageContent{Type: "text", Text: "Hello"}}},
+			MaxTokens: 10,
+		})
+		if err != nil {
+			return "", err
+		}
+		return reply.Content.Text, nil
+	})
+	peer := runOverStdio(t, srv)
+	peer.initialize(`{"sampling":{}}`)
+
+	peer.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"ask","arguments":{}}}`)
+	request := peer.next("sampling/createMessage", "")
+	id := request["id"].(json.Number)
+	peer.send(`{"jsonrpc":"2.0","id":` + id.String() + `,"result":{"role":"assistant","content":{"type":"text","text":"Hi there"},"model":"test"}}`)
+
+	response := peer.next("", "2")
+	if text := stdioToolText(t, response); text != "Hi there" {
+		t.Errorf("Expected the tool to return the client's reply, got %q", text)
+	}
+}
+
+// stdioToolText returns the text of a tools/call response.
+func stdioToolText(t *testing.T, response map[string]interface{}) string {
+	t.Helper()
+	result, ok := response["result"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected a result, got %v", response)
+	}
+	content, _ := result["content"].([]interface{})
+	if len(content) == 0 {
+		t.Fatalf("Expected content in the result, got %v", result)
+	}
+	text, _ := content[0].(map[string]interface{})["text"].(string)
+	return text
+}
@@ -0,0 +1,110 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// fakeRows serves fixed rows through the server.Rows interface, as
+// *sql.Rows would.
+type fakeRows struct {
+	columns []string
+	rows    [][]interface{}
+	next    int
+}
+
+func (r *fakeRows) Columns() ([]string, error) { return r.columns, nil }
+func (r *fakeRows) Err() error                 { return nil }
+
+func (r *fakeRows) Next() bool {
+	r.next++
+	return r.next <= len(r.rows)
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	if len(dest) != len(r.columns) {
+		return errors.New("wrong number of destinations")
+	}
+	for i, value := range r.rows[r.next-1] {
+		*dest[i].(*interface{}) = value
+	}
+	return nil
+}
+
+func TestTableFromCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sales.csv")
+	csv := "region,units,revenue,active,note\nnorth,12,1500.50,true,\"a|b\"\nsouth,7,900,false,\n"
+	if err := os.WriteFile(path, []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := server.TableFromCSVFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	var types []string
+	for _, column := range table.Columns {
+		types = append(types, column.Type)
+	}
+	if want := []string{"string", "integer", "number", "boolean", "string"}; !reflect.DeepEqual(types, want) {
+		t.Errorf("Expected column types %v, got %v", want, types)
+	}
+	if want := []interface{}{"south", int64(7), float64(900), false, nil}; !reflect.DeepEqual(table.Rows[1], want) {
+		t.Errorf("Expected typed row %v, got %v", want, table.Rows[1])
+	}
+
+	markdown := table.Markdown()
+	if !strings.HasPrefix(markdown, "| region | units | revenue | active | note |\n| --- | ---: | ---: | --- | --- |\n") {
+		t.Errorf("Unexpected Markdown header:\n%s", markdown)
+	}
+	if !strings.Contains(markdown, `| north | 12 | 1500.5 | true | a\|b |`) {
+		t.Errorf("Expected an escaped pipe in the Markdown table:\n%s", markdown)
+	}
+}
+
+func TestTableFromRows(t *testing.T) {
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	table, err := server.TableFromRows(&fakeRows{
+		columns: []string{"id", "name", "score", "joined"},
+		rows: [][]interface{}{
+			{int64(1), []byte("ada"), int64(90), day},
+			{int64(2), "grace", 87.5, nil},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to read rows: %v", err)
+	}
+	want := []server.Column{{Name: "id", Type: "integer"}, {Name: "name", Type: "string"}, {Name: "score", Type: "number"}, {Name: "joined", Type: "datetime"}}
+	if !reflect.DeepEqual(table.Columns, want) {
+		t.Errorf("Expected columns %v, got %v", want, table.Columns)
+	}
+	if table.Rows[0][1] != "ada" || table.Rows[0][3] != "2025-03-01T00:00:00Z" || table.Rows[1][3] != nil {
+		t.Errorf("Unexpected rows %v", table.Rows)
+	}
+}
+
+func TestTableToolResult(t *testing.T) {
+	srv := server.NewServer("table-test").
+		Tool("inventory", "List inventory", func(ctx *server.Context, args struct{}) (interface{}, error) {
+			return server.NewTable([][]string{{"item", "count"}, {"bolts", "40"}}), nil
+		})
+	initializeWithCapabilities(t, srv, `{"experimental":{"structuredContent":true}}`)
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"inventory","arguments":{}}}`)
+	result := response["result"].(map[string]interface{})
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"]
+	if text != "| item | count |\n| --- | ---: |\n| bolts | 40 |" {
+		t.Errorf("Expected a Markdown table as text, got %q", text)
+	}
+	structured, _ := result["structuredContent"].(map[string]interface{})
+	if structured["rowCount"] != float64(1) || len(structured["columns"].([]interface{})) != 2 {
+		t.Errorf("Unexpected structured content %v", structured)
+	}
+}
@@ -3,11 +3,13 @@ package test
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/transport"
 )
 
 // MockTransport is a simple mock transport for testing
@@ -444,3 +446,61 @@ type ToolHandler func(*Context, interface{}) (interface{}, error)
 func convertToResourceHandler(handler interface{}) (server.ResourceHandler, bool) {
 	return server.ConvertToResourceHandler(handler)
 }
+
+// RecordingTransport is a server transport that records every message the
+// server sends instead of delivering it.
+type RecordingTransport struct {
+	transport.BaseTransport
+
+	mu   sync.Mutex
+	sent [][]byte
+}
+
+// NewRecordingTransport creates a new recording transport
+func NewRecordingTransport() *RecordingTransport {
+	return &RecordingTransport{}
+}
+
+// Initialize implements transport.Transport
+func (r *RecordingTransport) Initialize() error { return nil }
+
+// Start implements transport.Transport
+func (r *RecordingTransport) Start() error { return nil }
+
+// Stop implements transport.Transport
+func (r *RecordingTransport) Stop() error { return nil }
+
+// Receive implements transport.Transport
+func (r *RecordingTransport) Receive() ([]byte, error) { return nil, nil }
+
+// Send records the message
+func (r *RecordingTransport) Send(message []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, append([]byte(nil), message...))
+	return nil
+}
+
+// Sent returns a copy of all recorded messages
+func (r *RecordingTransport) Sent() [][]byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sent := make([][]byte, len(r.sent))
+	copy(sent, r.sent)
+	return sent
+}
+
+// SentWithMethod returns the recorded messages whose method matches the given one
+func (r *RecordingTransport) SentWithMethod(method string) []map[string]interface{} {
+	var matches []map[string]interface{}
+	for _, message := range r.Sent() {
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(message, &decoded); err != nil {
+			continue
+		}
+		if decoded["method"] == method {
+			matches = append(matches, decoded)
+		}
+	}
+	return matches
+}
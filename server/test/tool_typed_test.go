@@ -0,0 +1,111 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+type searchArgs struct {
+	Query string `json:"query" description:"Text to search for"`
+	Limit int    `json:"limit,omitempty" default:"10"`
+	Sort  string `json:"sort,omitempty" enum:"relevance,date"`
+}
+
+type searchResult struct {
+	Hits  []string `json:"hits" description:"Matching document IDs"`
+	Total int      `json:"total"`
+}
+
+func newSearchServer() server.Server {
+	srv := server.NewServer("typed-tools")
+	server.AddTool(srv, "search", "Search the index", func(ctx *server.Context, args searchArgs) (searchResult, error) {
+		return searchResult{Hits: []string{args.Query + "-1"}, Total: args.Limit}, nil
+	})
+	return srv
+}
+
+func TestAddToolAdvertisesSchemas(t *testing.T) {
+	srv := newSearchServer()
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+
+	var list struct {
+		Result struct {
+			Tools []struct {
+				Name         string `json:"name"`
+				InputSchema  map[string]interface{}
+				OutputSchema map[string]interface{}
+			} `json:"tools"`
+		} `json:"result"`
+	}
+	data, _ := json.Marshal(response)
+	if err := json.Unmarshal(data, &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Result.Tools) != 1 {
+		t.Fatalf("Expected 1 tool, got %v", response)
+	}
+	tool := list.Result.Tools[0]
+
+	inputProps, _ := tool.InputSchema["properties"].(map[string]interface{})
+	query, _ := inputProps["query"].(map[string]interface{})
+	if query["description"] != "Text to search for" {
+		t.Errorf("Expected the query description from its tag, got %v", query)
+	}
+	limit, _ := inputProps["limit"].(map[string]interface{})
+	if limit["default"] != float64(10) {
+		t.Errorf("Expected limit to default to 10, got %v", limit)
+	}
+	sort, _ := inputProps["sort"].(map[string]interface{})
+	if enum, _ := sort["enum"].([]interface{}); len(enum) != 2 {
+		t.Errorf("Expected sort to be an enum of 2 values, got %v", sort)
+	}
+	if required, _ := tool.InputSchema["required"].([]interface{}); len(required) != 1 || required[0] != "query" {
+		t.Errorf("Expected only query to be required, got %v", tool.InputSchema["required"])
+	}
+
+	if tool.OutputSchema == nil {
+		t.Fatal("Expected an output schema generated from the result type")
+	}
+	outputProps, _ := tool.OutputSchema["properties"].(map[string]interface{})
+	hits, _ := outputProps["hits"].(map[string]interface{})
+	if hits["type"] != "array" || hits["description"] != "Matching document IDs" {
+		t.Errorf("Expected hits to be a described array, got %v", hits)
+	}
+}
+
+func TestAddToolReturnsStructuredContent(t *testing.T) {
+	srv := newSearchServer()
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"search","arguments":{"query":"go","limit":3}}}`)
+
+	result, _ := response["result"].(map[string]interface{})
+	structured, ok := result["structuredContent"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected structuredContent in the result, got %v", response)
+	}
+	if structured["total"] != float64(3) {
+		t.Errorf("Expected total 3, got %v", structured["total"])
+	}
+	if content, _ := result["content"].([]interface{}); len(content) != 1 {
+		t.Errorf("Expected the result as text content too, got %v", result["content"])
+	}
+}
+
+func TestUntypedToolHasNoOutputSchema(t *testing.T) {
+	srv := server.NewServer("untyped-tools")
+	srv.Tool("echo", "Echo", func(ctx *server.Context, args map[string]interface{}) (interface{}, error) {
+		return args, nil
+	})
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	tools := response["result"].(map[string]interface{})["tools"].([]interface{})
+	if _, ok := tools[0].(map[string]interface{})["outputSchema"]; ok {
+		t.Errorf("Expected no output schema for an interface{} result, got %v", tools[0])
+	}
+
+	response = handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"a":1}}}`)
+	if _, ok := response["result"].(map[string]interface{})["structuredContent"]; ok {
+		t.Errorf("Expected no structuredContent without an output schema, got %v", response["result"])
+	}
+}
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func createUpload(t *testing.T, srv server.Server, id int) map[string]interface{} {
+	t.Helper()
+	return handleRaw(t, srv, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"uploads/create","params":{"name":"empty.txt"}}`, id))
+}
+
+func TestUploadsInProgressAreLimited(t *testing.T) {
+	dir := t.TempDir()
+	srv := server.NewServer("upload-limits",
+		server.WithUploads(server.UploadOptions{Dir: dir, MaxPendingPerSession: 2}),
+	)
+
+	var ids []string
+	for i := 1; i <= 2; i++ {
+		response := createUpload(t, srv, i)
+		result, ok := response["result"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected upload %d to start, got %v", i, response["error"])
+		}
+		ids = append(ids, result["uploadId"].(string))
+	}
+
+	// Uploads hold no file until data arrives
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected no files before the first chunk, got %d", len(entries))
+	}
+
+	if code := errorCode(createUpload(t, srv, 3)); code != 1006 {
+		t.Fatalf("Expected a third upload to be rate limited, got %v", code)
+	}
+
+	// Completing an upload frees its slot
+	complete := fmt.Sprintf(`{"jsonrpc":"2.0","id":4,"method":"uploads/complete","params":{"uploadId":%q}}`, ids[0])
+	if response := handleRaw(t, srv, complete); response["error"] != nil {
+		t.Fatalf("Expected the empty upload to complete, got %v", response["error"])
+	}
+	if response := createUpload(t, srv, 5); response["error"] != nil {
+		t.Errorf("Expected a new upload after one completed, got %v", response["error"])
+	}
+}
+
+func TestUploadsInProgressAreLimitedServerWide(t *testing.T) {
+	srv := server.NewServer("upload-limits",
+		server.WithUploads(server.UploadOptions{Dir: t.TempDir(), MaxPending: 1}),
+	)
+
+	if response := createUpload(t, srv, 1); response["error"] != nil {
+		t.Fatalf("Expected the first upload to start, got %v", response["error"])
+	}
+	if code := errorCode(createUpload(t, srv, 2)); code != 1006 {
+		t.Errorf("Expected the server-wide limit to refuse a second upload, got %v", code)
+	}
+}
@@ -0,0 +1,82 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func newValidationServer(calls *int, options ...server.Option) server.Server {
+	return server.NewServer("validation-test", options...).
+		Tool("search", "Search documents", func(ctx *server.Context, args struct {
+			Query string `json:"query" minLength:"2"`
+			Limit int    `json:"limit,omitempty" min:"1" max:"50"`
+		}) (interface{}, error) {
+			*calls++
+			return "results", nil
+		})
+}
+
+func TestStrictValidationRejectsInvalidArguments(t *testing.T) {
+	calls := 0
+	srv := newValidationServer(&calls)
+	initializeWithCapabilities(t, srv, `{}`)
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"search","arguments":{"limit":500}}}`)
+	if code := errorCode(response); code != -32602 {
+		t.Fatalf("Expected -32602 for invalid arguments, got %v", response)
+	}
+	if calls != 0 {
+		t.Error("Expected the handler not to be called with invalid arguments")
+	}
+
+	data := response["error"].(map[string]interface{})["data"].(map[string]interface{})
+	if data["tool"] != "search" {
+		t.Errorf("Expected the tool name in the error data, got %v", data)
+	}
+	errors, _ := data["errors"].([]interface{})
+	if len(errors) != 2 {
+		t.Fatalf("Expected two field errors, got %v", data["errors"])
+	}
+	fields := map[string]string{}
+	for _, e := range errors {
+		fieldError := e.(map[string]interface{})
+		fields[fieldError["field"].(string)] = fieldError["message"].(string)
+	}
+	if fields["query"] != "is required" || fields["limit"] != "must be at most 50" {
+		t.Errorf("Unexpected field errors %v", fields)
+	}
+
+	valid := handleRaw(t, srv, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"search","arguments":{"query":"go"}}}`)
+	if valid["error"] != nil || calls != 1 {
+		t.Errorf("Expected valid arguments to reach the handler, got %v", valid)
+	}
+}
+
+func TestStrictValidationAppliesCustomSchema(t *testing.T) {
+	calls := 0
+	srv := newValidationServer(&calls).WithSchema("search", map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"query"},
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{"type": "string", "enum": []interface{}{"a", "b"}},
+		},
+	})
+	initializeWithCapabilities(t, srv, `{}`)
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"search","arguments":{"query":"c"}}}`)
+	if code := errorCode(response); code != -32602 {
+		t.Errorf("Expected the schema set with WithSchema to be enforced, got %v", response)
+	}
+}
+
+func TestStrictValidationDisabled(t *testing.T) {
+	calls := 0
+	srv := newValidationServer(&calls, server.WithStrictValidation(false))
+	initializeWithCapabilities(t, srv, `{}`)
+
+	response := handleRaw(t, srv, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"search","arguments":{"query":"x","limit":500}}}`)
+	if response["error"] != nil || calls != 1 {
+		t.Errorf("Expected arguments to reach the handler unvalidated, got %v", response)
+	}
+}
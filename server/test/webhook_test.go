@@ -0,0 +1,111 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/ratelimit"
+	"github.com/localrivet/gomcp/server"
+	"github.com/localrivet/gomcp/webhook"
+)
+
+var webhookSecret = []byte("webhook-secret")
+
+// collectWebhooks starts an endpoint that verifies and keeps deliveries.
+func collectWebhooks(t *testing.T) (*webhook.Dispatcher, func() map[string][]webhook.Event) {
+	t.Helper()
+	var mu sync.Mutex
+	received := map[string][]webhook.Event{}
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		events, err := webhook.Verify(r, webhookSecret, time.Minute)
+		if err != nil {
+			t.Errorf("Delivery failed verification: %v", err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		for _, event := range events {
+			received[event.Type] = append(received[event.Type], event)
+		}
+		mu.Unlock()
+	}))
+	t.Cleanup(endpoint.Close)
+
+	d := webhook.NewDispatcher(endpoint.URL, webhookSecret)
+	return d, func() map[string][]webhook.Event {
+		if err := d.Close(context.Background()); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return received
+	}
+}
+
+func TestWebhookEvents(t *testing.T) {
+	hooks, received := collectWebhooks(t)
+	srv := server.NewServer("webhook-test",
+		server.WithTransport(NewRecordingTransport()),
+		server.WithWebhooks(hooks),
+		server.WithQuotas(ratelimit.NewMemoryStore(), server.Quota{Name: "daily", Period: server.QuotaDaily, Hard: 3}),
+		server.WithToolErrorSpike(2, time.Minute),
+	).
+		Tool("convert", "Convert a file", func(ctx *server.Context, args struct {
+			Fail bool `json:"fail,omitempty"`
+		}) (interface{}, error) {
+			if args.Fail {
+				return nil, errors.New("conversion failed")
+			}
+			return "converted", nil
+		}).
+		WithCost("convert", server.ToolCost{Credits: 2})
+
+	handleRaw(t, srv, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"probe","version":"1.2"}}}`)
+	handleRaw(t, srv, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	calls := []string{
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"convert","arguments":{"fail":true},"_meta":{"apiKey":"key-1"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"convert","arguments":{"fail":true},"_meta":{"apiKey":"key-1"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"convert","arguments":{},"_meta":{"apiKey":"key-1"}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"convert","arguments":{},"_meta":{"apiKey":"key-1"}}}`,
+	}
+	for _, call := range calls {
+		handleRaw(t, srv, call)
+	}
+	handleRaw(t, srv, `{"jsonrpc":"2.0","id":6,"method":"shutdown"}`)
+
+	events := received()
+
+	started := events[webhook.EventSessionStarted]
+	if len(started) != 1 || started[0].Data["clientName"] != "probe" || started[0].Data["clientVersion"] != "1.2" {
+		t.Errorf("Expected a session.started event for the client, got %+v", started)
+	}
+	if ended := events[webhook.EventSessionEnded]; len(ended) != 1 || ended[0].Data["reason"] != "shutdown" {
+		t.Errorf("Expected a session.ended event for the shutdown, got %+v", ended)
+	}
+
+	audit := events[webhook.EventAuditToolCall]
+	if len(audit) != 3 {
+		t.Fatalf("Expected 3 audited calls, got %+v", audit)
+	}
+	if audit[0].Data["outcome"] != "error" || audit[2].Data["outcome"] != "ok" {
+		t.Errorf("Unexpected audit outcomes %+v", audit)
+	}
+	if audit[0].Data["apiKeyID"] == "key-1" || audit[0].Data["apiKeyID"] == "" {
+		t.Errorf("Expected the API key to be audited by hash, got %v", audit[0].Data["apiKeyID"])
+	}
+
+	if spikes := events[webhook.EventToolErrorSpike]; len(spikes) != 1 || spikes[0].Data["errors"] != float64(2) {
+		t.Errorf("Expected one error spike after two failures, got %+v", spikes)
+	}
+
+	exceeded := events[webhook.EventQuotaExceeded]
+	if len(exceeded) != 1 || exceeded[0].Data["quota"] != "daily" || exceeded[0].Data["limit"] != float64(3) {
+		t.Errorf("Expected a quota.exceeded event for the last call, got %+v", exceeded)
+	}
+}
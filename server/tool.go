@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"runtime/debug"
 	"strings"
 
 	"github.com/localrivet/gomcp/util/schema"
+	"github.com/localrivet/gomcp/util/textutil"
 )
 
 // ToolHandler is a function that handles tool calls.
@@ -15,6 +17,22 @@ import (
 // and returns a result and any error that occurred.
 type ToolHandler func(ctx *Context, args interface{}) (interface{}, error)
 
+// HandlerPanic is the value a panic in a tool handler is re-raised with on
+// the goroutine handling the request, so that WithErrorReporting or
+// middleware can recover it. Otherwise the panic still crashes the server.
+type HandlerPanic struct {
+	// Value is the value the handler panicked with.
+	Value interface{}
+
+	// Stack is the stack trace of the handler's goroutine.
+	Stack []byte
+}
+
+// Error returns the panic value as text.
+func (p *HandlerPanic) Error() string {
+	return fmt.Sprint(p.Value)
+}
+
 // Tool represents a tool registered with the server.
 // Tools are functions that can be called by clients connected to the server.
 type Tool struct {
@@ -30,8 +48,41 @@ type Tool struct {
 	// Schema defines the expected input format for the tool
 	Schema interface{}
 
+	// OutputSchema describes the tool's result when the handler returns a
+	// struct type. It is nil for handlers returning interface{} or other types.
+	OutputSchema map[string]interface{}
+
 	// Annotations contains additional metadata about the tool
 	Annotations map[string]interface{}
+
+	// Sanitizer cleans text content returned by the tool, overriding the server default
+	Sanitizer textutil.Sanitizer
+
+	// Cost is what a call to the tool is billed at, or nil if it is free
+	Cost *ToolCost
+
+	// version names Handler once a candidate version has been promoted
+	version string
+
+	// candidate is a version being rolled out; see WithToolVersion
+	candidate *toolCandidate
+
+	// probe checks the tool's dependencies, or is nil if it has none
+	probe *toolProbe
+
+	// flag gates the tool's availability; see WithToolFlag
+	flag string
+
+	// scopes are required of callers; see WithRequiredScopes
+	scopes []string
+
+	// schemaFlag chooses among schemaVariants; see WithSchemaVariants
+	schemaFlag     string
+	schemaVariants map[string]*schemaVariant
+
+	// validator checks arguments against Schema, or is nil if Schema could
+	// not be prepared for validation
+	validator *schema.ArgumentValidator
 }
 
 // Tool registers a tool with the server.
@@ -56,11 +107,53 @@ func (s *serverImpl) Tool(name string, description string, handler interface{})
 		}
 	}
 
+	if !s.checkDescriptions(name, schema) {
+		return s
+	}
+
 	// Use the internal registerTool method to store the tool
 	s.registerTool(name, description, toolHandler, schema)
+
+	if outputSchema := extractOutputSchema(handler); outputSchema != nil {
+		s.mu.Lock()
+		if tool, ok := s.tools[name]; ok {
+			tool.OutputSchema = outputSchema
+		}
+		s.mu.Unlock()
+	}
 	return s
 }
 
+// AddTool registers a tool whose handler takes typed arguments and returns a
+// typed result. It is Tool with the handler's signature checked at compile
+// time instead of at registration.
+//
+// The input schema is generated from Args and, when Result is a struct or a
+// pointer to one, an output schema from Result. Both honor the json,
+// description, required, enum, default and other constraint tags of the
+// struct fields. Tools with an output schema advertise it in tools/list and
+// return their result as structuredContent alongside its JSON text.
+//
+// Example:
+//
+//	type SearchArgs struct {
+//	    Query string `json:"query" description:"Text to search for"`
+//	    Limit int    `json:"limit,omitempty" default:"10" min:"1" max:"100"`
+//	    Sort  string `json:"sort,omitempty" enum:"relevance,date"`
+//	}
+//	type SearchResult struct {
+//	    Hits  []string `json:"hits" description:"Matching document IDs"`
+//	    Total int      `json:"total"`
+//	}
+//
+//	server.AddTool(srv, "search", "Search the index",
+//	    func(ctx *server.Context, args SearchArgs) (SearchResult, error) {
+//	        return index.Search(args.Query, args.Limit, args.Sort)
+//	    })
+func AddTool[Args, Result any](srv Server, name, description string, handler func(ctx *Context, args Args) (Result, error)) Server {
+	return srv.Tool(name, description, handler)
+}
+
 // registerTool registers a tool with the server.
 // It's an internal method used by the Tool method.
 // This method handles validation, duplicate detection, and notifications.
@@ -91,14 +184,15 @@ func (s *serverImpl) registerTool(name, description string, handler ToolHandler,
 		Handler:     handler,
 		Schema:      schema,
 		Annotations: make(map[string]interface{}),
+		validator:   s.newArgumentValidator(name, schema),
 	}
 
 	s.logger.Debug("registered tool", "name", name)
 
-	// Mark that tools have changed, but don't send a notification immediately
-	// The notification will be sent after client initialization
+	// Tell initialized clients; the others are told after initialization
 	if !exists || isUpdate {
 		s.toolsChanged = true
+		s.notifyListChanged(toolsListChanged)
 	}
 
 	return s
@@ -109,40 +203,45 @@ func (s *serverImpl) registerTool(name, description string, handler ToolHandler,
 // The response includes the tools' name, description, and input schema.
 func (s *serverImpl) ProcessToolList(ctx *Context) (interface{}, error) {
 	// Get pagination cursor if provided
-	var cursor string
-	if ctx.Request.Params != nil {
-		var params struct {
-			Cursor string `json:"cursor"`
-		}
-		if err := json.Unmarshal(ctx.Request.Params, &params); err != nil {
-			return nil, fmt.Errorf("invalid params: %w", err)
-		}
-		cursor = params.Cursor
+	cursor, err := listCursor(ctx)
+	if err != nil {
+		return nil, err
 	}
 
+	// Rewrite schemas for hosts with known quirks
+	quirks := s.hostQuirks(ctx)
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	// For now, we'll use a simple pagination that returns all tools
-	// In a real implementation, you'd parse the cursor and limit results
-	const maxPageSize = 50
-	var tools = make([]map[string]interface{}, 0, len(s.tools))
-	var nextCursor string
-
-	// Convert tools to the expected format
-	i := 0
-	for name, tool := range s.tools {
-		// If we have a cursor, skip until we find it
-		// This is a simplistic approach; real cursor would be more sophisticated
-		if cursor != "" && name <= cursor {
-			continue
+	tools := make([]map[string]interface{}, 0)
+	nextCursor := paginate(s, s.tools, cursor, func(name string, tool *Tool) bool {
+		// Leave out tools whose dependencies are down, if so configured,
+		// and tools flagged off for or not permitted to the caller
+		if s.hiddenByProbe(tool) || !s.toolEnabled(ctx, tool) || !ctx.permitted(tool.scopes) {
+			return false
+		}
+		inputSchema, _ := s.toolSchema(ctx, tool)
+
+		// Translate the tool's text for the client's language, if registered
+		text, localized := s.localization(ctx, tool.Name)
+		transforms := quirks
+		if localized && len(text.Arguments) > 0 {
+			transforms = append([]schema.Transform{describeArguments(text.Arguments)}, quirks...)
 		}
 
 		// Add the tool to the result
 		toolInfo := map[string]interface{}{
 			"name":        tool.Name,
 			"description": tool.Description,
-			"inputSchema": tool.Schema,
+			"inputSchema": s.applyQuirks(tool.Name, inputSchema, transforms),
+		}
+		if localized {
+			localizeInfo(toolInfo, text)
+		}
+
+		if tool.OutputSchema != nil {
+			toolInfo["outputSchema"] = s.applyQuirks(tool.Name, tool.OutputSchema, quirks)
 		}
 
 		// Only include annotations if they exist
@@ -151,14 +250,8 @@ func (s *serverImpl) ProcessToolList(ctx *Context) (interface{}, error) {
 		}
 
 		tools = append(tools, toolInfo)
-
-		i++
-		if i >= maxPageSize {
-			// Set cursor for next page
-			nextCursor = name
-			break
-		}
-	}
+		return true
+	})
 
 	// Return the list of tools
 	result := map[string]interface{}{
@@ -225,29 +318,102 @@ func extractSchema(handler interface{}) (map[string]interface{}, error) {
 	}, nil
 }
 
+// extractOutputSchema generates a JSON Schema for a handler's result type
+// when it is a struct or pointer to struct, and returns nil otherwise.
+func extractOutputSchema(handler interface{}) map[string]interface{} {
+	handlerType := reflect.TypeOf(handler)
+	if handlerType.Kind() != reflect.Func || handlerType.NumOut() != 2 {
+		return nil
+	}
+
+	resultType := handlerType.Out(0)
+	if resultType.Kind() == reflect.Ptr {
+		resultType = resultType.Elem()
+	}
+	if resultType.Kind() != reflect.Struct {
+		return nil
+	}
+
+	schemaMap, err := schema.NewGenerator().GenerateSchema(reflect.New(resultType).Elem().Interface())
+	if err != nil {
+		return nil
+	}
+	// Advertised schemas must not carry "required": null
+	if required, ok := schemaMap["required"].([]string); !ok || len(required) == 0 {
+		delete(schemaMap, "required")
+	}
+	return schemaMap
+}
+
+// hasOutputSchema reports whether the named tool declares an output schema.
+func (s *serverImpl) hasOutputSchema(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tool, ok := s.tools[name]
+	return ok && tool.OutputSchema != nil
+}
+
+// isStructResult reports whether a handler result is a struct or a non-nil
+// pointer to one, the results an output schema is generated for.
+func isStructResult(result interface{}) bool {
+	v := reflect.ValueOf(result)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	return v.Kind() == reflect.Struct
+}
+
+// toolFor returns the tool a call names, or the error to answer with when
+// it doesn't exist, is switched off for the caller or needs scopes the
+// caller lacks.
+func (s *serverImpl) toolFor(ctx *Context, name string) (*Tool, error) {
+	s.mu.RLock()
+	tool, exists := s.tools[name]
+	s.mu.RUnlock()
+
+	if !exists || !s.toolEnabled(ctx, tool) {
+		return nil, unknownToolError(name)
+	}
+	if !ctx.permitted(tool.scopes) {
+		return nil, insufficientScopeError("tool", name, tool.scopes)
+	}
+	return tool, nil
+}
+
 // executeTool executes a registered tool with the given arguments.
 // It handles argument validation, conversion, and execution of the tool handler.
 // Returns the result from the tool handler or an error if execution fails.
 func (s *serverImpl) executeTool(ctx *Context, name string, args map[string]interface{}) (interface{}, error) {
-	s.mu.RLock()
-	tool, exists := s.tools[name]
-	s.mu.RUnlock()
+	tool, err := s.toolFor(ctx, name)
+	if err != nil {
+		return nil, err
+	}
 
-	if !exists {
-		return nil, fmt.Errorf("tool not found: %s", name)
+	inputSchema, validator := s.toolSchema(ctx, tool)
+	if s.strictValidation && validator != nil {
+		if problems := validator.Validate(args); len(problems) > 0 {
+			return nil, invalidArgumentsError(name, problems)
+		}
 	}
 
 	// Register for cancellation notifications
 	cancelCh := ctx.RegisterForCancellation()
 
+	// Pick the version serving the call
+	handler, shadow := s.routeToolCall(ctx, tool)
+
 	// Get the handler's parameter type
-	handlerType := reflect.TypeOf(tool.Handler)
+	handlerType := reflect.TypeOf(handler)
 	paramType := handlerType.In(1)
 
 	// Validate and convert the arguments using schema package
-	convertedArgs, err := schema.ValidateAndConvertArgs(tool.Schema.(map[string]interface{}), args, paramType)
+	schemaMap, _ := inputSchema.(map[string]interface{})
+	convertedArgs, err := schema.ValidateAndConvertArgs(schemaMap, args, paramType)
 	if err != nil {
-		return nil, fmt.Errorf("invalid arguments: %w", err)
+		return nil, NewInvalidParametersError(fmt.Sprintf("invalid arguments: %v", err))
 	}
 
 	// Check for cancellation before executing
@@ -260,9 +426,15 @@ func (s *serverImpl) executeTool(ctx *Context, name string, args map[string]inte
 		result interface{}
 		err    error
 	}, 1)
+	panicCh := make(chan *HandlerPanic, 1)
 
 	go func() {
-		result, err := tool.Handler(ctx, convertedArgs)
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				panicCh <- &HandlerPanic{Value: recovered, Stack: debug.Stack()}
+			}
+		}()
+		result, err := handler(ctx, convertedArgs)
 		// Check if cancelled after execution but before sending result
 		select {
 		case <-cancelCh:
@@ -282,8 +454,14 @@ func (s *serverImpl) executeTool(ctx *Context, name string, args map[string]inte
 	case <-cancelCh:
 		// Request was cancelled during execution
 		return nil, fmt.Errorf("tool execution cancelled: %s", name)
+	case p := <-panicCh:
+		// Re-raise on the request's goroutine, where middleware can recover it
+		panic(p)
 	case res := <-resultCh:
 		// Execution completed
+		if shadow != nil {
+			go s.shadowToolCall(ctx, tool, shadow, convertedArgs, res.result, res.err)
+		}
 		if res.err != nil {
 			return nil, fmt.Errorf("tool execution failed: %w", res.err)
 		}
@@ -304,7 +482,8 @@ func (s *serverImpl) ProcessToolCall(ctx *Context) (interface{}, error) {
 	if err != nil {
 		// For tool-specific errors, we still return a valid result but with isError=true
 		if strings.Contains(err.Error(), "tool execution failed:") {
-			return map[string]interface{}{
+			ctx.handlerErr = errors.Unwrap(err)
+			return s.finishToolResult(ctx, ctx.completeStream(map[string]interface{}{
 				"content": []map[string]interface{}{
 					{
 						"type": "text",
@@ -312,9 +491,9 @@ func (s *serverImpl) ProcessToolCall(ctx *Context) (interface{}, error) {
 					},
 				},
 				"isError": true,
-			}, nil
+			})), nil
 		}
-		// For other errors (like tool not found), return a protocol error
+		// For other errors (like unknown tools), return a protocol error
 		return nil, err
 	}
 
@@ -324,8 +503,18 @@ func (s *serverImpl) ProcessToolCall(ctx *Context) (interface{}, error) {
 		"isError": false,
 	}
 
+	// Results of tools with an output schema are also sent as structured
+	// content, which clients can check against the schema
+	if s.hasOutputSchema(ctx.Request.ToolName) && isStructResult(result) {
+		formattedResult["structuredContent"] = result
+	}
+
 	// Add appropriate content based on result type
 	switch v := result.(type) {
+	case Representations:
+		s.negotiatedContent(ctx, v, formattedResult)
+	case *Table:
+		s.negotiatedContent(ctx, v.Representations(), formattedResult)
 	case string:
 		// Simple text result
 		formattedResult["content"] = []map[string]interface{}{
@@ -440,7 +629,14 @@ func (s *serverImpl) ProcessToolCall(ctx *Context) (interface{}, error) {
 		}
 	}
 
-	return formattedResult, nil
+	return s.finishToolResult(ctx, ctx.completeStream(formattedResult)), nil
+}
+
+// finishToolResult sanitizes, post-processes as Markdown and scans a
+// formatted tool result before delivery.
+func (s *serverImpl) finishToolResult(ctx *Context, result map[string]interface{}) map[string]interface{} {
+	toolName := ctx.Request.ToolName
+	return s.digestToolResult(s.scanToolResult(toolName, s.processMarkdown(ctx, s.sanitizeToolResult(toolName, result))))
 }
 
 // SendToolsListChangedNotification sends a notification to inform clients that the tool list has changed.
@@ -474,7 +670,8 @@ func (s *serverImpl) SendToolsListChangedNotification() error {
 
 	// Send the notification through the configured transport
 	if s.transport != nil {
-		if err := s.transport.Send(notificationBytes); err != nil {
+		if err := s.sendMessage(notificationBytes); err != nil {
+			s.metrics.notificationFailed("notifications/tools/list_changed")
 			s.logger.Error("failed to send notification", "error", err)
 			return fmt.Errorf("failed to send notification: %w", err)
 		}
@@ -486,6 +683,22 @@ func (s *serverImpl) SendToolsListChangedNotification() error {
 	return nil
 }
 
+// UnregisterTool removes a tool, so clients can no longer list or call it,
+// and tells initialized clients with notifications/tools/list_changed.
+func (s *serverImpl) UnregisterTool(name string) bool {
+	s.mu.Lock()
+	_, exists := s.tools[name]
+	delete(s.tools, name)
+	s.mu.Unlock()
+
+	if !exists {
+		return false
+	}
+	s.logger.Debug("unregistered tool", "name", name)
+	s.notifyListChanged(toolsListChanged)
+	return true
+}
+
 // WithAnnotations adds annotations to a tool.
 // Annotations provide additional metadata that can be used by clients.
 // The function returns the server instance to allow for method chaining.
@@ -526,6 +739,7 @@ func (s *serverImpl) WithSchema(toolName string, schema interface{}) Server {
 
 	// Set the schema for the tool
 	tool.Schema = schema
+	tool.validator = s.newArgumentValidator(toolName, schema)
 
 	// Mark that tools have changed, but don't send a notification immediately
 	// The notification will be sent after client initialization
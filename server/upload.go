@@ -0,0 +1,541 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/localrivet/gomcp/protocol"
+)
+
+// UploadChunkSize is the largest chunk, before base64 encoding, that clients
+// send in an uploads/append request.
+const UploadChunkSize = 512 << 10
+
+// UploadOptions configures WithUploads. Zero values select the defaults.
+type UploadOptions struct {
+	// Dir is where uploaded files are stored. The default is a directory
+	// created in os.TempDir and removed when the server stops.
+	Dir string
+
+	// MaxSize is the largest file a client may upload. The default is
+	// 100 MiB.
+	MaxSize int64
+
+	// MaxTotal bounds the size of all stored uploads together. Uploads
+	// that would exceed it are refused until older ones expire. The
+	// default is 1 GiB.
+	MaxTotal int64
+
+	// TTL is how long an upload is kept after its last chunk. The default
+	// is an hour.
+	TTL time.Duration
+
+	// MaxPending bounds the uploads in progress across all sessions. The
+	// default is 64.
+	MaxPending int
+
+	// MaxPendingPerSession bounds the uploads one session may have in
+	// progress. The default is 4.
+	MaxPendingPerSession int
+}
+
+// Upload is a file a client uploaded.
+type Upload struct {
+	// URI identifies the upload, as upload://<id>/<name>.
+	URI string
+
+	// Name is the file name the client gave, without directories.
+	Name string
+
+	// MimeType is the media type the client gave, if any.
+	MimeType string
+
+	// Size is the size of the file in bytes.
+	Size int64
+
+	// SHA256 is the hex-encoded SHA-256 digest of the file.
+	SHA256 string
+
+	// Path is where the file is stored. It is removed when the upload
+	// expires.
+	Path string
+}
+
+// Open opens the uploaded file for reading.
+func (u *Upload) Open() (*os.File, error) {
+	return os.Open(u.Path)
+}
+
+// uploadStore holds the uploads of a server.
+type uploadStore struct {
+	options    UploadOptions
+	dir        string
+	createdDir bool
+
+	mu      sync.Mutex
+	uploads map[string]*storedUpload
+	total   int64
+
+	// Uploads in progress, in total and per session
+	pendingCount     int
+	pendingBySession map[SessionID]int
+}
+
+// storedUpload is an upload in progress or completed.
+type storedUpload struct {
+	Upload
+	id       string
+	session  SessionID
+	expected int64
+	file     *os.File // opened on the first chunk
+	hash     hash.Hash
+	updated  time.Time
+	done     bool
+}
+
+// WithUploads lets clients upload files, such as a local file the user
+// asks to analyze, with the uploads/create, uploads/append and
+// uploads/complete requests. Files are sent in base64 chunks of up to
+// UploadChunkSize bytes over any transport and stored in temporary
+// storage, and the upload:// URI a completed upload returns is passed to
+// tools, which read the file with Context.Upload. Uploads belong to the
+// session that made them and are removed after the TTL.
+//
+// Example:
+//
+//	srv := server.NewServer("analyzer", server.WithUploads(server.UploadOptions{MaxSize: 20 << 20}))
+//	srv.Tool("analyze", "Analyze an uploaded file", func(ctx *server.Context, args struct {
+//	    File string `json:"file" description:"upload:// URI of the file"`
+//	}) (string, error) {
+//	    upload, err := ctx.Upload(args.File)
+//	    if err != nil {
+//	        return "", err
+//	    }
+//	    return analyze(upload.Path)
+//	})
+func WithUploads(options UploadOptions) Option {
+	return func(s *serverImpl) {
+		if options.MaxSize <= 0 {
+			options.MaxSize = 100 << 20
+		}
+		if options.MaxTotal <= 0 {
+			options.MaxTotal = 1 << 30
+		}
+		if options.TTL <= 0 {
+			options.TTL = time.Hour
+		}
+		if options.MaxPending <= 0 {
+			options.MaxPending = 64
+		}
+		if options.MaxPendingPerSession <= 0 {
+			options.MaxPendingPerSession = 4
+		}
+		s.uploads = &uploadStore{
+			options:          options,
+			dir:              options.Dir,
+			uploads:          make(map[string]*storedUpload),
+			pendingBySession: make(map[SessionID]int),
+		}
+	}
+}
+
+// Upload returns the upload with the given upload:// URI, which must have
+// been completed by the client of this request's session.
+func (c *Context) Upload(uri string) (*Upload, error) {
+	if c.server == nil || c.server.uploads == nil {
+		return nil, fmt.Errorf("upload %s not found: uploads are not enabled", uri)
+	}
+	id, _, ok := strings.Cut(strings.TrimPrefix(uri, "upload://"), "/")
+	if !strings.HasPrefix(uri, "upload://") || !ok {
+		return nil, fmt.Errorf("invalid upload URI %q", uri)
+	}
+
+	store := c.server.uploads
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	upload, found := store.uploads[id]
+	if !found || !upload.done || upload.session != c.sessionID() || upload.URI != uri {
+		return nil, fmt.Errorf("upload %s not found", uri)
+	}
+	copied := upload.Upload
+	return &copied, nil
+}
+
+// startUploads removes expired uploads until the server stops, and then
+// removes all of them.
+func (s *serverImpl) startUploads() {
+	if s.uploads == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(min(s.uploads.options.TTL, time.Minute))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stopped:
+				s.uploads.removeAll()
+				return
+			case <-ticker.C:
+				if removed := s.uploads.removeExpired(time.Now()); removed > 0 {
+					s.logger.Debug("removed expired uploads", "count", removed)
+				}
+			}
+		}
+	}()
+}
+
+// requireUploads returns the server's upload store, or a CapabilityError
+// if uploads are not enabled.
+func (s *serverImpl) requireUploads(ctx *Context) (*uploadStore, error) {
+	if s.uploads == nil {
+		return nil, &CapabilityError{
+			Method:     ctx.Request.Method,
+			Capability: "experimental.uploads",
+			Side:       "server",
+			Hint:       "enable it with server.WithUploads",
+		}
+	}
+	return s.uploads, nil
+}
+
+// ProcessUploadCreate starts an upload, answering with its ID and the
+// chunk size to send it in.
+func (s *serverImpl) ProcessUploadCreate(ctx *Context) (interface{}, error) {
+	store, err := s.requireUploads(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var params struct {
+		Name     string `json:"name"`
+		Size     int64  `json:"size"`
+		MimeType string `json:"mimeType"`
+	}
+	if err := unmarshalUploadParams(ctx, &params); err != nil {
+		return nil, err
+	}
+	name := filepath.Base(filepath.Clean("/" + params.Name))
+	if name == "/" || name == "." {
+		return nil, NewInvalidParametersError("upload requires a file name")
+	}
+	if params.Size > store.options.MaxSize {
+		return nil, uploadTooLargeError(store.options.MaxSize)
+	}
+
+	upload, err := store.create(ctx.sessionID(), name, params.MimeType, params.Size)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Debug("upload started", "upload", upload.URI, "size", params.Size)
+	return map[string]interface{}{
+		"uploadId":  upload.id,
+		"chunkSize": UploadChunkSize,
+	}, nil
+}
+
+// ProcessUploadAppend appends a base64 chunk to an upload. Chunks are sent
+// in order, each at the offset the previous one ended.
+func (s *serverImpl) ProcessUploadAppend(ctx *Context) (interface{}, error) {
+	store, err := s.requireUploads(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var params struct {
+		UploadID string `json:"uploadId"`
+		Offset   int64  `json:"offset"`
+		Data     string `json:"data"`
+	}
+	if err := unmarshalUploadParams(ctx, &params); err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(params.Data)
+	if err != nil {
+		return nil, NewInvalidParametersError("invalid upload chunk: " + err.Error())
+	}
+	if len(data) > UploadChunkSize {
+		return nil, NewInvalidParametersError(fmt.Sprintf("upload chunk exceeds %d bytes", UploadChunkSize))
+	}
+
+	received, err := store.append(ctx.sessionID(), params.UploadID, params.Offset, data)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"received": received}, nil
+}
+
+// ProcessUploadComplete finishes an upload, checking its size and digest,
+// and answers with the URI tools read it by.
+func (s *serverImpl) ProcessUploadComplete(ctx *Context) (interface{}, error) {
+	store, err := s.requireUploads(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var params struct {
+		UploadID string `json:"uploadId"`
+		SHA256   string `json:"sha256"`
+	}
+	if err := unmarshalUploadParams(ctx, &params); err != nil {
+		return nil, err
+	}
+
+	upload, err := store.complete(ctx.sessionID(), params.UploadID, params.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("upload completed", "upload", upload.URI, "size", upload.Size)
+	return map[string]interface{}{
+		"uri":    upload.URI,
+		"size":   upload.Size,
+		"sha256": upload.SHA256,
+	}, nil
+}
+
+// unmarshalUploadParams decodes the parameters of an upload request.
+func unmarshalUploadParams(ctx *Context, params interface{}) error {
+	if len(ctx.Request.Params) == 0 {
+		return NewInvalidParametersError("missing upload parameters")
+	}
+	if err := json.Unmarshal(ctx.Request.Params, params); err != nil {
+		return NewInvalidParametersError("invalid upload parameters: " + err.Error())
+	}
+	return nil
+}
+
+// uploadTooLargeError is the error uploads over the size limit fail with.
+func uploadTooLargeError(maxSize int64) error {
+	return NewInvalidParametersError(fmt.Sprintf("upload exceeds the maximum size of %d bytes", maxSize))
+}
+
+// create starts an upload for a session.
+func (u *uploadStore) create(session SessionID, name, mimeType string, size int64) (*storedUpload, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if size > 0 && u.total+size > u.options.MaxTotal {
+		return nil, &RPCError{Code: protocol.RateLimited, Message: "Upload storage is full"}
+	}
+	if u.pendingCount >= u.options.MaxPending || u.pendingBySession[session] >= u.options.MaxPendingPerSession {
+		return nil, &RPCError{Code: protocol.RateLimited, Message: "Too many uploads in progress"}
+	}
+	if err := u.ensureDir(); err != nil {
+		return nil, err
+	}
+
+	var random [16]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return nil, err
+	}
+	id := hex.EncodeToString(random[:])
+	path := filepath.Join(u.dir, id)
+
+	upload := &storedUpload{
+		Upload: Upload{
+			URI:      "upload://" + id + "/" + name,
+			Name:     name,
+			MimeType: mimeType,
+			Path:     path,
+		},
+		id:       id,
+		session:  session,
+		expected: size,
+		hash:     sha256.New(),
+		updated:  time.Now(),
+	}
+	u.uploads[id] = upload
+	u.total += max(size, 0)
+	u.pendingCount++
+	u.pendingBySession[session]++
+	return upload, nil
+}
+
+// open creates an upload's file. Uploads hold no file until their first
+// chunk, or until they complete empty.
+func (upload *storedUpload) open() error {
+	if upload.file != nil {
+		return nil
+	}
+	file, err := os.OpenFile(upload.Path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create upload: %w", err)
+	}
+	upload.file = file
+	return nil
+}
+
+// finishPending stops counting an upload as in progress. Callers hold u.mu.
+func (u *uploadStore) finishPending(upload *storedUpload) {
+	upload.done = true
+	u.pendingCount--
+	if u.pendingBySession[upload.session]--; u.pendingBySession[upload.session] <= 0 {
+		delete(u.pendingBySession, upload.session)
+	}
+}
+
+// ensureDir creates the storage directory on first use. Callers hold u.mu.
+func (u *uploadStore) ensureDir() error {
+	if u.dir == "" {
+		dir, err := os.MkdirTemp("", "gomcp-uploads-")
+		if err != nil {
+			return fmt.Errorf("failed to create upload directory: %w", err)
+		}
+		u.dir, u.createdDir = dir, true
+		return nil
+	}
+	if err := os.MkdirAll(u.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	return nil
+}
+
+// pending returns a session's upload that is still receiving chunks.
+// Callers hold u.mu.
+func (u *uploadStore) pending(session SessionID, id string) (*storedUpload, error) {
+	upload, found := u.uploads[id]
+	if !found || upload.session != session {
+		return nil, NewInvalidParametersError("unknown upload: " + id)
+	}
+	if upload.done {
+		return nil, NewInvalidParametersError("upload already completed: " + id)
+	}
+	return upload, nil
+}
+
+// append writes a chunk at offset, returning the bytes received so far.
+func (u *uploadStore) append(session SessionID, id string, offset int64, data []byte) (int64, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	upload, err := u.pending(session, id)
+	if err != nil {
+		return 0, err
+	}
+	if offset != upload.Size {
+		return 0, NewInvalidParametersError(fmt.Sprintf("upload chunk at offset %d, expected %d", offset, upload.Size))
+	}
+	limit := u.options.MaxSize
+	if upload.expected > 0 {
+		limit = upload.expected
+	}
+	size := upload.Size + int64(len(data))
+	if size > limit {
+		u.remove(upload)
+		return 0, uploadTooLargeError(limit)
+	}
+	// Uploads that declared their size reserved it at create
+	if upload.expected <= 0 && u.total+int64(len(data)) > u.options.MaxTotal {
+		u.remove(upload)
+		return 0, &RPCError{Code: protocol.RateLimited, Message: "Upload storage is full"}
+	}
+
+	if err := upload.open(); err != nil {
+		u.remove(upload)
+		return 0, err
+	}
+	if _, err := upload.file.Write(data); err != nil {
+		u.remove(upload)
+		return 0, fmt.Errorf("failed to store upload chunk: %w", err)
+	}
+	upload.hash.Write(data)
+	upload.Size = size
+	upload.updated = time.Now()
+	if upload.expected <= 0 {
+		u.total += int64(len(data))
+	}
+	return size, nil
+}
+
+// complete finishes an upload, checking it against its declared size and,
+// if given, its digest.
+func (u *uploadStore) complete(session SessionID, id, digest string) (*storedUpload, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	upload, err := u.pending(session, id)
+	if err != nil {
+		return nil, err
+	}
+	if upload.expected > 0 && upload.Size != upload.expected {
+		u.remove(upload)
+		return nil, NewInvalidParametersError(fmt.Sprintf("upload has %d bytes, expected %d", upload.Size, upload.expected))
+	}
+	sum := hex.EncodeToString(upload.hash.Sum(nil))
+	if digest != "" && !strings.EqualFold(digest, sum) {
+		u.remove(upload)
+		return nil, NewInvalidParametersError("upload digest mismatch")
+	}
+	if err := upload.open(); err != nil {
+		u.remove(upload)
+		return nil, err
+	}
+	if err := upload.file.Close(); err != nil {
+		u.remove(upload)
+		return nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+
+	u.finishPending(upload)
+	upload.file, upload.hash = nil, nil
+	upload.SHA256 = sum
+	upload.updated = time.Now()
+	return upload, nil
+}
+
+// storedSize is what an upload counts against MaxTotal.
+func (upload *storedUpload) storedSize() int64 {
+	if upload.expected > 0 {
+		return upload.expected
+	}
+	return upload.Size
+}
+
+// remove deletes an upload and its file. Callers hold u.mu.
+func (u *uploadStore) remove(upload *storedUpload) {
+	if upload.file != nil {
+		upload.file.Close()
+	}
+	if !upload.done {
+		u.finishPending(upload)
+	}
+	os.Remove(upload.Path)
+	u.total -= upload.storedSize()
+	delete(u.uploads, upload.id)
+}
+
+// removeExpired removes the uploads not touched within the TTL, returning
+// how many were removed.
+func (u *uploadStore) removeExpired(now time.Time) int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	removed := 0
+	for _, upload := range u.uploads {
+		if now.Sub(upload.updated) > u.options.TTL {
+			u.remove(upload)
+			removed++
+		}
+	}
+	return removed
+}
+
+// removeAll removes every upload, and the storage directory if the store
+// created it.
+func (u *uploadStore) removeAll() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for _, upload := range u.uploads {
+		u.remove(upload)
+	}
+	if u.createdDir {
+		os.RemoveAll(u.dir)
+		u.dir, u.createdDir = "", false
+	}
+}
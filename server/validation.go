@@ -0,0 +1,57 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/localrivet/gomcp/util/schema"
+)
+
+// WithStrictValidation sets whether tools/call arguments are validated
+// against the tool's input schema before the handler runs. It is enabled by
+// default: arguments that don't match are rejected with an Invalid params
+// error (-32602) whose data lists the problem with each field, and the
+// handler is not called.
+//
+// Deployments that trust their clients and want to save the cost of
+// validating every call can disable it. Handlers taking a struct still get
+// their arguments decoded, with only required fields checked.
+//
+// Example:
+//
+//	srv := server.NewServer("search", server.WithStrictValidation(false))
+func WithStrictValidation(enabled bool) Option {
+	return func(s *serverImpl) {
+		s.strictValidation = enabled
+	}
+}
+
+// newArgumentValidator prepares a tool's input schema for validating its
+// arguments. Schemas that can't be prepared are logged and not enforced.
+func (s *serverImpl) newArgumentValidator(toolName string, inputSchema interface{}) *schema.ArgumentValidator {
+	if inputSchema == nil {
+		return nil
+	}
+	validator, err := schema.NewArgumentValidator(inputSchema)
+	if err != nil {
+		s.logger.Error("tool arguments will not be validated", "name", toolName, "error", err)
+		return nil
+	}
+	return validator
+}
+
+// invalidArgumentsError reports the problems found with a tool's arguments.
+func invalidArgumentsError(toolName string, problems []schema.FieldError) *RPCError {
+	messages := make([]string, len(problems))
+	for i, problem := range problems {
+		messages[i] = problem.String()
+	}
+	return &RPCError{
+		Code:    -32602,
+		Message: fmt.Sprintf("Invalid arguments for tool %s: %s", toolName, strings.Join(messages, "; ")),
+		Data: map[string]interface{}{
+			"tool":   toolName,
+			"errors": problems,
+		},
+	}
+}
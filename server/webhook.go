@@ -0,0 +1,177 @@
+package server
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/localrivet/gomcp/webhook"
+)
+
+// WithWebhooks sends server, session, quota, tool error, audit, memory
+// pressure and tool divergence events to a webhook dispatcher. The event
+// types and their data are:
+//
+//   - server.started: name, transport
+//   - session.started: sessionID, protocolVersion, clientName, clientVersion
+//   - session.ended: sessionID, reason ("shutdown" or "initialize_timeout")
+//   - quota.warning and quota.exceeded: quota, used, limit, apiKeyID, tool
+//   - tool.error_spike: errors, window, threshold, tool (the last to fail)
+//   - audit.tool_call: tool, sessionID, apiKeyID, outcome ("ok", "error" or
+//     "rejected"), durationMs
+//   - memory.pressure: level and previous ("normal", "high" or "critical"),
+//     rssBytes, runtimeBytes, limitBytes, ratio
+//   - tool.divergence: tool, sessionID, current and candidate (version
+//     names), currentResult, candidateResult
+//
+// API keys are identified by hash, as in Usage. The server does not close
+// the dispatcher; close it after the server stops to deliver queued events.
+//
+// Example:
+//
+//	hooks := webhook.NewDispatcher(url, secret)
+//	defer hooks.Close(context.Background())
+//
+//	srv := server.NewServer("my-service",
+//	    server.WithWebhooks(hooks),
+//	    server.WithToolErrorSpike(20, time.Minute),
+//	)
+func WithWebhooks(d *webhook.Dispatcher) Option {
+	return func(s *serverImpl) {
+		s.webhooks = d
+	}
+}
+
+// WithToolErrorSpike sets when a tool.error_spike event is sent: once
+// threshold tool calls have failed within window. At most one event is sent
+// per window. The default is 10 failures per minute.
+func WithToolErrorSpike(threshold int, window time.Duration) Option {
+	return func(s *serverImpl) {
+		if threshold > 0 {
+			s.errorSpike.threshold = threshold
+		}
+		if window > 0 {
+			s.errorSpike.window = window
+		}
+	}
+}
+
+// WithEventHandler calls handler with every event the server emits, as
+// described for WithWebhooks. Handlers are called synchronously and must not
+// block.
+func WithEventHandler(handler func(eventType string, data map[string]interface{})) Option {
+	return func(s *serverImpl) {
+		s.eventHandlers = append(s.eventHandlers, handler)
+	}
+}
+
+// errorSpikeDetector counts recent tool call failures.
+type errorSpikeDetector struct {
+	mu        sync.Mutex
+	threshold int
+	window    time.Duration
+	failures  []time.Time
+	quietTill time.Time
+}
+
+// record counts a failure at now and reports whether it completes a spike
+// that should be announced, with the number of failures in the window.
+func (d *errorSpikeDetector) record(now time.Time) (int, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cutoff := now.Add(-d.window)
+	recent := d.failures[:0]
+	for _, at := range d.failures {
+		if at.After(cutoff) {
+			recent = append(recent, at)
+		}
+	}
+	d.failures = append(recent, now)
+
+	if len(d.failures) < d.threshold || now.Before(d.quietTill) {
+		return len(d.failures), false
+	}
+	d.quietTill = now.Add(d.window)
+	return len(d.failures), true
+}
+
+// observed reports whether any webhook or event handler receives events.
+func (s *serverImpl) observed() bool {
+	return s.webhooks != nil || len(s.eventHandlers) > 0
+}
+
+// emitEvent sends an event to the webhooks and event handlers.
+func (s *serverImpl) emitEvent(eventType string, data map[string]interface{}) {
+	if s.webhooks != nil {
+		s.webhooks.Emit(eventType, data)
+	}
+	for _, handler := range s.eventHandlers {
+		handler(eventType, data)
+	}
+}
+
+// emitSessionStarted announces a session created by initialize.
+func (s *serverImpl) emitSessionStarted(session *ClientSession, params json.RawMessage) {
+	if !s.observed() {
+		return
+	}
+	clientName, clientVersion := extractClientIdentity(params)
+
+	s.emitEvent(webhook.EventSessionStarted, map[string]interface{}{
+		"sessionID":       string(session.ID),
+		"protocolVersion": session.ProtocolVersion,
+		"clientName":      clientName,
+		"clientVersion":   clientVersion,
+	})
+}
+
+// emitSessionEnded announces the end of a session.
+func (s *serverImpl) emitSessionEnded(sessionID SessionID, reason string) {
+	s.emitEvent(webhook.EventSessionEnded, map[string]interface{}{
+		"sessionID": string(sessionID),
+		"reason":    reason,
+	})
+}
+
+// toolCallOutcome classifies a finished tool call as "ok", "error" for a
+// result flagged isError, or "rejected" for a protocol error.
+func toolCallOutcome(result interface{}, err error) string {
+	if err != nil {
+		return "rejected"
+	}
+	if formatted, ok := result.(map[string]interface{}); ok && formatted["isError"] == true {
+		return "error"
+	}
+	return "ok"
+}
+
+// observeToolCall records a finished tool call in the audit trail and
+// watches for spikes in failures.
+func (s *serverImpl) observeToolCall(ctx *Context, result interface{}, err error, elapsed time.Duration) {
+	if !s.observed() || ctx.Request == nil {
+		return
+	}
+
+	outcome := toolCallOutcome(result, err)
+	s.emitEvent(webhook.EventAuditToolCall, map[string]interface{}{
+		"tool":       ctx.Request.ToolName,
+		"sessionID":  string(ctx.sessionID()),
+		"apiKeyID":   apiKeyID(ctx.Meta().APIKey()),
+		"outcome":    outcome,
+		"durationMs": elapsed.Milliseconds(),
+	})
+
+	if outcome == "ok" {
+		return
+	}
+	if count, spike := s.errorSpike.record(time.Now()); spike {
+		s.logger.Warn("tool error spike", "errors", count, "window", s.errorSpike.window)
+		s.emitEvent(webhook.EventToolErrorSpike, map[string]interface{}{
+			"errors":    count,
+			"window":    s.errorSpike.window.String(),
+			"threshold": s.errorSpike.threshold,
+			"tool":      ctx.Request.ToolName,
+		})
+	}
+}
@@ -10,28 +10,43 @@ import (
 // clients and the server with lower overhead than HTTP polling.
 //
 // As per the MCP custom transport implementation, this transport provides a WebSocket
-// endpoint at /ws by default for bidirectional communication.
+// endpoint at /ws by default for bidirectional communication. Each connection is
+// its own session: requests are answered in order, responses to requests the
+// server sent are handled as they arrive, and idle clients are pinged every 30
+// seconds and disconnected if they don't answer.
 //
 // Parameters:
 //   - address: The listening address for the server (e.g., ":8080" for all interfaces on port 8080)
+//   - options: Optional configuration options for the WebSocket transport
 //
 // Returns:
 //   - The server instance for method chaining
 //
+// Example usage:
+//
+//	// Basic usage with the default path
+//	server.AsWebsocket(":8080")
+//
+//	// Serving the endpoint at /mcp, pinging clients every 15 seconds
+//	server.AsWebsocket(":8080", ws.WS.WithPath("/mcp"), ws.WS.WithKeepAlive(15*time.Second, 5*time.Second))
+//
 // This transport is particularly useful for web applications requiring real-time
 // updates and interactive communication.
-func (s *serverImpl) AsWebsocket(address string) Server {
+func (s *serverImpl) AsWebsocket(address string, options ...ws.Option) Server {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// Create WebSocket transport with the provided address
 	wsTransport := ws.NewTransport(address)
 
-	// Configure the transport with an empty path prefix by default
-	// Users can set a custom prefix using AsWebsocketWithPaths if needed
+	// Apply any provided options
+	for _, option := range options {
+		option(wsTransport)
+	}
 
 	// Configure the message handler
 	wsTransport.SetMessageHandler(s.handleMessage)
+	s.registerDiscoveryHandler(wsTransport)
 
 	// Set as the server's transport
 	s.transport = wsTransport
@@ -71,6 +86,7 @@ func (s *serverImpl) AsWebsocketWithPaths(address, pathPrefix, wsPath string) Se
 
 	// Configure the message handler
 	wsTransport.SetMessageHandler(s.handleMessage)
+	s.registerDiscoveryHandler(wsTransport)
 
 	// Set as the server's transport
 	s.transport = wsTransport
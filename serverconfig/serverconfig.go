@@ -0,0 +1,342 @@
+// Package serverconfig registers tools, resources and prompts on a server
+// from a JSON, YAML or TOML configuration file, and keeps them in step with
+// the file while the server runs.
+//
+// Tools name a handler from a Handlers map, since their logic lives in
+// code; resources serve a file or fixed text, and prompts are given as
+// messages:
+//
+//	# mcp.yaml
+//	tools:
+//	  - name: search
+//	    description: Search the docs
+//	    handler: search
+//	resources:
+//	  - uri: docs://changelog
+//	    description: Release notes
+//	    file: CHANGELOG.md
+//	prompts:
+//	  - name: review
+//	    description: Review a change
+//	    messages:
+//	      - role: user
+//	        content: "Review this change: {{diff}}"
+//
+// # Basic Usage
+//
+//	srv := server.NewServer("docs")
+//	watcher, err := serverconfig.Watch(srv, "mcp.yaml", serverconfig.Handlers{
+//	    "search": searchDocs,
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer watcher.Close()
+//
+// When the file changes, the watcher registers added and changed entries,
+// unregisters removed ones and tells connected clients the lists changed,
+// without restarting the server. A file that fails to load or validate is
+// reported and leaves the running configuration as it was.
+package serverconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/localrivet/gomcp/server"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the content of a configuration file.
+type Config struct {
+	Tools     []Tool     `json:"tools,omitempty" yaml:"tools,omitempty" toml:"tools,omitempty"`
+	Resources []Resource `json:"resources,omitempty" yaml:"resources,omitempty" toml:"resources,omitempty"`
+	Prompts   []Prompt   `json:"prompts,omitempty" yaml:"prompts,omitempty" toml:"prompts,omitempty"`
+}
+
+// Tool configures a tool served by a handler from Handlers.
+type Tool struct {
+	Name        string                 `json:"name" yaml:"name" toml:"name"`
+	Description string                 `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+	Handler     string                 `json:"handler" yaml:"handler" toml:"handler"`
+	Annotations map[string]interface{} `json:"annotations,omitempty" yaml:"annotations,omitempty" toml:"annotations,omitempty"`
+}
+
+// Resource configures a resource serving a file or fixed text.
+type Resource struct {
+	URI         string `json:"uri" yaml:"uri" toml:"uri"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+
+	// File is the path of the file to serve, relative to the configuration
+	// file's directory.
+	File string `json:"file,omitempty" yaml:"file,omitempty" toml:"file,omitempty"`
+
+	// Text is served when File is empty.
+	Text string `json:"text,omitempty" yaml:"text,omitempty" toml:"text,omitempty"`
+
+	// MimeType overrides the type inferred from the file name.
+	MimeType string `json:"mimeType,omitempty" yaml:"mimeType,omitempty" toml:"mimeType,omitempty"`
+}
+
+// Prompt configures a prompt.
+type Prompt struct {
+	Name        string    `json:"name" yaml:"name" toml:"name"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+	Messages    []Message `json:"messages" yaml:"messages" toml:"messages"`
+}
+
+// Message is a message of a prompt. Content may use {{variables}}, which
+// become the prompt's arguments.
+type Message struct {
+	Role    string `json:"role" yaml:"role" toml:"role"`
+	Content string `json:"content" yaml:"content" toml:"content"`
+}
+
+// Handlers maps the handler names used in a configuration to tool handlers,
+// which take any of the forms server.Tool accepts.
+type Handlers map[string]interface{}
+
+// Load reads a configuration file, choosing the format from its extension:
+// .json, .yaml, .yml or .toml.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, filepath.Ext(path))
+}
+
+// Parse decodes a configuration in the format named by ext, such as
+// ".yaml".
+func Parse(data []byte, ext string) (*Config, error) {
+	var config Config
+	switch strings.ToLower(strings.TrimPrefix(ext, ".")) {
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&config); err != nil {
+			return nil, fmt.Errorf("invalid JSON configuration: %w", err)
+		}
+	case "yaml", "yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("invalid YAML configuration: %w", err)
+		}
+	case "toml":
+		metadata, err := toml.Decode(string(data), &config)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TOML configuration: %w", err)
+		}
+		if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("invalid TOML configuration: unknown key %s", undecoded[0])
+		}
+	default:
+		return nil, fmt.Errorf("unsupported configuration format %q", ext)
+	}
+	return &config, nil
+}
+
+// Validate checks that entries are named, unique and complete, and that
+// every tool's handler is in handlers.
+func (c *Config) Validate(handlers Handlers) error {
+	seen := make(map[string]bool)
+	unique := func(kind, name string) error {
+		if name == "" {
+			return fmt.Errorf("%s without a name", kind)
+		}
+		if seen[kind+" "+name] {
+			return fmt.Errorf("duplicate %s %s", kind, name)
+		}
+		seen[kind+" "+name] = true
+		return nil
+	}
+
+	for _, tool := range c.Tools {
+		if err := unique("tool", tool.Name); err != nil {
+			return err
+		}
+		if _, ok := handlers[tool.Handler]; !ok {
+			return fmt.Errorf("tool %s uses unknown handler %q", tool.Name, tool.Handler)
+		}
+	}
+	for _, resource := range c.Resources {
+		if err := unique("resource", resource.URI); err != nil {
+			return err
+		}
+		if resource.File == "" && resource.Text == "" {
+			return fmt.Errorf("resource %s needs a file or text", resource.URI)
+		}
+	}
+	for _, prompt := range c.Prompts {
+		if err := unique("prompt", prompt.Name); err != nil {
+			return err
+		}
+		if len(prompt.Messages) == 0 {
+			return fmt.Errorf("prompt %s has no messages", prompt.Name)
+		}
+		for _, message := range prompt.Messages {
+			switch message.Role {
+			case "system", "user", "assistant":
+			default:
+				return fmt.Errorf("prompt %s has a message with unknown role %q", prompt.Name, message.Role)
+			}
+		}
+	}
+	return nil
+}
+
+// Changes lists the names of entries of one kind that differ between two
+// configurations, each sorted.
+type Changes struct {
+	Added   []string
+	Updated []string
+	Removed []string
+}
+
+// Empty reports whether nothing changed.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Updated) == 0 && len(c.Removed) == 0
+}
+
+// Diff is the difference between two configurations.
+type Diff struct {
+	Tools     Changes
+	Resources Changes
+	Prompts   Changes
+}
+
+// Empty reports whether the configurations define the same entries.
+func (d Diff) Empty() bool {
+	return d.Tools.Empty() && d.Resources.Empty() && d.Prompts.Empty()
+}
+
+// Compare returns what changed from one configuration to another. A nil
+// from is an empty configuration.
+func Compare(from, to *Config) Diff {
+	if from == nil {
+		from = &Config{}
+	}
+	return Diff{
+		Tools:     compareEntries(from.Tools, to.Tools, func(t Tool) string { return t.Name }),
+		Resources: compareEntries(from.Resources, to.Resources, func(r Resource) string { return r.URI }),
+		Prompts:   compareEntries(from.Prompts, to.Prompts, func(p Prompt) string { return p.Name }),
+	}
+}
+
+// compareEntries compares two lists of entries keyed by key.
+func compareEntries[T any](from, to []T, key func(T) string) Changes {
+	before := make(map[string]T, len(from))
+	for _, entry := range from {
+		before[key(entry)] = entry
+	}
+
+	var changes Changes
+	for _, entry := range to {
+		name := key(entry)
+		previous, existed := before[name]
+		switch {
+		case !existed:
+			changes.Added = append(changes.Added, name)
+		case !reflect.DeepEqual(previous, entry):
+			changes.Updated = append(changes.Updated, name)
+		}
+		delete(before, name)
+	}
+	for name := range before {
+		changes.Removed = append(changes.Removed, name)
+	}
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Updated)
+	sort.Strings(changes.Removed)
+	return changes
+}
+
+// Apply registers every entry of a configuration on srv. Relative resource
+// files are resolved against dir.
+func Apply(srv server.Server, config *Config, handlers Handlers, dir string) error {
+	if err := config.Validate(handlers); err != nil {
+		return err
+	}
+	applyDiff(srv, config, Compare(nil, config), handlers, dir)
+	return nil
+}
+
+// applyDiff registers the entries of config that diff lists as added or
+// updated, and unregisters those it lists as removed.
+func applyDiff(srv server.Server, config *Config, diff Diff, handlers Handlers, dir string) {
+	for _, name := range diff.Tools.Removed {
+		srv.UnregisterTool(name)
+	}
+	for _, uri := range diff.Resources.Removed {
+		srv.UnregisterResource(uri)
+	}
+	for _, name := range diff.Prompts.Removed {
+		srv.UnregisterPrompt(name)
+	}
+
+	changed := func(changes Changes) map[string]bool {
+		names := make(map[string]bool)
+		for _, name := range append(changes.Added, changes.Updated...) {
+			names[name] = true
+		}
+		return names
+	}
+
+	tools := changed(diff.Tools)
+	for _, tool := range config.Tools {
+		if tools[tool.Name] {
+			srv.Tool(tool.Name, tool.Description, handlers[tool.Handler])
+			if len(tool.Annotations) > 0 {
+				srv.WithAnnotations(tool.Name, tool.Annotations)
+			}
+		}
+	}
+
+	resources := changed(diff.Resources)
+	for _, resource := range config.Resources {
+		if resources[resource.URI] {
+			srv.Resource(resource.URI, resource.Description, resourceHandler(resource, dir))
+		}
+	}
+
+	prompts := changed(diff.Prompts)
+	for _, prompt := range config.Prompts {
+		if prompts[prompt.Name] {
+			templates := make([]interface{}, 0, len(prompt.Messages))
+			for _, message := range prompt.Messages {
+				templates = append(templates, server.PromptTemplate{Role: message.Role, Content: message.Content})
+			}
+			srv.Prompt(prompt.Name, prompt.Description, templates...)
+		}
+	}
+}
+
+// resourceHandler returns the handler serving a configured resource.
+func resourceHandler(resource Resource, dir string) interface{} {
+	if resource.File == "" {
+		text := resource.Text
+		return func(ctx *server.Context, args interface{}) (string, error) {
+			return text, nil
+		}
+	}
+
+	path := resource.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	var options []server.FileContentOption
+	if resource.MimeType != "" {
+		options = append(options, server.WithFileMimeType(resource.MimeType))
+	}
+	return server.WithFileContent(path, options...)
+}
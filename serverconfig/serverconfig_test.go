@@ -0,0 +1,194 @@
+package serverconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+func echo(ctx *server.Context, args struct {
+	Text string `json:"text"`
+}) (string, error) {
+	return args.Text, nil
+}
+
+var testHandlers = Handlers{"echo": echo, "upper": echo}
+
+func TestParseFormats(t *testing.T) {
+	want := &Config{
+		Tools:     []Tool{{Name: "echo", Description: "Echo text", Handler: "echo"}},
+		Resources: []Resource{{URI: "docs://motd", Text: "Hello"}},
+		Prompts:   []Prompt{{Name: "greet", Messages: []Message{{Role: "user", Content: "Greet {{name}}"}}}},
+	}
+	documents := map[string]string{
+		".json": `{"tools":[{"name":"echo","description":"Echo text","handler":"echo"}],
+			"resources":[{"uri":"docs://motd","text":"Hello"}],
+			"prompts":[{"name":"greet","messages":[{"role":"user","content":"Greet {{name}}"}]}]}`,
+		".yaml": `
+tools:
+  - name: echo
+    description: Echo text
+    handler: echo
+resources:
+  - uri: docs://motd
+    text: Hello
+prompts:
+  - name: greet
+    messages:
+      - role: user
+        content: "Greet {{name}}"
+`,
+		".toml": `
+[[tools]]
+name = "echo"
+description = "Echo text"
+handler = "echo"
+
+[[resources]]
+uri = "docs://motd"
+text = "Hello"
+
+[[prompts]]
+name = "greet"
+[[prompts.messages]]
+role = "user"
+content = "Greet {{name}}"
+`,
+	}
+	for ext, document := range documents {
+		config, err := Parse([]byte(document), ext)
+		if err != nil {
+			t.Errorf("%s: %v", ext, err)
+			continue
+		}
+		if !reflect.DeepEqual(config, want) {
+			t.Errorf("%s: got %+v, want %+v", ext, config, want)
+		}
+	}
+
+	if _, err := Parse([]byte(`{"tools":[{"name":"echo","handlr":"echo"}]}`), ".json"); err == nil {
+		t.Error("Expected unknown fields to be rejected")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	from := &Config{Tools: []Tool{
+		{Name: "a", Handler: "echo"},
+		{Name: "b", Handler: "echo"},
+		{Name: "c", Handler: "echo"},
+	}}
+	to := &Config{Tools: []Tool{
+		{Name: "a", Handler: "echo"},
+		{Name: "b", Handler: "upper"},
+		{Name: "d", Handler: "echo"},
+	}}
+	diff := Compare(from, to)
+	want := Changes{Added: []string{"d"}, Updated: []string{"b"}, Removed: []string{"c"}}
+	if !reflect.DeepEqual(diff.Tools, want) || !diff.Resources.Empty() || !diff.Prompts.Empty() {
+		t.Errorf("Unexpected diff: %+v", diff)
+	}
+}
+
+func toolDescriptions(srv server.Server) map[string]string {
+	tools := make(map[string]string)
+	for tool := range srv.EachTool {
+		tools[tool.Name] = tool.Description
+	}
+	return tools
+}
+
+func TestWatchAppliesChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mcp.yaml")
+	write := func(content string) {
+		t.Helper()
+		// Write to a new file and rename, as editors do
+		tmp := path + ".tmp"
+		if err := os.WriteFile(tmp, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`
+tools:
+  - {name: echo, description: Echo text, handler: echo}
+  - {name: shout, description: Shout text, handler: upper}
+resources:
+  - {uri: "docs://motd", text: Hello}
+`)
+
+	srv := server.NewServer("config-test")
+	reloads := make(chan Diff, 1)
+	errs := make(chan error, 1)
+	watcher, err := Watch(srv, path, testHandlers,
+		WithInterval(10*time.Millisecond),
+		WithOnReload(func(diff Diff) { reloads <- diff }),
+		WithOnError(func(err error) { errs <- err }))
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	defer watcher.Close()
+
+	if tools := toolDescriptions(srv); len(tools) != 2 || tools["shout"] != "Shout text" {
+		t.Fatalf("Expected the configured tools, got %v", tools)
+	}
+	resources := 0
+	for range srv.EachResource {
+		resources++
+	}
+	if resources != 1 {
+		t.Fatalf("Expected the configured resource, got %d", resources)
+	}
+
+	write(`
+tools:
+  - {name: echo, description: Repeat text, handler: echo}
+  - {name: whisper, description: Whisper text, handler: upper}
+prompts:
+  - name: greet
+    messages:
+      - {role: user, content: "Greet {{name}}"}
+`)
+	select {
+	case diff := <-reloads:
+		if !reflect.DeepEqual(diff.Tools, Changes{Added: []string{"whisper"}, Updated: []string{"echo"}, Removed: []string{"shout"}}) {
+			t.Errorf("Unexpected tool changes: %+v", diff.Tools)
+		}
+		if !reflect.DeepEqual(diff.Resources.Removed, []string{"docs://motd"}) || !reflect.DeepEqual(diff.Prompts.Added, []string{"greet"}) {
+			t.Errorf("Unexpected changes: %+v", diff)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the reload")
+	}
+	tools := toolDescriptions(srv)
+	if len(tools) != 2 || tools["echo"] != "Repeat text" || tools["whisper"] == "" {
+		t.Errorf("Expected the reloaded tools, got %v", tools)
+	}
+	for resource := range srv.EachResource {
+		t.Errorf("Expected the removed resource to be unregistered, got %s", resource.Path)
+	}
+
+	// A broken file is reported and leaves the server as it was
+	write("tools:\n  - {name: echo, handler: missing}\n")
+	select {
+	case err := <-errs:
+		if !strings.Contains(err.Error(), "unknown handler") {
+			t.Errorf("Expected an unknown handler error, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the reload error")
+	}
+	if tools := toolDescriptions(srv); len(tools) != 2 {
+		t.Errorf("Expected the tools to stay registered, got %v", tools)
+	}
+	if len(watcher.Config().Tools) != 2 {
+		t.Errorf("Expected the last good configuration to stay current")
+	}
+}
@@ -0,0 +1,215 @@
+package serverconfig
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// DefaultInterval is how often a Watcher checks its file unless
+// WithInterval is used.
+const DefaultInterval = 2 * time.Second
+
+// Option configures a Watcher.
+type Option func(*Watcher)
+
+// WithInterval sets how often the file is checked for changes.
+func WithInterval(interval time.Duration) Option {
+	return func(w *Watcher) {
+		if interval > 0 {
+			w.interval = interval
+		}
+	}
+}
+
+// WithOnReload calls fn after each reload that changed the server, with
+// what changed.
+func WithOnReload(fn func(Diff)) Option {
+	return func(w *Watcher) {
+		w.onReload = fn
+	}
+}
+
+// WithOnError calls fn when the file fails to load or validate, in
+// addition to logging it. The server keeps the last good configuration.
+func WithOnError(fn func(error)) Option {
+	return func(w *Watcher) {
+		w.onError = fn
+	}
+}
+
+// WithLogger sets the logger reload errors and changes are logged to.
+func WithLogger(logger *slog.Logger) Option {
+	return func(w *Watcher) {
+		w.logger = logger
+	}
+}
+
+// Watcher keeps the tools, resources and prompts of a server in step with a
+// configuration file.
+type Watcher struct {
+	srv      server.Server
+	path     string
+	handlers Handlers
+	interval time.Duration
+	onReload func(Diff)
+	onError  func(error)
+	logger   *slog.Logger
+
+	mu      sync.Mutex
+	current *Config
+	modTime time.Time
+	size    int64
+	digest  [sha256.Size]byte
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// Watch registers the entries of the configuration file at path on srv and
+// checks the file for changes until Close is called. It fails if the file
+// can't be loaded or is invalid.
+func Watch(srv server.Server, path string, handlers Handlers, options ...Option) (*Watcher, error) {
+	w := &Watcher{
+		srv:      srv,
+		path:     path,
+		handlers: handlers,
+		interval: DefaultInterval,
+		logger:   srv.Logger(),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, option := range options {
+		option(w)
+	}
+
+	if _, err := w.Reload(); err != nil {
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+// Config returns the configuration the server currently runs with.
+func (w *Watcher) Config() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Reload loads the file and applies what changed since the last load,
+// whether or not the file looks modified. A file that fails to load or
+// validate leaves the server as it was.
+func (w *Watcher) Reload() (Diff, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return Diff{}, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return Diff{}, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	w.modTime, w.size, w.digest = info.ModTime(), info.Size(), sha256.Sum256(data)
+
+	config, err := Parse(data, filepath.Ext(w.path))
+	if err != nil {
+		return Diff{}, err
+	}
+	if err := config.Validate(w.handlers); err != nil {
+		return Diff{}, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	diff := Compare(w.current, config)
+	applyDiff(w.srv, config, diff, w.handlers, filepath.Dir(w.path))
+	w.current = config
+
+	// Tools registered on a running server don't announce themselves
+	if len(diff.Tools.Added)+len(diff.Tools.Updated) > 0 {
+		w.srv.GetServer().SendToolsListChangedNotification()
+	}
+	if len(diff.Resources.Added)+len(diff.Resources.Updated) > 0 {
+		w.srv.GetServer().SendResourcesListChangedNotification()
+	}
+	return diff, nil
+}
+
+// Close stops watching the file. Registered entries stay registered.
+func (w *Watcher) Close() error {
+	w.once.Do(func() { close(w.stop) })
+	<-w.done
+	return nil
+}
+
+// run checks the file every interval until Close is called.
+func (w *Watcher) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if !w.modified() {
+				continue
+			}
+			diff, err := w.Reload()
+			if err != nil {
+				w.logger.Error("failed to reload configuration", "path", w.path, "error", err)
+				if w.onError != nil {
+					w.onError(err)
+				}
+				continue
+			}
+			if diff.Empty() {
+				continue
+			}
+			w.logger.Info("reloaded configuration", "path", w.path,
+				"tools", changeCounts(diff.Tools),
+				"resources", changeCounts(diff.Resources),
+				"prompts", changeCounts(diff.Prompts))
+			if w.onReload != nil {
+				w.onReload(diff)
+			}
+		}
+	}
+}
+
+// modified reports whether the file differs from the one last loaded.
+// Content is compared when the modification time and size match, since
+// editors can rewrite a file within the time's resolution.
+func (w *Watcher) modified() bool {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		// Editors replace files by renaming; reload once it is back
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !info.ModTime().Equal(w.modTime) || info.Size() != w.size {
+		return true
+	}
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return false
+	}
+	digest := sha256.Sum256(data)
+	return !bytes.Equal(digest[:], w.digest[:])
+}
+
+// changeCounts summarizes changes for logging.
+func changeCounts(c Changes) string {
+	return fmt.Sprintf("+%d ~%d -%d", len(c.Added), len(c.Updated), len(c.Removed))
+}
@@ -0,0 +1,177 @@
+// Package telemetry traces MCP requests with OpenTelemetry.
+//
+// Servers and clients configured with a TracerProvider record a span for
+// every JSON-RPC request they handle or send, named after the method and,
+// for tool calls, the tool, and carrying the session ID, request ID, the
+// name of the client application on the server side and any JSON-RPC error
+// code. The span's context travels in the request's _meta as
+// W3C "traceparent" and "tracestate" fields, so a tool call made through a
+// chain of MCP proxies shows up as a single trace.
+//
+// # Basic Usage
+//
+//	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+//
+//	srv := server.NewServer("my-service", server.WithTracerProvider(tp))
+//	c, err := client.NewClient(url, client.WithTracerProvider(tp))
+//
+// A proxy passes the trace on by handing the span context of the request it
+// is serving to the calls it makes:
+//
+//	srv.Tool("search", "Search upstream", func(ctx *server.Context, args SearchArgs) (interface{}, error) {
+//	    return upstream.CallTool("search", args.Map(), client.WithTraceContext(ctx.Context()))
+//	})
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the spans recorded by this
+// package.
+const ScopeName = "github.com/localrivet/gomcp/telemetry"
+
+// Attribute keys set on request spans.
+const (
+	MethodKey     = attribute.Key("mcp.method.name")
+	ToolKey       = attribute.Key("gen_ai.tool.name")
+	SessionKey    = attribute.Key("mcp.session.id")
+	ClientNameKey = attribute.Key("mcp.client.name")
+	RequestIDKey  = attribute.Key("jsonrpc.request.id")
+	ErrorCodeKey  = attribute.Key("rpc.jsonrpc.error_code")
+)
+
+// propagator reads and writes the W3C trace context fields of _meta.
+var propagator = propagation.TraceContext{}
+
+// MetaCarrier adapts a request's _meta object to a propagation carrier.
+type MetaCarrier map[string]interface{}
+
+// Get returns the value of a string field.
+func (m MetaCarrier) Get(key string) string {
+	value, _ := m[key].(string)
+	return value
+}
+
+// Set sets a field.
+func (m MetaCarrier) Set(key, value string) {
+	m[key] = value
+}
+
+// Keys lists the fields.
+func (m MetaCarrier) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Inject writes the trace context of ctx into meta.
+func Inject(ctx context.Context, meta map[string]interface{}) {
+	propagator.Inject(ctx, MetaCarrier(meta))
+}
+
+// InjectHTTP writes the trace context of ctx into the headers of an
+// outgoing HTTP request.
+func InjectHTTP(ctx context.Context, header http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Extract returns ctx carrying the remote span context found in meta, if
+// any.
+func Extract(ctx context.Context, meta map[string]interface{}) context.Context {
+	return propagator.Extract(ctx, MetaCarrier(meta))
+}
+
+// Request describes the request a span is recorded for.
+type Request struct {
+	Method    string
+	Tool      string
+	SessionID string
+	ID        interface{}
+
+	// ClientName is the name the client application gave at initialize,
+	// for spans recorded by servers.
+	ClientName string
+}
+
+// spanName follows the "method target" form, such as "tools/call search".
+func (r Request) spanName() string {
+	if r.Tool != "" {
+		return r.Method + " " + r.Tool
+	}
+	return r.Method
+}
+
+func (r Request) attributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{MethodKey.String(r.Method)}
+	if r.Tool != "" {
+		attrs = append(attrs, ToolKey.String(r.Tool))
+	}
+	if r.SessionID != "" {
+		attrs = append(attrs, SessionKey.String(r.SessionID))
+	}
+	if r.ClientName != "" {
+		attrs = append(attrs, ClientNameKey.String(r.ClientName))
+	}
+	if r.ID != nil {
+		attrs = append(attrs, RequestIDKey.String(fmt.Sprint(r.ID)))
+	}
+	return attrs
+}
+
+// Tracer records request spans.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer returns a Tracer recording spans with provider.
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	return &Tracer{tracer: provider.Tracer(ScopeName)}
+}
+
+// StartServer starts the span of a request received with meta, as a child
+// of the span the sender propagated in it, if any.
+func (t *Tracer) StartServer(ctx context.Context, meta map[string]interface{}, request Request) (context.Context, trace.Span) {
+	ctx = Extract(ctx, meta)
+	return t.tracer.Start(ctx, request.spanName(),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(request.attributes()...))
+}
+
+// StartClient starts the span of a request about to be sent, and writes its
+// context into meta for the receiver. A trace context already in meta, such
+// as one set with Inject by the caller, is taken as the parent.
+func (t *Tracer) StartClient(ctx context.Context, meta map[string]interface{}, request Request) (context.Context, trace.Span) {
+	ctx, span := t.tracer.Start(Extract(ctx, meta), request.spanName(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(request.attributes()...))
+	Inject(ctx, meta)
+	return ctx, span
+}
+
+// End ends a request span, recording the JSON-RPC error code if the request
+// failed with one, or err otherwise.
+func End(span trace.Span, errorCode int, err error) {
+	switch {
+	case errorCode != 0:
+		span.SetAttributes(ErrorCodeKey.Int(errorCode))
+		message := fmt.Sprintf("JSON-RPC error %d", errorCode)
+		if err != nil {
+			message = err.Error()
+		}
+		span.SetStatus(codes.Error, message)
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,56 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestClientSpanPropagatesThroughMeta(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := NewTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	meta := map[string]interface{}{}
+	ctx, clientSpan := tracer.StartClient(context.Background(), meta, Request{Method: "tools/call", Tool: "search", ID: 1})
+	if meta["traceparent"] == nil {
+		t.Fatal("Expected StartClient to write a traceparent into _meta")
+	}
+
+	_, serverSpan := tracer.StartServer(context.Background(), meta, Request{Method: "tools/call", Tool: "search", SessionID: "s1", ID: 1})
+	End(serverSpan, -32602, nil)
+	End(clientSpan, 0, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	server := spans[0]
+	if server.Name() != "tools/call search" || server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("Unexpected server span %q (%v)", server.Name(), server.SpanKind())
+	}
+	if server.Parent().SpanID() != trace.SpanContextFromContext(ctx).SpanID() {
+		t.Error("Expected the server span to be a child of the client span")
+	}
+	if server.Status().Description == "" {
+		t.Error("Expected the server span to record its error status")
+	}
+}
+
+func TestStartClientContinuesCallerTrace(t *testing.T) {
+	tracer := NewTracer(sdktrace.NewTracerProvider())
+
+	parentCtx, parent := tracer.StartServer(context.Background(), nil, Request{Method: "tools/call"})
+	defer parent.End()
+
+	meta := map[string]interface{}{}
+	Inject(parentCtx, meta)
+	ctx, span := tracer.StartClient(context.Background(), meta, Request{Method: "tools/call"})
+	defer span.End()
+
+	if trace.SpanContextFromContext(ctx).TraceID() != parent.SpanContext().TraceID() {
+		t.Error("Expected the client span to continue the trace injected into _meta")
+	}
+}
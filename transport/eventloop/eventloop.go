@@ -0,0 +1,323 @@
+// Package eventloop serves long-lived HTTP event streams, such as SSE
+// streams, from a shared loop instead of a goroutine per connection.
+//
+// By default the HTTP transports hold each stream open in its handler: a
+// goroutine blocks on the session's message channel for as long as the
+// client stays connected, keeping its stack and the server's per-connection
+// read and write buffers alive. That is simple and fast, but most MCP
+// sessions sit idle for long stretches, and at thousands of sessions those
+// goroutines and buffers dominate the server's memory.
+//
+// A Loop instead takes over the connection from net/http once the stream
+// starts (see Loop.Open). An idle stream then costs a small struct and the
+// socket: nothing runs for it until a message is queued, when one of a fixed
+// pool of writer goroutines sends everything pending in a single write. On
+// Linux a single epoll instance watches all idle connections for hangups,
+// so clients that go away are dropped immediately; elsewhere they are
+// dropped when the next write to them fails.
+//
+// The trade-offs:
+//
+//   - Only HTTP/1.x connections can be taken over. Open fails with
+//     ErrNotSupported for HTTP/2, and callers fall back to serving the
+//     stream from the handler.
+//   - A stream's response is delimited by closing the connection rather
+//     than chunked, so the connection is not reused after the stream ends.
+//   - Writes are shared. A client that stops reading holds a writer for up
+//     to Config.WriteTimeout before it is dropped, delaying other streams
+//     if every writer is stuck, where a per-connection goroutine would only
+//     stall itself. Size Workers for the number of slow clients expected.
+//   - Messages queue per stream up to Config.QueueSize and are dropped past
+//     that, as with the buffered channels of the goroutine model.
+//   - Middleware that wraps the http.ResponseWriter must implement
+//     http.Hijacker for the loop to take over the connection.
+//
+// Use the goroutine model for few, busy sessions and the loop for many,
+// mostly idle ones.
+package eventloop
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// DefaultWriteTimeout is how long a write to one connection may take
+// unless Config.WriteTimeout is set.
+const DefaultWriteTimeout = 10 * time.Second
+
+// DefaultQueueSize is the number of messages queued per stream unless
+// Config.QueueSize is set.
+const DefaultQueueSize = 32
+
+// ErrNotSupported is returned by Open when the connection can't be taken
+// over from net/http, as with HTTP/2.
+var ErrNotSupported = errors.New("eventloop: connection cannot be hijacked")
+
+// ErrClosed is returned by Open after the loop has been closed.
+var ErrClosed = errors.New("eventloop: loop closed")
+
+// Config configures a Loop. The zero value is ready to use.
+type Config struct {
+	// Workers is the number of goroutines writing to connections. It
+	// defaults to GOMAXPROCS.
+	Workers int
+
+	// WriteTimeout bounds each write to a connection. A client that
+	// doesn't read its stream within it is disconnected.
+	WriteTimeout time.Duration
+
+	// QueueSize is the most messages waiting to be written to a stream.
+	QueueSize int
+}
+
+// Loop writes to and watches a set of streams.
+type Loop struct {
+	config Config
+	poller *poller
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	ready   []*Stream // streams with pending messages, in the order they became ready
+	streams map[*Stream]struct{}
+	closed  bool
+	workers sync.WaitGroup
+}
+
+// New starts a loop and its writers.
+func New(config Config) (*Loop, error) {
+	if config.Workers <= 0 {
+		config.Workers = runtime.GOMAXPROCS(0)
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = DefaultWriteTimeout
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultQueueSize
+	}
+
+	poller, err := newPoller()
+	if err != nil {
+		return nil, fmt.Errorf("eventloop: %w", err)
+	}
+	l := &Loop{
+		config:  config,
+		poller:  poller,
+		streams: make(map[*Stream]struct{}),
+	}
+	l.cond = sync.NewCond(&l.mu)
+
+	l.workers.Add(config.Workers)
+	for i := 0; i < config.Workers; i++ {
+		go l.write()
+	}
+	return l, nil
+}
+
+// Open takes over the connection of an HTTP request and starts a streamed
+// response on it with status 200 and the given headers. The handler that
+// called Open must return without writing to w again; messages are sent
+// with the returned Stream, and onClose, if not nil, is called once when
+// the stream ends for any reason.
+func (l *Loop) Open(w http.ResponseWriter, r *http.Request, header http.Header, onClose func()) (*Stream, error) {
+	if r.ProtoMajor != 1 {
+		return nil, ErrNotSupported
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	l.mu.Lock()
+	closed := l.closed
+	l.mu.Unlock()
+	if closed {
+		return nil, ErrClosed
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("eventloop: %w", err)
+	}
+	// Deadlines set by the http.Server still apply to a hijacked connection
+	conn.SetDeadline(time.Time{})
+
+	var head bytes.Buffer
+	fmt.Fprintf(&head, "HTTP/1.%d 200 OK\r\n", r.ProtoMinor)
+	header = header.Clone()
+	header.Set("Connection", "close")
+	header.Write(&head)
+	head.WriteString("\r\n")
+
+	conn.SetWriteDeadline(time.Now().Add(l.config.WriteTimeout))
+	if _, err := conn.Write(head.Bytes()); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	s := &Stream{loop: l, conn: conn, onClose: onClose}
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		conn.Close()
+		return nil, ErrClosed
+	}
+	l.streams[s] = struct{}{}
+	l.mu.Unlock()
+
+	l.poller.add(s)
+	return s, nil
+}
+
+// Len returns the number of open streams.
+func (l *Loop) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.streams)
+}
+
+// Close closes every stream and stops the loop.
+func (l *Loop) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	streams := make([]*Stream, 0, len(l.streams))
+	for s := range l.streams {
+		streams = append(streams, s)
+	}
+	l.cond.Broadcast()
+	l.mu.Unlock()
+
+	for _, s := range streams {
+		s.Close()
+	}
+	l.workers.Wait()
+	return l.poller.close()
+}
+
+// schedule queues a stream for a writer.
+func (l *Loop) schedule(s *Stream) {
+	l.mu.Lock()
+	l.ready = append(l.ready, s)
+	l.cond.Signal()
+	l.mu.Unlock()
+}
+
+// next waits for a stream with pending messages. It returns nil once the
+// loop is closed.
+func (l *Loop) next() *Stream {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for len(l.ready) == 0 && !l.closed {
+		l.cond.Wait()
+	}
+	if l.closed {
+		return nil
+	}
+	s := l.ready[0]
+	l.ready[0] = nil
+	l.ready = l.ready[1:]
+	return s
+}
+
+func (l *Loop) write() {
+	defer l.workers.Done()
+	for s := l.next(); s != nil; s = l.next() {
+		s.flush()
+	}
+}
+
+func (l *Loop) forget(s *Stream) {
+	l.mu.Lock()
+	delete(l.streams, s)
+	l.mu.Unlock()
+}
+
+// Stream is a response stream served by a Loop.
+type Stream struct {
+	loop    *Loop
+	conn    net.Conn
+	onClose func()
+
+	// id identifies the stream to the poller, which never reuses one.
+	id int32
+
+	mu        sync.Mutex
+	pending   [][]byte
+	scheduled bool
+	closed    bool
+}
+
+// Send queues data to be written to the stream as is, without blocking. It
+// returns false if the stream is closed or its queue is full.
+func (s *Stream) Send(data []byte) bool {
+	s.mu.Lock()
+	if s.closed || len(s.pending) >= s.loop.config.QueueSize {
+		s.mu.Unlock()
+		return false
+	}
+	s.pending = append(s.pending, data)
+	schedule := !s.scheduled
+	s.scheduled = true
+	s.mu.Unlock()
+
+	if schedule {
+		s.loop.schedule(s)
+	}
+	return true
+}
+
+// flush writes everything pending in one write, requeueing the stream if
+// more arrived meanwhile.
+func (s *Stream) flush() {
+	s.mu.Lock()
+	batch := net.Buffers(s.pending)
+	s.pending = nil
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return
+	}
+
+	s.conn.SetWriteDeadline(time.Now().Add(s.loop.config.WriteTimeout))
+	if _, err := batch.WriteTo(s.conn); err != nil {
+		s.Close()
+		return
+	}
+
+	s.mu.Lock()
+	more := len(s.pending) > 0 && !s.closed
+	s.scheduled = more
+	s.mu.Unlock()
+	if more {
+		s.loop.schedule(s)
+	}
+}
+
+// Close ends the stream and closes its connection.
+func (s *Stream) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	s.pending = nil
+	s.mu.Unlock()
+
+	// The poller must let go of the descriptor before it can be reused
+	s.loop.poller.remove(s)
+	s.conn.Close()
+	s.loop.forget(s)
+	if s.onClose != nil {
+		s.onClose()
+	}
+}
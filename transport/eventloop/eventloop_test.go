@@ -0,0 +1,136 @@
+package eventloop
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// openStream serves one stream from loop and connects to it, returning the
+// stream, the client's response, and a channel closed when the stream ends.
+func openStream(t *testing.T, loop *Loop) (*Stream, *http.Response, <-chan struct{}) {
+	t.Helper()
+	streams := make(chan *Stream, 1)
+	closed := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := http.Header{"Content-Type": {"text/event-stream"}}
+		stream, err := loop.Open(w, r, header, func() { close(closed) })
+		if err != nil {
+			t.Errorf("Open failed: %v", err)
+			return
+		}
+		streams <- stream
+	}))
+	t.Cleanup(ts.Close)
+
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return <-streams, resp, closed
+}
+
+func newLoop(t *testing.T) *Loop {
+	t.Helper()
+	loop, err := New(Config{Workers: 2, WriteTimeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { loop.Close() })
+	return loop
+}
+
+func waitClosed(t *testing.T, closed <-chan struct{}, while func()) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case <-closed:
+			return
+		case <-deadline:
+			t.Fatal("Timed out waiting for the stream to close")
+		case <-time.After(10 * time.Millisecond):
+			if while != nil {
+				while()
+			}
+		}
+	}
+}
+
+func TestStreamDeliversInOrder(t *testing.T) {
+	loop := newLoop(t)
+	stream, resp, _ := openStream(t, loop)
+
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Expected the stream's headers, got Content-Type %q", got)
+	}
+	if !resp.Close {
+		t.Error("Expected the response to close the connection")
+	}
+
+	want := []string{"one\n", "two\n", "three\n"}
+	for _, line := range want {
+		if !stream.Send([]byte(line)) {
+			t.Fatalf("Send(%q) failed", line)
+		}
+	}
+	reader := bufio.NewReader(resp.Body)
+	for _, line := range want {
+		got, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		if got != line {
+			t.Errorf("Expected %q, got %q", line, got)
+		}
+	}
+	if n := loop.Len(); n != 1 {
+		t.Errorf("Expected 1 open stream, got %d", n)
+	}
+}
+
+func TestHangupClosesStream(t *testing.T) {
+	loop := newLoop(t)
+	stream, resp, closed := openStream(t, loop)
+	resp.Body.Close()
+
+	// Linux notices the hangup without writing; elsewhere a write fails
+	var probe func()
+	if runtime.GOOS != "linux" {
+		probe = func() { stream.Send([]byte("ping\n")) }
+	}
+	waitClosed(t, closed, probe)
+
+	if stream.Send([]byte("late\n")) {
+		t.Error("Expected Send on a closed stream to fail")
+	}
+	if n := loop.Len(); n != 0 {
+		t.Errorf("Expected no open streams, got %d", n)
+	}
+}
+
+func TestCloseEndsStreams(t *testing.T) {
+	loop := newLoop(t)
+	_, resp, closed := openStream(t, loop)
+
+	loop.Close()
+	waitClosed(t, closed, nil)
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Errorf("Expected the response to end cleanly, got %v", err)
+	}
+}
+
+func TestOpenHTTP2NotSupported(t *testing.T) {
+	loop := newLoop(t)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.ProtoMajor, r.ProtoMinor = 2, 0
+	if _, err := loop.Open(httptest.NewRecorder(), r, http.Header{}, nil); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Expected ErrNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+//go:build linux
+
+package eventloop
+
+import (
+	"crypto/tls"
+	"net"
+	"sync"
+	"syscall"
+)
+
+// epollET is EPOLLET, which the syscall package defines as a negative int.
+const epollET = 1 << 31
+
+// poller watches stream connections for hangups with one epoll instance,
+// so idle streams need no goroutine of their own to notice a client leave.
+type poller struct {
+	epfd int
+	wake [2]int // a pipe whose read end wakes the wait loop to exit
+
+	mu      sync.Mutex
+	nextID  int32
+	streams map[int32]*Stream
+	done    chan struct{}
+}
+
+// wakeID identifies the wake pipe; stream IDs start after it.
+const wakeID = 0
+
+func newPoller() (*poller, error) {
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	p := &poller{epfd: epfd, streams: make(map[int32]*Stream), done: make(chan struct{})}
+	if err := syscall.Pipe2(p.wake[:], syscall.O_CLOEXEC|syscall.O_NONBLOCK); err != nil {
+		syscall.Close(epfd)
+		return nil, err
+	}
+	event := syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: wakeID}
+	if err := syscall.EpollCtl(epfd, syscall.EPOLL_CTL_ADD, p.wake[0], &event); err != nil {
+		syscall.Close(p.wake[0])
+		syscall.Close(p.wake[1])
+		syscall.Close(epfd)
+		return nil, err
+	}
+	go p.wait()
+	return p, nil
+}
+
+// add starts watching a stream's connection. Connections that don't expose
+// a descriptor, such as TLS connections wrapping one that does, are watched
+// through the connection underneath.
+func (p *poller) add(s *Stream) {
+	fd, ok := descriptor(s.conn)
+	if !ok {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Events carry an ID rather than the descriptor, so an event that
+	// arrives after its stream closed can't be mistaken for a later stream
+	// that reuses the descriptor.
+	p.nextID++
+	if p.nextID <= wakeID {
+		p.nextID = wakeID + 1
+	}
+	id := p.nextID
+
+	event := syscall.EpollEvent{Events: syscall.EPOLLRDHUP | epollET, Fd: id}
+	if err := syscall.EpollCtl(p.epfd, syscall.EPOLL_CTL_ADD, fd, &event); err != nil {
+		return
+	}
+	s.id = id
+	p.streams[id] = s
+}
+
+// remove stops watching a stream's connection. It must be called before the
+// connection is closed.
+func (p *poller) remove(s *Stream) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s.id == 0 || p.streams[s.id] != s {
+		return
+	}
+	delete(p.streams, s.id)
+	if fd, ok := descriptor(s.conn); ok {
+		syscall.EpollCtl(p.epfd, syscall.EPOLL_CTL_DEL, fd, nil)
+	}
+}
+
+func (p *poller) wait() {
+	defer close(p.done)
+	events := make([]syscall.EpollEvent, 128)
+	for {
+		n, err := syscall.EpollWait(p.epfd, events, -1)
+		if err != nil {
+			if err == syscall.EINTR {
+				continue
+			}
+			return
+		}
+		for _, event := range events[:n] {
+			if event.Fd == wakeID {
+				return
+			}
+			if event.Events&(syscall.EPOLLRDHUP|syscall.EPOLLHUP|syscall.EPOLLERR) == 0 {
+				continue
+			}
+			p.mu.Lock()
+			s := p.streams[event.Fd]
+			p.mu.Unlock()
+			if s != nil {
+				s.Close()
+			}
+		}
+	}
+}
+
+func (p *poller) close() error {
+	syscall.Write(p.wake[1], []byte{0})
+	<-p.done
+	syscall.Close(p.wake[0])
+	syscall.Close(p.wake[1])
+	return syscall.Close(p.epfd)
+}
+
+// descriptor returns the file descriptor of a connection, looking through
+// TLS to the connection it wraps.
+func descriptor(conn net.Conn) (int, bool) {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return 0, false
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return 0, false
+	}
+	fd := -1
+	if err := raw.Control(func(s uintptr) { fd = int(s) }); err != nil || fd < 0 {
+		return 0, false
+	}
+	return fd, true
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package eventloop
+
+// poller does nothing on platforms without epoll: a stream whose client has
+// gone is closed when the next write to it fails.
+type poller struct{}
+
+func newPoller() (*poller, error) { return &poller{}, nil }
+
+func (p *poller) add(s *Stream)    {}
+func (p *poller) remove(s *Stream) {}
+func (p *poller) close() error     { return nil }
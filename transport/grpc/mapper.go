@@ -33,6 +33,7 @@ var ErrorMap = map[int]codes.Code{
 	1008: codes.DeadlineExceeded,   // Timeout
 	1009: codes.Unavailable,        // Connection error
 	1010: codes.FailedPrecondition, // Protocol error
+	1011: codes.Unavailable,        // Maintenance
 }
 
 // GRPCToJSONRPCError converts a gRPC status error to a JSON-RPC error
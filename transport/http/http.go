@@ -34,6 +34,8 @@ type Transport struct {
 	asyncHandlers map[string]AsyncMessageHandler
 	pathPrefix    string // Optional prefix for endpoint paths (e.g., "/mcp")
 	apiPath       string // Path for the HTTP API endpoint
+	handlers      map[string]http.Handler
+	middleware    func(http.Handler) http.Handler
 	mu            sync.RWMutex
 }
 
@@ -80,6 +82,25 @@ func (t *Transport) GetFullAPIPath() string {
 	return t.pathPrefix + t.apiPath
 }
 
+// RegisterHandler registers an additional HTTP handler served alongside the
+// transport's own endpoints. It must be called before Start.
+func (t *Transport) RegisterHandler(pattern string, handler http.Handler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.handlers == nil {
+		t.handlers = make(map[string]http.Handler)
+	}
+	t.handlers[pattern] = handler
+}
+
+// WrapEndpoints wraps the API endpoint with middleware. It must be called
+// before Start.
+func (t *Transport) WrapEndpoints(middleware func(http.Handler) http.Handler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.middleware = transport.ChainMiddleware(t.middleware, middleware)
+}
+
 // Initialize initializes the transport
 func (t *Transport) Initialize() error {
 	// Nothing special to initialize for HTTP
@@ -92,7 +113,16 @@ func (t *Transport) Start() error {
 	mux := http.NewServeMux()
 
 	// Register the API endpoint at the configured path
-	mux.HandleFunc(t.GetFullAPIPath(), t.handleHTTPRequest)
+	var handler http.Handler = http.HandlerFunc(t.handleHTTPRequest)
+	if t.middleware != nil {
+		handler = t.middleware(handler)
+	}
+	mux.Handle(t.GetFullAPIPath(), handler)
+
+	// Register any additional handlers
+	for pattern, handler := range t.handlers {
+		mux.Handle(pattern, handler)
+	}
 
 	t.server = &http.Server{
 		Addr:    t.addr,
@@ -221,6 +251,25 @@ func (t *Transport) handleHTTPRequest(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
+	body = transport.InjectRequestMeta(body, r)
+
+	// Batches are answered with an array of responses, or 202 Accepted if
+	// they held only notifications
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		response, err := t.HandleMessage(body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if response == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(response)
+		return
+	}
+
 	// Parse JSON-RPC request to determine if it's a notification
 	var jsonRPCRequest struct {
 		Jsonrpc string          `json:"jsonrpc"`
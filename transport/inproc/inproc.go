@@ -0,0 +1,270 @@
+// Package inproc provides an in-memory transport that connects an MCP client
+// to a server in the same process.
+//
+// Messages are passed over channels, with no sockets, pipes or
+// subprocesses, which makes the transport suited to unit tests of tool
+// handlers and of full request/response flows.
+//
+// # Basic Usage
+//
+//	c, s := inproc.Pair()
+//
+//	srv := server.NewServer("test", server.WithTransport(s)).
+//	    Tool("echo", "Echo a message", echoHandler)
+//	go srv.Run()
+//
+//	cl, err := client.NewClient("test", client.WithInProcess(c))
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	defer cl.Close()
+//
+//	result, err := cl.CallTool("echo", map[string]interface{}{"message": "hi"})
+package inproc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/localrivet/gomcp/transport"
+)
+
+// ErrClosed is returned when a message is sent after either end of the pair
+// has been closed.
+var ErrClosed = errors.New("in-process transport closed")
+
+// eventBuffer is how many server-initiated messages may wait for the client
+// to process them before the server's Send blocks.
+const eventBuffer = 64
+
+// call is a message sent by the client, with the channel its response is
+// delivered on.
+type call struct {
+	message []byte
+	reply   chan reply
+}
+
+type reply struct {
+	message []byte
+	err     error
+}
+
+// pipe is the state shared by the two ends of a pair.
+type pipe struct {
+	calls     chan call
+	events    chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (p *pipe) close() {
+	p.closeOnce.Do(func() { close(p.done) })
+}
+
+// Pair returns the two ends of a new in-process connection. The client end
+// implements client.Transport and the server end implements
+// transport.Transport.
+//
+// The client may connect before the server runs: its messages wait until
+// the server end is started or the request times out.
+func Pair() (*ClientTransport, *ServerTransport) {
+	p := &pipe{
+		calls:  make(chan call),
+		events: make(chan []byte, eventBuffer),
+		done:   make(chan struct{}),
+	}
+	c := &ClientTransport{
+		pipe:              p,
+		requestTimeout:    30 * time.Second,
+		connectionTimeout: 10 * time.Second,
+	}
+	return c, &ServerTransport{pipe: p}
+}
+
+// ServerTransport is the server end of an in-process connection.
+type ServerTransport struct {
+	transport.BaseTransport
+	pipe      *pipe
+	startOnce sync.Once
+}
+
+// Initialize implements transport.Transport.
+func (t *ServerTransport) Initialize() error {
+	return nil
+}
+
+// Start implements transport.Transport. It begins handling messages from
+// the client, each on its own goroutine so that a handler waiting on the
+// client, such as for a sampling response, does not block other messages.
+func (t *ServerTransport) Start() error {
+	t.startOnce.Do(func() {
+		go t.serve()
+	})
+	return nil
+}
+
+func (t *ServerTransport) serve() {
+	for {
+		select {
+		case c := <-t.pipe.calls:
+			go func() {
+				response, err := t.HandleMessage(c.message)
+				c.reply <- reply{message: response, err: err}
+			}()
+		case <-t.pipe.done:
+			return
+		}
+	}
+}
+
+// Stop implements transport.Transport. It closes both ends of the pair.
+func (t *ServerTransport) Stop() error {
+	t.pipe.close()
+	return nil
+}
+
+// Send implements transport.Transport. It delivers a notification or request
+// to the client's notification handler.
+func (t *ServerTransport) Send(message []byte) error {
+	// A closed pipe may still have room for events, which select would
+	// pick at random
+	select {
+	case <-t.pipe.done:
+		return ErrClosed
+	default:
+	}
+	select {
+	case t.pipe.events <- clone(message):
+		return nil
+	case <-t.pipe.done:
+		return ErrClosed
+	}
+}
+
+// Receive implements transport.Transport. Messages are delivered to the
+// message handler instead.
+func (t *ServerTransport) Receive() ([]byte, error) {
+	return nil, errors.New("receive operation not supported for in-process transport")
+}
+
+// ClientTransport is the client end of an in-process connection.
+type ClientTransport struct {
+	pipe                *pipe
+	requestTimeout      time.Duration
+	connectionTimeout   time.Duration
+	notificationHandler func(method string, params []byte)
+	mu                  sync.RWMutex
+	connectOnce         sync.Once
+}
+
+// Connect implements client.Transport. It starts delivering server-initiated
+// messages to the notification handler.
+func (t *ClientTransport) Connect() error {
+	select {
+	case <-t.pipe.done:
+		return ErrClosed
+	default:
+	}
+	t.connectOnce.Do(func() {
+		go t.dispatch()
+	})
+	return nil
+}
+
+// ConnectWithContext implements client.Transport.
+func (t *ClientTransport) ConnectWithContext(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		return t.Connect()
+	}
+}
+
+func (t *ClientTransport) dispatch() {
+	for {
+		select {
+		case message := <-t.pipe.events:
+			t.mu.RLock()
+			handler := t.notificationHandler
+			t.mu.RUnlock()
+			if handler != nil {
+				handler("", message)
+			}
+		case <-t.pipe.done:
+			return
+		}
+	}
+}
+
+// Disconnect implements client.Transport. It closes both ends of the pair.
+func (t *ClientTransport) Disconnect() error {
+	t.pipe.close()
+	return nil
+}
+
+// Send implements client.Transport.
+func (t *ClientTransport) Send(message []byte) ([]byte, error) {
+	t.mu.RLock()
+	timeout := t.requestTimeout
+	t.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return t.SendWithContext(ctx, message)
+}
+
+// SendWithContext implements client.Transport. It returns once the server
+// has handled the message; notifications and responses return a nil
+// response.
+func (t *ClientTransport) SendWithContext(ctx context.Context, message []byte) ([]byte, error) {
+	c := call{message: clone(message), reply: make(chan reply, 1)}
+	select {
+	case t.pipe.calls <- c:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-t.pipe.done:
+		return nil, ErrClosed
+	}
+
+	select {
+	case r := <-c.reply:
+		return r.message, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-t.pipe.done:
+		return nil, ErrClosed
+	}
+}
+
+// SetRequestTimeout implements client.Transport.
+func (t *ClientTransport) SetRequestTimeout(timeout time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requestTimeout = timeout
+}
+
+// SetConnectionTimeout implements client.Transport.
+func (t *ClientTransport) SetConnectionTimeout(timeout time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.connectionTimeout = timeout
+}
+
+// RegisterNotificationHandler implements client.Transport. The handler
+// receives each server-initiated message whole, with an empty method.
+func (t *ClientTransport) RegisterNotificationHandler(handler func(method string, params []byte)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.notificationHandler = handler
+}
+
+// clone copies a message so that neither end can modify the other's buffer.
+func clone(message []byte) []byte {
+	if message == nil {
+		return nil
+	}
+	return append([]byte(nil), message...)
+}
@@ -0,0 +1,114 @@
+package inproc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRequestResponse(t *testing.T) {
+	c, s := Pair()
+	s.SetMessageHandler(func(message []byte) ([]byte, error) {
+		return append([]byte("echo:"), message...), nil
+	})
+
+	// Messages sent before the server starts wait for it
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		s.Start()
+	}()
+
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	response, err := c.Send([]byte("ping"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(response) != "echo:ping" {
+		t.Errorf("Expected echo:ping, got %q", response)
+	}
+}
+
+func TestServerMessagesReachClient(t *testing.T) {
+	c, s := Pair()
+	received := make(chan string, 1)
+	c.RegisterNotificationHandler(func(method string, params []byte) {
+		if method != "" {
+			t.Errorf("Expected whole messages with an empty method, got %q", method)
+		}
+		received <- string(params)
+	})
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Send([]byte(`{"jsonrpc":"2.0","method":"notifications/progress"}`)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case message := <-received:
+		if message != `{"jsonrpc":"2.0","method":"notifications/progress"}` {
+			t.Errorf("Unexpected message %q", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the server message")
+	}
+}
+
+func TestHandlersRunConcurrently(t *testing.T) {
+	c, s := Pair()
+	release := make(chan struct{})
+	s.SetMessageHandler(func(message []byte) ([]byte, error) {
+		if string(message) == "wait" {
+			<-release
+		} else {
+			close(release)
+		}
+		return message, nil
+	})
+	s.Start()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Send([]byte("wait"))
+		done <- err
+	}()
+
+	// The second message unblocks the first, as a client's response to a
+	// server request would
+	if _, err := c.Send([]byte("release")); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	c, s := Pair()
+	s.SetMessageHandler(func(message []byte) ([]byte, error) { return nil, nil })
+	s.Start()
+
+	if err := c.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Send([]byte("ping")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed from the client, got %v", err)
+	}
+	if err := s.Send([]byte("ping")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed from the server, got %v", err)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	c, _ := Pair()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// Nothing serves the server end
+	if _, err := c.SendWithContext(ctx, []byte("ping")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the request to time out, got %v", err)
+	}
+}
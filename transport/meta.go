@@ -0,0 +1,203 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// HeaderMeta maps HTTP request headers to the _meta keys they populate on
+// incoming JSON-RPC requests. HTTP-based transports use it so that hints a
+// client sends as headers reach the server the same way as hints sent in
+// the request itself.
+var HeaderMeta = map[string]string{
+	"Accept-Language": "locale",
+	"X-API-Key":       "apiKey",
+}
+
+// MetaConnectionID is the _meta key transports that serve several clients
+// at once set on incoming requests to name the connection, or transport
+// session, they arrived on. The server uses it to tell clients apart and to
+// address notifications to one of them with SessionSender.
+const MetaConnectionID = "connectionId"
+
+// SessionSender is implemented by transports that can send a message to a
+// single client rather than to all of them.
+type SessionSender interface {
+	// SendTo sends a message to the client on the connection that
+	// MetaConnectionID named.
+	SendTo(connectionID string, message []byte) error
+}
+
+// ConnectionNotifier is implemented by transports serving several clients
+// that can tell the server when a client's connection closes, so that it
+// can drop what it keeps for the connection.
+type ConnectionNotifier interface {
+	// SetConnectionClosedHandler sets the function called with the
+	// MetaConnectionID of each connection that closes.
+	SetConnectionClosedHandler(handler func(connectionID string))
+}
+
+// SessionHooks lets a server that shares its sessions with other replicas
+// take part in the session handling of a transport.
+type SessionHooks struct {
+	// Resolve is asked about a connection ID the transport doesn't know,
+	// such as the ID of a session started on another replica. If it
+	// returns true, the transport adopts the connection rather than
+	// rejecting it.
+	Resolve func(connectionID string) bool
+
+	// Ended is called when a client ends its session.
+	Ended func(connectionID string)
+}
+
+// SessionResumer is implemented by transports that only accept messages on
+// sessions they created, so that clients can resume sessions on any
+// replica of a server.
+type SessionResumer interface {
+	SetSessionHooks(hooks SessionHooks)
+}
+
+// EndpointWrapper is implemented by HTTP-based transports whose MCP
+// endpoints can be wrapped with middleware, such as authorization. The
+// additional handlers registered next to the endpoints are not wrapped.
+type EndpointWrapper interface {
+	// WrapEndpoints wraps the endpoints with middleware. It must be called
+	// before Start; middleware added first runs first.
+	WrapEndpoints(middleware func(http.Handler) http.Handler)
+}
+
+// ChainMiddleware returns middleware running outer and then inner, where
+// outer may be nil.
+func ChainMiddleware(outer, inner func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	if outer == nil {
+		return inner
+	}
+	return func(next http.Handler) http.Handler {
+		return outer(inner(next))
+	}
+}
+
+// SetConnectionID sets MetaConnectionID in the params._meta object of a
+// JSON-RPC request, replacing any value the client sent so that clients
+// cannot pose as one another.
+func SetConnectionID(message []byte, id string) []byte {
+	return mergeMeta(message, map[string]string{MetaConnectionID: id}, true)
+}
+
+// requestMetaKey is the context key of values set with ContextWithMeta.
+type requestMetaKey struct{}
+
+// ContextWithMeta returns a context carrying _meta values for the requests
+// of an HTTP request. Middleware in front of an HTTP-based transport uses
+// it to pass what it learned about the caller, such as the identity in a
+// validated access token, to the server.
+func ContextWithMeta(ctx context.Context, values map[string]string) context.Context {
+	merged := make(map[string]string)
+	if previous, ok := ctx.Value(requestMetaKey{}).(map[string]string); ok {
+		for key, value := range previous {
+			merged[key] = value
+		}
+	}
+	for key, value := range values {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, requestMetaKey{}, merged)
+}
+
+// InjectRequestMeta adds the headers listed in HeaderMeta to the _meta of a
+// JSON-RPC request like InjectHeaderMeta, and the values set on the
+// request's context with ContextWithMeta, which replace any the client
+// sent.
+func InjectRequestMeta(message []byte, r *http.Request) []byte {
+	message = InjectHeaderMeta(message, r.Header)
+	if values, ok := r.Context().Value(requestMetaKey{}).(map[string]string); ok {
+		message = mergeMeta(message, values, true)
+	}
+	return message
+}
+
+// InjectHeaderMeta copies the headers listed in HeaderMeta into the
+// params._meta object of a JSON-RPC request. Keys the client already set in
+// _meta are left alone. Messages that are not JSON objects, or whose params
+// are not an object, are returned unchanged.
+func InjectHeaderMeta(message []byte, header http.Header) []byte {
+	values := make(map[string]string)
+	for name, key := range HeaderMeta {
+		if value := header.Get(name); value != "" {
+			values[key] = value
+		}
+	}
+	return mergeMeta(message, values, false)
+}
+
+// mergeMeta adds values to the params._meta object of a JSON-RPC request,
+// or of each request in a batch, replacing values the client set only if
+// replace is true.
+func mergeMeta(message []byte, values map[string]string, replace bool) []byte {
+	if len(values) == 0 {
+		return message
+	}
+
+	var batch []json.RawMessage
+	if json.Unmarshal(message, &batch) == nil {
+		for i, element := range batch {
+			batch[i] = mergeMeta(element, values, replace)
+		}
+		if merged, err := json.Marshal(batch); err == nil {
+			return merged
+		}
+		return message
+	}
+
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return message
+	}
+	if _, isRequest := msg["method"]; !isRequest {
+		return message
+	}
+
+	params := make(map[string]json.RawMessage)
+	if raw, ok := msg["params"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return message
+		}
+	}
+
+	meta := make(map[string]interface{})
+	if raw, ok := params["_meta"]; ok {
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return message
+		}
+	}
+
+	changed := false
+	for key, value := range values {
+		if current, exists := meta[key]; !exists || (replace && current != value) {
+			meta[key] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return message
+	}
+
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return message
+	}
+	params["_meta"] = metaJSON
+
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return message
+	}
+	msg["params"] = paramsJSON
+
+	result, err := json.Marshal(msg)
+	if err != nil {
+		return message
+	}
+	return result
+}
@@ -0,0 +1,63 @@
+package transport
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func metaOf(t *testing.T, message []byte) map[string]interface{} {
+	t.Helper()
+
+	var msg struct {
+		Params struct {
+			Meta map[string]interface{} `json:"_meta"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(message, &msg); err != nil {
+		t.Fatalf("Failed to parse message: %v", err)
+	}
+	return msg.Params.Meta
+}
+
+func TestInjectHeaderMeta(t *testing.T) {
+	header := http.Header{}
+	header.Set("Accept-Language", "fr-FR,fr;q=0.9")
+
+	message := InjectHeaderMeta([]byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`), header)
+	if locale := metaOf(t, message)["locale"]; locale != "fr-FR,fr;q=0.9" {
+		t.Errorf("Expected locale from header, got %v", locale)
+	}
+
+	message = InjectHeaderMeta([]byte(`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`), header)
+	if locale := metaOf(t, message)["locale"]; locale != "fr-FR,fr;q=0.9" {
+		t.Errorf("Expected locale to be added to request without params, got %v", locale)
+	}
+}
+
+func TestInjectHeaderMetaKeepsClientValues(t *testing.T) {
+	header := http.Header{}
+	header.Set("Accept-Language", "fr")
+
+	original := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"_meta":{"locale":"de"}}}`)
+	if got := InjectHeaderMeta(original, header); string(got) != string(original) {
+		t.Errorf("Expected message to be unchanged, got %s", got)
+	}
+
+	response := []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`)
+	if got := InjectHeaderMeta(response, header); string(got) != string(response) {
+		t.Errorf("Expected response to be unchanged, got %s", got)
+	}
+}
+
+func TestSetConnectionIDReplacesClientValue(t *testing.T) {
+	message := SetConnectionID([]byte(`{"jsonrpc":"2.0","id":1,"method":"ping","params":{"_meta":{"connectionId":"someone-else"}}}`), "conn-1")
+	if id := metaOf(t, message)[MetaConnectionID]; id != "conn-1" {
+		t.Errorf("Expected the transport's connection ID, got %v", id)
+	}
+
+	response := []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`)
+	if got := SetConnectionID(response, "conn-1"); string(got) != string(response) {
+		t.Errorf("Expected responses to be left alone, got %s", got)
+	}
+}
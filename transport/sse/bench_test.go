@@ -0,0 +1,45 @@
+package sse
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// BenchmarkSendFanOut measures broadcasting one message to many connected
+// clients, with a reader draining each client's channel as the SSE
+// handler would.
+func BenchmarkSendFanOut(b *testing.B) {
+	message := []byte(`{"jsonrpc":"2.0","method":"notifications/resources/updated","params":{"uri":"file:///status"}}`)
+
+	for _, clients := range []int{1, 10, 100, 1000} {
+		b.Run(fmt.Sprintf("Clients=%d", clients), func(b *testing.B) {
+			transport := NewTransport(":0")
+			var wg sync.WaitGroup
+			for i := 0; i < clients; i++ {
+				ch := make(chan []byte, 10)
+				transport.clients[fmt.Sprintf("client-%d", i)] = ch
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for range ch {
+					}
+				}()
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := transport.Send(message); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+
+			for _, ch := range transport.clients {
+				close(ch)
+			}
+			wg.Wait()
+		})
+	}
+}
@@ -8,15 +8,19 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/localrivet/gomcp/transport"
+	"github.com/localrivet/gomcp/transport/eventloop"
 )
 
 // Option is a function that configures a Transport
@@ -49,6 +53,15 @@ func (Options) WithMessagePath(path string) Option {
 	}
 }
 
+// WithEventLoop returns an option that serves SSE connections from a shared
+// event loop instead of a goroutine each, which suits many mostly idle
+// clients. See package eventloop for the trade-offs.
+func (Options) WithEventLoop(config eventloop.Config) Option {
+	return func(t *Transport) {
+		t.loopConfig = &config
+	}
+}
+
 // DefaultShutdownTimeout is the default timeout for graceful shutdown
 const DefaultShutdownTimeout = 10 * time.Second
 
@@ -58,6 +71,10 @@ const DefaultEventsPath = "/sse"
 // DefaultMessagePath is the default endpoint path for message posting
 const DefaultMessagePath = "/message"
 
+// SessionIDParam is the query parameter of the message endpoint URL that
+// identifies which SSE connection a posted message belongs to.
+const SessionIDParam = "sessionId"
+
 // Transport implements the transport.Transport interface for SSE
 type Transport struct {
 	addr     string
@@ -70,6 +87,12 @@ type Transport struct {
 	pathPrefix  string // Optional prefix for endpoint paths (e.g., "/mcp")
 	eventsPath  string // Endpoint for SSE connections
 	messagePath string // Endpoint for receiving messages
+	handlers    map[string]http.Handler
+	middleware  func(http.Handler) http.Handler
+	loopConfig  *eventloop.Config
+	loop        *eventloop.Loop
+	streams     map[string]*eventloop.Stream // Clients served by the event loop
+	closed      func(clientID string)        // Called when a client disconnects
 
 	// For client mode
 	url          string
@@ -102,6 +125,7 @@ func NewTransport(addr string) *Transport {
 		t.doneCh = make(chan struct{})
 	} else {
 		t.clients = make(map[string]chan []byte)
+		t.streams = make(map[string]*eventloop.Stream)
 		// Set default endpoint paths
 		t.eventsPath = DefaultEventsPath
 		t.messagePath = DefaultMessagePath
@@ -155,6 +179,33 @@ func (t *Transport) GetFullMessagePath() string {
 	return t.pathPrefix + t.messagePath
 }
 
+// RegisterHandler registers an additional HTTP handler served alongside the
+// transport's own endpoints. It must be called before Start.
+func (t *Transport) RegisterHandler(pattern string, handler http.Handler) {
+	t.clientsMu.Lock()
+	defer t.clientsMu.Unlock()
+	if t.handlers == nil {
+		t.handlers = make(map[string]http.Handler)
+	}
+	t.handlers[pattern] = handler
+}
+
+// WrapEndpoints wraps the events and message endpoints with middleware. It
+// must be called before Start.
+func (t *Transport) WrapEndpoints(middleware func(http.Handler) http.Handler) {
+	t.clientsMu.Lock()
+	defer t.clientsMu.Unlock()
+	t.middleware = transport.ChainMiddleware(t.middleware, middleware)
+}
+
+// wrapEndpoint applies the middleware set with WrapEndpoints to an endpoint.
+func (t *Transport) wrapEndpoint(handler http.HandlerFunc) http.Handler {
+	if t.middleware == nil {
+		return handler
+	}
+	return t.middleware(handler)
+}
+
 // Initialize initializes the transport
 func (t *Transport) Initialize() error {
 	if t.isClient {
@@ -176,14 +227,27 @@ func (t *Transport) Start() error {
 		return nil
 	}
 
+	if t.loopConfig != nil {
+		loop, err := eventloop.New(*t.loopConfig)
+		if err != nil {
+			return err
+		}
+		t.loop = loop
+	}
+
 	// Start the server
 	mux := http.NewServeMux()
 
 	// SSE endpoint for clients to connect and receive messages
-	mux.HandleFunc(t.GetFullEventsPath(), t.handleSSERequest)
+	mux.Handle(t.GetFullEventsPath(), t.wrapEndpoint(t.handleSSERequest))
 
 	// HTTP POST endpoint for clients to send messages
-	mux.HandleFunc(t.GetFullMessagePath(), t.handleMessageRequest)
+	mux.Handle(t.GetFullMessagePath(), t.wrapEndpoint(t.handleMessageRequest))
+
+	// Register any additional handlers
+	for pattern, handler := range t.handlers {
+		mux.Handle(pattern, handler)
+	}
 
 	t.server = &http.Server{
 		Addr:    t.addr,
@@ -221,6 +285,11 @@ func (t *Transport) Stop() error {
 	t.clients = make(map[string]chan []byte)
 	t.clientsMu.Unlock()
 
+	// Hijacked connections are not closed by the HTTP server
+	if t.loop != nil {
+		t.loop.Close()
+	}
+
 	// Shutdown the server
 	return t.server.Shutdown(ctx)
 }
@@ -280,12 +349,21 @@ func (t *Transport) Send(message []byte) error {
 	}
 
 	// Server mode - send to all clients
+	t.clientsMu.Lock()
+	defer t.clientsMu.Unlock()
+
 	if t.debugHandler != nil {
-		t.debugHandler(fmt.Sprintf("Broadcasting message to %d clients", len(t.clients)))
+		t.debugHandler(fmt.Sprintf("Broadcasting message to %d clients", len(t.clients)+len(t.streams)))
 	}
 
-	t.clientsMu.Lock()
-	defer t.clientsMu.Unlock()
+	if len(t.streams) > 0 {
+		event := formatEvent(message)
+		for _, stream := range t.streams {
+			if !stream.Send(event) && t.debugHandler != nil {
+				t.debugHandler("Client queue full, message dropped")
+			}
+		}
+	}
 
 	for _, clientCh := range t.clients {
 		select {
@@ -301,6 +379,35 @@ func (t *Transport) Send(message []byte) error {
 	return nil
 }
 
+// SendTo sends a message to a single connected client (server mode). The
+// client is the one named by transport.MetaConnectionID on the messages it
+// posts.
+func (t *Transport) SendTo(connectionID string, message []byte) error {
+	if t.isClient {
+		return errors.New("SendTo is only supported in server mode")
+	}
+
+	t.clientsMu.Lock()
+	defer t.clientsMu.Unlock()
+
+	if stream, ok := t.streams[connectionID]; ok {
+		if !stream.Send(formatEvent(message)) {
+			return errors.New("client queue full, message dropped")
+		}
+		return nil
+	}
+	clientCh, ok := t.clients[connectionID]
+	if !ok {
+		return fmt.Errorf("no client connected as %s", connectionID)
+	}
+	select {
+	case clientCh <- message:
+		return nil
+	default:
+		return errors.New("client channel full, message dropped")
+	}
+}
+
 // Receive receives a message (client mode only)
 func (t *Transport) Receive() ([]byte, error) {
 	if !t.isClient {
@@ -325,9 +432,15 @@ func (t *Transport) Receive() ([]byte, error) {
 	}
 }
 
-// generateClientID creates a unique client ID
+// generateClientID creates a unique client ID. IDs are random because the
+// message endpoint trusts them to say which client a message came from.
 func (t *Transport) generateClientID() string {
-	return fmt.Sprintf("client-%d", time.Now().UnixNano())
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		// crypto/rand does not fail on supported platforms
+		panic(err)
+	}
+	return "client-" + hex.EncodeToString(id[:])
 }
 
 // handleSSERequest handles incoming SSE connection requests
@@ -353,6 +466,10 @@ func (t *Transport) handleSSERequest(w http.ResponseWriter, r *http.Request) {
 	clientID := t.generateClientID()
 	fmt.Printf("SERVER DEBUG: Generated client ID: %s\n", clientID)
 
+	if t.loop != nil && t.serveLooped(w, r, clientID) {
+		return
+	}
+
 	// Create a channel for this client
 	clientCh := make(chan []byte, 10)
 
@@ -363,7 +480,7 @@ func (t *Transport) handleSSERequest(w http.ResponseWriter, r *http.Request) {
 	fmt.Printf("SERVER DEBUG: Registered client with ID: %s\n", clientID)
 
 	// Create the full message endpoint for this client
-	messageURL := fmt.Sprintf("http://%s%s", r.Host, t.GetFullMessagePath())
+	messageURL := t.messageURL(r, clientID)
 	fmt.Printf("SERVER DEBUG: Message endpoint URL: %s\n", messageURL)
 
 	// Clean up when the client disconnects
@@ -372,7 +489,11 @@ func (t *Transport) handleSSERequest(w http.ResponseWriter, r *http.Request) {
 		t.clientsMu.Lock()
 		delete(t.clients, clientID)
 		close(clientCh)
+		closed := t.closed
 		t.clientsMu.Unlock()
+		if closed != nil {
+			closed(clientID)
+		}
 	}()
 
 	// Ensure the connection stays open with a flush
@@ -409,13 +530,69 @@ func (t *Transport) handleSSERequest(w http.ResponseWriter, r *http.Request) {
 
 			// Format the message as an SSE event
 			fmt.Printf("SERVER DEBUG: Sending message to client: %s\n", string(msg))
-			fmt.Fprintf(w, "event: message\ndata: %s\n\n", string(msg))
+			w.Write(formatEvent(msg))
 			flusher.Flush()
 			fmt.Printf("SERVER DEBUG: Flushed message to client\n")
 		}
 	}
 }
 
+// serveLooped hands an SSE connection to the event loop and reports whether
+// it did; connections the loop can't take, such as HTTP/2 ones, are served
+// by the handler as usual.
+func (t *Transport) serveLooped(w http.ResponseWriter, r *http.Request, clientID string) bool {
+	stream, err := t.loop.Open(w, r, w.Header(), func() {
+		t.clientsMu.Lock()
+		delete(t.streams, clientID)
+		closed := t.closed
+		t.clientsMu.Unlock()
+		if closed != nil {
+			closed(clientID)
+		}
+	})
+	if errors.Is(err, eventloop.ErrNotSupported) {
+		return false
+	}
+	if err != nil {
+		if t.debugHandler != nil {
+			t.debugHandler(fmt.Sprintf("Failed to open looped stream: %v", err))
+		}
+		return true
+	}
+
+	t.clientsMu.Lock()
+	t.streams[clientID] = stream
+	t.clientsMu.Unlock()
+
+	// Tell the client where to send messages. If it already left, the
+	// stream's close callback may have run before it was registered.
+	messageURL := t.messageURL(r, clientID)
+	if !stream.Send([]byte(fmt.Sprintf("event: endpoint\ndata: %s\n\n", messageURL))) {
+		t.clientsMu.Lock()
+		delete(t.streams, clientID)
+		t.clientsMu.Unlock()
+	}
+	return true
+}
+
+// SetConnectionClosedHandler implements transport.ConnectionNotifier.
+func (t *Transport) SetConnectionClosedHandler(handler func(connectionID string)) {
+	t.clientsMu.Lock()
+	defer t.clientsMu.Unlock()
+	t.closed = handler
+}
+
+// messageURL returns the endpoint a client posts its messages to. It names
+// the client's connection so the server can tell clients apart.
+func (t *Transport) messageURL(r *http.Request, clientID string) string {
+	return fmt.Sprintf("http://%s%s?%s=%s", r.Host, t.GetFullMessagePath(), SessionIDParam, url.QueryEscape(clientID))
+}
+
+// formatEvent encodes a message as an SSE message event.
+func formatEvent(message []byte) []byte {
+	return []byte(fmt.Sprintf("event: message\ndata: %s\n\n", message))
+}
+
 // handleMessageRequest handles incoming client messages via HTTP POST
 func (t *Transport) handleMessageRequest(w http.ResponseWriter, r *http.Request) {
 	// Validate method
@@ -439,6 +616,11 @@ func (t *Transport) handleMessageRequest(w http.ResponseWriter, r *http.Request)
 	}
 	defer r.Body.Close()
 
+	body = transport.InjectRequestMeta(body, r)
+	if clientID := r.URL.Query().Get(SessionIDParam); clientID != "" {
+		body = transport.SetConnectionID(body, clientID)
+	}
+
 	// Process the message
 	var response []byte
 	if t.handler != nil {
@@ -1,13 +1,17 @@
 package sse
 
 import (
+	"bufio"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/localrivet/gomcp/transport/eventloop"
 )
 
 func getRandomPort() string {
@@ -191,3 +195,57 @@ func TestServerReceiveError(t *testing.T) {
 		t.Error("Expected Receive to fail in server mode, but it succeeded")
 	}
 }
+
+func TestServerModeEventLoop(t *testing.T) {
+	port := getRandomPort()
+	transport := NewTransport(port)
+	SSE.WithEventLoop(eventloop.Config{Workers: 1})(transport)
+	if err := transport.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer transport.Stop()
+
+	var resp *http.Response
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var err error
+		if resp, err = http.Get("http://localhost" + port + DefaultEventsPath); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+
+	readEvent := func() string {
+		t.Helper()
+		var event strings.Builder
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			if line == "\n" {
+				return event.String()
+			}
+			event.WriteString(line)
+		}
+	}
+
+	if event := readEvent(); !strings.HasPrefix(event, "event: endpoint\n") {
+		t.Fatalf("Expected the endpoint event first, got %q", event)
+	}
+	if n := transport.loop.Len(); n != 1 {
+		t.Errorf("Expected the client to be served by the event loop, got %d looped streams", n)
+	}
+
+	if err := transport.Send([]byte(`{"jsonrpc":"2.0","method":"ping"}`)); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if event := readEvent(); event != "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"ping\"}\n" {
+		t.Errorf("Expected the broadcast message, got %q", event)
+	}
+}
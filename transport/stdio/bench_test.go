@@ -0,0 +1,51 @@
+package stdio
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+var benchMessage = []byte(`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"It is 18 degrees and sunny in Paris."}],"isError":false}}`)
+
+func BenchmarkSend(b *testing.B) {
+	transport := NewTransportWithIO(bytes.NewReader(nil), io.Discard)
+	b.SetBytes(int64(len(benchMessage) + 1))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := transport.Send(benchMessage); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkRoundTrip measures reading requests line by line, handling
+// them and writing the responses, as a server on stdio does.
+func BenchmarkRoundTrip(b *testing.B) {
+	in, writer := io.Pipe()
+	transport := NewTransportWithIO(in, io.Discard)
+	done := make(chan struct{}, 1)
+	handled := 0
+	transport.SetMessageHandler(func(message []byte) ([]byte, error) {
+		handled++
+		if handled == b.N {
+			done <- struct{}{}
+		}
+		return message, nil
+	})
+	transport.Start()
+	defer transport.Stop()
+
+	line := append(append([]byte{}, benchMessage...), '\n')
+	b.SetBytes(int64(len(line)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	go func() {
+		for i := 0; i < b.N; i++ {
+			writer.Write(line)
+		}
+	}()
+	<-done
+	b.StopTimer()
+	writer.Close()
+}
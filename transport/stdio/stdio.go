@@ -6,10 +6,12 @@ package stdio
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/localrivet/gomcp/transport"
@@ -20,6 +22,7 @@ type Transport struct {
 	transport.BaseTransport
 	reader  *bufio.Reader
 	writer  *bufio.Writer
+	writeMu sync.Mutex // serializes messages written from several goroutines
 	done    chan struct{}
 	readEOF bool
 	newline bool // Whether to append a newline to each message
@@ -64,6 +67,9 @@ func (t *Transport) Stop() error {
 
 // Send sends a message over stdout.
 func (t *Transport) Send(message []byte) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
 	// Write the message to stdout
 	_, err := t.writer.Write(message)
 	if err != nil {
@@ -149,10 +155,35 @@ func (t *Transport) readLoop() {
 				}
 			}
 
-			// Process the message with the handler
-			if response, err := t.HandleMessage([]byte(line)); err == nil && response != nil {
-				t.Send(response)
+			// Notifications are handled in order as they arrive. Requests and
+			// responses are handled concurrently, so a running handler
+			// doesn't hold up reading a cancellation or the client's answer
+			// to a request it sent.
+			if isNotification([]byte(line)) {
+				t.respond([]byte(line))
+				continue
 			}
+			go t.respond([]byte(line))
 		}
 	}
 }
+
+// respond handles a message and writes the response, if any, to stdout.
+func (t *Transport) respond(message []byte) {
+	if response, err := t.HandleMessage(message); err == nil && response != nil {
+		t.Send(response)
+	}
+}
+
+// isNotification reports whether a message is a notification, which has a
+// method but no ID.
+func isNotification(message []byte) bool {
+	var msg struct {
+		Method string          `json:"method"`
+		ID     json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return false
+	}
+	return msg.Method != "" && len(msg.ID) == 0
+}
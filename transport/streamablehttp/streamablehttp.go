@@ -0,0 +1,1259 @@
+// Package streamablehttp provides a Streamable HTTP implementation of the MCP transport.
+//
+// Streamable HTTP, introduced in the 2025-03-26 revision of the MCP
+// specification, replaces the HTTP+SSE transport's two endpoints with a
+// single one. Clients POST every JSON-RPC message to the endpoint. The
+// server answers a request with a JSON body, or with an SSE stream when it
+// has messages to send before the response, such as progress notifications.
+// Clients may GET the endpoint to open a stream for server-initiated
+// messages, and DELETE it to end their session.
+//
+// A transport created with an http:// or https:// URL runs in client mode;
+// any other address is a listen address and runs the transport as a server.
+package streamablehttp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/localrivet/gomcp/transport"
+	"github.com/localrivet/gomcp/transport/eventloop"
+)
+
+// DefaultShutdownTimeout is the default timeout for graceful shutdown
+const DefaultShutdownTimeout = 10 * time.Second
+
+// DefaultEndpoint is the default path of the MCP endpoint
+const DefaultEndpoint = "/mcp"
+
+// SessionIDHeader carries the session ID the server assigns on initialize.
+const SessionIDHeader = "Mcp-Session-Id"
+
+// reconnectDelay is how long a client waits before reopening a dropped
+// server-message stream.
+const reconnectDelay = time.Second
+
+// streamBuffer is the number of messages queued per open stream.
+const streamBuffer = 32
+
+// ErrSessionExpired is returned in client mode when the server no longer
+// knows the session. The client must initialize again.
+var ErrSessionExpired = errors.New("session expired")
+
+// ErrNoStream is returned by Send in server mode when a request for the
+// client cannot be delivered because no stream to the client is open.
+var ErrNoStream = errors.New("no open stream to the client")
+
+// Option is a function that configures a Transport
+type Option func(*Transport)
+
+// WithEndpoint sets the path of the MCP endpoint (server mode).
+func WithEndpoint(path string) Option {
+	return func(t *Transport) {
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		t.endpoint = path
+	}
+}
+
+// WithAllowedOrigins sets the browser origins allowed to use the endpoint
+// (server mode). Requests without an Origin header and same-origin requests
+// are always allowed; "*" allows any origin.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(t *Transport) {
+		t.allowedOrigins = append(t.allowedOrigins, origins...)
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to reach the server (client mode).
+func WithHTTPClient(client *http.Client) Option {
+	return func(t *Transport) {
+		t.client = client
+	}
+}
+
+// WithHeader adds a header to every request sent to the server (client mode).
+func WithHeader(key, value string) Option {
+	return func(t *Transport) {
+		if t.headers == nil {
+			t.headers = make(map[string]string)
+		}
+		t.headers[key] = value
+	}
+}
+
+// WithEventLoop serves the server-message streams clients open with GET
+// from a shared event loop instead of a goroutine each (server mode), which
+// suits many mostly idle sessions. Streams answering POST requests are
+// short-lived and are always served by their handler. See package eventloop
+// for the trade-offs.
+func WithEventLoop(config eventloop.Config) Option {
+	return func(t *Transport) {
+		t.loopConfig = &config
+	}
+}
+
+// Transport implements the transport.Transport interface for Streamable HTTP
+type Transport struct {
+	transport.BaseTransport
+	addr     string
+	isClient bool
+
+	// For server mode
+	server         *http.Server
+	endpoint       string
+	allowedOrigins []string
+	handlers       map[string]http.Handler
+	middleware     func(http.Handler) http.Handler
+	sessions       map[string]*session
+	sessionHooks   transport.SessionHooks
+	loopConfig     *eventloop.Config
+	loop           *eventloop.Loop
+	loopOnce       sync.Once
+
+	// For client mode
+	client    *http.Client
+	headers   map[string]string
+	sessionID string
+	started   bool
+	listening bool
+	done      chan struct{}
+	stopOnce  sync.Once
+
+	mu sync.Mutex
+}
+
+// NewTransport creates a new Streamable HTTP transport. An http:// or
+// https:// URL selects client mode; anything else is a listen address.
+func NewTransport(addr string, options ...Option) *Transport {
+	t := &Transport{
+		addr:     addr,
+		isClient: strings.HasPrefix(addr, "http://") || strings.HasPrefix(addr, "https://"),
+		endpoint: DefaultEndpoint,
+		sessions: make(map[string]*session),
+		done:     make(chan struct{}),
+	}
+	for _, option := range options {
+		option(t)
+	}
+	if t.isClient && t.client == nil {
+		t.client = &http.Client{}
+	}
+	return t
+}
+
+// GetAddr returns the transport's address
+func (t *Transport) GetAddr() string {
+	return t.addr
+}
+
+// GetEndpoint returns the path of the MCP endpoint
+func (t *Transport) GetEndpoint() string {
+	return t.endpoint
+}
+
+// RegisterHandler registers an additional HTTP handler served alongside the
+// transport's own endpoint. It must be called before Start.
+func (t *Transport) RegisterHandler(pattern string, handler http.Handler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.handlers == nil {
+		t.handlers = make(map[string]http.Handler)
+	}
+	t.handlers[pattern] = handler
+}
+
+// WrapEndpoints wraps the MCP endpoint with middleware, whether it is
+// served by Start or mounted with ServeHTTP. It must be called before the
+// transport serves requests.
+func (t *Transport) WrapEndpoints(middleware func(http.Handler) http.Handler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.middleware = transport.ChainMiddleware(t.middleware, middleware)
+}
+
+// Initialize initializes the transport
+func (t *Transport) Initialize() error {
+	return nil
+}
+
+// Start starts the transport. In server mode it starts listening; in client
+// mode the server-message stream is opened once a session is established.
+func (t *Transport) Start() error {
+	if t.isClient {
+		t.mu.Lock()
+		t.started = true
+		listen := t.sessionID != "" && !t.listening
+		t.listening = t.listening || listen
+		t.mu.Unlock()
+		if listen {
+			go t.listen()
+		}
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(t.endpoint, t)
+
+	t.mu.Lock()
+	for pattern, handler := range t.handlers {
+		mux.Handle(pattern, handler)
+	}
+	t.server = &http.Server{
+		Addr:    t.addr,
+		Handler: mux,
+	}
+	t.mu.Unlock()
+
+	go func() {
+		if err := t.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			t.debugf("Streamable HTTP server error: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops the transport. In client mode it also ends the session on the
+// server.
+func (t *Transport) Stop() error {
+	if t.isClient {
+		t.stopOnce.Do(func() { close(t.done) })
+		t.endSession()
+		return nil
+	}
+
+	t.mu.Lock()
+	sessions := t.sessions
+	t.sessions = make(map[string]*session)
+	t.mu.Unlock()
+	for _, sess := range sessions {
+		sess.close()
+	}
+	t.mu.Lock()
+	loop := t.loop
+	t.mu.Unlock()
+	if loop != nil {
+		loop.Close()
+	}
+
+	if t.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultShutdownTimeout)
+	defer cancel()
+	return t.server.Shutdown(ctx)
+}
+
+// Send sends a message. In server mode the message is written to an open
+// stream: progress notifications go to the stream of the request that asked
+// for them, anything else to the clients' server-message streams, falling
+// back to an in-flight request's stream. Notifications nobody can receive
+// are dropped. In client mode the message is POSTed to the server and any
+// reply is passed to the message handler.
+func (t *Transport) Send(message []byte) error {
+	if t.isClient {
+		reply, err := t.Request(context.Background(), message)
+		if err != nil {
+			return err
+		}
+		if reply != nil {
+			t.deliver(reply)
+		}
+		return nil
+	}
+
+	if t.route(message) {
+		return nil
+	}
+	if info, err := inspect(message); err == nil && len(info.ids) > 0 {
+		return ErrNoStream
+	}
+	t.debugf("No open stream, notification dropped: %s", message)
+	return nil
+}
+
+// SendTo sends a message to the client of one session (server mode), on its
+// server-message stream or else on one of its in-flight requests' streams.
+// The session is the one named by transport.MetaConnectionID on the
+// client's requests. It returns ErrNoStream if the session is unknown or
+// has no open stream.
+func (t *Transport) SendTo(connectionID string, message []byte) error {
+	t.mu.Lock()
+	sess := t.sessions[connectionID]
+	t.mu.Unlock()
+	if sess == nil {
+		return ErrNoStream
+	}
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.listener != nil && sess.listener.offer(message) {
+		return nil
+	}
+	for _, st := range sess.streams {
+		if st.offer(message) {
+			return nil
+		}
+	}
+	return ErrNoStream
+}
+
+// Receive is not supported; incoming messages are passed to the message handler
+func (t *Transport) Receive() ([]byte, error) {
+	return nil, errors.New("receive operation not supported for Streamable HTTP transport")
+}
+
+func (t *Transport) debugf(format string, args ...interface{}) {
+	if debug := t.GetDebugHandler(); debug != nil {
+		debug(fmt.Sprintf(format, args...))
+	}
+}
+
+// session is a client session in server mode.
+type session struct {
+	id string
+
+	mu       sync.Mutex
+	listener *stream   // the GET stream, if open
+	streams  []*stream // streams of in-flight POST requests, oldest first
+	closed   bool
+}
+
+// stream is an open SSE response.
+type stream struct {
+	events chan []byte
+	done   chan struct{}
+
+	// looped is set when the stream is served by the event loop, which
+	// then takes its messages instead of events.
+	looped *eventloop.Stream
+
+	// progressTokens are the tokens of the request the stream answers.
+	progressTokens []string
+}
+
+func newStream(progressTokens []string) *stream {
+	return &stream{
+		events:         make(chan []byte, streamBuffer),
+		done:           make(chan struct{}),
+		progressTokens: progressTokens,
+	}
+}
+
+// offer queues a message on the stream without blocking.
+func (st *stream) offer(message []byte) bool {
+	if st.looped != nil {
+		return st.looped.Send(formatEvent(message))
+	}
+	select {
+	case st.events <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+func (sess *session) addStream(st *stream) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.streams = append(sess.streams, st)
+}
+
+func (sess *session) removeStream(st *stream) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	for i, s := range sess.streams {
+		if s == st {
+			sess.streams = append(sess.streams[:i], sess.streams[i+1:]...)
+			return
+		}
+	}
+}
+
+// close ends the session and all of its streams.
+func (sess *session) close() {
+	sess.mu.Lock()
+	if sess.closed {
+		sess.mu.Unlock()
+		return
+	}
+	sess.closed = true
+	var looped *eventloop.Stream
+	if sess.listener != nil {
+		close(sess.listener.done)
+		looped = sess.listener.looped
+		sess.listener = nil
+	}
+	for _, st := range sess.streams {
+		close(st.done)
+	}
+	sess.streams = nil
+	sess.mu.Unlock()
+
+	// Closing a looped stream calls back into the session
+	if looped != nil {
+		looped.Close()
+	}
+}
+
+// route delivers a server message to the best open stream.
+func (t *Transport) route(message []byte) bool {
+	t.mu.Lock()
+	sessions := make([]*session, 0, len(t.sessions))
+	for _, sess := range t.sessions {
+		sessions = append(sessions, sess)
+	}
+	t.mu.Unlock()
+
+	if token, ok := progressToken(message); ok {
+		for _, sess := range sessions {
+			sess.mu.Lock()
+			for _, st := range sess.streams {
+				for _, tok := range st.progressTokens {
+					if tok == token && st.offer(message) {
+						sess.mu.Unlock()
+						return true
+					}
+				}
+			}
+			sess.mu.Unlock()
+		}
+	}
+
+	delivered := false
+	for _, sess := range sessions {
+		sess.mu.Lock()
+		if sess.listener != nil && sess.listener.offer(message) {
+			delivered = true
+		}
+		sess.mu.Unlock()
+	}
+	if delivered {
+		return true
+	}
+
+	for _, sess := range sessions {
+		sess.mu.Lock()
+		for _, st := range sess.streams {
+			if st.offer(message) {
+				sess.mu.Unlock()
+				return true
+			}
+		}
+		sess.mu.Unlock()
+	}
+	return false
+}
+
+// ServeHTTP serves the MCP endpoint. It lets the transport be mounted on an
+// existing mux instead of being started with Start.
+func (t *Transport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t.mu.Lock()
+	middleware := t.middleware
+	t.mu.Unlock()
+	if middleware != nil {
+		middleware(http.HandlerFunc(t.serveEndpoint)).ServeHTTP(w, r)
+		return
+	}
+	t.serveEndpoint(w, r)
+}
+
+// serveEndpoint serves the MCP endpoint behind any middleware.
+func (t *Transport) serveEndpoint(w http.ResponseWriter, r *http.Request) {
+	if !t.originAllowed(r) {
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		t.handlePost(w, r)
+	case http.MethodGet:
+		t.handleGet(w, r)
+	case http.MethodDelete:
+		t.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// originAllowed guards against DNS rebinding: browsers send an Origin header,
+// which must name this host or one of the allowed origins.
+func (t *Transport) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range t.allowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// handlePost handles a JSON-RPC message or batch sent by the client.
+func (t *Transport) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	info, err := inspect(body)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, nil, -32700, "Parse error", err.Error())
+		return
+	}
+	body = transport.InjectRequestMeta(body, r)
+
+	var sess *session
+	if info.initialize {
+		sess = t.newSession()
+		w.Header().Set(SessionIDHeader, sess.id)
+	} else if sess = t.lookupSession(w, r); sess == nil {
+		return
+	}
+	body = transport.SetConnectionID(body, sess.id)
+
+	// Notifications and responses get no reply
+	if len(info.ids) == 0 {
+		if _, err := t.HandleMessage(body); err != nil {
+			t.debugf("Error processing message: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	t.respond(w, r, sess, body, info)
+}
+
+// respond runs a request and writes its response. The response is plain
+// JSON unless messages for the client arrive while the request runs, in
+// which case the reply switches to an SSE stream carrying them first.
+func (t *Transport) respond(w http.ResponseWriter, r *http.Request, sess *session, body []byte, info messageInfo) {
+	acceptsJSON, acceptsSSE := accepts(r.Header.Get("Accept"))
+
+	var st *stream
+	var events chan []byte
+	if acceptsSSE {
+		st = newStream(info.progressTokens)
+		events = st.events
+		sess.addStream(st)
+		defer sess.removeStream(st)
+	}
+
+	type result struct {
+		response []byte
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		response, err := t.HandleMessage(body)
+		done <- result{response, err}
+	}()
+
+	var sw *sseWriter
+	write := func(message []byte) bool {
+		if sw == nil {
+			if sw = startSSE(w); sw == nil {
+				return false
+			}
+		}
+		return sw.write(message) == nil
+	}
+
+	var closed <-chan struct{}
+	if st != nil {
+		closed = st.done
+	}
+
+	for {
+		select {
+		case message := <-events:
+			if !write(message) {
+				return
+			}
+
+		case res := <-done:
+			// Send anything queued before the response
+			for pending := true; pending; {
+				select {
+				case message := <-events:
+					if !write(message) {
+						return
+					}
+				default:
+					pending = false
+				}
+			}
+
+			response := res.response
+			if res.err != nil {
+				response = jsonError(info.firstID(), -32603, "Internal error", res.err.Error())
+			}
+			switch {
+			case sw != nil:
+				if response != nil {
+					sw.write(response)
+				}
+			case response == nil:
+				// The request was queued; its response is sent on a stream later
+				w.WriteHeader(http.StatusAccepted)
+			case acceptsJSON || !acceptsSSE:
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(response)
+			default:
+				write(response)
+			}
+			return
+
+		case <-closed:
+			return
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleGet opens the stream for server-initiated messages.
+func (t *Transport) handleGet(w http.ResponseWriter, r *http.Request) {
+	if _, acceptsSSE := accepts(r.Header.Get("Accept")); !acceptsSSE {
+		http.Error(w, "Accept must include text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+	sess := t.lookupSession(w, r)
+	if sess == nil {
+		return
+	}
+
+	st := newStream(nil)
+	sess.mu.Lock()
+	if sess.closed || sess.listener != nil {
+		sess.mu.Unlock()
+		http.Error(w, "A stream is already open for this session", http.StatusConflict)
+		return
+	}
+	sess.listener = st
+	sess.mu.Unlock()
+
+	if t.serveLooped(w, r, sess, st) {
+		return
+	}
+
+	defer func() {
+		sess.mu.Lock()
+		if sess.listener == st {
+			sess.listener = nil
+		}
+		sess.mu.Unlock()
+	}()
+
+	sw := startSSE(w)
+	if sw == nil {
+		return
+	}
+
+	for {
+		select {
+		case message := <-st.events:
+			if err := sw.write(message); err != nil {
+				return
+			}
+		case <-st.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// serveLooped hands a session's server-message stream to the event loop, if
+// one is configured, and reports whether it did. The stream then outlives
+// the handler until the client disconnects or the session ends.
+func (t *Transport) serveLooped(w http.ResponseWriter, r *http.Request, sess *session, st *stream) bool {
+	loop := t.eventLoop()
+	if loop == nil {
+		return false
+	}
+
+	header := w.Header().Clone()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+
+	release := func() {
+		sess.mu.Lock()
+		if sess.listener == st {
+			sess.listener = nil
+		}
+		sess.mu.Unlock()
+	}
+	looped, err := loop.Open(w, r, header, release)
+	if errors.Is(err, eventloop.ErrNotSupported) || errors.Is(err, eventloop.ErrClosed) {
+		return false
+	}
+	if err != nil {
+		t.debugf("Failed to open looped stream: %v", err)
+		release()
+		return true
+	}
+
+	// Messages routed while the stream was handed over wait in events
+	sess.mu.Lock()
+	if sess.listener != st {
+		// The session ended or the client left before the handover
+		sess.mu.Unlock()
+		looped.Close()
+		return true
+	}
+	st.looped = looped
+	defer sess.mu.Unlock()
+	for pending := true; pending; {
+		select {
+		case message := <-st.events:
+			looped.Send(formatEvent(message))
+		default:
+			pending = false
+		}
+	}
+	return true
+}
+
+// eventLoop returns the transport's event loop, starting it on first use,
+// or nil if the transport doesn't use one.
+func (t *Transport) eventLoop() *eventloop.Loop {
+	if t.loopConfig == nil {
+		return nil
+	}
+	t.loopOnce.Do(func() {
+		loop, err := eventloop.New(*t.loopConfig)
+		if err != nil {
+			t.debugf("Event loop unavailable, serving streams from handlers: %v", err)
+			return
+		}
+		t.mu.Lock()
+		t.loop = loop
+		t.mu.Unlock()
+	})
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.loop
+}
+
+// handleDelete ends a session.
+func (t *Transport) handleDelete(w http.ResponseWriter, r *http.Request) {
+	sess := t.lookupSession(w, r)
+	if sess == nil {
+		return
+	}
+	t.mu.Lock()
+	delete(t.sessions, sess.id)
+	ended := t.sessionHooks.Ended
+	t.mu.Unlock()
+	sess.close()
+	if ended != nil {
+		ended(sess.id)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (t *Transport) newSession() *session {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		// crypto/rand does not fail on supported platforms
+		panic(err)
+	}
+	sess := &session{id: hex.EncodeToString(id[:])}
+	t.mu.Lock()
+	t.sessions[sess.id] = sess
+	t.mu.Unlock()
+	return sess
+}
+
+// SetSessionHooks implements transport.SessionResumer. Requests naming a
+// session this transport didn't create are passed to hooks.Resolve before
+// being rejected, so a session started on another replica continues here.
+func (t *Transport) SetSessionHooks(hooks transport.SessionHooks) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sessionHooks = hooks
+}
+
+// resumeSession adopts a session started elsewhere if the session hooks
+// know it.
+func (t *Transport) resumeSession(id string) *session {
+	t.mu.Lock()
+	resolve := t.sessionHooks.Resolve
+	t.mu.Unlock()
+	if resolve == nil || !resolve(id) {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// Concurrent requests may have adopted it meanwhile
+	if sess := t.sessions[id]; sess != nil {
+		return sess
+	}
+	sess := &session{id: id}
+	t.sessions[id] = sess
+	return sess
+}
+
+// lookupSession returns the session named by the request, writing an error
+// response and returning nil if there is none.
+func (t *Transport) lookupSession(w http.ResponseWriter, r *http.Request) *session {
+	id := r.Header.Get(SessionIDHeader)
+	if id == "" {
+		http.Error(w, "Missing "+SessionIDHeader+" header", http.StatusBadRequest)
+		return nil
+	}
+	t.mu.Lock()
+	sess := t.sessions[id]
+	t.mu.Unlock()
+	if sess == nil {
+		sess = t.resumeSession(id)
+	}
+	if sess == nil {
+		http.Error(w, "Unknown session", http.StatusNotFound)
+		return nil
+	}
+	return sess
+}
+
+// sseWriter writes messages as SSE events.
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// startSSE writes the headers of an SSE response.
+func startSSE(w http.ResponseWriter) *sseWriter {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return nil
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return &sseWriter{w: w, flusher: flusher}
+}
+
+func (sw *sseWriter) write(message []byte) error {
+	if _, err := sw.w.Write(formatEvent(message)); err != nil {
+		return err
+	}
+	sw.flusher.Flush()
+	return nil
+}
+
+// formatEvent encodes a message as an SSE event.
+func formatEvent(message []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("event: message\n")
+	for _, line := range bytes.Split(message, []byte("\n")) {
+		b.WriteString("data: ")
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	return b.Bytes()
+}
+
+// accepts reports which response types an Accept header allows. A missing
+// header allows plain JSON only.
+func accepts(header string) (acceptsJSON, acceptsSSE bool) {
+	if header == "" {
+		return true, false
+	}
+	for _, part := range strings.Split(header, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "application/json", "application/*":
+			acceptsJSON = true
+		case "text/event-stream", "text/*":
+			acceptsSSE = true
+		case "*/*":
+			acceptsJSON = true
+		}
+	}
+	return acceptsJSON, acceptsSSE
+}
+
+// messageInfo describes a POSTed message or batch.
+type messageInfo struct {
+	// ids are the raw IDs of the requests; notifications and responses have none.
+	ids []json.RawMessage
+
+	initialize     bool
+	progressTokens []string
+}
+
+func (info messageInfo) firstID() json.RawMessage {
+	if len(info.ids) == 0 {
+		return nil
+	}
+	return info.ids[0]
+}
+
+// inspect classifies a JSON-RPC message or batch.
+func inspect(body []byte) (messageInfo, error) {
+	var info messageInfo
+	var messages []json.RawMessage
+	trimmed := bytes.TrimSpace(body)
+	if bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &messages); err != nil {
+			return info, err
+		}
+	} else {
+		messages = []json.RawMessage{trimmed}
+	}
+
+	for _, raw := range messages {
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return info, err
+		}
+		if msg.Method == "" || len(msg.ID) == 0 || string(msg.ID) == "null" {
+			continue
+		}
+		info.ids = append(info.ids, msg.ID)
+		if msg.Method == "initialize" {
+			info.initialize = true
+		}
+		var params struct {
+			Meta struct {
+				ProgressToken json.RawMessage `json:"progressToken"`
+			} `json:"_meta"`
+		}
+		if json.Unmarshal(msg.Params, &params) == nil && len(params.Meta.ProgressToken) > 0 {
+			info.progressTokens = append(info.progressTokens, string(params.Meta.ProgressToken))
+		}
+	}
+	return info, nil
+}
+
+// progressToken returns the raw token of a progress notification.
+func progressToken(message []byte) (string, bool) {
+	var msg struct {
+		Method string `json:"method"`
+		Params struct {
+			ProgressToken json.RawMessage `json:"progressToken"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(message, &msg); err != nil || msg.Method != "notifications/progress" {
+		return "", false
+	}
+	return string(msg.Params.ProgressToken), len(msg.Params.ProgressToken) > 0
+}
+
+// jsonError builds a JSON-RPC error response.
+func jsonError(id json.RawMessage, code int, message, data string) []byte {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	response, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"error": map[string]interface{}{
+			"code":    code,
+			"message": message,
+			"data":    data,
+		},
+	})
+	return response
+}
+
+func writeJSONError(w http.ResponseWriter, status int, id json.RawMessage, code int, message, data string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(jsonError(id, code, message, data))
+}
+
+// Request POSTs a message to the server and returns the response to it (client
+// mode). Requests sent by the server while it works on the response are
+// passed to the message handler. Notifications and responses return nil.
+func (t *Transport) Request(ctx context.Context, message []byte) ([]byte, error) {
+	if !t.isClient {
+		return nil, errors.New("request is only supported in client mode")
+	}
+	info, err := inspect(message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON-RPC message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.addr, bytes.NewReader(message))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	sessionID := t.prepare(req)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if id := resp.Header.Get(SessionIDHeader); id != "" && info.initialize {
+		t.setSession(id)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound && sessionID != "":
+		t.mu.Lock()
+		if t.sessionID == sessionID {
+			t.sessionID = ""
+		}
+		t.mu.Unlock()
+		return nil, ErrSessionExpired
+	case resp.StatusCode == http.StatusAccepted:
+		return nil, nil
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("unexpected status code: %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/event-stream" {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(body)) == 0 {
+			return nil, nil
+		}
+		return body, nil
+	}
+
+	var response []byte
+	err = readEvents(resp.Body, func(data []byte) bool {
+		if isResponseTo(data, info.ids) {
+			response = data
+			return false
+		}
+		t.deliver(data)
+		return true
+	})
+	if response != nil {
+		return response, nil
+	}
+	if err == nil {
+		err = errors.New("stream closed before the response arrived")
+	}
+	return nil, err
+}
+
+// prepare sets the configured headers and the session ID on a request, and
+// returns the session ID used.
+func (t *Transport) prepare(req *http.Request) string {
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	if sessionID != "" {
+		req.Header.Set(SessionIDHeader, sessionID)
+	}
+	return sessionID
+}
+
+// SessionID returns the session ID assigned by the server (client mode).
+func (t *Transport) SessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+// setSession records a new session and opens its server-message stream.
+func (t *Transport) setSession(id string) {
+	t.mu.Lock()
+	t.sessionID = id
+	listen := t.started && !t.listening
+	t.listening = t.listening || listen
+	t.mu.Unlock()
+	if listen {
+		go t.listen()
+	}
+}
+
+// listen keeps the server-message stream open until the transport stops or
+// the server declines to offer one.
+func (t *Transport) listen() {
+	defer func() {
+		t.mu.Lock()
+		t.listening = false
+		t.mu.Unlock()
+	}()
+
+	for {
+		status, err := t.openStream()
+		if status >= 400 && status < 500 {
+			// 405 means the server has no stream to offer; other client
+			// errors won't go away by retrying
+			t.debugf("Server-message stream unavailable: status %d", status)
+			return
+		}
+		if err != nil {
+			t.debugf("Server-message stream closed: %v", err)
+		}
+
+		select {
+		case <-t.done:
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// openStream GETs the endpoint and delivers the messages it streams.
+func (t *Transport) openStream() (int, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-t.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.addr, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if t.prepare(req) == "" {
+		return http.StatusBadRequest, nil
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+
+	return resp.StatusCode, readEvents(resp.Body, func(data []byte) bool {
+		t.deliver(data)
+		return true
+	})
+}
+
+// endSession asks the server to end the session.
+func (t *Transport) endSession() {
+	t.mu.Lock()
+	id := t.sessionID
+	t.sessionID = ""
+	t.mu.Unlock()
+	if id == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.addr, nil)
+	if err != nil {
+		return
+	}
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set(SessionIDHeader, id)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		t.debugf("Failed to end session: %v", err)
+		return
+	}
+	resp.Body.Close()
+}
+
+// deliver passes a server message to the message handler.
+func (t *Transport) deliver(message []byte) {
+	if _, err := t.HandleMessage(message); err != nil {
+		t.debugf("Error handling message: %v", err)
+	}
+}
+
+// readEvents parses an SSE stream, calling fn with the data of each event
+// until fn returns false or the stream ends.
+func readEvents(r io.Reader, fn func(data []byte) bool) error {
+	reader := bufio.NewReader(r)
+	var data bytes.Buffer
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && len(line) == 0 {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+
+		switch {
+		case len(line) == 0:
+			if data.Len() > 0 {
+				message := append([]byte(nil), data.Bytes()...)
+				data.Reset()
+				if !fn(message) {
+					return nil
+				}
+			}
+		case bytes.HasPrefix(line, []byte("data:")):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.Write(bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" ")))
+		}
+		// Comments, event names, ids and retry hints need no handling
+	}
+}
+
+// isResponseTo reports whether message is the response to one of ids.
+func isResponseTo(message []byte, ids []json.RawMessage) bool {
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.Unmarshal(message, &msg); err != nil || msg.Method != "" {
+		return false
+	}
+	for _, id := range ids {
+		if bytes.Equal(bytes.TrimSpace(id), bytes.TrimSpace(msg.ID)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,305 @@
+package streamablehttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/localrivet/gomcp/transport/eventloop"
+)
+
+// newTestServer serves a transport whose handler answers every request with
+// its method name. Requests carrying a progress token first send a progress
+// notification through the transport.
+func newTestServer(t *testing.T, options ...Option) (*Transport, *httptest.Server) {
+	t.Helper()
+	server := NewTransport(":0", options...)
+	server.SetMessageHandler(func(message []byte) ([]byte, error) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params struct {
+				Meta struct {
+					ProgressToken json.RawMessage `json:"progressToken"`
+				} `json:"_meta"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(message, &req); err != nil {
+			return nil, err
+		}
+		if len(req.ID) == 0 {
+			return nil, nil
+		}
+		if token := req.Params.Meta.ProgressToken; token != nil {
+			progress := `{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":` + string(token) + `,"progress":1}}`
+			if err := server.Send([]byte(progress)); err != nil {
+				return nil, err
+			}
+		}
+		return []byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":{"method":"` + req.Method + `"}}`), nil
+	})
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	return server, ts
+}
+
+// collector records messages passed to a client transport's handler.
+type collector struct {
+	mu       sync.Mutex
+	messages []string
+	received chan struct{}
+}
+
+func newClient(t *testing.T, url string) (*Transport, *collector) {
+	t.Helper()
+	c := &collector{received: make(chan struct{}, 16)}
+	client := NewTransport(url)
+	client.SetMessageHandler(func(message []byte) ([]byte, error) {
+		c.mu.Lock()
+		c.messages = append(c.messages, string(message))
+		c.mu.Unlock()
+		c.received <- struct{}{}
+		return nil, nil
+	})
+	t.Cleanup(func() { client.Stop() })
+	return client, c
+}
+
+func (c *collector) wait(t *testing.T) string {
+	t.Helper()
+	select {
+	case <-c.received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a server message")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.messages[len(c.messages)-1]
+}
+
+const initializeRequest = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`
+
+func post(t *testing.T, url, body string, header http.Header) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestNewTransportMode(t *testing.T) {
+	if NewTransport(":8080").isClient {
+		t.Error("Expected server mode for a listen address")
+	}
+	if !NewTransport("http://localhost:8080/mcp").isClient {
+		t.Error("Expected client mode for a URL")
+	}
+}
+
+func TestSessionHeaders(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	resp := post(t, ts.URL, initializeRequest, nil)
+	sessionID := resp.Header.Get(SessionIDHeader)
+	if resp.StatusCode != http.StatusOK || sessionID == "" {
+		t.Fatalf("Expected initialize to succeed with a session ID, got %d %q", resp.StatusCode, sessionID)
+	}
+
+	ping := `{"jsonrpc":"2.0","id":2,"method":"ping"}`
+	tests := []struct {
+		name   string
+		body   string
+		header http.Header
+		status int
+	}{
+		{"missing session", ping, nil, http.StatusBadRequest},
+		{"unknown session", ping, http.Header{SessionIDHeader: {"nope"}}, http.StatusNotFound},
+		{"request", ping, http.Header{SessionIDHeader: {sessionID}}, http.StatusOK},
+		{"notification", `{"jsonrpc":"2.0","method":"notifications/initialized"}`, http.Header{SessionIDHeader: {sessionID}}, http.StatusAccepted},
+		{"foreign origin", ping, http.Header{SessionIDHeader: {sessionID}, "Origin": {"http://evil.example"}}, http.StatusForbidden},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if resp := post(t, ts.URL, tc.body, tc.header); resp.StatusCode != tc.status {
+				t.Errorf("Expected status %d, got %d", tc.status, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestAllowedOrigins(t *testing.T) {
+	_, ts := newTestServer(t, WithAllowedOrigins("https://app.example.com"))
+	resp := post(t, ts.URL, initializeRequest, http.Header{"Origin": {"https://app.example.com"}})
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected an allowed origin to be accepted, got %d", resp.StatusCode)
+	}
+}
+
+func TestRequestStreamsProgress(t *testing.T) {
+	_, ts := newTestServer(t)
+	client, received := newClient(t, ts.URL)
+
+	if _, err := client.Request(context.Background(), []byte(initializeRequest)); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	if client.SessionID() == "" {
+		t.Fatal("Expected the client to record the session ID")
+	}
+
+	response, err := client.Request(context.Background(),
+		[]byte(`{"jsonrpc":"2.0","id":"call-1","method":"tools/call","params":{"_meta":{"progressToken":"tok"}}}`))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if !strings.Contains(string(response), `"id":"call-1"`) {
+		t.Errorf("Expected the response to call-1, got %s", response)
+	}
+	if message := received.wait(t); !strings.Contains(message, `"progressToken":"tok"`) {
+		t.Errorf("Expected the progress notification to reach the handler, got %s", message)
+	}
+}
+
+func TestServerMessageStream(t *testing.T) {
+	server, ts := newTestServer(t)
+	client, received := newClient(t, ts.URL)
+	if err := client.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Request(context.Background(), []byte(initializeRequest)); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+
+	notification := []byte(`{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`)
+	deadline := time.Now().Add(5 * time.Second)
+	for !server.route(notification) {
+		if time.Now().After(deadline) {
+			t.Fatal("The client never opened its server-message stream")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if message := received.wait(t); message != string(notification) {
+		t.Errorf("Expected %s, got %s", notification, message)
+	}
+
+	if err := server.Send([]byte(`{"jsonrpc":"2.0","id":7,"method":"roots/list"}`)); err != nil {
+		t.Errorf("Expected a server request to be delivered, got %v", err)
+	}
+}
+
+func TestServerMessageStreamEventLoop(t *testing.T) {
+	server, ts := newTestServer(t, WithEventLoop(eventloop.Config{Workers: 1}))
+	t.Cleanup(func() { server.Stop() })
+	client, received := newClient(t, ts.URL)
+	if err := client.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Request(context.Background(), []byte(initializeRequest)); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+
+	notification := []byte(`{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`)
+	deadline := time.Now().Add(5 * time.Second)
+	for !server.route(notification) {
+		if time.Now().After(deadline) {
+			t.Fatal("The client never opened its server-message stream")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if message := received.wait(t); message != string(notification) {
+		t.Errorf("Expected %s, got %s", notification, message)
+	}
+	if n := server.eventLoop().Len(); n != 1 {
+		t.Errorf("Expected the stream to be served by the event loop, got %d looped streams", n)
+	}
+
+	// Ending the session closes its looped stream
+	client.Stop()
+	for server.eventLoop().Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("The looped stream outlived its session")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSendToSession(t *testing.T) {
+	server, ts := newTestServer(t)
+	a, receivedA := newClient(t, ts.URL)
+	b, receivedB := newClient(t, ts.URL)
+	for _, client := range []*Transport{a, b} {
+		if err := client.Start(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Request(context.Background(), []byte(initializeRequest)); err != nil {
+			t.Fatalf("initialize failed: %v", err)
+		}
+	}
+
+	notification := []byte(`{"jsonrpc":"2.0","method":"notifications/resources/updated","params":{"uri":"file:///a.txt"}}`)
+	deadline := time.Now().Add(5 * time.Second)
+	for server.SendTo(a.SessionID(), notification) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("The client never opened its server-message stream")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if message := receivedA.wait(t); message != string(notification) {
+		t.Errorf("Expected %s, got %s", notification, message)
+	}
+	select {
+	case <-receivedB.received:
+		t.Error("Expected the other session to receive nothing")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := server.SendTo("unknown", notification); !errors.Is(err, ErrNoStream) {
+		t.Errorf("Expected ErrNoStream for an unknown session, got %v", err)
+	}
+}
+
+func TestSendWithoutStream(t *testing.T) {
+	server := NewTransport(":0")
+	if err := server.Send([]byte(`{"jsonrpc":"2.0","method":"notifications/message"}`)); err != nil {
+		t.Errorf("Expected an undeliverable notification to be dropped, got %v", err)
+	}
+	if err := server.Send([]byte(`{"jsonrpc":"2.0","id":1,"method":"roots/list"}`)); !errors.Is(err, ErrNoStream) {
+		t.Errorf("Expected ErrNoStream for an undeliverable request, got %v", err)
+	}
+}
+
+func TestStopEndsSession(t *testing.T) {
+	_, ts := newTestServer(t)
+	client, _ := newClient(t, ts.URL)
+	if _, err := client.Request(context.Background(), []byte(initializeRequest)); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	sessionID := client.SessionID()
+	client.Stop()
+
+	resp := post(t, ts.URL, `{"jsonrpc":"2.0","id":2,"method":"ping"}`, http.Header{SessionIDHeader: {sessionID}})
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected the ended session to be unknown, got %d", resp.StatusCode)
+	}
+
+	expired, _ := newClient(t, ts.URL)
+	expired.setSession(sessionID)
+	if _, err := expired.Request(context.Background(), []byte(`{"jsonrpc":"2.0","id":3,"method":"ping"}`)); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("Expected ErrSessionExpired, got %v", err)
+	}
+}
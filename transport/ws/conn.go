@@ -0,0 +1,168 @@
+package ws
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/localrivet/gomcp/transport"
+)
+
+const (
+	// DefaultPingInterval is how often the server pings an idle client.
+	DefaultPingInterval = 30 * time.Second
+
+	// DefaultPongTimeout is how long the server waits for a client to answer
+	// a ping before closing its connection.
+	DefaultPongTimeout = 10 * time.Second
+
+	// writeTimeout bounds each write, so a client that stops reading can't
+	// block the server.
+	writeTimeout = 10 * time.Second
+)
+
+// serverConn is a client connection in server mode. Writes from the
+// responder, the pinger and broadcasts are serialized, since frames written
+// concurrently would interleave.
+type serverConn struct {
+	id     string
+	conn   net.Conn
+	header http.Header
+
+	writeMu sync.Mutex
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newServerConn(conn net.Conn, header http.Header) *serverConn {
+	return &serverConn{
+		id:     newConnectionID(),
+		conn:   conn,
+		header: header,
+		done:   make(chan struct{}),
+	}
+}
+
+// newConnectionID returns a random connection ID.
+func newConnectionID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// write sends a text message.
+func (c *serverConn) write(message []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return wsutil.WriteServerMessage(c.conn, ws.OpText, message)
+}
+
+// ping sends a ping frame.
+func (c *serverConn) ping() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return ws.WriteFrame(c.conn, ws.NewPingFrame(nil))
+}
+
+// close closes the connection once.
+func (c *serverConn) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// read returns the next data message. With keepalive on, the read fails if
+// nothing, not even a pong, arrives within idle.
+func (c *serverConn) read(idle time.Duration) ([]byte, error) {
+	return readData(c.conn, ws.StateServerSide, &c.writeMu, idle)
+}
+
+// readData returns the next data message from conn, answering pings and
+// close frames along the way while holding writeMu, so the answers don't
+// interleave with other writes. A positive idle is the read deadline of
+// each frame.
+func readData(conn net.Conn, state ws.State, writeMu *sync.Mutex, idle time.Duration) ([]byte, error) {
+	controlHandler := wsutil.ControlFrameHandler(conn, state)
+	handleControl := func(hdr ws.Header, r io.Reader) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return controlHandler(hdr, r)
+	}
+	rd := wsutil.Reader{
+		Source:         conn,
+		State:          state,
+		CheckUTF8:      true,
+		OnIntermediate: handleControl,
+	}
+
+	for {
+		if idle > 0 {
+			conn.SetReadDeadline(time.Now().Add(idle))
+		}
+		hdr, err := rd.NextFrame()
+		if err != nil {
+			return nil, err
+		}
+		if hdr.OpCode.IsControl() {
+			if err := handleControl(hdr, &rd); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if hdr.OpCode&(ws.OpText|ws.OpBinary) == 0 {
+			if err := rd.Discard(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return io.ReadAll(&rd)
+	}
+}
+
+// keepAlive pings the client every interval until the connection closes.
+// Missing pongs are caught by the read deadline.
+func (c *serverConn) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.ping(); err != nil {
+				c.close()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// prepare names the connection in a request's _meta and adds the hints the
+// client sent as headers when it connected.
+func (c *serverConn) prepare(message []byte) []byte {
+	message = transport.InjectHeaderMeta(message, c.header)
+	return transport.SetConnectionID(message, c.id)
+}
+
+// isResponse reports whether a message is a JSON-RPC response, which answers
+// a request the server sent rather than asking for work.
+func isResponse(message []byte) bool {
+	var msg struct {
+		Method string          `json:"method"`
+		ID     json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(message, &msg); err != nil {
+		return false
+	}
+	return msg.Method == "" && len(msg.ID) > 0
+}
@@ -7,6 +7,7 @@ package ws
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"strings"
@@ -24,16 +25,57 @@ const DefaultShutdownTimeout = 10 * time.Second
 // DefaultWSPath is the default endpoint path for WebSocket connections
 const DefaultWSPath = "/ws"
 
+// Option is a function that configures a Transport
+type Option func(*Transport)
+
+// Options provides a fluent API for configuring WebSocket transport options
+type Options struct{}
+
+// WS provides access to WebSocket transport configuration options
+var WS = Options{}
+
+// WithPathPrefix returns an option that sets a prefix for the endpoint path
+func (Options) WithPathPrefix(prefix string) Option {
+	return func(t *Transport) {
+		t.SetPathPrefix(prefix)
+	}
+}
+
+// WithPath returns an option that sets the path of the WebSocket endpoint
+func (Options) WithPath(path string) Option {
+	return func(t *Transport) {
+		t.SetWSPath(path)
+	}
+}
+
+// WithKeepAlive returns an option that sets how often idle clients are
+// pinged and how long they have to answer before their connection is
+// closed. A zero interval disables pings.
+func (Options) WithKeepAlive(interval, timeout time.Duration) Option {
+	return func(t *Transport) {
+		t.pingInterval = interval
+		t.pongTimeout = timeout
+	}
+}
+
 // Transport implements the transport.Transport interface for WebSocket
 type Transport struct {
 	transport.BaseTransport
 	addr       string
 	server     *http.Server
-	conns      map[net.Conn]bool
+	conns      map[string]*serverConn
 	connsMu    sync.Mutex
 	isClient   bool
 	pathPrefix string // Optional prefix for endpoint path (e.g., "/mcp")
 	wsPath     string // Endpoint path for WebSocket connections
+	handlers   map[string]http.Handler
+
+	// Keepalive for server connections
+	pingInterval time.Duration
+	pongTimeout  time.Duration
+
+	// Called with the ID of each server connection that closes
+	closedHandler func(connectionID string)
 
 	// For client mode
 	clientConn net.Conn
@@ -49,11 +91,13 @@ func NewTransport(addr string) *Transport {
 	isClient := strings.HasPrefix(addr, "ws://") || strings.HasPrefix(addr, "wss://")
 
 	t := &Transport{
-		addr:       addr,
-		conns:      make(map[net.Conn]bool),
-		isClient:   isClient,
-		pathPrefix: "", // Empty by default
-		wsPath:     DefaultWSPath,
+		addr:         addr,
+		conns:        make(map[string]*serverConn),
+		isClient:     isClient,
+		pathPrefix:   "", // Empty by default
+		wsPath:       DefaultWSPath,
+		pingInterval: DefaultPingInterval,
+		pongTimeout:  DefaultPongTimeout,
 	}
 
 	if isClient {
@@ -98,6 +142,22 @@ func (t *Transport) GetFullWSPath() string {
 	return t.pathPrefix + t.wsPath
 }
 
+// GetAddr returns the transport's address
+func (t *Transport) GetAddr() string {
+	return t.addr
+}
+
+// RegisterHandler registers an additional HTTP handler served alongside the
+// transport's own endpoints. It must be called before Start.
+func (t *Transport) RegisterHandler(pattern string, handler http.Handler) {
+	t.connsMu.Lock()
+	defer t.connsMu.Unlock()
+	if t.handlers == nil {
+		t.handlers = make(map[string]http.Handler)
+	}
+	t.handlers[pattern] = handler
+}
+
 // Initialize initializes the transport
 func (t *Transport) Initialize() error {
 	if t.isClient {
@@ -143,6 +203,11 @@ func (t *Transport) Start() error {
 	// Register WebSocket handler at the configured path
 	mux.HandleFunc(t.GetFullWSPath(), t.handleWebSocketRequest)
 
+	// Register any additional handlers
+	for pattern, handler := range t.handlers {
+		mux.Handle(pattern, handler)
+	}
+
 	t.server = &http.Server{
 		Addr:    t.addr,
 		Handler: mux,
@@ -177,10 +242,10 @@ func (t *Transport) Stop() error {
 
 	// Close all connections
 	t.connsMu.Lock()
-	for conn := range t.conns {
-		conn.Close()
+	for _, conn := range t.conns {
+		conn.close()
 	}
-	t.conns = make(map[net.Conn]bool)
+	t.conns = make(map[string]*serverConn)
 	t.connsMu.Unlock()
 
 	// Shutdown the server
@@ -202,23 +267,57 @@ func (t *Transport) Send(message []byte) error {
 	}
 
 	// Server mode - send to all clients
-	t.connsMu.Lock()
-	defer t.connsMu.Unlock()
-
 	var lastErr error
-	for conn := range t.conns {
-		if err := wsutil.WriteServerMessage(conn, ws.OpText, message); err != nil {
+	for _, conn := range t.connections() {
+		if err := conn.write(message); err != nil {
 			// Note the error but continue trying to send to other clients
 			lastErr = err
-			// Remove failed connection
-			conn.Close()
-			delete(t.conns, conn)
+			conn.close()
 		}
 	}
 
 	return lastErr
 }
 
+// SendTo sends a message to a single connected client (server mode). The
+// client is the one named by transport.MetaConnectionID on the messages it
+// sends.
+func (t *Transport) SendTo(connectionID string, message []byte) error {
+	if t.isClient {
+		return errors.New("SendTo is only supported in server mode")
+	}
+
+	t.connsMu.Lock()
+	conn, ok := t.conns[connectionID]
+	t.connsMu.Unlock()
+	if !ok {
+		return fmt.Errorf("no client connected as %s", connectionID)
+	}
+	if err := conn.write(message); err != nil {
+		conn.close()
+		return err
+	}
+	return nil
+}
+
+// SetConnectionClosedHandler implements transport.ConnectionNotifier.
+func (t *Transport) SetConnectionClosedHandler(handler func(connectionID string)) {
+	t.connsMu.Lock()
+	defer t.connsMu.Unlock()
+	t.closedHandler = handler
+}
+
+// connections returns the connected clients.
+func (t *Transport) connections() []*serverConn {
+	t.connsMu.Lock()
+	defer t.connsMu.Unlock()
+	conns := make([]*serverConn, 0, len(t.conns))
+	for _, conn := range t.conns {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
 // Receive receives a message (client mode only)
 func (t *Transport) Receive() ([]byte, error) {
 	if !t.isClient {
@@ -238,56 +337,81 @@ func (t *Transport) Receive() ([]byte, error) {
 // handleWebSocketRequest handles incoming WebSocket connection requests
 func (t *Transport) handleWebSocketRequest(w http.ResponseWriter, r *http.Request) {
 	// Upgrade the HTTP connection to WebSocket
-	conn, _, _, err := ws.UpgradeHTTP(r, w)
+	netConn, _, _, err := ws.UpgradeHTTP(r, w)
 	if err != nil {
 		return
 	}
 
 	// Register the connection
+	conn := newServerConn(netConn, r.Header.Clone())
 	t.connsMu.Lock()
-	t.conns[conn] = true
+	t.conns[conn.id] = conn
 	t.connsMu.Unlock()
 
+	if t.pingInterval > 0 {
+		go conn.keepAlive(t.pingInterval)
+	}
+
 	// Handle incoming messages in a goroutine
 	go t.handleServerConnection(conn)
 }
 
-// handleServerConnection processes messages from a client connection
-func (t *Transport) handleServerConnection(conn net.Conn) {
+// handleServerConnection processes messages from a client connection.
+// Requests and notifications are handled one at a time, in order, while
+// responses to requests the server sent are handed over as they arrive, so
+// a handler waiting for the client's answer doesn't block reading it.
+func (t *Transport) handleServerConnection(conn *serverConn) {
+	requests := make(chan []byte, 16)
 	defer func() {
-		conn.Close()
+		close(requests)
+		conn.close()
 		t.connsMu.Lock()
-		delete(t.conns, conn)
+		registered := t.conns[conn.id] == conn
+		if registered {
+			delete(t.conns, conn.id)
+		}
+		closed := t.closedHandler
 		t.connsMu.Unlock()
+		if registered && closed != nil {
+			closed(conn.id)
+		}
 	}()
 
+	go func() {
+		for msg := range requests {
+			t.respond(conn, msg)
+		}
+	}()
+
+	var idle time.Duration
+	if t.pingInterval > 0 {
+		idle = t.pingInterval + t.pongTimeout
+	}
 	for {
-		msg, op, err := wsutil.ReadClientData(conn)
+		msg, err := conn.read(idle)
 		if err != nil {
-			// Connection closed or error
+			// Connection closed, timed out or failed
 			return
 		}
 
-		if op == ws.OpClose {
-			return
+		msg = conn.prepare(msg)
+		if isResponse(msg) {
+			go t.respond(conn, msg)
+			continue
 		}
+		requests <- msg
+	}
+}
 
-		if op == ws.OpText || op == ws.OpBinary {
-			// Process the message
-			response, err := t.HandleMessage(msg)
-			if err != nil {
-				// Log error
-				continue
-			}
-
-			if response != nil {
-				// Send response back to this specific client
-				if err := wsutil.WriteServerMessage(conn, ws.OpText, response); err != nil {
-					// Log error
-					return
-				}
-			}
-		}
+// respond handles a message and writes the response, if any, back to the
+// client it came from.
+func (t *Transport) respond(conn *serverConn, msg []byte) {
+	response, err := t.HandleMessage(msg)
+	if err != nil || response == nil {
+		return
+	}
+	if err := conn.write(response); err != nil {
+		conn.close()
 	}
 }
 
@@ -316,26 +440,20 @@ func (t *Transport) readClientMessages() {
 				return
 			}
 
-			msg, op, err := wsutil.ReadServerData(conn)
+			// Pongs are written under clientMu, like messages sent with Send
+			msg, err := readData(conn, ws.StateClientSide, &t.clientMu, 0)
 			if err != nil {
 				t.errCh <- err
 				return
 			}
 
-			if op == ws.OpClose {
-				t.errCh <- errors.New("connection closed by server")
-				return
-			}
-
-			if op == ws.OpText || op == ws.OpBinary {
-				select {
-				case t.readCh <- msg:
-					// Message sent to channel
-				default:
-					// Channel full, discard oldest message
-					<-t.readCh
-					t.readCh <- msg
-				}
+			select {
+			case t.readCh <- msg:
+				// Message sent to channel
+			default:
+				// Channel full, discard oldest message
+				<-t.readCh
+				t.readCh <- msg
 			}
 		}
 	}
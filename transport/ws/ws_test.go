@@ -2,6 +2,7 @@ package ws
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -134,3 +135,174 @@ func TestEchoHandler(t *testing.T) {
 		t.Fatalf("Failed to stop transport: %v", err)
 	}
 }
+
+// startTestServer serves a server-mode transport from an httptest server
+// and returns the transport and a URL to dial.
+func startTestServer(t *testing.T, options ...Option) (*Transport, string) {
+	t.Helper()
+	transport := NewTransport(":0")
+	for _, option := range options {
+		option(transport)
+	}
+	server := httptest.NewServer(http.HandlerFunc(transport.handleWebSocketRequest))
+	t.Cleanup(func() {
+		for _, conn := range transport.connections() {
+			conn.close()
+		}
+		server.Close()
+	})
+	return transport, "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// waitForConnections waits until the transport has n connected clients.
+func waitForConnections(t *testing.T, transport *Transport, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(transport.connections()) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d connections, got %d", n, len(transport.connections()))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServerWritesDoNotInterleave(t *testing.T) {
+	transport, url := startTestServer(t)
+	transport.SetMessageHandler(func(message []byte) ([]byte, error) {
+		return []byte(`{"jsonrpc":"2.0","id":1,"result":{}}`), nil
+	})
+
+	conn, _, _, err := ws.Dial(context.Background(), url)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	waitForConnections(t, transport, 1)
+
+	// Broadcast while the server answers requests on the same connection
+	const count = 50
+	notification := []byte(`{"jsonrpc":"2.0","method":"notifications/message","params":{"data":"` + strings.Repeat("x", 4096) + `"}}`)
+	go func() {
+		for i := 0; i < count; i++ {
+			transport.Send(notification)
+		}
+	}()
+	for i := 0; i < count; i++ {
+		if err := wsutil.WriteClientText(conn, []byte(`{"jsonrpc":"2.0","id":1,"method":"ping"}`)); err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for i := 0; i < 2*count; i++ {
+		msg, err := wsutil.ReadServerText(conn)
+		if err != nil {
+			t.Fatalf("Failed to read message %d: %v", i, err)
+		}
+		if !json.Valid(msg) {
+			t.Fatalf("Message %d is not valid JSON: %.80q", i, msg)
+		}
+	}
+}
+
+func TestKeepAliveClosesUnresponsiveClient(t *testing.T) {
+	transport, url := startTestServer(t, WS.WithKeepAlive(20*time.Millisecond, 20*time.Millisecond))
+
+	// The client never reads, so it never answers pings
+	conn, _, _, err := ws.Dial(context.Background(), url)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+	waitForConnections(t, transport, 1)
+	waitForConnections(t, transport, 0)
+}
+
+func TestKeepAliveKeepsResponsiveClient(t *testing.T) {
+	transport, url := startTestServer(t, WS.WithKeepAlive(20*time.Millisecond, 20*time.Millisecond))
+
+	client := NewTransport(url)
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize client: %v", err)
+	}
+	defer client.Stop()
+	waitForConnections(t, transport, 1)
+
+	// The client answers pings as it reads
+	time.Sleep(200 * time.Millisecond)
+	if n := len(transport.connections()); n != 1 {
+		t.Fatalf("Expected the client to stay connected, got %d connections", n)
+	}
+}
+
+func TestSendTo(t *testing.T) {
+	transport, url := startTestServer(t)
+	ids := make(chan string, 2)
+	transport.SetMessageHandler(func(message []byte) ([]byte, error) {
+		var msg struct {
+			Params struct {
+				Meta map[string]string `json:"_meta"`
+			} `json:"params"`
+		}
+		json.Unmarshal(message, &msg)
+		ids <- msg.Params.Meta["connectionId"]
+		return nil, nil
+	})
+
+	first, _, _, err := ws.Dial(context.Background(), url)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer first.Close()
+	second, _, _, err := ws.Dial(context.Background(), url)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer second.Close()
+
+	wsutil.WriteClientText(first, []byte(`{"jsonrpc":"2.0","method":"notifications/initialized","params":{}}`))
+	id := <-ids
+	if id == "" {
+		t.Fatal("Expected the request to name its connection")
+	}
+
+	if err := transport.SendTo(id, []byte(`{"jsonrpc":"2.0","method":"hello"}`)); err != nil {
+		t.Fatalf("SendTo failed: %v", err)
+	}
+	first.SetReadDeadline(time.Now().Add(2 * time.Second))
+	msg, err := wsutil.ReadServerText(first)
+	if err != nil || !strings.Contains(string(msg), "hello") {
+		t.Fatalf("Expected the message on the first connection, got %q, %v", msg, err)
+	}
+	second.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if msg, err := wsutil.ReadServerText(second); err == nil {
+		t.Fatalf("Expected nothing on the second connection, got %q", msg)
+	}
+
+	if err := transport.SendTo("unknown", []byte(`{}`)); err == nil {
+		t.Fatal("Expected an error for an unknown connection")
+	}
+}
+
+func TestConnectionClosedHandler(t *testing.T) {
+	transport, url := startTestServer(t)
+	closed := make(chan string, 1)
+	transport.SetConnectionClosedHandler(func(connectionID string) { closed <- connectionID })
+
+	conn, _, _, err := ws.Dial(context.Background(), url)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	waitForConnections(t, transport, 1)
+	id := transport.connections()[0].id
+	conn.Close()
+
+	select {
+	case got := <-closed:
+		if got != id {
+			t.Errorf("Expected connection %s to be reported closed, got %s", id, got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the closed connection to be reported")
+	}
+}
@@ -0,0 +1,316 @@
+package typegen
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PythonStyle selects the kind of Python class WritePython emits.
+type PythonStyle int
+
+const (
+	// TypedDict emits typing.TypedDict classes, which need Python 3.11 or
+	// later for NotRequired.
+	TypedDict PythonStyle = iota
+
+	// Pydantic emits pydantic v2 BaseModel classes.
+	Pydantic
+)
+
+// pyIdentifier matches names usable as Python attributes.
+var pyIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// pyKeywords are reserved words that cannot be attribute names.
+var pyKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true,
+	"async": true, "await": true, "break": true, "class": true, "continue": true,
+	"def": true, "del": true, "elif": true, "else": true, "except": true, "finally": true,
+	"for": true, "from": true, "global": true, "if": true, "import": true, "in": true,
+	"is": true, "lambda": true, "nonlocal": true, "not": true, "or": true, "pass": true,
+	"raise": true, "return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+// WritePython writes a class for every tool input and output, and for the
+// nested objects they contain, in the given style.
+func WritePython(w io.Writer, tools []Tool, style PythonStyle) error {
+	decls, err := build(tools)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", generatedHeader)
+	if style == Pydantic {
+		b.WriteString("from typing import Any, Dict, List, Literal, Optional, Union\n\n")
+		b.WriteString("from pydantic import BaseModel, Field\n")
+	} else {
+		b.WriteString("from typing import Any, Dict, List, Literal, NotRequired, Optional, TypedDict, Union\n")
+	}
+
+	// Declarations come dependencies first, so only recursive references
+	// need to be quoted as forward references
+	r := &pyRenderer{declared: make(map[string]bool)}
+	var rebuild []string
+	for _, d := range decls {
+		r.forward = false
+		b.WriteString("\n\n")
+		if style == Pydantic {
+			r.writePydanticModel(&b, d)
+			if r.forward {
+				rebuild = append(rebuild, d.name)
+			}
+		} else {
+			r.writeTypedDict(&b, d)
+		}
+		r.declared[d.name] = true
+	}
+
+	if len(rebuild) > 0 {
+		b.WriteString("\n\n")
+		for _, name := range rebuild {
+			fmt.Fprintf(&b, "%s.model_rebuild()\n", name)
+		}
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// pyRenderer renders declarations in order, tracking which names are
+// already defined.
+type pyRenderer struct {
+	declared map[string]bool
+
+	// forward records whether the current declaration used a forward reference.
+	forward bool
+}
+
+// writeTypedDict writes a TypedDict class. Keys that are not valid Python
+// identifiers force the functional syntax.
+func (r *pyRenderer) writeTypedDict(b *strings.Builder, d decl) {
+	for _, f := range d.fields {
+		if !validPyName(f.name) {
+			r.writeFunctionalTypedDict(b, d)
+			return
+		}
+	}
+
+	fmt.Fprintf(b, "class %s(TypedDict):\n", d.name)
+	writePyDocstring(b, d.description)
+	if len(d.fields) == 0 {
+		if d.description == "" {
+			b.WriteString("    pass\n")
+		}
+		return
+	}
+	if d.description != "" {
+		b.WriteString("\n")
+	}
+	for _, f := range d.fields {
+		if f.description != "" {
+			fmt.Fprintf(b, "    # %s\n", strings.ReplaceAll(strings.TrimSpace(f.description), "\n", "\n    # "))
+		}
+		fmt.Fprintf(b, "    %s: %s\n", f.name, r.typedDictFieldType(f))
+	}
+}
+
+// writeFunctionalTypedDict writes a TypedDict using the call syntax.
+func (r *pyRenderer) writeFunctionalTypedDict(b *strings.Builder, d decl) {
+	if d.description != "" {
+		fmt.Fprintf(b, "# %s\n", strings.ReplaceAll(strings.TrimSpace(d.description), "\n", "\n# "))
+	}
+	fmt.Fprintf(b, "%s = TypedDict(%s, {\n", d.name, pyString(d.name))
+	for _, f := range d.fields {
+		fmt.Fprintf(b, "    %s: %s,\n", pyString(f.name), r.typedDictFieldType(f))
+	}
+	b.WriteString("})\n")
+}
+
+func (r *pyRenderer) typedDictFieldType(f field) string {
+	if f.required {
+		return r.pyType(f.typ)
+	}
+	return "NotRequired[" + r.pyType(f.typ) + "]"
+}
+
+// writePydanticModel writes a BaseModel class. Names that are not valid
+// Python identifiers become aliased fields.
+func (r *pyRenderer) writePydanticModel(b *strings.Builder, d decl) {
+	fmt.Fprintf(b, "class %s(BaseModel):\n", d.name)
+	writePyDocstring(b, d.description)
+	if len(d.fields) == 0 {
+		if d.description == "" {
+			b.WriteString("    pass\n")
+		}
+		return
+	}
+	if d.description != "" {
+		b.WriteString("\n")
+	}
+
+	for _, f := range d.fields {
+		attr := f.name
+		var args []string
+		if !validPyName(attr) {
+			attr = pyAttributeName(attr)
+			args = append(args, "alias="+pyString(f.name))
+		}
+		if f.description != "" {
+			args = append(args, "description="+pyString(strings.TrimSpace(f.description)))
+		}
+
+		typ := r.pyType(f.typ)
+		if !f.required {
+			if f.typ.kind != kindNull && !strings.HasPrefix(typ, "Optional[") {
+				typ = "Optional[" + typ + "]"
+			}
+			args = append([]string{"default=None"}, args...)
+		}
+
+		switch {
+		case len(args) == 0:
+			fmt.Fprintf(b, "    %s: %s\n", attr, typ)
+		case len(args) == 1 && args[0] == "default=None":
+			fmt.Fprintf(b, "    %s: %s = None\n", attr, typ)
+		default:
+			fmt.Fprintf(b, "    %s: %s = Field(%s)\n", attr, typ, strings.Join(args, ", "))
+		}
+	}
+}
+
+// pyType renders a type expression.
+func (r *pyRenderer) pyType(t typeExpr) string {
+	switch t.kind {
+	case kindString:
+		return "str"
+	case kindInteger:
+		return "int"
+	case kindNumber:
+		return "float"
+	case kindBoolean:
+		return "bool"
+	case kindNull:
+		return "None"
+	case kindArray:
+		return "List[" + r.pyType(*t.elem) + "]"
+	case kindMap:
+		return "Dict[str, " + r.pyType(*t.elem) + "]"
+	case kindNamed:
+		if r.declared[t.name] {
+			return t.name
+		}
+		r.forward = true
+		return pyString(t.name)
+	case kindUnion:
+		return r.pyUnion(t.members)
+	case kindLiteral:
+		return "Literal[" + pyLiteral(t.literal) + "]"
+	default:
+		return "Any"
+	}
+}
+
+// pyUnion renders a union, folding literals into one Literal and null into
+// Optional.
+func (r *pyRenderer) pyUnion(members []typeExpr) string {
+	var literals, parts []string
+	nullable := false
+	for _, member := range members {
+		switch member.kind {
+		case kindLiteral:
+			if member.literal == nil {
+				nullable = true
+				continue
+			}
+			literals = append(literals, pyLiteral(member.literal))
+		case kindNull:
+			nullable = true
+		default:
+			parts = append(parts, r.pyType(member))
+		}
+	}
+	if len(literals) > 0 {
+		parts = append([]string{"Literal[" + strings.Join(literals, ", ") + "]"}, parts...)
+	}
+
+	var result string
+	switch len(parts) {
+	case 0:
+		return "None"
+	case 1:
+		result = parts[0]
+	default:
+		result = "Union[" + strings.Join(parts, ", ") + "]"
+	}
+	if nullable {
+		result = "Optional[" + result + "]"
+	}
+	return result
+}
+
+// pyLiteral renders a literal value.
+func pyLiteral(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "None"
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	case string:
+		return pyString(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return pyString(fmt.Sprint(v))
+	}
+}
+
+// pyString renders a Python string literal. Go's escapes are a subset of
+// Python's, so strconv.Quote output is valid Python.
+func pyString(s string) string {
+	return strconv.Quote(s)
+}
+
+func validPyName(name string) bool {
+	return pyIdentifier.MatchString(name) && !pyKeywords[name]
+}
+
+// pyAttributeName turns a property name into a usable attribute name.
+func pyAttributeName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	attr := b.String()
+	if attr == "" || attr[0] >= '0' && attr[0] <= '9' {
+		attr = "f_" + attr
+	}
+	if pyKeywords[attr] {
+		attr += "_"
+	}
+	return attr
+}
+
+// writePyDocstring writes a class docstring, if text is not empty.
+func writePyDocstring(b *strings.Builder, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	text = strings.ReplaceAll(text, `"""`, `\"\"\"`)
+	if !strings.Contains(text, "\n") {
+		fmt.Fprintf(b, "    \"\"\"%s\"\"\"\n", text)
+		return
+	}
+	fmt.Fprintf(b, "    \"\"\"%s\n    \"\"\"\n", strings.ReplaceAll(text, "\n", "\n    "))
+}
@@ -0,0 +1,309 @@
+// Package typegen emits TypeScript and Python types for the inputs and
+// outputs of a server's tools, so clients written in other languages stay in
+// sync with the Go types behind them.
+//
+// Types are generated from the tools' JSON Schemas. Each tool produces an
+// <Name>Input type and, when its handler returns a struct, an <Name>Output
+// type; nested objects become their own named types.
+//
+// # Basic Usage
+//
+//	tools := typegen.FromServer(srv)
+//
+//	ts, _ := os.Create("web/src/tools.ts")
+//	typegen.WriteTypeScript(ts, tools)
+//
+//	py, _ := os.Create("agent/tools.py")
+//	typegen.WritePython(py, tools, typegen.Pydantic)
+package typegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+// generatedHeader opens every generated file.
+const generatedHeader = "Code generated by gomcp typegen. DO NOT EDIT."
+
+// Tool is a tool whose types should be emitted.
+type Tool struct {
+	Name        string
+	Description string
+
+	// Input is the tool's input schema.
+	Input interface{}
+
+	// Output is the schema of the tool's result, or nil if it is not known.
+	Output interface{}
+}
+
+// FromServer returns the registered tools of srv in name order.
+func FromServer(srv server.Server) []Tool {
+	var tools []Tool
+	for tool := range srv.EachTool {
+		t := Tool{Name: tool.Name, Description: tool.Description, Input: tool.Schema}
+		if tool.OutputSchema != nil {
+			// Leave Output a nil interface rather than a typed nil map
+			t.Output = tool.OutputSchema
+		}
+		tools = append(tools, t)
+	}
+	return tools
+}
+
+// kind classifies a type expression.
+type kind int
+
+const (
+	kindUnknown kind = iota
+	kindString
+	kindInteger
+	kindNumber
+	kindBoolean
+	kindNull
+	kindArray
+	kindMap
+	kindNamed
+	kindUnion
+	kindLiteral
+)
+
+// typeExpr is a language-neutral type.
+type typeExpr struct {
+	kind    kind
+	elem    *typeExpr   // kindArray and kindMap
+	name    string      // kindNamed
+	members []typeExpr  // kindUnion
+	literal interface{} // kindLiteral
+}
+
+// field is a member of a declared object type.
+type field struct {
+	name        string
+	description string
+	required    bool
+	typ         typeExpr
+}
+
+// decl is a named object type.
+type decl struct {
+	name        string
+	description string
+	fields      []field
+}
+
+// builder turns schemas into declarations, dependencies first.
+type builder struct {
+	decls    []decl
+	declared map[string]bool
+}
+
+// build converts every tool's schemas into declarations.
+func build(tools []Tool) ([]decl, error) {
+	b := &builder{declared: make(map[string]bool)}
+	for _, tool := range tools {
+		base := pascalCase(tool.Name)
+		if err := b.root(tool.Input, base+"Input", tool.Description); err != nil {
+			return nil, fmt.Errorf("tool %s input: %w", tool.Name, err)
+		}
+		if tool.Output != nil {
+			if err := b.root(tool.Output, base+"Output", "Result of "+tool.Name+"."); err != nil {
+				return nil, fmt.Errorf("tool %s output: %w", tool.Name, err)
+			}
+		}
+	}
+	return b.decls, nil
+}
+
+// root declares the type for a top-level schema and its definitions.
+func (b *builder) root(raw interface{}, name, description string) error {
+	schema, err := decode(raw)
+	if err != nil {
+		return err
+	}
+	if schema == nil {
+		schema = map[string]interface{}{"type": "object"}
+	}
+
+	for _, key := range []string{"$defs", "definitions"} {
+		defs, _ := schema[key].(map[string]interface{})
+		for _, defName := range slices.Sorted(maps.Keys(defs)) {
+			if def, ok := defs[defName].(map[string]interface{}); ok {
+				b.declare(def, pascalCase(defName), "")
+			}
+		}
+	}
+
+	b.declare(schema, name, description)
+	return nil
+}
+
+// declare adds an object declaration for schema under name. A schema without
+// properties produces a declaration with no fields.
+func (b *builder) declare(schema map[string]interface{}, name, description string) {
+	if b.declared[name] {
+		return
+	}
+	b.declared[name] = true
+
+	if description == "" {
+		description, _ = schema["description"].(string)
+	}
+	d := decl{name: name, description: description}
+
+	required := make(map[string]bool)
+	if names, ok := schema["required"].([]interface{}); ok {
+		for _, n := range names {
+			if s, ok := n.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	for _, propName := range slices.Sorted(maps.Keys(properties)) {
+		prop, _ := properties[propName].(map[string]interface{})
+		propDescription, _ := prop["description"].(string)
+		d.fields = append(d.fields, field{
+			name:        propName,
+			description: propDescription,
+			required:    required[propName],
+			typ:         b.expr(prop, name+pascalCase(propName)),
+		})
+	}
+
+	b.decls = append(b.decls, d)
+}
+
+// expr converts a schema into a type expression. Objects with properties
+// are declared under hint.
+func (b *builder) expr(schema map[string]interface{}, hint string) typeExpr {
+	if schema == nil {
+		return typeExpr{kind: kindUnknown}
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		return typeExpr{kind: kindNamed, name: pascalCase(ref[strings.LastIndex(ref, "/")+1:])}
+	}
+
+	if value, ok := schema["const"]; ok {
+		return typeExpr{kind: kindLiteral, literal: value}
+	}
+
+	if values, ok := schema["enum"].([]interface{}); ok && len(values) > 0 {
+		members := make([]typeExpr, len(values))
+		for i, v := range values {
+			members[i] = typeExpr{kind: kindLiteral, literal: v}
+		}
+		return union(members)
+	}
+
+	for _, key := range []string{"anyOf", "oneOf"} {
+		if options, ok := schema[key].([]interface{}); ok && len(options) > 0 {
+			members := make([]typeExpr, 0, len(options))
+			for i, option := range options {
+				optionSchema, _ := option.(map[string]interface{})
+				members = append(members, b.expr(optionSchema, fmt.Sprintf("%sOption%d", hint, i+1)))
+			}
+			return union(members)
+		}
+	}
+
+	switch t := schema["type"].(type) {
+	case string:
+		return b.typed(t, schema, hint)
+	case []interface{}:
+		members := make([]typeExpr, 0, len(t))
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				members = append(members, b.typed(s, schema, hint))
+			}
+		}
+		return union(members)
+	}
+	return typeExpr{kind: kindUnknown}
+}
+
+// typed converts a schema with a single JSON type.
+func (b *builder) typed(jsonType string, schema map[string]interface{}, hint string) typeExpr {
+	switch jsonType {
+	case "string":
+		return typeExpr{kind: kindString}
+	case "integer":
+		return typeExpr{kind: kindInteger}
+	case "number":
+		return typeExpr{kind: kindNumber}
+	case "boolean":
+		return typeExpr{kind: kindBoolean}
+	case "null":
+		return typeExpr{kind: kindNull}
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		elem := b.expr(items, hint+"Item")
+		return typeExpr{kind: kindArray, elem: &elem}
+	case "object":
+		if properties, ok := schema["properties"].(map[string]interface{}); ok && len(properties) > 0 {
+			b.declare(schema, hint, "")
+			return typeExpr{kind: kindNamed, name: hint}
+		}
+		elem := typeExpr{kind: kindUnknown}
+		if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+			elem = b.expr(additional, hint+"Value")
+		}
+		return typeExpr{kind: kindMap, elem: &elem}
+	}
+	return typeExpr{kind: kindUnknown}
+}
+
+// union collapses single-member unions.
+func union(members []typeExpr) typeExpr {
+	if len(members) == 1 {
+		return members[0]
+	}
+	return typeExpr{kind: kindUnion, members: members}
+}
+
+// decode converts a schema into decoded JSON form.
+func decode(raw interface{}) (map[string]interface{}, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("schema is not a JSON object: %w", err)
+	}
+	return schema, nil
+}
+
+// pascalCase converts a tool or property name such as "get_weather" or
+// "user-id" into an identifier such as "GetWeather" or "UserId".
+func pascalCase(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	result := b.String()
+	if result == "" || unicode.IsDigit(rune(result[0])) {
+		result = "T" + result
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package typegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/localrivet/gomcp/server"
+)
+
+type forecastArgs struct {
+	City  string    `json:"city" required:"true" description:"City name"`
+	Days  *int      `json:"days,omitempty" description:"Number of days"`
+	Units *string   `json:"units,omitempty" enum:"metric,imperial"`
+	Tags  *[]string `json:"tags,omitempty"`
+}
+
+type forecastResult struct {
+	Summary string   `json:"summary" required:"true"`
+	High    *float64 `json:"high"`
+}
+
+func forecastServer() server.Server {
+	return server.NewServer("typegen-test").
+		Tool("get_forecast", "Weather forecast for a city", func(ctx *server.Context, args forecastArgs) (forecastResult, error) {
+			return forecastResult{}, nil
+		}).
+		Tool("ping", "Health check", func(ctx *server.Context, args struct{}) (interface{}, error) {
+			return "pong", nil
+		})
+}
+
+// nestedTool exercises schema features the generator does not produce.
+var nestedTool = Tool{
+	Name:        "search-items",
+	Description: "Search the catalog",
+	Input: map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"query"},
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{"type": "string"},
+			"filters": map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type":     "object",
+					"required": []interface{}{"field"},
+					"properties": map[string]interface{}{
+						"field": map[string]interface{}{"type": "string"},
+						"value": map[string]interface{}{"type": []interface{}{"string", "number", "null"}},
+					},
+				},
+			},
+			"labels":   map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}},
+			"from":     map[string]interface{}{"type": "string", "description": "Start date"},
+			"category": map[string]interface{}{"$ref": "#/$defs/category"},
+		},
+		"$defs": map[string]interface{}{
+			"category": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name":   map[string]interface{}{"type": "string"},
+					"parent": map[string]interface{}{"$ref": "#/$defs/category"},
+				},
+			},
+		},
+	},
+}
+
+func generate(t *testing.T, write func(*strings.Builder) error) string {
+	t.Helper()
+	var b strings.Builder
+	if err := write(&b); err != nil {
+		t.Fatalf("Generation failed: %v", err)
+	}
+	return b.String()
+}
+
+func assertContains(t *testing.T, output string, wants ...string) {
+	t.Helper()
+	for _, want := range wants {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestWriteTypeScript(t *testing.T) {
+	tools := append(FromServer(forecastServer()), nestedTool)
+	output := generate(t, func(b *strings.Builder) error { return WriteTypeScript(b, tools) })
+
+	assertContains(t, output,
+		"// Code generated by gomcp typegen. DO NOT EDIT.",
+		"/** Weather forecast for a city */\nexport interface GetForecastInput {",
+		"  /** City name */\n  city: string;",
+		"  /** Number of days */\n  days?: number;",
+		`  units?: "metric" | "imperial";`,
+		"export interface GetForecastOutput {\n  high?: number;\n  summary: string;\n}",
+		"export interface PingInput {\n}",
+		"export interface SearchItemsInputFiltersItem {\n  field: string;\n  value?: string | number | null;\n}",
+		"  filters?: SearchItemsInputFiltersItem[];",
+		"  labels?: Record<string, string>;",
+		"  category?: Category;",
+		"export interface Category {\n  name?: string;\n  parent?: Category;\n}",
+	)
+	if strings.Contains(output, "PingOutput") {
+		t.Error("Expected no output type for a handler returning interface{}")
+	}
+	if strings.Index(output, "interface SearchItemsInputFiltersItem") > strings.Index(output, "interface SearchItemsInput {") {
+		t.Error("Expected nested types to be declared before the types using them")
+	}
+}
+
+func TestWritePythonTypedDict(t *testing.T) {
+	tools := append(FromServer(forecastServer()), nestedTool)
+	output := generate(t, func(b *strings.Builder) error { return WritePython(b, tools, TypedDict) })
+
+	assertContains(t, output,
+		"class GetForecastInput(TypedDict):\n    \"\"\"Weather forecast for a city\"\"\"\n\n    # City name\n    city: str\n",
+		"    days: NotRequired[int]\n",
+		`    units: NotRequired[Literal["metric", "imperial"]]`,
+		`    parent: NotRequired["Category"]`,
+		"class PingInput(TypedDict):\n    \"\"\"Health check\"\"\"\n",
+		"    value: NotRequired[Optional[Union[str, float]]]\n",
+		`SearchItemsInput = TypedDict("SearchItemsInput", {`,
+		`    "from": NotRequired[str],`,
+		`    "labels": NotRequired[Dict[str, str]],`,
+	)
+}
+
+func TestWritePythonPydantic(t *testing.T) {
+	tools := append(FromServer(forecastServer()), nestedTool)
+	output := generate(t, func(b *strings.Builder) error { return WritePython(b, tools, Pydantic) })
+
+	assertContains(t, output,
+		"from pydantic import BaseModel, Field",
+		"class GetForecastInput(BaseModel):",
+		`    city: str = Field(description="City name")`,
+		`    days: Optional[int] = Field(default=None, description="Number of days")`,
+		`    from_: Optional[str] = Field(default=None, alias="from", description="Start date")`,
+		`    parent: Optional["Category"] = None`,
+		"Category.model_rebuild()\n",
+	)
+}
@@ -0,0 +1,106 @@
+package typegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// tsIdentifier matches property names that need no quoting in TypeScript.
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// WriteTypeScript writes an exported interface for every tool input and
+// output, and for the nested objects they contain.
+func WriteTypeScript(w io.Writer, tools []Tool) error {
+	decls, err := build(tools)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s\n", generatedHeader)
+	for _, d := range decls {
+		b.WriteString("\n")
+		writeTSComment(&b, "", d.description)
+		fmt.Fprintf(&b, "export interface %s {\n", d.name)
+		for _, f := range d.fields {
+			writeTSComment(&b, "  ", f.description)
+			name := f.name
+			if !tsIdentifier.MatchString(name) {
+				name = tsLiteral(name)
+			}
+			optional := "?"
+			if f.required {
+				optional = ""
+			}
+			fmt.Fprintf(&b, "  %s%s: %s;\n", name, optional, tsType(f.typ))
+		}
+		b.WriteString("}\n")
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// tsType renders a type expression.
+func tsType(t typeExpr) string {
+	switch t.kind {
+	case kindString:
+		return "string"
+	case kindInteger, kindNumber:
+		return "number"
+	case kindBoolean:
+		return "boolean"
+	case kindNull:
+		return "null"
+	case kindArray:
+		elem := tsType(*t.elem)
+		if t.elem.kind == kindUnion {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case kindMap:
+		return "Record<string, " + tsType(*t.elem) + ">"
+	case kindNamed:
+		return t.name
+	case kindUnion:
+		parts := make([]string, len(t.members))
+		for i, member := range t.members {
+			parts[i] = tsType(member)
+		}
+		return strings.Join(parts, " | ")
+	case kindLiteral:
+		return tsLiteral(t.literal)
+	default:
+		return "unknown"
+	}
+}
+
+// tsLiteral renders a literal value.
+func tsLiteral(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "unknown"
+	}
+	return string(data)
+}
+
+// writeTSComment writes a JSDoc comment, if text is not empty.
+func writeTSComment(b *strings.Builder, indent, text string) {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "*/", "*\\/"))
+	if text == "" {
+		return
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s * %s\n", indent, strings.TrimRight(line, " "))
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
@@ -0,0 +1,429 @@
+// Package mdns provides minimal multicast DNS (zeroconf) advertisement and
+// browsing for MCP servers.
+//
+// Servers are advertised under the "_mcp._tcp" service type. Each service
+// instance carries SRV, TXT and A records describing where and how to connect,
+// so desktop hosts can list locally running MCP servers without manual
+// configuration.
+package mdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// ServiceType is the DNS-SD service type used for MCP servers.
+const ServiceType = "_mcp._tcp"
+
+// Domain is the multicast DNS domain.
+const Domain = "local."
+
+// maxReadBackoff bounds how long the responder waits after a failed read
+// before reading again.
+const maxReadBackoff = time.Second
+
+// DefaultTTL is the time-to-live advertised for service records.
+const DefaultTTL = 120 * time.Second
+
+// mdnsAddr is the IPv4 multicast group and port used by mDNS.
+var mdnsAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Service describes an MCP server instance to advertise.
+type Service struct {
+	// Instance is the human-readable instance name, e.g. "weather-server".
+	Instance string
+
+	// Host is the host name without the ".local." suffix. Defaults to os.Hostname.
+	Host string
+
+	// Port is the TCP port the server listens on.
+	Port int
+
+	// IPs are the addresses advertised for Host. Defaults to the machine's
+	// non-loopback IPv4 addresses.
+	IPs []net.IP
+
+	// Text holds the TXT record key/value pairs, e.g. transport and path.
+	Text map[string]string
+
+	// Logger receives errors the responder recovers from. Defaults to
+	// slog.Default.
+	Logger *slog.Logger
+}
+
+// Entry is a service instance found while browsing.
+type Entry struct {
+	Instance string
+	Host     string
+	Port     int
+	IPs      []net.IP
+	Text     map[string]string
+}
+
+// Responder answers mDNS queries for an advertised service.
+type Responder struct {
+	conn    *net.UDPConn
+	service Service
+	once    sync.Once
+	done    chan struct{}
+}
+
+// Advertise starts answering mDNS queries for the service until Close is called.
+func Advertise(service Service) (*Responder, error) {
+	if service.Instance == "" {
+		return nil, errors.New("mdns: service instance name is required")
+	}
+	if service.Port <= 0 {
+		return nil, errors.New("mdns: service port is required")
+	}
+	if service.Host == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("mdns: failed to determine host name: %w", err)
+		}
+		service.Host = host
+	}
+	if len(service.IPs) == 0 {
+		service.IPs = localIPv4s()
+	}
+
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsAddr)
+	if err != nil {
+		return nil, fmt.Errorf("mdns: failed to join multicast group: %w", err)
+	}
+
+	r := &Responder{
+		conn:    conn,
+		service: service,
+		done:    make(chan struct{}),
+	}
+
+	go r.serve()
+
+	// Announce the service so that active browsers learn about it immediately
+	if announcement, err := buildResponse(service, 0, DefaultTTL); err == nil {
+		conn.WriteToUDP(announcement, mdnsAddr)
+	}
+
+	return r, nil
+}
+
+// Close stops answering queries and sends a goodbye packet.
+func (r *Responder) Close() error {
+	var err error
+	r.once.Do(func() {
+		close(r.done)
+		if goodbye, buildErr := buildResponse(r.service, 0, 0); buildErr == nil {
+			r.conn.WriteToUDP(goodbye, mdnsAddr)
+		}
+		err = r.conn.Close()
+	})
+	return err
+}
+
+// serve reads queries and answers those that concern the advertised service.
+func (r *Responder) serve() {
+	logger := r.service.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	buf := make([]byte, 9000)
+	var backoff time.Duration
+	for {
+		n, src, err := r.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			// Wait before reading again, so a failing socket doesn't spin
+			backoff = min(max(2*backoff, 10*time.Millisecond), maxReadBackoff)
+			logger.Warn("mdns: failed to read query", "error", err, "retryIn", backoff)
+			select {
+			case <-r.done:
+				return
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		backoff = 0
+
+		id, ok := matchesQuery(buf[:n], r.service.Instance)
+		if !ok {
+			continue
+		}
+
+		// Legacy unicast queries (not from port 5353) get a direct reply
+		// carrying the query ID; everyone else gets a multicast response.
+		dst := mdnsAddr
+		if src.Port != mdnsAddr.Port {
+			dst = src
+		} else {
+			id = 0
+		}
+
+		response, err := buildResponse(r.service, id, DefaultTTL)
+		if err != nil {
+			continue
+		}
+		r.conn.WriteToUDP(response, dst)
+	}
+}
+
+// Browse sends a query for MCP services and collects the answers until ctx is done.
+func Browse(ctx context.Context) ([]Entry, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero, Port: 0})
+	if err != nil {
+		return nil, fmt.Errorf("mdns: failed to open socket: %w", err)
+	}
+	defer conn.Close()
+
+	query, err := buildQuery()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.WriteToUDP(query, mdnsAddr); err != nil {
+		return nil, fmt.Errorf("mdns: failed to send query: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		conn.SetReadDeadline(time.Now())
+	}()
+
+	found := make(map[string]*Entry)
+	var order []string
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			break
+		}
+		for _, entry := range parseResponse(buf[:n]) {
+			if _, seen := found[entry.Instance]; !seen {
+				order = append(order, entry.Instance)
+			}
+			e := entry
+			found[entry.Instance] = &e
+		}
+	}
+
+	entries := make([]Entry, 0, len(order))
+	for _, instance := range order {
+		entries = append(entries, *found[instance])
+	}
+	return entries, nil
+}
+
+// serviceName returns the fully qualified service type name.
+func serviceName() string {
+	return ServiceType + "." + Domain
+}
+
+// instanceName returns the fully qualified name of a service instance.
+func instanceName(instance string) string {
+	return strings.ReplaceAll(instance, ".", "-") + "." + serviceName()
+}
+
+// hostName returns the fully qualified host name for a service.
+func hostName(host string) string {
+	host = strings.TrimSuffix(host, ".")
+	host = strings.TrimSuffix(host, ".local")
+	return host + "." + Domain
+}
+
+// buildQuery creates a PTR query for the MCP service type.
+func buildQuery() ([]byte, error) {
+	name, err := dnsmessage.NewName(serviceName())
+	if err != nil {
+		return nil, err
+	}
+
+	msg := dnsmessage.Message{
+		Questions: []dnsmessage.Question{{
+			Name:  name,
+			Type:  dnsmessage.TypePTR,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	return msg.Pack()
+}
+
+// matchesQuery reports whether a packet is a query for the service type or
+// the given instance, returning the query ID.
+func matchesQuery(packet []byte, instance string) (uint16, bool) {
+	var p dnsmessage.Parser
+	header, err := p.Start(packet)
+	if err != nil || header.Response {
+		return 0, false
+	}
+
+	questions, err := p.AllQuestions()
+	if err != nil {
+		return 0, false
+	}
+
+	service := strings.ToLower(serviceName())
+	own := strings.ToLower(instanceName(instance))
+	for _, q := range questions {
+		name := strings.ToLower(q.Name.String())
+		if name == service || name == own {
+			return header.ID, true
+		}
+	}
+	return 0, false
+}
+
+// buildResponse creates an authoritative response describing the service.
+// A ttl of zero produces a goodbye packet.
+func buildResponse(service Service, id uint16, ttl time.Duration) ([]byte, error) {
+	svcName, err := dnsmessage.NewName(serviceName())
+	if err != nil {
+		return nil, err
+	}
+	instName, err := dnsmessage.NewName(instanceName(service.Instance))
+	if err != nil {
+		return nil, err
+	}
+	host, err := dnsmessage.NewName(hostName(service.Host))
+	if err != nil {
+		return nil, err
+	}
+
+	seconds := uint32(ttl / time.Second)
+	header := func(name dnsmessage.Name, typ dnsmessage.Type) dnsmessage.ResourceHeader {
+		return dnsmessage.ResourceHeader{Name: name, Type: typ, Class: dnsmessage.ClassINET, TTL: seconds}
+	}
+
+	txt := make([]string, 0, len(service.Text))
+	for key, value := range service.Text {
+		txt = append(txt, key+"="+value)
+	}
+	if len(txt) == 0 {
+		// A TXT record must contain at least one string
+		txt = append(txt, "")
+	}
+
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: id, Response: true, Authoritative: true},
+		Answers: []dnsmessage.Resource{{
+			Header: header(svcName, dnsmessage.TypePTR),
+			Body:   &dnsmessage.PTRResource{PTR: instName},
+		}},
+		Additionals: []dnsmessage.Resource{
+			{
+				Header: header(instName, dnsmessage.TypeSRV),
+				Body:   &dnsmessage.SRVResource{Target: host, Port: uint16(service.Port)},
+			},
+			{
+				Header: header(instName, dnsmessage.TypeTXT),
+				Body:   &dnsmessage.TXTResource{TXT: txt},
+			},
+		},
+	}
+
+	for _, ip := range service.IPs {
+		ip4 := ip.To4()
+		if ip4 == nil {
+			continue
+		}
+		var a [4]byte
+		copy(a[:], ip4)
+		msg.Additionals = append(msg.Additionals, dnsmessage.Resource{
+			Header: header(host, dnsmessage.TypeA),
+			Body:   &dnsmessage.AResource{A: a},
+		})
+	}
+
+	return msg.Pack()
+}
+
+// parseResponse extracts MCP service entries from a response packet.
+func parseResponse(packet []byte) []Entry {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(packet); err != nil || !msg.Response {
+		return nil
+	}
+
+	service := strings.ToLower(serviceName())
+	entries := make(map[string]*Entry)
+	var order []string
+	hosts := make(map[string][]net.IP)
+
+	records := append(append([]dnsmessage.Resource{}, msg.Answers...), msg.Additionals...)
+
+	// PTR records with a TTL of zero are goodbye packets and are skipped
+	for _, rr := range records {
+		if ptr, ok := rr.Body.(*dnsmessage.PTRResource); ok && strings.ToLower(rr.Header.Name.String()) == service && rr.Header.TTL > 0 {
+			name := ptr.PTR.String()
+			if _, exists := entries[name]; !exists {
+				entries[name] = &Entry{
+					Instance: strings.TrimSuffix(name, "."+serviceName()),
+					Text:     map[string]string{},
+				}
+				order = append(order, name)
+			}
+		}
+	}
+
+	for _, rr := range records {
+		name := rr.Header.Name.String()
+		switch body := rr.Body.(type) {
+		case *dnsmessage.SRVResource:
+			if entry, ok := entries[name]; ok {
+				entry.Host = strings.TrimSuffix(body.Target.String(), ".")
+				entry.Port = int(body.Port)
+			}
+		case *dnsmessage.TXTResource:
+			if entry, ok := entries[name]; ok {
+				for _, kv := range body.TXT {
+					if key, value, found := strings.Cut(kv, "="); found {
+						entry.Text[key] = value
+					}
+				}
+			}
+		case *dnsmessage.AResource:
+			hosts[strings.TrimSuffix(name, ".")] = append(hosts[strings.TrimSuffix(name, ".")], net.IP(body.A[:]))
+		}
+	}
+
+	result := make([]Entry, 0, len(order))
+	for _, name := range order {
+		entry := entries[name]
+		entry.IPs = hosts[entry.Host]
+		if entry.Port == 0 {
+			continue
+		}
+		result = append(result, *entry)
+	}
+	return result
+}
+
+// localIPv4s returns the machine's non-loopback IPv4 addresses.
+func localIPv4s() []net.IP {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+
+	var ips []net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() {
+			continue
+		}
+		if ip4 := ipNet.IP.To4(); ip4 != nil {
+			ips = append(ips, ip4)
+		}
+	}
+	return ips
+}
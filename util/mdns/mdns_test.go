@@ -0,0 +1,131 @@
+package mdns
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestResponseRoundTrip(t *testing.T) {
+	service := Service{
+		Instance: "weather.server",
+		Host:     "devbox",
+		Port:     8080,
+		IPs:      []net.IP{net.IPv4(192, 168, 1, 20)},
+		Text:     map[string]string{"transport": "sse", "path": "/sse"},
+	}
+
+	packet, err := buildResponse(service, 0, DefaultTTL)
+	if err != nil {
+		t.Fatalf("buildResponse failed: %v", err)
+	}
+
+	entries := parseResponse(packet)
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Instance != "weather-server" {
+		t.Errorf("Expected instance 'weather-server', got %q", entry.Instance)
+	}
+	if entry.Host != "devbox.local" {
+		t.Errorf("Expected host 'devbox.local', got %q", entry.Host)
+	}
+	if entry.Port != 8080 {
+		t.Errorf("Expected port 8080, got %d", entry.Port)
+	}
+	if len(entry.IPs) != 1 || !entry.IPs[0].Equal(net.IPv4(192, 168, 1, 20)) {
+		t.Errorf("Expected IP 192.168.1.20, got %v", entry.IPs)
+	}
+	if entry.Text["transport"] != "sse" || entry.Text["path"] != "/sse" {
+		t.Errorf("Unexpected TXT records: %v", entry.Text)
+	}
+}
+
+func TestGoodbyeIsIgnored(t *testing.T) {
+	packet, err := buildResponse(Service{Instance: "gone", Host: "devbox", Port: 8080}, 0, 0)
+	if err != nil {
+		t.Fatalf("buildResponse failed: %v", err)
+	}
+
+	if entries := parseResponse(packet); len(entries) != 0 {
+		t.Errorf("Expected goodbye packet to yield no entries, got %v", entries)
+	}
+}
+
+func TestMatchesQuery(t *testing.T) {
+	query, err := buildQuery()
+	if err != nil {
+		t.Fatalf("buildQuery failed: %v", err)
+	}
+
+	if _, ok := matchesQuery(query, "weather"); !ok {
+		t.Error("Expected service type query to match")
+	}
+
+	response, _ := buildResponse(Service{Instance: "weather", Host: "devbox", Port: 1}, 0, DefaultTTL)
+	if _, ok := matchesQuery(response, "weather"); ok {
+		t.Error("Expected responses not to be treated as queries")
+	}
+}
+
+func TestAdvertiseValidation(t *testing.T) {
+	if _, err := Advertise(Service{Port: 8080}); err == nil {
+		t.Error("Expected error for missing instance name")
+	}
+	if _, err := Advertise(Service{Instance: "weather"}); err == nil {
+		t.Error("Expected error for missing port")
+	}
+}
+
+func TestAdvertiseAndBrowse(t *testing.T) {
+	responder, err := Advertise(Service{
+		Instance: "browse-test",
+		Host:     "devbox",
+		Port:     9090,
+		IPs:      []net.IP{net.IPv4(127, 0, 0, 1)},
+		Text:     map[string]string{"transport": "http"},
+	})
+	if err != nil {
+		t.Skipf("multicast not available: %v", err)
+	}
+	defer responder.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	entries, err := Browse(ctx)
+	if err != nil {
+		t.Skipf("multicast not available: %v", err)
+	}
+
+	for _, entry := range entries {
+		if entry.Instance == "browse-test" && entry.Port == 9090 {
+			return
+		}
+	}
+	t.Skipf("advertised service not seen, multicast loopback may be disabled: %v", entries)
+}
+
+func TestServeStopsWhenSocketCloses(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	r := &Responder{conn: conn, service: Service{Instance: "test"}, done: make(chan struct{})}
+	stopped := make(chan struct{})
+	go func() {
+		r.serve()
+		close(stopped)
+	}()
+
+	// Closed underneath the responder, without Close
+	conn.Close()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected serve to return once its socket is closed")
+	}
+}
@@ -0,0 +1,225 @@
+// Package scan provides prompt injection scanning for text that is delivered
+// to MCP clients, such as tool results and resource contents.
+//
+// Text returned by tools often comes from untrusted sources (web pages,
+// e-mails, files). A Scanner looks for content that tries to steer the model
+// rather than inform it, so the server can annotate, sanitize or block it.
+package scan
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Finding describes a suspicious span of text.
+type Finding struct {
+	// Rule identifies the heuristic that matched, e.g. "instruction".
+	Rule string `json:"rule"`
+
+	// Description explains why the span is suspicious.
+	Description string `json:"description"`
+
+	// Start and End are byte offsets of the span within the scanned text.
+	Start int `json:"start"`
+	End   int `json:"end"`
+
+	// Replacement is the text substituted for the span when sanitizing.
+	Replacement string `json:"-"`
+}
+
+// Scanner inspects text and reports suspicious spans.
+type Scanner interface {
+	Scan(text string) []Finding
+}
+
+// ScannerFunc adapts an ordinary function to the Scanner interface.
+type ScannerFunc func(text string) []Finding
+
+// Scan implements Scanner.
+func (f ScannerFunc) Scan(text string) []Finding {
+	return f(text)
+}
+
+// Sanitize returns text with every finding replaced by its Replacement.
+// Overlapping findings are merged, keeping the replacement of the earliest one.
+// Findings whose span is not within text are ignored.
+func Sanitize(text string, findings []Finding) string {
+	if len(findings) == 0 {
+		return text
+	}
+
+	sorted := make([]Finding, len(findings))
+	copy(sorted, findings)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var b strings.Builder
+	pos := 0
+	for _, f := range sorted {
+		if f.Start < 0 || f.End < f.Start || f.End > len(text) {
+			continue
+		}
+		if f.Start < pos {
+			// Overlaps the previous finding, which replaced its start
+			pos = max(pos, f.End)
+			continue
+		}
+		b.WriteString(text[pos:f.Start])
+		b.WriteString(f.Replacement)
+		pos = f.End
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
+
+// instructionPatterns match phrases that address the model instead of the user.
+var instructionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\s+(all\s+|any\s+)?(the\s+)?(previous|prior|above|earlier|preceding)\s+(instructions|prompts|messages|rules|context)\b`),
+	regexp.MustCompile(`(?i)\byou\s+are\s+now\s+(a|an|in)\b`),
+	regexp.MustCompile(`(?i)\b(new|updated|real)\s+(system\s+)?instructions\s*:`),
+	regexp.MustCompile(`(?i)\bdo\s+not\s+(tell|inform|mention\s+(this\s+)?to)\s+the\s+user\b`),
+	regexp.MustCompile(`(?i)<\s*/?\s*(system|assistant|instructions?|im_start|im_end)\s*>`),
+	regexp.MustCompile(`(?i)\b(reveal|print|output)\s+(your|the)\s+system\s+prompt\b`),
+}
+
+// markdownImagePattern matches markdown images, which hosts may fetch automatically.
+var markdownImagePattern = regexp.MustCompile(`!\[[^\]]*\]\((https?://[^)\s]+)\)`)
+
+// urlPattern matches http(s) URLs.
+var urlPattern = regexp.MustCompile(`https?://[^\s)"'<>]+`)
+
+// Heuristic is a Scanner bundled with gomcp that looks for instruction-like
+// phrases, invisible Unicode characters and URLs that could smuggle data out
+// of the conversation.
+type Heuristic struct {
+	// MaxQueryValueLength is the length above which a URL query value is
+	// considered an exfiltration attempt. Defaults to 64.
+	MaxQueryValueLength int
+}
+
+// NewHeuristic creates a heuristic scanner with default settings.
+func NewHeuristic() *Heuristic {
+	return &Heuristic{MaxQueryValueLength: 64}
+}
+
+// Scan implements Scanner.
+func (h *Heuristic) Scan(text string) []Finding {
+	var findings []Finding
+
+	for _, pattern := range instructionPatterns {
+		for _, loc := range pattern.FindAllStringIndex(text, -1) {
+			findings = append(findings, Finding{
+				Rule:        "instruction",
+				Description: "text addresses the model with instructions",
+				Start:       loc[0],
+				End:         loc[1],
+				Replacement: "[removed instruction]",
+			})
+		}
+	}
+
+	findings = append(findings, h.invisibleCharacters(text)...)
+	findings = append(findings, h.exfiltrationURLs(text)...)
+
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Start < findings[j].Start })
+	return findings
+}
+
+// invisibleCharacters reports zero-width, bidi control and Unicode tag characters.
+func (h *Heuristic) invisibleCharacters(text string) []Finding {
+	var findings []Finding
+	for i, r := range text {
+		if !isInvisible(r) {
+			continue
+		}
+		findings = append(findings, Finding{
+			Rule:        "invisible-unicode",
+			Description: "invisible Unicode character can hide instructions",
+			Start:       i,
+			End:         i + utf8.RuneLen(r),
+			Replacement: "",
+		})
+	}
+	return findings
+}
+
+// exfiltrationURLs reports markdown images and URLs that carry suspiciously
+// long query values.
+func (h *Heuristic) exfiltrationURLs(text string) []Finding {
+	var findings []Finding
+
+	images := markdownImagePattern.FindAllStringSubmatchIndex(text, -1)
+	for _, loc := range images {
+		if !strings.Contains(text[loc[2]:loc[3]], "?") {
+			continue
+		}
+		findings = append(findings, Finding{
+			Rule:        "exfiltration-url",
+			Description: "markdown image with query parameters may leak data when rendered",
+			Start:       loc[0],
+			End:         loc[1],
+			Replacement: "[removed image]",
+		})
+	}
+
+	maxLen := h.MaxQueryValueLength
+	if maxLen <= 0 {
+		maxLen = 64
+	}
+
+	for _, loc := range urlPattern.FindAllStringIndex(text, -1) {
+		if insideAny(loc, images) {
+			continue
+		}
+		rawURL := text[loc[0]:loc[1]]
+		_, query, found := strings.Cut(rawURL, "?")
+		if !found {
+			continue
+		}
+		for _, pair := range strings.Split(query, "&") {
+			_, value, _ := strings.Cut(pair, "=")
+			if len(value) > maxLen {
+				findings = append(findings, Finding{
+					Rule:        "exfiltration-url",
+					Description: "URL carries an unusually long query value",
+					Start:       loc[0],
+					End:         loc[1],
+					Replacement: "[removed url]",
+				})
+				break
+			}
+		}
+	}
+
+	return findings
+}
+
+// insideAny reports whether loc lies within any of the given match locations.
+func insideAny(loc []int, matches [][]int) bool {
+	for _, m := range matches {
+		if loc[0] >= m[0] && loc[1] <= m[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// isInvisible reports whether r renders as nothing in most hosts.
+func isInvisible(r rune) bool {
+	switch {
+	case r >= 0x200B && r <= 0x200F: // zero-width space/joiners, LRM/RLM
+		return true
+	case r >= 0x202A && r <= 0x202E: // bidi embeddings and overrides
+		return true
+	case r >= 0x2060 && r <= 0x2064: // word joiner, invisible operators
+		return true
+	case r >= 0x2066 && r <= 0x2069: // bidi isolates
+		return true
+	case r == 0xFEFF: // zero-width no-break space
+		return true
+	case r >= 0xE0000 && r <= 0xE007F: // Unicode tag characters
+		return true
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package schema
+
+import "testing"
+
+type benchAddress struct {
+	Street  string `json:"street" required:"true"`
+	City    string `json:"city" required:"true"`
+	Country string `json:"country" enum:"US,CA,MX" default:"US"`
+}
+
+type benchOrder struct {
+	ID       string            `json:"id" required:"true" description:"Order ID" pattern:"^[A-Z0-9]+$"`
+	Quantity int               `json:"quantity" min:"1" max:"1000"`
+	Price    float64           `json:"price" min:"0"`
+	Tags     []string          `json:"tags,omitempty" description:"Labels"`
+	Shipping benchAddress      `json:"shipping" required:"true"`
+	Billing  *benchAddress     `json:"billing,omitempty"`
+	Items    []benchAddress    `json:"items,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+func BenchmarkFromStruct(b *testing.B) {
+	b.Run("Flat", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			FromStruct(TestStruct{})
+		}
+	})
+	b.Run("Nested", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			FromStruct(benchOrder{})
+		}
+	})
+}
+
+func BenchmarkGenerateSchema(b *testing.B) {
+	generator := NewGenerator()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := generator.GenerateSchema(benchOrder{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
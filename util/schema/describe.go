@@ -0,0 +1,92 @@
+package schema
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// DescriptionRules configures LintDescriptions.
+type DescriptionRules struct {
+	// MinLength is the shortest acceptable parameter description, in
+	// characters. Zero accepts any non-empty description.
+	MinLength int
+}
+
+// LintDescriptions reports tool parameters, including nested ones, whose
+// descriptions are missing, shorter than rules.MinLength, or do not mention
+// any of the parameter's enum values. Models rely on these descriptions to
+// choose arguments, so every issue is a warning; callers decide whether to
+// treat them as errors.
+func LintDescriptions(tool Tool, rules DescriptionRules) []Issue {
+	schema, err := normalize(tool.InputSchema)
+	if err != nil || schema == nil {
+		return nil
+	}
+
+	issues := describeProperties(schema, "inputSchema", rules)
+	for i := range issues {
+		issues[i].Tool = tool.Name
+	}
+	return issues
+}
+
+// describeProperties checks the properties of an object schema and recurses
+// into nested objects and array items.
+func describeProperties(schema map[string]interface{}, path string, rules DescriptionRules) []Issue {
+	var issues []Issue
+	properties, _ := schema["properties"].(map[string]interface{})
+	for _, name := range slices.Sorted(maps.Keys(properties)) {
+		prop, ok := properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		propPath := path + ".properties." + name
+		if issue, ok := checkDescription(prop, propPath, rules); !ok {
+			issues = append(issues, issue)
+		}
+		issues = append(issues, describeNested(prop, propPath, rules)...)
+	}
+	return issues
+}
+
+// describeNested descends into a property's own properties and its items.
+func describeNested(prop map[string]interface{}, path string, rules DescriptionRules) []Issue {
+	issues := describeProperties(prop, path, rules)
+	if items, ok := prop["items"].(map[string]interface{}); ok {
+		issues = append(issues, describeProperties(items, path+".items", rules)...)
+	}
+	return issues
+}
+
+// checkDescription checks a single parameter's description.
+func checkDescription(prop map[string]interface{}, path string, rules DescriptionRules) (Issue, bool) {
+	issue := Issue{Path: path, Severity: SeverityWarning}
+
+	description, _ := prop["description"].(string)
+	description = strings.TrimSpace(description)
+	switch {
+	case description == "":
+		issue.Message = "has no description"
+		issue.Fix = "add a description tag explaining what the parameter is for"
+		return issue, false
+	case len(description) < rules.MinLength:
+		issue.Message = fmt.Sprintf("description is %d characters, shorter than the minimum of %d", len(description), rules.MinLength)
+		issue.Fix = "say what the parameter is for, its units or format, and an example value"
+		return issue, false
+	}
+
+	if values, ok := prop["enum"].([]interface{}); ok && len(values) > 0 {
+		lower := strings.ToLower(description)
+		for _, value := range values {
+			if strings.Contains(lower, strings.ToLower(fmt.Sprint(value))) {
+				return issue, true
+			}
+		}
+		issue.Message = "description does not explain any of its enum values"
+		issue.Fix = "describe what each allowed value means"
+		return issue, false
+	}
+	return issue, true
+}
@@ -0,0 +1,49 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintDescriptions(t *testing.T) {
+	prop := func(fields ...interface{}) map[string]interface{} {
+		m := map[string]interface{}{}
+		for i := 0; i < len(fields); i += 2 {
+			m[fields[i].(string)] = fields[i+1]
+		}
+		return m
+	}
+
+	inputSchema := prop(
+		"type", "object",
+		"properties", prop(
+			"city", prop("type", "string", "description", "City name, e.g. Paris"),
+			"days", prop("type", "integer", "description", "Days"),
+			"units", prop("type", "string", "enum", []interface{}{"metric", "imperial"}, "description", "Unit system for temperatures"),
+			"mode", prop("type", "string", "enum", []interface{}{"fast", "exact"}, "description", "Either fast or exact matching"),
+			"note", prop("type", "string"),
+			"filters", prop("type", "array", "description", "Filters applied to results",
+				"items", prop("type", "object", "properties", prop(
+					"field", prop("type", "string", "description", "Field to match on"),
+					"value", prop("type", "string"),
+				))),
+		),
+	)
+
+	issues := LintDescriptions(Tool{Name: "forecast", InputSchema: inputSchema}, DescriptionRules{MinLength: 10})
+
+	want := strings.Join([]string{
+		"warning inputSchema.properties.days: description is 4 characters, shorter than the minimum of 10",
+		"warning inputSchema.properties.filters.items.properties.value: has no description",
+		"warning inputSchema.properties.note: has no description",
+		"warning inputSchema.properties.units: description does not explain any of its enum values",
+	}, "\n")
+	if got := issueStrings(issues); got != want {
+		t.Errorf("Unexpected issues:\n%s\nwant:\n%s", got, want)
+	}
+	for _, issue := range issues {
+		if issue.Tool != "forecast" || issue.Fix == "" {
+			t.Errorf("Expected tool name and fix on every issue, got %+v", issue)
+		}
+	}
+}
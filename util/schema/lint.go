@@ -0,0 +1,384 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+)
+
+// Severity says how serious a lint issue is.
+type Severity string
+
+const (
+	// SeverityError marks schemas that are invalid or that hosts reject.
+	SeverityError Severity = "error"
+
+	// SeverityWarning marks schemas that are valid but that models tend to
+	// fill in badly.
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a problem found by Lint.
+type Issue struct {
+	// Tool is the name of the tool the issue was found in.
+	Tool string `json:"tool"`
+
+	// Path locates the problem, e.g. "inputSchema.properties.tags.items".
+	Path string `json:"path"`
+
+	// Message describes the problem.
+	Message string `json:"message"`
+
+	// Fix suggests how to resolve it, when there is a general answer.
+	Fix string `json:"fix,omitempty"`
+
+	Severity Severity `json:"severity"`
+}
+
+// String formats the issue as `tool "name": path: message`.
+func (i Issue) String() string {
+	return fmt.Sprintf("tool %q: %s: %s", i.Tool, i.Path, i.Message)
+}
+
+// Tool is the part of a tool definition Lint inspects.
+type Tool struct {
+	Name string
+
+	// InputSchema is the tool's input schema, either as decoded JSON or as
+	// the value generated for a registered tool.
+	InputSchema interface{}
+}
+
+// toolNamePattern is the tool name format hosts accept.
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Lint checks a tool definition against the JSON Schema meta-schema and the
+// stricter rules MCP hosts apply, and flags schemas that are valid but hard
+// for a model to use. Errors mean some host will refuse the tool; warnings
+// mean models are likely to call it with bad arguments.
+//
+// A test asserting that Lint finds nothing keeps a new parameter type from
+// silently generating a broken schema:
+//
+//	for _, issue := range server.LintAll(srv) {
+//	    t.Error(issue)
+//	}
+func Lint(tool Tool) []Issue {
+	var issues []Issue
+	if !toolNamePattern.MatchString(tool.Name) {
+		issues = append(issues, Issue{
+			Path:     "name",
+			Message:  fmt.Sprintf("must match %s", toolNamePattern),
+			Fix:      "use only letters, digits, '_' and '-' in tool names",
+			Severity: SeverityError,
+		})
+	}
+
+	schema, err := normalize(tool.InputSchema)
+	if err != nil || schema == nil {
+		issues = append(issues, Issue{Path: "inputSchema", Message: "missing or not a JSON object", Severity: SeverityError})
+	} else {
+		if t, _ := schema["type"].(string); t != "object" {
+			issues = append(issues, Issue{
+				Path:     "inputSchema.type",
+				Message:  `must be "object"`,
+				Fix:      "take arguments as a struct or map so the generated schema is an object",
+				Severity: SeverityError,
+			})
+		}
+		issues = append(issues, validateSchema(schema, "inputSchema", true)...)
+	}
+
+	for i := range issues {
+		issues[i].Tool = tool.Name
+	}
+	return issues
+}
+
+// HasErrors reports whether any issue has SeverityError.
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize converts a schema into the shapes encoding/json decodes to, so
+// generated schemas built from Go structs can be checked like decoded ones.
+func normalize(schema interface{}) (map[string]interface{}, error) {
+	if m, ok := schema.(map[string]interface{}); ok && isDecoded(m) {
+		return m, nil
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// isDecoded reports whether a value only contains the types encoding/json
+// produces.
+func isDecoded(value interface{}) bool {
+	switch v := value.(type) {
+	case nil, bool, float64, string:
+		return true
+	case map[string]interface{}:
+		for _, item := range v {
+			if !isDecoded(item) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		for _, item := range v {
+			if !isDecoded(item) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+var jsonSchemaTypes = map[string]bool{
+	"string": true, "number": true, "integer": true, "boolean": true,
+	"object": true, "array": true, "null": true,
+}
+
+// validateSchema checks one schema and its subschemas. root is true for the
+// input schema itself, whose object shape is checked by Lint.
+func validateSchema(schema map[string]interface{}, path string, root bool) []Issue {
+	var issues []Issue
+	add := func(key, message, fix string) {
+		issues = append(issues, Issue{Path: path + "." + key, Message: message, Fix: fix, Severity: SeverityError})
+	}
+	warn := func(message, fix string) {
+		issues = append(issues, Issue{Path: path, Message: message, Fix: fix, Severity: SeverityWarning})
+	}
+
+	types, typeOK := schemaTypes(schema["type"])
+	if _, present := schema["type"]; present && !typeOK {
+		add("type", "must be a JSON Schema type name or a list of them",
+			"use one of string, number, integer, boolean, object, array or null")
+	}
+
+	if !root && slices.Contains(types, "object") && !describesMembers(schema) {
+		warn("object without properties; the model has to guess its shape",
+			"use a struct with named fields, or describe the values with additionalProperties")
+	}
+
+	for _, key := range []string{"properties", "$defs", "definitions", "patternProperties"} {
+		value, present := schema[key]
+		if !present {
+			continue
+		}
+		members, ok := value.(map[string]interface{})
+		if !ok {
+			add(key, "must be an object of schemas", "")
+			continue
+		}
+		for _, name := range slices.Sorted(maps.Keys(members)) {
+			memberPath := path + "." + key + "." + name
+			if member, ok := members[name].(map[string]interface{}); ok && key == "properties" && !hasTypeInfo(member) {
+				issues = append(issues, Issue{
+					Path:     memberPath,
+					Message:  "has no type; the model has to guess what to send",
+					Fix:      "give the parameter a concrete Go type instead of interface{}",
+					Severity: SeverityWarning,
+				})
+			}
+			issues = append(issues, validateSubschema(members[name], memberPath)...)
+		}
+	}
+
+	for _, key := range []string{"additionalProperties", "not", "contains", "propertyNames", "if", "then", "else"} {
+		if value, present := schema[key]; present {
+			issues = append(issues, validateSubschema(value, path+"."+key)...)
+		}
+	}
+
+	for _, key := range []string{"anyOf", "oneOf", "allOf"} {
+		value, present := schema[key]
+		if !present {
+			continue
+		}
+		list, ok := value.([]interface{})
+		if !ok || len(list) == 0 {
+			add(key, "must be a non-empty array of schemas", "")
+			continue
+		}
+		for i, member := range list {
+			issues = append(issues, validateSubschema(member, fmt.Sprintf("%s.%s[%d]", path, key, i))...)
+		}
+	}
+
+	if value, present := schema["items"]; present {
+		if list, ok := value.([]interface{}); ok {
+			for i, member := range list {
+				issues = append(issues, validateSubschema(member, fmt.Sprintf("%s.items[%d]", path, i))...)
+			}
+		} else {
+			issues = append(issues, validateSubschema(value, path+".items")...)
+		}
+	} else if slices.Contains(types, "array") {
+		add("items", "array schemas must declare items; strict hosts reject the tool",
+			"use a slice of a concrete element type, or declare items explicitly")
+	}
+
+	if value, present := schema["required"]; present {
+		names, ok := stringSlice(value)
+		if !ok {
+			add("required", "must be an array of strings", "")
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		seen := make(map[string]bool)
+		for _, name := range names {
+			if seen[name] {
+				add("required", fmt.Sprintf("lists %q more than once", name), "")
+			}
+			seen[name] = true
+			if _, ok := properties[name]; !ok {
+				add("required", fmt.Sprintf("%q is not a declared property", name),
+					"remove it from required or declare the property")
+			}
+		}
+	}
+
+	if value, present := schema["enum"]; present {
+		if list, ok := value.([]interface{}); !ok || len(list) == 0 {
+			add("enum", "must be a non-empty array", "")
+		}
+	}
+
+	for _, key := range []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf"} {
+		if value, present := schema[key]; present {
+			if _, ok := number(value); !ok {
+				add(key, "must be a number", "")
+			}
+		}
+	}
+
+	for _, key := range []string{"minLength", "maxLength", "minItems", "maxItems", "minProperties", "maxProperties"} {
+		if value, present := schema[key]; present {
+			if n, ok := number(value); !ok || n < 0 || n != float64(int64(n)) {
+				add(key, "must be a non-negative integer", "")
+			}
+		}
+	}
+
+	if value, present := schema["pattern"]; present {
+		pattern, ok := value.(string)
+		if !ok {
+			add("pattern", "must be a string", "")
+		} else if _, err := regexp.Compile(pattern); err != nil {
+			add("pattern", fmt.Sprintf("is not a valid regular expression: %v", err), "")
+		}
+	}
+
+	for _, key := range []string{"title", "description", "format", "$ref"} {
+		if value, present := schema[key]; present {
+			if _, ok := value.(string); !ok {
+				add(key, "must be a string", "")
+			}
+		}
+	}
+
+	return issues
+}
+
+// validateSubschema checks a value that must itself be a schema. JSON Schema
+// allows true and false as schemas.
+func validateSubschema(value interface{}, path string) []Issue {
+	switch v := value.(type) {
+	case bool:
+		return nil
+	case map[string]interface{}:
+		return validateSchema(v, path, false)
+	default:
+		return []Issue{{Path: path, Message: "must be a schema object or boolean", Severity: SeverityError}}
+	}
+}
+
+// describesMembers reports whether an object schema says anything about the
+// members it accepts.
+func describesMembers(schema map[string]interface{}) bool {
+	for _, key := range []string{"properties", "patternProperties", "additionalProperties", "$ref", "anyOf", "oneOf", "allOf"} {
+		if _, ok := schema[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTypeInfo reports whether a property schema constrains the value's type.
+func hasTypeInfo(schema map[string]interface{}) bool {
+	for _, key := range []string{"type", "enum", "const", "$ref", "anyOf", "oneOf", "allOf"} {
+		if _, ok := schema[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaTypes returns the type names in a "type" keyword.
+func schemaTypes(value interface{}) ([]string, bool) {
+	if value == nil {
+		return nil, true
+	}
+	if name, ok := value.(string); ok {
+		return []string{name}, jsonSchemaTypes[name]
+	}
+	names, ok := stringSlice(value)
+	if !ok || len(names) == 0 {
+		return nil, false
+	}
+	for _, name := range names {
+		if !jsonSchemaTypes[name] {
+			return names, false
+		}
+	}
+	return names, true
+}
+
+func stringSlice(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case []string:
+		return v, true
+	case []interface{}:
+		names := make([]string, 0, len(v))
+		for _, item := range v {
+			name, ok := item.(string)
+			if !ok {
+				return names, false
+			}
+			names = append(names, name)
+		}
+		return names, true
+	default:
+		return nil, false
+	}
+}
+
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+)
+
+func issueStrings(issues []Issue) string {
+	lines := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		lines = append(lines, string(issue.Severity)+" "+issue.Path+": "+issue.Message)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLintMetaSchema(t *testing.T) {
+	issues := Lint(Tool{
+		Name: "search",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"mode":  map[string]interface{}{"type": "text"},
+				"limit": map[string]interface{}{"type": "integer", "minimum": "1"},
+				"ids":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			},
+			"required": []interface{}{"mode", "missing"},
+		},
+	})
+
+	want := strings.Join([]string{
+		"error inputSchema.properties.limit.minimum: must be a number",
+		"error inputSchema.properties.mode.type: must be a JSON Schema type name or a list of them",
+		`error inputSchema.required: "missing" is not a declared property`,
+	}, "\n")
+	if got := issueStrings(issues); got != want {
+		t.Errorf("Unexpected issues:\n%s\nwant:\n%s", got, want)
+	}
+	for _, issue := range issues {
+		if issue.Tool != "search" {
+			t.Errorf("Expected the tool name on every issue, got %+v", issue)
+		}
+	}
+}
+
+func TestLintGeneratedSchema(t *testing.T) {
+	type args struct {
+		Query   string            `json:"query"`
+		Tags    []string          `json:"tags"`
+		Filters map[string]string `json:"filters"`
+	}
+
+	generated, err := NewGenerator().GenerateSchema(args{})
+	if err != nil {
+		t.Fatalf("GenerateSchema failed: %v", err)
+	}
+	issues := Lint(Tool{Name: "search.v2", InputSchema: generated})
+
+	// Slices and maps get items and additionalProperties, so only the name is wrong
+	want := strings.Join([]string{
+		"error name: must match ^[a-zA-Z0-9_-]{1,64}$",
+	}, "\n")
+	if got := issueStrings(issues); got != want {
+		t.Errorf("Unexpected issues:\n%s\nwant:\n%s", got, want)
+	}
+	if !HasErrors(issues) {
+		t.Error("Expected HasErrors to report the errors")
+	}
+}
+
+func TestLintAcceptsCleanSchema(t *testing.T) {
+	issues := Lint(Tool{
+		Name: "weather",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"city":  map[string]interface{}{"type": "string", "description": "City name"},
+				"units": map[string]interface{}{"enum": []interface{}{"metric", "imperial"}},
+				"days":  map[string]interface{}{"type": []interface{}{"integer", "null"}, "minimum": 1},
+			},
+			"required": []string{"city"},
+		},
+	})
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, got:\n%s", issueStrings(issues))
+	}
+}
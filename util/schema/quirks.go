@@ -0,0 +1,135 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Transform rewrites a decoded JSON schema in place, to work around a host
+// that rejects or misreads part of it.
+type Transform func(schema map[string]interface{})
+
+// Rewrite returns a copy of schema, decoded as JSON, with transforms applied
+// in order. The original is left untouched.
+func Rewrite(schema interface{}, transforms ...Transform) (map[string]interface{}, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var rewritten map[string]interface{}
+	if err := json.Unmarshal(data, &rewritten); err != nil {
+		return nil, err
+	}
+	if rewritten == nil {
+		return nil, nil
+	}
+	for _, transform := range transforms {
+		transform(rewritten)
+	}
+	return rewritten, nil
+}
+
+// IntegerAsNumber replaces the "integer" type with "number", for hosts that
+// only know JSON's own number type. Integer-only values are lost.
+func IntegerAsNumber(schema map[string]interface{}) {
+	eachSubschema(schema, func(node map[string]interface{}) {
+		switch t := node["type"].(type) {
+		case string:
+			if t == "integer" {
+				node["type"] = "number"
+			}
+		case []interface{}:
+			for i, item := range t {
+				if item == "integer" {
+					t[i] = "number"
+				}
+			}
+		}
+	})
+}
+
+// DropEmptyRequired removes "required" lists that name no properties, for
+// hosts that reject an empty array there.
+func DropEmptyRequired(schema map[string]interface{}) {
+	eachSubschema(schema, func(node map[string]interface{}) {
+		if required, ok := node["required"].([]interface{}); ok && len(required) == 0 {
+			delete(node, "required")
+		}
+	})
+}
+
+// maxInlineDepth bounds how often InlineRefs expands a recursive definition
+// within itself.
+const maxInlineDepth = 3
+
+// InlineRefs replaces every "$ref" to the root schema's "$defs" with the
+// definition it points to and removes "$defs", for hosts that don't resolve
+// references. Recursive definitions are expanded a few levels deep and then
+// cut off as a plain object.
+func InlineRefs(schema map[string]interface{}) {
+	defs, _ := schema["$defs"].(map[string]interface{})
+	delete(schema, "$defs")
+	inlineRefs(schema, defs, map[string]int{})
+}
+
+func inlineRefs(node map[string]interface{}, defs map[string]interface{}, expanding map[string]int) {
+	if ref, ok := node["$ref"].(string); ok {
+		delete(node, "$ref")
+		name := strings.TrimPrefix(ref, "#/$defs/")
+		def, found := defs[name].(map[string]interface{})
+		if !found || expanding[name] >= maxInlineDepth {
+			if _, typed := node["type"]; !typed {
+				node["type"] = "object"
+			}
+			return
+		}
+
+		copied, _ := Rewrite(def)
+		for key, value := range copied {
+			if _, set := node[key]; !set {
+				node[key] = value
+			}
+		}
+		expanding[name]++
+		defer func() { expanding[name]-- }()
+	}
+	for _, child := range subschemas(node) {
+		inlineRefs(child, defs, expanding)
+	}
+}
+
+// eachSubschema calls fn for schema and every schema nested in it.
+func eachSubschema(schema map[string]interface{}, fn func(node map[string]interface{})) {
+	fn(schema)
+	for _, child := range subschemas(schema) {
+		eachSubschema(child, fn)
+	}
+}
+
+// subschemas returns the schemas directly nested in node.
+func subschemas(node map[string]interface{}) []map[string]interface{} {
+	var children []map[string]interface{}
+	add := func(value interface{}) {
+		if child, ok := value.(map[string]interface{}); ok {
+			children = append(children, child)
+		}
+	}
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		add(node[key])
+	}
+	for _, key := range []string{"properties", "patternProperties", "$defs"} {
+		if object, ok := node[key].(map[string]interface{}); ok {
+			for _, value := range object {
+				add(value)
+			}
+		}
+	}
+	for _, key := range []string{"anyOf", "oneOf", "allOf", "prefixItems"} {
+		if list, ok := node[key].([]interface{}); ok {
+			for _, value := range list {
+				add(value)
+			}
+		}
+	}
+	return children
+}
@@ -0,0 +1,68 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRewriteTransforms(t *testing.T) {
+	original := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"count": map[string]interface{}{"type": "integer"},
+			"ids":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": []interface{}{"integer", "null"}}},
+		},
+		"required": []interface{}{},
+	}
+
+	rewritten, err := Rewrite(original, IntegerAsNumber, DropEmptyRequired)
+	if err != nil {
+		t.Fatalf("Rewrite failed: %v", err)
+	}
+	want := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"count": map[string]interface{}{"type": "number"},
+			"ids":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": []interface{}{"number", "null"}}},
+		},
+	}
+	if !reflect.DeepEqual(rewritten, want) {
+		t.Errorf("Expected %v, got %v", want, rewritten)
+	}
+	if original["properties"].(map[string]interface{})["count"].(map[string]interface{})["type"] != "integer" {
+		t.Error("Expected the original schema to be left untouched")
+	}
+}
+
+func TestInlineRefs(t *testing.T) {
+	type Node struct {
+		Name     string  `json:"name"`
+		Children []*Node `json:"children"`
+	}
+	generated := FromStruct(struct {
+		Root Node `json:"root"`
+	}{})
+
+	rewritten, err := Rewrite(generated, InlineRefs)
+	if err != nil {
+		t.Fatalf("Rewrite failed: %v", err)
+	}
+	if _, ok := rewritten["$defs"]; ok {
+		t.Error("Expected $defs to be removed")
+	}
+	depth := 0
+	var check func(node map[string]interface{})
+	check = func(node map[string]interface{}) {
+		if _, ok := node["$ref"]; ok {
+			t.Errorf("Expected no $ref to remain, found one in %v", node)
+		}
+		for _, child := range subschemas(node) {
+			depth++
+			check(child)
+		}
+	}
+	check(rewritten)
+	if depth == 0 {
+		t.Error("Expected the recursive definition to be expanded")
+	}
+}
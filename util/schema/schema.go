@@ -2,6 +2,7 @@
 package schema
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"regexp"
@@ -14,8 +15,11 @@ import (
 )
 
 // PropertyDetail represents a JSON Schema property definition.
+//
+// Type is empty for properties that accept any JSON value, such as
+// interface{} and json.RawMessage fields, and for references to $defs.
 type PropertyDetail struct {
-	Type        string        `json:"type"`
+	Type        string        `json:"type,omitempty"`
 	Description string        `json:"description,omitempty"`
 	Enum        []interface{} `json:"enum,omitempty"`
 	Format      string        `json:"format,omitempty"`
@@ -25,6 +29,19 @@ type PropertyDetail struct {
 	MaxLength   *int          `json:"maxLength,omitempty"`
 	Pattern     string        `json:"pattern,omitempty"`
 	Default     interface{}   `json:"default,omitempty"`
+
+	// Items describes the elements of arrays.
+	Items *PropertyDetail `json:"items,omitempty"`
+
+	// Properties and Required describe the fields of nested structs.
+	Properties map[string]PropertyDetail `json:"properties,omitempty"`
+	Required   []string                  `json:"required,omitempty"`
+
+	// AdditionalProperties describes the values of maps.
+	AdditionalProperties *PropertyDetail `json:"additionalProperties,omitempty"`
+
+	// Ref points to a definition in the root schema's $defs, for recursive types.
+	Ref string `json:"$ref,omitempty"`
 }
 
 // ToolInputSchema represents a JSON Schema for tool input.
@@ -32,6 +49,9 @@ type ToolInputSchema struct {
 	Type       string                    `json:"type"`
 	Properties map[string]PropertyDetail `json:"properties"`
 	Required   []string                  `json:"required,omitempty"`
+
+	// Defs holds the definitions of recursive struct types, keyed by type name.
+	Defs map[string]PropertyDetail `json:"$defs,omitempty"`
 }
 
 // Generator generates JSON Schema from Go types.
@@ -56,11 +76,15 @@ func (g *Generator) WithIncludeFieldsWithoutTags(include bool) *Generator {
 // GenerateSchema generates a JSON Schema from a Go struct or any value.
 func (g *Generator) GenerateSchema(v interface{}) (map[string]interface{}, error) {
 	schema := FromStruct(v)
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"type":       schema.Type,
 		"properties": schema.Properties,
 		"required":   schema.Required,
-	}, nil
+	}
+	if len(schema.Defs) > 0 {
+		result["$defs"] = schema.Defs
+	}
+	return result, nil
 }
 
 // goTypeToJSONType maps Go kinds to JSON Schema types.
@@ -111,11 +135,62 @@ func parseIntTag(tagValue string) *int {
 // FromStruct generates a ToolInputSchema from struct tags.
 // It examines the struct fields and their tags to create a schema that describes
 // the expected input format for an MCP tool.
+//
+// Slices and arrays get an items schema, maps an additionalProperties schema
+// and nested structs their own properties, recursively. Struct types that
+// contain themselves are described once in $defs and referenced with $ref.
 func FromStruct(v interface{}) ToolInputSchema {
 	t := reflect.TypeOf(v)
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
+
+	g := &structWalker{
+		expanding: map[reflect.Type]bool{t: true},
+		defNames:  make(map[reflect.Type]string),
+		defs:      make(map[string]PropertyDetail),
+	}
+	props, requiredFields := g.fields(t)
+
+	schema := ToolInputSchema{
+		Type:       "object",
+		Properties: props,
+	}
+
+	// Only add Required field if there are any required fields
+	if len(requiredFields) > 0 {
+		schema.Required = requiredFields
+	}
+
+	// The root type is defined in $defs only if one of its fields refers back to it
+	if name, ok := g.defNames[t]; ok {
+		g.defs[name] = PropertyDetail{Type: "object", Properties: props, Required: schema.Required}
+	}
+	if len(g.defs) > 0 {
+		schema.Defs = g.defs
+	}
+
+	return schema
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// structWalker builds the schemas of nested types for FromStruct.
+type structWalker struct {
+	// expanding holds the struct types whose schemas are being built, to
+	// detect recursion.
+	expanding map[reflect.Type]bool
+
+	// defNames and defs are the $defs of recursive types.
+	defNames map[reflect.Type]string
+	defs     map[string]PropertyDetail
+}
+
+// fields builds the properties and required list of a struct type.
+func (g *structWalker) fields(t reflect.Type) (map[string]PropertyDetail, []string) {
 	props := map[string]PropertyDetail{}
 	requiredFields := []string{}
 	trackFields := make(map[string]bool)
@@ -123,13 +198,43 @@ func FromStruct(v interface{}) ToolInputSchema {
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
 
+		descTag := field.Tag.Get("description")
+		jsonTag := field.Tag.Get("json")
+
+		// Embedded structs without a JSON name contribute their fields, as
+		// encoding/json flattens them
+		if field.Anonymous && jsonTag == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if g.expanding[embedded] {
+					continue
+				}
+				g.expanding[embedded] = true
+				embeddedProps, embeddedRequired := g.fields(embedded)
+				delete(g.expanding, embedded)
+				for name, prop := range embeddedProps {
+					if _, exists := props[name]; !exists {
+						props[name] = prop
+					}
+				}
+				for _, name := range embeddedRequired {
+					if !trackFields[name] {
+						requiredFields = append(requiredFields, name)
+						trackFields[name] = true
+					}
+				}
+				continue
+			}
+		}
+
 		// Skip unexported fields
 		if field.PkgPath != "" {
 			continue
 		}
 
-		descTag := field.Tag.Get("description")
-		jsonTag := field.Tag.Get("json")
 		var name string
 
 		if jsonTag == "-" {
@@ -139,10 +244,11 @@ func FromStruct(v interface{}) ToolInputSchema {
 			// Use JSON tag if present
 			name = strings.Split(jsonTag, ",")[0]
 
-			// Determine if field is required (convention: non-pointer types are required)
-			// Only include fields with JSON tags in required fields list
+			// Determine if field is required (convention: non-pointer types are required,
+			// unless marked omitempty). Only include fields with JSON tags in required fields list
 			isPtr := field.Type.Kind() == reflect.Ptr
-			if !isPtr && !trackFields[name] {
+			omitEmpty := strings.Contains(jsonTag, ",omitempty")
+			if !isPtr && !omitEmpty && !trackFields[name] {
 				requiredFields = append(requiredFields, name)
 				trackFields[name] = true
 			}
@@ -157,18 +263,12 @@ func FromStruct(v interface{}) ToolInputSchema {
 			trackFields[name] = true
 		}
 
-		// Determine the schema type
-		fieldType := field.Type
-		if field.Type.Kind() == reflect.Ptr {
-			fieldType = fieldType.Elem()
-		}
-		schemaType := goTypeToJSONType(fieldType.Kind())
-
-		// Create property definition
-		propDetail := PropertyDetail{
-			Type:        schemaType,
-			Description: descTag,
+		// Create property definition from the field's type
+		propDetail := g.typeSchema(field.Type)
+		if descTag != "" {
+			propDetail.Description = descTag
 		}
+		schemaType := propDetail.Type
 
 		// Process enum tag
 		enumTag := field.Tag.Get("enum")
@@ -246,17 +346,87 @@ func FromStruct(v interface{}) ToolInputSchema {
 		props[name] = propDetail
 	}
 
-	schema := ToolInputSchema{
-		Type:       "object",
-		Properties: props,
+	return props, requiredFields
+}
+
+// typeSchema builds the schema of a Go type as encoding/json encodes it.
+func (g *structWalker) typeSchema(t reflect.Type) PropertyDetail {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
 
-	// Only add Required field if there are any required fields
-	if len(requiredFields) > 0 {
-		schema.Required = requiredFields
+	switch {
+	case t == timeType:
+		return PropertyDetail{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		// Any JSON value
+		return PropertyDetail{}
 	}
 
-	return schema
+	switch t.Kind() {
+	case reflect.Interface:
+		return PropertyDetail{}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			// encoding/json sends []byte as a base64 string
+			return PropertyDetail{Type: "string", Format: "byte"}
+		}
+		items := g.typeSchema(t.Elem())
+		return PropertyDetail{Type: "array", Items: &items}
+	case reflect.Map:
+		values := g.typeSchema(t.Elem())
+		return PropertyDetail{Type: "object", AdditionalProperties: &values}
+	case reflect.Struct:
+		if g.expanding[t] {
+			return PropertyDetail{Ref: "#/$defs/" + g.defName(t)}
+		}
+		g.expanding[t] = true
+		props, required := g.fields(t)
+		delete(g.expanding, t)
+
+		object := PropertyDetail{Type: "object", Properties: props}
+		if len(required) > 0 {
+			object.Required = required
+		}
+
+		// A type that refers to itself is described once in $defs
+		if name, ok := g.defNames[t]; ok {
+			g.defs[name] = object
+			return PropertyDetail{Ref: "#/$defs/" + name}
+		}
+		return object
+	default:
+		return PropertyDetail{Type: goTypeToJSONType(t.Kind())}
+	}
+}
+
+// defName returns the $defs key of a recursive type, distinguishing types
+// from different packages that share a name.
+func (g *structWalker) defName(t reflect.Type) string {
+	if name, ok := g.defNames[t]; ok {
+		return name
+	}
+
+	base := t.Name()
+	if base == "" {
+		base = "Object"
+	}
+	name := base
+	for i := 2; ; i++ {
+		taken := false
+		for _, existing := range g.defNames {
+			if existing == name {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			break
+		}
+		name = base + strconv.Itoa(i)
+	}
+	g.defNames[t] = name
+	return name
 }
 
 // Validator provides validation for struct fields.
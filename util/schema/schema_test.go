@@ -3,6 +3,7 @@ package schema
 import (
 	"encoding/json"
 	"testing"
+	"time"
 )
 
 type TestStruct struct {
@@ -122,6 +123,123 @@ func TestFromStruct(t *testing.T) {
 	}
 }
 
+type testAddress struct {
+	Street string `json:"street"`
+	City   string `json:"city" description:"City name"`
+}
+
+type testCategory struct {
+	Name     string         `json:"name"`
+	Children []testCategory `json:"children"`
+}
+
+type testAudit struct {
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type testNestedStruct struct {
+	testAudit
+	Addresses  []testAddress          `json:"addresses"`
+	Scores     map[string]int         `json:"scores"`
+	Primary    *testAddress           `json:"primary"`
+	Matrix     [][]float64            `json:"matrix"`
+	Extra      json.RawMessage        `json:"extra"`
+	Anything   interface{}            `json:"anything"`
+	Categories []testCategory         `json:"categories"`
+	Labels     map[string][]string    `json:"labels"`
+	Meta       map[string]interface{} `json:"meta"`
+}
+
+type testTree struct {
+	Value    int        `json:"value"`
+	Children []testTree `json:"children"`
+}
+
+// marshalSchema returns the JSON form of a schema as generic maps.
+func marshalSchema(t *testing.T, schema ToolInputSchema) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestFromStructNestedTypes(t *testing.T) {
+	m := marshalSchema(t, FromStruct(testNestedStruct{}))
+	props := m["properties"].(map[string]interface{})
+	get := func(path ...string) interface{} {
+		var current interface{} = props
+		for _, key := range path {
+			current = current.(map[string]interface{})[key]
+		}
+		return current
+	}
+
+	if get("addresses", "type") != "array" || get("addresses", "items", "type") != "object" {
+		t.Errorf("Expected an array of objects, got %v", props["addresses"])
+	}
+	if get("addresses", "items", "properties", "city", "description") != "City name" {
+		t.Errorf("Expected nested field tags to apply, got %v", props["addresses"])
+	}
+	if required, _ := get("addresses", "items", "required").([]interface{}); len(required) != 2 {
+		t.Errorf("Expected the nested struct's required fields, got %v", required)
+	}
+	if get("scores", "additionalProperties", "type") != "integer" {
+		t.Errorf("Expected map values to be described, got %v", props["scores"])
+	}
+	if get("primary", "type") != "object" || get("primary", "properties", "street", "type") != "string" {
+		t.Errorf("Expected pointers to structs to be expanded, got %v", props["primary"])
+	}
+	if get("matrix", "items", "items", "type") != "number" {
+		t.Errorf("Expected nested arrays to be described, got %v", props["matrix"])
+	}
+	if get("labels", "additionalProperties", "items", "type") != "string" {
+		t.Errorf("Expected maps of slices to be described, got %v", props["labels"])
+	}
+	if extra := props["extra"].(map[string]interface{}); len(extra) != 0 {
+		t.Errorf("Expected json.RawMessage to accept any value, got %v", extra)
+	}
+	if anything := props["anything"].(map[string]interface{}); len(anything) != 0 {
+		t.Errorf("Expected interface{} to accept any value, got %v", anything)
+	}
+	if get("createdAt", "type") != "string" || get("createdAt", "format") != "date-time" {
+		t.Errorf("Expected embedded fields to be flattened and time.Time to be a date-time, got %v", props["createdAt"])
+	}
+
+	// The recursive category type is defined once and referenced
+	if get("categories", "items", "$ref") != "#/$defs/testCategory" {
+		t.Errorf("Expected a reference to the recursive type, got %v", props["categories"])
+	}
+	defs, _ := m["$defs"].(map[string]interface{})
+	category, _ := defs["testCategory"].(map[string]interface{})
+	children := category["properties"].(map[string]interface{})["children"].(map[string]interface{})
+	if children["items"].(map[string]interface{})["$ref"] != "#/$defs/testCategory" {
+		t.Errorf("Expected the definition to refer to itself, got %v", category)
+	}
+}
+
+func TestFromStructRecursiveRoot(t *testing.T) {
+	m := marshalSchema(t, FromStruct(testTree{}))
+
+	children := m["properties"].(map[string]interface{})["children"].(map[string]interface{})
+	if children["items"].(map[string]interface{})["$ref"] != "#/$defs/testTree" {
+		t.Errorf("Expected children to refer to the root type, got %v", children)
+	}
+	defs, _ := m["$defs"].(map[string]interface{})
+	if tree, ok := defs["testTree"].(map[string]interface{}); !ok || tree["type"] != "object" {
+		t.Errorf("Expected the root type in $defs, got %v", defs)
+	}
+
+	if _, ok := marshalSchema(t, FromStruct(TestStruct{}))["$defs"]; ok {
+		t.Error("Expected no $defs for a type without recursion")
+	}
+}
+
 func TestValidateStruct(t *testing.T) {
 	// Valid struct
 	valid := TestStruct{
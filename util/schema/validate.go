@@ -0,0 +1,422 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldError describes an argument that does not match its schema.
+type FieldError struct {
+	// Field is the path of the argument, such as "shipping.city" or
+	// "items[2].sku". It is empty for problems with the arguments object
+	// itself.
+	Field string `json:"field"`
+
+	// Message says what is wrong with the value.
+	Message string `json:"message"`
+}
+
+// String returns the error as "field: message".
+func (e FieldError) String() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// ArgumentValidator checks tool arguments against an input schema. It
+// supports the JSON Schema keywords the schema generator emits, plus the
+// common ones of hand-written schemas: type, enum, const, required,
+// properties, additionalProperties, items, minItems, maxItems, uniqueItems,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf,
+// minLength, maxLength, pattern, format, allOf, anyOf, oneOf, not and $ref
+// to local definitions. Other keywords are ignored.
+//
+// An ArgumentValidator is safe for concurrent use.
+type ArgumentValidator struct {
+	root map[string]interface{}
+
+	mu       sync.Mutex
+	patterns map[string]*regexp.Regexp
+}
+
+// NewArgumentValidator prepares a schema for validating arguments. The
+// schema may be a decoded JSON map or any value that marshals to one, such
+// as a ToolInputSchema.
+func NewArgumentValidator(inputSchema interface{}) (*ArgumentValidator, error) {
+	root, err := normalize(inputSchema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input schema: %w", err)
+	}
+	return &ArgumentValidator{root: root, patterns: make(map[string]*regexp.Regexp)}, nil
+}
+
+// Validate returns the problems with args, or nil if they match the
+// schema. Problems are sorted by field. Arguments holding Go values other
+// than the ones encoding/json decodes to are checked as their JSON encoding.
+func (v *ArgumentValidator) Validate(args map[string]interface{}) []FieldError {
+	var value interface{} = args
+	if args == nil {
+		value = map[string]interface{}{}
+	} else if !isDecoded(args) {
+		normalized, err := normalize(args)
+		if err != nil {
+			return []FieldError{{Message: fmt.Sprintf("arguments are not valid JSON: %v", err)}}
+		}
+		value = normalized
+	}
+	problems := v.check(v.root, "", value, 0)
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Field < problems[j].Field
+	})
+	return problems
+}
+
+// maxRefDepth bounds $ref resolution, so that a schema referring to itself
+// without consuming any input can't recurse forever.
+const maxRefDepth = 64
+
+func (v *ArgumentValidator) check(schema map[string]interface{}, path string, value interface{}, depth int) []FieldError {
+	if ref, ok := schema["$ref"].(string); ok {
+		if depth >= maxRefDepth {
+			return []FieldError{{Field: path, Message: "schema reference nesting too deep"}}
+		}
+		target, ok := v.resolve(ref)
+		if !ok {
+			return nil
+		}
+		return v.check(target, path, value, depth+1)
+	}
+
+	var problems []FieldError
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if types, ok := schemaTypes(schema["type"]); ok && len(types) > 0 && !matchesAnyType(value, types) {
+		fail("must be %s, got %s", describeTypes(types), jsonType(value))
+		return problems
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, value) {
+		fail("must be one of %s", formatValues(enum))
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, value) {
+		fail("must be %s", formatValue(constant))
+	}
+
+	switch val := value.(type) {
+	case string:
+		problems = append(problems, v.checkString(schema, path, val)...)
+	case float64:
+		problems = append(problems, checkNumber(schema, path, val)...)
+	case map[string]interface{}:
+		problems = append(problems, v.checkObject(schema, path, val, depth)...)
+	case []interface{}:
+		problems = append(problems, v.checkArray(schema, path, val, depth)...)
+	}
+
+	problems = append(problems, v.checkCombinators(schema, path, value, depth)...)
+	return problems
+}
+
+func (v *ArgumentValidator) checkString(schema map[string]interface{}, path, value string) []FieldError {
+	var problems []FieldError
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(value)
+	if min, ok := number(schema["minLength"]); ok && float64(length) < min {
+		fail("must be at least %v characters long", min)
+	}
+	if max, ok := number(schema["maxLength"]); ok && float64(length) > max {
+		fail("must be at most %v characters long", max)
+	}
+	if pattern, ok := schema["pattern"].(string); ok && pattern != "" {
+		if re := v.compile(pattern); re != nil && !re.MatchString(value) {
+			fail("must match pattern %q", pattern)
+		}
+	}
+	if format, ok := schema["format"].(string); ok {
+		if expected, ok := checkFormat(format, value); !ok {
+			fail("must be %s", expected)
+		}
+	}
+	return problems
+}
+
+func checkNumber(schema map[string]interface{}, path string, value float64) []FieldError {
+	var problems []FieldError
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if min, ok := number(schema["minimum"]); ok && value < min {
+		fail("must be at least %v", min)
+	}
+	if max, ok := number(schema["maximum"]); ok && value > max {
+		fail("must be at most %v", max)
+	}
+	if min, ok := number(schema["exclusiveMinimum"]); ok && value <= min {
+		fail("must be greater than %v", min)
+	}
+	if max, ok := number(schema["exclusiveMaximum"]); ok && value >= max {
+		fail("must be less than %v", max)
+	}
+	if step, ok := number(schema["multipleOf"]); ok && step > 0 {
+		if q := value / step; math.Abs(q-math.Round(q)) > 1e-9 {
+			fail("must be a multiple of %v", step)
+		}
+	}
+	return problems
+}
+
+func (v *ArgumentValidator) checkObject(schema map[string]interface{}, path string, value map[string]interface{}, depth int) []FieldError {
+	var problems []FieldError
+
+	if required, ok := stringSlice(schema["required"]); ok {
+		for _, name := range required {
+			if _, present := value[name]; !present {
+				problems = append(problems, FieldError{Field: joinField(path, name), Message: "is required"})
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, item := range value {
+		if sub, ok := properties[name].(map[string]interface{}); ok {
+			problems = append(problems, v.check(sub, joinField(path, name), item, depth)...)
+			continue
+		}
+		if _, declared := properties[name]; declared {
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				problems = append(problems, FieldError{Field: joinField(path, name), Message: "is not a known argument"})
+			}
+		case map[string]interface{}:
+			problems = append(problems, v.check(additional, joinField(path, name), item, depth)...)
+		}
+	}
+	return problems
+}
+
+func (v *ArgumentValidator) checkArray(schema map[string]interface{}, path string, value []interface{}, depth int) []FieldError {
+	var problems []FieldError
+	fail := func(format string, args ...interface{}) {
+		problems = append(problems, FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if min, ok := number(schema["minItems"]); ok && float64(len(value)) < min {
+		fail("must have at least %v items", min)
+	}
+	if max, ok := number(schema["maxItems"]); ok && float64(len(value)) > max {
+		fail("must have at most %v items", max)
+	}
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+	duplicates:
+		for i := range value {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(value[i], value[j]) {
+					fail("must not contain duplicate items")
+					break duplicates
+				}
+			}
+		}
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		for i, item := range value {
+			problems = append(problems, v.check(items, path+"["+strconv.Itoa(i)+"]", item, depth)...)
+		}
+	}
+	return problems
+}
+
+func (v *ArgumentValidator) checkCombinators(schema map[string]interface{}, path string, value interface{}, depth int) []FieldError {
+	var problems []FieldError
+
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			if subschema, ok := sub.(map[string]interface{}); ok {
+				problems = append(problems, v.check(subschema, path, value, depth)...)
+			}
+		}
+	}
+	if any, ok := schema["anyOf"].([]interface{}); ok && v.countMatches(any, value, depth) == 0 {
+		problems = append(problems, FieldError{Field: path, Message: "must match at least one of the allowed schemas"})
+	}
+	if one, ok := schema["oneOf"].([]interface{}); ok {
+		if n := v.countMatches(one, value, depth); n != 1 {
+			problems = append(problems, FieldError{Field: path, Message: fmt.Sprintf("must match exactly one of the allowed schemas, matched %d", n)})
+		}
+	}
+	if not, ok := schema["not"].(map[string]interface{}); ok && len(v.check(not, path, value, depth)) == 0 {
+		problems = append(problems, FieldError{Field: path, Message: "must not match the excluded schema"})
+	}
+	return problems
+}
+
+// countMatches returns how many of the subschemas value matches.
+func (v *ArgumentValidator) countMatches(subschemas []interface{}, value interface{}, depth int) int {
+	n := 0
+	for _, sub := range subschemas {
+		if subschema, ok := sub.(map[string]interface{}); ok && len(v.check(subschema, "", value, depth)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// resolve looks up a local reference such as "#/$defs/Node".
+func (v *ArgumentValidator) resolve(ref string) (map[string]interface{}, bool) {
+	if ref == "#" {
+		return v.root, true
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, false
+	}
+	var current interface{} = v.root
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = m[part]
+	}
+	target, ok := current.(map[string]interface{})
+	return target, ok
+}
+
+// compile returns the compiled pattern, or nil if it is not a valid
+// regular expression, which the schema linter reports separately.
+func (v *ArgumentValidator) compile(pattern string) *regexp.Regexp {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if re, ok := v.patterns[pattern]; ok {
+		return re
+	}
+	re, _ := regexp.Compile(pattern)
+	v.patterns[pattern] = re
+	return re
+}
+
+func joinField(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// jsonType names the JSON type of a decoded value.
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func matchesAnyType(value interface{}, types []string) bool {
+	actual := jsonType(value)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func describeTypes(types []string) string {
+	described := make([]string, len(types))
+	for i, t := range types {
+		switch t {
+		case "integer", "array", "object":
+			described[i] = "an " + t
+		case "null":
+			described[i] = "null"
+		default:
+			described[i] = "a " + t
+		}
+	}
+	return strings.Join(described, " or ")
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, allowed := range values {
+		if reflect.DeepEqual(allowed, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func formatValues(values []interface{}) string {
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		formatted[i] = formatValue(value)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// checkFormat validates the formats clients are likely to be asked for, and
+// accepts unknown formats, which JSON Schema treats as annotations. It
+// returns a description of the expected value.
+func checkFormat(format, value string) (string, bool) {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return "an RFC 3339 date-time", err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		return "a date in YYYY-MM-DD form", err == nil
+	case "time":
+		_, err := time.Parse("15:04:05Z07:00", value)
+		return "an RFC 3339 time", err == nil
+	case "email":
+		address, err := mail.ParseAddress(value)
+		return "an email address", err == nil && address.Address == value
+	case "uri", "url":
+		u, err := url.Parse(value)
+		return "an absolute URI", err == nil && u.Scheme != ""
+	case "uuid":
+		return "a UUID", uuidPattern.MatchString(value)
+	}
+	return "", true
+}
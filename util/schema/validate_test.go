@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArgumentValidatorGeneratedSchema(t *testing.T) {
+	validator, err := NewArgumentValidator(FromStruct(benchOrder{}))
+	if err != nil {
+		t.Fatalf("NewArgumentValidator failed: %v", err)
+	}
+
+	valid := map[string]interface{}{
+		"id":       "A1",
+		"quantity": float64(3),
+		"price":    9.5,
+		"shipping": map[string]interface{}{"street": "1 Main St", "city": "Springfield", "country": "US"},
+	}
+	if problems := validator.Validate(valid); problems != nil {
+		t.Errorf("Expected valid arguments to pass, got %v", problems)
+	}
+
+	invalid := map[string]interface{}{
+		"id":       "a-1",
+		"quantity": float64(0),
+		"price":    "free",
+		"shipping": map[string]interface{}{"street": "1 Main St", "country": "FR"},
+		"items":    []interface{}{map[string]interface{}{"street": "x", "city": 4, "country": "US"}},
+	}
+	want := []FieldError{
+		{Field: "id", Message: `must match pattern "^[A-Z0-9]+$"`},
+		{Field: "items[0].city", Message: "must be a string, got integer"},
+		{Field: "price", Message: "must be a number, got string"},
+		{Field: "quantity", Message: "must be at least 1"},
+		{Field: "shipping.city", Message: "is required"},
+		{Field: "shipping.country", Message: `must be one of "US", "CA", "MX"`},
+	}
+	if got := validator.Validate(invalid); !reflect.DeepEqual(got, want) {
+		t.Errorf("Unexpected problems:\n got: %v\nwant: %v", got, want)
+	}
+}
+
+func TestArgumentValidatorKeywords(t *testing.T) {
+	validator, err := NewArgumentValidator(map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]interface{}{
+			"when":  map[string]interface{}{"type": "string", "format": "date-time"},
+			"count": map[string]interface{}{"type": "integer", "multipleOf": 5.0, "exclusiveMaximum": 100.0},
+			"tags":  map[string]interface{}{"type": "array", "uniqueItems": true, "maxItems": 3.0},
+			"id":    map[string]interface{}{"oneOf": []interface{}{map[string]interface{}{"type": "string"}, map[string]interface{}{"type": "integer"}}},
+			"node":  map[string]interface{}{"$ref": "#/$defs/node"},
+		},
+		"$defs": map[string]interface{}{
+			"node": map[string]interface{}{
+				"type":       "object",
+				"required":   []interface{}{"name"},
+				"properties": map[string]interface{}{"child": map[string]interface{}{"$ref": "#/$defs/node"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewArgumentValidator failed: %v", err)
+	}
+
+	problems := validator.Validate(map[string]interface{}{
+		"when":  "tomorrow",
+		"count": 12.5,
+		"tags":  []interface{}{"a", "a"},
+		"id":    true,
+		"node":  map[string]interface{}{"name": "root", "child": map[string]interface{}{}},
+		"extra": 1,
+	})
+	want := []FieldError{
+		{Field: "count", Message: "must be an integer, got number"},
+		{Field: "extra", Message: "is not a known argument"},
+		{Field: "id", Message: "must match exactly one of the allowed schemas, matched 0"},
+		{Field: "node.child.name", Message: "is required"},
+		{Field: "tags", Message: "must not contain duplicate items"},
+		{Field: "when", Message: "must be an RFC 3339 date-time"},
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("Unexpected problems:\n got: %v\nwant: %v", problems, want)
+	}
+}
+
+func TestArgumentValidatorGoValues(t *testing.T) {
+	validator, err := NewArgumentValidator(FromStruct(TestStruct{}))
+	if err != nil {
+		t.Fatalf("NewArgumentValidator failed: %v", err)
+	}
+
+	// Arguments built in Go rather than decoded from JSON
+	problems := validator.Validate(map[string]interface{}{
+		"name": "Ada", "age": 200, "email": "ada@example.com", "role": "admin", "score": 50,
+	})
+	want := []FieldError{{Field: "age", Message: "must be at most 120"}}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("Unexpected problems:\n got: %v\nwant: %v", problems, want)
+	}
+}
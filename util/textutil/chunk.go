@@ -0,0 +1,272 @@
+package textutil
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Tokenizer counts the tokens a model would read for a text. Counts should
+// roughly add up when texts are concatenated, as they do for the tokenizers
+// of LLMs.
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// TokenizerFunc adapts a function to a Tokenizer, for plugging in a model's
+// own tokenizer library.
+type TokenizerFunc func(text string) int
+
+// CountTokens implements Tokenizer.
+func (f TokenizerFunc) CountTokens(text string) int {
+	return f(text)
+}
+
+// ApproximateTokenizer estimates token counts without a model's vocabulary:
+// four ASCII characters per token, and a token per other character, which
+// overestimates slightly for accented Latin text and holds for CJK text.
+// It is used when no Tokenizer is given.
+var ApproximateTokenizer Tokenizer = TokenizerFunc(approximateTokens)
+
+// approximateTokens implements ApproximateTokenizer.
+func approximateTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// sentenceEnd matches the end of a sentence and the space after it, and
+// endsSentence text ending so.
+var (
+	sentenceEnd  = regexp.MustCompile(`[.!?]["')\]]*[ \t]+`)
+	endsSentence = regexp.MustCompile(`[.!?]["')\]]*[ \t]+$`)
+)
+
+// splitters split text at ever finer boundaries: paragraphs, lines,
+// sentences and words. Separators stay at the end of the piece before them,
+// so the pieces concatenate to the text.
+var splitters = []func(text string) []string{
+	func(text string) []string { return strings.SplitAfter(text, "\n\n") },
+	func(text string) []string { return strings.SplitAfter(text, "\n") },
+	func(text string) []string {
+		var pieces []string
+		start := 0
+		for _, loc := range sentenceEnd.FindAllStringIndex(text, -1) {
+			pieces = append(pieces, text[start:loc[1]])
+			start = loc[1]
+		}
+		return append(pieces, text[start:])
+	},
+	func(text string) []string { return strings.SplitAfter(text, " ") },
+}
+
+// Chunk splits text into chunks of at most maxTokens tokens each. A chunk
+// ends at the strongest boundary in its second half: a paragraph, else a
+// line, a sentence or a word, and within a word only when a single word is
+// too long. Each chunk after the first starts with up to overlap tokens
+// from the end of the one before, in whole words, so that no chunk starts
+// without context; overlap is capped at half of maxTokens. Chunks are
+// trimmed of blank lines and trailing whitespace, and empty chunks are
+// dropped.
+//
+// A nil tokenizer selects ApproximateTokenizer. Text within maxTokens, or
+// any text if maxTokens isn't positive, is returned as a single chunk.
+//
+// Example:
+//
+//	for _, chunk := range textutil.Chunk(report, 2000, 100, nil) {
+//	    content = append(content, server.TextContent(chunk))
+//	}
+func Chunk(text string, maxTokens, overlap int, tokenizer Tokenizer) []string {
+	if tokenizer == nil {
+		tokenizer = ApproximateTokenizer
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	if maxTokens <= 0 || tokenizer.CountTokens(text) <= maxTokens {
+		return []string{text}
+	}
+	overlap = min(max(overlap, 0), maxTokens/2)
+
+	var chunks []string
+	var current []piece
+	size := 0
+	for _, text := range split(text, maxTokens, tokenizer, 0) {
+		next := piece{text: text, tokens: tokenizer.CountTokens(text), boundary: boundaryOf(text)}
+		for size > 0 && size+next.tokens > maxTokens {
+			// End the chunk at its strongest boundary past the halfway point
+			cut, best, sum := len(current)-1, len(splitters)+1, 0
+			for i, p := range current {
+				sum += p.tokens
+				if sum*2 >= maxTokens && p.boundary <= best && !p.overlap {
+					cut, best = i, p.boundary
+				}
+			}
+
+			var b strings.Builder
+			for _, p := range current[:cut+1] {
+				b.WriteString(p.text)
+			}
+			chunk := b.String()
+			chunks = append(chunks, chunk)
+
+			current = append([]piece(nil), current[cut+1:]...)
+			size = 0
+			for _, p := range current {
+				size += p.tokens
+			}
+			if tail := overlapTail(chunk, overlap, tokenizer); tail != "" {
+				tn := tokenizer.CountTokens(tail)
+				if tn+size+next.tokens <= maxTokens {
+					current = append([]piece{{text: tail, tokens: tn, overlap: true}}, current...)
+					size += tn
+				}
+			}
+		}
+		current = append(current, next)
+		size += next.tokens
+	}
+	var b strings.Builder
+	for _, p := range current {
+		b.WriteString(p.text)
+	}
+	chunks = append(chunks, b.String())
+
+	trimmed := chunks[:0]
+	for _, chunk := range chunks {
+		chunk = strings.TrimRight(chunk, " \t\r\n")
+		chunk = strings.TrimLeft(chunk, "\r\n")
+		if strings.TrimSpace(chunk) != "" {
+			trimmed = append(trimmed, chunk)
+		}
+	}
+	return trimmed
+}
+
+// piece is a piece of text Chunk packs into chunks.
+type piece struct {
+	text   string
+	tokens int
+
+	// boundary is the index of the coarsest splitter whose boundary the
+	// piece ends at, or len(splitters) if it ends within a word
+	boundary int
+
+	// overlap is set for text repeated from the chunk before
+	overlap bool
+}
+
+// boundaryOf returns the boundary a piece of text ends at.
+func boundaryOf(text string) int {
+	switch {
+	case strings.HasSuffix(text, "\n\n"):
+		return 0
+	case strings.HasSuffix(text, "\n"):
+		return 1
+	case endsSentence.MatchString(text):
+		return 2
+	case strings.HasSuffix(text, " "):
+		return 3
+	}
+	return len(splitters)
+}
+
+// split splits text into pieces of at most maxTokens tokens, at the
+// boundaries of splitters[level] and finer ones.
+func split(text string, maxTokens int, tokenizer Tokenizer, level int) []string {
+	if tokenizer.CountTokens(text) <= maxTokens {
+		return []string{text}
+	}
+	if level == len(splitters) {
+		return splitRunes(text, maxTokens, tokenizer)
+	}
+	var pieces []string
+	for _, part := range splitters[level](text) {
+		if part != "" {
+			pieces = append(pieces, split(part, maxTokens, tokenizer, level+1)...)
+		}
+	}
+	return pieces
+}
+
+// splitRunes splits text anywhere into pieces of at most maxTokens tokens,
+// searching for the longest prefix that fits by doubling and bisection so
+// that long unbroken text, such as encoded data, splits in linear time.
+func splitRunes(text string, maxTokens int, tokenizer Tokenizer) []string {
+	var pieces []string
+	runes := []rune(text)
+	for len(runes) > 0 {
+		fits := func(n int) bool { return tokenizer.CountTokens(string(runes[:n])) <= maxTokens }
+
+		lo, hi := 1, min(maxTokens, len(runes))
+		for hi < len(runes) && fits(hi) {
+			lo, hi = hi, min(hi*2, len(runes))
+		}
+		if fits(hi) {
+			lo = hi
+		}
+		for lo+1 < hi {
+			mid := (lo + hi) / 2
+			if fits(mid) {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+
+		pieces = append(pieces, string(runes[:lo]))
+		runes = runes[lo:]
+	}
+	return pieces
+}
+
+// overlapTail returns the longest end of chunk of at most overlap tokens
+// that starts at a word.
+func overlapTail(chunk string, overlap int, tokenizer Tokenizer) string {
+	if overlap <= 0 {
+		return ""
+	}
+	tail := ""
+	end := len(strings.TrimRight(chunk, " \t\r\n"))
+	for end > 0 {
+		start := strings.LastIndexAny(chunk[:end], " \t\r\n") + 1
+		candidate := chunk[start:]
+		if tokenizer.CountTokens(candidate) > overlap {
+			break
+		}
+		tail = candidate
+		end = len(strings.TrimRight(chunk[:start], " \t\r\n"))
+	}
+	return tail
+}
+
+// truncatedMarker ends text cut short by Truncate.
+const truncatedMarker = "\n\n[truncated]"
+
+// Truncate returns text cut to at most maxTokens tokens at the best
+// boundary Chunk would break at, followed by a "[truncated]" line, or text
+// as it is if it fits. A nil tokenizer selects ApproximateTokenizer.
+func Truncate(text string, maxTokens int, tokenizer Tokenizer) string {
+	if tokenizer == nil {
+		tokenizer = ApproximateTokenizer
+	}
+	if tokenizer.CountTokens(text) <= maxTokens {
+		return text
+	}
+	budget := maxTokens - tokenizer.CountTokens(truncatedMarker)
+	if budget <= 0 {
+		return ""
+	}
+	chunks := Chunk(text, budget, 0, tokenizer)
+	if len(chunks) == 0 {
+		return ""
+	}
+	return chunks[0] + truncatedMarker
+}
@@ -0,0 +1,105 @@
+package textutil
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// words counts a token per word, to make expected chunks easy to read.
+var words = TokenizerFunc(func(text string) int { return len(strings.Fields(text)) })
+
+func TestChunk(t *testing.T) {
+	text := "One two three.\n\nFour five six seven. Eight nine ten eleven twelve.\n\nThirteen."
+	tests := []struct {
+		maxTokens, overlap int
+		want               []string
+	}{
+		{0, 0, []string{text}},
+		{100, 0, []string{text}},
+		{10, 0, []string{"One two three.", "Four five six seven. Eight nine ten eleven twelve.\n\nThirteen."}},
+		{6, 0, []string{"One two three.", "Four five six seven.", "Eight nine ten eleven twelve.\n\nThirteen."}},
+		{7, 2, []string{"One two three.\n\nFour five six seven.", "six seven. Eight nine ten eleven twelve.", "eleven twelve.\n\nThirteen."}},
+		{2, 0, []string{"One two", "three.", "Four five", "six seven.", "Eight nine", "ten eleven", "twelve.\n\nThirteen."}},
+	}
+	for _, tc := range tests {
+		got := Chunk(text, tc.maxTokens, tc.overlap, words)
+		if strings.Join(got, "|") != strings.Join(tc.want, "|") {
+			t.Errorf("Chunk(%d, %d) = %q, want %q", tc.maxTokens, tc.overlap, got, tc.want)
+		}
+		for _, chunk := range got {
+			if tc.maxTokens > 0 && words(chunk) > tc.maxTokens {
+				t.Errorf("Chunk(%d, %d) returned %q of %d tokens", tc.maxTokens, tc.overlap, chunk, words(chunk))
+			}
+		}
+	}
+
+	if got := Chunk(" \n\n ", 10, 0, nil); got != nil {
+		t.Errorf("Expected no chunks of blank text, got %q", got)
+	}
+}
+
+func TestChunkLongWords(t *testing.T) {
+	blob := strings.Repeat("QUJD", 1000)
+	chunks := Chunk(blob, 100, 0, nil)
+	if len(chunks) != 10 {
+		t.Fatalf("Expected 10 chunks, got %d", len(chunks))
+	}
+	if strings.Join(chunks, "") != blob {
+		t.Error("Expected the chunks to concatenate to the text")
+	}
+
+	cjk := strings.Repeat("漢字", 150)
+	for _, chunk := range Chunk(cjk, 100, 0, nil) {
+		if n := ApproximateTokenizer.CountTokens(chunk); n > 100 {
+			t.Errorf("Expected chunks of at most 100 tokens, got %d", n)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	text := "First paragraph here.\n\nSecond paragraph, which is longer than the first."
+	if got := Truncate(text, 100, words); got != text {
+		t.Errorf("Expected text within the limit unchanged, got %q", got)
+	}
+	if got, want := Truncate(text, 6, words), "First paragraph here.\n\n[truncated]"; got != want {
+		t.Errorf("Truncate() = %q, want %q", got, want)
+	}
+}
+
+func TestFit(t *testing.T) {
+	var calls []int
+	summarize := func(ctx context.Context, text string, maxTokens int) (string, error) {
+		calls = append(calls, maxTokens)
+		return strings.Join(strings.Fields(text)[:2], " "), nil
+	}
+	text := strings.Repeat("word ", 800)
+
+	got, err := Fit(context.Background(), text, 100, words, summarize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if words(got) > 100 {
+		t.Errorf("Expected at most 100 tokens, got %d", words(got))
+	}
+	if len(calls) != 2 || calls[0] != 50 {
+		t.Errorf("Expected two parts summarized to 50 tokens each, got %v", calls)
+	}
+
+	short := "Already short."
+	if got, _ := Fit(context.Background(), short, 100, words, summarize); got != short {
+		t.Errorf("Expected short text unchanged, got %q", got)
+	}
+
+	if got, _ := Fit(context.Background(), text, 10, words, nil); !strings.HasSuffix(got, "[truncated]") {
+		t.Errorf("Expected text truncated without a summarizer, got %q", got)
+	}
+
+	failing := func(ctx context.Context, text string, maxTokens int) (string, error) {
+		return "", errors.New("no model")
+	}
+	if _, err := Fit(context.Background(), text, 10, words, failing); err == nil {
+		t.Error("Expected the summarizer's error")
+	}
+}
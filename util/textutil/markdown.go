@@ -0,0 +1,230 @@
+package textutil
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// fencePattern matches the opening line of a fenced code block, capturing
+// its indentation, fence and info string.
+var fencePattern = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})(.*)$")
+
+// inlineLinkPattern matches inline links and images, capturing the label,
+// the target and an optional title.
+var inlineLinkPattern = regexp.MustCompile(`(!?\[[^\]]*\])\(\s*(<[^>\n]*>|[^)\s]+)((?:\s+(?:"[^"]*"|'[^']*'))?\s*)\)`)
+
+// referencePattern matches link reference definitions, capturing the label,
+// the target and the rest of the line.
+var referencePattern = regexp.MustCompile(`^( {0,3}\[[^\]]+\]:[ \t]*)(<[^>\n]*>|\S+)(.*)$`)
+
+// htmlCommentPattern, htmlElementPattern and htmlTagPattern match raw HTML.
+var (
+	htmlCommentPattern = regexp.MustCompile(`<!--.*?-->`)
+	htmlElementPattern = regexp.MustCompile(`(?i)<script\b[^>]*>.*?</script\s*>|<style\b[^>]*>.*?</style\s*>`)
+	htmlTagPattern     = regexp.MustCompile(`</?[A-Za-z][A-Za-z0-9-]*(?:\s[^<>]*)?/?>`)
+)
+
+// RewriteLinks rewrites the targets of Markdown links and images, both
+// inline ([text](target "title")) and in reference definitions
+// ([id]: target), with rewrite. Code blocks and code spans are left alone.
+func RewriteLinks(markdown string, rewrite func(target string) string) string {
+	rewriteTarget := func(target string) string {
+		if strings.HasPrefix(target, "<") && strings.HasSuffix(target, ">") {
+			return "<" + rewrite(target[1:len(target)-1]) + ">"
+		}
+		return rewrite(target)
+	}
+
+	return mapLines(markdown, func(line string) string {
+		if match := referencePattern.FindStringSubmatch(line); match != nil {
+			return match[1] + rewriteTarget(match[2]) + match[3]
+		}
+		return mapProse(line, func(prose string) string {
+			return inlineLinkPattern.ReplaceAllStringFunc(prose, func(link string) string {
+				match := inlineLinkPattern.FindStringSubmatch(link)
+				return match[1] + "(" + rewriteTarget(match[2]) + match[3] + ")"
+			})
+		})
+	})
+}
+
+// ResolveLinks returns a Sanitizer that resolves relative link targets in
+// Markdown against base, such as a resource URI like "docs://handbook/".
+// Absolute URLs and fragment-only targets are left unchanged.
+//
+// Example:
+//
+//	ResolveLinks("docs://handbook/guide/")("See [setup](../setup.md)")
+//	// "See [setup](docs://handbook/setup.md)"
+func ResolveLinks(base string) Sanitizer {
+	baseURL, err := url.Parse(base)
+	if err != nil || base == "" {
+		return func(text string) string { return text }
+	}
+	return func(text string) string {
+		return RewriteLinks(text, func(target string) string {
+			if target == "" || strings.HasPrefix(target, "#") || strings.HasPrefix(target, "//") {
+				return target
+			}
+			ref, err := url.Parse(target)
+			if err != nil || ref.IsAbs() {
+				return target
+			}
+			return baseURL.ResolveReference(ref).String()
+		})
+	}
+}
+
+// StripHTML removes raw HTML from Markdown, for hosts that would show it
+// as literal text or can't render it safely. Tags and comments are removed
+// and the text between tags kept, except for script and style elements,
+// which are removed whole. Code blocks and code spans are left alone, as
+// are autolinks such as <https://example.com>.
+func StripHTML(markdown string) string {
+	return mapLines(markdown, func(line string) string {
+		return mapProse(line, func(prose string) string {
+			if !strings.Contains(prose, "<") {
+				return prose
+			}
+			prose = htmlCommentPattern.ReplaceAllString(prose, "")
+			prose = htmlElementPattern.ReplaceAllString(prose, "")
+			return htmlTagPattern.ReplaceAllString(prose, "")
+		})
+	})
+}
+
+// NormalizeCodeFences rewrites fenced code blocks in one form: backtick
+// fences, unindented, with a trimmed info string and a fence longer than
+// any backtick run starting a line of the code. A block left open at the
+// end of the text is closed.
+func NormalizeCodeFences(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	out := make([]string, 0, len(lines)+1)
+
+	for i := 0; i < len(lines); i++ {
+		match := fencePattern.FindStringSubmatch(lines[i])
+		if match == nil || (match[2][0] == '`' && strings.Contains(match[3], "`")) {
+			out = append(out, lines[i])
+			continue
+		}
+		indent, fence, info := len(match[1]), match[2], strings.TrimSpace(match[3])
+
+		var code []string
+		longest := 0
+		closed := false
+		for i++; i < len(lines); i++ {
+			if isClosingFence(lines[i], fence) {
+				closed = true
+				break
+			}
+			line := unindent(lines[i], indent)
+			if run := leadingRun(strings.TrimLeft(line, " "), '`'); run > longest {
+				longest = run
+			}
+			code = append(code, line)
+		}
+
+		length := max(3, longest+1)
+		backticks := strings.Repeat("`", length)
+		out = append(out, backticks+info)
+		out = append(out, code...)
+		out = append(out, backticks)
+		if !closed {
+			break
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// mapLines applies fn to each line of markdown outside fenced code blocks.
+func mapLines(markdown string, fn func(line string) string) string {
+	lines := strings.Split(markdown, "\n")
+	fence := ""
+	for i, line := range lines {
+		if fence != "" {
+			if isClosingFence(line, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if match := fencePattern.FindStringSubmatch(line); match != nil {
+			fence = match[2]
+			continue
+		}
+		lines[i] = fn(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mapProse applies fn to the parts of a line outside code spans.
+func mapProse(line string, fn func(prose string) string) string {
+	if !strings.Contains(line, "`") {
+		return fn(line)
+	}
+
+	var b strings.Builder
+	start := 0
+	for i := 0; i < len(line); {
+		if line[i] != '`' {
+			i++
+			continue
+		}
+		run := leadingRun(line[i:], '`')
+		end := closingRun(line, i+run, run)
+		if end < 0 {
+			i += run
+			continue
+		}
+		b.WriteString(fn(line[start:i]))
+		b.WriteString(line[i : end+run])
+		i = end + run
+		start = i
+	}
+	b.WriteString(fn(line[start:]))
+	return b.String()
+}
+
+// closingRun returns the index of the next run of exactly n backticks in
+// line from start, or -1.
+func closingRun(line string, start, n int) int {
+	for i := start; i < len(line); {
+		if line[i] != '`' {
+			i++
+			continue
+		}
+		run := leadingRun(line[i:], '`')
+		if run == n {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
+
+// isClosingFence reports whether line closes a block opened with fence.
+func isClosingFence(line, fence string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return false
+	}
+	run := leadingRun(trimmed, fence[0])
+	return run >= len(fence) && strings.TrimSpace(trimmed[run:]) == ""
+}
+
+// leadingRun counts the leading occurrences of c in s.
+func leadingRun(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+// unindent removes up to n leading spaces from line.
+func unindent(line string, n int) string {
+	for i := 0; i < n && strings.HasPrefix(line, " "); i++ {
+		line = line[1:]
+	}
+	return line
+}
@@ -0,0 +1,54 @@
+package textutil
+
+import "testing"
+
+func TestResolveLinks(t *testing.T) {
+	resolve := ResolveLinks("docs://handbook/guide/")
+	tests := map[string]string{
+		"See [setup](../setup.md).":                   "See [setup](docs://handbook/setup.md).",
+		"![diagram](img/flow.png \"Flow\")":           "![diagram](docs://handbook/guide/img/flow.png \"Flow\")",
+		"[home](https://example.com) and [top](#top)": "[home](https://example.com) and [top](#top)",
+		"[ref]: ./faq.md \"FAQ\"":                     "[ref]: docs://handbook/guide/faq.md \"FAQ\"",
+		"Run `[x](y.md)` to link [y](<y z.md>)":       "Run `[x](y.md)` to link [y](<docs://handbook/guide/y%20z.md>)",
+		"```md\n[a](a.md)\n```\n[b](b.md)":            "```md\n[a](a.md)\n```\n[b](docs://handbook/guide/b.md)",
+		"[mail](mailto:team@example.com)":             "[mail](mailto:team@example.com)",
+		"[root](/index.md)":                           "[root](docs://handbook/index.md)",
+	}
+	for input, want := range tests {
+		if got := resolve(input); got != want {
+			t.Errorf("ResolveLinks(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestStripHTML(t *testing.T) {
+	tests := map[string]string{
+		"<b>bold</b> and <br/> break":                "bold and  break",
+		"keep <https://example.com> autolinks":       "keep <https://example.com> autolinks",
+		"<script>alert(1)</script>safe<!-- note -->": "safe",
+		"code `<div>` stays":                         "code `<div>` stays",
+		"```html\n<p>kept</p>\n```":                  "```html\n<p>kept</p>\n```",
+		"a < b > c":                                  "a < b > c",
+	}
+	for input, want := range tests {
+		if got := StripHTML(input); got != want {
+			t.Errorf("StripHTML(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestNormalizeCodeFences(t *testing.T) {
+	tests := map[string]string{
+		"~~~ go \nfmt.Println()\n~~~":       "```go\nfmt.Println()\n```",
+		"  ```\n  indented\n  ```":          "```\nindented\n```",
+		"```sh\necho open":                  "```sh\necho open\n```",
+		"~~~md\n```go\nx\n```\n~~~":         "````md\n```go\nx\n```\n````",
+		"no fences here":                    "no fences here",
+		"```\na\n```\ntext\n~~~\nb\n~~~~\n": "```\na\n```\ntext\n```\nb\n```\n",
+	}
+	for input, want := range tests {
+		if got := NormalizeCodeFences(input); got != want {
+			t.Errorf("NormalizeCodeFences(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
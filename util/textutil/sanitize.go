@@ -0,0 +1,84 @@
+// Package textutil provides helpers for preparing text content before it is
+// returned to MCP clients.
+package textutil
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Sanitizer transforms text before it is delivered to a client.
+type Sanitizer func(text string) string
+
+// ansiPattern matches CSI and OSC escape sequences as well as single-character
+// escapes emitted by terminal programs.
+var ansiPattern = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[@-Z\\-_])`)
+
+// StripANSI removes ANSI escape sequences such as colors and cursor movement.
+func StripANSI(text string) string {
+	if !strings.ContainsRune(text, '\x1b') {
+		return text
+	}
+	return ansiPattern.ReplaceAllString(text, "")
+}
+
+// StripControl removes control characters other than newline, carriage
+// return and tab. Backspaces are applied by deleting the preceding rune, so
+// output written for terminals ("abc\bd") reads as it would on screen.
+func StripControl(text string) string {
+	if strings.IndexFunc(text, isStrippedControl) < 0 {
+		return text
+	}
+
+	out := make([]rune, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r == '\b':
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+		case isStrippedControl(r):
+			// drop
+		default:
+			out = append(out, r)
+		}
+	}
+	return string(out)
+}
+
+// EnforceUTF8 replaces invalid UTF-8 byte sequences with U+FFFD so the text
+// can be encoded as a JSON string without loss of framing.
+func EnforceUTF8(text string) string {
+	if utf8.ValidString(text) {
+		return text
+	}
+	return strings.ToValidUTF8(text, string(utf8.RuneError))
+}
+
+// Chain returns a Sanitizer that applies each sanitizer in order.
+func Chain(sanitizers ...Sanitizer) Sanitizer {
+	return func(text string) string {
+		for _, sanitize := range sanitizers {
+			if sanitize != nil {
+				text = sanitize(text)
+			}
+		}
+		return text
+	}
+}
+
+// SanitizeCLIOutput is a Sanitizer suited to the output of wrapped command
+// line programs: it enforces UTF-8, then strips ANSI escapes and control
+// characters.
+var SanitizeCLIOutput = Chain(EnforceUTF8, StripANSI, StripControl)
+
+// isStrippedControl reports whether r is a control character that should not
+// appear in text content.
+func isStrippedControl(r rune) bool {
+	if r == '\n' || r == '\r' || r == '\t' {
+		return false
+	}
+	return unicode.IsControl(r)
+}
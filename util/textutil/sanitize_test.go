@@ -0,0 +1,48 @@
+package textutil
+
+import "testing"
+
+func TestStripANSI(t *testing.T) {
+	tests := map[string]string{
+		"\x1b[31merror\x1b[0m: failed":    "error: failed",
+		"\x1b[2K\x1b[1Gprogress 100%":     "progress 100%",
+		"\x1b]0;title\x07prompt":          "prompt",
+		"plain text":                      "plain text",
+		"\x1b[38;5;208morange\x1b[m done": "orange done",
+	}
+	for input, want := range tests {
+		if got := StripANSI(input); got != want {
+			t.Errorf("StripANSI(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestStripControl(t *testing.T) {
+	tests := map[string]string{
+		"line1\nline2\ttab\r\n": "line1\nline2\ttab\r\n",
+		"bell\x07 null\x00":     "bell null",
+		"abc\bd":                "abd",
+		"\x7fdel":               "del",
+	}
+	for input, want := range tests {
+		if got := StripControl(input); got != want {
+			t.Errorf("StripControl(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestEnforceUTF8(t *testing.T) {
+	if got := EnforceUTF8("ok \xff\xfe bytes"); got != "ok � bytes" {
+		t.Errorf("EnforceUTF8 = %q", got)
+	}
+	if got := EnforceUTF8("héllo"); got != "héllo" {
+		t.Errorf("EnforceUTF8 changed valid text: %q", got)
+	}
+}
+
+func TestSanitizeCLIOutput(t *testing.T) {
+	input := "\x1b[32mOK\x1b[0m \xc3\x28 done\x00\n"
+	if got, want := SanitizeCLIOutput(input), "OK �( done\n"; got != want {
+		t.Errorf("SanitizeCLIOutput = %q, want %q", got, want)
+	}
+}
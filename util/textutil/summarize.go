@@ -0,0 +1,62 @@
+package textutil
+
+import (
+	"context"
+	"strings"
+)
+
+// Summarizer condenses text to about maxTokens tokens or fewer, typically by
+// asking a language model.
+type Summarizer func(ctx context.Context, text string, maxTokens int) (string, error)
+
+const (
+	// summaryInputFactor is how many times maxTokens each text handed to a
+	// Summarizer by Fit may be, so that every round shrinks the text.
+	summaryInputFactor = 4
+
+	// minSummaryTokens is the least budget Fit gives a Summarizer.
+	minSummaryTokens = 50
+
+	// maxSummaryRounds is how many times Fit summarizes summaries before it
+	// truncates instead.
+	maxSummaryRounds = 3
+)
+
+// Fit returns text as it is if it is within maxTokens tokens, and otherwise
+// a summary of it that is. Long text is summarized in parts of up to four
+// times maxTokens, each to its share of the budget, and the joined summaries
+// are summarized again while they are over it; what is still over after
+// three rounds is truncated, as is everything when summarize is nil. A nil
+// tokenizer selects ApproximateTokenizer.
+//
+// Example:
+//
+//	srv.Tool("logs", "Show the service logs", func(ctx *server.Context, args LogsArgs) (string, error) {
+//	    return textutil.Fit(ctx.Context(), readLogs(args.Service), 4000, nil, ctx.Summarizer())
+//	})
+func Fit(ctx context.Context, text string, maxTokens int, tokenizer Tokenizer, summarize Summarizer) (string, error) {
+	if tokenizer == nil {
+		tokenizer = ApproximateTokenizer
+	}
+	for round := 0; round < maxSummaryRounds && summarize != nil; round++ {
+		if tokenizer.CountTokens(text) <= maxTokens {
+			return text, nil
+		}
+
+		parts := Chunk(text, max(maxTokens, minSummaryTokens)*summaryInputFactor, 0, tokenizer)
+		share := max(maxTokens/len(parts), min(maxTokens, minSummaryTokens))
+		summaries := make([]string, 0, len(parts))
+		for _, part := range parts {
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			summary, err := summarize(ctx, part, share)
+			if err != nil {
+				return "", err
+			}
+			summaries = append(summaries, strings.TrimSpace(summary))
+		}
+		text = strings.Join(summaries, "\n\n")
+	}
+	return Truncate(text, maxTokens, tokenizer), nil
+}
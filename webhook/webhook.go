@@ -0,0 +1,439 @@
+// Package webhook delivers server events to an HTTP endpoint.
+//
+// A Dispatcher queues events, posts them in batches and retries failed
+// deliveries with exponential backoff. Every request is signed with an
+// HMAC-SHA256 of its timestamp and body, so receivers can check that it came
+// from the server and was not replayed; see Verify.
+//
+// # Basic Usage
+//
+//	hooks := webhook.NewDispatcher("https://ops.example.com/mcp-events", []byte(secret),
+//	    webhook.WithEventTypes(webhook.EventSessionStarted, webhook.EventQuotaExceeded),
+//	)
+//	defer hooks.Close(context.Background())
+//
+//	srv := server.NewServer("my-service", server.WithWebhooks(hooks))
+//
+// Each request body is a JSON object with an "events" array:
+//
+//	{"events": [{"id": "…", "type": "session.started", "time": "…", "data": {…}}]}
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event types emitted by the server.
+const (
+	// EventServerStarted is sent when the server's transport has started.
+	EventServerStarted = "server.started"
+
+	// EventSessionStarted is sent when a client completes initialize.
+	EventSessionStarted = "session.started"
+
+	// EventSessionEnded is sent when a session shuts down, times out during
+	// the handshake, stops answering keepalive pings, is ended by its client
+	// or is replaced by a new initialize.
+	EventSessionEnded = "session.ended"
+
+	// EventQuotaWarning is sent when a key reaches a quota's soft limit.
+	EventQuotaWarning = "quota.warning"
+
+	// EventQuotaExceeded is sent when a call is rejected by a quota's hard limit.
+	EventQuotaExceeded = "quota.exceeded"
+
+	// EventToolErrorSpike is sent when tool calls fail more often than the
+	// configured threshold.
+	EventToolErrorSpike = "tool.error_spike"
+
+	// EventAuditToolCall records every tool call, for audit trails.
+	EventAuditToolCall = "audit.tool_call"
+
+	// EventMemoryPressure is sent when the server's memory pressure level
+	// changes.
+	EventMemoryPressure = "memory.pressure"
+
+	// EventToolDivergence is sent when a tool version run in shadow mode
+	// returns a different result than the current version.
+	EventToolDivergence = "tool.divergence"
+)
+
+// Headers set on every delivery.
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the
+	// timestamp, a period and the body.
+	SignatureHeader = "X-Webhook-Signature"
+
+	// TimestampHeader carries the Unix time the request was signed at.
+	TimestampHeader = "X-Webhook-Timestamp"
+)
+
+// ErrClosed is returned by Close when the dispatcher was already closed.
+var ErrClosed = errors.New("webhook dispatcher closed")
+
+// Event is a single occurrence delivered to the endpoint.
+type Event struct {
+	ID   string                 `json:"id"`
+	Type string                 `json:"type"`
+	Time time.Time              `json:"time"`
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// Option configures a Dispatcher.
+type Option func(*Dispatcher)
+
+// WithHTTPClient sets the client used for deliveries.
+func WithHTTPClient(client *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+// WithBatching sets the largest number of events sent in one request, and
+// how long an event may wait for a batch to fill. The defaults are 50 events
+// and one second.
+func WithBatching(size int, interval time.Duration) Option {
+	return func(d *Dispatcher) {
+		if size > 0 {
+			d.batchSize = size
+		}
+		if interval > 0 {
+			d.flushInterval = interval
+		}
+	}
+}
+
+// WithRetries sets how many times a failed delivery is retried, and the
+// delay before the first retry, which doubles on each attempt. The defaults
+// are 5 retries starting at 500ms.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.maxRetries = retries
+		if backoff > 0 {
+			d.retryBackoff = backoff
+		}
+	}
+}
+
+// WithEventTypes limits delivery to the given event types. All events are
+// delivered by default.
+func WithEventTypes(types ...string) Option {
+	return func(d *Dispatcher) {
+		d.types = make(map[string]bool, len(types))
+		for _, t := range types {
+			d.types[t] = true
+		}
+	}
+}
+
+// WithQueueSize sets how many events may wait for delivery. Events emitted
+// while the queue is full are dropped and counted by Dropped. The default
+// is 1024.
+func WithQueueSize(size int) Option {
+	return func(d *Dispatcher) {
+		if size > 0 {
+			d.queueSize = size
+		}
+	}
+}
+
+// WithLogger sets the logger delivery failures are reported to.
+func WithLogger(logger *slog.Logger) Option {
+	return func(d *Dispatcher) {
+		d.logger = logger
+	}
+}
+
+// maxBackoff caps the delay between retries.
+const maxBackoff = 30 * time.Second
+
+// Dispatcher queues events and delivers them to a webhook endpoint.
+type Dispatcher struct {
+	url           string
+	secret        []byte
+	client        *http.Client
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+	retryBackoff  time.Duration
+	queueSize     int
+	types         map[string]bool
+	logger        *slog.Logger
+
+	queue   chan Event
+	dropped atomic.Int64
+
+	mu     sync.RWMutex
+	closed bool
+
+	// ctx is cancelled when Close gives up waiting, to abandon deliveries
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+	finished chan struct{}
+}
+
+// NewDispatcher creates a dispatcher that posts events to url, signed with
+// secret, and starts delivering in the background. Call Close to deliver the
+// remaining events and stop.
+func NewDispatcher(url string, secret []byte, options ...Option) *Dispatcher {
+	d := &Dispatcher{
+		url:           url,
+		secret:        secret,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		batchSize:     50,
+		flushInterval: time.Second,
+		maxRetries:    5,
+		retryBackoff:  500 * time.Millisecond,
+		queueSize:     1024,
+		logger:        slog.Default(),
+		done:          make(chan struct{}),
+		finished:      make(chan struct{}),
+	}
+	for _, option := range options {
+		option(d)
+	}
+	d.queue = make(chan Event, d.queueSize)
+	d.ctx, d.cancel = context.WithCancel(context.Background())
+
+	go d.run()
+	return d
+}
+
+// Emit queues an event of the given type. It never blocks: events are
+// dropped if the queue is full or the dispatcher is closed, and events of
+// types excluded by WithEventTypes are ignored.
+func (d *Dispatcher) Emit(eventType string, data map[string]interface{}) {
+	if d.types != nil && !d.types[eventType] {
+		return
+	}
+	event := Event{ID: newID(), Type: eventType, Time: time.Now().UTC(), Data: data}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		d.dropped.Add(1)
+		return
+	}
+	select {
+	case d.queue <- event:
+	default:
+		d.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of events discarded because the queue was full,
+// the dispatcher was closed or every delivery attempt failed.
+func (d *Dispatcher) Dropped() int64 {
+	return d.dropped.Load()
+}
+
+// Close stops accepting events and delivers the queued ones. If ctx ends
+// first, pending deliveries are abandoned and ctx's error is returned.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return ErrClosed
+	}
+	d.closed = true
+	close(d.done)
+	d.mu.Unlock()
+
+	select {
+	case <-d.finished:
+		d.cancel()
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-d.finished
+		return ctx.Err()
+	}
+}
+
+// run collects events into batches and delivers them.
+func (d *Dispatcher) run() {
+	defer close(d.finished)
+
+	ticker := time.NewTicker(d.flushInterval)
+	defer ticker.Stop()
+
+	var batch []Event
+	flush := func() {
+		if len(batch) > 0 {
+			d.deliver(batch)
+			batch = nil
+		}
+	}
+
+	for {
+		select {
+		case event := <-d.queue:
+			batch = append(batch, event)
+			if len(batch) >= d.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-d.done:
+			// No more events can be queued; deliver what is left
+			for {
+				select {
+				case event := <-d.queue:
+					batch = append(batch, event)
+					if len(batch) >= d.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver posts a batch, retrying on network errors, 5xx and 429 responses.
+func (d *Dispatcher) deliver(batch []Event) {
+	body, err := json.Marshal(map[string]interface{}{"events": batch})
+	if err != nil {
+		d.logger.Error("failed to marshal webhook events", "error", err)
+		d.dropped.Add(int64(len(batch)))
+		return
+	}
+
+	backoff := d.retryBackoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := d.post(body)
+		if err == nil {
+			return
+		}
+		if retryAfter < 0 || attempt >= d.maxRetries {
+			d.logger.Error("webhook delivery failed", "url", d.url, "events", len(batch), "attempts", attempt+1, "error", err)
+			d.dropped.Add(int64(len(batch)))
+			return
+		}
+
+		wait := backoff
+		if retryAfter > 0 {
+			wait = retryAfter
+		}
+		if wait > maxBackoff {
+			wait = maxBackoff
+		}
+		backoff *= 2
+
+		select {
+		case <-time.After(wait):
+		case <-d.ctx.Done():
+			d.dropped.Add(int64(len(batch)))
+			return
+		}
+	}
+}
+
+// post sends one signed request. It returns a negative retryAfter for
+// failures that must not be retried, and a positive one when the endpoint
+// asked for a specific delay.
+func (d *Dispatcher) post(body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(d.secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return 0, nil
+	case resp.StatusCode == http.StatusTooManyRequests:
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return time.Duration(seconds) * time.Second, fmt.Errorf("endpoint returned %s", resp.Status)
+	case resp.StatusCode >= 500:
+		return 0, fmt.Errorf("endpoint returned %s", resp.Status)
+	default:
+		return -1, fmt.Errorf("endpoint returned %s", resp.Status)
+	}
+}
+
+// Sign returns the SignatureHeader value for a request body signed at
+// timestamp.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a delivery and that it was signed within
+// tolerance of now, and returns its events. The request body is consumed.
+//
+// Example:
+//
+//	http.HandleFunc("/mcp-events", func(w http.ResponseWriter, r *http.Request) {
+//	    events, err := webhook.Verify(r, secret, 5*time.Minute)
+//	    if err != nil {
+//	        http.Error(w, err.Error(), http.StatusUnauthorized)
+//	        return
+//	    }
+//	    for _, event := range events {
+//	        log.Println(event.Type, event.Data)
+//	    }
+//	})
+func Verify(r *http.Request, secret []byte, tolerance time.Duration) ([]Event, error) {
+	timestamp := r.Header.Get(TimestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, errors.New("missing or invalid webhook timestamp")
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return nil, errors.New("webhook timestamp outside tolerance")
+	}
+
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(r.Body); err != nil {
+		return nil, fmt.Errorf("failed to read webhook body: %w", err)
+	}
+	expected := Sign(secret, timestamp, body.Bytes())
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get(SignatureHeader))) {
+		return nil, errors.New("invalid webhook signature")
+	}
+
+	var payload struct {
+		Events []Event `json:"events"`
+	}
+	if err := json.Unmarshal(body.Bytes(), &payload); err != nil {
+		return nil, fmt.Errorf("invalid webhook body: %w", err)
+	}
+	return payload.Events, nil
+}
+
+// newID returns a random event identifier.
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("s3cret")
+
+// receiver records verified deliveries.
+type receiver struct {
+	mu       sync.Mutex
+	batches  [][]Event
+	failures int32
+	failNext atomic.Int32
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.failNext.Load() > 0 {
+		r.failNext.Add(-1)
+		atomic.AddInt32(&r.failures, 1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	events, err := Verify(req, testSecret, time.Minute)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	r.mu.Lock()
+	r.batches = append(r.batches, events)
+	r.mu.Unlock()
+}
+
+func (r *receiver) events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var all []Event
+	for _, batch := range r.batches {
+		all = append(all, batch...)
+	}
+	return all
+}
+
+func TestBatchingAndSigning(t *testing.T) {
+	recv := &receiver{}
+	endpoint := httptest.NewServer(recv)
+	defer endpoint.Close()
+
+	d := NewDispatcher(endpoint.URL, testSecret, WithBatching(3, time.Hour))
+	for i := 0; i < 7; i++ {
+		d.Emit(EventAuditToolCall, map[string]interface{}{"n": i})
+	}
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	events := recv.events()
+	if len(events) != 7 {
+		t.Fatalf("Expected 7 events, got %d", len(events))
+	}
+	if len(recv.batches) != 3 || len(recv.batches[0]) != 3 {
+		t.Errorf("Expected batches of at most 3 events, got %d batches", len(recv.batches))
+	}
+	if events[0].ID == "" || events[0].Type != EventAuditToolCall || events[0].Time.IsZero() {
+		t.Errorf("Unexpected event %+v", events[0])
+	}
+	if d.Dropped() != 0 {
+		t.Errorf("Expected no dropped events, got %d", d.Dropped())
+	}
+}
+
+func TestRetriesFailedDeliveries(t *testing.T) {
+	recv := &receiver{}
+	recv.failNext.Store(2)
+	endpoint := httptest.NewServer(recv)
+	defer endpoint.Close()
+
+	d := NewDispatcher(endpoint.URL, testSecret, WithRetries(3, time.Millisecond))
+	d.Emit(EventSessionStarted, nil)
+	d.Close(context.Background())
+
+	if len(recv.events()) != 1 || atomic.LoadInt32(&recv.failures) != 2 {
+		t.Errorf("Expected one delivery after two failures, got %d events and %d failures",
+			len(recv.events()), recv.failures)
+	}
+}
+
+func TestGivesUpAfterRetries(t *testing.T) {
+	recv := &receiver{}
+	recv.failNext.Store(10)
+	endpoint := httptest.NewServer(recv)
+	defer endpoint.Close()
+
+	d := NewDispatcher(endpoint.URL, testSecret, WithRetries(1, time.Millisecond))
+	d.Emit(EventSessionStarted, nil)
+	d.Close(context.Background())
+
+	if atomic.LoadInt32(&recv.failures) != 2 || d.Dropped() != 1 {
+		t.Errorf("Expected two attempts and a dropped event, got %d attempts and %d dropped",
+			recv.failures, d.Dropped())
+	}
+}
+
+func TestEventTypeFilter(t *testing.T) {
+	recv := &receiver{}
+	endpoint := httptest.NewServer(recv)
+	defer endpoint.Close()
+
+	d := NewDispatcher(endpoint.URL, testSecret, WithEventTypes(EventQuotaExceeded))
+	d.Emit(EventAuditToolCall, nil)
+	d.Emit(EventQuotaExceeded, map[string]interface{}{"quota": "daily"})
+	d.Close(context.Background())
+
+	events := recv.events()
+	if len(events) != 1 || events[0].Type != EventQuotaExceeded {
+		t.Errorf("Expected only the quota event, got %+v", events)
+	}
+}
+
+func TestEmitAfterClose(t *testing.T) {
+	d := NewDispatcher("http://127.0.0.1:0", testSecret)
+	d.Close(context.Background())
+	d.Emit(EventSessionEnded, nil)
+
+	if d.Dropped() != 1 {
+		t.Errorf("Expected the event to be dropped, got %d", d.Dropped())
+	}
+	if err := d.Close(context.Background()); err != ErrClosed {
+		t.Errorf("Expected ErrClosed on second Close, got %v", err)
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	body := []byte(`{"events":[]}`)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	request := func(body []byte, timestamp, signature string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/", io.NopCloser(bytes.NewReader(body)))
+		r.Header.Set(TimestampHeader, timestamp)
+		r.Header.Set(SignatureHeader, signature)
+		return r
+	}
+
+	if _, err := Verify(request(body, timestamp, Sign(testSecret, timestamp, body)), testSecret, time.Minute); err != nil {
+		t.Errorf("Expected a valid signature, got %v", err)
+	}
+	if _, err := Verify(request([]byte(`{"events":[{}]}`), timestamp, Sign(testSecret, timestamp, body)), testSecret, time.Minute); err == nil {
+		t.Error("Expected a modified body to be rejected")
+	}
+	if _, err := Verify(request(body, timestamp, Sign([]byte("other"), timestamp, body)), testSecret, time.Minute); err == nil {
+		t.Error("Expected a signature with another secret to be rejected")
+	}
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	if _, err := Verify(request(body, old, Sign(testSecret, old, body)), testSecret, time.Minute); err == nil {
+		t.Error("Expected an old timestamp to be rejected")
+	}
+}